@@ -0,0 +1,28 @@
+package ai
+
+import "memory-game-server/config"
+
+const (
+	// defaultAdaptiveMaxShift is the largest adjustment, in percentage points, of an adaptive profile.
+	defaultAdaptiveMaxShift = 20
+	// adaptiveFullLead is the lead (points) at which an adaptive profile eases up the most.
+	adaptiveFullLead = 6
+)
+
+// adaptiveParams returns the parameters an adaptive profile plays with at the given lead (its score minus
+// the opponent's): the more it leads, the more it forgets and the less often it plays the best move.
+// Trailing or even, and for non-adaptive profiles, params is returned unchanged.
+func adaptiveParams(params *config.AIParams, lead int) *config.AIParams {
+	if !params.Adaptive || lead <= 0 {
+		return params
+	}
+	maxShift := params.AdaptiveMaxShift
+	if maxShift <= 0 {
+		maxShift = defaultAdaptiveMaxShift
+	}
+	shift := maxShift * min(lead, adaptiveFullLead) / adaptiveFullLead
+	p := *params
+	p.ForgetChance = clampPercent(p.ForgetChance + shift)
+	p.UseBestMoveChance = clampPercent(p.UseBestMoveChance - shift)
+	return &p
+}
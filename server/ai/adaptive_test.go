@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"testing"
+
+	"memory-game-server/config"
+)
+
+func TestAdaptiveParams(t *testing.T) {
+	base := &config.AIParams{Name: "Thalia", ForgetChance: 10, UseBestMoveChance: 90, Adaptive: true}
+	if p := adaptiveParams(base, -3); p != base {
+		t.Error("expected base params while trailing")
+	}
+	half := adaptiveParams(base, adaptiveFullLead/2)
+	if half.ForgetChance != 20 || half.UseBestMoveChance != 80 {
+		t.Errorf("expected half the shift at half the full lead, got forget %d best %d", half.ForgetChance, half.UseBestMoveChance)
+	}
+	full := adaptiveParams(base, 2*adaptiveFullLead)
+	if full.ForgetChance != 30 || full.UseBestMoveChance != 70 {
+		t.Errorf("expected the full shift past the full lead, got forget %d best %d", full.ForgetChance, full.UseBestMoveChance)
+	}
+	if base.ForgetChance != 10 || base.UseBestMoveChance != 90 {
+		t.Error("expected the profile itself to stay unchanged")
+	}
+	fixed := &config.AIParams{ForgetChance: 10, UseBestMoveChance: 90}
+	if p := adaptiveParams(fixed, 10); p != fixed {
+		t.Error("expected non-adaptive profiles to ignore the lead")
+	}
+}
@@ -30,13 +30,15 @@ func indexInSlice(idx int, s []int) bool {
 	return false
 }
 
-// waitUntilClairvoyanceEnd sleeps until the Clairvoyance reveal has ended so the chosen card is hidden again before we send the flip.
-func waitUntilClairvoyanceEnd(endsAtUnixMs int64) {
-	remaining := endsAtUnixMs - time.Now().UnixMilli()
-	if remaining <= 0 {
-		return
-	}
-	time.Sleep(time.Duration(remaining) * time.Millisecond)
+// untilClairvoyanceEnd is how long the Clairvoyance reveal still lasts; the chosen card must be hidden again before we send the flip.
+func untilClairvoyanceEnd(endsAtUnixMs int64) time.Duration {
+	return time.Duration(endsAtUnixMs-time.Now().UnixMilli()) * time.Millisecond
+}
+
+// flipChoice is a card chosen to flip and why (flipReason*).
+type flipChoice struct {
+	idx    int
+	reason string
 }
 
 // applyForgetByRecency removes entries from memoryData with recency-based chance:
@@ -80,19 +82,62 @@ type tileMemory struct {
 
 // Run receives game state messages from the given channel and sends actions to the game
 // when it is the AI's turn. It only uses information from the game_state payload (no
-// access to board internals). It runs until the channel is closed or a game_over is received.
+// access to board internals). It runs until the channel is closed, a game_over is received or the
+// game loop ends (e.g. its own seat was abandoned on shutdown, which sends game_over only to the opponent).
+// Choosing a move runs on SharedPool within the profile's search budget. The pause before sending it is a
+// timer: the loop goes back to reading messages meanwhile, and a newer game_state or the end of the game
+// cancels the move.
 // humanReady is closed when the human client sends board_ready (intro dismissed); the AI
 // blocks until then so the first move happens only after the player can see the board.
 func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIParams, humanReady <-chan struct{}) {
-	memoryData := make(map[int]tileMemory)   // index -> pairID + lastSeenRound (for recency-based forget)
+	RunWithKnowledge(aiSend, g, playerIdx, params, humanReady, nil)
+}
+
+// RunWithKnowledge is Run with tiles the AI knows before the first flip (index -> pairID), e.g. the
+// co-op raid handicap. The tiles are treated as seen in round 0 and fade through ForgetChance like any other.
+func RunWithKnowledge(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIParams, humanReady <-chan struct{}, known map[int]int) {
+	seedMemory := func() map[int]tileMemory {
+		m := make(map[int]tileMemory, len(known))
+		for idx, pairID := range known {
+			m[idx] = tileMemory{PairID: pairID, LastSeenRound: 0}
+		}
+		return m
+	}
+	memoryData := seedMemory()               // index -> pairID + lastSeenRound (for recency-based forget)
 	elementMemory := make(map[int]string)    // index -> element (tiles we know the element of, e.g. from elemental highlight)
 	var lastElementalUsed string             // element of the elemental we just used; next state's HighlightIndices will be that element
 	var clearElementMemoryNext bool          // true after we use Chaos (board shuffles, so element-by-index is stale)
 	var useBestMoveForSecondFlip bool        // when in second_flip, use same decision as first_flip so we complete known pairs
+	turnStartRound := -1                     // round of the last turn we started (resign is considered once per turn)
+	pool, budget := SharedPool(), searchBudget(params)
+	var next *time.Timer // the move waiting out its pause, if any
+	later := func(d time.Duration, send func()) {
+		next = time.AfterFunc(d, send)
+	}
+	defer func() {
+		if next != nil {
+			next.Stop()
+		}
+	}()
 
-	<-humanReady // wait until human has seen the board (client sent board_ready)
+	// Wait until human has seen the board (client sent board_ready).
+	select {
+	case <-humanReady:
+	case <-g.Done:
+		return
+	}
 
-	for data := range aiSend {
+	for {
+		var data []byte
+		select {
+		case d, ok := <-aiSend:
+			if !ok {
+				return
+			}
+			data = d
+		case <-g.Done:
+			return
+		}
 		var typeEnvelope struct {
 			Type string `json:"type"`
 		}
@@ -103,11 +148,21 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 		switch typeEnvelope.Type {
 		case "game_over":
 			return
+		case "draft_offer":
+			var offer game.DraftOfferMsg
+			if err := json.Unmarshal(data, &offer); err == nil && len(offer.Options) > 0 {
+				sendDraftPick(g, playerIdx, offer.Options[0].PowerUpID)
+			}
 		case "game_state":
 			var state game.GameStateMsg
 			if err := json.Unmarshal(data, &state); err != nil {
 				continue
 			}
+			// A move still waiting out its pause was chosen from an older view; choose again from this one.
+			if next != nil {
+				next.Stop()
+				next = nil
+			}
 
 			// When Chaos was used the board was shuffled; KnownIndices is cleared by the game. Our index->pairID memory
 			// would be stale (positions now hold different cards), so clear it in clearElementMemoryNext below.
@@ -122,8 +177,9 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 				}
 			}
 			if allHidden && len(state.KnownIndices) == 0 {
-				memoryData = make(map[int]tileMemory)
+				memoryData = seedMemory()
 			}
+			known = nil // the seed only holds for the opening board; a later reset (e.g. after Chaos) starts empty
 			// Update memory from current view: any revealed or matched card exposes its pairID and the round we saw it.
 			// Never overwrite an index with a different pairID than we've already seen (avoids stale/wrong
 			// state from overwriting correct memory, e.g. index 0 already known as pairID 4).
@@ -143,8 +199,11 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 				}
 			}
 
+			// Adaptive profiles ease up with the lead (see adaptiveParams); others play with params as is.
+			turnParams := adaptiveParams(params, state.You.Score-state.Opponent.Score)
+
 			// Forget: Option A — tiles seen this round (age 0) are never forgotten; older tiles have ForgetChance to be removed.
-			forgetChance := clampPercent(params.ForgetChance)
+			forgetChance := clampPercent(turnParams.ForgetChance)
 			if forgetChance > 0 && len(memoryData) > 0 {
 				forgotten := applyForgetByRecency(memoryData, currentRound, forgetChance, func() int { return rand.Intn(100) })
 				for _, tile := range forgotten {
@@ -168,11 +227,22 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 				continue
 			}
 
-			// Do not act during resolve phase (wait for next state after mismatch timer or turn switch)
-			if state.Phase == "resolve" {
+			// Do not act during resolve phase (wait for next state after mismatch timer or turn switch) or
+			// before the starting draft is over
+			if state.Phase == "resolve" || state.Phase == "draft" {
 				continue
 			}
 
+			// At the start of each of our turns, resign if even the best case cannot catch up.
+			if state.Phase == "first_flip" && state.Round != turnStartRound {
+				turnStartRound = state.Round
+				if !params.NeverResign && hopeless(&state) {
+					slog.Debug("resigning hopeless position", "tag", "ai", "name", params.Name, "score", state.You.Score, "opponent_score", state.Opponent.Score)
+					sendResign(g, playerIdx)
+					continue
+				}
+			}
+
 			// After Chaos the board is shuffled; index->pairID and element memory are no longer valid.
 			if clearElementMemoryNext {
 				memoryData = make(map[int]tileMemory)
@@ -220,17 +290,7 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 				}
 			}
 
-			// Human-like delay before acting
-			delayMS := params.DelayMinMS
-			if params.DelayMaxMS > params.DelayMinMS {
-				delayMS = params.DelayMinMS + rand.Intn(params.DelayMaxMS-params.DelayMinMS)
-			}
-			time.Sleep(time.Duration(delayMS) * time.Millisecond)
-
-			// Re-read state after delay in case game ended (e.g. opponent disconnected)
-			// We don't have a way to re-read; just send. Game will ignore if not our turn.
-
-			useBestMove := rand.Intn(100) < clampPercent(params.UseBestMoveChance)
+			useBestMove := rand.Intn(100) < clampPercent(turnParams.UseBestMoveChance)
 
 			hiddenByElement := hiddenIndicesByElement(elementMemory, hidden)
 			knownIndicesSet := make(map[int]struct{}, len(state.KnownIndices))
@@ -245,14 +305,40 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 				if clairvoyanceRevealed == nil {
 					clairvoyanceRevealed = []int{}
 				}
-				secondIdx, flipReason := pickSecondCard(memory, hidden, firstIdx, useBestMoveForSecondFlip, hiddenHighlighted, elementMemory, hiddenByElement, knownIndicesSet, clairvoyanceRevealed)
-				if secondIdx >= 0 {
+				second, ok := decide(pool, g.Done, budget, func(time.Duration) flipChoice {
+					idx, reason := pickSecondCard(memory, hidden, firstIdx, useBestMoveForSecondFlip, hiddenHighlighted, elementMemory, hiddenByElement, knownIndicesSet, clairvoyanceRevealed)
+					return flipChoice{idx, reason}
+				})
+				if !ok {
+					return
+				}
+				if second.idx >= 0 {
+					// Human-like pause between flips; longer when the second card is a guess.
+					delay := pause(secondFlipDelayMS(params), flipMargin(second.reason, pairsRemaining(state.Cards)), params)
 					// If the chosen card is one of the 9 temporarily revealed by Clairvoyance, wait until they hide before sending.
-					if state.ClairvoyanceRevealEndsAtUnixMs > 0 && indexInSlice(secondIdx, clairvoyanceRevealed) {
-						waitUntilClairvoyanceEnd(state.ClairvoyanceRevealEndsAtUnixMs)
+					if state.ClairvoyanceRevealEndsAtUnixMs > 0 && indexInSlice(second.idx, clairvoyanceRevealed) {
+						delay = max(delay, untilClairvoyanceEnd(state.ClairvoyanceRevealEndsAtUnixMs))
 					}
-					slog.Debug("flipping tile (second)", "tag", "ai", "name", params.Name, "tile", secondIdx, "reason", flipReason)
-					sendAction(g, playerIdx, secondIdx)
+					slog.Debug("flipping tile (second)", "tag", "ai", "name", params.Name, "tile", second.idx, "reason", second.reason)
+					later(delay, func() { sendAction(g, playerIdx, second.idx) })
+				}
+				continue
+			}
+
+			if state.Phase == "third_flip" && len(state.FlippedIndices) > 1 {
+				// Third Eye: the first two did not match; a third card may pair with either of them
+				third, ok := decide(pool, g.Done, budget, func(time.Duration) flipChoice {
+					idx, reason := pickThirdCard(memory, hidden, state.FlippedIndices, useBestMoveForSecondFlip, knownIndicesSet)
+					return flipChoice{idx, reason}
+				})
+				if !ok {
+					return
+				}
+				if third.idx >= 0 {
+					slog.Debug("flipping tile (third)", "tag", "ai", "name", params.Name, "tile", third.idx, "reason", third.reason)
+					later(pause(secondFlipDelayMS(params), flipMargin(third.reason, pairsRemaining(state.Cards)), params), func() {
+						sendAction(g, playerIdx, third.idx)
+					})
 				}
 				continue
 			}
@@ -267,10 +353,15 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 			}
 			if hasUsableArcana {
 				rows, cols := 0, 0
-				if g.Config != nil {
-					rows, cols = g.Config.BoardRows, g.Config.BoardCols
+				if g.Board != nil {
+					rows, cols = g.Board.Rows, g.Board.Cols
+				}
+				dec, ok := decide(pool, g.Done, budget, func(budget time.Duration) arcanaDecision {
+					return pickArcanaToUse(&state, memory, hidden, rows, cols, params, budget)
+				})
+				if !ok {
+					return
 				}
-				dec := pickArcanaToUse(&state, memory, hidden, rows, cols, params)
 				handStr := formatHand(state.Hand)
 				if dec.use && dec.powerUpID != "" {
 					slog.Debug("decided to use arcana", "tag", "ai", "name", params.Name, "arcana", dec.powerUpID, "reason", dec.reason, "hand", handStr)
@@ -280,7 +371,9 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 					if dec.powerUpID == PowerUpChaos {
 						clearElementMemoryNext = true
 					}
-					sendUsePowerUp(g, playerIdx, dec.powerUpID, dec.CardIndex)
+					later(pause(firstFlipDelayMS(params), dec.margin, params), func() {
+						sendUsePowerUp(g, playerIdx, dec.powerUpID, dec.CardIndex)
+					})
 					continue
 				}
 				slog.Debug("decided not to use arcana", "tag", "ai", "name", params.Name, "reason", dec.reason, "hand", handStr)
@@ -291,32 +384,58 @@ func Run(aiSend <-chan []byte, g *game.Game, playerIdx int, params *config.AIPar
 			if clairvoyanceRevealed == nil {
 				clairvoyanceRevealed = []int{}
 			}
-			firstIdx, _, flipReason := pickPair(memory, hidden, useBestMove, hiddenHighlighted, hiddenByElement, knownIndicesSet, clairvoyanceRevealed)
-			if firstIdx < 0 {
+			// Leave known pairs for next turn when an arcana on cooldown makes them worth more then.
+			first, ok := decide(pool, g.Done, budget, func(time.Duration) flipChoice {
+				pickMemory, pickHidden, pickHighlighted, pickByElement := memory, hidden, hiddenHighlighted, hiddenByElement
+				if useBestMove {
+					if save, margin := planSaveKnownPairs(state.Hand, memory, hidden); save {
+						if m, h := withoutKnownPairs(memory, hidden); len(h) > 0 {
+							pickMemory, pickHidden = m, h
+							pickHighlighted = nil
+							for _, idx := range hiddenHighlighted {
+								if indexInSlice(idx, h) {
+									pickHighlighted = append(pickHighlighted, idx)
+								}
+							}
+							pickByElement = hiddenIndicesByElement(elementMemory, h)
+							slog.Debug("saving known pairs for next turn", "tag", "ai", "name", params.Name, "hand", formatHand(state.Hand), "margin", margin)
+						}
+					}
+				}
+				idx, _, reason := pickPair(pickMemory, pickHidden, useBestMove, pickHighlighted, pickByElement, knownIndicesSet, clairvoyanceRevealed)
+				return flipChoice{idx, reason}
+			})
+			if !ok {
+				return
+			}
+			if first.idx < 0 {
 				continue
 			}
+			// Human-like pause before the first flip; longer when no known pair makes the choice a guess.
+			// The game ignores the flip if the turn ended meanwhile (e.g. opponent disconnected).
+			delay := pause(firstFlipDelayMS(params), flipMargin(first.reason, pairsRemaining(state.Cards)), params)
 			// If the chosen card is one of the 9 temporarily revealed by Clairvoyance, wait until they hide before sending.
-			if state.ClairvoyanceRevealEndsAtUnixMs > 0 && indexInSlice(firstIdx, clairvoyanceRevealed) {
-				waitUntilClairvoyanceEnd(state.ClairvoyanceRevealEndsAtUnixMs)
+			if state.ClairvoyanceRevealEndsAtUnixMs > 0 && indexInSlice(first.idx, clairvoyanceRevealed) {
+				delay = max(delay, untilClairvoyanceEnd(state.ClairvoyanceRevealEndsAtUnixMs))
 			}
-			// Persist useBestMove for second flip so we complete known pairs (set only when we actually send the first flip)
+			// Persist useBestMove for second flip so we complete known pairs (a cancelled first flip is chosen again anyway)
 			useBestMoveForSecondFlip = useBestMove
-			slog.Debug("flipping tile (first)", "tag", "ai", "name", params.Name, "tile", firstIdx, "reason", flipReason)
-			sendAction(g, playerIdx, firstIdx)
+			slog.Debug("flipping tile (first)", "tag", "ai", "name", params.Name, "tile", first.idx, "reason", first.reason)
+			later(delay, func() { sendAction(g, playerIdx, first.idx) })
 		}
 	}
 }
 
 func sendAction(g *game.Game, playerIdx int, cardIndex int) {
 	select {
-	case g.Actions <- game.Action{Type: game.ActionFlipCard, PlayerIdx: playerIdx, Index: cardIndex}:
+	case g.Actions <- game.Action{Type: game.ActionFlipCard, PlayerIdx: playerIdx, Index: cardIndex, ReceivedAt: time.Now()}:
 	case <-g.Done:
 	}
 }
 
 func sendUsePowerUp(g *game.Game, playerIdx int, powerUpID string, cardIndex int) {
 	select {
-	case g.Actions <- game.Action{Type: game.ActionUsePowerUp, PlayerIdx: playerIdx, PowerUpID: powerUpID, CardIndex: cardIndex}:
+	case g.Actions <- game.Action{Type: game.ActionUsePowerUp, PlayerIdx: playerIdx, PowerUpID: powerUpID, CardIndex: cardIndex, ReceivedAt: time.Now()}:
 	case <-g.Done:
 	}
 }
@@ -9,31 +9,21 @@ import (
 	"memory-game-server/ai/heuristic"
 	"memory-game-server/config"
 	"memory-game-server/game"
+	"memory-game-server/powerup"
 )
 
-type mockPowerUpProvider struct{}
-
-func (m *mockPowerUpProvider) GetPowerUp(id string) (game.PowerUpDef, bool) {
-	return game.PowerUpDef{}, false
-}
-
-func (m *mockPowerUpProvider) AllPowerUps() []game.PowerUpDef {
-	return nil
-}
-
-func (m *mockPowerUpProvider) PickArcanaForMatch(n int) []game.PowerUpDef {
-	return nil
-}
-
 func TestRunExitsOnGameOver(t *testing.T) {
-	cfg := &config.Config{AIPairTimeoutSec: 60}
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, AIPairTimeoutSec: 60}
 	params := &config.AIParams{Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 20, UseBestMoveChance: 85, ArcanaRandomness: 0}
 
 	aiSend := make(chan []byte, 4)
 	board := game.NewBoard(2, 2, 0)
 	p0 := game.NewPlayer("Human", make(chan []byte, 4))
 	p1 := game.NewPlayer("Mnemosyne", aiSend)
-	g := game.NewGame("test", cfg, p0, p1, &mockPowerUpProvider{})
+	g, err := game.NewGame("test", cfg, p0, p1, powerup.NewBuiltinRegistry(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
 	g.Board = board
 
 	go g.Run()
@@ -68,7 +58,10 @@ func TestRunExitsOnClosedChannel(t *testing.T) {
 	board := game.NewBoard(2, 2, 0)
 	p0 := game.NewPlayer("Human", make(chan []byte, 4))
 	p1 := game.NewPlayer(params.Name, aiSend)
-	g := game.NewGame("test", cfg, p0, p1, &mockPowerUpProvider{})
+	g, err := game.NewGame("test", cfg, p0, p1, powerup.NewBuiltinRegistry(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
 	g.Board = board
 
 	go g.Run()
@@ -98,6 +91,44 @@ func TestRunExitsOnClosedChannel(t *testing.T) {
 	}
 }
 
+func TestRun_ReadsMessagesDuringPause(t *testing.T) {
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, AIPairTimeoutSec: 60}
+	params := &config.AIParams{Name: "Mnemosyne", DelayMinMS: 3_600_000, DelayMaxMS: 3_600_000, UseBestMoveChance: 100, NeverResign: true}
+	aiSend := make(chan []byte, 4)
+	p0 := game.NewPlayer("Human", make(chan []byte, 4))
+	p1 := game.NewPlayer("Mnemosyne", aiSend)
+	g, err := game.NewGame("test", cfg, p0, p1, powerup.NewBuiltinRegistry(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	humanReady := make(chan struct{})
+	close(humanReady)
+
+	done := make(chan struct{})
+	go func() {
+		Run(aiSend, g, 1, params, humanReady)
+		close(done)
+	}()
+
+	// An hour-long pause before the first flip: the loop must still see the game_over behind it.
+	state, _ := json.Marshal(game.GameStateMsg{Type: "game_state", YourTurn: true, Phase: "first_flip", Round: 1,
+		Cards: []game.CardView{{Index: 0, State: "hidden"}, {Index: 1, State: "hidden"}, {Index: 2, State: "hidden"}, {Index: 3, State: "hidden"}}})
+	aiSend <- state
+	gameOver, _ := json.Marshal(map[string]string{"type": "game_over"})
+	aiSend <- gameOver
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not read game_over while pausing")
+	}
+	select {
+	case a := <-g.Actions:
+		t.Errorf("expected the paused flip to be dropped, got %+v", a)
+	default:
+	}
+}
+
 func TestHiddenIndices(t *testing.T) {
 	cards := []game.CardView{
 		{Index: 0, State: "hidden"},
@@ -343,6 +374,27 @@ func TestPickSecondCard_PrefersUnseenTilesWhenBestMove(t *testing.T) {
 	}
 }
 
+func TestPickThirdCard_CompletesEitherFlippedCard(t *testing.T) {
+	// Flipped 0 (pair 1) and 1 (pair 2) did not match; memory knows 3 is the partner of 1.
+	hidden := []int{2, 3, 4}
+	memory := map[int]int{0: 1, 1: 2, 3: 2, 4: 5}
+	third, reason := pickThirdCard(memory, hidden, []int{0, 1}, false, nil)
+	if third != 3 || reason != "known_pair" {
+		t.Errorf("pickThirdCard want 3 (known_pair), got %d (%s)", third, reason)
+	}
+	// No known partner: prefer unseen tiles with best move
+	knownIndicesSet := map[int]struct{}{0: {}, 1: {}, 4: {}}
+	for range 30 {
+		third, reason = pickThirdCard(map[int]int{0: 1, 1: 2, 4: 5}, hidden, []int{0, 1}, true, knownIndicesSet)
+		if third != 2 && third != 3 {
+			t.Errorf("pickThirdCard with useBestMove should prefer unseen tiles (2 or 3), got %d", third)
+		}
+		if reason != "unseen" {
+			t.Errorf("pickThirdCard with unseen tiles should return reason 'unseen', got %q", reason)
+		}
+	}
+}
+
 func TestPickPair_ReturnsRandomWhenNotUseBestMove(t *testing.T) {
 	hidden := []int{0, 1, 2, 3}
 	memory := map[int]int{0: 5, 1: 5} // known pair at 0,1
@@ -470,3 +522,29 @@ func TestApplyForgetByRecency_Age1UsesHalfChance(t *testing.T) {
 		t.Error("age 2 entry with roll 0 and forgetChance 12 should be forgotten")
 	}
 }
+
+func TestPlanSaveKnownPairs(t *testing.T) {
+	memory := map[int]int{0: 5, 1: 5, 2: 7}
+	hidden := []int{0, 1, 2, 3, 4, 5}
+
+	leechNextTurn := []game.PowerUpInHand{{PowerUpID: PowerUpLeech, Count: 1, UsableCount: 0}}
+	if save, _ := planSaveKnownPairs(leechNextTurn, memory, hidden); !save {
+		t.Error("expected to save the known pair for Leech usable next turn")
+	}
+	leechNow := []game.PowerUpInHand{{PowerUpID: PowerUpLeech, Count: 1, UsableCount: 1}}
+	if save, _ := planSaveKnownPairs(leechNow, memory, hidden); save {
+		t.Error("expected no saving when Leech is usable this turn")
+	}
+	pactNextTurn := []game.PowerUpInHand{{PowerUpID: PowerUpBloodPact, Count: 1, UsableCount: 0}}
+	if save, _ := planSaveKnownPairs(pactNextTurn, memory, hidden); save {
+		t.Error("expected no saving for Blood Pact with a single known pair")
+	}
+
+	m, h := withoutKnownPairs(memory, hidden)
+	if _, ok := m[0]; ok || len(h) != 4 || indexInSlice(0, h) || indexInSlice(1, h) {
+		t.Errorf("expected the known pair removed, got memory %v hidden %v", m, h)
+	}
+	if first, _, reason := pickPair(m, h, true, nil, nil, nil, nil); first == 0 || first == 1 || reason == flipReasonKnownPair {
+		t.Errorf("expected a flip outside the saved pair, got %d (%s)", first, reason)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
+	"time"
 
 	"memory-game-server/ai/heuristic"
 	"memory-game-server/config"
@@ -45,19 +46,23 @@ func evWithCard(state *game.GameStateMsg, memory map[int]int, hidden []int, powe
 	return heuristic.EV(powerUpID, state, memory, hidden, P)
 }
 
-// arcanaDecision holds the result of pickArcanaToUse. Reason is "ev" (maximize EV), "random" (randomness applied), or "no_improvement" (no card improved EV).
+// arcanaDecision holds the result of pickArcanaToUse. Reason is "ev" (maximize EV), "random" (randomness applied),
+// "no_improvement" (no card improved EV) or "save" (lookahead: the card is worth more next turn).
 // CardIndex is the target for power-ups that need it (e.g. Clairvoyance); -1 otherwise.
+// margin is the EV gap between using the best card and playing without one (drives think time; 0 for random picks).
 type arcanaDecision struct {
 	powerUpID string
 	use       bool
 	reason    string
 	CardIndex int
+	margin    float64
 }
 
 // pickArcanaToUse decides whether to use an arcana this turn and which one.
 // Applies ArcanaRandomness: with that probability we may skip using a good card or randomize.
-// rows and cols are the board dimensions (for Clairvoyance target choice).
-func pickArcanaToUse(state *game.GameStateMsg, memory map[int]int, hidden []int, rows, cols int, params *config.AIParams) arcanaDecision {
+// rows and cols are the board dimensions (for Clairvoyance target choice). budget bounds the lookahead of
+// profiles with SearchDepth 2 (see searchBudget); when it runs out the card is used.
+func pickArcanaToUse(state *game.GameStateMsg, memory map[int]int, hidden []int, rows, cols int, params *config.AIParams, budget time.Duration) arcanaDecision {
 	P := pairsRemaining(state.Cards)
 	if P <= 0 {
 		return arcanaDecision{reason: "no_improvement"}
@@ -70,6 +75,7 @@ func pickArcanaToUse(state *game.GameStateMsg, memory map[int]int, hidden []int,
 		ev        float64
 	}
 	var candidates []choice
+	bestUsableEV := -1.0
 	for _, slot := range state.Hand {
 		if slot.UsableCount <= 0 {
 			continue
@@ -78,13 +84,20 @@ func pickArcanaToUse(state *game.GameStateMsg, memory map[int]int, hidden []int,
 		if ev < 0 {
 			continue
 		}
+		if ev > bestUsableEV {
+			bestUsableEV = ev
+		}
 		// Use card when it improves or equals EV (e.g. Chaos when no known pair: same EV, may use to disrupt).
 		if ev >= evNo {
 			candidates = append(candidates, choice{slot.PowerUpID, ev})
 		}
 	}
 	if len(candidates) == 0 {
-		return arcanaDecision{reason: "no_improvement"}
+		margin := 1.0
+		if bestUsableEV >= 0 {
+			margin = evNo - bestUsableEV
+		}
+		return arcanaDecision{reason: "no_improvement", margin: margin}
 	}
 
 	// Best: highest EV
@@ -110,7 +123,13 @@ func pickArcanaToUse(state *game.GameStateMsg, memory map[int]int, hidden []int,
 		return dec
 	}
 
-	dec := arcanaDecision{powerUpID: best.powerUpID, use: true, reason: "ev"}
+	if params.SearchDepth >= 2 {
+		if save, margin, ok := lookAhead(state, memory, hidden, best.powerUpID, best.ev-evNo, P, budget); ok && save {
+			return arcanaDecision{reason: "save", margin: margin}
+		}
+	}
+
+	dec := arcanaDecision{powerUpID: best.powerUpID, use: true, reason: "ev", margin: best.ev - evNo}
 	dec.CardIndex = heuristic.PickTarget(best.powerUpID, state, memory, hidden, rows, cols)
 	if dec.CardIndex == -1 && needsTarget(best.powerUpID) {
 		return arcanaDecision{reason: "no_improvement"}
@@ -128,6 +147,84 @@ func needsTarget(powerUpID string) bool {
 	}
 }
 
+// savedPairRisk is the assumed chance that a known pair left on the board is taken before the AI's next
+// turn: the opponent saw the same flips.
+const savedPairRisk = 0.3
+
+// waitingArcana returns the power-up IDs held only as copies on cooldown, i.e. usable next turn but not now.
+func waitingArcana(hand []game.PowerUpInHand) map[string]bool {
+	out := make(map[string]bool)
+	for _, slot := range hand {
+		if slot.Count > 0 && slot.UsableCount <= 0 {
+			out[slot.PowerUpID] = true
+		}
+	}
+	return out
+}
+
+// knownPairIndices returns the hidden indices whose pair is fully known, i.e. pairs the AI can match at will.
+func knownPairIndices(memory map[int]int, hidden []int) map[int]bool {
+	byPair := make(map[int][]int)
+	for _, idx := range hidden {
+		if p, ok := memory[idx]; ok {
+			byPair[p] = append(byPair[p], idx)
+		}
+	}
+	out := make(map[int]bool)
+	for _, indices := range byPair {
+		if len(indices) >= 2 {
+			for _, idx := range indices {
+				out[idx] = true
+			}
+		}
+	}
+	return out
+}
+
+// planSaveKnownPairs reports whether the AI should leave its known pairs on the board this turn because an
+// arcana that only becomes usable next turn makes them worth more then: Leech drains the opponent a point
+// per match, and Blood Pact pays its bonus once enough pairs are certain. Waiting is discounted by
+// savedPairRisk; margin is its expected gain over matching the pairs now.
+func planSaveKnownPairs(hand []game.PowerUpInHand, memory map[int]int, hidden []int) (save bool, margin float64) {
+	k := len(knownPairIndices(memory, hidden)) / 2
+	if k == 0 {
+		return false, 0
+	}
+	waiting := waitingArcana(hand)
+	now := float64(k * game.PointsPerMatch)
+	later := 0.0
+	if waiting[PowerUpLeech] {
+		later = max(later, float64(2*k*game.PointsPerMatch))
+	}
+	if waiting[PowerUpBloodPact] && k >= game.BloodPactMatchesNeeded {
+		later = max(later, float64(k*game.PointsPerMatch+game.BloodPactBonus))
+	}
+	later *= 1 - savedPairRisk
+	if later <= now {
+		return false, 0
+	}
+	return true, later - now
+}
+
+// withoutKnownPairs returns memory and hidden without the tiles of fully known pairs, so the flip pickers
+// leave those pairs on the board.
+func withoutKnownPairs(memory map[int]int, hidden []int) (map[int]int, []int) {
+	saved := knownPairIndices(memory, hidden)
+	m := make(map[int]int, len(memory))
+	for idx, p := range memory {
+		if !saved[idx] {
+			m[idx] = p
+		}
+	}
+	h := make([]int, 0, len(hidden))
+	for _, idx := range hidden {
+		if !saved[idx] {
+			h = append(h, idx)
+		}
+	}
+	return m, h
+}
+
 // formatHand returns a short description of the AI hand for logging (e.g. "fire_elemental(1), chaos(2, 1 usable)").
 func formatHand(hand []game.PowerUpInHand) string {
 	if len(hand) == 0 {
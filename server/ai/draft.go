@@ -0,0 +1,12 @@
+package ai
+
+import "memory-game-server/game"
+
+// sendDraftPick picks the AI's starting arcana. The offer comes in random order, so the first option is
+// as good a pick as any.
+func sendDraftPick(g *game.Game, playerIdx int, powerUpID string) {
+	select {
+	case g.Actions <- game.Action{Type: game.ActionDraftPick, PlayerIdx: playerIdx, PowerUpID: powerUpID}:
+	case <-g.Done:
+	}
+}
@@ -102,6 +102,27 @@ func TestEV_Leech_WithoutKnownPair(t *testing.T) {
 	}
 }
 
+func TestEV_ThirdEye(t *testing.T) {
+	state := &game.GameStateMsg{Cards: make([]game.CardView, 12), ArcanaPairs: 6}
+	for i := range state.Cards {
+		state.Cards[i] = game.CardView{Index: i, State: "hidden"}
+	}
+	hidden := hiddenIndicesForTest(state.Cards)
+	P := 6
+	ev := EV("third_eye", state, map[int]int{}, hidden, P)
+	if ev <= RandomMatchProb(P) || ev != ThreeFlipMatchProb(P) {
+		t.Errorf("EV(third_eye) without known pair should beat a plain guess, got %v", ev)
+	}
+	evKnown := EV("third_eye", state, map[int]int{0: 0, 1: 0}, hidden, P)
+	if evKnown != 1+ThreeFlipMatchProb(P-1) {
+		t.Errorf("EV(third_eye) with known pair want %v, got %v", 1+ThreeFlipMatchProb(P-1), evKnown)
+	}
+	// Last pair: nothing left to guess, so the card would be wasted
+	if ev1 := EV("third_eye", state, map[int]int{}, []int{0, 1}, 1); ev1 != 0 {
+		t.Errorf("EV(third_eye) with P=1 want 0, got %v", ev1)
+	}
+}
+
 func TestEV_BloodPact_WithThreeKnownPairs(t *testing.T) {
 	state := &game.GameStateMsg{Cards: make([]game.CardView, 12), ArcanaPairs: 6}
 	for i := range state.Cards {
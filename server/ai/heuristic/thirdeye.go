@@ -0,0 +1,37 @@
+package heuristic
+
+import (
+	"memory-game-server/game"
+)
+
+func init() {
+	Register("third_eye", evThirdEye, nil)
+}
+
+// ThreeFlipMatchProb returns the probability of scoring in one attempt by random guess when P pairs remain
+// and a third flip is allowed: the first two match, or the third card pairs with either of them.
+func ThreeFlipMatchProb(P int) float64 {
+	p2 := RandomMatchProb(P)
+	if P < 2 {
+		return p2
+	}
+	// After a miss, 2P-2 cards stay hidden and two of them pair with the revealed cards.
+	return p2 + (1-p2)*2/float64(2*P-2)
+}
+
+// evThirdEye returns the expected value of using Third Eye. It mirrors evNoCard with the guessing attempt
+// scored by ThreeFlipMatchProb: a known pair is still worth 1, then the guess that follows has better odds.
+// Returns 0 when no guess is left to improve (the card would be wasted).
+func evThirdEye(state *game.GameStateMsg, memory map[int]int, hidden []int, P int) float64 {
+	if HasKnownPair(memory, hidden) {
+		PAfter := P - 1
+		if PAfter < 2 {
+			return 0
+		}
+		return 1 + ThreeFlipMatchProb(PAfter)
+	}
+	if P < 2 {
+		return 0
+	}
+	return ThreeFlipMatchProb(P)
+}
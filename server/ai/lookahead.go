@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"time"
+
+	"memory-game-server/ai/heuristic"
+	"memory-game-server/config"
+	"memory-game-server/game"
+)
+
+// defaultSearchBudget is the lookahead budget for profiles with SearchDepth set and no SearchBudgetMS.
+const defaultSearchBudget = 20 * time.Millisecond
+
+// opponentRecall is the assumed chance that the opponent takes a pair the AI knows: it was flipped in
+// the open, so they may remember it too.
+const opponentRecall = 0.8
+
+// replyOutcome is one way the opponent's next turn can go: its probability and the position the AI
+// faces afterwards.
+type replyOutcome struct {
+	prob   float64
+	memory map[int]int
+	hidden []int
+	P      int
+}
+
+// opponentReplies models the opponent's next turn from public information. They take a pair the AI
+// knows (opponentRecall) or otherwise match at random; when they miss, the tiles they reveal may
+// complete a pair the AI half knows (the expected memory gain).
+func opponentReplies(memory map[int]int, hidden []int, P int) []replyOutcome {
+	known := knownPairIndices(memory, hidden)
+	pMatch := heuristic.RandomMatchProb(P)
+	if len(known) > 0 {
+		pMatch = opponentRecall
+	}
+	var out []replyOutcome
+
+	// Opponent matches: a known pair if there is one (it leaves the AI's memory), else an unknown one.
+	matched := replyOutcome{prob: pMatch, memory: memory, hidden: hidden, P: P - 1}
+	if len(known) > 0 {
+		var pairID int
+		for idx := range known {
+			pairID = memory[idx]
+			break
+		}
+		matched.memory = make(map[int]int, len(memory))
+		for idx, p := range memory {
+			if p != pairID {
+				matched.memory[idx] = p
+			}
+		}
+		matched.hidden = make([]int, 0, len(hidden))
+		for _, idx := range hidden {
+			if p, ok := memory[idx]; !ok || p != pairID {
+				matched.hidden = append(matched.hidden, idx)
+			}
+		}
+	}
+	out = append(out, matched)
+
+	// Opponent misses: each of the two tiles they reveal is the partner of a half-known pair with
+	// probability singles/unseen.
+	var singles, unseen []int
+	for _, idx := range hidden {
+		if _, ok := memory[idx]; !ok {
+			unseen = append(unseen, idx)
+		} else if !known[idx] {
+			singles = append(singles, idx)
+		}
+	}
+	pMiss := 1 - pMatch
+	if len(singles) == 0 || len(unseen) == 0 {
+		return append(out, replyOutcome{prob: pMiss, memory: memory, hidden: hidden, P: P})
+	}
+	q := min(1, float64(len(singles))/float64(len(unseen)))
+	pGain := 1 - (1-q)*(1-q)
+	gained := make(map[int]int, len(memory)+1)
+	for idx, p := range memory {
+		gained[idx] = p
+	}
+	gained[unseen[0]] = memory[singles[0]]
+	return append(out,
+		replyOutcome{prob: pMiss * pGain, memory: gained, hidden: hidden, P: P},
+		replyOutcome{prob: pMiss * (1 - pGain), memory: memory, hidden: hidden, P: P},
+	)
+}
+
+// lookAhead weighs using powerUpID now against saving it, simulating one opponent reply (2-ply). gainNow
+// is the card's EV gain over playing without it this turn; saving wins when its expected gain on the
+// AI's next turn is larger, and margin is the difference. ok is false when the budget ran out first.
+func lookAhead(state *game.GameStateMsg, memory map[int]int, hidden []int, powerUpID string, gainNow float64, P int, budget time.Duration) (save bool, margin float64, ok bool) {
+	deadline := time.Now().Add(budget)
+	gainNext := 0.0
+	for _, o := range opponentReplies(memory, hidden, P) {
+		if !time.Now().Before(deadline) {
+			return false, 0, false
+		}
+		if o.P <= 0 || o.prob <= 0 {
+			continue
+		}
+		gainNext += o.prob * (evWithCard(state, o.memory, o.hidden, powerUpID, o.P) - evNoCard(state, o.memory, o.hidden, o.P))
+	}
+	if gainNext <= gainNow {
+		return false, 0, true
+	}
+	return true, gainNext - gainNow, true
+}
+
+// searchBudget returns the profile's lookahead budget.
+func searchBudget(params *config.AIParams) time.Duration {
+	if params.SearchBudgetMS > 0 {
+		return time.Duration(params.SearchBudgetMS) * time.Millisecond
+	}
+	return defaultSearchBudget
+}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"math"
+	"testing"
+
+	"memory-game-server/game"
+)
+
+func TestOpponentReplies_ProbabilitiesAndKnownPair(t *testing.T) {
+	memory := map[int]int{0: 5, 1: 5, 2: 7}
+	hidden := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	outcomes := opponentReplies(memory, hidden, 4)
+	total := 0.0
+	for _, o := range outcomes {
+		total += o.prob
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected probabilities to sum to 1, got %v", total)
+	}
+	matched := outcomes[0]
+	if matched.prob != opponentRecall || matched.P != 3 {
+		t.Errorf("expected the opponent to take the known pair with p=%v, got %+v", opponentRecall, matched)
+	}
+	if _, ok := matched.memory[0]; ok || indexInSlice(1, matched.hidden) {
+		t.Errorf("expected the known pair gone after the opponent matched it, got memory %v hidden %v", matched.memory, matched.hidden)
+	}
+	if len(outcomes) != 3 || len(knownPairIndices(outcomes[1].memory, hidden)) != 4 {
+		t.Errorf("expected a miss outcome where the half-known pair gets completed, got %+v", outcomes)
+	}
+}
+
+func TestLookAhead(t *testing.T) {
+	state := &game.GameStateMsg{Cards: make([]game.CardView, 16), ArcanaPairs: 2}
+	for i := range state.Cards {
+		state.Cards[i] = game.CardView{Index: i, State: "hidden"}
+	}
+	hidden := hiddenIndices(state.Cards)
+	memory := map[int]int{0: 5}
+	P := pairsRemaining(state.Cards)
+
+	// Chaos only ties a blind guess now and gets worse once the opponent's reveals complete a pair.
+	if save, _, ok := lookAhead(state, memory, hidden, PowerUpChaos, 0, P, defaultSearchBudget); !ok || save {
+		t.Errorf("expected to use Chaos now, got save=%v ok=%v", save, ok)
+	}
+	if _, _, ok := lookAhead(state, memory, hidden, PowerUpChaos, 0, P, 0); ok {
+		t.Error("expected no decision with an exhausted budget")
+	}
+}
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"math/rand"
+	"time"
+
+	"memory-game-server/ai/heuristic"
+	"memory-game-server/config"
+)
+
+// randBetweenMS returns a random duration in [minMS, maxMS) milliseconds, or minMS when the range is empty.
+func randBetweenMS(minMS, maxMS int) int {
+	if maxMS > minMS {
+		return minMS + rand.Intn(maxMS-minMS)
+	}
+	return minMS
+}
+
+// firstFlipDelayMS is the base pause before the first action of a turn (first flip or arcana use).
+func firstFlipDelayMS(params *config.AIParams) int {
+	return randBetweenMS(params.DelayMinMS, params.DelayMaxMS)
+}
+
+// secondFlipDelayMS is the base pause between the first and second flip. Falls back to the first-flip
+// range when the profile does not configure a separate one.
+func secondFlipDelayMS(params *config.AIParams) int {
+	if params.SecondFlipDelayMinMS <= 0 && params.SecondFlipDelayMaxMS <= 0 {
+		return firstFlipDelayMS(params)
+	}
+	return randBetweenMS(params.SecondFlipDelayMinMS, params.SecondFlipDelayMaxMS)
+}
+
+// thinkExtraMS models "thinking longer when uncertain". margin is the EV gap between the chosen move and
+// the best alternative (clamped to [0, 1]): a clear-cut move adds nothing, a close call adds up to maxExtraMS.
+func thinkExtraMS(margin float64, maxExtraMS int) int {
+	if maxExtraMS <= 0 {
+		return 0
+	}
+	if margin < 0 {
+		margin = 0
+	}
+	if margin > 1 {
+		margin = 1
+	}
+	return int(float64(maxExtraMS) * (1 - margin))
+}
+
+// flipMargin returns the EV margin of a flip decision with P pairs remaining. Completing a known pair is
+// worth a point against the odds of a blind guess; any other pick is a guess between near-equal options.
+func flipMargin(reason string, P int) float64 {
+	if reason == flipReasonKnownPair {
+		return 1 - heuristic.RandomMatchProb(P)
+	}
+	return 0
+}
+
+// pause is the base delay plus the uncertainty extra of the profile.
+func pause(baseMS int, margin float64, params *config.AIParams) time.Duration {
+	return time.Duration(baseMS+thinkExtraMS(margin, params.ThinkMaxExtraMS)) * time.Millisecond
+}
@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"testing"
+
+	"memory-game-server/config"
+)
+
+func TestThinkExtraMS(t *testing.T) {
+	tests := []struct {
+		margin float64
+		max    int
+		want   int
+	}{
+		{margin: 0, max: 1000, want: 1000},
+		{margin: 0.25, max: 1000, want: 750},
+		{margin: 1, max: 1000, want: 0},
+		{margin: -0.5, max: 1000, want: 1000}, // clamped to 0
+		{margin: 3, max: 1000, want: 0},       // clamped to 1
+		{margin: 0, max: 0, want: 0},          // disabled
+	}
+	for _, tt := range tests {
+		if got := thinkExtraMS(tt.margin, tt.max); got != tt.want {
+			t.Errorf("thinkExtraMS(%v, %d) = %d, want %d", tt.margin, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestFlipMargin_KnownPairIsClearCut(t *testing.T) {
+	known := flipMargin(flipReasonKnownPair, 10)
+	if known <= 0.9 {
+		t.Errorf("expected a known pair to have a large margin with 10 pairs left, got %v", known)
+	}
+	for _, reason := range []string{flipReasonUnseen, flipReasonRandom, flipReasonHighlight, flipReasonElementKnown} {
+		if m := flipMargin(reason, 10); m != 0 {
+			t.Errorf("expected margin 0 for guess %q, got %v", reason, m)
+		}
+	}
+}
+
+func TestSecondFlipDelayMS(t *testing.T) {
+	separate := &config.AIParams{DelayMinMS: 1000, DelayMaxMS: 2000, SecondFlipDelayMinMS: 100, SecondFlipDelayMaxMS: 200}
+	for range 50 {
+		if d := secondFlipDelayMS(separate); d < 100 || d >= 200 {
+			t.Fatalf("expected second flip delay in [100, 200), got %d", d)
+		}
+	}
+	fallback := &config.AIParams{DelayMinMS: 1000, DelayMaxMS: 2000}
+	for range 50 {
+		if d := secondFlipDelayMS(fallback); d < 1000 || d >= 2000 {
+			t.Fatalf("expected fallback to first flip range [1000, 2000), got %d", d)
+		}
+	}
+	fixed := &config.AIParams{SecondFlipDelayMinMS: 300, SecondFlipDelayMaxMS: 300}
+	if d := secondFlipDelayMS(fixed); d != 300 {
+		t.Errorf("expected fixed delay 300 for an empty range, got %d", d)
+	}
+}
@@ -135,3 +135,34 @@ func pickSecondCard(memory map[int]int, hidden []int, firstIdx int, useBestMove
 	}
 	return candidates[rand.Intn(len(candidates))], flipReasonRandom
 }
+
+// pickThirdCard chooses the third card of a Third Eye attempt after the first two (flipped) did not match.
+// Completes a pair with either revealed card when memory knows its partner; otherwise prefers unseen tiles,
+// since a never-seen tile is as likely as any other to pair with them and also teaches us something.
+func pickThirdCard(memory map[int]int, hidden []int, flipped []int, useBestMove bool, knownIndicesSet map[int]struct{}) (int, string) {
+	want := make(map[int]bool, len(flipped))
+	for _, idx := range flipped {
+		if pairID, ok := memory[idx]; ok {
+			want[pairID] = true
+		}
+	}
+	var candidates []int
+	for _, idx := range hidden {
+		if indexInSlice(idx, flipped) {
+			continue
+		}
+		if pairID, ok := memory[idx]; ok && want[pairID] {
+			return idx, flipReasonKnownPair
+		}
+		candidates = append(candidates, idx)
+	}
+	if len(candidates) == 0 {
+		return -1, flipReasonRandom
+	}
+	if useBestMove {
+		if unseen := unseenFromCandidates(candidates, knownIndicesSet); len(unseen) > 0 {
+			return unseen[rand.Intn(len(unseen))], flipReasonUnseen
+		}
+	}
+	return candidates[rand.Intn(len(candidates))], flipReasonRandom
+}
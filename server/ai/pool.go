@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Pool computes AI decisions on a fixed number of workers, so hundreds of bots playing at once share a
+// bounded amount of CPU instead of all searching at the same time. Bots only hand it the choice of a move
+// (pickPair, pickArcanaToUse, ...); their human-like pauses are timers (time.AfterFunc) that hold neither a
+// worker nor a goroutine.
+type Pool struct {
+	workers int
+	jobs    chan func()
+
+	mu       sync.Mutex
+	counters poolCounters
+}
+
+// PoolStats is a snapshot of a pool's activity since it started.
+type PoolStats struct {
+	Workers int
+	// Queued is how many decisions are waiting for a worker right now.
+	Queued    int
+	Decisions int64
+	// OverBudget counts decisions that took longer than their budget, queue wait included.
+	OverBudget int64
+	// AvgQueueWait and MaxQueueWait cover the time from submitting a decision to a worker picking it up;
+	// AvgCompute and MaxCompute the time the worker then spent on it.
+	AvgQueueWait time.Duration
+	MaxQueueWait time.Duration
+	AvgCompute   time.Duration
+	MaxCompute   time.Duration
+}
+
+type poolCounters struct {
+	decisions, overBudget    int64
+	waitTotal, waitMax       time.Duration
+	computeTotal, computeMax time.Duration
+}
+
+// NewPool starts a pool of workers goroutines; workers <= 0 uses one per CPU (GOMAXPROCS).
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &Pool{workers: workers, jobs: make(chan func(), 4*workers)}
+	for range workers {
+		go func() {
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	return p
+}
+
+// Stats returns the pool's decision counters.
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c := p.counters
+	st := PoolStats{Workers: p.workers, Queued: len(p.jobs), Decisions: c.decisions, OverBudget: c.overBudget,
+		MaxQueueWait: c.waitMax, MaxCompute: c.computeMax}
+	if c.decisions > 0 {
+		st.AvgQueueWait = c.waitTotal / time.Duration(c.decisions)
+		st.AvgCompute = c.computeTotal / time.Duration(c.decisions)
+	}
+	return st
+}
+
+func (p *Pool) record(wait, compute, budget time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c := &p.counters
+	c.decisions++
+	if wait+compute > budget {
+		c.overBudget++
+	}
+	c.waitTotal += wait
+	c.waitMax = max(c.waitMax, wait)
+	c.computeTotal += compute
+	c.computeMax = max(c.computeMax, compute)
+}
+
+var (
+	sharedOnce    sync.Once
+	sharedWorkers int
+	shared        *Pool
+)
+
+// SetWorkers sizes the pool every bot of the process decides on (config AIWorkers). It must be called
+// before the first bot runs; later calls have no effect.
+func SetWorkers(n int) {
+	sharedWorkers = n
+}
+
+// SharedPool returns the pool every bot of the process decides on, starting it on first use.
+func SharedPool() *Pool {
+	sharedOnce.Do(func() {
+		shared = NewPool(sharedWorkers)
+	})
+	return shared
+}
+
+// decide runs choose on a worker of p and waits for its result. The budget counts from now: choose gets
+// what is left of it once a worker picks it up (possibly 0, which lookahead treats as out of time), so a
+// backlog makes bots search less rather than answer late. ok is false when done is closed first; choose
+// may still run then, and its result is dropped.
+func decide[T any](p *Pool, done <-chan struct{}, budget time.Duration, choose func(budget time.Duration) T) (result T, ok bool) {
+	queued := time.Now()
+	out := make(chan T, 1)
+	job := func() {
+		start := time.Now()
+		wait := start.Sub(queued)
+		v := choose(max(budget-wait, 0))
+		p.record(wait, time.Since(start), budget)
+		out <- v
+	}
+	select {
+	case p.jobs <- job:
+	case <-done:
+		return result, false
+	}
+	select {
+	case result = <-out:
+		return result, true
+	case <-done:
+		return result, false
+	}
+}
@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecide_QueueWaitComesOutOfTheBudget(t *testing.T) {
+	p := NewPool(1)
+	done := make(chan struct{})
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	// Hold the only worker so the next decision waits in line.
+	go decide(p, done, time.Second, func(time.Duration) bool {
+		close(started)
+		<-release
+		return true
+	})
+	<-started
+	time.AfterFunc(30*time.Millisecond, func() { close(release) })
+
+	got, ok := decide(p, done, 20*time.Millisecond, func(budget time.Duration) time.Duration { return budget })
+	if !ok {
+		t.Fatal("expected the decision to run")
+	}
+	if got != 0 {
+		t.Errorf("expected the budget to be spent in the queue, got %v left", got)
+	}
+	st := p.Stats()
+	if st.Workers != 1 || st.Decisions != 2 || st.OverBudget != 1 || st.MaxQueueWait < 20*time.Millisecond {
+		t.Errorf("expected 2 decisions, 1 over budget after waiting, got %+v", st)
+	}
+}
+
+func TestDecide_GameEndsWhileQueued(t *testing.T) {
+	p := NewPool(1)
+	done := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go decide(p, make(chan struct{}), time.Second, func(time.Duration) bool {
+		close(started)
+		<-release
+		return true
+	})
+	<-started
+
+	close(done)
+	if _, ok := decide(p, done, time.Second, func(time.Duration) bool { return true }); ok {
+		t.Error("expected no decision once the game is over")
+	}
+}
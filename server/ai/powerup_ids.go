@@ -12,4 +12,6 @@ const (
 	PowerUpAirElemental   = "air_elemental"
 	PowerUpEarthElemental = "earth_elemental"
 	PowerUpNecromancy     = "necromancy"
+	PowerUpThirdEye       = "third_eye"
+	PowerUpBloodPact      = "blood_pact"
 )
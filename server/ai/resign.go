@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"memory-game-server/game"
+)
+
+// outlookFromState builds the AI's ScoreOutlook from its game_state view at the start of its turn, when no
+// Leech or Blood Pact can be active for either side. The opponent's hand is hidden, so every arcana pair
+// already matched is assumed to be in the opponent's hand; that keeps MaxGain an upper bound.
+func outlookFromState(state *game.GameStateMsg) game.ScoreOutlook {
+	remaining := 0
+	matched := make(map[int]bool)
+	for _, c := range state.Cards {
+		switch c.State {
+		case "matched":
+			if c.PairID != nil {
+				matched[*c.PairID] = true
+			}
+		case "removed":
+		default:
+			remaining++
+		}
+	}
+	o := game.ScoreOutlook{
+		RemainingPairs: remaining / 2,
+		OwnArcana:      make(map[string]int, len(state.Hand)),
+		OpponentArcana: make(map[string]int),
+		OwnPactMatches: -1,
+		OpponentScore:  state.Opponent.Score,
+	}
+	for _, slot := range state.Hand {
+		o.OwnArcana[slot.PowerUpID] += slot.Count
+	}
+	for pairID, id := range state.PairIDToPowerUp {
+		if matched[pairID] {
+			o.OpponentArcana[id]++
+		} else {
+			o.BoardArcana = append(o.BoardArcana, id)
+		}
+	}
+	return o
+}
+
+// hopeless reports whether the AI can no longer reach even a draw: the opponent's lead is larger than
+// the most the AI could still gain. Never true while a Necromancy is in play (unbounded outlook).
+func hopeless(state *game.GameStateMsg) bool {
+	gap := state.Opponent.Score - state.You.Score
+	if gap <= 0 {
+		return false
+	}
+	gain, bounded := outlookFromState(state).MaxGain()
+	return bounded && gap > gain
+}
+
+func sendResign(g *game.Game, playerIdx int) {
+	select {
+	case g.Actions <- game.Action{Type: game.ActionResign, PlayerIdx: playerIdx}:
+	case <-g.Done:
+	}
+}
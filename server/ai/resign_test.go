@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"testing"
+
+	"memory-game-server/game"
+)
+
+// stateWithPairs returns a game_state view with the given hidden and matched pairs (pair IDs from 0).
+func stateWithPairs(hiddenPairs, matchedPairs int, you, opponent int) *game.GameStateMsg {
+	var cards []game.CardView
+	idx := 0
+	for p := range matchedPairs {
+		for range 2 {
+			pairID := p
+			cards = append(cards, game.CardView{Index: idx, PairID: &pairID, State: "matched"})
+			idx++
+		}
+	}
+	for range hiddenPairs * 2 {
+		cards = append(cards, game.CardView{Index: idx, State: "hidden"})
+		idx++
+	}
+	return &game.GameStateMsg{
+		Cards:           cards,
+		You:             game.PlayerView{Score: you},
+		Opponent:        game.PlayerView{Score: opponent},
+		PairIDToPowerUp: map[int]string{},
+	}
+}
+
+func TestHopeless(t *testing.T) {
+	if !hopeless(stateWithPairs(2, 6, 0, 6)) {
+		t.Error("expected hopeless when the lead (6) exceeds the 2 remaining pairs")
+	}
+	if hopeless(stateWithPairs(2, 6, 0, 2)) {
+		t.Error("expected not hopeless when the remaining pairs can still draw")
+	}
+	if hopeless(stateWithPairs(2, 6, 5, 1)) {
+		t.Error("expected not hopeless when the AI leads")
+	}
+}
+
+func TestHopeless_ArcanaKeepsHopeAlive(t *testing.T) {
+	// Blood Pact still on the board: 3 pairs + 5 bonus can cover a lead of 6.
+	state := stateWithPairs(3, 5, 0, 6)
+	state.PairIDToPowerUp = map[int]string{5: "blood_pact"}
+	if hopeless(state) {
+		t.Error("expected a reachable Blood Pact to keep the game alive")
+	}
+
+	// Necromancy already collected may sit in the opponent's hand and revive pairs: never hopeless.
+	state = stateWithPairs(1, 5, 0, 20)
+	state.PairIDToPowerUp = map[int]string{0: "necromancy"}
+	if hopeless(state) {
+		t.Error("expected no resign while a Necromancy may still be played")
+	}
+}
+
+func TestOutlookFromState_AssumesMatchedArcanaWithOpponent(t *testing.T) {
+	state := stateWithPairs(4, 2, 0, 0)
+	state.PairIDToPowerUp = map[int]string{0: "leech", 3: "chaos"}
+	state.Hand = []game.PowerUpInHand{{PowerUpID: "silence", Count: 1, UsableCount: 1}}
+
+	o := outlookFromState(state)
+	if o.RemainingPairs != 4 {
+		t.Errorf("expected 4 remaining pairs, got %d", o.RemainingPairs)
+	}
+	if o.OpponentArcana["leech"] != 1 {
+		t.Errorf("expected the matched leech pair to count as the opponent's, got %v", o.OpponentArcana)
+	}
+	if len(o.BoardArcana) != 1 || o.BoardArcana[0] != "chaos" {
+		t.Errorf("expected chaos still on the board, got %v", o.BoardArcana)
+	}
+	if o.OwnArcana["silence"] != 1 {
+		t.Errorf("expected own hand to be counted, got %v", o.OwnArcana)
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"memory-game-server/config"
+	"memory-game-server/storage"
+)
+
+// maxFriendIDLength bounds the user IDs accepted by the friend endpoints.
+const maxFriendIDLength = 128
+
+// FriendRequest is the JSON body for POST /api/friends/request and /api/friends/accept: the other user.
+type FriendRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// FriendRequestResponse is the JSON response for POST /api/friends/request and /api/friends/accept.
+type FriendRequestResponse struct {
+	UserID string `json:"user_id"`
+	// Status is "pending" until the other user accepts, then "accepted".
+	Status string `json:"status"`
+}
+
+// FriendsResponse is the JSON structure for GET /api/friends.
+type FriendsResponse struct {
+	Friends []storage.Friend `json:"friends"`
+}
+
+// Friends lists the authenticated user's friends and pending requests, with their rating in the user's realm.
+func (h *Handler) Friends(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, claimRealm := h.extractIdentity(r)
+	if userID == "" {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return
+	}
+	if h.HistoryStore == nil {
+		http.Error(w, "friends not available", http.StatusServiceUnavailable)
+		return
+	}
+	friends, err := h.HistoryStore.ListFriends(r.Context(), claimRealm, userID)
+	if err != nil {
+		slog.Error("ListFriends", "tag", "api", "err", err)
+		http.Error(w, "failed to load friends", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(FriendsResponse{Friends: friends}); err != nil {
+		slog.Error("Encode friends response", "tag", "api", "err", err)
+	}
+}
+
+// RequestFriend sends a friend request from the authenticated user to body.user_id. When that user had
+// already asked, the request accepts theirs. Repeating a request is harmless.
+func (h *Handler) RequestFriend(w http.ResponseWriter, r *http.Request) {
+	userID, friendID, ok := h.friendRequest(w, r)
+	if !ok {
+		return
+	}
+	status, err := h.HistoryStore.RequestFriend(r.Context(), userID, friendID)
+	if err != nil {
+		slog.Error("RequestFriend", "tag", "api", "err", err)
+		http.Error(w, "failed to send friend request", http.StatusInternalServerError)
+		return
+	}
+	writeFriendStatus(w, friendID, status)
+}
+
+// AcceptFriend accepts the pending friend request body.user_id sent to the authenticated user.
+func (h *Handler) AcceptFriend(w http.ResponseWriter, r *http.Request) {
+	userID, requesterID, ok := h.friendRequest(w, r)
+	if !ok {
+		return
+	}
+	accepted, err := h.HistoryStore.AcceptFriend(r.Context(), userID, requesterID)
+	if err != nil {
+		slog.Error("AcceptFriend", "tag", "api", "err", err)
+		http.Error(w, "failed to accept friend request", http.StatusInternalServerError)
+		return
+	}
+	if !accepted {
+		http.Error(w, "friend request not found", http.StatusNotFound)
+		return
+	}
+	writeFriendStatus(w, requesterID, storage.FriendAccepted)
+}
+
+// friendRequest handles what the friend POST endpoints share: CORS, method, auth and the body naming the
+// other user, who must be another human. It writes the error response and returns ok false on failure.
+func (h *Handler) friendRequest(w http.ResponseWriter, r *http.Request) (userID, otherID string, ok bool) {
+	if CORSWithPost(w, r) {
+		return "", "", false
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return "", "", false
+	}
+	userID = h.extractUserID(r)
+	if userID == "" {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return "", "", false
+	}
+	if h.HistoryStore == nil {
+		http.Error(w, "friends not available", http.StatusServiceUnavailable)
+		return "", "", false
+	}
+	var body FriendRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid friend request", http.StatusBadRequest)
+		return "", "", false
+	}
+	otherID = strings.TrimSpace(body.UserID)
+	if otherID == "" || otherID == userID || len(otherID) > maxFriendIDLength || strings.HasPrefix(otherID, config.AIUserIDPrefix) {
+		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		return "", "", false
+	}
+	return userID, otherID, true
+}
+
+// writeFriendStatus writes a FriendRequestResponse.
+func writeFriendStatus(w http.ResponseWriter, userID, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(FriendRequestResponse{UserID: userID, Status: status}); err != nil {
+		slog.Error("Encode friend response", "tag", "api", "err", err)
+	}
+}
@@ -2,14 +2,20 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"memory-game-server/auth"
 	"memory-game-server/config"
 	"memory-game-server/storage"
+	"memory-game-server/ws"
+
+	"github.com/google/uuid"
 )
 
 const bearerPrefix = "Bearer "
@@ -19,6 +25,14 @@ type Handler struct {
 	Config               *config.Config
 	HistoryStore         storage.HistoryStore
 	FrontendErrorLogger  *slog.Logger
+	// Announcer delivers admin announcements to connected clients; nil disables the announcement endpoints.
+	Announcer *ws.Announcer
+	// StatsSources are the hubs and matchmakers summed by /api/stats.
+	StatsSources []StatsSource
+	// Hubs are the WebSocket hubs (default and realms) an admin can disconnect users from.
+	Hubs []*ws.Hub
+
+	statsCache statsCache
 }
 
 // NewHandler creates a new API handler with the given dependencies.
@@ -64,19 +78,42 @@ func CORSWithPost(w http.ResponseWriter, r *http.Request) bool {
 
 // extractUserID validates the Authorization header and returns the user ID, or empty string on failure.
 func (h *Handler) extractUserID(r *http.Request) string {
+	userID, _ := h.extractIdentity(r)
+	return userID
+}
+
+// extractIdentity validates the Authorization header and returns the user ID and the token's realm claim
+// ("" for the default realm). Both are empty on failure.
+func (h *Handler) extractIdentity(r *http.Request) (userID, realm string) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		return ""
+		return "", ""
 	}
 	if !strings.HasPrefix(authHeader, bearerPrefix) {
-		return ""
+		return "", ""
 	}
 	token := strings.TrimSpace(authHeader[len(bearerPrefix):])
 	claims, err := auth.ValidateNeonToken(h.Config.NeonAuthBaseURL, token)
 	if err != nil {
-		return ""
+		return "", ""
 	}
-	return auth.UserIDFromClaims(claims)
+	return auth.UserIDFromClaims(claims), auth.RealmFromClaims(claims)
+}
+
+// requestRealm resolves the realm of a request: the /realms/{realm}/ path prefix when present, otherwise
+// the token's realm claim. status is http.StatusNotFound for an unknown realm and http.StatusForbidden when
+// the token belongs to a different realm than the path; http.StatusOK otherwise.
+func (h *Handler) requestRealm(r *http.Request, claimRealm string) (realm string, status int) {
+	realm = r.PathValue("realm")
+	if realm == "" {
+		realm = claimRealm
+	} else if claimRealm != "" && claimRealm != realm {
+		return "", http.StatusForbidden
+	}
+	if _, ok := h.Config.ForRealm(realm); !ok {
+		return "", http.StatusNotFound
+	}
+	return realm, http.StatusOK
 }
 
 // HistoryResponse is the JSON structure for /api/history (paginated).
@@ -85,7 +122,8 @@ type HistoryResponse struct {
 	HasMore bool                 `json:"has_more"`
 }
 
-// History returns the game history for the authenticated user (paginated).
+// History returns the game history for the authenticated user (paginated). With season=N it only lists
+// the games played during that season of the realm.
 func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 	if CORS(w, r) {
 		return
@@ -95,11 +133,16 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := h.extractUserID(r)
+	userID, claimRealm := h.extractIdentity(r)
 	if userID == "" {
 		http.Error(w, "authorization required", http.StatusUnauthorized)
 		return
 	}
+	realm, status := h.requestRealm(r, claimRealm)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
 
 	limit := 10
 	if s := r.URL.Query().Get("limit"); s != "" {
@@ -120,7 +163,9 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 	resp := HistoryResponse{Games: []storage.GameRecord{}}
 	if h.HistoryStore != nil {
 		var err error
-		resp.Games, resp.HasMore, err = h.HistoryStore.ListByUserIDPaginated(r.Context(), userID, limit, offset)
+		resp.Games, resp.HasMore, err = h.HistoryStore.ListByUserIDPaginated(r.Context(), realm, userID, storage.HistoryQuery{
+			Season: seasonParam(r), Limit: limit, Offset: offset,
+		})
 		if err != nil {
 			slog.Error("ListByUserIDPaginated", "tag", "api", "err", err)
 			http.Error(w, "failed to load history", http.StatusInternalServerError)
@@ -138,9 +183,16 @@ func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
 type LeaderboardResponse struct {
 	Entries          []storage.LeaderboardEntry  `json:"entries"`
 	CurrentUserEntry *storage.LeaderboardEntry  `json:"current_user_entry"`
+	// Season is the number of the season listed; 0 when seasons are off.
+	Season int `json:"season"`
+	// Bracket is who is ranked: "humans", "bots", or empty for everyone.
+	Bracket string `json:"bracket,omitempty"`
 }
 
-// Leaderboard returns the global leaderboard with optional current user entry.
+// Leaderboard returns the global leaderboard with optional current user entry. With scope=friends it only
+// lists the authenticated user and their accepted friends. With season=N for an ended season it lists the
+// final ratings of that season instead of the current ones. humans_only=true (or bracket=humans) leaves bots
+// out of the ranking, and bracket=bots ranks the bots on their own.
 func (h *Handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
 	if CORS(w, r) {
 		return
@@ -150,6 +202,29 @@ func (h *Handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	authUserID, claimRealm := h.extractIdentity(r)
+	realm, status := h.requestRealm(r, claimRealm)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	scope := r.URL.Query().Get("scope")
+	if scope != storage.LeaderboardScopeFriends {
+		scope = storage.LeaderboardScopeGlobal
+	} else if authUserID == "" {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return
+	}
+
+	bracket := r.URL.Query().Get("bracket")
+	if humansOnly, _ := strconv.ParseBool(r.URL.Query().Get("humans_only")); humansOnly {
+		bracket = storage.LeaderboardBracketHumans
+	}
+	if bracket != storage.LeaderboardBracketHumans && bracket != storage.LeaderboardBracketBots {
+		bracket = storage.LeaderboardBracketAll
+	}
+
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	if limit <= 0 {
 		limit = 20
@@ -159,10 +234,25 @@ func (h *Handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
+	// archived is the ended season asked for, 0 for the current ratings.
+	season, archived := 0, 0
+	if h.HistoryStore != nil {
+		cur, err := h.HistoryStore.CurrentSeason(r.Context(), realm)
+		if err != nil {
+			slog.Error("CurrentSeason", "tag", "api", "err", err)
+		}
+		season = cur.Number
+		if n := seasonParam(r); n > 0 && n != cur.Number {
+			season, archived = n, n
+		}
+	}
+
 	entries := []storage.LeaderboardEntry{}
 	if h.HistoryStore != nil {
 		var err error
-		entries, err = h.HistoryStore.ListLeaderboard(r.Context(), limit, offset)
+		entries, err = h.HistoryStore.ListLeaderboard(r.Context(), realm, storage.LeaderboardQuery{
+			ViewerUserID: authUserID, Scope: scope, Bracket: bracket, Season: archived, Limit: limit, Offset: offset,
+		})
 		if err != nil {
 			slog.Error("ListLeaderboard", "tag", "api", "err", err)
 			http.Error(w, "failed to load leaderboard", http.StatusInternalServerError)
@@ -170,12 +260,18 @@ func (h *Handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// The bot bracket has no place for the caller.
 	var currentUserEntry *storage.LeaderboardEntry
-	authUserID := h.extractUserID(r)
-	if authUserID != "" && h.HistoryStore != nil {
-		cur, err := h.HistoryStore.GetLeaderboardEntryByUserID(r.Context(), authUserID)
+	if authUserID != "" && h.HistoryStore != nil && bracket != storage.LeaderboardBracketBots {
+		var cur *storage.LeaderboardEntry
+		var err error
+		if archived > 0 {
+			cur, err = h.HistoryStore.GetSeasonEntryByUserID(r.Context(), realm, authUserID, archived)
+		} else {
+			cur, err = h.HistoryStore.GetLeaderboardEntryByUserID(r.Context(), realm, authUserID)
+		}
 		if err != nil {
-			slog.Error("GetLeaderboardEntryByUserID", "tag", "api", "err", err)
+			slog.Error("GetLeaderboardEntryByUserID", "tag", "api", "season", archived, "err", err)
 		} else if cur != nil {
 			inTop := false
 			for i := range entries {
@@ -192,41 +288,118 @@ func (h *Handler) Leaderboard(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	for i := range entries {
+		h.badgeBot(&entries[i])
+	}
+	if currentUserEntry != nil {
+		h.badgeBot(currentUserEntry)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	resp := LeaderboardResponse{Entries: entries, CurrentUserEntry: currentUserEntry}
+	resp := LeaderboardResponse{Entries: entries, CurrentUserEntry: currentUserEntry, Season: season, Bracket: bracket}
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		slog.Error("Encode leaderboard response", "tag", "api", "err", err)
 	}
 }
 
-// TelemetryMetrics returns aggregated telemetry metrics. Requires admin role (from neon_auth.user).
-func (h *Handler) TelemetryMetrics(w http.ResponseWriter, r *http.Request) {
-	if CORS(w, r) {
+// badgeBot sets the difficulty of a bot entry from its configured AI profile.
+func (h *Handler) badgeBot(e *storage.LeaderboardEntry) {
+	if !e.IsBot || h.Config == nil {
 		return
 	}
-	if r.Method != http.MethodGet {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
+	if profile := h.Config.AIProfileByUserID(e.UserID); profile != nil {
+		e.BotDifficulty = profile.Difficulty
 	}
+}
+
+// requireAdmin checks that the request comes from a user with the admin role (from neon_auth.user) and
+// writes the error response when it does not. unavailable is the message used when there is no store.
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request, unavailable string) bool {
 	userID := h.extractUserID(r)
 	if userID == "" {
 		http.Error(w, "authorization required", http.StatusUnauthorized)
-		return
+		return false
 	}
 	if h.HistoryStore == nil {
-		http.Error(w, "telemetry not available", http.StatusServiceUnavailable)
-		return
+		http.Error(w, unavailable, http.StatusServiceUnavailable)
+		return false
 	}
 	role, err := h.HistoryStore.GetUserRole(r.Context(), userID)
 	if err != nil {
 		slog.Error("GetUserRole", "tag", "api", "err", err)
 		http.Error(w, "failed to verify role", http.StatusInternalServerError)
-		return
+		return false
 	}
 	if role != "admin" {
 		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// TelemetryMetrics returns aggregated telemetry metrics of the realm in the path prefix (the default realm
+// without one). Requires admin role (from neon_auth.user).
+func (h *Handler) TelemetryMetrics(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "telemetry not available") {
+		return
+	}
+	realm, status := h.requestRealm(r, "")
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	binConfig, err := h.telemetryBinConfig(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch groupBy := r.URL.Query().Get("group_by"); groupBy {
+	case "":
+	case storage.TelemetryGroupByBoardSize:
+		binConfig.GroupBy = groupBy
+	default:
+		http.Error(w, "group_by must be board_size", http.StatusBadRequest)
+		return
+	}
+	asCSV := r.URL.Query().Get("format") == "csv"
+	table := r.URL.Query().Get("table")
+	if table == "" {
+		table = telemetryTableByCard
+	}
+	if asCSV && !validTelemetryTable(table) {
+		http.Error(w, "table must be by_card, by_combo or histograms", http.StatusBadRequest)
+		return
+	}
+	metrics, err := h.HistoryStore.GetTelemetryMetrics(r.Context(), realm, &binConfig)
+	if err != nil {
+		slog.Error("GetTelemetryMetrics", "tag", "api", "err", err)
+		http.Error(w, "failed to load metrics", http.StatusInternalServerError)
 		return
 	}
+	if asCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="telemetry-%s-%s-%s.csv"`, table, binConfig.MatchType, binConfig.TimeRange))
+		if err := writeTelemetryCSV(w, metrics, table); err != nil {
+			slog.Error("Write telemetry CSV", "tag", "api", "err", err)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(metrics); err != nil {
+		slog.Error("Encode telemetry response", "tag", "api", "err", err)
+	}
+}
+
+// telemetryBinConfig returns the configured histogram bins with the match_type, time_range, board_size
+// and churn_days params of r. Fails only on a malformed board_size.
+func (h *Handler) telemetryBinConfig(r *http.Request) (storage.TelemetryBinConfig, error) {
 	matchType := r.URL.Query().Get("match_type")
 	if matchType != "all" && matchType != "pvp" && matchType != "vs_ai" {
 		matchType = "all"
@@ -236,7 +409,7 @@ func (h *Handler) TelemetryMetrics(w http.ResponseWriter, r *http.Request) {
 		timeRange = "7d"
 	}
 	th := h.Config.TelemetryHistogram
-	binConfig := &storage.TelemetryBinConfig{
+	cfg := storage.TelemetryBinConfig{
 		TurnMax:      th.TurnMax,
 		TurnNumBins:  th.TurnNumBins,
 		PairsMax:     th.PairsMax,
@@ -244,15 +417,450 @@ func (h *Handler) TelemetryMetrics(w http.ResponseWriter, r *http.Request) {
 		MatchType:    matchType,
 		TimeRange:    timeRange,
 	}
-	metrics, err := h.HistoryStore.GetTelemetryMetrics(r.Context(), binConfig)
+	if n, err := strconv.Atoi(r.URL.Query().Get("churn_days")); err == nil && n > 0 {
+		cfg.ChurnDays = n
+	}
+	if boardSize := r.URL.Query().Get("board_size"); boardSize != "" {
+		rows, cols, ok := storage.ParseBoardSize(boardSize)
+		if !ok {
+			return cfg, errors.New("board_size must be <rows>x<cols>, e.g. 4x4")
+		}
+		cfg.BoardSize = fmt.Sprintf("%dx%d", rows, cols)
+	}
+	return cfg, nil
+}
+
+// TelemetryCombosResponse is the JSON structure for /api/telemetry/combos.
+type TelemetryCombosResponse struct {
+	Combos  []storage.TelemetryByCombo `json:"combos"`
+	HasMore bool                       `json:"has_more"`
+}
+
+// TelemetryCombos returns one page of arcana combos of the realm in the path prefix (the default realm
+// without one), filtered by minimum uses and sorted by uses, win rate or point swing. Requires admin role.
+func (h *Handler) TelemetryCombos(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "telemetry not available") {
+		return
+	}
+	realm, status := h.requestRealm(r, "")
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	bins, err := h.telemetryBinConfig(r)
 	if err != nil {
-		slog.Error("GetTelemetryMetrics", "tag", "api", "err", err)
-		http.Error(w, "failed to load metrics", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q := storage.TelemetryComboQuery{Bins: bins, SortBy: r.URL.Query().Get("sort")}
+	switch q.SortBy {
+	case "":
+		q.SortBy = storage.ComboSortUses
+	case storage.ComboSortUses, storage.ComboSortWinRate, storage.ComboSortSwing:
+	default:
+		http.Error(w, "sort must be uses, win_rate or swing", http.StatusBadRequest)
+		return
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("min_uses")); err == nil && n > 0 {
+		q.MinUses = n
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		q.Limit = n
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && n >= 0 {
+		q.Offset = n
+	}
+	var resp TelemetryCombosResponse
+	resp.Combos, resp.HasMore, err = h.HistoryStore.GetTopCombos(r.Context(), realm, q)
+	if err != nil {
+		slog.Error("GetTopCombos", "tag", "api", "err", err)
+		http.Error(w, "failed to load combos", http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(metrics); err != nil {
-		slog.Error("Encode telemetry response", "tag", "api", "err", err)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Encode combos response", "tag", "api", "err", err)
+	}
+}
+
+// IntegrityReportResponse is the JSON structure for /api/admin/integrity.
+type IntegrityReportResponse struct {
+	Flags []storage.IntegrityFlag `json:"flags"`
+	// BlindPlay lists accounts that find unseen pairs far more often than chance.
+	BlindPlay []storage.BlindPlayFlag `json:"blind_play"`
+	// SuspiciousTiming lists accounts with many flips rejected for coming faster than MIN_FLIP_SPACING_MS.
+	SuspiciousTiming []storage.SuspiciousTimingFlag `json:"suspicious_timing"`
+}
+
+// IntegrityReport returns pairs of accounts suspected of win trading, accounts whose blind guesses hit
+// far more often than chance, and accounts with many too-fast flips. Requires admin role.
+// Query: time_range (24h, 7d, 30d; default 30d), min_matches (default 5), min_guesses (default 30),
+// min_fast_flips (default 5).
+func (h *Handler) IntegrityReport(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "integrity report not available") {
+		return
+	}
+	cfg := storage.IntegrityReportConfig{TimeRange: r.URL.Query().Get("time_range")}
+	if cfg.TimeRange != "24h" && cfg.TimeRange != "7d" && cfg.TimeRange != "30d" {
+		cfg.TimeRange = "30d"
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("min_matches")); err == nil && n > 1 {
+		cfg.MinMatches = n
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("min_guesses")); err == nil && n > 0 {
+		cfg.MinBlindGuesses = n
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("min_fast_flips")); err == nil && n > 0 {
+		cfg.MinFastFlips = n
+	}
+	flags, err := h.HistoryStore.GetIntegrityReport(r.Context(), cfg)
+	if err != nil {
+		slog.Error("GetIntegrityReport", "tag", "api", "err", err)
+		http.Error(w, "failed to load integrity report", http.StatusInternalServerError)
+		return
+	}
+	blindPlay, err := h.HistoryStore.GetBlindPlayReport(r.Context(), cfg)
+	if err != nil {
+		slog.Error("GetBlindPlayReport", "tag", "api", "err", err)
+		http.Error(w, "failed to load integrity report", http.StatusInternalServerError)
+		return
+	}
+	timing, err := h.HistoryStore.GetSuspiciousTimingReport(r.Context(), cfg)
+	if err != nil {
+		slog.Error("GetSuspiciousTimingReport", "tag", "api", "err", err)
+		http.Error(w, "failed to load integrity report", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(IntegrityReportResponse{Flags: flags, BlindPlay: blindPlay, SuspiciousTiming: timing}); err != nil {
+		slog.Error("Encode integrity response", "tag", "api", "err", err)
+	}
+}
+
+// AnnouncementRequest is the JSON body for POST /api/admin/announcements.
+type AnnouncementRequest struct {
+	Message string `json:"message"`
+	Level   string `json:"level,omitempty"`
+	// SendAt schedules the announcement (RFC 3339); omitted or in the past sends it right away.
+	SendAt *time.Time `json:"send_at,omitempty"`
+}
+
+// AnnouncementsResponse is the JSON structure for GET /api/admin/announcements.
+type AnnouncementsResponse struct {
+	Pending []ws.Announcement `json:"pending"`
+}
+
+// Announcements lists pending announcements (GET) or sends or schedules a new one (POST). Requires admin role.
+func (h *Handler) Announcements(w http.ResponseWriter, r *http.Request) {
+	if CORSWithPost(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "announcements not available") {
+		return
+	}
+	if h.Announcer == nil {
+		http.Error(w, "announcements not available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodGet {
+		if err := json.NewEncoder(w).Encode(AnnouncementsResponse{Pending: h.Announcer.Pending()}); err != nil {
+			slog.Error("Encode announcements response", "tag", "api", "err", err)
+		}
+		return
+	}
+
+	var req AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	var sendAt time.Time
+	if req.SendAt != nil {
+		sendAt = *req.SendAt
+	}
+	ann, err := h.Announcer.Schedule(req.Message, req.Level, sendAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(ann); err != nil {
+		slog.Error("Encode announcement response", "tag", "api", "err", err)
+	}
+}
+
+// CancelAnnouncement cancels a scheduled announcement that has not been sent yet. Requires admin role.
+func (h *Handler) CancelAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if CORSWithPost(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "announcements not available") {
+		return
+	}
+	if h.Announcer == nil || !h.Announcer.Cancel(r.PathValue("id")) {
+		http.Error(w, "announcement not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DisconnectUserResponse is the JSON structure for POST /api/admin/users/{id}/disconnect.
+type DisconnectUserResponse struct {
+	Disconnected int `json:"disconnected"`
+}
+
+// DisconnectUser closes every WebSocket connection of a user, in every realm, with close code 4003 so
+// clients do not reconnect on their own. A game in progress gets the usual reconnection window.
+// Requires admin role.
+func (h *Handler) DisconnectUser(w http.ResponseWriter, r *http.Request) {
+	if CORSWithPost(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "disconnect not available") {
+		return
+	}
+	userID := r.PathValue("id")
+	n := 0
+	for _, hub := range h.Hubs {
+		n += hub.DisconnectUser(userID, ws.CloseKicked, "disconnected by an admin")
+	}
+	slog.Info("user disconnected by admin", "tag", "api", "user_id", userID, "connections", n)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(DisconnectUserResponse{Disconnected: n}); err != nil {
+		slog.Error("Encode disconnect response", "tag", "api", "err", err)
+	}
+}
+
+// DisplayNameSyncResponse is the JSON structure for POST /api/admin/display-names/sync.
+type DisplayNameSyncResponse struct {
+	Updated int64 `json:"updated"`
+}
+
+// SyncDisplayNames backfills leaderboard display names from Neon Auth right away (the same sync that
+// runs every DISPLAY_NAME_SYNC_SEC). Requires admin role.
+func (h *Handler) SyncDisplayNames(w http.ResponseWriter, r *http.Request) {
+	if CORSWithPost(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "display name sync not available") {
+		return
+	}
+	n, err := h.HistoryStore.SyncDisplayNamesFromAuth(r.Context())
+	if err != nil {
+		slog.Error("SyncDisplayNamesFromAuth", "tag", "api", "err", err)
+		http.Error(w, "failed to sync display names", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("display names backfilled", "tag", "api", "updated", n)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(DisplayNameSyncResponse{Updated: n}); err != nil {
+		slog.Error("Encode display name sync response", "tag", "api", "err", err)
+	}
+}
+
+// ArcanaStatsResponse is the JSON structure for /api/me/arcana-stats.
+type ArcanaStatsResponse struct {
+	Cards []storage.UserArcanaStats `json:"cards"`
+}
+
+// ArcanaStats returns the authenticated user's arcana usage per card in their realm.
+func (h *Handler) ArcanaStats(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, claimRealm := h.extractIdentity(r)
+	if userID == "" {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return
+	}
+	realm, status := h.requestRealm(r, claimRealm)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	resp := ArcanaStatsResponse{Cards: []storage.UserArcanaStats{}}
+	if h.HistoryStore != nil {
+		cards, err := h.HistoryStore.GetUserArcanaStats(r.Context(), realm, userID)
+		if err != nil {
+			slog.Error("GetUserArcanaStats", "tag", "api", "err", err)
+			http.Error(w, "failed to load arcana stats", http.StatusInternalServerError)
+			return
+		}
+		resp.Cards = cards
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Encode arcana stats response", "tag", "api", "err", err)
+	}
+}
+
+// Settings returns (GET) or replaces (POST, body storage.UserSettings) the authenticated user's settings.
+func (h *Handler) Settings(w http.ResponseWriter, r *http.Request) {
+	if CORSWithPost(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := h.extractUserID(r)
+	if userID == "" {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return
+	}
+	if h.HistoryStore == nil {
+		http.Error(w, "settings not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var settings storage.UserSettings
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "invalid settings", http.StatusBadRequest)
+			return
+		}
+		if err := h.HistoryStore.SaveUserSettings(r.Context(), userID, settings); err != nil {
+			slog.Error("SaveUserSettings", "tag", "api", "err", err)
+			http.Error(w, "failed to save settings", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var err error
+		settings, err = h.HistoryStore.GetUserSettings(r.Context(), userID)
+		if err != nil {
+			slog.Error("GetUserSettings", "tag", "api", "err", err)
+			http.Error(w, "failed to load settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(settings); err != nil {
+		slog.Error("Encode settings response", "tag", "api", "err", err)
+	}
+}
+
+// MatchSummary returns a shareable summary of a finished match (no auth; match IDs are unguessable UUIDs).
+// Path: /api/history/{id}/summary, or /realms/{realm}/api/history/{id}/summary for a match of another realm.
+// With ?format=svg it returns a scoreboard image instead of JSON.
+func (h *Handler) MatchSummary(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	realm, status := h.requestRealm(r, "")
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	matchID := r.PathValue("id")
+	if _, err := uuid.Parse(matchID); err != nil {
+		http.Error(w, "match not found", http.StatusNotFound)
+		return
+	}
+	var summary *storage.MatchSummary
+	if h.HistoryStore != nil {
+		var err error
+		summary, err = h.HistoryStore.GetMatchSummary(r.Context(), realm, matchID)
+		if err != nil {
+			slog.Error("GetMatchSummary", "tag", "api", "err", err)
+			http.Error(w, "failed to load match summary", http.StatusInternalServerError)
+			return
+		}
+	}
+	if summary == nil {
+		http.Error(w, "match not found", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(renderSummarySVG(summary))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		slog.Error("Encode match summary response", "tag", "api", "err", err)
+	}
+}
+
+// MatchReplay handles GET /api/replay/{id}: the ordered event stream of a recorded match, for playing it
+// back move by move. No JWT (match IDs are UUIDs); 404 when the match is unknown.
+func (h *Handler) MatchReplay(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	matchID := r.PathValue("id")
+	if _, err := uuid.Parse(matchID); err != nil {
+		http.Error(w, "match not found", http.StatusNotFound)
+		return
+	}
+	var replay *storage.MatchReplay
+	if h.HistoryStore != nil {
+		var err error
+		replay, err = h.HistoryStore.GetMatchReplay(r.Context(), matchID)
+		if err != nil {
+			slog.Error("GetMatchReplay", "tag", "api", "err", err)
+			http.Error(w, "failed to load match replay", http.StatusInternalServerError)
+			return
+		}
+	}
+	if replay == nil {
+		http.Error(w, "match not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(replay); err != nil {
+		slog.Error("Encode match replay response", "tag", "api", "err", err)
 	}
 }
 
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"memory-game-server/modes"
+)
+
+// ModesResponse is the JSON structure for /api/modes.
+type ModesResponse struct {
+	Modes []modes.Mode `json:"modes"`
+}
+
+// Modes lists the game modes a player can pick with set_name, with their rules (see package modes).
+func (h *Handler) Modes(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ModesResponse{Modes: modes.All()}); err != nil {
+		slog.Error("Encode modes response", "tag", "api", "err", err)
+	}
+}
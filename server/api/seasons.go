@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"memory-game-server/storage"
+)
+
+// SeasonsResponse is the JSON structure for /api/seasons.
+type SeasonsResponse struct {
+	// Current is the number of the open season; 0 when seasons are off.
+	Current int              `json:"current"`
+	Seasons []storage.Season `json:"seasons"`
+}
+
+// Seasons lists the realm's rating seasons, newest first. Ended seasons can be passed as season=N to
+// /api/leaderboard and /api/history.
+func (h *Handler) Seasons(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	_, claimRealm := h.extractIdentity(r)
+	realm, status := h.requestRealm(r, claimRealm)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	resp := SeasonsResponse{Seasons: []storage.Season{}}
+	if h.HistoryStore != nil {
+		var err error
+		resp.Seasons, err = h.HistoryStore.ListSeasons(r.Context(), realm)
+		if err != nil {
+			slog.Error("ListSeasons", "tag", "api", "err", err)
+			http.Error(w, "failed to load seasons", http.StatusInternalServerError)
+			return
+		}
+	}
+	for _, s := range resp.Seasons {
+		if s.EndedAt == "" {
+			resp.Current = s.Number
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Encode seasons response", "tag", "api", "err", err)
+	}
+}
+
+// seasonParam returns the season=N query parameter, or 0 (no season filter) when absent or invalid.
+func seasonParam(r *http.Request) int {
+	n, err := strconv.Atoi(r.URL.Query().Get("season"))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
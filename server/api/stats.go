@@ -0,0 +1,248 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"memory-game-server/ai"
+	"memory-game-server/matchmaking"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+// statsCacheTTL is how long /api/stats serves the same snapshot; the endpoint is public and polled
+// by landing pages, so counters are not read on every request.
+const statsCacheTTL = 10 * time.Second
+
+// StatsSource is one hub and the matchmaker behind it (the default realm or a configured realm).
+type StatsSource struct {
+	Hub        *ws.Hub
+	Matchmaker *matchmaking.Matchmaker
+}
+
+// StatsResponse is the response for GET /api/stats, summed over every realm.
+type StatsResponse struct {
+	PlayersOnline   int       `json:"players_online"`
+	GamesInProgress int       `json:"games_in_progress"`
+	GamesToday      int       `json:"games_today"`
+	AvgQueueWaitMS  int64     `json:"avg_queue_wait_ms"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type statsCache struct {
+	mu   sync.Mutex
+	resp *StatsResponse
+}
+
+// Stats handles GET /api/stats: public aggregate activity for a landing-page widget. No auth required.
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := h.currentStats(time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=10")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Encode stats response", "tag", "api", "err", err)
+	}
+}
+
+// currentStats returns the cached snapshot, refreshing it from the sources once it is older than statsCacheTTL.
+func (h *Handler) currentStats(now time.Time) StatsResponse {
+	h.statsCache.mu.Lock()
+	defer h.statsCache.mu.Unlock()
+	if c := h.statsCache.resp; c != nil && now.Sub(c.UpdatedAt) < statsCacheTTL {
+		return *c
+	}
+	resp := StatsResponse{UpdatedAt: now}
+	var waitTotal time.Duration
+	waitSamples := 0
+	for _, src := range h.StatsSources {
+		if src.Hub != nil {
+			resp.PlayersOnline += src.Hub.Connections()
+		}
+		if src.Matchmaker != nil {
+			st := src.Matchmaker.Stats()
+			resp.GamesInProgress += st.GamesInProgress
+			resp.GamesToday += st.GamesToday
+			waitTotal += st.AvgQueueWait * time.Duration(st.QueueWaitSamples)
+			waitSamples += st.QueueWaitSamples
+		}
+	}
+	if waitSamples > 0 {
+		resp.AvgQueueWaitMS = (waitTotal / time.Duration(waitSamples)).Milliseconds()
+	}
+	h.statsCache.resp = &resp
+	return resp
+}
+
+// PersistStepResponse is one end-of-game write step in GET /api/admin/persistence, summed over every realm.
+type PersistStepResponse struct {
+	Step         string `json:"step"`
+	Succeeded    int64  `json:"succeeded"`
+	Failed       int64  `json:"failed"`
+	Retries      int64  `json:"retries"`
+	AvgLatencyMS int64  `json:"avg_latency_ms"`
+	MaxLatencyMS int64  `json:"max_latency_ms"`
+}
+
+// TelemetryQueueResponse is the queued telemetry in GET /api/admin/persistence, summed over every realm.
+type TelemetryQueueResponse struct {
+	QueuedEvents   int   `json:"queued_events"`
+	QueuedMatches  int   `json:"queued_matches"`
+	EvictedMatches int64 `json:"evicted_matches"`
+	EvictedEvents  int64 `json:"evicted_events"`
+	DroppedFlushes int64 `json:"dropped_flushes"`
+}
+
+// PersistStats handles GET /api/admin/persistence: outcome counters of the writes made when a game ends
+// (ratings, history, arcana, latency, telemetry) and the telemetry still queued. Requires admin role.
+func (h *Handler) PersistStats(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "persistence stats not available") {
+		return
+	}
+
+	type total struct {
+		PersistStepResponse
+		latencyTotal time.Duration
+		maxLatency   time.Duration
+	}
+	byStep := make(map[string]*total)
+	var order []string
+	var queue TelemetryQueueResponse
+	for _, src := range h.StatsSources {
+		if src.Matchmaker == nil {
+			continue
+		}
+		q := src.Matchmaker.TelemetryQueueStats()
+		queue.QueuedEvents += q.QueuedEvents
+		queue.QueuedMatches += q.QueuedMatches
+		queue.EvictedMatches += q.EvictedMatches
+		queue.EvictedEvents += q.EvictedEvents
+		queue.DroppedFlushes += q.DroppedFlushes
+		for _, st := range src.Matchmaker.PersistStats() {
+			t := byStep[st.Step]
+			if t == nil {
+				t = &total{PersistStepResponse: PersistStepResponse{Step: st.Step}}
+				byStep[st.Step] = t
+				order = append(order, st.Step)
+			}
+			t.Succeeded += st.Succeeded
+			t.Failed += st.Failed
+			t.Retries += st.Retries
+			t.latencyTotal += st.AvgLatency * time.Duration(st.Succeeded)
+			t.maxLatency = max(t.maxLatency, st.MaxLatency)
+		}
+	}
+	sort.Strings(order)
+	steps := make([]PersistStepResponse, 0, len(order))
+	for _, step := range order {
+		t := byStep[step]
+		if t.Succeeded > 0 {
+			t.AvgLatencyMS = (t.latencyTotal / time.Duration(t.Succeeded)).Milliseconds()
+		}
+		t.MaxLatencyMS = t.maxLatency.Milliseconds()
+		steps = append(steps, t.PersistStepResponse)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"steps": steps, "telemetry_queue": queue}); err != nil {
+		slog.Error("Encode persistence stats response", "tag", "api", "err", err)
+	}
+}
+
+// AIPoolStatsResponse is the JSON structure for GET /api/admin/ai.
+type AIPoolStatsResponse struct {
+	Workers        int   `json:"workers"`
+	Queued         int   `json:"queued"`
+	Decisions      int64 `json:"decisions"`
+	OverBudget     int64 `json:"over_budget"`
+	AvgQueueWaitMS int64 `json:"avg_queue_wait_ms"`
+	MaxQueueWaitMS int64 `json:"max_queue_wait_ms"`
+	AvgComputeUS   int64 `json:"avg_compute_us"`
+	MaxComputeUS   int64 `json:"max_compute_us"`
+}
+
+// AIPoolStats handles GET /api/admin/ai: latency of the AI decisions computed since the server started
+// (time waiting for a worker of the shared pool, then computing). Requires admin role.
+func (h *Handler) AIPoolStats(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.requireAdmin(w, r, "AI stats not available") {
+		return
+	}
+
+	st := ai.SharedPool().Stats()
+	resp := AIPoolStatsResponse{
+		Workers:        st.Workers,
+		Queued:         st.Queued,
+		Decisions:      st.Decisions,
+		OverBudget:     st.OverBudget,
+		AvgQueueWaitMS: st.AvgQueueWait.Milliseconds(),
+		MaxQueueWaitMS: st.MaxQueueWait.Milliseconds(),
+		AvgComputeUS:   st.AvgCompute.Microseconds(),
+		MaxComputeUS:   st.MaxCompute.Microseconds(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Encode AI pool stats response", "tag", "api", "err", err)
+	}
+}
+
+// PlayerStats handles GET /api/stats/me: the authenticated user's aggregates in their realm (win rate
+// against humans and bots, average score, combo streaks, favorite arcana, game length and rating trend).
+func (h *Handler) PlayerStats(w http.ResponseWriter, r *http.Request) {
+	if CORS(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	userID, claimRealm := h.extractIdentity(r)
+	if userID == "" {
+		http.Error(w, "authorization required", http.StatusUnauthorized)
+		return
+	}
+	realm, status := h.requestRealm(r, claimRealm)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	resp := &storage.PlayerStats{EloTrend: []storage.EloPoint{}}
+	if h.HistoryStore != nil {
+		var err error
+		resp, err = h.HistoryStore.GetPlayerStats(r.Context(), realm, userID)
+		if err != nil {
+			slog.Error("GetPlayerStats", "tag", "api", "err", err)
+			http.Error(w, "failed to load stats", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Error("Encode player stats response", "tag", "api", "err", err)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"memory-game-server/storage"
+)
+
+// keyMomentLabels are the scoreboard captions for each key moment kind.
+var keyMomentLabels = map[string]string{
+	storage.KeyMomentBiggestCombo:   "Biggest combo",
+	storage.KeyMomentDecisiveArcana: "Decisive arcana",
+	storage.KeyMomentComeback:       "Comeback",
+}
+
+// renderSummarySVG draws a small scoreboard card for a match summary: both names and scores with the
+// winner highlighted, followed by one line per key moment.
+func renderSummarySVG(s *storage.MatchSummary) []byte {
+	const width, rowHeight, top = 480, 28, 120
+	height := top + rowHeight*len(s.KeyMoments) + 20
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`, width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" rx="12" fill="#1b1530"/>`, width, height)
+	for i, p := range s.Players {
+		x, anchor := 24, "start"
+		if i == 1 {
+			x, anchor = width-24, "end"
+		}
+		color := "#d8d0f0"
+		if s.WinnerIndex != nil && *s.WinnerIndex == i {
+			color = "#f5c542"
+		}
+		fmt.Fprintf(&b, `<text x="%d" y="40" text-anchor="%s" font-size="18" fill="%s">%s</text>`, x, anchor, color, html.EscapeString(p.Name))
+		fmt.Fprintf(&b, `<text x="%d" y="88" text-anchor="%s" font-size="44" font-weight="bold" fill="%s">%d</text>`, x, anchor, color, p.Score)
+	}
+	result := "Draw"
+	if s.WinnerIndex != nil && *s.WinnerIndex >= 0 && *s.WinnerIndex <= 1 {
+		result = s.Players[*s.WinnerIndex].Name + " wins"
+	}
+	fmt.Fprintf(&b, `<text x="%d" y="88" text-anchor="middle" font-size="14" fill="#a89fc8">%s</text>`, width/2, html.EscapeString(result))
+	for i, m := range s.KeyMoments {
+		label := keyMomentLabels[m.Kind]
+		if label == "" {
+			label = m.Kind
+		}
+		detail := fmt.Sprintf("%s: %s, round %d, %+d", label, s.Players[m.PlayerIdx].Name, m.Round, m.PointSwing)
+		if m.PowerUpID != "" {
+			detail += " (" + m.PowerUpID + ")"
+		}
+		fmt.Fprintf(&b, `<text x="24" y="%d" font-size="14" fill="#d8d0f0">%s</text>`, top+rowHeight*i, html.EscapeString(detail))
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
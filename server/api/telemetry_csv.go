@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"memory-game-server/storage"
+)
+
+// Tables of GET /api/telemetry/metrics?format=csv, chosen with the table query param.
+const (
+	telemetryTableByCard     = "by_card"
+	telemetryTableByCombo    = "by_combo"
+	telemetryTableHistograms = "histograms"
+)
+
+// validTelemetryTable reports whether table names a CSV table.
+func validTelemetryTable(table string) bool {
+	switch table {
+	case telemetryTableByCard, telemetryTableByCombo, telemetryTableHistograms:
+		return true
+	}
+	return false
+}
+
+// writeTelemetryCSV streams one table of metrics to w as CSV, row by row. The histograms table is in long
+// format: one row per bin of every card's and combo's turn and pairs histograms.
+func writeTelemetryCSV(w io.Writer, metrics *storage.TelemetryMetrics, table string) error {
+	cw := csv.NewWriter(w)
+	switch table {
+	case telemetryTableByCard:
+		cw.Write([]string{"power_up_id", "total_matches", "wins_with_card", "win_rate_pct", "use_count", "avg_point_swing_player", "avg_point_swing_opponent", "avg_pairs_matched_before", "avg_turn_at_use"})
+		for _, c := range metrics.ByCard {
+			cw.Write([]string{c.PowerUpID, strconv.Itoa(c.TotalMatches), strconv.Itoa(c.WinsWithCard), formatCSVFloat(c.WinRatePct), strconv.Itoa(c.UseCount),
+				formatCSVFloat(c.AvgPointSwingPlayer), formatCSVFloat(c.AvgPointSwingOpponent), formatCSVFloat(c.AvgPairsMatchedBefore), formatCSVFloat(c.AvgTurnAtUse)})
+		}
+	case telemetryTableByCombo:
+		cw.Write([]string{"combo_key", "card_count", "total_matches", "wins", "win_rate_pct", "avg_point_swing_player", "avg_point_swing_opponent", "avg_turn_at_use", "avg_pairs_matched_before"})
+		for _, c := range metrics.ByCombo {
+			cw.Write([]string{c.ComboKey, strconv.Itoa(c.CardCount), strconv.Itoa(c.TotalMatches), strconv.Itoa(c.Wins), formatCSVFloat(c.WinRatePct),
+				formatCSVFloat(c.AvgPointSwingPlayer), formatCSVFloat(c.AvgPointSwingOpponent), formatCSVFloat(c.AvgTurnAtUse), formatCSVFloat(c.AvgPairsMatchedBefore)})
+		}
+	case telemetryTableHistograms:
+		cw.Write([]string{"scope", "key", "histogram", "bin", "label", "count"})
+		writeBins := func(scope, key, histogram string, buckets []storage.TelemetryHistogramBucket) {
+			for i, b := range buckets {
+				cw.Write([]string{scope, key, histogram, strconv.Itoa(i), b.Label, strconv.Itoa(b.Count)})
+			}
+		}
+		for _, c := range metrics.ByCard {
+			writeBins("card", c.PowerUpID, "turn", c.TurnHistogram)
+			writeBins("card", c.PowerUpID, "pairs", c.PairsHistogram)
+		}
+		for _, c := range metrics.ByCombo {
+			writeBins("combo", c.ComboKey, "turn", c.TurnHistogram)
+			writeBins("combo", c.ComboKey, "pairs", c.PairsHistogram)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
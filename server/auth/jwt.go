@@ -65,3 +65,9 @@ func UserIDFromClaims(claims jwt.MapClaims) string {
 	}
 	return ""
 }
+
+// RealmFromClaims returns the "realm" claim (the community the account belongs to), or "" for the default realm.
+func RealmFromClaims(claims jwt.MapClaims) string {
+	realm, _ := claims["realm"].(string)
+	return strings.TrimSpace(realm)
+}
@@ -0,0 +1,178 @@
+// Package balance watches live per-card telemetry and alerts when the balance drifts after a config change.
+package balance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/storage"
+)
+
+// Alert kinds.
+const (
+	// AlertWinRateBounds: the card's win rate is outside [MinWinRatePct, MaxWinRatePct].
+	AlertWinRateBounds = "win_rate_out_of_bounds"
+	// AlertWinRateDrift: the card's win rate moved more than MaxWinRateDriftPct since the config change.
+	AlertWinRateDrift = "win_rate_drift"
+	// AlertUseShareDrift: the card's share of arcana uses moved more than MaxUseShareDriftPct since the config change.
+	AlertUseShareDrift = "use_share_drift"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// Source reads per-card balance for games played in [since, until). *storage.Store implements it.
+type Source interface {
+	GetCardBalance(ctx context.Context, since, until time.Time) ([]storage.CardBalance, error)
+}
+
+// Alert is one card whose balance crossed a threshold.
+type Alert struct {
+	Kind      string  `json:"kind"`
+	PowerUpID string  `json:"power_up_id"`
+	Value     float64 `json:"value"`              // current win rate or use share (%)
+	Baseline  float64 `json:"baseline,omitempty"` // same metric before the config change, for drift alerts
+	Threshold float64 `json:"threshold"`          // bound or tolerated drift that was crossed
+	Matches   int     `json:"matches"`            // players who used the card in the current window
+}
+
+// Analyzer periodically compares the balance of games played since the server started (the current
+// config, which is only read at startup) against the configured bounds and against the same window before
+// the start. Each alert is logged and posted to the webhook once; it fires again only after the card has
+// gone back within the threshold.
+type Analyzer struct {
+	Source    Source
+	Config    config.BalanceAlertsConfig
+	StartedAt time.Time
+	Client    *http.Client
+
+	active map[string]bool // kind + power-up ID of alerts already sent; only touched by Run
+}
+
+// NewAnalyzer creates an analyzer for games played since startedAt.
+func NewAnalyzer(source Source, cfg config.BalanceAlertsConfig, startedAt time.Time) *Analyzer {
+	return &Analyzer{
+		Source:    source,
+		Config:    cfg,
+		StartedAt: startedAt,
+		Client:    &http.Client{Timeout: webhookTimeout},
+		active:    make(map[string]bool),
+	}
+}
+
+// Run checks the balance every IntervalSec until ctx is cancelled. Should be run as a goroutine; returns
+// right away when IntervalSec is 0.
+func (a *Analyzer) Run(ctx context.Context) {
+	if a.Config.IntervalSec <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(a.Config.IntervalSec) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.check(ctx, time.Now()); err != nil {
+				slog.Error("balance check failed", "tag", "balance", "err", err)
+			}
+		}
+	}
+}
+
+// check reads both windows and sends the alerts that are new since the previous check.
+func (a *Analyzer) check(ctx context.Context, now time.Time) error {
+	window := time.Duration(a.Config.WindowHours) * time.Hour
+	since := a.StartedAt
+	if window > 0 && now.Add(-window).After(since) {
+		since = now.Add(-window)
+	}
+	current, err := a.Source.GetCardBalance(ctx, since, now)
+	if err != nil {
+		return err
+	}
+	baseline, err := a.Source.GetCardBalance(ctx, a.StartedAt.Add(-window), a.StartedAt)
+	if err != nil {
+		return err
+	}
+	firing := make(map[string]bool)
+	for _, alert := range Evaluate(current, baseline, a.Config) {
+		key := alert.Kind + ":" + alert.PowerUpID
+		firing[key] = true
+		if !a.active[key] {
+			a.send(ctx, alert)
+		}
+	}
+	a.active = firing
+	return nil
+}
+
+// Evaluate returns the alerts for the current window given the baseline window. Cards with fewer than
+// MinMatches players are not judged, on either side.
+func Evaluate(current, baseline []storage.CardBalance, cfg config.BalanceAlertsConfig) []Alert {
+	base := make(map[string]storage.CardBalance, len(baseline))
+	for _, c := range baseline {
+		base[c.PowerUpID] = c
+	}
+	var alerts []Alert
+	for _, c := range current {
+		if c.Users < max(cfg.MinMatches, 1) {
+			continue
+		}
+		if c.WinRatePct < float64(cfg.MinWinRatePct) {
+			alerts = append(alerts, Alert{Kind: AlertWinRateBounds, PowerUpID: c.PowerUpID, Value: c.WinRatePct, Threshold: float64(cfg.MinWinRatePct), Matches: c.Users})
+		} else if cfg.MaxWinRatePct > 0 && c.WinRatePct > float64(cfg.MaxWinRatePct) {
+			alerts = append(alerts, Alert{Kind: AlertWinRateBounds, PowerUpID: c.PowerUpID, Value: c.WinRatePct, Threshold: float64(cfg.MaxWinRatePct), Matches: c.Users})
+		}
+		b, ok := base[c.PowerUpID]
+		if !ok || b.Users < max(cfg.MinMatches, 1) {
+			continue
+		}
+		if cfg.MaxWinRateDriftPct > 0 && math.Abs(c.WinRatePct-b.WinRatePct) > float64(cfg.MaxWinRateDriftPct) {
+			alerts = append(alerts, Alert{Kind: AlertWinRateDrift, PowerUpID: c.PowerUpID, Value: c.WinRatePct, Baseline: b.WinRatePct, Threshold: float64(cfg.MaxWinRateDriftPct), Matches: c.Users})
+		}
+		if cfg.MaxUseShareDriftPct > 0 && math.Abs(c.UseSharePct-b.UseSharePct) > float64(cfg.MaxUseShareDriftPct) {
+			alerts = append(alerts, Alert{Kind: AlertUseShareDrift, PowerUpID: c.PowerUpID, Value: c.UseSharePct, Baseline: b.UseSharePct, Threshold: float64(cfg.MaxUseShareDriftPct), Matches: c.Users})
+		}
+	}
+	return alerts
+}
+
+// send logs the alert and posts it to the webhook, when one is configured.
+func (a *Analyzer) send(ctx context.Context, alert Alert) {
+	slog.Warn("balance alert", "tag", "balance", "kind", alert.Kind, "power_up_id", alert.PowerUpID,
+		"value", alert.Value, "baseline", alert.Baseline, "threshold", alert.Threshold, "matches", alert.Matches)
+	if a.Config.WebhookURL == "" {
+		return
+	}
+	if err := a.postWebhook(ctx, alert); err != nil {
+		slog.Error("balance alert webhook failed", "tag", "balance", "kind", alert.Kind, "power_up_id", alert.PowerUpID, "err", err)
+	}
+}
+
+func (a *Analyzer) postWebhook(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.Config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package balance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/storage"
+)
+
+func testAlertsConfig() config.BalanceAlertsConfig {
+	return config.BalanceAlertsConfig{
+		IntervalSec:         60,
+		WindowHours:         24,
+		MinMatches:          10,
+		MinWinRatePct:       35,
+		MaxWinRatePct:       65,
+		MaxWinRateDriftPct:  10,
+		MaxUseShareDriftPct: 10,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	current := []storage.CardBalance{
+		{PowerUpID: "chaos", Users: 20, WinRatePct: 70, UseSharePct: 30},
+		{PowerUpID: "leech", Users: 20, WinRatePct: 50, UseSharePct: 10},
+		{PowerUpID: "peek", Users: 5, WinRatePct: 90, UseSharePct: 60},
+	}
+	baseline := []storage.CardBalance{
+		{PowerUpID: "chaos", Users: 30, WinRatePct: 55, UseSharePct: 25},
+		{PowerUpID: "leech", Users: 30, WinRatePct: 48, UseSharePct: 25},
+	}
+	alerts := Evaluate(current, baseline, testAlertsConfig())
+
+	want := map[string]bool{
+		AlertWinRateBounds + ":chaos": true,
+		AlertWinRateDrift + ":chaos":  true,
+		AlertUseShareDrift + ":leech": true,
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("expected %d alerts, got %+v", len(want), alerts)
+	}
+	for _, a := range alerts {
+		if !want[a.Kind+":"+a.PowerUpID] {
+			t.Errorf("unexpected alert %+v", a)
+		}
+	}
+}
+
+type fakeSource struct {
+	current, baseline []storage.CardBalance
+	startedAt         time.Time
+}
+
+func (f *fakeSource) GetCardBalance(ctx context.Context, since, until time.Time) ([]storage.CardBalance, error) {
+	if until.Equal(f.startedAt) {
+		return f.baseline, nil
+	}
+	return f.current, nil
+}
+
+func TestAnalyzerCheck_SendsEachAlertOnce(t *testing.T) {
+	var mu sync.Mutex
+	var received []Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		json.NewDecoder(r.Body).Decode(&a)
+		mu.Lock()
+		received = append(received, a)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	startedAt := time.Now().Add(-time.Hour)
+	src := &fakeSource{current: []storage.CardBalance{{PowerUpID: "chaos", Users: 20, WinRatePct: 80}}, startedAt: startedAt}
+	cfg := testAlertsConfig()
+	cfg.WebhookURL = srv.URL
+	a := NewAnalyzer(src, cfg, startedAt)
+
+	for range 2 {
+		if err := a.check(context.Background(), time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(received) != 1 || received[0].Kind != AlertWinRateBounds || received[0].PowerUpID != "chaos" {
+		t.Fatalf("expected one chaos win rate alert, got %+v", received)
+	}
+
+	// Back within bounds, then out again: the alert fires a second time.
+	src.current = []storage.CardBalance{{PowerUpID: "chaos", Users: 20, WinRatePct: 50}}
+	a.check(context.Background(), time.Now())
+	src.current = []storage.CardBalance{{PowerUpID: "chaos", Users: 20, WinRatePct: 80}}
+	a.check(context.Background(), time.Now())
+	if len(received) != 2 {
+		t.Errorf("expected the alert to fire again after recovering, got %d alerts", len(received))
+	}
+}
@@ -0,0 +1,326 @@
+// Command soak runs the server's matchmaker, games, AI and persistence in-process under sustained load:
+// a fixed number of bots queue, play out their match with the AI and queue again, for hours if asked.
+// Every report interval it logs goroutines, heap, finished matches, AI decision latency and end-of-game
+// write throughput; at the end it shuts the server down the way main does and fails if goroutines did not
+// return to the baseline.
+//
+// Bots join the matchmaker directly (no WebSocket), so they are paired with each other or, after
+// AI_PAIR_TIMEOUT_SEC (0 by default here), with the server's AI. Set DATABASE_URL to a throwaway database
+// to include persistence: every match writes ratings and history for the bots' "soak-N" user IDs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+	"memory-game-server/ai"
+	"memory-game-server/config"
+	"memory-game-server/loghandler"
+	"memory-game-server/matchmaking"
+	"memory-game-server/powerup"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+// options are the soak run's command-line settings.
+type options struct {
+	Bots     int
+	Duration time.Duration
+	Report   time.Duration
+	// Fast cuts AI think delays and the mismatch reveal so matches take seconds instead of minutes.
+	Fast bool
+	// Grace bounds how long shutdown may take: draining the matchmaker, then goroutines winding down.
+	Grace time.Duration
+	// LeakSlack is how many goroutines above the baseline still count as a clean shutdown.
+	LeakSlack int
+}
+
+// result summarizes a soak run.
+type result struct {
+	Matches    int64
+	Goroutines int
+	Baseline   int
+	Leaked     bool
+}
+
+func main() {
+	slog.SetDefault(slog.New(loghandler.NewCompactHandler(os.Stderr, slog.LevelInfo)))
+	_ = godotenv.Load()
+
+	opts := options{}
+	flag.IntVar(&opts.Bots, "bots", 200, "number of bots playing at once")
+	flag.DurationVar(&opts.Duration, "duration", time.Hour, "how long to keep starting matches")
+	flag.DurationVar(&opts.Report, "report", 30*time.Second, "interval between stats reports")
+	flag.BoolVar(&opts.Fast, "fast", false, "remove AI think delays and shorten the mismatch reveal")
+	flag.DurationVar(&opts.Grace, "grace", 2*time.Minute, "max time for shutdown before reporting leaks")
+	flag.IntVar(&opts.LeakSlack, "leak-slack", 10, "goroutines above the baseline tolerated after shutdown")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("cannot start", "tag", "soak", "err", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(slog.New(loghandler.NewCompactHandler(os.Stderr, cfg.SlogLevel())))
+	if os.Getenv("AI_PAIR_TIMEOUT_SEC") == "" {
+		cfg.AIPairTimeoutSec = 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	res, err := run(ctx, cfg, opts)
+	if err != nil {
+		slog.Error("soak failed", "tag", "soak", "err", err)
+		os.Exit(1)
+	}
+	if res.Leaked {
+		os.Exit(1)
+	}
+}
+
+// run soaks until opts.Duration elapses or ctx is cancelled, then shuts down and checks for leaks.
+func run(ctx context.Context, cfg *config.Config, opts options) (result, error) {
+	if opts.Fast {
+		speedUp(cfg)
+	}
+	registry := powerup.NewRegistry()
+	powerup.RegisterAll(registry, &cfg.PowerUps)
+
+	historyStore, err := storage.NewStore(context.Background(), cfg.DatabaseURL)
+	if err != nil {
+		return result{}, fmt.Errorf("connect to database: %w", err)
+	}
+	if historyStore != nil {
+		defer historyStore.Close()
+	}
+
+	// Start the AI pool's workers before the baseline: they live as long as the process.
+	ai.SetWorkers(cfg.AIWorkers)
+	ai.SharedPool()
+	baseline := runtime.NumGoroutine()
+	serverCtx, cancelServer := context.WithCancel(context.Background())
+	defer cancelServer()
+	mm := matchmaking.NewMatchmaker(cfg, registry, historyStore)
+	go mm.Run(serverCtx)
+
+	slog.Info("soak started", "tag", "soak", "bots", opts.Bots, "duration", opts.Duration, "fast", opts.Fast,
+		"persistence", historyStore != nil, "baseline_goroutines", baseline)
+
+	botCtx, stopBots := context.WithTimeout(ctx, opts.Duration)
+	defer stopBots()
+	var matches atomic.Int64
+	var bots sync.WaitGroup
+	for i := range opts.Bots {
+		bots.Add(1)
+		go func() {
+			defer bots.Done()
+			playBot(botCtx, serverCtx, mm, cfg, i, &matches)
+		}()
+	}
+
+	rep := newReporter(mm, &matches, historyStore != nil)
+	ticker := time.NewTicker(opts.Report)
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-botCtx.Done():
+			break loop
+		case <-ticker.C:
+			rep.report()
+		}
+	}
+
+	// Shut down as main does while bots are mid-match: drain the matchmaker (games get ShutdownGraceSec to
+	// finish, then end as draws, and are recorded), stop it, then wait for the goroutines to wind down.
+	slog.Info("soak stopping", "tag", "soak", "games_in_progress", mm.Stats().GamesInProgress)
+	deadline := time.Now().Add(opts.Grace)
+	drainCtx, cancelDrain := context.WithDeadline(context.Background(), deadline)
+	defer cancelDrain()
+	if err := mm.Shutdown(drainCtx, time.Now().Add(time.Duration(cfg.ShutdownGraceSec)*time.Second)); err != nil {
+		slog.Error("matchmaker did not drain", "tag", "soak", "err", err)
+	}
+	cancelServer()
+	botsDone := make(chan struct{})
+	go func() {
+		bots.Wait()
+		close(botsDone)
+	}()
+	select {
+	case <-botsDone:
+	case <-time.After(time.Until(deadline)):
+		slog.Warn("bots still playing at the end of the grace period", "tag", "soak")
+	}
+	goroutines := runtime.NumGoroutine()
+	for goroutines > baseline+opts.LeakSlack && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		goroutines = runtime.NumGoroutine()
+	}
+	rep.report()
+
+	res := result{Matches: matches.Load(), Goroutines: goroutines, Baseline: baseline}
+	res.Leaked = goroutines > baseline+opts.LeakSlack
+	if res.Leaked {
+		slog.Error("goroutines leaked after shutdown", "tag", "soak", "goroutines", goroutines, "baseline", baseline, "slack", opts.LeakSlack)
+		buf := make([]byte, 1<<20)
+		fmt.Fprintf(os.Stderr, "%s\n", buf[:runtime.Stack(buf, true)])
+	}
+	slog.Info("soak finished", "tag", "soak", "matches", res.Matches, "goroutines", goroutines, "baseline", baseline, "leaked", res.Leaked)
+	return res, nil
+}
+
+// speedUp removes the AI's human-like pauses and shortens the mismatch reveal.
+func speedUp(cfg *config.Config) {
+	for i := range cfg.AIProfiles {
+		p := &cfg.AIProfiles[i]
+		p.DelayMinMS, p.DelayMaxMS = 0, 10
+		p.SecondFlipDelayMinMS, p.SecondFlipDelayMaxMS = 0, 10
+		p.ThinkMaxExtraMS = 0
+	}
+	cfg.RevealDurationMS = 50
+}
+
+// playBot queues bot i and plays its matches with an AI profile until botCtx is done. A match in
+// progress is always played to game_over; a shutdown only abandons the wait in queue.
+func playBot(botCtx, serverCtx context.Context, mm *matchmaking.Matchmaker, cfg *config.Config, i int, matches *atomic.Int64) {
+	profile := &cfg.AIProfiles[i%len(cfg.AIProfiles)]
+	for botCtx.Err() == nil {
+		c := &ws.Client{
+			Send:   make(chan []byte, 256),
+			Name:   fmt.Sprintf("Soak %d", i),
+			UserID: fmt.Sprintf("soak-%d", i),
+		}
+		mm.Enqueue(c)
+		if !waitForMatch(serverCtx, c) {
+			mm.LeaveQueue(c)
+			return
+		}
+		g := c.Game
+		mm.SignalHumanReady(g.ID)
+		ready := make(chan struct{})
+		close(ready)
+		ai.Run(untilDone(c.Send, g.Done), g, c.PlayerID, profile, ready)
+		matches.Add(1)
+	}
+}
+
+// untilDone forwards the bot's messages to a channel that is closed once the game is over, so ai.Run
+// returns even if game_over was dropped on a full channel. Send itself stays open: the matchmaker may
+// still send to it (rating_update).
+func untilDone(send <-chan []byte, done <-chan struct{}) <-chan []byte {
+	out := make(chan []byte, cap(send))
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case data := <-send:
+				select {
+				case out <- data:
+				default:
+				}
+			case <-done:
+				for {
+					select {
+					case data := <-send:
+						select {
+						case out <- data:
+						default:
+						}
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// waitForMatch reads the bot's messages until match_found; false if the server shuts down first.
+func waitForMatch(serverCtx context.Context, c *ws.Client) bool {
+	for {
+		select {
+		case <-serverCtx.Done():
+			return false
+		case data := <-c.Send:
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(data, &msg) != nil {
+				continue
+			}
+			switch msg.Type {
+			case "match_found":
+				return true
+			case "server_shutdown":
+				return false
+			}
+		}
+	}
+}
+
+// reporter logs load and throughput since the previous report.
+type reporter struct {
+	mm      *matchmaking.Matchmaker
+	matches *atomic.Int64
+	start   time.Time
+	// persisting is false without DATABASE_URL; the end-of-game writes are then no-ops and not reported.
+	persisting bool
+
+	last        time.Time
+	lastMatches int64
+	lastWrites  int64
+}
+
+func newReporter(mm *matchmaking.Matchmaker, matches *atomic.Int64, persisting bool) *reporter {
+	now := time.Now()
+	return &reporter{mm: mm, matches: matches, start: now, persisting: persisting, last: now}
+}
+
+func (r *reporter) report() {
+	now := time.Now()
+	elapsed := now.Sub(r.last).Seconds()
+	var writes, failed, retries int64
+	for _, st := range r.mm.PersistStats() {
+		writes += st.Succeeded
+		failed += st.Failed
+		retries += st.Retries
+	}
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	matches := r.matches.Load()
+	args := []any{"tag", "soak",
+		"uptime", now.Sub(r.start).Round(time.Second),
+		"goroutines", runtime.NumGoroutine(),
+		"heap_mb", mem.HeapAlloc >> 20, "sys_mb", mem.Sys >> 20, "num_gc", mem.NumGC,
+		"games_in_progress", r.mm.Stats().GamesInProgress,
+		"matches", matches, "matches_per_min", perSecond(60*(matches-r.lastMatches), elapsed)}
+	pool := ai.SharedPool().Stats()
+	args = append(args, "ai_decisions", pool.Decisions, "ai_queued", pool.Queued, "ai_over_budget", pool.OverBudget,
+		"ai_avg_wait", pool.AvgQueueWait, "ai_max_wait", pool.MaxQueueWait, "ai_avg_compute", pool.AvgCompute, "ai_max_compute", pool.MaxCompute)
+	if r.persisting {
+		args = append(args, "db_writes", writes, "db_writes_per_sec", perSecond(writes-r.lastWrites, elapsed),
+			"db_failed", failed, "db_retries", retries)
+	}
+	slog.Info("soak stats", args...)
+	r.last, r.lastMatches, r.lastWrites = now, matches, writes
+}
+
+func perSecond(n int64, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return math.Round(float64(n)/seconds*10) / 10
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+)
+
+func TestRun_ShortSoakShutsDownCleanly(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.AIPairTimeoutSec = 0
+	cfg.ShutdownGraceSec = 1
+	res, err := run(context.Background(), cfg, options{Bots: 4, Duration: 2 * time.Second, Report: time.Second, Fast: true, Grace: 20 * time.Second, LeakSlack: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matches == 0 {
+		t.Error("no match finished")
+	}
+	if res.Leaked {
+		t.Errorf("goroutines after shutdown = %d, baseline %d", res.Goroutines, res.Baseline)
+	}
+}
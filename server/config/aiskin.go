@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AISkin renames an AI profile within a realm, to localize it or fit the realm's theme. The bot keeps its
+// user ID ("ai:" + the profile's ID or original name), so its rating and history are the same profile's.
+type AISkin struct {
+	// Name replaces the profile name: shown on the realm's leaderboard, and in matches when the profile has
+	// no identities.
+	Name string `json:"name,omitempty"`
+	// Identities replaces the profile's identity pool (names and avatars played under, one per match).
+	Identities []AIIdentity `json:"identities,omitempty"`
+}
+
+// skinAIProfiles returns a copy of profiles with the skins applied. Skins are keyed by the profile's
+// original name or ID, case-insensitive. No profiles means the default ones, as everywhere else.
+func skinAIProfiles(profiles []AIParams, skins map[string]AISkin) []AIParams {
+	if len(profiles) == 0 {
+		profiles = Defaults().AIProfiles
+	}
+	out := make([]AIParams, len(profiles))
+	copy(out, profiles)
+	for i := range out {
+		skin, ok := findAISkin(&out[i], skins)
+		if !ok {
+			continue
+		}
+		if out[i].ID == "" {
+			out[i].ID = out[i].Name // pin the user ID before the name changes
+		}
+		if skin.Name != "" {
+			out[i].Name = skin.Name
+		}
+		if len(skin.Identities) > 0 {
+			out[i].Identities = skin.Identities
+		}
+	}
+	return out
+}
+
+func findAISkin(p *AIParams, skins map[string]AISkin) (AISkin, bool) {
+	for key, skin := range skins {
+		if strings.EqualFold(key, p.Name) || (p.ID != "" && strings.EqualFold(key, p.ID)) {
+			return skin, true
+		}
+	}
+	return AISkin{}, false
+}
+
+// validateAISkins rejects skins that name no configured AI profile, which would silently leave the bot
+// under its original name.
+func validateAISkins(profiles []AIParams, skins map[string]AISkin) error {
+	if len(profiles) == 0 {
+		profiles = Defaults().AIProfiles
+	}
+	keys := make([]string, 0, len(skins))
+	for key := range skins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		found := false
+		for i := range profiles {
+			if _, ok := findAISkin(&profiles[i], map[string]AISkin{key: skins[key]}); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("ai_skins: unknown AI profile %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// ArcanaPairsPerMatch is the number of board pairs that grant power-ups in each match.
+const ArcanaPairsPerMatch = 6
+
+// Normal pairs get one of BoardElements elements (fire, water, air, earth), in groups of
+// PairsPerElementGroup consecutive pairs per element.
+const (
+	BoardElements        = 4
+	PairsPerElementGroup = 3
+)
+
+// NormalPairsNeeded returns how many normal pairs a board needs so that every element has at least
+// minPairsPerElement of them, given how pairs are grouped by element.
+func NormalPairsNeeded(minPairsPerElement int) int {
+	if minPairsPerElement <= 0 {
+		return 0
+	}
+	counts := make([]int, BoardElements)
+	n := 0
+	for {
+		done := true
+		for _, c := range counts {
+			if c < minPairsPerElement {
+				done = false
+			}
+		}
+		if done {
+			return n
+		}
+		counts[(n/PairsPerElementGroup)%BoardElements]++
+		n++
+	}
+}
+
+// ValidateBoard checks a board size: positive rows and cols and an even card count. With
+// minPairsPerElement > 0 the board must also hold the ArcanaPairsPerMatch arcana pairs plus
+// minPairsPerElement normal pairs of every element; 0 allows tiny boards (tests, local dev).
+func ValidateBoard(rows, cols, minPairsPerElement int) error {
+	if rows <= 0 || cols <= 0 {
+		return fmt.Errorf("board %dx%d: rows and cols must be positive", rows, cols)
+	}
+	if rows*cols%2 != 0 {
+		return fmt.Errorf("board %dx%d: %d cards cannot form pairs (rows*cols must be even)", rows, cols, rows*cols)
+	}
+	if minPairsPerElement <= 0 {
+		return nil
+	}
+	need := ArcanaPairsPerMatch + NormalPairsNeeded(minPairsPerElement)
+	if pairs := rows * cols / 2; pairs < need {
+		return fmt.Errorf("board %dx%d: %d pairs, need at least %d (%d arcana + %d per element)",
+			rows, cols, pairs, need, ArcanaPairsPerMatch, minPairsPerElement)
+	}
+	return nil
+}
+
+// BoardSizeChoices are the square board sizes (side length) a client may request per match; the server
+// offers those listed in Config.BoardSizes.
+var BoardSizeChoices = []int{4, 6, 8}
+
+// OffersBoardSize reports whether players may request an n x n board (see BoardSizes).
+func (c *Config) OffersBoardSize(n int) bool {
+	return slices.Contains(c.BoardSizes, n)
+}
+
+// Validate checks every board the config can deal: the server-wide board, each realm's board, the
+// selectable board sizes and the raid board. It also rejects timers and AI profiles that contradict
+// themselves (validateTiming, validateAIProfiles), realm AI skins for unknown profiles, unknown chat
+// modes, season settings out of range, experiments without a unique ID and settings the environment profile requires.
+func (c *Config) Validate() error {
+	if err := ValidateBoard(c.BoardRows, c.BoardCols, c.MinPairsPerElement); err != nil {
+		return err
+	}
+	for _, n := range c.BoardSizes {
+		if !slices.Contains(BoardSizeChoices, n) {
+			return fmt.Errorf("board size %d: must be one of %v", n, BoardSizeChoices)
+		}
+		if err := ValidateBoard(n, n, c.MinPairsPerElement); err != nil {
+			return fmt.Errorf("board sizes: %w", err)
+		}
+	}
+	names := make([]string, 0, len(c.Realms))
+	for name := range c.Realms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rc, _ := c.ForRealm(name)
+		if err := ValidateBoard(rc.BoardRows, rc.BoardCols, c.MinPairsPerElement); err != nil {
+			return fmt.Errorf("realm %q: %w", name, err)
+		}
+		if err := rc.validateTiming(); err != nil {
+			return fmt.Errorf("realm %q: %w", name, err)
+		}
+		if err := validateAISkins(c.AIProfiles, c.Realms[name].AISkins); err != nil {
+			return fmt.Errorf("realm %q: %w", name, err)
+		}
+		if err := rc.Chat.validate(); err != nil {
+			return fmt.Errorf("realm %q: %w", name, err)
+		}
+	}
+	if err := ValidateBoard(c.Raid.BoardRows, c.Raid.BoardCols, c.MinPairsPerElement); err != nil {
+		return fmt.Errorf("raid: %w", err)
+	}
+	if c.Raid.PeekTiles > c.Raid.BoardRows*c.Raid.BoardCols {
+		return fmt.Errorf("raid: peek_tiles %d exceeds the %d cards of the board", c.Raid.PeekTiles, c.Raid.BoardRows*c.Raid.BoardCols)
+	}
+	if err := c.validateTiming(); err != nil {
+		return err
+	}
+	if err := validateAIProfiles(c.AIProfiles, c.MinFlipSpacingMS); err != nil {
+		return err
+	}
+	if err := c.Chat.validate(); err != nil {
+		return err
+	}
+	if err := c.Seasons.validate(); err != nil {
+		return err
+	}
+	if err := validateExperiments(c.Experiments); err != nil {
+		return err
+	}
+	return c.validateProfile()
+}
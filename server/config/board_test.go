@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestValidateBoard(t *testing.T) {
+	tests := []struct {
+		rows, cols, minPerElement int
+		wantErr                   bool
+	}{
+		{6, 6, 1, false},
+		{4, 8, 1, false}, // 16 pairs: 6 arcana + 10 normal reach every element
+		{4, 6, 1, true},  // 12 pairs
+		{5, 5, 0, true},  // odd card count
+		{0, 4, 0, true},  // empty board
+		{2, 2, 0, false}, // tiny boards are fine without element constraints
+		{4, 8, 2, true},  // 10 normal pairs leave earth one short
+		{6, 8, 2, false}, // 24 pairs: 6 arcana + 18 normal
+	}
+	for _, tt := range tests {
+		err := ValidateBoard(tt.rows, tt.cols, tt.minPerElement)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateBoard(%d, %d, %d) = %v, want error %v", tt.rows, tt.cols, tt.minPerElement, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalPairsNeeded(t *testing.T) {
+	for min, want := range map[int]int{0: 0, 1: 10, 2: 11} {
+		if got := NormalPairsNeeded(min); got != want {
+			t.Errorf("NormalPairsNeeded(%d) = %d, want %d", min, got, want)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidBoard(t *testing.T) {
+	os.Setenv("BOARD_ROWS", "5")
+	os.Setenv("BOARD_COLS", "5")
+	defer func() {
+		os.Unsetenv("BOARD_ROWS")
+		os.Unsetenv("BOARD_COLS")
+	}()
+
+	if _, err := Load(); err == nil {
+		t.Error("expected Load to reject a 5x5 board")
+	}
+}
+
+func TestValidateRejectsDuplicateExperimentIDs(t *testing.T) {
+	cfg := Defaults()
+	cfg.Experiments = []ExperimentConfig{{ID: "fun", Active: true, Question: "Fun?"}, {ID: "fun"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a duplicate experiment id to be rejected")
+	}
+	cfg.Experiments = []ExperimentConfig{{Active: true, Question: "Fun?"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an experiment without id to be rejected")
+	}
+	cfg.Experiments = []ExperimentConfig{{ID: "fun", Active: true, Question: "Fun?"}, {ID: "quiet", Active: true}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if polls := cfg.ActivePolls(); len(polls) != 1 || polls[0].ID != "fun" {
+		t.Errorf("expected only the experiment with a question to poll, got %+v", polls)
+	}
+}
+
+func TestValidateBoardSizes(t *testing.T) {
+	cfg := Defaults()
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.BoardSizes = []int{4}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a 4x4 board to be rejected while every element needs a pair")
+	}
+	cfg.MinPairsPerElement = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a 4x4 board without the element minimum, got %v", err)
+	}
+	cfg.BoardSizes = []int{10}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a size outside BoardSizeChoices to be rejected")
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Chat modes.
+const (
+	// ChatModeFree accepts any text within the length and rate limits.
+	ChatModeFree = "free"
+	// ChatModeRestricted accepts only the preset phrases and emoji.
+	ChatModeRestricted = "restricted"
+)
+
+// DefaultChatPhrases is the preset catalog used when the config does not list its own.
+var DefaultChatPhrases = []string{
+	"Hi!",
+	"Good luck!",
+	"Have fun!",
+	"Nice one!",
+	"Oops!",
+	"So close!",
+	"Well played!",
+	"Good game!",
+	"Thanks!",
+	"Rematch?",
+}
+
+// emojiRanges are the code points accepted as emoji in restricted chat: pictographs, symbols and dingbats,
+// plus the joiners and modifiers that build emoji sequences (ZWJ, variation selector, keycap, tags).
+// Enclosed letters are left out on purpose, since they would let free text through.
+var emojiRanges = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x200d, Hi: 0x200d, Stride: 1},
+		{Lo: 0x20e3, Hi: 0x20e3, Stride: 1},
+		{Lo: 0x2300, Hi: 0x23ff, Stride: 1},
+		{Lo: 0x2600, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b00, Hi: 0x2bff, Stride: 1},
+		{Lo: 0xfe0f, Hi: 0xfe0f, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f1e6, Hi: 0x1f1ff, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1faff, Stride: 1},
+		{Lo: 0xe0020, Hi: 0xe007f, Stride: 1},
+	},
+}
+
+// Restricted reports whether chat only accepts the preset phrases and emoji.
+func (c ChatConfig) Restricted() bool {
+	return c.Mode == ChatModeRestricted
+}
+
+// Allows reports whether a trimmed chat line may be sent in this mode: any line in free mode; in
+// restricted mode, one of Phrases exactly or a line made only of emoji and spaces.
+func (c ChatConfig) Allows(text string) bool {
+	if !c.Restricted() {
+		return true
+	}
+	if slices.Contains(c.Phrases, text) {
+		return true
+	}
+	return strings.TrimSpace(text) != "" && strings.IndexFunc(text, func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.Is(emojiRanges, r)
+	}) < 0
+}
+
+// validate rejects an unknown chat mode.
+func (c ChatConfig) validate() error {
+	if c.Mode != "" && c.Mode != ChatModeFree && c.Mode != ChatModeRestricted {
+		return fmt.Errorf("chat mode %q: must be %q or %q", c.Mode, ChatModeFree, ChatModeRestricted)
+	}
+	return nil
+}
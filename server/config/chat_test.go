@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestChatAllows(t *testing.T) {
+	free := ChatConfig{Phrases: DefaultChatPhrases}
+	restricted := ChatConfig{Mode: ChatModeRestricted, Phrases: DefaultChatPhrases}
+	tests := []struct {
+		text       string
+		restricted bool
+	}{
+		{"Good game!", true},
+		{"good game!", false},
+		{"👍", true},
+		{"🎉 🎉", true},
+		{"👍🏽", true},
+		{"👨‍👩‍👧", true},
+		{"❤️", true},
+		{"🇧🇷", true},
+		{"hello 👋", false},
+		{"Ⓗⓘ", false},
+		{"123", false},
+	}
+	for _, tt := range tests {
+		if !free.Allows(tt.text) {
+			t.Errorf("free mode: expected %q to be allowed", tt.text)
+		}
+		if got := restricted.Allows(tt.text); got != tt.restricted {
+			t.Errorf("restricted mode: Allows(%q) = %v, want %v", tt.text, got, tt.restricted)
+		}
+	}
+}
+
+func TestForRealm_ChatMode(t *testing.T) {
+	cfg := Defaults()
+	mode := ChatModeRestricted
+	cfg.Realms = map[string]RealmConfig{
+		"kids": {ChatMode: &mode, ChatPhrases: []string{"Oi!", "Boa sorte!"}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	realm, _ := cfg.ForRealm("kids")
+	if !realm.Chat.Restricted() || !realm.Chat.Allows("Boa sorte!") || realm.Chat.Allows("Good luck!") {
+		t.Errorf("expected restricted chat with the realm's phrases, got %+v", realm.Chat)
+	}
+	if cfg.Chat.Restricted() {
+		t.Error("expected the server chat to stay free")
+	}
+
+	bad := "silent"
+	cfg.Realms["kids"] = RealmConfig{ChatMode: &bad}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unknown chat mode to be rejected")
+	}
+}
@@ -2,12 +2,22 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"os"
 	"strconv"
 	"strings"
 )
 
+// AIUserIDPrefix marks bot user IDs in ratings, history and the leaderboard.
+const AIUserIDPrefix = "ai:"
+
+// AIIdentity is a name (and optional avatar) an AI profile can appear under in a match.
+type AIIdentity struct {
+	Name   string `json:"name"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
 // AIParams holds the parameters for one AI profile (name and behavior).
 type AIParams struct {
 	Name              string `json:"name"`
@@ -16,6 +26,58 @@ type AIParams struct {
 	UseBestMoveChance int    `json:"use_best_move_chance"` // 0-100, probability to use best move (known pair, highlight, element, or reveal unseen); on fail, random move
 	ForgetChance      int    `json:"forget_chance"`        // 0-100, probability to forget (delete from memory) a known card each turn
 	ArcanaRandomness  int    `json:"arcana_randomness"`    // 0-100, probability to randomize arcana use decision (avoids robotic play)
+
+	// SecondFlipDelayMinMS/MaxMS is the pause between the first and second flip; 0/0 reuses DelayMinMS/DelayMaxMS.
+	SecondFlipDelayMinMS int `json:"second_flip_delay_min_ms"`
+	SecondFlipDelayMaxMS int `json:"second_flip_delay_max_ms"`
+	// ThinkMaxExtraMS is the extra pause for close calls: added in full when the EV margin of the chosen
+	// move is 0 and scaled down as the margin grows (a sure pair adds almost nothing). 0 disables.
+	ThinkMaxExtraMS int `json:"think_max_extra_ms"`
+	// NeverResign keeps the AI playing hopeless positions to the end (by default it resigns when it cannot catch up).
+	NeverResign bool `json:"never_resign"`
+
+	// ID keys the bot's stable user ID ("ai:" + ID) for ratings and the leaderboard; empty uses Name.
+	ID string `json:"id,omitempty"`
+	// Difficulty is a label shown to players (e.g. "easy", "hard"); the leaderboard badges the bot with it.
+	Difficulty string `json:"difficulty,omitempty"`
+	// Identities is the pool of names/avatars the bot plays under, one picked per match; empty plays as Name.
+	Identities []AIIdentity `json:"identities,omitempty"`
+
+	// SearchDepth 2 makes arcana decisions look one opponent turn ahead (use now or save for next turn);
+	// 0 or 1 decides on the current turn alone.
+	SearchDepth int `json:"search_depth,omitempty"`
+	// SearchBudgetMS caps the time spent looking ahead per decision; over budget the current-turn decision
+	// stands. 0 uses the AI's default budget.
+	SearchBudgetMS int `json:"search_budget_ms,omitempty"`
+
+	// Adaptive lets the bot ease up while it leads: ForgetChance rises and UseBestMoveChance falls with the
+	// lead, by up to AdaptiveMaxShift percentage points (0 uses the AI's default), and return as the gap
+	// closes. Matches against an adaptive bot are flagged is_adaptive in history.
+	Adaptive         bool `json:"adaptive,omitempty"`
+	AdaptiveMaxShift int  `json:"adaptive_max_shift,omitempty"`
+}
+
+// UserID returns the bot's stable user ID. It does not depend on the identity picked for a match, so
+// ratings stay with the profile whatever name it plays under.
+func (p *AIParams) UserID() string {
+	if p.ID != "" {
+		return AIUserIDPrefix + p.ID
+	}
+	return AIUserIDPrefix + p.Name
+}
+
+// AIProfileByUserID returns the configured (or default) AI profile whose UserID is userID, or nil.
+func (c *Config) AIProfileByUserID(userID string) *AIParams {
+	profiles := c.AIProfiles
+	if len(profiles) == 0 {
+		profiles = Defaults().AIProfiles
+	}
+	for i := range profiles {
+		if profiles[i].UserID() == userID {
+			return &profiles[i]
+		}
+	}
+	return nil
 }
 
 // ChaosPowerUpConfig holds configuration for the Chaos power-up.
@@ -29,10 +91,17 @@ type ClairvoyancePowerUpConfig struct {
 	RevealDurationMS int `json:"reveal_duration_ms"`
 }
 
-// PowerUpsConfig holds per-power-up configuration sections.
+// PeekPowerUpConfig holds configuration for Peek, a basic power-up bought with score points.
+type PeekPowerUpConfig struct {
+	Cost int `json:"cost"`
+}
+
+// PowerUpsConfig holds per-power-up configuration sections. A Cost above 0 puts the power-up up for sale:
+// a player without a usable copy may buy one use for that many score points.
 type PowerUpsConfig struct {
 	Chaos        ChaosPowerUpConfig        `json:"chaos"`
 	Clairvoyance ClairvoyancePowerUpConfig `json:"clairvoyance"`
+	Peek         PeekPowerUpConfig         `json:"peek"`
 }
 
 // TelemetryHistogramConfig holds bin settings for telemetry histograms (turn and pairs at card use).
@@ -43,6 +112,111 @@ type TelemetryHistogramConfig struct {
 	PairsNumBins int `json:"pairs_num_bins"` // e.g. 6 equal bins in [0,PairsMax]
 }
 
+// BalanceAlertsConfig holds the thresholds of the balance analyzer, which compares per-card win rates and
+// use shares of games played since the server started (the current config) against fixed bounds and
+// against the same window before the start (the previous config).
+type BalanceAlertsConfig struct {
+	IntervalSec int `json:"interval_sec"` // how often to check; 0 = analyzer off
+	WindowHours int `json:"window_hours"` // rolling window on each side of the server start
+	MinMatches  int `json:"min_matches"`  // players who used the card needed before it is judged (per window)
+	// MinWinRatePct and MaxWinRatePct bound the win rate of the players who used a card.
+	MinWinRatePct int `json:"min_win_rate_pct"`
+	MaxWinRatePct int `json:"max_win_rate_pct"`
+	// MaxWinRateDriftPct and MaxUseShareDriftPct are the largest moves (percentage points) tolerated
+	// against the window before the start.
+	MaxWinRateDriftPct  int    `json:"max_win_rate_drift_pct"`
+	MaxUseShareDriftPct int    `json:"max_use_share_drift_pct"`
+	WebhookURL          string `json:"webhook_url"` // alerts are POSTed here as JSON besides being logged; empty = log only
+}
+
+// SeasonsConfig sets up rating seasons: every LengthDays each realm's ratings are archived and squashed
+// toward the initial rating, and a new season starts.
+type SeasonsConfig struct {
+	LengthDays int `json:"length_days"` // season length; 0 = no seasons (ratings never reset)
+	// CarryOverPct is how much of a rating's distance from the initial rating is kept at the reset: 50
+	// halves it, 0 resets everyone, 100 keeps ratings and only clears wins, losses and draws.
+	CarryOverPct int `json:"carry_over_pct"`
+}
+
+// validate rejects a negative season length and a carry-over outside 0-100.
+func (s SeasonsConfig) validate() error {
+	if s.LengthDays < 0 {
+		return fmt.Errorf("seasons length_days %d: must not be negative", s.LengthDays)
+	}
+	if s.CarryOverPct < 0 || s.CarryOverPct > 100 {
+		return fmt.Errorf("seasons carry_over_pct %d: must be between 0 and 100", s.CarryOverPct)
+	}
+	return nil
+}
+
+// ConnectionQualityConfig decides when an in-game connection counts as unstable (degraded but still
+// connected) and how much extra turn time an unstable player gets. A zero threshold disables that check.
+type ConnectionQualityConfig struct {
+	MissedPongs    int `json:"missed_pongs"`     // consecutive unanswered pings
+	RTTThresholdMS int `json:"rtt_threshold_ms"` // smoothed round-trip time
+	// TurnExtensionSec is added once per turn to the turn of an unstable player (0 = no extension).
+	TurnExtensionSec int `json:"turn_extension_sec"`
+}
+
+// ChatConfig configures in-game chat between the two seats of a match.
+type ChatConfig struct {
+	// Disabled turns chat off server-wide; chat messages are then rejected.
+	Disabled bool `json:"disabled"`
+	// MaxLength is the longest chat line accepted, in characters.
+	MaxLength int `json:"max_length"`
+	// MaxMessages is how many lines a connection may send per WindowSec; more are rejected until the
+	// window moves on.
+	MaxMessages int `json:"max_messages"`
+	WindowSec   int `json:"window_sec"`
+	// Mode is ChatModeFree (default) or ChatModeRestricted: only the lines in Phrases and emoji are
+	// accepted, for younger audiences.
+	Mode string `json:"mode"`
+	// Phrases is the catalog of preset lines offered in restricted mode, in display order.
+	Phrases []string `json:"phrases"`
+}
+
+// ArcanaPlacementConfig holds optional rules for where arcana cards may land when a board is dealt, so
+// one lucky region cannot yield several arcana. Rules are best effort: a board too small to satisfy them
+// keeps the cards that do not fit where they were shuffled.
+type ArcanaPlacementConfig struct {
+	// NoAdjacent keeps arcana cards from being orthogonally adjacent to each other.
+	NoAdjacent bool `json:"no_adjacent"`
+	// SpreadQuadrants deals arcana cards evenly across the four board quadrants (at most a quarter,
+	// rounded up, in each).
+	SpreadQuadrants bool `json:"spread_quadrants"`
+}
+
+// RaidConfig holds settings for co-op raids (two humans sharing a seat against one strong AI).
+type RaidConfig struct {
+	BoardRows int    `json:"board_rows"`
+	BoardCols int    `json:"board_cols"`
+	AIProfile string `json:"ai_profile"` // name of the AI profile defending the raid; first profile when not found
+	PeekTiles int    `json:"peek_tiles"` // tiles the raid AI knows before the first flip (handicap against the team)
+
+	// ArcanaPlacement applies to raid boards instead of the server-wide rules.
+	ArcanaPlacement ArcanaPlacementConfig `json:"arcana_placement"`
+}
+
+// RealmConfig holds per-realm overrides for an isolated community sharing the server. Nil fields inherit
+// the server-wide value.
+type RealmConfig struct {
+	BoardRows        *int `json:"board_rows,omitempty"`
+	BoardCols        *int `json:"board_cols,omitempty"`
+	TurnLimitSec     *int `json:"turn_limit_sec,omitempty"`
+	AIPairTimeoutSec *int `json:"ai_pair_timeout_sec,omitempty"`
+	// AIProfiles restricts the realm's AI opponents to these profile names; empty keeps all.
+	AIProfiles []string `json:"ai_profiles,omitempty"`
+	// AISkins renames AI profiles in the realm (localized or themed names), keyed by profile name or ID.
+	AISkins map[string]AISkin `json:"ai_skins,omitempty"`
+
+	ArcanaPlacement *ArcanaPlacementConfig `json:"arcana_placement,omitempty"`
+
+	// ChatMode and ChatPhrases override Chat.Mode and Chat.Phrases (e.g. a restricted realm for kids, or
+	// phrases in the realm's language).
+	ChatMode    *string  `json:"chat_mode,omitempty"`
+	ChatPhrases []string `json:"chat_phrases,omitempty"`
+}
+
 // Config holds all configurable game parameters.
 type Config struct {
 	BoardRows        int    `json:"board_rows"`
@@ -55,47 +229,181 @@ type Config struct {
 	MaxLatencyMS     int    `json:"max_latency_ms"`
 	AIPairTimeoutSec int    `json:"ai_pair_timeout_sec"`
 
+	// AIPairTimeoutMinSec and AIPairTimeoutMaxSec bound the adaptive wait before a queued player is offered
+	// a bot: it shrinks from AIPairTimeoutSec toward the min as fewer other players are online, and grows
+	// toward the max while other players wait in the queue. AIPairBusyPlayers is the number of players at
+	// which either bound is reached. Adaptation is off (AIPairTimeoutSec applies) when the max is 0.
+	AIPairTimeoutMinSec int `json:"ai_pair_timeout_min_sec"`
+	AIPairTimeoutMaxSec int `json:"ai_pair_timeout_max_sec"`
+	AIPairBusyPlayers   int `json:"ai_pair_busy_players"`
+
+	// RegionFallbackSec is how long a queued player waits for an opponent from the same region (region hint
+	// sent at auth or set_name) before being paired across regions; 0 pairs across regions right away.
+	RegionFallbackSec int `json:"region_fallback_sec"`
+
+	// RatingWindow is the ELO gap a ranked player accepts right after queueing; it grows by
+	// RatingWindowWidenPerSec for every second waited, so nobody waits on rating alone for long. Two players
+	// are paired once either one's window covers their gap. 0 pairs regardless of rating.
+	RatingWindow            int `json:"rating_window"`
+	RatingWindowWidenPerSec int `json:"rating_window_widen_per_sec"`
+
 	// TurnLimitSec is the max time per turn in seconds; 0 = disabled.
 	TurnLimitSec int `json:"turn_limit_sec"`
 	// TurnCountdownShowSec is how many seconds before turn end to show the countdown.
 	TurnCountdownShowSec int `json:"turn_countdown_show_sec"`
 	// ReconnectTimeoutSec is how long to wait for a disconnected player to rejoin before ending the game.
 	ReconnectTimeoutSec int `json:"reconnect_timeout_sec"`
+	// PollIdleTimeoutSec is how long an HTTP long-polling session (kiosk bridge) may go without a request before it is dropped.
+	PollIdleTimeoutSec int `json:"poll_idle_timeout_sec"`
+	// PollMaxSessions caps the open kiosk bridge sessions, and PollMaxSessionsPerAddr those opened from one
+	// remote address; a session over either cap is refused. 0 = no limit.
+	PollMaxSessions        int `json:"poll_max_sessions"`
+	PollMaxSessionsPerAddr int `json:"poll_max_sessions_per_addr"`
+	// ShutdownGraceSec is how long games in progress may go on after SIGTERM; those still running then end
+	// as unrated draws before the server exits.
+	ShutdownGraceSec int `json:"shutdown_grace_sec"`
+	// ServeWebClient serves the web client embedded in the binary (package webclient) at /, next to /ws and
+	// /api, so a self-hosted deployment needs no separate static host. Requires a client build in webclient/dist.
+	ServeWebClient bool `json:"serve_web_client"`
+	// EndOnInsurmountableLead ends the match early once the trailing player can no longer catch up.
+	EndOnInsurmountableLead bool `json:"end_on_insurmountable_lead"`
+	// AssistIdleSec is how long a player in assisted mode may stay idle on their turn before the server
+	// highlights a card for them (at most once per turn); 0 = never.
+	AssistIdleSec int `json:"assist_idle_sec"`
+	// RevealDurationMinMS and RevealDurationMaxMS bound the per-match mismatch reveal, which grows with the
+	// worse round-trip time of the two players. Adaptation is off (RevealDurationMS applies) when the max is 0.
+	RevealDurationMinMS int `json:"reveal_duration_min_ms"`
+	RevealDurationMaxMS int `json:"reveal_duration_max_ms"`
+	// MaxHandSize caps how many arcana copies a player may hold; 0 = unlimited.
+	MaxHandSize int `json:"max_hand_size"`
+	// HandOverflowRule decides what happens when a player matches an arcana pair with a full hand:
+	// "discard_oldest" (default), "convert_to_points" or "block".
+	HandOverflowRule string `json:"hand_overflow_rule"`
+	// ArcanaPityMatches guarantees an arcana to a player who has matched this many normal pairs without
+	// obtaining one: their next matched pair also grants a random arcana from the match pool. 0 = off.
+	ArcanaPityMatches int `json:"arcana_pity_matches"`
+	// StartingDraftSec turns on the starting-hand draft: before the first turn each player privately picks
+	// one of three random arcana to start with, within this many seconds (the first option is picked for
+	// a player who does not). 0 = off.
+	StartingDraftSec int `json:"starting_draft_sec"`
+	// MinPairsPerElement is the fewest normal pairs of each element a board must deal; with it, board
+	// sizes must also fit every arcana pair (see ValidateBoard). 0 only requires an even card count.
+	MinPairsPerElement int `json:"min_pairs_per_element"`
+	// BoardSizes are the square boards (side length, one of BoardSizeChoices) a player may request when
+	// queuing; players are only paired with others requesting the same size. Empty = only the BoardRows x
+	// BoardCols board. A 4x4 board has room for the arcana pairs only with MinPairsPerElement 0.
+	BoardSizes []int `json:"board_sizes"`
+	// DisplayNameSyncSec is how often leaderboard display names are refreshed from Neon Auth, so renamed
+	// users do not stay stale until their next game; 0 = never (names still update when users sign in).
+	DisplayNameSyncSec int `json:"display_name_sync_sec"`
+	// MismatchRetries is a rules variant: a player keeps the turn after this many consecutive mismatches
+	// and only passes it on the next one. 0 = classic rules (a mismatch passes the turn).
+	MismatchRetries int `json:"mismatch_retries"`
+
+	// ArcanaPlacement constrains where arcana cards are dealt on the board (off by default).
+	ArcanaPlacement ArcanaPlacementConfig `json:"arcana_placement"`
 
 	// PowerUps holds configuration for each power-up.
 	PowerUps PowerUpsConfig `json:"powerups"`
 
 	// AIProfiles lists available AI opponents; one is chosen at random when pairing vs AI.
 	AIProfiles []AIParams `json:"ai_profiles"`
+	// AIWorkers is how many AI moves are computed at once, shared by every bot of the server; more bots
+	// wait in line for a worker (their search budget shrinks meanwhile). 0 = one per CPU.
+	AIWorkers int `json:"ai_workers"`
+
+	// Raid configures the co-op raid mode.
+	Raid RaidConfig `json:"raid"`
+
+	// Realms lists the isolated communities served besides the default realm (""), keyed by realm name.
+	// Each realm has its own matchmaking queue, ratings and history.
+	Realms map[string]RealmConfig `json:"realms"`
 
 	// TelemetryHistogram defines histogram bins for "game stage at use" (turn and pairs already matched).
 	TelemetryHistogram TelemetryHistogramConfig `json:"telemetry_histogram"`
+	// TelemetryQueueMax caps the telemetry and replay events held in memory for matches still running;
+	// beyond it the events of the oldest match are dropped. 0 = no cap.
+	TelemetryQueueMax int `json:"telemetry_queue_max"`
+
+	// BalanceAlerts configures the background analyzer that alerts on drifting card balance.
+	BalanceAlerts BalanceAlertsConfig `json:"balance_alerts"`
+
+	// Seasons configures seasonal rating resets.
+	Seasons SeasonsConfig `json:"seasons"`
+
+	// ConnectionQuality configures the connection_unstable indicator and the turn extension that goes with it.
+	ConnectionQuality ConnectionQualityConfig `json:"connection_quality"`
+
+	// Chat configures in-game chat.
+	Chat ChatConfig `json:"chat"`
+	// MaxEmotesPerTurn is how many emotes a seat may send per turn; 0 = no limit.
+	MaxEmotesPerTurn int `json:"max_emotes_per_turn"`
+	// MaxMessagesPerSec is how many messages a WebSocket connection may send per second; a connection
+	// over the limit is closed (close code 4029). 0 = no limit.
+	MaxMessagesPerSec int `json:"max_messages_per_sec"`
+	// ActionsPerSec and ActionBurst are the token bucket for game actions (flip_card, use_power_up) of a
+	// WebSocket connection: ActionBurst actions at once, refilled at ActionsPerSec. An action over the
+	// bucket is dropped with an error; after ActionBurst drops in a row the connection is closed (4029).
+	// ActionsPerSec 0 = no limit.
+	ActionsPerSec int `json:"actions_per_sec"`
+	ActionBurst   int `json:"action_burst"`
+	// MinFlipSpacingMS is the shortest time between two flips of a turn, measured when the server received
+	// them; a faster flip is rejected and recorded as suspicious timing. 0 = no check.
+	MinFlipSpacingMS int `json:"min_flip_spacing_ms"`
+
+	// Experiments lists rules experiments; active ones may poll players after each game.
+	Experiments []ExperimentConfig `json:"experiments"`
 
 	// LogLevel is the minimum log level: "debug", "info", "warn", "error". Default "info".
 	LogLevel string `json:"log_level"`
+
+	// Profile is the environment profile the config was loaded with (ProfileDev, ProfileStaging,
+	// ProfileProd; "" = none). From CONFIG_PROFILE.
+	Profile string `json:"-"`
 }
 
 // Defaults returns a Config with all default values from the spec.
 func Defaults() *Config {
 	return &Config{
-		BoardRows:            6,
-		BoardCols:            6,
-		RevealDurationMS:     1000,
-		MaxNameLength:        24,
-		WSPort:               8080,
-		MaxLatencyMS:         500,
-		AIPairTimeoutSec:     15,
-		TurnLimitSec:         60,
-		TurnCountdownShowSec: 30,
-		ReconnectTimeoutSec:  120,
+		BoardRows:         6,
+		BoardCols:         6,
+		RevealDurationMS:  1000,
+		MaxNameLength:     24,
+		WSPort:            8080,
+		MaxLatencyMS:      500,
+		AIPairTimeoutSec:  15,
+		RegionFallbackSec: 5,
+		AIPairBusyPlayers: 10,
+
+		RatingWindow:            200,
+		RatingWindowWidenPerSec: 25,
+
+		TurnLimitSec:           60,
+		TurnCountdownShowSec:   30,
+		ReconnectTimeoutSec:    120,
+		PollIdleTimeoutSec:     60,
+		PollMaxSessions:        500,
+		PollMaxSessionsPerAddr: 8,
+		ShutdownGraceSec:       20,
+		AssistIdleSec:          20,
+		HandOverflowRule:       "discard_oldest",
+		MinPairsPerElement:     1,
+		BoardSizes:             []int{6, 8},
+		DisplayNameSyncSec:     3600,
 		PowerUps: PowerUpsConfig{
 			Chaos:        ChaosPowerUpConfig{},
 			Clairvoyance: ClairvoyancePowerUpConfig{RevealDurationMS: 3000},
 		},
 		AIProfiles: []AIParams{
-			{Name: "Mnemosyne", DelayMinMS: 1000, DelayMaxMS: 2000, UseBestMoveChance: 90, ForgetChance: 2, ArcanaRandomness: 10},
-			{Name: "Calliope", DelayMinMS: 500, DelayMaxMS: 1100, UseBestMoveChance: 90, ForgetChance: 8, ArcanaRandomness: 15},
-			{Name: "Thalia", DelayMinMS: 500, DelayMaxMS: 2000, UseBestMoveChance: 90, ForgetChance: 12, ArcanaRandomness: 20},
+			{Name: "Mnemosyne", Difficulty: "hard", DelayMinMS: 1000, DelayMaxMS: 2000, UseBestMoveChance: 90, ForgetChance: 2, ArcanaRandomness: 10, SecondFlipDelayMinMS: 500, SecondFlipDelayMaxMS: 1100, ThinkMaxExtraMS: 900, SearchDepth: 2},
+			{Name: "Calliope", Difficulty: "medium", DelayMinMS: 500, DelayMaxMS: 1100, UseBestMoveChance: 90, ForgetChance: 8, ArcanaRandomness: 15, SecondFlipDelayMinMS: 300, SecondFlipDelayMaxMS: 700, ThinkMaxExtraMS: 500},
+			{Name: "Thalia", Difficulty: "easy", DelayMinMS: 500, DelayMaxMS: 2000, UseBestMoveChance: 90, ForgetChance: 12, ArcanaRandomness: 20, SecondFlipDelayMinMS: 400, SecondFlipDelayMaxMS: 1200, ThinkMaxExtraMS: 1200},
+		},
+		Raid: RaidConfig{
+			BoardRows: 6,
+			BoardCols: 8,
+			AIProfile: "Mnemosyne",
+			PeekTiles: 6,
 		},
 		TelemetryHistogram: TelemetryHistogramConfig{
 			TurnMax:      100,
@@ -103,8 +411,77 @@ func Defaults() *Config {
 			PairsMax:     36,
 			PairsNumBins: 6,
 		},
-		LogLevel: "info",
+		TelemetryQueueMax: 200000,
+		BalanceAlerts: BalanceAlertsConfig{
+			WindowHours:         24,
+			MinMatches:          30,
+			MinWinRatePct:       35,
+			MaxWinRatePct:       65,
+			MaxWinRateDriftPct:  10,
+			MaxUseShareDriftPct: 10,
+		},
+		Seasons: SeasonsConfig{
+			CarryOverPct: 50,
+		},
+		ConnectionQuality: ConnectionQualityConfig{
+			MissedPongs:      2,
+			RTTThresholdMS:   1000,
+			TurnExtensionSec: 5,
+		},
+		Chat: ChatConfig{
+			MaxLength:   200,
+			MaxMessages: 5,
+			WindowSec:   10,
+			Phrases:     DefaultChatPhrases,
+		},
+		MaxEmotesPerTurn:  2,
+		MaxMessagesPerSec: 30,
+		ActionsPerSec:     8,
+		ActionBurst:       12,
+		MinFlipSpacingMS:  50,
+		LogLevel:          "info",
+	}
+}
+
+// ForRealm returns the configuration for a realm: a copy of c with the realm's overrides applied.
+// The default realm ("") returns c itself; ok is false for a realm that is not configured.
+func (c *Config) ForRealm(realm string) (cfg *Config, ok bool) {
+	if realm == "" {
+		return c, true
+	}
+	rc, ok := c.Realms[realm]
+	if !ok {
+		return nil, false
+	}
+	cp := *c
+	if rc.BoardRows != nil {
+		cp.BoardRows = *rc.BoardRows
+	}
+	if rc.BoardCols != nil {
+		cp.BoardCols = *rc.BoardCols
+	}
+	if rc.TurnLimitSec != nil {
+		cp.TurnLimitSec = *rc.TurnLimitSec
+	}
+	if rc.AIPairTimeoutSec != nil {
+		cp.AIPairTimeoutSec = *rc.AIPairTimeoutSec
+	}
+	if rc.ArcanaPlacement != nil {
+		cp.ArcanaPlacement = *rc.ArcanaPlacement
 	}
+	if rc.ChatMode != nil {
+		cp.Chat.Mode = *rc.ChatMode
+	}
+	if len(rc.ChatPhrases) > 0 {
+		cp.Chat.Phrases = rc.ChatPhrases
+	}
+	if len(rc.AIProfiles) > 0 {
+		cp.AIProfiles = filterAIProfilesByName(c.AIProfiles, strings.Join(rc.AIProfiles, ","))
+	}
+	if len(rc.AISkins) > 0 {
+		cp.AIProfiles = skinAIProfiles(cp.AIProfiles, rc.AISkins)
+	}
+	return &cp, true
 }
 
 // SlogLevel returns the slog.Level for the configured LogLevel string.
@@ -126,8 +503,19 @@ func (c *Config) SlogLevel() slog.Level {
 // then applies environment variable overrides. Fields not set
 // in either source retain their default values.
 // Config file path: CONFIG_PATH or CONFIG_FILE env, or "config.json" in the current directory.
-func Load() *Config {
+// The environment profile is CONFIG_PROFILE (see LoadProfile).
+// Returns an error when the config fails Validate, so the server fails at startup.
+func Load() (*Config, error) {
+	return LoadProfile(os.Getenv("CONFIG_PROFILE"))
+}
+
+// LoadProfile is Load with the given environment profile: its defaults apply before the config file
+// and env overrides, and its checks are part of Validate. "" loads without a profile.
+func LoadProfile(profile string) (*Config, error) {
 	cfg := Defaults()
+	if err := applyProfile(cfg, profile); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -148,25 +536,78 @@ func Load() *Config {
 	overrideInt(&cfg.BoardCols, "BOARD_COLS")
 	overrideInt(&cfg.RevealDurationMS, "REVEAL_DURATION_MS")
 	overrideInt(&cfg.PowerUps.Clairvoyance.RevealDurationMS, "POWERUP_CLAIRVOYANCE_REVEAL_MS")
+	overrideInt(&cfg.PowerUps.Chaos.Cost, "POWERUP_CHAOS_COST")
+	overrideInt(&cfg.PowerUps.Clairvoyance.Cost, "POWERUP_CLAIRVOYANCE_COST")
+	overrideInt(&cfg.PowerUps.Peek.Cost, "POWERUP_PEEK_COST")
 	overrideInt(&cfg.MaxNameLength, "MAX_NAME_LENGTH")
 	overrideInt(&cfg.WSPort, "WS_PORT")
 	overrideInt(&cfg.MaxLatencyMS, "MAX_LATENCY_MS")
 	overrideInt(&cfg.AIPairTimeoutSec, "AI_PAIR_TIMEOUT_SEC")
+	overrideInt(&cfg.AIPairTimeoutMinSec, "AI_PAIR_TIMEOUT_MIN_SEC")
+	overrideInt(&cfg.AIPairTimeoutMaxSec, "AI_PAIR_TIMEOUT_MAX_SEC")
+	overrideInt(&cfg.AIPairBusyPlayers, "AI_PAIR_BUSY_PLAYERS")
+	overrideInt(&cfg.RegionFallbackSec, "REGION_FALLBACK_SEC")
+	overrideInt(&cfg.RatingWindow, "RATING_WINDOW")
+	overrideInt(&cfg.RatingWindowWidenPerSec, "RATING_WINDOW_WIDEN_PER_SEC")
 	overrideInt(&cfg.TurnLimitSec, "TURN_LIMIT_SEC")
 	overrideInt(&cfg.TurnCountdownShowSec, "TURN_COUNTDOWN_SHOW_SEC")
 	overrideInt(&cfg.ReconnectTimeoutSec, "RECONNECT_TIMEOUT_SEC")
+	overrideInt(&cfg.PollIdleTimeoutSec, "POLL_IDLE_TIMEOUT_SEC")
+	overrideInt(&cfg.PollMaxSessions, "POLL_MAX_SESSIONS")
+	overrideInt(&cfg.PollMaxSessionsPerAddr, "POLL_MAX_SESSIONS_PER_ADDR")
+	overrideInt(&cfg.ShutdownGraceSec, "SHUTDOWN_GRACE_SEC")
+	overrideBool(&cfg.ServeWebClient, "SERVE_WEB_CLIENT")
+	overrideBool(&cfg.EndOnInsurmountableLead, "END_ON_INSURMOUNTABLE_LEAD")
+	overrideInt(&cfg.AssistIdleSec, "ASSIST_IDLE_SEC")
+	overrideInt(&cfg.RevealDurationMinMS, "REVEAL_DURATION_MIN_MS")
+	overrideInt(&cfg.RevealDurationMaxMS, "REVEAL_DURATION_MAX_MS")
+	overrideInt(&cfg.MaxHandSize, "MAX_HAND_SIZE")
+	overrideString(&cfg.HandOverflowRule, "HAND_OVERFLOW_RULE")
+	overrideInt(&cfg.ArcanaPityMatches, "ARCANA_PITY_MATCHES")
+	overrideInt(&cfg.StartingDraftSec, "STARTING_DRAFT_SEC")
+	overrideInt(&cfg.MismatchRetries, "MISMATCH_RETRIES")
+	overrideInt(&cfg.MinPairsPerElement, "MIN_PAIRS_PER_ELEMENT")
+	overrideIntList(&cfg.BoardSizes, "BOARD_SIZES")
+	overrideInt(&cfg.DisplayNameSyncSec, "DISPLAY_NAME_SYNC_SEC")
+	overrideBool(&cfg.ArcanaPlacement.NoAdjacent, "ARCANA_NO_ADJACENT")
+	overrideBool(&cfg.ArcanaPlacement.SpreadQuadrants, "ARCANA_SPREAD_QUADRANTS")
 	overrideString(&cfg.NeonAuthBaseURL, "NEON_AUTH_BASE_URL")
 	overrideString(&cfg.DatabaseURL, "DATABASE_URL")
 	if names := os.Getenv("AI_PROFILES"); names != "" {
 		cfg.AIProfiles = filterAIProfilesByName(cfg.AIProfiles, names)
 	}
+	overrideInt(&cfg.AIWorkers, "AI_WORKERS")
+	overrideInt(&cfg.Raid.BoardRows, "RAID_BOARD_ROWS")
+	overrideInt(&cfg.Raid.BoardCols, "RAID_BOARD_COLS")
+	overrideString(&cfg.Raid.AIProfile, "RAID_AI_PROFILE")
+	overrideInt(&cfg.Raid.PeekTiles, "RAID_PEEK_TILES")
+	overrideBool(&cfg.Raid.ArcanaPlacement.NoAdjacent, "RAID_ARCANA_NO_ADJACENT")
+	overrideBool(&cfg.Raid.ArcanaPlacement.SpreadQuadrants, "RAID_ARCANA_SPREAD_QUADRANTS")
 	overrideInt(&cfg.TelemetryHistogram.TurnMax, "TELEMETRY_TURN_MAX")
 	overrideInt(&cfg.TelemetryHistogram.TurnNumBins, "TELEMETRY_TURN_NUM_BINS")
 	overrideInt(&cfg.TelemetryHistogram.PairsMax, "TELEMETRY_PAIRS_MAX")
 	overrideInt(&cfg.TelemetryHistogram.PairsNumBins, "TELEMETRY_PAIRS_NUM_BINS")
+	overrideInt(&cfg.TelemetryQueueMax, "TELEMETRY_QUEUE_MAX")
+	overrideInt(&cfg.BalanceAlerts.IntervalSec, "BALANCE_ALERTS_INTERVAL_SEC")
+	overrideString(&cfg.BalanceAlerts.WebhookURL, "BALANCE_ALERTS_WEBHOOK_URL")
+	overrideInt(&cfg.Seasons.LengthDays, "SEASON_LENGTH_DAYS")
+	overrideInt(&cfg.Seasons.CarryOverPct, "SEASON_CARRY_OVER_PCT")
+	overrideBool(&cfg.Chat.Disabled, "CHAT_DISABLED")
+	overrideInt(&cfg.Chat.MaxLength, "CHAT_MAX_LENGTH")
+	overrideInt(&cfg.Chat.MaxMessages, "CHAT_MAX_MESSAGES")
+	overrideInt(&cfg.Chat.WindowSec, "CHAT_WINDOW_SEC")
+	overrideString(&cfg.Chat.Mode, "CHAT_MODE")
+	overrideInt(&cfg.MaxEmotesPerTurn, "MAX_EMOTES_PER_TURN")
+	overrideInt(&cfg.MaxMessagesPerSec, "MAX_MESSAGES_PER_SEC")
+	overrideInt(&cfg.ActionsPerSec, "ACTIONS_PER_SEC")
+	overrideInt(&cfg.ActionBurst, "ACTION_BURST")
+	overrideInt(&cfg.MinFlipSpacingMS, "MIN_FLIP_SPACING_MS")
 	overrideString(&cfg.LogLevel, "LOG_LEVEL")
 
-	return cfg
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
 }
 
 // filterAIProfilesByName returns only profiles whose Name (case-insensitive) is in the
@@ -205,6 +646,38 @@ func overrideInt(field *int, envKey string) {
 	}
 }
 
+// overrideIntList reads a comma-separated list of ints; "none" clears the list.
+func overrideIntList(field *[]int, envKey string) {
+	val := os.Getenv(envKey)
+	if val == "" {
+		return
+	}
+	if strings.EqualFold(val, "none") {
+		*field = nil
+		return
+	}
+	var list []int
+	for _, s := range strings.Split(val, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			slog.Warn("invalid config value", "tag", "config", "key", envKey, "value", val)
+			return
+		}
+		list = append(list, n)
+	}
+	*field = list
+}
+
+func overrideBool(field *bool, envKey string) {
+	if val := os.Getenv(envKey); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			*field = b
+		} else {
+			slog.Warn("invalid config value", "tag", "config", "key", envKey, "value", val)
+		}
+	}
+}
+
 func overrideString(field *string, envKey string) {
 	if val := os.Getenv(envKey); val != "" {
 		*field = val
@@ -36,6 +36,21 @@ func TestDefaults(t *testing.T) {
 	if cfg.AIPairTimeoutSec != 15 {
 		t.Errorf("expected AIPairTimeoutSec=15, got %d", cfg.AIPairTimeoutSec)
 	}
+	if cfg.RegionFallbackSec != 5 {
+		t.Errorf("expected RegionFallbackSec=5, got %d", cfg.RegionFallbackSec)
+	}
+	if cfg.PollIdleTimeoutSec != 60 {
+		t.Errorf("expected PollIdleTimeoutSec=60, got %d", cfg.PollIdleTimeoutSec)
+	}
+	if cfg.PollMaxSessions != 500 || cfg.PollMaxSessionsPerAddr != 8 {
+		t.Errorf("expected 500 kiosk sessions, 8 per address, got %d and %d", cfg.PollMaxSessions, cfg.PollMaxSessionsPerAddr)
+	}
+	if cfg.AssistIdleSec != 20 {
+		t.Errorf("expected AssistIdleSec=20, got %d", cfg.AssistIdleSec)
+	}
+	if cfg.MaxHandSize != 0 || cfg.HandOverflowRule != "discard_oldest" {
+		t.Errorf("expected unlimited hand with discard_oldest, got %d %q", cfg.MaxHandSize, cfg.HandOverflowRule)
+	}
 	if cfg.TurnLimitSec != 60 {
 		t.Errorf("expected TurnLimitSec=60, got %d", cfg.TurnLimitSec)
 	}
@@ -51,6 +66,9 @@ func TestDefaults(t *testing.T) {
 	if cfg.AIProfiles[0].DelayMinMS != 1000 || cfg.AIProfiles[0].DelayMaxMS != 2000 || cfg.AIProfiles[0].UseBestMoveChance != 90 || cfg.AIProfiles[0].ForgetChance != 2 || cfg.AIProfiles[0].ArcanaRandomness != 10 {
 		t.Errorf("expected Mnemosyne 1000/2000/90 ForgetChance=2 ArcanaRandomness=10, got %d/%d/%d ForgetChance=%d ArcanaRandomness=%d", cfg.AIProfiles[0].DelayMinMS, cfg.AIProfiles[0].DelayMaxMS, cfg.AIProfiles[0].UseBestMoveChance, cfg.AIProfiles[0].ForgetChance, cfg.AIProfiles[0].ArcanaRandomness)
 	}
+	if cfg.AIProfiles[0].SecondFlipDelayMinMS != 500 || cfg.AIProfiles[0].SecondFlipDelayMaxMS != 1100 || cfg.AIProfiles[0].ThinkMaxExtraMS != 900 {
+		t.Errorf("expected Mnemosyne second flip 500/1100 ThinkMaxExtraMS=900, got %d/%d ThinkMaxExtraMS=%d", cfg.AIProfiles[0].SecondFlipDelayMinMS, cfg.AIProfiles[0].SecondFlipDelayMaxMS, cfg.AIProfiles[0].ThinkMaxExtraMS)
+	}
 	if cfg.AIProfiles[1].Name != "Calliope" {
 		t.Errorf("expected second AI name Calliope, got %q", cfg.AIProfiles[1].Name)
 	}
@@ -60,8 +78,11 @@ func TestDefaults(t *testing.T) {
 	if cfg.AIProfiles[2].Name != "Thalia" {
 		t.Errorf("expected third AI name Thalia, got %q", cfg.AIProfiles[2].Name)
 	}
-	if cfg.AIProfiles[2].DelayMinMS != 500 || cfg.AIProfiles[2].DelayMaxMS != 2000 || cfg.AIProfiles[2].UseBestMoveChance != 90 || cfg.AIProfiles[2].ForgetChance != 15 || cfg.AIProfiles[2].ArcanaRandomness != 20 {
-		t.Errorf("expected Thalia 500/2000/90 ForgetChance=15 ArcanaRandomness=20, got %d/%d/%d ForgetChance=%d ArcanaRandomness=%d", cfg.AIProfiles[2].DelayMinMS, cfg.AIProfiles[2].DelayMaxMS, cfg.AIProfiles[2].UseBestMoveChance, cfg.AIProfiles[2].ForgetChance, cfg.AIProfiles[2].ArcanaRandomness)
+	if cfg.AIProfiles[2].DelayMinMS != 500 || cfg.AIProfiles[2].DelayMaxMS != 2000 || cfg.AIProfiles[2].UseBestMoveChance != 90 || cfg.AIProfiles[2].ForgetChance != 12 || cfg.AIProfiles[2].ArcanaRandomness != 20 {
+		t.Errorf("expected Thalia 500/2000/90 ForgetChance=12 ArcanaRandomness=20, got %d/%d/%d ForgetChance=%d ArcanaRandomness=%d", cfg.AIProfiles[2].DelayMinMS, cfg.AIProfiles[2].DelayMaxMS, cfg.AIProfiles[2].UseBestMoveChance, cfg.AIProfiles[2].ForgetChance, cfg.AIProfiles[2].ArcanaRandomness)
+	}
+	if cfg.Raid.BoardRows != 6 || cfg.Raid.BoardCols != 8 || cfg.Raid.AIProfile != "Mnemosyne" || cfg.Raid.PeekTiles != 6 {
+		t.Errorf("expected Raid 6x8 Mnemosyne PeekTiles=6, got %dx%d %s PeekTiles=%d", cfg.Raid.BoardRows, cfg.Raid.BoardCols, cfg.Raid.AIProfile, cfg.Raid.PeekTiles)
 	}
 	if cfg.LogLevel != "info" {
 		t.Errorf("expected LogLevel=info, got %q", cfg.LogLevel)
@@ -81,7 +102,10 @@ func TestLoadWithEnvOverrides(t *testing.T) {
 		os.Unsetenv("WS_PORT")
 	}()
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if cfg.BoardRows != 6 {
 		t.Errorf("expected BoardRows=6 after env override, got %d", cfg.BoardRows)
@@ -106,7 +130,10 @@ func TestLoadWithAIProfilesFilter(t *testing.T) {
 		os.Unsetenv("AI_PROFILES")
 	}()
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if cfg.AIPairTimeoutSec != 30 {
 		t.Errorf("expected AIPairTimeoutSec=30, got %d", cfg.AIPairTimeoutSec)
@@ -126,7 +153,10 @@ func TestLoadWithInvalidEnv(t *testing.T) {
 	os.Setenv("BOARD_ROWS", "invalid")
 	defer os.Unsetenv("BOARD_ROWS")
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Should fall back to default when env value is invalid
 	if cfg.BoardRows != 6 {
@@ -138,7 +168,10 @@ func TestLoadWithPowerUpEnvOverrides(t *testing.T) {
 	os.Setenv("POWERUP_CLAIRVOYANCE_REVEAL_MS", "3000")
 	defer os.Unsetenv("POWERUP_CLAIRVOYANCE_REVEAL_MS")
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if cfg.PowerUps.Clairvoyance.RevealDurationMS != 3000 {
 		t.Errorf("expected PowerUps.Clairvoyance.RevealDurationMS=3000 after env override, got %d", cfg.PowerUps.Clairvoyance.RevealDurationMS)
@@ -166,3 +199,82 @@ func TestSlogLevel(t *testing.T) {
 		}
 	}
 }
+
+func TestForRealm(t *testing.T) {
+	cfg := Defaults()
+	rows, turnLimit := 4, 0
+	cfg.Realms = map[string]RealmConfig{
+		"school": {BoardRows: &rows, TurnLimitSec: &turnLimit, AIProfiles: []string{"calliope"},
+			ArcanaPlacement: &ArcanaPlacementConfig{NoAdjacent: true}},
+	}
+
+	if got, ok := cfg.ForRealm(""); !ok || got != cfg {
+		t.Error("expected the default realm to use the server config itself")
+	}
+	if _, ok := cfg.ForRealm("unknown"); ok {
+		t.Error("expected an unconfigured realm to be rejected")
+	}
+
+	realm, ok := cfg.ForRealm("school")
+	if !ok {
+		t.Fatal("expected configured realm")
+	}
+	if realm.BoardRows != 4 || realm.BoardCols != cfg.BoardCols || realm.TurnLimitSec != 0 {
+		t.Errorf("expected rows 4, inherited cols %d and turn limit 0, got %d/%d/%d", cfg.BoardCols, realm.BoardRows, realm.BoardCols, realm.TurnLimitSec)
+	}
+	if !realm.ArcanaPlacement.NoAdjacent || cfg.ArcanaPlacement.NoAdjacent {
+		t.Errorf("expected the realm's arcana placement only in the realm, got %+v / %+v", realm.ArcanaPlacement, cfg.ArcanaPlacement)
+	}
+	if len(realm.AIProfiles) != 1 || realm.AIProfiles[0].Name != "Calliope" {
+		t.Errorf("expected only Calliope in the realm, got %+v", realm.AIProfiles)
+	}
+	if cfg.BoardRows != 6 || len(cfg.AIProfiles) != 3 {
+		t.Error("expected the server config to be left unchanged")
+	}
+}
+
+func TestAIProfileByUserID(t *testing.T) {
+	cfg := Defaults()
+	cfg.AIProfiles = append(cfg.AIProfiles, AIParams{Name: "Clio", ID: "clio-v2", Difficulty: "hard"})
+
+	if got := cfg.AIProfileByUserID("ai:Thalia"); got == nil || got.Difficulty != "easy" {
+		t.Errorf("expected Thalia (easy) by profile name, got %+v", got)
+	}
+	if got := cfg.AIProfileByUserID("ai:clio-v2"); got == nil || got.Name != "Clio" {
+		t.Errorf("expected Clio by profile ID, got %+v", got)
+	}
+	if got := cfg.AIProfileByUserID("ai:Clio"); got != nil {
+		t.Errorf("expected a profile with an ID not to match by name, got %+v", got)
+	}
+}
+
+func TestForRealm_AISkins(t *testing.T) {
+	cfg := Defaults()
+	cfg.AIProfiles = append(cfg.AIProfiles, AIParams{Name: "Clio", ID: "clio-v2", DelayMinMS: 500, DelayMaxMS: 1000})
+	cfg.Realms = map[string]RealmConfig{
+		"br": {AISkins: map[string]AISkin{
+			"thalia":  {Name: "Tália"},
+			"clio-v2": {Identities: []AIIdentity{{Name: "Clio BR", Avatar: "clio-br"}}},
+		}},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	realm, _ := cfg.ForRealm("br")
+	thalia := realm.AIProfileByUserID("ai:Thalia")
+	if thalia == nil || thalia.Name != "Tália" {
+		t.Fatalf("expected Thalia renamed under her stable user ID, got %+v", thalia)
+	}
+	if clio := realm.AIProfileByUserID("ai:clio-v2"); clio == nil || clio.Name != "Clio" || len(clio.Identities) != 1 || clio.Identities[0].Name != "Clio BR" {
+		t.Errorf("expected Clio to keep her name and play as Clio BR, got %+v", clio)
+	}
+	if cfg.AIProfiles[2].Name != "Thalia" || cfg.AIProfiles[2].ID != "" {
+		t.Errorf("expected the server profiles to be left unchanged, got %+v", cfg.AIProfiles[2])
+	}
+
+	cfg.Realms["br"].AISkins["Urania"] = AISkin{Name: "Urânia"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a skin for an unknown profile to be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import "fmt"
+
+// defaultPollOptions are the answers of a poll that does not list its own.
+var defaultPollOptions = []string{"yes", "no"}
+
+// ExperimentConfig is a rules experiment. While it is active, its poll (when it has a question) is asked
+// to both players after each game, and the answers are stored with the match.
+type ExperimentConfig struct {
+	// ID identifies the experiment in stored poll responses; it must be unique.
+	ID     string `json:"id"`
+	Active bool   `json:"active"`
+	// Question is the one-tap poll shown after game_over (e.g. "Was this match fun?"); empty asks nothing.
+	Question string `json:"question"`
+	// Options are the allowed answers, in display order; empty means "yes" and "no".
+	Options []string `json:"options,omitempty"`
+}
+
+// PollOptions returns the experiment's allowed answers.
+func (e ExperimentConfig) PollOptions() []string {
+	if len(e.Options) == 0 {
+		return defaultPollOptions
+	}
+	return e.Options
+}
+
+// ActivePolls returns the active experiments that ask a poll question, in config order.
+func (c *Config) ActivePolls() []ExperimentConfig {
+	var out []ExperimentConfig
+	for _, e := range c.Experiments {
+		if e.Active && e.Question != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// ActivePoll returns the active poll with the given experiment ID.
+func (c *Config) ActivePoll(id string) (ExperimentConfig, bool) {
+	for _, e := range c.ActivePolls() {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return ExperimentConfig{}, false
+}
+
+// validateExperiments rejects experiments without an ID or sharing one, since their answers would mix.
+func validateExperiments(experiments []ExperimentConfig) error {
+	seen := make(map[string]bool, len(experiments))
+	for i, e := range experiments {
+		if e.ID == "" {
+			return fmt.Errorf("experiment %d has no id", i)
+		}
+		if seen[e.ID] {
+			return fmt.Errorf("experiment id %q is used twice", e.ID)
+		}
+		seen[e.ID] = true
+	}
+	return nil
+}
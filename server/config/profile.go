@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Environment profiles, selected with CONFIG_PROFILE. A profile sets defaults suited to where the server
+// runs (before the config file and env overrides) and adds the checks that environment needs.
+const (
+	ProfileDev     = "dev"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// applyProfile sets the defaults of profile on cfg; "" applies none. Unknown profiles are an error.
+func applyProfile(cfg *Config, profile string) error {
+	switch profile {
+	case "", ProfileStaging, ProfileProd:
+	case ProfileDev:
+		// Local play: AI decision logs, and a bot opponent without a long wait in the queue.
+		cfg.LogLevel = "debug"
+		cfg.AIPairTimeoutSec = 5
+	default:
+		return fmt.Errorf("unknown config profile %q (want %s, %s or %s)", profile, ProfileDev, ProfileStaging, ProfileProd)
+	}
+	cfg.Profile = profile
+	return nil
+}
+
+// validateProfile checks what the profile's environment needs: staging and prod record games
+// (DATABASE_URL), and prod also authenticates players (NEON_AUTH_BASE_URL).
+func (c *Config) validateProfile() error {
+	if c.Profile != ProfileStaging && c.Profile != ProfileProd {
+		return nil
+	}
+	if c.DatabaseURL == "" {
+		return fmt.Errorf("profile %s: DATABASE_URL is not set", c.Profile)
+	}
+	if c.Profile == ProfileProd && c.NeonAuthBaseURL == "" {
+		return errors.New("profile prod: NEON_AUTH_BASE_URL is not set")
+	}
+	return nil
+}
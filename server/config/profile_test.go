@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	cfg, err := LoadProfile(ProfileDev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != ProfileDev || cfg.LogLevel != "debug" || cfg.AIPairTimeoutSec != 5 {
+		t.Errorf("expected dev defaults, got profile %q log level %q AI timeout %d", cfg.Profile, cfg.LogLevel, cfg.AIPairTimeoutSec)
+	}
+
+	// Env overrides still win over the profile's defaults.
+	os.Setenv("AI_PAIR_TIMEOUT_SEC", "30")
+	defer os.Unsetenv("AI_PAIR_TIMEOUT_SEC")
+	if cfg, err := LoadProfile(ProfileDev); err != nil || cfg.AIPairTimeoutSec != 30 {
+		t.Errorf("expected AI_PAIR_TIMEOUT_SEC to override the dev profile, got %v", err)
+	}
+
+	if _, err := LoadProfile("qa"); err == nil {
+		t.Error("expected an unknown profile to be rejected")
+	}
+}
+
+func TestValidateProfileRequirements(t *testing.T) {
+	cfg := Defaults()
+	cfg.Profile = ProfileProd
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected prod without DATABASE_URL to be rejected")
+	}
+	cfg.DatabaseURL = "postgres://db"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected prod without NEON_AUTH_BASE_URL to be rejected")
+	}
+	cfg.NeonAuthBaseURL = "https://auth"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected a complete prod config to pass, got %v", err)
+	}
+
+	cfg = Defaults()
+	cfg.Profile = ProfileStaging
+	cfg.DatabaseURL = "postgres://db"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected staging to pass without auth, got %v", err)
+	}
+}
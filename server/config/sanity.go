@@ -0,0 +1,78 @@
+package config
+
+import "fmt"
+
+// validateTiming rejects timers that contradict each other, such as a turn countdown that would show
+// before the turn starts.
+func (c *Config) validateTiming() error {
+	if c.TurnLimitSec < 0 {
+		return fmt.Errorf("turn_limit_sec %d: must not be negative", c.TurnLimitSec)
+	}
+	if c.TurnLimitSec > 0 && c.TurnCountdownShowSec > c.TurnLimitSec {
+		return fmt.Errorf("turn_countdown_show_sec %d: exceeds turn_limit_sec %d", c.TurnCountdownShowSec, c.TurnLimitSec)
+	}
+	if c.RevealDurationMS <= 0 {
+		return fmt.Errorf("reveal_duration_ms %d: must be positive", c.RevealDurationMS)
+	}
+	if c.RevealDurationMaxMS > 0 && (c.RevealDurationMinMS < 0 || c.RevealDurationMinMS > c.RevealDurationMaxMS) {
+		return fmt.Errorf("reveal_duration_min_ms %d: must be between 0 and reveal_duration_max_ms %d", c.RevealDurationMinMS, c.RevealDurationMaxMS)
+	}
+	if c.AIPairTimeoutMaxSec > 0 {
+		if c.AIPairTimeoutMinSec < 0 || c.AIPairTimeoutMinSec > c.AIPairTimeoutSec || c.AIPairTimeoutSec > c.AIPairTimeoutMaxSec {
+			return fmt.Errorf("ai_pair_timeout_sec %d: must be between ai_pair_timeout_min_sec %d and ai_pair_timeout_max_sec %d", c.AIPairTimeoutSec, c.AIPairTimeoutMinSec, c.AIPairTimeoutMaxSec)
+		}
+		if c.AIPairBusyPlayers <= 0 {
+			return fmt.Errorf("ai_pair_busy_players %d: must be positive", c.AIPairBusyPlayers)
+		}
+	}
+	if c.PowerUps.Clairvoyance.RevealDurationMS < 0 {
+		return fmt.Errorf("clairvoyance reveal_duration_ms %d: must not be negative", c.PowerUps.Clairvoyance.RevealDurationMS)
+	}
+	return nil
+}
+
+// validateAIProfiles rejects AI profiles with delay ranges out of order, a pause between flips shorter than
+// minFlipSpacingMS (the game would reject the bot's flips as too fast, see MinFlipSpacingMS) and chances
+// outside 0-100.
+func validateAIProfiles(profiles []AIParams, minFlipSpacingMS int) error {
+	for _, p := range profiles {
+		if err := p.validate(minFlipSpacingMS); err != nil {
+			return fmt.Errorf("ai profile %q: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *AIParams) validate(minFlipSpacingMS int) error {
+	if p.DelayMinMS < 0 || p.DelayMinMS > p.DelayMaxMS {
+		return fmt.Errorf("delay_min_ms %d: must be between 0 and delay_max_ms %d", p.DelayMinMS, p.DelayMaxMS)
+	}
+	if (p.SecondFlipDelayMinMS != 0 || p.SecondFlipDelayMaxMS != 0) &&
+		(p.SecondFlipDelayMinMS < 0 || p.SecondFlipDelayMinMS > p.SecondFlipDelayMaxMS) {
+		return fmt.Errorf("second_flip_delay_min_ms %d: must be between 0 and second_flip_delay_max_ms %d", p.SecondFlipDelayMinMS, p.SecondFlipDelayMaxMS)
+	}
+	// Later flips of a turn wait the second-flip pause, or the first-flip one when the profile sets none.
+	if p.SecondFlipDelayMinMS == 0 && p.SecondFlipDelayMaxMS == 0 {
+		if p.DelayMinMS < minFlipSpacingMS {
+			return fmt.Errorf("delay_min_ms %d: must be at least min_flip_spacing_ms %d", p.DelayMinMS, minFlipSpacingMS)
+		}
+	} else if p.SecondFlipDelayMinMS < minFlipSpacingMS {
+		return fmt.Errorf("second_flip_delay_min_ms %d: must be at least min_flip_spacing_ms %d", p.SecondFlipDelayMinMS, minFlipSpacingMS)
+	}
+	if p.ThinkMaxExtraMS < 0 {
+		return fmt.Errorf("think_max_extra_ms %d: must not be negative", p.ThinkMaxExtraMS)
+	}
+	for _, chance := range []struct {
+		name  string
+		value int
+	}{
+		{"use_best_move_chance", p.UseBestMoveChance},
+		{"forget_chance", p.ForgetChance},
+		{"arcana_randomness", p.ArcanaRandomness},
+	} {
+		if chance.value < 0 || chance.value > 100 {
+			return fmt.Errorf("%s %d: must be between 0 and 100", chance.name, chance.value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestValidateTiming(t *testing.T) {
+	cfg := Defaults()
+	cfg.TurnCountdownShowSec = cfg.TurnLimitSec + 1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a countdown longer than the turn to be rejected")
+	}
+	cfg.TurnLimitSec = 0 // no turn limit: the countdown is never shown
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no check without a turn limit, got %v", err)
+	}
+
+	cfg = Defaults()
+	cfg.RevealDurationMinMS, cfg.RevealDurationMaxMS = 2000, 1000
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected reveal durations out of order to be rejected")
+	}
+
+	cfg = Defaults()
+	cfg.AIPairTimeoutMinSec, cfg.AIPairTimeoutMaxSec = 5, 10 // AIPairTimeoutSec 15 is above the max
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an AI pairing timeout outside its adaptive bounds to be rejected")
+	}
+	cfg.AIPairTimeoutMaxSec = 30
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected adaptive AI pairing bounds around the timeout to pass, got %v", err)
+	}
+
+	limit := 10
+	cfg = Defaults()
+	cfg.Realms = map[string]RealmConfig{"kids": {TurnLimitSec: &limit}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a realm turn limit below the countdown to be rejected")
+	}
+}
+
+func TestValidateAIProfiles(t *testing.T) {
+	for name, mutate := range map[string]func(p *AIParams){
+		"delays out of order":       func(p *AIParams) { p.DelayMinMS, p.DelayMaxMS = 2000, 1000 },
+		"second flip out of order":  func(p *AIParams) { p.SecondFlipDelayMinMS, p.SecondFlipDelayMaxMS = 900, 100 },
+		"chance above 100":          func(p *AIParams) { p.UseBestMoveChance = 101 },
+		"negative forget chance":    func(p *AIParams) { p.ForgetChance = -1 },
+		"negative think extra time": func(p *AIParams) { p.ThinkMaxExtraMS = -5 },
+		"second flip too fast":      func(p *AIParams) { p.SecondFlipDelayMinMS = 20 },
+		"flips too fast":            func(p *AIParams) { p.DelayMinMS, p.SecondFlipDelayMinMS, p.SecondFlipDelayMaxMS = 20, 0, 0 },
+	} {
+		cfg := Defaults()
+		mutate(&cfg.AIProfiles[0])
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: expected the AI profile to be rejected", name)
+		}
+	}
+}
+
+func TestValidateSeasons(t *testing.T) {
+	cfg := Defaults()
+	cfg.Seasons.LengthDays = 30
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected 30-day seasons to be valid, got %v", err)
+	}
+	cfg.Seasons.CarryOverPct = 120
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a carry-over above 100% to be rejected")
+	}
+	cfg = Defaults()
+	cfg.Seasons.LengthDays = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a negative season length to be rejected")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"memory-game-server/config"
+)
+
+// runCommand runs the subcommand in args (the arguments after the program name) instead of the server.
+// Returns the process exit code.
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "config":
+		if len(args) > 1 && args[1] == "validate" {
+			return runConfigValidate(args[2:], stdout, stderr)
+		}
+	case "fairness":
+		if len(args) > 1 && args[1] == "check" {
+			return runFairnessCheck(args[2:], stdout, stderr)
+		}
+	}
+	fmt.Fprintln(stderr, "usage: run-app [config validate [-profile dev|staging|prod] | fairness check [-samples n]]")
+	return 2
+}
+
+// effectiveConfig is what "config validate" prints: the resolved config and whether the secrets that are
+// never printed are set.
+type effectiveConfig struct {
+	Profile        string         `json:"profile"`
+	DatabaseURLSet bool           `json:"database_url_set"`
+	NeonAuthURLSet bool           `json:"neon_auth_base_url_set"`
+	Config         *config.Config `json:"config"`
+}
+
+// runConfigValidate loads the config the server would start with (config file, env and the profile from
+// -profile or CONFIG_PROFILE), runs every check in config.Validate and prints the effective config as
+// JSON. Exits non-zero when the config is invalid, so deployments can run it before starting the server.
+func runConfigValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", os.Getenv("CONFIG_PROFILE"), "environment profile: dev, staging or prod")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := config.LoadProfile(*profile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	out := effectiveConfig{
+		Profile:        cfg.Profile,
+		DatabaseURLSet: cfg.DatabaseURL != "",
+		NeonAuthURLSet: cfg.NeonAuthBaseURL != "",
+		Config:         cfg,
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stderr, "config OK")
+	return 0
+}
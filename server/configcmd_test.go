@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestConfigValidateCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCommand([]string{"config", "validate", "-profile", "dev"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var out struct {
+		Profile string `json:"profile"`
+		Config  struct {
+			LogLevel string `json:"log_level"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("expected the effective config as JSON: %v", err)
+	}
+	if out.Profile != "dev" || out.Config.LogLevel != "debug" {
+		t.Errorf("expected the dev profile's config, got %+v", out)
+	}
+
+	t.Setenv("TURN_LIMIT_SEC", "20")
+	t.Setenv("TURN_COUNTDOWN_SHOW_SEC", "30")
+	stdout.Reset()
+	stderr.Reset()
+	if code := runCommand([]string{"config", "validate"}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 for a countdown longer than the turn, got %d", code)
+	}
+	if stdout.Len() != 0 {
+		t.Error("expected no config printed for an invalid config")
+	}
+
+	if code := runCommand([]string{"serve"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for an unknown command, got %d", code)
+	}
+}
@@ -0,0 +1,222 @@
+// Package fairness checks that match seeding is unbiased: board layouts, the arcana dealt per match and
+// the first turn. Each check deals many matches through the production code paths and compares the
+// outcome counts with the expected distribution using a chi-square test.
+package fairness
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/powerup"
+)
+
+// MaxZ is the largest normalized chi-square (see Result.Z) a check may reach and still pass. A fair source
+// exceeds it about once in three million tests, while a real bias of a few percent exceeds it within a few
+// thousand samples.
+const MaxZ = 5.0
+
+// Result is the outcome of one check.
+type Result struct {
+	Check     string  `json:"check"`
+	Samples   int     `json:"samples"`
+	ChiSquare float64 `json:"chi_square"`
+	DF        int     `json:"df"`
+	// Z is ChiSquare mapped to a standard normal score (Wilson-Hilferty): about 0 for a fair source,
+	// growing with the bias. For a check made of several tests (e.g. one per board position), it is the worst.
+	Z    float64 `json:"z"`
+	Pass bool    `json:"pass"`
+}
+
+// String formats r on one line for the fairness command.
+func (r Result) String() string {
+	verdict := "ok"
+	if !r.Pass {
+		verdict = "BIASED"
+	}
+	return fmt.Sprintf("%-28s samples=%d chi2=%.1f df=%d z=%.2f %s", r.Check, r.Samples, r.ChiSquare, r.DF, r.Z, verdict)
+}
+
+// Run deals samples matches per check on cfg's board and returns every check's result. reg should be
+// built like the server's (powerup.RegisterAll) so the arcana check sees the production rarities.
+func Run(cfg *config.Config, reg *powerup.Registry, samples int) ([]Result, error) {
+	results := []Result{BoardPositions(cfg.BoardRows, cfg.BoardCols, samples)}
+	results = append(results, ArcanaSlots(reg, samples)...)
+	for seats := 2; seats <= game.MaxSeats; seats++ {
+		r, err := FirstTurn(cfg, seats, samples)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// BoardPositions deals samples boards with game.NewBoard and checks that every position is equally likely
+// to hold each pair. The result is the position that deviates most.
+func BoardPositions(rows, cols, samples int) Result {
+	cells := rows * cols
+	pairs := cells / 2
+	counts := make([][]int, cells)
+	for i := range counts {
+		counts[i] = make([]int, pairs)
+	}
+	for range samples {
+		board := game.NewBoard(rows, cols, game.ArcanaPairsPerMatch)
+		for i, card := range board.Cards {
+			counts[i][card.PairID]++
+		}
+	}
+	expected := make([]float64, pairs)
+	for i := range expected {
+		expected[i] = float64(samples) / float64(pairs)
+	}
+	var worst Result
+	for i, observed := range counts {
+		r := newResult("board positions "+strconv.Itoa(rows)+"x"+strconv.Itoa(cols), samples, observed, expected)
+		if i == 0 || r.Z > worst.Z {
+			worst = r
+		}
+	}
+	return worst
+}
+
+// ArcanaSlots draws samples arcana sets with reg.PickArcanaForMatch and checks every weighted slot against
+// its exact distribution under powerup.RarityWeight. RarityMust cards fill the first slots unconditionally
+// and are not checked. It returns one result per weighted slot, none when the pool is too small to choose from.
+func ArcanaSlots(reg *powerup.Registry, samples int) []Result {
+	n := game.ArcanaPairsPerMatch
+	var pool []game.PowerUpDef
+	must := 0
+	for _, p := range reg.AllPowerUps() {
+		switch p.Rarity {
+		case powerup.RarityShopOnly:
+		case powerup.RarityMust:
+			must++
+		default:
+			pool = append(pool, p)
+		}
+	}
+	slots := n - must
+	if slots <= 0 || slots >= len(pool) {
+		return nil
+	}
+	index := make(map[string]int, len(pool))
+	weights := make([]float64, len(pool))
+	for i, p := range pool {
+		index[p.ID] = i
+		weights[i] = float64(powerup.RarityWeight(p.Rarity))
+	}
+
+	counts := make([][]int, slots)
+	for k := range counts {
+		counts[k] = make([]int, len(pool))
+	}
+	for range samples {
+		picked := reg.PickArcanaForMatch(n)
+		for k := range slots {
+			counts[k][index[picked[must+k].ID]]++
+		}
+	}
+
+	probs := slotProbabilities(weights, slots)
+	results := make([]Result, slots)
+	for k := range slots {
+		expected := make([]float64, len(pool))
+		for i, p := range probs[k] {
+			expected[i] = p * float64(samples)
+		}
+		results[k] = newResult("arcana slot "+strconv.Itoa(must+k+1), samples, counts[k], expected)
+	}
+	return results
+}
+
+// slotProbabilities returns, for weighted draws without replacement, the probability of each item at each
+// of the first slots draws. It walks every set of items that can be drawn first, which stays small for the
+// arcana pool.
+func slotProbabilities(weights []float64, slots int) [][]float64 {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	probs := make([][]float64, slots)
+	// reach holds the probability of having drawn exactly each set (a bitmask of items) so far.
+	reach := map[uint64]float64{0: 1}
+	for k := range slots {
+		probs[k] = make([]float64, len(weights))
+		next := make(map[uint64]float64)
+		for set, p := range reach {
+			left := total
+			for i, w := range weights {
+				if set&(1<<i) != 0 {
+					left -= w
+				}
+			}
+			for i, w := range weights {
+				if set&(1<<i) != 0 {
+					continue
+				}
+				q := p * w / left
+				probs[k][i] += q
+				next[set|1<<i] += q
+			}
+		}
+		reach = next
+	}
+	return probs
+}
+
+// FirstTurn starts samples games for seats players (game.NewPartyGame, which shares the seeding of
+// game.NewGame) and checks that every seat is equally likely to move first.
+func FirstTurn(cfg *config.Config, seats, samples int) (Result, error) {
+	counts := make([]int, seats)
+	for range samples {
+		players := make([]*game.Player, seats)
+		for i := range players {
+			players[i] = game.NewPlayer("Seat "+strconv.Itoa(i+1), nil)
+		}
+		g, err := game.NewPartyGame("fairness", cfg, players, nil)
+		if err != nil {
+			return Result{}, err
+		}
+		counts[g.CurrentTurn]++
+	}
+	expected := make([]float64, seats)
+	for i := range expected {
+		expected[i] = float64(samples) / float64(seats)
+	}
+	return newResult("first turn "+strconv.Itoa(seats)+" seats", samples, counts, expected), nil
+}
+
+// newResult runs the chi-square test of observed against expected counts. A count in a cell expected to
+// stay empty fails the check outright.
+func newResult(check string, samples int, observed []int, expected []float64) Result {
+	r := Result{Check: check, Samples: samples}
+	for i, e := range expected {
+		if e == 0 {
+			if observed[i] > 0 {
+				r.Z = math.Inf(1)
+			}
+			continue
+		}
+		d := float64(observed[i]) - e
+		r.ChiSquare += d * d / e
+		r.DF++
+	}
+	r.DF--
+	if r.DF > 0 && !math.IsInf(r.Z, 1) {
+		r.Z = normalScore(r.ChiSquare, r.DF)
+	}
+	r.Pass = r.Z <= MaxZ
+	return r
+}
+
+// normalScore maps a chi-square value with df degrees of freedom to a standard normal score using the
+// Wilson-Hilferty approximation.
+func normalScore(chiSquare float64, df int) float64 {
+	k := float64(df)
+	v := 2 / (9 * k)
+	return (math.Cbrt(chiSquare/k) - (1 - v)) / math.Sqrt(v)
+}
@@ -0,0 +1,63 @@
+package fairness
+
+import (
+	"math"
+	"testing"
+
+	"memory-game-server/config"
+	"memory-game-server/powerup"
+)
+
+func TestRun_ProductionSeedingIsFair(t *testing.T) {
+	cfg := config.Defaults()
+	reg := powerup.NewRegistry()
+	powerup.RegisterAll(reg, &cfg.PowerUps)
+
+	results, err := Run(cfg, reg, 4000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One board check, one per arcana slot and one per seat count from 2 to 4.
+	if want := 1 + 6 + 3; len(results) != want {
+		t.Fatalf("expected %d results, got %d", want, len(results))
+	}
+	for _, r := range results {
+		if !r.Pass || r.DF == 0 {
+			t.Errorf("expected a fair result, got %s", r)
+		}
+	}
+}
+
+func TestArcanaSlots_SeededRegistryIsFair(t *testing.T) {
+	for _, r := range ArcanaSlots(powerup.NewBuiltinRegistry(nil, 42), 4000) {
+		if !r.Pass {
+			t.Errorf("expected a fair result, got %s", r)
+		}
+	}
+}
+
+func TestSlotProbabilities(t *testing.T) {
+	probs := slotProbabilities([]float64{2, 1, 1}, 2)
+	want := [][]float64{{0.5, 0.25, 0.25}, {1.0 / 3, 1.0 / 3, 1.0 / 3}}
+	for k := range want {
+		for i := range want[k] {
+			if math.Abs(probs[k][i]-want[k][i]) > 1e-9 {
+				t.Errorf("slot %d item %d: expected %.4f, got %.4f", k, i, want[k][i], probs[k][i])
+			}
+		}
+	}
+}
+
+func TestNewResult_FlagsBias(t *testing.T) {
+	expected := []float64{1000, 1000, 1000, 1000}
+	if r := newResult("fair", 4000, []int{1010, 985, 1003, 1002}, expected); !r.Pass || r.DF != 3 {
+		t.Errorf("expected a fair result, got %s", r)
+	}
+	// One outcome 20% more frequent than it should be.
+	if r := newResult("biased", 4000, []int{1200, 940, 930, 930}, expected); r.Pass {
+		t.Errorf("expected a bias to be flagged, got %s", r)
+	}
+	if r := newResult("impossible", 2, []int{1, 1}, []float64{2, 0}); r.Pass {
+		t.Errorf("expected a count in an empty cell to be flagged, got %s", r)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"memory-game-server/config"
+	"memory-game-server/fairness"
+	"memory-game-server/powerup"
+)
+
+// defaultFairnessSamples is how many matches "fairness check" deals per check by default.
+const defaultFairnessSamples = 20000
+
+// runFairnessCheck deals many matches with the configured board and power-ups (see package fairness) and
+// prints one line per check. Exits non-zero when a check finds the board shuffle, the arcana draw or the
+// first turn biased.
+func runFairnessCheck(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fairness check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", os.Getenv("CONFIG_PROFILE"), "environment profile: dev, staging or prod")
+	samples := fs.Int("samples", defaultFairnessSamples, "matches dealt per check")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *samples < 100 {
+		fmt.Fprintln(stderr, "-samples must be at least 100")
+		return 2
+	}
+	cfg, err := config.LoadProfile(*profile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	registry := powerup.NewRegistry()
+	powerup.RegisterAll(registry, &cfg.PowerUps)
+	results, err := fairness.Run(cfg, registry, *samples)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	failed := 0
+	for _, r := range results {
+		fmt.Fprintln(stdout, r)
+		if !r.Pass {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(stderr, "fairness check failed: %d of %d checks biased (z > %.0f)\n", failed, len(results), fairness.MaxZ)
+		return 1
+	}
+	fmt.Fprintln(stderr, "fairness OK")
+	return 0
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFairnessCheckCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runCommand([]string{"fairness", "check", "-profile", "dev", "-samples", "2000"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "first turn 4 seats") || !strings.Contains(stderr.String(), "fairness OK") {
+		t.Errorf("expected a line per check and a verdict, got %q and %q", stdout.String(), stderr.String())
+	}
+
+	if code := runCommand([]string{"fairness", "check", "-samples", "10"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 for too few samples, got %d", code)
+	}
+}
@@ -3,8 +3,6 @@ package game
 import (
 	"encoding/json"
 	"time"
-
-	"memory-game-server/wsutil"
 )
 
 func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
@@ -14,14 +12,18 @@ func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
 		return
 	}
 
-	// Validate turn phase (must be FirstFlip or SecondFlip)
+	// Validate turn phase (must be FirstFlip, SecondFlip or ThirdFlip)
+	if g.TurnPhase == Draft {
+		g.sendError(playerIdx, "Pick your starting arcana first.")
+		return
+	}
 	if g.TurnPhase == Resolve {
 		g.sendError(playerIdx, "Please wait for the current turn to resolve.")
 		return
 	}
 
 	// Validate card index bounds
-	totalCards := g.Config.BoardRows * g.Config.BoardCols
+	totalCards := len(g.Board.Cards)
 	if cardIndex < 0 || cardIndex >= totalCards {
 		g.sendError(playerIdx, "Card index out of bounds.")
 		return
@@ -44,11 +46,14 @@ func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
 	}
 
 	// Flip the card
+	g.noteBlindGuess(playerIdx, cardIndex)
 	card.State = Revealed
 	if g.KnownIndices != nil {
 		g.KnownIndices[cardIndex] = struct{}{}
 	}
+	g.markSeen(cardIndex)
 	g.FlippedIndices = append(g.FlippedIndices, cardIndex)
+	g.recordReplay(ReplayFlip, playerIdx, cardIndex, "", "")
 
 	if g.TurnPhase == FirstFlip {
 		// First card flipped - advance to SecondFlip phase
@@ -57,21 +62,33 @@ func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
 		return
 	}
 
-	// Second card flipped - check for match
-	card1 := &g.Board.Cards[g.FlippedIndices[0]]
-	card2 := &g.Board.Cards[g.FlippedIndices[1]]
+	// Second card flipped (or third, with Third Eye) - check for match
+	idx1, idx2, matched := g.flippedPair()
+	if !matched && g.TurnPhase == SecondFlip && g.Players[playerIdx].ThirdEyeActive {
+		// Third Eye: one more flip may pair with either revealed card
+		g.TurnPhase = ThirdFlip
+		g.broadcastState()
+		return
+	}
+	card1 := &g.Board.Cards[idx1]
+	card2 := &g.Board.Cards[idx2]
 
-	if card1.PairID == card2.PairID {
-		// Match found!
+	if matched {
+		// Match found! With Third Eye, the flipped card outside the pair goes face down again.
+		for _, idx := range g.FlippedIndices {
+			if idx != idx1 && idx != idx2 {
+				g.Board.Cards[idx].State = Hidden
+			}
+		}
 		card1.State = Matched
 		card2.State = Matched
 
 		player := g.Players[playerIdx]
-		points := 1
+		points := PointsPerMatch
 		player.Score += points
-		// Leech: subtract same amount from opponent (minimum 0)
+		// Leech: subtract same amount from opponent (minimum 0); in a party game, from the best-scoring rival
 		if player.LeechActive {
-			opponent := g.Players[1-playerIdx]
+			opponent := g.Players[g.opponentOf(playerIdx)]
 			opponent.Score -= points
 			if opponent.Score < 0 {
 				opponent.Score = 0
@@ -80,33 +97,27 @@ func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
 		// Blood Pact: count consecutive matches; at 3 grant +5 and clear
 		if player.BloodPactActive {
 			player.BloodPactMatchesCount++
-			if player.BloodPactMatchesCount >= 3 {
-				player.Score += 5
+			if player.BloodPactMatchesCount >= BloodPactMatchesNeeded {
+				player.Score += BloodPactBonus
 				g.broadcastPowerUpEffectResolved(player.Name, "Blood Pact", player.Name+" honored the Pact and gained 5 points")
 				player.BloodPactActive = false
 				player.BloodPactMatchesCount = 0
 			}
 		}
 
-		// Grant power-up for this pair if mapped (pairId 0, 1, 2 -> first power-ups in registry order)
-		if powerUpID, ok := g.PairIDToPowerUp[card1.PairID]; ok {
-			if player.Hand == nil {
-				player.Hand = make(map[string]int)
-			}
-			if player.HandCooldown == nil {
-				player.HandCooldown = make(map[string]int)
-			}
-			player.Hand[powerUpID]++
-			player.HandCooldown[powerUpID]++
-		}
+		// Grant power-up for this pair if mapped (pairId 0, 1, 2 -> first power-ups in registry order); a full hand
+		// applies the overflow rule. Normal pairs count toward the arcana pity timer.
+		g.awardMatchArcana(playerIdx, card1.PairID)
 
 		g.FlippedIndices = g.FlippedIndices[:0]
 		g.TurnPhase = FirstFlip
+		g.missStreak = 0
+		g.recordReplay(ReplayMatch, playerIdx, -1, "", "")
 
-		// End of match: clear highlight for both players; Leech lasts whole turn (cleared on mismatch/timeout)
-		for i := range 2 {
-			if g.Players[i] != nil {
-				g.Players[i].HighlightIndices = nil
+		// End of match: clear highlight for all players; Leech lasts whole turn (cleared on mismatch/timeout)
+		for _, p := range g.Players {
+			if p != nil {
+				p.HighlightIndices = nil
 			}
 		}
 		// Blood Pact is not cleared on match; only on mismatch or timeout
@@ -124,6 +135,7 @@ func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
 		g.cancelTurnTimer()
 		g.startTurnTimer()
 		g.broadcastState()
+		g.endIfInsurmountable()
 	} else {
 		// No match - enter resolve phase, broadcast the revealed state,
 		// then schedule hiding the cards after the reveal duration.
@@ -140,7 +152,7 @@ func (g *Game) handleFlipCard(playerIdx int, cardIndex int) {
 			case g.Actions <- Action{Type: ActionResolveMismatch, PlayerIdx: pIdx}:
 			case <-g.Done:
 			}
-		}(playerIdx, g.Config.RevealDurationMS)
+		}(playerIdx, g.RevealDurationMS())
 	}
 }
 
@@ -156,16 +168,9 @@ func (g *Game) handleResolveMismatch(playerIdx int) {
 		g.Board.Cards[idx].State = Hidden
 	}
 
-	// End of turn: clear highlight for both players and Leech (effects last only this turn)
-	for i := range 2 {
-		if g.Players[i] != nil {
-			g.Players[i].HighlightIndices = nil
-		}
-	}
-	player.LeechActive = false
 	// Blood Pact: failed (mismatch); lose 3 points and clear pact
 	if player.BloodPactActive {
-		player.Score -= 3
+		player.Score -= BloodPactPenalty
 		if player.Score < 0 {
 			player.Score = 0
 		}
@@ -175,25 +180,56 @@ func (g *Game) handleResolveMismatch(playerIdx int) {
 	}
 
 	g.FlippedIndices = g.FlippedIndices[:0]
-	// Record turn telemetry for the turn that just ended (before advancing Round/CurrentTurn)
-	if g.TelemetrySink != nil {
-		pidx := g.CurrentTurn
-		scoreAfter := g.Players[pidx].Score
-		oppScoreAfter := g.Players[1-pidx].Score
-		deltaPlayer := scoreAfter - g.TurnStartScores[pidx]
-		deltaOpponent := oppScoreAfter - g.TurnStartScores[1-pidx]
-		g.TelemetrySink.RecordTurn(g.ID, g.Round, pidx, scoreAfter, oppScoreAfter, deltaPlayer, deltaOpponent)
+
+	// Mismatch retries variant: the player keeps the turn until the misses in a row exceed the retries.
+	if g.missStreak < g.Config.MismatchRetries {
+		g.missStreak++
+		g.TurnPhase = FirstFlip
+		g.recordReplay(ReplayMismatch, playerIdx, -1, "", "")
+		g.cancelTurnTimer()
+		g.startTurnTimer()
+		g.broadcastState()
+		g.endIfInsurmountable()
+		return
 	}
+	g.missStreak = 0
+
+	// End of turn: clear highlight for all players and Leech (effects last only this turn)
+	for _, p := range g.Players {
+		if p != nil {
+			p.HighlightIndices = nil
+		}
+	}
+	player.LeechActive = false
+	player.ThirdEyeActive = false
+	// Record the turn that just ended (before advancing Round/CurrentTurn)
+	g.recordTurn(TurnEndMismatch)
 	g.Round++
-	g.CurrentTurn = 1 - g.CurrentTurn
+	g.CurrentTurn = g.nextSeat(g.CurrentTurn)
+	g.rotateTeam(g.CurrentTurn)
 	g.TurnPhase = FirstFlip
-	g.TurnStartScores[0] = g.Players[0].Score
-	g.TurnStartScores[1] = g.Players[1].Score
+	g.noteTurnStartScores()
+	g.recordReplay(ReplayMismatch, playerIdx, -1, "", "")
 
 	g.clearHandCooldownForPlayer(g.CurrentTurn)
 	g.cancelTurnTimer()
 	g.startTurnTimer()
 	g.broadcastState()
+	g.endIfInsurmountable()
+}
+
+// flippedPair returns two flipped cards that share a pair, or the first two flipped cards and false
+// when none do. Only Third Eye turns have more than two flipped cards.
+func (g *Game) flippedPair() (idx1, idx2 int, matched bool) {
+	flipped := g.FlippedIndices
+	for i := 0; i < len(flipped); i++ {
+		for j := i + 1; j < len(flipped); j++ {
+			if g.Board.Cards[flipped[i]].PairID == g.Board.Cards[flipped[j]].PairID {
+				return flipped[i], flipped[j], true
+			}
+		}
+	}
+	return flipped[0], flipped[1], false
 }
 
 func (g *Game) clearHandCooldownForPlayer(playerIdx int) {
@@ -210,6 +246,7 @@ func (g *Game) handleTurnTimeout() {
 	g.cancelTurnTimer()
 
 	g.broadcastTurnTimeout()
+	g.missStreak = 0
 
 	// Hide any flipped cards
 	for _, idx := range g.FlippedIndices {
@@ -217,16 +254,17 @@ func (g *Game) handleTurnTimeout() {
 	}
 	player := g.Players[g.CurrentTurn]
 	if player != nil {
-		// End of turn: clear highlight for both players and Leech (effects last only this turn)
-		for i := range 2 {
-			if g.Players[i] != nil {
-				g.Players[i].HighlightIndices = nil
+		// End of turn: clear highlight for all players and Leech (effects last only this turn)
+		for _, p := range g.Players {
+			if p != nil {
+				p.HighlightIndices = nil
 			}
 		}
 		player.LeechActive = false
+		player.ThirdEyeActive = false
 		// Blood Pact: turn timeout counts as failure; lose 3 points and clear pact
 		if player.BloodPactActive {
-			player.Score -= 3
+			player.Score -= BloodPactPenalty
 			if player.Score < 0 {
 				player.Score = 0
 			}
@@ -236,32 +274,26 @@ func (g *Game) handleTurnTimeout() {
 		}
 	}
 	g.FlippedIndices = g.FlippedIndices[:0]
-	// Record turn telemetry for the turn that just ended (before advancing Round/CurrentTurn)
-	if g.TelemetrySink != nil {
-		pidx := g.CurrentTurn
-		scoreAfter := g.Players[pidx].Score
-		oppScoreAfter := g.Players[1-pidx].Score
-		deltaPlayer := scoreAfter - g.TurnStartScores[pidx]
-		deltaOpponent := oppScoreAfter - g.TurnStartScores[1-pidx]
-		g.TelemetrySink.RecordTurn(g.ID, g.Round, pidx, scoreAfter, oppScoreAfter, deltaPlayer, deltaOpponent)
-	}
+	// Record the turn that just ended (before advancing Round/CurrentTurn)
+	g.recordTurn(TurnEndTimeout)
+	timedOut := g.CurrentTurn
 	g.Round++
-	g.CurrentTurn = 1 - g.CurrentTurn
+	g.CurrentTurn = g.nextSeat(g.CurrentTurn)
+	g.rotateTeam(g.CurrentTurn)
 	g.TurnPhase = FirstFlip
-	g.TurnStartScores[0] = g.Players[0].Score
-	g.TurnStartScores[1] = g.Players[1].Score
+	g.noteTurnStartScores()
+	g.recordReplay(ReplayTimeout, timedOut, -1, "", "")
 
 	g.clearHandCooldownForPlayer(g.CurrentTurn)
 	g.startTurnTimer()
 	g.broadcastState()
+	g.endIfInsurmountable()
 }
 
 func (g *Game) broadcastTurnTimeout() {
 	msg := map[string]string{"type": "turn_timeout"}
 	data, _ := json.Marshal(msg)
-	for i := range 2 {
-		if g.Players[i] != nil && g.Players[i].Send != nil {
-			wsutil.SafeSend(g.Players[i].Send, data)
-		}
+	for i := range g.Players {
+		g.sendToSeat(i, data)
 	}
 }
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"fmt"
 	"time"
 )
 
@@ -49,20 +50,29 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 	}
 
 	player := g.Players[playerIdx]
-	if player.Hand[powerUpID] < 1 {
-		g.sendError(playerIdx, "You don't have this power-up in hand.")
-		return
-	}
 	cooldown := 0
 	if player.HandCooldown != nil {
 		cooldown = player.HandCooldown[powerUpID]
 	}
-	if player.Hand[powerUpID]-cooldown < 1 {
+	// Without a usable copy, a power-up with a price is bought with score points instead.
+	purchased := false
+	switch {
+	case player.Hand[powerUpID]-cooldown >= 1:
+	case pup.Cost > 0:
+		if player.Score < pup.Cost {
+			g.sendError(playerIdx, fmt.Sprintf("You need %d points to buy %s.", pup.Cost, pup.Name))
+			return
+		}
+		purchased = true
+	case player.Hand[powerUpID] < 1:
+		g.sendError(playerIdx, "You don't have this power-up in hand.")
+		return
+	default:
 		g.sendError(playerIdx, "This arcana can only be used on your next turn.")
 		return
 	}
 
-	totalCards := g.Config.BoardRows * g.Config.BoardCols
+	totalCards := len(g.Board.Cards)
 
 	// Clairvoyance: require a valid card target (hidden card)
 	if powerUpID == "clairvoyance" {
@@ -75,6 +85,17 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 			return
 		}
 	}
+	// Peek: require a valid hidden card target
+	if powerUpID == "peek" {
+		if cardIndex < 0 || cardIndex >= totalCards {
+			g.sendError(playerIdx, "Peek requires a valid card target.")
+			return
+		}
+		if g.Board.Cards[cardIndex].State != Hidden {
+			g.sendError(playerIdx, "Peek target card must be hidden.")
+			return
+		}
+	}
 	// Oblivion: require a valid hidden card target
 	if powerUpID == "oblivion" {
 		if cardIndex < 0 || cardIndex >= totalCards {
@@ -87,10 +108,11 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 		}
 	}
 
-	// Consume one from hand
-	player.Hand[powerUpID]--
-	if player.Hand[powerUpID] == 0 {
-		delete(player.Hand, powerUpID)
+	// Consume one from hand, or pay the price
+	if purchased {
+		player.Score -= pup.Cost
+	} else {
+		removeFromHand(player, powerUpID)
 	}
 
 	// Clairvoyance: reveal 3x3 region and schedule hiding after duration
@@ -109,6 +131,7 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 				if g.KnownIndices != nil {
 					g.KnownIndices[idx] = struct{}{}
 				}
+				g.markSeen(idx)
 			}
 		}
 	}
@@ -123,7 +146,7 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 	}
 
 	// Apply effect (Clairvoyance has no-op Apply; logic is above)
-	opponent := g.Players[1-playerIdx]
+	opponent := g.Players[g.opponentOf(playerIdx)]
 	selfPairID := -1
 	for pairID, id := range g.PairIDToPowerUp {
 		if id == powerUpID {
@@ -132,33 +155,39 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 		}
 	}
 	playerScoreBefore := g.Players[playerIdx].Score
-	opponentScoreBefore := g.Players[1-playerIdx].Score
+	opponentScoreBefore := opponent.Score
 	pairsMatchedBefore := CountMatchedPairs(g.Board)
 
 	ctx := &PowerUpContext{SelfPairID: selfPairID}
+	layoutBefore := g.pairIDs()
 	if err := pup.Apply(g.Board, player, opponent, ctx); err != nil {
 		// Revert Clairvoyance reveals if any; power-up already consumed
 		for _, idx := range clairvoyanceRevealIndices {
 			g.Board.Cards[idx].State = Hidden
 		}
+		if purchased {
+			player.Score += pup.Cost
+		}
 		g.sendError(playerIdx, "Power-up failed: "+err.Error())
 		return
 	}
+	g.forgetMoved(layoutBefore)
 
+	targetIdx := cardIndex
+	if powerUpID != "clairvoyance" && powerUpID != "oblivion" && powerUpID != "peek" {
+		targetIdx = -1
+	}
 	if g.TelemetrySink != nil {
-		targetIdx := cardIndex
-		if powerUpID != "clairvoyance" && powerUpID != "oblivion" {
-			targetIdx = -1
-		}
 		g.TelemetrySink.RecordArcanaUse(g.ID, g.Round, playerIdx, powerUpID, targetIdx, playerScoreBefore, opponentScoreBefore, pairsMatchedBefore)
 	}
+	g.recordReplay(ReplayArcana, playerIdx, targetIdx, powerUpID, "")
 
-	// Chaos: clear known indices and highlight for both players
+	// Chaos: clear known indices and highlight for all players
 	if powerUpID == "chaos" {
 		g.KnownIndices = make(map[int]struct{})
-		for i := range 2 {
-			if g.Players[i] != nil {
-				g.Players[i].HighlightIndices = nil
+		for _, p := range g.Players {
+			if p != nil {
+				p.HighlightIndices = nil
 			}
 		}
 	}
@@ -181,8 +210,7 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 		}
 		if targetElement != "" {
 			indices := elementalHighlightIndices(g.Board, targetElement)
-			player.HighlightIndices = indices
-			opponent.HighlightIndices = indices // same info for both players
+			g.highlightForAll(indices) // same info for all players
 		}
 	}
 
@@ -195,13 +223,16 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 				indices = append(indices, i)
 			}
 		}
-		player.HighlightIndices = indices
-		opponent.HighlightIndices = indices // same info for both players
+		g.highlightForAll(indices) // same info for all players
 	}
 	// Leech: this turn, match points are subtracted from opponent
 	if powerUpID == "leech" {
 		player.LeechActive = true
 	}
+	// Third Eye: this turn, a mismatched second flip allows a third flip
+	if powerUpID == "third_eye" {
+		player.ThirdEyeActive = true
+	}
 	// Blood Pact: next 3 matches grant +5; first mismatch or timeout loses 3
 	if powerUpID == "blood_pact" {
 		player.BloodPactActive = true
@@ -219,18 +250,25 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 	powerUpLabel := pup.Name
 	g.broadcastPowerUpUsed(player.Name, powerUpLabel, noEffect)
 
+	// Peek: show the target card to the buyer only; the board does not change
+	if powerUpID == "peek" {
+		g.sendPeekResult(playerIdx, cardIndex)
+		g.markSeenBy(playerIdx, cardIndex)
+	}
+
 	// Silence: pass turn immediately without revealing a pair
 	if powerUpID == "silence" {
-		// End of turn: clear highlight for both players and Leech
-		for i := range 2 {
-			if g.Players[i] != nil {
-				g.Players[i].HighlightIndices = nil
+		// End of turn: clear highlight for all players and Leech
+		for _, p := range g.Players {
+			if p != nil {
+				p.HighlightIndices = nil
 			}
 		}
 		player.LeechActive = false
+		player.ThirdEyeActive = false
 		// Blood Pact: passing turn counts as failure; lose 3 points and clear pact
 		if player.BloodPactActive {
-			player.Score -= 3
+			player.Score -= BloodPactPenalty
 			if player.Score < 0 {
 				player.Score = 0
 			}
@@ -238,44 +276,26 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 			player.BloodPactActive = false
 			player.BloodPactMatchesCount = 0
 		}
-		// Record turn telemetry, advance turn, start timer for next player
-		if g.TelemetrySink != nil {
-			pidx := g.CurrentTurn
-			scoreAfter := g.Players[pidx].Score
-			oppScoreAfter := g.Players[1-pidx].Score
-			deltaPlayer := scoreAfter - g.TurnStartScores[pidx]
-			deltaOpponent := oppScoreAfter - g.TurnStartScores[1-pidx]
-			g.TelemetrySink.RecordTurn(g.ID, g.Round, pidx, scoreAfter, oppScoreAfter, deltaPlayer, deltaOpponent)
-		}
+		// Record the turn, advance turn, start timer for next player
+		g.recordTurn(TurnEndPass)
 		g.Round++
-		g.CurrentTurn = 1 - g.CurrentTurn
+		g.CurrentTurn = g.nextSeat(g.CurrentTurn)
+		g.rotateTeam(g.CurrentTurn)
 		g.TurnPhase = FirstFlip
-		g.TurnStartScores[0] = g.Players[0].Score
-		g.TurnStartScores[1] = g.Players[1].Score
+		g.missStreak = 0
+		g.noteTurnStartScores()
+		g.recordReplay(ReplayPass, playerIdx, -1, powerUpID, "")
 		g.clearHandCooldownForPlayer(g.CurrentTurn)
 		g.cancelTurnTimer()
 		g.startTurnTimer()
 		g.broadcastState()
+		g.endIfInsurmountable()
 		return
 	}
 
-	// Broadcast updated state (turn does not end)
-	g.broadcastState()
-
-	// Oblivion may have removed the last pair(s); check for game over
-	if powerUpID == "oblivion" && AllMatched(g.Board) {
-		g.cancelTurnTimer()
-		g.broadcastGameOver()
-		g.Finished = true
-		return
-	}
-
-	// Clairvoyance: schedule hiding the revealed cards after duration
+	// Clairvoyance: schedule hiding the revealed cards after duration (before broadcasting, so the state carries the timing)
 	if powerUpID == "clairvoyance" && len(clairvoyanceRevealIndices) > 0 {
-		durationMS := g.Config.PowerUps.Clairvoyance.RevealDurationMS
-		if durationMS <= 0 {
-			durationMS = 1000
-		}
+		durationMS := g.clairvoyanceRevealMS()
 		g.ClairvoyanceRevealEndsAt = time.Now().Add(time.Duration(durationMS) * time.Millisecond)
 		indices := make([]int, len(clairvoyanceRevealIndices))
 		copy(indices, clairvoyanceRevealIndices)
@@ -287,13 +307,25 @@ func (g *Game) handleUsePowerUp(playerIdx int, powerUpID string, cardIndex int)
 			}
 		}()
 	}
+
+	// Broadcast updated state (turn does not end)
+	g.broadcastState()
+
+	// Oblivion may have removed the last pair(s); check for game over
+	if powerUpID == "oblivion" && AllMatched(g.Board) {
+		g.cancelTurnTimer()
+		g.broadcastGameOver()
+		g.Finished = true
+		return
+	}
+	g.endIfInsurmountable()
 }
 
 // handleHideClairvoyanceReveal hides cards that were temporarily revealed by Clairvoyance.
 // Only cards still Revealed and not in FlippedIndices are hidden.
-// Do not broadcast when phase is SecondFlip: the player (or AI) has already received "pick second card"
-// and may have sent their second flip; broadcasting again would duplicate that state and cause the AI
-// to send the same second-flip action twice (race between hide timer and the second flip being processed).
+// Do not broadcast when phase is SecondFlip or ThirdFlip: the player (or AI) has already received "pick
+// second card" and may have sent that flip; broadcasting again would duplicate that state and cause the AI
+// to send the same flip action twice (race between hide timer and the flip being processed).
 func (g *Game) handleHideClairvoyanceReveal(indices []int) {
 	flippedSet := make(map[int]bool)
 	for _, idx := range g.FlippedIndices {
@@ -310,7 +342,8 @@ func (g *Game) handleHideClairvoyanceReveal(indices []int) {
 			c.State = Hidden
 		}
 	}
-	if g.TurnPhase != SecondFlip {
+	g.recordReplay(ReplayHide, -1, -1, "", "")
+	if g.TurnPhase != SecondFlip && g.TurnPhase != ThirdFlip {
 		g.broadcastState()
 	}
 }
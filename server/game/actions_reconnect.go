@@ -3,23 +3,18 @@ package game
 import (
 	"encoding/json"
 	"time"
-
-	"memory-game-server/wsutil"
 )
 
 func (g *Game) handleDisconnect(playerIdx int) {
 	g.Finished = true
 	opponentIdx := 1 - playerIdx
-	if g.OnGameEnd != nil {
-		g.OnGameEnd(g.ID, g.PlayerUserIDs[0], g.PlayerUserIDs[1], g.Players[0].Name, g.Players[1].Name, g.Players[0].Score, g.Players[1].Score, opponentIdx, "opponent_disconnected", func(_, _, _, _ *int) {})
+	if !g.reportGameEnd(opponentIdx, "opponent_disconnected", func(_, _, _, _ *int) {}) {
+		return
 	}
 	// Notify the opponent
-	opponent := g.Players[opponentIdx]
-	if opponent != nil && opponent.Send != nil {
-		msg := map[string]string{"type": "opponent_disconnected"}
-		data, _ := json.Marshal(msg)
-		wsutil.SafeSend(opponent.Send, data)
-	}
+	msg := map[string]string{"type": "opponent_disconnected"}
+	data, _ := json.Marshal(msg)
+	g.sendToSeat(opponentIdx, data)
 }
 
 func (g *Game) cancelReconnectionTimer() {
@@ -45,16 +40,12 @@ func (g *Game) handlePlayerDisconnected(playerIdx int) {
 		timeoutSec = 120
 	}
 	g.ReconnectionDeadline = time.Now().Add(time.Duration(timeoutSec) * time.Second)
-	opponentIdx := 1 - playerIdx
-	opponent := g.Players[opponentIdx]
-	if opponent != nil && opponent.Send != nil {
-		msg := map[string]any{
-			"type":                        "opponent_reconnecting",
-			"reconnectionDeadlineUnixMs": g.ReconnectionDeadline.UnixMilli(),
-		}
-		data, _ := json.Marshal(msg)
-		wsutil.SafeSend(opponent.Send, data)
+	msg := map[string]any{
+		"type":                        "opponent_reconnecting",
+		"reconnectionDeadlineUnixMs": g.ReconnectionDeadline.UnixMilli(),
 	}
+	data, _ := json.Marshal(msg)
+	g.sendToSeat(1-playerIdx, data)
 	g.reconnectionTimerCancel = make(chan struct{})
 	cancel := g.reconnectionTimerCancel
 	go func() {
@@ -80,13 +71,14 @@ func (g *Game) handleRejoinCompleted(playerIdx int, newSend chan []byte) {
 	if playerIdx >= 0 && playerIdx <= 1 && g.Players[playerIdx] != nil && newSend != nil {
 		g.Players[playerIdx].Send = newSend
 	}
-	opponentIdx := 1 - playerIdx
-	opponent := g.Players[opponentIdx]
-	if opponent != nil && opponent.Send != nil {
-		msg := map[string]string{"type": "opponent_reconnected"}
-		data, _ := json.Marshal(msg)
-		wsutil.SafeSend(opponent.Send, data)
-	}
+	msg := map[string]string{"type": "opponent_reconnected"}
+	data, _ := json.Marshal(msg)
+	g.sendToSeat(1-playerIdx, data)
 	g.startTurnTimer()
 	g.broadcastState()
+	// In a game resumed after a restart (see RestoreGame) the opponent may not be back yet: give them the
+	// usual reconnection window.
+	if opp := g.Players[1-playerIdx]; opp != nil && opp.Send == nil && g.Teams[1-playerIdx] == nil && !g.Hotseat {
+		g.handlePlayerDisconnected(1 - playerIdx)
+	}
 }
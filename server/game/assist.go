@@ -0,0 +1,71 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"time"
+)
+
+// armAssist (re)starts the inactivity timer for the seat on the move when it is in assisted mode. Called
+// after every processed action, so any activity restarts the countdown. No-op once the seat has had its
+// hint this turn, while a mismatch is resolving or while the game is paused for a reconnection.
+func (g *Game) armAssist() {
+	g.cancelAssistTimer()
+	if g.Config.AssistIdleSec <= 0 || !g.Assist[g.CurrentTurn] || g.assistHintRound == g.Round ||
+		g.TurnPhase == Resolve || g.TurnPhase == Draft || g.DisconnectedPlayerIdx >= 0 {
+		return
+	}
+	cancel := make(chan struct{})
+	g.assistTimerCancel = cancel
+	round := g.Round
+	idle := time.Duration(g.Config.AssistIdleSec) * time.Second
+	go func() {
+		select {
+		case <-time.After(idle):
+			select {
+			case g.Actions <- Action{Type: ActionAssistHint, Round: round}:
+			case <-g.Done:
+			}
+		case <-cancel:
+		}
+	}()
+}
+
+// cancelAssistTimer stops a pending inactivity timer. Safe if none is running.
+func (g *Game) cancelAssistTimer() {
+	if g.assistTimerCancel != nil {
+		close(g.assistTimerCancel)
+		g.assistTimerCancel = nil
+	}
+}
+
+// handleAssistHint highlights one legal card for the assisted seat on the move. The hint is only a pointer
+// ("assist_hint" with a card index); it reveals nothing and the player still has to flip the card.
+// Stale timers (the turn moved on, or a hint was already sent this turn) are ignored.
+func (g *Game) handleAssistHint(round int) {
+	seat := g.CurrentTurn
+	if round != g.Round || !g.Assist[seat] || g.assistHintRound == g.Round || g.TurnPhase == Resolve || g.DisconnectedPlayerIdx >= 0 {
+		return
+	}
+	idx := assistCard(g.Board)
+	if idx < 0 {
+		return
+	}
+	g.assistHintRound = g.Round
+	data, _ := json.Marshal(map[string]any{"type": "assist_hint", "index": idx})
+	g.sendToSeat(seat, data)
+}
+
+// assistCard picks a random card that can legally be flipped, or -1 when there is none.
+func assistCard(board *Board) int {
+	var legal []int
+	for i, c := range board.Cards {
+		if c.State == Hidden {
+			legal = append(legal, i)
+		}
+	}
+	if len(legal) == 0 {
+		return -1
+	}
+	return legal[rand.Intn(len(legal))]
+}
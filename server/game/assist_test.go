@@ -0,0 +1,71 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func assistHints(msgs [][]byte) []int {
+	var out []int
+	for _, msg := range msgs {
+		var m struct {
+			Type  string `json:"type"`
+			Index int    `json:"index"`
+		}
+		json.Unmarshal(msg, &m)
+		if m.Type == "assist_hint" {
+			out = append(out, m.Index)
+		}
+	}
+	return out
+}
+
+func TestAssist_HintAfterIdleOncePerTurn(t *testing.T) {
+	cfg := testConfig()
+	cfg.AssistIdleSec = 1
+	g, send0, send1, _ := createTestGame(cfg)
+	g.CurrentTurn = 0
+	g.Assist[0] = true
+	go g.Run()
+	defer func() { g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 1} }()
+
+	time.Sleep(1300 * time.Millisecond)
+	hints := assistHints(drainChannel(send0))
+	if len(hints) != 1 {
+		t.Fatalf("expected one assist_hint after the idle time, got %d", len(hints))
+	}
+	if g.Board.Cards[hints[0]].State != Hidden {
+		t.Errorf("expected the hinted card to be hidden, got %v", g.Board.Cards[hints[0]].State)
+	}
+	if len(assistHints(drainChannel(send1))) != 0 {
+		t.Error("expected no hint for the opponent")
+	}
+
+	// Activity restarts the countdown, but the seat already had its hint this turn.
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: hints[0]}
+	time.Sleep(1300 * time.Millisecond)
+	if n := len(assistHints(drainChannel(send0))); n != 0 {
+		t.Errorf("expected no second hint in the same turn, got %d", n)
+	}
+}
+
+func TestAssist_OnlyAssistedSeat(t *testing.T) {
+	g, send0, _, _ := createTestGame(testConfig())
+	g.CurrentTurn = 0
+
+	g.handleAssistHint(g.Round)
+	if len(assistHints(drainChannel(send0))) != 0 {
+		t.Fatal("expected no hint for a seat without assisted mode")
+	}
+
+	g.Assist[0] = true
+	g.handleAssistHint(g.Round - 1)
+	if len(assistHints(drainChannel(send0))) != 0 {
+		t.Error("expected a stale timer from an earlier round to be ignored")
+	}
+	g.handleAssistHint(g.Round)
+	if len(assistHints(drainChannel(send0))) != 1 {
+		t.Error("expected a hint for the assisted seat")
+	}
+}
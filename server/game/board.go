@@ -2,6 +2,8 @@ package game
 
 import (
 	"math/rand"
+
+	"memory-game-server/config"
 )
 
 // CardState represents the current state of a card.
@@ -56,12 +58,13 @@ type Board struct {
 
 // ElementForNormalPair returns the element for a normal pair (pairID >= arcanaPairs).
 // 3 pairs per element: 6,7,8->fire; 9,10,11->water; 12,13,14->air; 15,16,17->earth.
+// Larger boards (e.g. co-op raids) cycle through the elements again from pair 18.
 func ElementForNormalPair(pairID, arcanaPairs int) string {
 	normalPairIndex := pairID - arcanaPairs
 	if normalPairIndex < 0 {
 		return ""
 	}
-	elementIndex := normalPairIndex / 3
+	elementIndex := (normalPairIndex / config.PairsPerElementGroup) % config.BoardElements
 	switch elementIndex {
 	case 0:
 		return ElementFire
@@ -88,6 +91,17 @@ func assignElementsForNormalPairs(board *Board, arcanaPairs int) {
 // NewBoard creates a new board with randomly shuffled pairs.
 // arcanaPairs is the number of arcana pairs (pairIDs 0..arcanaPairs-1); remaining pairs are normal and get an element.
 func NewBoard(rows, cols, arcanaPairs int) *Board {
+	return newBoard(rows, cols, arcanaPairs, config.ArcanaPlacementConfig{}, rand.Shuffle)
+}
+
+// NewPlacedBoard creates a board like NewBoard, then moves the arcana cards to follow rules.
+func NewPlacedBoard(rows, cols, arcanaPairs int, rules config.ArcanaPlacementConfig) *Board {
+	return newBoard(rows, cols, arcanaPairs, rules, rand.Shuffle)
+}
+
+// newBoard creates a board whose card positions are shuffled with shuffle (rand.Shuffle, or a seeded
+// source's Shuffle to reproduce a layout) and whose arcana cards then follow the placement rules.
+func newBoard(rows, cols, arcanaPairs int, rules config.ArcanaPlacementConfig, shuffle func(n int, swap func(i, j int))) *Board {
 	totalCards := rows * cols
 	numPairs := totalCards / 2
 
@@ -99,9 +113,10 @@ func NewBoard(rows, cols, arcanaPairs int) *Board {
 	}
 
 	// Shuffle card positions
-	rand.Shuffle(totalCards, func(i, j int) {
+	shuffle(totalCards, func(i, j int) {
 		cards[i], cards[j] = cards[j], cards[i]
 	})
+	placeArcana(cards, rows, cols, arcanaPairs, rules, shuffle)
 
 	// Assign indices after shuffle
 	for i := range cards {
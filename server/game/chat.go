@@ -0,0 +1,41 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// ChatMsg is a chat line relayed to every seat of the match it was sent in. Seat is the sender's seat,
+// so a client can tell its own lines from the opponent's; Name is the sender (a team member in a raid).
+type ChatMsg struct {
+	Type       string `json:"type"` // "chat"
+	Seat       int    `json:"seat"`
+	Name       string `json:"name"`
+	Text       string `json:"text"`
+	SentUnixMs int64  `json:"sentUnixMs"`
+}
+
+// handleChat relays a chat line to everyone in the match. The text was validated (length, rate) when
+// it was received. Hotseat games share one screen and have no chat.
+func (g *Game) handleChat(action Action) {
+	if g.Hotseat || g.Players[action.PlayerIdx] == nil || g.HasLeft(action.PlayerIdx) {
+		return
+	}
+	name := g.Players[action.PlayerIdx].Name
+	if t := g.Teams[action.PlayerIdx]; t != nil && action.MemberIdx >= 0 && action.MemberIdx < len(t.Members) {
+		name = t.Members[action.MemberIdx].Name
+	}
+	sent := action.ReceivedAt
+	if sent.IsZero() {
+		sent = time.Now()
+	}
+	data, err := json.Marshal(ChatMsg{Type: "chat", Seat: action.PlayerIdx, Name: name, Text: action.Text, SentUnixMs: sent.UnixMilli()})
+	if err != nil {
+		slog.Error("marshaling chat", "tag", "game", "err", err)
+		return
+	}
+	for i := range g.Players {
+		g.sendToSeat(i, data)
+	}
+}
@@ -0,0 +1,33 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestChat_RelayedToBothSeats(t *testing.T) {
+	g, send0, send1, _ := createTestGame(testConfig())
+	g.handleChat(Action{Type: ActionChat, PlayerIdx: 1, Text: "gl hf"})
+
+	for seat, ch := range []chan []byte{send0, send1} {
+		var got []ChatMsg
+		for _, data := range drainChannel(ch) {
+			var m ChatMsg
+			if json.Unmarshal(data, &m) == nil && m.Type == "chat" {
+				got = append(got, m)
+			}
+		}
+		if len(got) != 1 || got[0].Seat != 1 || got[0].Name != g.Players[1].Name || got[0].Text != "gl hf" {
+			t.Errorf("seat %d: expected the line from seat 1, got %+v", seat, got)
+		}
+	}
+}
+
+func TestChat_IgnoredInHotseat(t *testing.T) {
+	g, send0, send1, _ := createTestGame(testConfig())
+	g.Hotseat = true
+	g.handleChat(Action{Type: ActionChat, PlayerIdx: 0, Text: "hi"})
+	if hasMessageType(drainChannel(send0), "chat") || hasMessageType(drainChannel(send1), "chat") {
+		t.Error("expected no chat in a hotseat game")
+	}
+}
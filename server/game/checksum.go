@@ -0,0 +1,66 @@
+package game
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"slices"
+)
+
+// recentChecksumsKept is how many broadcast checksums a client may echo back. A client acting on a state
+// that was just replaced (e.g. the mismatch resolving while it clicks) is behind, not out of sync.
+const recentChecksumsKept = 4
+
+// StateChecksum returns a short hash of the authoritative state both seats share: every card's state,
+// every seat's score, the seat on turn and the round. It never covers hidden pair IDs, so it leaks nothing.
+func (g *Game) StateChecksum() string {
+	h := fnv.New32a()
+	buf := make([]byte, 0, len(g.Board.Cards)+(len(g.Players)+2)*8)
+	for _, c := range g.Board.Cards {
+		buf = append(buf, byte(c.State))
+	}
+	for _, p := range g.Players {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p.Score))
+	}
+	for _, v := range []int{g.CurrentTurn, g.Round} {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+	}
+	h.Write(buf)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// noteChecksum remembers a checksum sent to the clients (see recentChecksumsKept).
+func (g *Game) noteChecksum(sum string) {
+	if n := len(g.recentChecksums); n > 0 && g.recentChecksums[n-1] == sum {
+		return
+	}
+	g.recentChecksums = append(g.recentChecksums, sum)
+	if len(g.recentChecksums) > recentChecksumsKept {
+		g.recentChecksums = g.recentChecksums[1:]
+	}
+}
+
+// inSync reports whether the checksum echoed with a flip or power-up matches a state the seat was sent.
+// Actions without a checksum (the AI, older clients) are always in sync. On a mismatch the desync is
+// logged, the seat gets the full state again and the action must be dropped: it was chosen from a view
+// of the board the server never had.
+func (g *Game) inSync(action Action) bool {
+	if action.StateChecksum == "" {
+		return true
+	}
+	current := g.StateChecksum()
+	if action.StateChecksum == current || slices.Contains(g.recentChecksums, action.StateChecksum) {
+		return true
+	}
+	slog.Warn("client state desync", "tag", "game", "game_id", g.ID, "seat", action.PlayerIdx, "member", action.MemberIdx,
+		"action", action.Type, "client_checksum", action.StateChecksum, "server_checksum", current, "round", g.Round)
+	data, err := json.Marshal(g.BuildStateForPlayer(action.PlayerIdx))
+	if err != nil {
+		slog.Error("marshaling game state", "tag", "game", "err", err)
+		return false
+	}
+	g.sendToSeat(action.PlayerIdx, data)
+	return false
+}
@@ -0,0 +1,60 @@
+package game
+
+import "testing"
+
+func TestStateChecksum_ChangesWithSharedState(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	base := g.StateChecksum()
+	if len(base) != 8 {
+		t.Fatalf("expected an 8-character checksum, got %q", base)
+	}
+	if g.StateChecksum() != base {
+		t.Fatal("expected the checksum to be stable")
+	}
+	if g.BuildStateForPlayer(0).StateChecksum != base || g.BuildStateForPlayer(1).StateChecksum != base {
+		t.Error("expected both seats to receive the same checksum")
+	}
+
+	g.Board.Cards[0].State = Revealed
+	flipped := g.StateChecksum()
+	if flipped == base {
+		t.Error("expected a card state change to change the checksum")
+	}
+	g.Players[1].Score++
+	if g.StateChecksum() == flipped {
+		t.Error("expected a score change to change the checksum")
+	}
+}
+
+func TestInSync_ResyncsAndDropsMismatchedAction(t *testing.T) {
+	g, send0, send1, _ := createTestGame(testConfig())
+	g.broadcastState()
+	old := g.StateChecksum()
+	drainChannel(send0)
+	drainChannel(send1)
+
+	if !g.inSync(Action{Type: ActionFlipCard, PlayerIdx: 0}) {
+		t.Error("expected an action without a checksum to be accepted")
+	}
+	if !g.inSync(Action{Type: ActionFlipCard, PlayerIdx: 0, StateChecksum: old}) {
+		t.Error("expected the current checksum to be accepted")
+	}
+
+	g.Board.Cards[0].State = Revealed
+	g.broadcastState()
+	drainChannel(send0)
+	drainChannel(send1)
+	if !g.inSync(Action{Type: ActionFlipCard, PlayerIdx: 0, StateChecksum: old}) {
+		t.Error("expected a checksum from a state just replaced to be accepted")
+	}
+
+	if g.inSync(Action{Type: ActionFlipCard, PlayerIdx: 0, StateChecksum: "deadbeef"}) {
+		t.Fatal("expected an unknown checksum to be rejected")
+	}
+	if !hasMessageType(drainChannel(send0), "game_state") {
+		t.Error("expected a full game_state resync for the desynced seat")
+	}
+	if len(drainChannel(send1)) > 0 {
+		t.Error("expected no message for the other seat")
+	}
+}
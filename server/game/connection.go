@@ -0,0 +1,71 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// ReportConnectionQuality records whether the seat's connection is unstable: degraded (missed pongs,
+// high round-trip time) but still connected. Safe to call from any goroutine; changes are handed to the
+// game loop, which tells the opponent and extends the seat's turn.
+func (g *Game) ReportConnectionQuality(seat int, unstable bool) {
+	if seat < 0 || seat >= len(g.Players) || g.seatUnstable[seat].Swap(unstable) == unstable {
+		return
+	}
+	go func() {
+		select {
+		case g.Actions <- Action{Type: ActionConnectionQuality, PlayerIdx: seat}:
+		case <-g.Done:
+		}
+	}()
+}
+
+// handleConnectionQuality tells the opponent that the seat's connection became unstable
+// (connection_unstable) or recovered (connection_stable). An unstable seat on turn gets its turn
+// extended once by ConnectionQuality.TurnExtensionSec. Hotseat games have no opponent to tell.
+func (g *Game) handleConnectionQuality(seat int) {
+	unstable := g.seatUnstable[seat].Load()
+	if g.Hotseat || unstable == g.notifiedUnstable[seat] {
+		return
+	}
+	g.notifiedUnstable[seat] = unstable
+	extended := time.Duration(0)
+	if unstable && seat == g.CurrentTurn && g.DisconnectedPlayerIdx < 0 {
+		extended = g.extendTurn()
+	}
+	name := ""
+	if p := g.Players[seat]; p != nil {
+		name = p.Name
+	}
+	slog.Info("connection quality changed", "tag", "game", "game_id", g.ID, "seat", seat, "unstable", unstable,
+		"rtt_ms", g.SeatRTT(seat).Milliseconds(), "turn_extended_ms", extended.Milliseconds())
+
+	msgType := "connection_stable"
+	if unstable {
+		msgType = "connection_unstable"
+	}
+	data, _ := json.Marshal(map[string]any{
+		"type":           msgType,
+		"playerName":     name,
+		"turnExtendedMs": extended.Milliseconds(),
+	})
+	g.sendToOthers(seat, data)
+	if extended > 0 {
+		g.broadcastState() // the player on turn sees the new turnEndsAtUnixMs
+	}
+}
+
+// extendTurn adds ConnectionQuality.TurnExtensionSec to the running turn timer, at most once per turn.
+// Returns the time added (0 when the timer is off or the turn was already extended).
+func (g *Game) extendTurn() time.Duration {
+	ext := time.Duration(g.Config.ConnectionQuality.TurnExtensionSec) * time.Second
+	if ext <= 0 || g.turnExtended || g.turnTimerCancel == nil {
+		return 0
+	}
+	endsAt := g.turnEndsAt.Add(ext)
+	g.cancelTurnTimer()
+	g.armTurnTimer(endsAt)
+	g.turnExtended = true
+	return ext
+}
@@ -0,0 +1,40 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHandleConnectionQuality_NotifiesOpponentAndExtendsTurnOnce(t *testing.T) {
+	cfg := testConfig()
+	cfg.TurnLimitSec = 30
+	cfg.ConnectionQuality.TurnExtensionSec = 5
+	g, send0, send1, _ := createTestGame(cfg)
+	g.CurrentTurn = 0
+	g.startTurnTimer()
+	defer g.cancelTurnTimer()
+	endsAt := g.turnEndsAt
+
+	g.seatUnstable[0].Store(true)
+	g.handleConnectionQuality(0)
+	if !hasMessageType(drainChannel(send1), "connection_unstable") {
+		t.Error("expected connection_unstable for the opponent")
+	}
+	if hasMessageType(drainChannel(send0), "connection_unstable") {
+		t.Error("expected no connection_unstable for the unstable player")
+	}
+	if got := g.turnEndsAt.Sub(endsAt); got != 5*time.Second {
+		t.Errorf("expected the turn extended by 5s, got %v", got)
+	}
+
+	g.seatUnstable[0].Store(false)
+	g.handleConnectionQuality(0)
+	if !hasMessageType(drainChannel(send1), "connection_stable") {
+		t.Error("expected connection_stable for the opponent")
+	}
+	g.seatUnstable[0].Store(true)
+	g.handleConnectionQuality(0)
+	if got := g.turnEndsAt.Sub(endsAt); got != 5*time.Second {
+		t.Errorf("expected a single extension per turn, got %v", got)
+	}
+}
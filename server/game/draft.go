@@ -0,0 +1,128 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// DraftOfferSize is how many arcana each player is offered in the starting-hand draft.
+const DraftOfferSize = 3
+
+// DraftOption is one arcana offered in the starting-hand draft.
+type DraftOption struct {
+	PowerUpID   string `json:"powerUpId"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// DraftOfferMsg is sent privately to each seat when the starting-hand draft opens. The seat answers with
+// draft_pick before DeadlineUnixMs; otherwise the first option is picked for it.
+type DraftOfferMsg struct {
+	Type           string        `json:"type"` // "draft_offer"
+	Options        []DraftOption `json:"options"`
+	DeadlineUnixMs int64         `json:"deadlineUnixMs"`
+}
+
+// startDraft opens the starting-hand draft when g.Draft is set: each seat is offered DraftOfferSize
+// random arcana and play waits in the Draft phase until every seat has picked or Config.StartingDraftSec
+// runs out.
+func (g *Game) startDraft() {
+	if !g.Draft || g.Config.StartingDraftSec <= 0 || g.PowerUps == nil {
+		return
+	}
+	offers := make([][]PowerUpDef, len(g.Players))
+	for seat := range offers {
+		offers[seat] = g.PowerUps.PickArcanaForMatch(DraftOfferSize)
+		if len(offers[seat]) == 0 {
+			return
+		}
+		rand.Shuffle(len(offers[seat]), func(i, j int) { offers[seat][i], offers[seat][j] = offers[seat][j], offers[seat][i] })
+	}
+	deadline := time.Now().Add(time.Duration(g.Config.StartingDraftSec) * time.Second)
+	for seat, defs := range offers {
+		msg := DraftOfferMsg{Type: "draft_offer", DeadlineUnixMs: deadline.UnixMilli()}
+		for _, def := range defs {
+			g.draftOffers[seat] = append(g.draftOffers[seat], def.ID)
+			msg.Options = append(msg.Options, DraftOption{PowerUpID: def.ID, Name: def.Name, Description: def.Description})
+		}
+		data, _ := json.Marshal(msg)
+		g.sendToSeat(seat, data)
+	}
+	g.TurnPhase = Draft
+	cancel := make(chan struct{})
+	g.draftTimerCancel = cancel
+	go func() {
+		select {
+		case <-time.After(time.Until(deadline)):
+			select {
+			case g.Actions <- Action{Type: ActionDraftTimeout}:
+			case <-g.Done:
+			}
+		case <-cancel:
+		case <-g.Done:
+		}
+	}()
+}
+
+// handleDraftPick records a seat's starting arcana; the draft ends once every seat has picked.
+func (g *Game) handleDraftPick(action Action) {
+	seat := action.PlayerIdx
+	if g.TurnPhase != Draft || g.draftPicks[seat] != "" {
+		g.sendErrorToSender(action, "There is no starting arcana to pick.")
+		return
+	}
+	if !slices.Contains(g.draftOffers[seat], action.PowerUpID) {
+		g.sendErrorToSender(action, "That arcana was not offered.")
+		return
+	}
+	g.draftPicks[seat] = action.PowerUpID
+	if g.draftComplete() {
+		g.finishDraft()
+	}
+}
+
+// draftComplete reports whether every seat still in play has picked its starting arcana.
+func (g *Game) draftComplete() bool {
+	for seat := range g.Players {
+		if g.draftPicks[seat] == "" && !g.HasLeft(seat) {
+			return false
+		}
+	}
+	return true
+}
+
+// finishDraft closes the draft: seats that did not pick get their first option. Each pick goes to the
+// seat's hand, usable on its first turn, and to telemetry; then the first turn starts.
+func (g *Game) finishDraft() {
+	if g.TurnPhase != Draft {
+		return
+	}
+	if g.draftTimerCancel != nil {
+		close(g.draftTimerCancel)
+		g.draftTimerCancel = nil
+	}
+	for seat := range g.Players {
+		if g.HasLeft(seat) {
+			continue
+		}
+		autoPicked := g.draftPicks[seat] == ""
+		if autoPicked {
+			g.draftPicks[seat] = g.draftOffers[seat][0]
+		}
+		id := g.draftPicks[seat]
+		player := g.Players[seat]
+		if player.Hand == nil {
+			player.Hand = make(map[string]int)
+		}
+		player.Hand[id]++
+		player.HandOrder = append(player.HandOrder, id)
+		if g.TelemetrySink != nil {
+			g.TelemetrySink.RecordDraftPick(g.ID, seat, id, g.draftOffers[seat], autoPicked)
+		}
+	}
+	g.TurnPhase = FirstFlip
+	g.broadcastState()
+	g.startTurnTimer()
+}
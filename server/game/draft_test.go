@@ -0,0 +1,105 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type draftRecorder struct {
+	pityRecorder
+	picks map[int]string
+	auto  map[int]bool
+}
+
+func (r *draftRecorder) RecordDraftPick(_ string, playerIdx int, powerUpID string, _ []string, autoPicked bool) {
+	r.picks[playerIdx] = powerUpID
+	r.auto[playerIdx] = autoPicked
+}
+
+// createDraftGame returns a test game with three arcana registered and the draft enabled.
+func createDraftGame() (*Game, chan []byte, chan []byte, *draftRecorder) {
+	cfg := testConfig()
+	cfg.StartingDraftSec = 30
+	g, send0, send1, pups := createTestGame(cfg)
+	for _, id := range []string{"chaos", "leech", "unveiling"} {
+		pups.Register(id, PowerUpDef{ID: id, Name: id})
+	}
+	g.Draft = true
+	sink := &draftRecorder{picks: map[int]string{}, auto: map[int]bool{}}
+	g.TelemetrySink = sink
+	return g, send0, send1, sink
+}
+
+func draftOffer(t *testing.T, msgs [][]byte) DraftOfferMsg {
+	t.Helper()
+	for _, data := range msgs {
+		var offer DraftOfferMsg
+		if json.Unmarshal(data, &offer) == nil && offer.Type == "draft_offer" {
+			return offer
+		}
+	}
+	t.Fatal("expected a draft_offer")
+	return DraftOfferMsg{}
+}
+
+func TestDraft_BothPicksStartTheGame(t *testing.T) {
+	g, send0, send1, sink := createDraftGame()
+	g.startDraft()
+	offer0, offer1 := draftOffer(t, drainChannel(send0)), draftOffer(t, drainChannel(send1))
+	if len(offer0.Options) != DraftOfferSize || len(offer1.Options) != DraftOfferSize || g.TurnPhase != Draft {
+		t.Fatalf("expected %d options for each seat and the draft phase, got %+v", DraftOfferSize, offer0)
+	}
+
+	g.handleFlipCard(g.CurrentTurn, 0)
+	if g.Board.Cards[0].State != Hidden {
+		t.Error("expected flips to be rejected during the draft")
+	}
+	g.handleDraftPick(Action{Type: ActionDraftPick, PlayerIdx: 0, PowerUpID: "necromancy"})
+	if g.draftPicks[0] != "" {
+		t.Error("expected a pick outside the offer to be rejected")
+	}
+
+	pick0 := offer0.Options[1].PowerUpID
+	g.handleDraftPick(Action{Type: ActionDraftPick, PlayerIdx: 0, PowerUpID: pick0})
+	if g.TurnPhase != Draft {
+		t.Fatal("expected the draft to wait for the other seat")
+	}
+	g.handleDraftPick(Action{Type: ActionDraftPick, PlayerIdx: 1, PowerUpID: offer1.Options[0].PowerUpID})
+	if g.TurnPhase != FirstFlip {
+		t.Fatal("expected the first turn to start once both seats picked")
+	}
+	if g.Players[0].Hand[pick0] != 1 || g.Players[0].HandCooldown[pick0] != 0 {
+		t.Errorf("expected %s in seat 0's hand and usable, got %v", pick0, g.Players[0].Hand)
+	}
+	if sink.picks[0] != pick0 || sink.auto[0] || sink.auto[1] {
+		t.Errorf("expected both picks recorded as chosen, got %v %v", sink.picks, sink.auto)
+	}
+	if !hasMessageType(drainChannel(send1), "game_state") {
+		t.Error("expected the game state to be broadcast when the draft ends")
+	}
+}
+
+func TestDraft_TimeoutPicksFirstOption(t *testing.T) {
+	g, send0, send1, sink := createDraftGame()
+	g.startDraft()
+	offer0, offer1 := draftOffer(t, drainChannel(send0)), draftOffer(t, drainChannel(send1))
+	g.handleDraftPick(Action{Type: ActionDraftPick, PlayerIdx: 0, PowerUpID: offer0.Options[2].PowerUpID})
+
+	g.finishDraft()
+	first := offer1.Options[0].PowerUpID
+	if g.TurnPhase != FirstFlip || g.Players[1].Hand[first] != 1 {
+		t.Fatalf("expected seat 1 to get its first option, got %v", g.Players[1].Hand)
+	}
+	if !sink.auto[1] || sink.auto[0] {
+		t.Errorf("expected only seat 1's pick recorded as automatic, got %v", sink.auto)
+	}
+}
+
+func TestDraft_OffWithoutFlag(t *testing.T) {
+	g, send0, _, _ := createDraftGame()
+	g.Draft = false
+	g.startDraft()
+	if g.TurnPhase != FirstFlip || hasMessageType(drainChannel(send0), "draft_offer") {
+		t.Error("expected no draft for a game without Draft set")
+	}
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+
+	"memory-game-server/wsutil"
+)
+
+// Emotes are the quick reactions a player may send; clients map each ID to an icon or localized text.
+var Emotes = []string{"hello", "good_game", "well_played", "wow", "oops", "thinking", "thanks"}
+
+// IsEmote reports whether id is one of the predefined Emotes.
+func IsEmote(id string) bool {
+	return slices.Contains(Emotes, id)
+}
+
+// EmoteMsg relays a quick reaction to the other seats. Seat is the sender's seat.
+type EmoteMsg struct {
+	Type    string `json:"type"` // "emote"
+	Seat    int    `json:"seat"`
+	EmoteID string `json:"emoteId"`
+}
+
+// handleEmote relays a predefined emote to the other seats, at most MaxEmotesPerTurn per seat and turn
+// (0 = no limit); the sender is told when the limit is reached. Hotseat games share one screen and have
+// no emotes.
+func (g *Game) handleEmote(action Action) {
+	seat := action.PlayerIdx
+	if g.Hotseat || seat < 0 || seat >= len(g.Players) || g.HasLeft(seat) || !IsEmote(action.EmoteID) {
+		return
+	}
+	if g.emoteRound[seat] != g.Round {
+		g.emoteRound[seat], g.emotesSent[seat] = g.Round, 0
+	}
+	if limit := g.Config.MaxEmotesPerTurn; limit > 0 && g.emotesSent[seat] >= limit {
+		g.sendErrorToSender(action, "Wait for the next turn to send another emote.")
+		return
+	}
+	g.emotesSent[seat]++
+	data, err := json.Marshal(EmoteMsg{Type: "emote", Seat: seat, EmoteID: action.EmoteID})
+	if err != nil {
+		slog.Error("marshaling emote", "tag", "game", "err", err)
+		return
+	}
+	g.sendToOthers(seat, data)
+}
+
+// sendErrorToSender sends an error to the connection the action came from: the seat's player, or the
+// acting member of a team seat.
+func (g *Game) sendErrorToSender(action Action, message string) {
+	var send chan []byte
+	if t := g.Teams[action.PlayerIdx]; t != nil {
+		if action.MemberIdx >= 0 && action.MemberIdx < len(t.Members) {
+			send = t.Members[action.MemberIdx].Send
+		}
+	} else if p := g.Players[action.PlayerIdx]; p != nil {
+		send = p.Send
+	}
+	if send == nil {
+		return
+	}
+	data, _ := json.Marshal(map[string]string{"type": "error", "message": message})
+	wsutil.SafeSend(send, data)
+}
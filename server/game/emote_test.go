@@ -0,0 +1,44 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmote_RelayedToOpponentWithPerTurnLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxEmotesPerTurn = 2
+	g, send0, send1, _ := createTestGame(cfg)
+
+	for range 3 {
+		g.handleEmote(Action{Type: ActionEmote, PlayerIdx: 0, EmoteID: "wow"})
+	}
+	var relayed []EmoteMsg
+	for _, data := range drainChannel(send1) {
+		var m EmoteMsg
+		if json.Unmarshal(data, &m) == nil && m.Type == "emote" {
+			relayed = append(relayed, m)
+		}
+	}
+	if len(relayed) != 2 || relayed[0].Seat != 0 || relayed[0].EmoteID != "wow" {
+		t.Fatalf("expected two emotes from seat 0 relayed to the opponent, got %+v", relayed)
+	}
+	sent := drainChannel(send0)
+	if hasMessageType(sent, "emote") || !hasMessageType(sent, "error") {
+		t.Error("expected the sender to get an error for the third emote and no emote back")
+	}
+
+	g.Round++
+	g.handleEmote(Action{Type: ActionEmote, PlayerIdx: 0, EmoteID: "thanks"})
+	if !hasMessageType(drainChannel(send1), "emote") {
+		t.Error("expected the limit to reset on the next turn")
+	}
+}
+
+func TestEmote_UnknownIDIgnored(t *testing.T) {
+	g, _, send1, _ := createTestGame(testConfig())
+	g.handleEmote(Action{Type: ActionEmote, PlayerIdx: 0, EmoteID: "rude"})
+	if hasMessageType(drainChannel(send1), "emote") {
+		t.Error("expected an unknown emote not to be relayed")
+	}
+}
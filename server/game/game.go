@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"log/slog"
 	"math/rand"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"memory-game-server/config"
-	"memory-game-server/wsutil"
 )
 
 // TurnPhase represents the current phase within a turn.
@@ -17,6 +18,10 @@ const (
 	FirstFlip  TurnPhase = iota
 	SecondFlip
 	Resolve
+	// ThirdFlip follows a mismatched second flip while Third Eye is active: one more card may complete a pair.
+	ThirdFlip
+	// Draft precedes the first turn while the players pick their starting arcana (see startDraft).
+	Draft
 )
 
 // String returns the protocol string for a TurnPhase.
@@ -28,6 +33,10 @@ func (tp TurnPhase) String() string {
 		return "second_flip"
 	case Resolve:
 		return "resolve"
+	case ThirdFlip:
+		return "third_flip"
+	case Draft:
+		return "draft"
 	default:
 		return "unknown"
 	}
@@ -46,6 +55,17 @@ const (
 	ActionResolveMismatch      // internal: fired after reveal timer expires
 	ActionHideClairvoyanceReveal  // internal: hide cards that were temporarily revealed by Clairvoyance
 	ActionTurnTimeout          // internal: fired when turn time limit is reached
+	ActionResign               // player concedes; the opponent wins with end reason "resigned"
+	ActionAssistHint           // internal: fired when an assisted player has been idle on their turn
+	ActionSeatRestarted        // internal: the AI of seat PlayerIdx was restarted; resend its view and rebuild its memory
+	ActionAIFailed             // internal: the AI of seat PlayerIdx could not be kept running; end the game
+	ActionConnectionQuality    // internal: the connection quality of seat PlayerIdx changed (see ReportConnectionQuality)
+	ActionServerShutdown       // the server is restarting: warn both seats and end the game as a draw at Deadline
+	ActionShutdownDeadline     // internal: the shutdown deadline passed; end the game as a draw
+	ActionChat                 // a player sent a chat line (Text); relayed to both seats
+	ActionEmote                // a player sent a predefined emote (EmoteID); relayed to the opponent
+	ActionDraftPick            // a player picked their starting arcana (PowerUpID) in the draft
+	ActionDraftTimeout         // internal: the draft pick time ran out; unpicked seats get their first option
 )
 
 // Action represents a player action sent into the game's action channel.
@@ -53,14 +73,22 @@ type Action struct {
 	Type               ActionType
 	PlayerIdx          int       // 0 or 1
 	Index              int       // card index (for FlipCard)
-	PowerUpID          string    // power-up ID (for UsePowerUp)
+	PowerUpID          string    // power-up ID (for UsePowerUp and DraftPick)
 	CardIndex             int       // card index for power-ups that need a target (e.g. Clairvoyance); -1 when not used
 	ClairvoyanceRevealIndices []int // indices to hide (for ActionHideClairvoyanceReveal)
 	NewSend            chan []byte // for ActionRejoinCompleted: new send channel for the reconnected player
+	MemberIdx          int         // acting member when PlayerIdx is a team seat (co-op raid); 0 otherwise
+	Round              int         // for ActionAssistHint: the round the hint was scheduled in
+	KnownReply         chan map[int]int // for ActionSeatRestarted: receives the seat's rebuilt memory (index -> pairID)
+	ReceivedAt         time.Time        // when the server received the player's flip/power-up message; zero for internal actions
+	StateChecksum      string           // for FlipCard/UsePowerUp: the game_state checksum the client echoed back ("" = not sent)
+	Deadline           time.Time        // for ActionServerShutdown: when the game is ended if still running
+	Text               string           // for ActionChat: the validated chat line
+	EmoteID            string           // for ActionEmote: one of Emotes
 }
 
 // ArcanaPairsPerMatch is the number of board pairs that grant power-ups in each match.
-const ArcanaPairsPerMatch = 6
+const ArcanaPairsPerMatch = config.ArcanaPairsPerMatch
 
 // PowerUpProvider abstracts the power-up registry so the game package
 // does not import the powerup package directly (avoids circular deps).
@@ -70,10 +98,13 @@ type PowerUpProvider interface {
 	PickArcanaForMatch(n int) []PowerUpDef
 }
 
-// TelemetrySink is called to record turn and arcana use events. Optional; may be nil.
+// TelemetrySink is called to record turn, arcana use and starting draft events. Optional; may be nil.
 type TelemetrySink interface {
-	RecordTurn(matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent int)
+	RecordTurn(matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent, pairsMatched int, latency TurnLatency)
 	RecordArcanaUse(matchID string, round, playerIdx int, powerUpID string, targetCardIndex int, playerScoreBefore, opponentScoreBefore, pairsMatchedBefore int)
+	RecordHandOverflow(matchID string, round, playerIdx int, powerUpID, rule, discardedPowerUpID string)
+	RecordPityGrant(matchID string, round, playerIdx int, powerUpID string, playerScore, opponentScore int)
+	RecordDraftPick(matchID string, playerIdx int, powerUpID string, offered []string, autoPicked bool)
 }
 
 // PowerUpContext is passed to power-up Apply when the game has context (e.g. which pairID is the power-up tile).
@@ -93,11 +124,11 @@ type PowerUpDef struct {
 	Apply       func(board *Board, active *Player, opponent *Player, ctx *PowerUpContext) error
 }
 
-// Game manages a single match between two players.
+// Game manages a single match between two players, or up to MaxSeats in a party game (see NewPartyGame).
 type Game struct {
 	ID             string
 	Board          *Board
-	Players        [2]*Player
+	Players        []*Player
 	CurrentTurn    int
 	TurnPhase      TurnPhase
 	FlippedIndices []int
@@ -121,20 +152,86 @@ type Game struct {
 	Round int
 
 	// TurnStartScores are the scores at the start of the current turn (for telemetry deltas).
-	TurnStartScores [2]int
+	TurnStartScores [MaxSeats]int
+
+	// missStreak counts consecutive mismatches in the current turn (mismatch retries variant).
+	missStreak int
+	// turnLatency accumulates the processing delays of the current turn's actions (see TurnLatency).
+	turnLatency turnLatency
+	// turnMoves counts the flips and power-ups handled in the current turn (see recordFinalTurn).
+	turnMoves int
+	// turnFlips, turnMatches and turnArcana log the current turn's flips (card indices), matched pairs and
+	// arcana used, for its turn_summary (see noteTurnMove).
+	turnFlips   []int
+	turnMatches int
+	turnArcana  []string
+	// shutdownAt is the server shutdown deadline announced to the seats (zero = no shutdown).
+	shutdownAt time.Time
+	// emotesSent counts each seat's emotes in emoteRound, the round of its last emote (see handleEmote).
+	emotesSent [MaxSeats]int
+	emoteRound [MaxSeats]int
 
 	// turnEndsAt is when the current turn ends (zero = timer disabled).
 	turnEndsAt        time.Time
 	turnTimerCancel   chan struct{}
 
+	// Assist marks seats in assisted accessibility mode (server highlights a card after inactivity); set by matchmaker.
+	Assist [MaxSeats]bool
+	// assistHintRound is the Round in which the last hint was sent (-1 = none yet); limits hints to one per turn.
+	assistHintRound   int
+	assistTimerCancel chan struct{}
+
+	// seenBy holds, per seat, the card indices whose face the seat was shown (flips, Clairvoyance, Peek)
+	// since the card last moved; blindPlay counts each seat's guesses at unseen cards (see BlindPlay).
+	seenBy    [MaxSeats]map[int]struct{}
+	blindPlay [MaxSeats]BlindPlay
+
+	// lastFlipAt is when the server received the last accepted flip of the turn; fastFlips counts each
+	// seat's flips rejected for coming too soon after it (see FastFlips).
+	lastFlipAt time.Time
+	fastFlips  [MaxSeats]FastFlips
+
 	// TelemetrySink records turn and arcana use events; optional, set by matchmaker.
 	TelemetrySink TelemetrySink
+	// ReplaySink records the match move by move for replays; optional, set by matchmaker.
+	ReplaySink ReplaySink
+	// replaySeq numbers the replay events; replayStart and replayLayout are the time and card layout
+	// of the first one (the layout is updated when cards move).
+	replaySeq    int
+	replayStart  time.Time
+	replayLayout []int
 
 	// RejoinTokens allow a disconnected player to rejoin; set by matchmaker.
-	RejoinTokens [2]string
+	RejoinTokens [MaxSeats]string
+
+	// Teams holds the members sharing a seat in co-op raids (nil for seats held by a single player).
+	// A team seat's Player has no Send; messages go to each connected member instead.
+	Teams [MaxSeats]*Team
+
+	// Seed derived the opening card layout and first turn (see NewSeededGame); recorded so the game can
+	// be replayed on the same board.
+	Seed int64
+	// RematchOf is the ID of the recorded game this one replays (same board and opponent); empty otherwise.
+	RematchOf string
 
-	// PlayerUserIDs are the auth user IDs for each seat (index 0 and 1); used for rejoin by user (cross-device). Set by matchmaker.
-	PlayerUserIDs [2]string
+	// Hotseat is set for pass-and-play games: both seats are played from one connection. Actions count for
+	// the seat on turn, and the connection receives each message once, as that seat sees it.
+	Hotseat bool
+
+	// Mode is the ID of the game mode (see package modes) the game was created for; empty for games not
+	// created by the matchmaker. Set by matchmaker.
+	Mode string
+
+	// Draft opens the starting-hand draft before the first turn (see startDraft); set by matchmaker when
+	// Config.StartingDraftSec > 0.
+	Draft bool
+	// draftOffers are the arcana offered to each seat in the draft, draftPicks those picked so far.
+	draftOffers      [MaxSeats][]string
+	draftPicks       [MaxSeats]string
+	draftTimerCancel chan struct{}
+
+	// PlayerUserIDs are the auth user IDs for each seat (index 0 and 1 in a 1v1); used for rejoin by user (cross-device). Set by matchmaker.
+	PlayerUserIDs [MaxSeats]string
 
 	// DisconnectedPlayerIdx is the player who lost connection (-1 = none); game is paused until rejoin or timeout.
 	DisconnectedPlayerIdx  int
@@ -144,21 +241,68 @@ type Game struct {
 	Actions chan Action
 	Done    chan struct{}
 
-	// OnGameEnd is called when the game ends (normal finish or opponent disconnect). winnerIndex is 0, 1, or -1 for draw.
+	// OnGameEnd is called when the game ends (normal finish or opponent disconnect). winnerIndex is 0, 1, or -1 for draw;
+	// in a party game it may be any seat, while the player arguments describe seats 0 and 1 only.
 	// done is invoked by the caller with elo0Before, elo0After, elo1Before, elo1After (nil when rating is not updated).
 	OnGameEnd func(gameID, player0UserID, player1UserID, player0Name, player1Name string, player0Score, player1Score int, winnerIndex int, endReason string, done func(elo0Before, elo0After, elo1Before, elo1After *int))
+	// OnCheckpoint receives the game's JSON GameSnapshot whenever the game is between moves and its state
+	// changed, so it can be resumed after a restart (see RestoreGame). Optional, set by matchmaker; called
+	// from the game loop.
+	OnCheckpoint func(snapshot []byte)
+	// checkpointSum is the StateChecksum of the last snapshot handed to OnCheckpoint.
+	checkpointSum string
+	// endReported is set by the first end report; OnGameEnd never runs twice for the same game.
+	endReported atomic.Bool
+	// seatRTTMS is the latest round-trip time reported for each seat's connection (ms; 0 = unknown). See ReportRTT.
+	seatRTTMS [MaxSeats]atomic.Int64
+	// seatUnstable is the latest connection quality reported for each seat (see ReportConnectionQuality);
+	// notifiedUnstable is what the opponent was last told, and turnExtended whether this turn was extended.
+	seatUnstable     [MaxSeats]atomic.Bool
+	notifiedUnstable [MaxSeats]bool
+	turnExtended     bool
+
+	// recentChecksums are the last state checksums broadcast to the clients (see inSync).
+	recentChecksums []string
+
+	// seatLeft marks the seats of a party game whose player resigned or left; turns skip them (see leaveSeat).
+	seatLeft [MaxSeats]atomic.Bool
 }
 
-// NewGame creates a new Game between two players.
-func NewGame(id string, cfg *config.Config, p0, p1 *Player, pups PowerUpProvider) *Game {
-	board := NewBoard(cfg.BoardRows, cfg.BoardCols, ArcanaPairsPerMatch)
-	firstTurn := rand.Intn(2)
+// NewGame creates a new Game between two players, on a board from a fresh random seed. Returns an error
+// when cfg's board size fails config.ValidateBoard.
+func NewGame(id string, cfg *config.Config, p0, p1 *Player, pups PowerUpProvider) (*Game, error) {
+	return NewSeededGame(id, cfg, p0, p1, pups, rand.Int63(), nil)
+}
 
+// NewSeededGame creates a new Game whose opening (card layout and first turn) is derived from seed, so a
+// game can be replayed on the same board. arcanaPool fixes the arcana dealt on the board; when empty they
+// are picked from pups as usual. With the same seed, pool and board size the opening is identical.
+// Returns an error when cfg's board size fails config.ValidateBoard.
+func NewSeededGame(id string, cfg *config.Config, p0, p1 *Player, pups PowerUpProvider, seed int64, arcanaPool []string) (*Game, error) {
+	return newSeededGame(id, cfg, []*Player{p0, p1}, pups, seed, arcanaPool)
+}
+
+// newSeededGame creates a game between players (2 to MaxSeats) whose opening is derived from seed.
+func newSeededGame(id string, cfg *config.Config, players []*Player, pups PowerUpProvider, seed int64, arcanaPool []string) (*Game, error) {
+	if err := config.ValidateBoard(cfg.BoardRows, cfg.BoardCols, cfg.MinPairsPerElement); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(seed))
+	board := newBoard(cfg.BoardRows, cfg.BoardCols, ArcanaPairsPerMatch, cfg.ArcanaPlacement, rng.Shuffle)
+	firstTurn := rng.Intn(len(players))
+
+	// Arcana are assigned to pair IDs in ID order, so the seed alone decides where each one lies.
+	ids := append([]string(nil), arcanaPool...)
+	if len(ids) == 0 && pups != nil {
+		for _, pup := range pups.PickArcanaForMatch(ArcanaPairsPerMatch) {
+			ids = append(ids, pup.ID)
+		}
+	}
+	sort.Strings(ids)
 	pairIDToPowerUp := make(map[int]string)
-	if pups != nil {
-		arcana := pups.PickArcanaForMatch(ArcanaPairsPerMatch)
-		for i, pup := range arcana {
-			pairIDToPowerUp[i] = pup.ID
+	for i, id := range ids {
+		if i < ArcanaPairsPerMatch {
+			pairIDToPowerUp[i] = id
 		}
 	}
 
@@ -166,8 +310,9 @@ func NewGame(id string, cfg *config.Config, p0, p1 *Player, pups PowerUpProvider
 
 	return &Game{
 		ID:                id,
+		Seed:              seed,
 		Board:             board,
-		Players:           [2]*Player{p0, p1},
+		Players:           players,
 		CurrentTurn:       firstTurn,
 		TurnPhase:         FirstFlip,
 		FlippedIndices:    make([]int, 0, 2),
@@ -177,9 +322,10 @@ func NewGame(id string, cfg *config.Config, p0, p1 *Player, pups PowerUpProvider
 		PairIDToPowerUp:   pairIDToPowerUp,
 		KnownIndices:      knownIndices,
 		DisconnectedPlayerIdx: -1,
+		assistHintRound:   -1,
 		Actions:           make(chan Action, 16),
 		Done:              make(chan struct{}),
-	}
+	}, nil
 }
 
 // Run is the main game loop. It processes actions sequentially.
@@ -187,11 +333,14 @@ func NewGame(id string, cfg *config.Config, p0, p1 *Player, pups PowerUpProvider
 func (g *Game) Run() {
 	defer close(g.Done)
 
-	// Broadcast initial game state to both players
+	// Broadcast initial game state to both players (after the draft offers, so it shows the draft phase)
+	g.startDraft()
 	g.broadcastState()
-	g.TurnStartScores[0] = g.Players[0].Score
-	g.TurnStartScores[1] = g.Players[1].Score
+	g.recordReplay(ReplayStart, -1, -1, "", "")
+	g.noteTurnStartScores()
 	g.startTurnTimer()
+	g.armAssist()
+	g.checkpoint()
 
 	for {
 		action, ok := <-g.Actions
@@ -200,19 +349,64 @@ func (g *Game) Run() {
 		}
 		switch action.Type {
 		case ActionFlipCard:
-			if g.DisconnectedPlayerIdx >= 0 {
+			g.hotseatSeat(&action)
+			if g.DisconnectedPlayerIdx >= 0 || !g.memberMayAct(action) {
+				continue
+			}
+			if !g.inSync(action) {
 				continue
 			}
+			if g.flipTooSoon(action) {
+				continue
+			}
+			g.noteActionLatency(action)
+			g.turnMoves++
+			flipped := len(g.FlippedIndices)
 			g.handleFlipCard(action.PlayerIdx, action.Index)
+			if len(g.FlippedIndices) > flipped {
+				g.lastFlipAt = action.ReceivedAt
+			}
 		case ActionUsePowerUp:
-			if g.DisconnectedPlayerIdx >= 0 {
+			g.hotseatSeat(&action)
+			if g.DisconnectedPlayerIdx >= 0 || !g.memberMayAct(action) {
+				continue
+			}
+			if !g.inSync(action) {
 				continue
 			}
+			g.noteActionLatency(action)
+			g.turnMoves++
 			g.handleUsePowerUp(action.PlayerIdx, action.PowerUpID, action.CardIndex)
 		case ActionDisconnect:
+			if g.Party() {
+				g.leaveSeat(action.PlayerIdx, "left")
+				break
+			}
+			if g.Hotseat {
+				g.handleHotseatLeft()
+				return
+			}
+			if g.Teams[action.PlayerIdx] != nil && g.dropTeamMember(action.PlayerIdx, action.MemberIdx) {
+				continue
+			}
 			g.handleDisconnect(action.PlayerIdx)
 			return
 		case ActionPlayerDisconnected:
+			if g.Party() {
+				g.leaveSeat(action.PlayerIdx, "disconnected")
+				break
+			}
+			if g.Hotseat {
+				g.handleHotseatLeft()
+				return
+			}
+			if g.Teams[action.PlayerIdx] != nil {
+				if g.dropTeamMember(action.PlayerIdx, action.MemberIdx) {
+					continue
+				}
+				g.handleDisconnect(action.PlayerIdx)
+				return
+			}
 			g.handlePlayerDisconnected(action.PlayerIdx)
 		case ActionReconnectionTimeout:
 			g.handleReconnectionTimeout()
@@ -225,10 +419,37 @@ func (g *Game) Run() {
 			g.handleHideClairvoyanceReveal(action.ClairvoyanceRevealIndices)
 		case ActionTurnTimeout:
 			g.handleTurnTimeout()
+		case ActionResign:
+			g.hotseatSeat(&action)
+			g.handleResign(action.PlayerIdx)
+		case ActionAssistHint:
+			g.handleAssistHint(action.Round)
+		case ActionSeatRestarted:
+			g.handleSeatRestarted(action.PlayerIdx, action.KnownReply)
+		case ActionAIFailed:
+			g.handleAIFailed(action.PlayerIdx)
+		case ActionConnectionQuality:
+			g.handleConnectionQuality(action.PlayerIdx)
+		case ActionServerShutdown:
+			g.handleServerShutdown(action.Deadline)
+		case ActionShutdownDeadline:
+			g.handleShutdownDeadline()
+		case ActionChat:
+			g.handleChat(action)
+			continue // chatting is not play: the assist idle timer keeps running
+		case ActionEmote:
+			g.handleEmote(action)
+			continue
+		case ActionDraftPick:
+			g.handleDraftPick(action)
+		case ActionDraftTimeout:
+			g.finishDraft()
 		}
 		if g.Finished {
 			return
 		}
+		g.armAssist()
+		g.checkpoint()
 	}
 }
 
@@ -244,37 +465,42 @@ func (g *Game) cancelTurnTimer() {
 // startTurnTimer starts a timer for the current turn. If it expires, ActionTurnTimeout is sent.
 // No-op if Config.TurnLimitSec <= 0. Cancels any existing turn timer first.
 func (g *Game) startTurnTimer() {
-	if g.Config.TurnLimitSec <= 0 {
+	if g.Config.TurnLimitSec <= 0 || g.TurnPhase == Draft {
 		return
 	}
 	g.cancelTurnTimer()
-	g.turnEndsAt = time.Now().Add(time.Duration(g.Config.TurnLimitSec) * time.Second)
+	g.armTurnTimer(time.Now().Add(time.Duration(g.Config.TurnLimitSec) * time.Second))
+	g.turnExtended = false
+	if g.notifiedUnstable[g.CurrentTurn] {
+		g.extendTurn()
+	}
+}
+
+// armTurnTimer sends ActionTurnTimeout at endsAt unless the timer is cancelled or the game ends first.
+func (g *Game) armTurnTimer(endsAt time.Time) {
+	g.turnEndsAt = endsAt
 	g.turnTimerCancel = make(chan struct{})
 	cancel := g.turnTimerCancel
-	limit := time.Duration(g.Config.TurnLimitSec) * time.Second
 	go func() {
 		select {
-		case <-time.After(limit):
+		case <-time.After(time.Until(endsAt)):
 			select {
 			case g.Actions <- Action{Type: ActionTurnTimeout}:
 			case <-g.Done:
 			}
 		case <-cancel:
+		case <-g.Done:
 		}
 	}()
 }
 
 func (g *Game) sendError(playerIdx int, message string) {
-	player := g.Players[playerIdx]
-	if player == nil || player.Send == nil {
-		return
-	}
 	msg := map[string]string{
 		"type":    "error",
 		"message": message,
 	}
 	data, _ := json.Marshal(msg)
-	wsutil.SafeSend(player.Send, data)
+	g.sendToSeat(playerIdx, data)
 }
 
 func (g *Game) broadcastPowerUpUsed(playerName, powerUpLabel string, noEffect bool) {
@@ -285,10 +511,8 @@ func (g *Game) broadcastPowerUpUsed(playerName, powerUpLabel string, noEffect bo
 		"noEffect":     noEffect,
 	}
 	data, _ := json.Marshal(msg)
-	for i := range 2 {
-		if g.Players[i] != nil && g.Players[i].Send != nil {
-			wsutil.SafeSend(g.Players[i].Send, data)
-		}
+	for i := range g.Players {
+		g.sendToSeat(i, data)
 	}
 }
 
@@ -302,24 +526,21 @@ func (g *Game) broadcastPowerUpEffectResolved(playerName, powerUpLabel, message
 		"message":      message,
 	}
 	data, _ := json.Marshal(msg)
-	for i := range 2 {
-		if g.Players[i] != nil && g.Players[i].Send != nil {
-			wsutil.SafeSend(g.Players[i].Send, data)
-		}
+	for i := range g.Players {
+		g.sendToSeat(i, data)
 	}
 }
 
 func (g *Game) broadcastState() {
-	for i := range 2 {
+	g.noteChecksum(g.StateChecksum())
+	for i := range g.Players {
 		state := g.BuildStateForPlayer(i)
 		data, err := json.Marshal(state)
 		if err != nil {
 			slog.Error("marshaling game state", "tag", "game", "err", err)
 			continue
 		}
-		if g.Players[i] != nil && g.Players[i].Send != nil {
-			wsutil.SafeSend(g.Players[i].Send, data)
-		}
+		g.sendToSeat(i, data)
 	}
 }
 
@@ -332,12 +553,8 @@ func (g *Game) isKnown(idx int) bool {
 	return ok
 }
 
-// BuildStateForPlayer returns the game state view for the given player (0 or 1).
-func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
-	opponentIdx := 1 - playerIdx
-
-	// Build hand from player's power-up hand, in registry order so it stays stable across turn changes.
-	p := g.Players[playerIdx]
+// handView returns the player's power-up hand, in registry order so it stays stable across turn changes.
+func (g *Game) handView(p *Player) []PowerUpInHand {
 	h := p.Hand
 	cooldown := p.HandCooldown
 	if cooldown == nil {
@@ -353,6 +570,14 @@ func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
 			hand = append(hand, PowerUpInHand{PowerUpID: def.ID, Count: count, UsableCount: usable})
 		}
 	}
+	return hand
+}
+
+// BuildStateForPlayer returns the game state view for the given seat.
+func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
+	opponentIdx := g.opponentOf(playerIdx)
+
+	hand := g.handView(g.Players[playerIdx])
 
 	flipped := g.FlippedIndices
 	if flipped == nil {
@@ -369,6 +594,7 @@ func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
 
 	var clairvoyanceRevealed []int
 	var clairvoyanceRevealEndsAtUnixMs int64
+	var clairvoyanceRevealDurationMS int
 	if len(g.ClairvoyanceRevealedIndices) > 0 {
 		clairvoyanceRevealed = make([]int, 0, len(g.ClairvoyanceRevealedIndices))
 		for idx := range g.ClairvoyanceRevealedIndices {
@@ -376,6 +602,7 @@ func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
 		}
 		if !g.ClairvoyanceRevealEndsAt.IsZero() {
 			clairvoyanceRevealEndsAtUnixMs = g.ClairvoyanceRevealEndsAt.UnixMilli()
+			clairvoyanceRevealDurationMS = g.clairvoyanceRevealMS()
 		}
 	}
 	state := GameStateMsg{
@@ -393,8 +620,32 @@ func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
 		HighlightIndices:                g.Players[playerIdx].HighlightIndices,
 		ClairvoyanceRevealedIndices:     clairvoyanceRevealed,
 		ClairvoyanceRevealEndsAtUnixMs:  clairvoyanceRevealEndsAtUnixMs,
+		ClairvoyanceRevealDurationMS:    clairvoyanceRevealDurationMS,
+		RevealDurationMS:                g.RevealDurationMS(),
 		Round:                           g.Round,
 	}
+	state.MaxRemainingPoints = remainingPairs(g.Board) * PointsPerMatch
+	state.StateChecksum = g.StateChecksum()
+	state.Shop = g.buildShop(playerIdx)
+	if g.Config.MismatchRetries > 0 {
+		state.MismatchRetries = g.Config.MismatchRetries
+		state.RetriesLeft = g.Config.MismatchRetries - g.missStreak
+	}
+	if g.Config.MaxHandSize > 0 {
+		state.MaxHandSize = g.Config.MaxHandSize
+		state.HandOverflowRule = g.handOverflowRule()
+	}
+	state.You.CanWin = g.canWin(playerIdx)
+	state.Opponent.CanWin = g.canWin(opponentIdx)
+	if t := g.Teams[playerIdx]; t != nil {
+		state.Team = t.view()
+	}
+	if g.Hotseat {
+		state.Hotseat = g.hotseatView()
+	}
+	if g.Party() {
+		state.Party = g.partyView(playerIdx)
+	}
 	if playerIdx == g.CurrentTurn && !g.turnEndsAt.IsZero() && g.Config.TurnLimitSec > 0 {
 		state.TurnEndsAtUnixMs = g.turnEndsAt.UnixMilli()
 		state.TurnCountdownShowSec = g.Config.TurnCountdownShowSec
@@ -403,21 +654,34 @@ func (g *Game) BuildStateForPlayer(playerIdx int) GameStateMsg {
 }
 
 func (g *Game) broadcastGameOver() {
-	sendGameOverToBoth := func(elo0Before, elo0After, elo1Before, elo1After *int) {
-		for i := range 2 {
-			opponentIdx := 1 - i
-			var result string
-			if g.Players[i].Score > g.Players[opponentIdx].Score {
-				result = "win"
-			} else if g.Players[i].Score < g.Players[opponentIdx].Score {
-				result = "lose"
-			} else {
-				result = "draw"
-			}
+	g.sendGameOver(g.leader(), "completed")
+}
 
+// handleResign ends the game in favor of the opponent of playerIdx. In a party game the seat leaves and
+// the others play on (see leaveSeat).
+func (g *Game) handleResign(playerIdx int) {
+	if playerIdx < 0 || playerIdx >= len(g.Players) {
+		return
+	}
+	if g.Party() {
+		g.leaveSeat(playerIdx, "resigned")
+		return
+	}
+	g.cancelTurnTimer()
+	g.sendGameOver(1-playerIdx, "resigned")
+	g.Finished = true
+}
+
+// sendGameOver reports the end of the game to OnGameEnd and sends game_over to every seat.
+// winnerIdx is the winning seat, or -1 for draw; endReason is included in the message unless it is
+// "completed". Party games add the final standings.
+func (g *Game) sendGameOver(winnerIdx int, endReason string) {
+	sendGameOverToBoth := func(elo0Before, elo0After, elo1Before, elo1After *int) {
+		for i := range g.Players {
+			opponentIdx := g.opponentOf(i)
 			msg := map[string]any{
 				"type":   "game_over",
-				"result": result,
+				"result": g.resultFor(i, winnerIdx),
 				"you": map[string]any{
 					"name":  g.Players[i].Name,
 					"score": g.Players[i].Score,
@@ -427,6 +691,12 @@ func (g *Game) broadcastGameOver() {
 					"score": g.Players[opponentIdx].Score,
 				},
 			}
+			if endReason != "completed" {
+				msg["endReason"] = endReason
+			}
+			if g.Party() {
+				msg["standings"] = g.standings()
+			}
 			if i == 0 && elo0Before != nil && elo0After != nil {
 				msg["you_elo_before"] = *elo0Before
 				msg["you_elo_after"] = *elo0After
@@ -436,21 +706,28 @@ func (g *Game) broadcastGameOver() {
 				msg["you_elo_after"] = *elo1After
 			}
 			data, _ := json.Marshal(msg)
-			if g.Players[i] != nil && g.Players[i].Send != nil {
-				wsutil.SafeSend(g.Players[i].Send, data)
-			}
+			g.sendToSeat(i, data)
 		}
+		g.sendPolls()
 	}
 
+	g.reportGameEnd(winnerIdx, endReason, sendGameOverToBoth)
+}
+
+// reportGameEnd hands the result to OnGameEnd (or straight to done when unset), at most once per game: a
+// second ending, e.g. a disconnect racing with board completion, is dropped so the match is never rated
+// or recorded twice. Returns false when the end had already been reported.
+func (g *Game) reportGameEnd(winnerIdx int, endReason string, done func(elo0Before, elo0After, elo1Before, elo1After *int)) bool {
+	if !g.endReported.CompareAndSwap(false, true) {
+		slog.Warn("duplicate game end ignored", "tag", "game", "match_id", g.ID, "end_reason", endReason)
+		return false
+	}
+	g.recordFinalTurn()
+	g.recordReplay(ReplayEnd, winnerIdx, -1, "", endReason)
 	if g.OnGameEnd != nil {
-		winnerIdx := -1
-		if g.Players[0].Score > g.Players[1].Score {
-			winnerIdx = 0
-		} else if g.Players[1].Score > g.Players[0].Score {
-			winnerIdx = 1
-		}
-		g.OnGameEnd(g.ID, g.PlayerUserIDs[0], g.PlayerUserIDs[1], g.Players[0].Name, g.Players[1].Name, g.Players[0].Score, g.Players[1].Score, winnerIdx, "completed", sendGameOverToBoth)
+		g.OnGameEnd(g.ID, g.PlayerUserIDs[0], g.PlayerUserIDs[1], g.Players[0].Name, g.Players[1].Name, g.Players[0].Score, g.Players[1].Score, winnerIdx, endReason, done)
 	} else {
-		sendGameOverToBoth(nil, nil, nil, nil)
+		done(nil, nil, nil, nil)
 	}
+	return true
 }
@@ -2,6 +2,8 @@ package game
 
 import (
 	"encoding/json"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 
 // mockPowerUpProvider is a test double for PowerUpProvider.
 // Register power-ups with Register() so AllPowerUps() returns them in deterministic order.
+// Tests outside this package use powerup.NewBuiltinRegistry instead, which cannot be imported here.
 type mockPowerUpProvider struct {
 	powerUps map[string]PowerUpDef
 	order    []string
@@ -81,7 +84,10 @@ func createTestGame(cfg *config.Config) (*Game, chan []byte, chan []byte, *mockP
 	p1 := NewPlayer("Bob", send1)
 
 	pups := newMockPowerUpProvider()
-	g := NewGame("test-1", cfg, p0, p1, pups)
+	g, err := NewGame("test-1", cfg, p0, p1, pups)
+	if err != nil {
+		panic(err)
+	}
 
 	return g, send0, send1, pups
 }
@@ -370,6 +376,110 @@ func TestFlipCard_Mismatch(t *testing.T) {
 
 }
 
+func TestFlipCard_MismatchRetries(t *testing.T) {
+	cfg := testConfig()
+	cfg.MismatchRetries = 1
+	g, _, _, _ := createTestGame(cfg)
+	go g.Run()
+	defer func() {
+		select {
+		case g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 0}:
+		default:
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	currentPlayer := g.CurrentTurn
+	mismatch := func() {
+		idx1, idx2 := findNonPair(g.Board)
+		g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: currentPlayer, Index: idx1}
+		g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: currentPlayer, Index: idx2}
+		time.Sleep(time.Duration(cfg.RevealDurationMS+100) * time.Millisecond)
+	}
+
+	if got := g.BuildStateForPlayer(currentPlayer).RetriesLeft; got != 1 {
+		t.Errorf("expected 1 retry left at turn start, got %d", got)
+	}
+	mismatch()
+	if g.CurrentTurn != currentPlayer || g.TurnPhase != FirstFlip {
+		t.Fatalf("expected player %d to retry after the first mismatch, got turn %d phase %v", currentPlayer, g.CurrentTurn, g.TurnPhase)
+	}
+	if got := g.BuildStateForPlayer(currentPlayer).RetriesLeft; got != 0 {
+		t.Errorf("expected no retry left after a mismatch, got %d", got)
+	}
+	mismatch()
+	if g.CurrentTurn != 1-currentPlayer {
+		t.Errorf("expected turn to pass after two consecutive mismatches, got %d", g.CurrentTurn)
+	}
+	if got := g.BuildStateForPlayer(1 - currentPlayer).RetriesLeft; got != 1 {
+		t.Errorf("expected the next player to start with 1 retry, got %d", got)
+	}
+}
+
+func TestFlipCard_ThirdEye(t *testing.T) {
+	cfg := testConfig()
+	g, _, _, _ := createTestGame(cfg)
+	go g.Run()
+	defer func() {
+		select {
+		case g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 0}:
+		default:
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	currentPlayer := g.CurrentTurn
+	g.Players[currentPlayer].ThirdEyeActive = true
+	idx1, idx2 := findNonPair(g.Board)
+	partner := -1
+	for _, c := range g.Board.Cards {
+		if c.Index != idx1 && c.PairID == g.Board.Cards[idx1].PairID {
+			partner = c.Index
+		}
+	}
+
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: currentPlayer, Index: idx1}
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: currentPlayer, Index: idx2}
+	time.Sleep(50 * time.Millisecond)
+	if g.TurnPhase != ThirdFlip {
+		t.Fatalf("expected third_flip after a mismatch with Third Eye, got %v", g.TurnPhase)
+	}
+
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: currentPlayer, Index: partner}
+	time.Sleep(50 * time.Millisecond)
+	if g.Board.Cards[idx1].State != Matched || g.Board.Cards[partner].State != Matched {
+		t.Errorf("expected the first and third cards to match")
+	}
+	if g.Board.Cards[idx2].State != Hidden {
+		t.Errorf("expected the unmatched second card to be hidden, got %v", g.Board.Cards[idx2].State)
+	}
+	if g.Players[currentPlayer].Score != 1 || g.CurrentTurn != currentPlayer || g.TurnPhase != FirstFlip {
+		t.Errorf("expected a point and another attempt, got score %d turn %d phase %v", g.Players[currentPlayer].Score, g.CurrentTurn, g.TurnPhase)
+	}
+
+	// A miss on all three cards ends the turn and clears Third Eye.
+	var three []int
+	seen := make(map[int]bool)
+	for _, c := range g.Board.Cards {
+		if c.State == Hidden && !seen[c.PairID] && len(three) < 3 {
+			seen[c.PairID] = true
+			three = append(three, c.Index)
+		}
+	}
+	for _, idx := range three {
+		g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: currentPlayer, Index: idx}
+	}
+	time.Sleep(time.Duration(cfg.RevealDurationMS+100) * time.Millisecond)
+	for _, idx := range three {
+		if g.Board.Cards[idx].State != Hidden {
+			t.Errorf("expected card %d hidden after a triple miss, got %v", idx, g.Board.Cards[idx].State)
+		}
+	}
+	if g.CurrentTurn != 1-currentPlayer || g.Players[currentPlayer].ThirdEyeActive {
+		t.Errorf("expected the turn to pass with Third Eye cleared")
+	}
+}
+
 func TestFixedScoring(t *testing.T) {
 	cfg := testConfig()
 	g, send0, send1, _ := createTestGame(cfg)
@@ -529,6 +639,64 @@ func TestUsePowerUp_NotInHand(t *testing.T) {
 	}
 }
 
+func TestUsePowerUp_BuyPeekWithScore(t *testing.T) {
+	cfg := testConfig()
+	g, send0, send1, pups := createTestGame(cfg)
+	pups.Register("peek", PowerUpDef{
+		ID:   "peek",
+		Name: "Peek",
+		Cost: 2,
+		Apply: func(board *Board, active *Player, opponent *Player, ctx *PowerUpContext) error {
+			return nil
+		},
+	})
+
+	go g.Run()
+	defer func() {
+		select {
+		case g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 0}:
+		default:
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	currentPlayer := g.CurrentTurn
+	ch, other := send0, send1
+	if currentPlayer == 1 {
+		ch, other = send1, send0
+	}
+	drainChannel(ch)
+	drainChannel(other)
+	idx, _ := findNonPair(g.Board)
+
+	// Not enough points yet
+	g.Actions <- Action{Type: ActionUsePowerUp, PlayerIdx: currentPlayer, PowerUpID: "peek", CardIndex: idx}
+	time.Sleep(50 * time.Millisecond)
+	if !hasMessageType(drainChannel(ch), "error") {
+		t.Fatal("expected error when the player cannot afford the power-up")
+	}
+	if shop := g.BuildStateForPlayer(currentPlayer).Shop; len(shop) != 1 || shop[0].CanAfford {
+		t.Errorf("expected peek listed as unaffordable, got %+v", shop)
+	}
+
+	g.Players[currentPlayer].Score = 3
+	g.Actions <- Action{Type: ActionUsePowerUp, PlayerIdx: currentPlayer, PowerUpID: "peek", CardIndex: idx}
+	time.Sleep(50 * time.Millisecond)
+
+	if g.Players[currentPlayer].Score != 1 {
+		t.Errorf("expected score 1 after paying 2, got %d", g.Players[currentPlayer].Score)
+	}
+	if g.Board.Cards[idx].State != Hidden {
+		t.Errorf("expected peeked card to stay hidden, got %v", g.Board.Cards[idx].State)
+	}
+	if !hasMessageType(drainChannel(ch), "peek_result") {
+		t.Error("expected peek_result for the buyer")
+	}
+	if hasMessageType(drainChannel(other), "peek_result") {
+		t.Error("expected no peek_result for the opponent")
+	}
+}
+
 func TestUsePowerUp_WrongPhase(t *testing.T) {
 	cfg := testConfig()
 	g, send0, send1, pups := createTestGame(cfg)
@@ -738,7 +906,10 @@ func TestMatchGrantsPowerUp(t *testing.T) {
 		Rarity: 1,
 		Apply:  func(board *Board, active *Player, opponent *Player, ctx *PowerUpContext) error { return nil },
 	})
-	g := NewGame("match-grants-test", cfg, p0, p1, pups)
+	g, err := NewGame("match-grants-test", cfg, p0, p1, pups)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	go g.Run()
 	defer func() {
@@ -795,7 +966,10 @@ func TestArcanaCooldown_UseRejectedSameTurn(t *testing.T) {
 		Rarity: 1,
 		Apply:  func(board *Board, active *Player, opponent *Player, ctx *PowerUpContext) error { return nil },
 	})
-	g := NewGame("arcana-cooldown-reject", cfg, p0, p1, pups)
+	g, err := NewGame("arcana-cooldown-reject", cfg, p0, p1, pups)
+	if err != nil {
+		t.Fatal(err)
+	}
 	go g.Run()
 	defer func() {
 		select {
@@ -869,7 +1043,10 @@ func TestArcanaCooldown_UseAllowedAfterTurnSwitch(t *testing.T) {
 		Rarity: 1,
 		Apply:  func(board *Board, active *Player, opponent *Player, ctx *PowerUpContext) error { return nil },
 	})
-	g := NewGame("arcana-cooldown-allowed", cfg, p0, p1, pups)
+	g, err := NewGame("arcana-cooldown-allowed", cfg, p0, p1, pups)
+	if err != nil {
+		t.Fatal(err)
+	}
 	go g.Run()
 	defer func() {
 		select {
@@ -1037,7 +1214,10 @@ func TestFullGame(t *testing.T) {
 	p1 := NewPlayer("Bob", send1)
 
 	pups := newMockPowerUpProvider()
-	g := NewGame("full-game-test", cfg, p0, p1, pups)
+	g, err := NewGame("full-game-test", cfg, p0, p1, pups)
+	if err != nil {
+		t.Fatal(err)
+	}
 	go g.Run()
 
 	time.Sleep(50 * time.Millisecond)
@@ -1091,3 +1271,92 @@ func TestFullGame(t *testing.T) {
 		t.Error("expected game_over message")
 	}
 }
+
+func TestOnGameEnd_DisconnectAfterCompletionIgnored(t *testing.T) {
+	g, _, send1, _ := createTestGame(testConfig())
+	var calls int
+	var reasons []string
+	g.OnGameEnd = func(_, _, _, _, _ string, _, _ int, _ int, endReason string, done func(_, _, _, _ *int)) {
+		calls++
+		reasons = append(reasons, endReason)
+		done(nil, nil, nil, nil)
+	}
+
+	g.sendGameOver(0, "completed")
+	drainChannel(send1)
+	g.handleDisconnect(0)
+
+	if calls != 1 || reasons[0] != "completed" {
+		t.Fatalf("expected a single completed report, got %d calls %v", calls, reasons)
+	}
+	if hasMessageType(drainChannel(send1), "opponent_disconnected") {
+		t.Error("expected no opponent_disconnected after the game was already over")
+	}
+}
+
+func TestOnGameEnd_ConcurrentEndsReportOnce(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	var calls atomic.Int32
+	g.OnGameEnd = func(_, _, _, _, _ string, _, _ int, _ int, _ string, _ func(_, _, _, _ *int)) {
+		calls.Add(1)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.reportGameEnd(i%2, "completed", func(_, _, _, _ *int) {})
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected OnGameEnd once, got %d", n)
+	}
+}
+
+func TestBuildState_TimingHints(t *testing.T) {
+	cfg := testConfig()
+	cfg.PowerUps.Clairvoyance.RevealDurationMS = 2500
+	g, _, _, _ := createTestGame(cfg)
+
+	state := g.BuildStateForPlayer(0)
+	if state.RevealDurationMS != cfg.RevealDurationMS {
+		t.Errorf("expected revealDurationMs %d, got %d", cfg.RevealDurationMS, state.RevealDurationMS)
+	}
+	if state.ClairvoyanceRevealDurationMS != 0 {
+		t.Errorf("expected no clairvoyance duration without an active reveal, got %d", state.ClairvoyanceRevealDurationMS)
+	}
+
+	g.ClairvoyanceRevealedIndices = map[int]struct{}{0: {}}
+	g.ClairvoyanceRevealEndsAt = time.Now().Add(2500 * time.Millisecond)
+	if got := g.BuildStateForPlayer(1).ClairvoyanceRevealDurationMS; got != 2500 {
+		t.Errorf("expected clairvoyanceRevealDurationMs 2500, got %d", got)
+	}
+}
+
+func TestRevealDuration_AdaptsToLatency(t *testing.T) {
+	cfg := testConfig()
+	cfg.RevealDurationMS = 1000
+	g, _, _, _ := createTestGame(cfg)
+	g.ReportRTT(0, 80*time.Millisecond)
+	g.ReportRTT(1, 300*time.Millisecond)
+	if got := g.RevealDurationMS(); got != 1000 {
+		t.Errorf("expected the configured duration without bounds, got %d", got)
+	}
+
+	cfg.RevealDurationMinMS, cfg.RevealDurationMaxMS = 1100, 1500
+	if got := g.RevealDurationMS(); got != 1300 {
+		t.Errorf("expected 1000ms plus the worse RTT, got %d", got)
+	}
+	g.ReportRTT(1, 2*time.Second)
+	if got := g.RevealDurationMS(); got != 1500 {
+		t.Errorf("expected the max bound, got %d", got)
+	}
+	g.ReportRTT(0, 0)
+	g.ReportRTT(1, 0)
+	if got := g.RevealDurationMS(); got != 1100 {
+		t.Errorf("expected the min bound, got %d", got)
+	}
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Hand overflow rules (Config.HandOverflowRule), applied when a player matches an arcana pair while
+// holding Config.MaxHandSize copies.
+const (
+	HandOverflowDiscardOldest = "discard_oldest"
+	HandOverflowConvertPoints = "convert_to_points"
+	HandOverflowBlock         = "block"
+)
+
+// HandOverflowPoints is the score a blocked grant is worth under the convert_to_points rule.
+const HandOverflowPoints = 1
+
+// handSize returns how many arcana copies the player holds, including those on cooldown.
+func handSize(p *Player) int {
+	n := 0
+	for _, count := range p.Hand {
+		n += count
+	}
+	return n
+}
+
+// handOverflowRule returns the configured overflow rule; unknown values fall back to discard_oldest.
+func (g *Game) handOverflowRule() string {
+	switch g.Config.HandOverflowRule {
+	case HandOverflowConvertPoints, HandOverflowBlock:
+		return g.Config.HandOverflowRule
+	}
+	return HandOverflowDiscardOldest
+}
+
+// grantPowerUp adds one copy of powerUpID to the player's hand for matching its pair. The copy is on
+// cooldown until the player's next turn. When the hand is full, the overflow rule decides the outcome;
+// the player is told with hand_overflow and the event goes to telemetry.
+func (g *Game) grantPowerUp(playerIdx int, powerUpID string) {
+	player := g.Players[playerIdx]
+	if player.Hand == nil {
+		player.Hand = make(map[string]int)
+	}
+	if player.HandCooldown == nil {
+		player.HandCooldown = make(map[string]int)
+	}
+	if g.Config.MaxHandSize <= 0 || handSize(player) < g.Config.MaxHandSize {
+		player.Hand[powerUpID]++
+		player.HandCooldown[powerUpID]++
+		player.HandOrder = append(player.HandOrder, powerUpID)
+		return
+	}
+
+	rule := g.handOverflowRule()
+	discarded, points := "", 0
+	switch rule {
+	case HandOverflowDiscardOldest:
+		discarded = oldestInHand(player)
+		removeFromHand(player, discarded)
+		player.Hand[powerUpID]++
+		player.HandCooldown[powerUpID]++
+		player.HandOrder = append(player.HandOrder, powerUpID)
+	case HandOverflowConvertPoints:
+		points = HandOverflowPoints
+		player.Score += points
+	}
+
+	data, _ := json.Marshal(map[string]any{
+		"type":               "hand_overflow",
+		"powerUpId":          powerUpID,
+		"rule":               rule,
+		"discardedPowerUpId": discarded,
+		"points":             points,
+	})
+	g.sendToSeat(playerIdx, data)
+	if g.TelemetrySink != nil {
+		g.TelemetrySink.RecordHandOverflow(g.ID, g.Round, playerIdx, powerUpID, rule, discarded)
+	}
+}
+
+// oldestInHand returns the power-up ID of the copy held the longest. Hands filled without HandOrder
+// (e.g. in tests) fall back to the first ID in sorted order.
+func oldestInHand(p *Player) string {
+	for _, id := range p.HandOrder {
+		if p.Hand[id] > 0 {
+			return id
+		}
+	}
+	ids := make([]string, 0, len(p.Hand))
+	for id, count := range p.Hand {
+		if count > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+// removeFromHand drops the oldest copy of powerUpID from the hand (used or discarded). Copies on
+// cooldown are the newest, so the cooldown count only shrinks when no usable copy is left.
+func removeFromHand(p *Player, powerUpID string) {
+	if p.Hand[powerUpID] < 1 {
+		return
+	}
+	p.Hand[powerUpID]--
+	if p.Hand[powerUpID] == 0 {
+		delete(p.Hand, powerUpID)
+	}
+	if p.HandCooldown[powerUpID] > p.Hand[powerUpID] {
+		p.HandCooldown[powerUpID] = p.Hand[powerUpID]
+	}
+	for i, id := range p.HandOrder {
+		if id == powerUpID {
+			p.HandOrder = append(p.HandOrder[:i], p.HandOrder[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGrantPowerUp_OverflowRules(t *testing.T) {
+	tests := []struct {
+		rule          string
+		wantHand      map[string]int
+		wantScore     int
+		wantDiscarded string
+	}{
+		{HandOverflowDiscardOldest, map[string]int{"leech": 1, "oblivion": 1}, 0, "chaos"},
+		{HandOverflowConvertPoints, map[string]int{"chaos": 1, "leech": 1}, HandOverflowPoints, ""},
+		{HandOverflowBlock, map[string]int{"chaos": 1, "leech": 1}, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.MaxHandSize = 2
+			cfg.HandOverflowRule = tt.rule
+			g, send0, _, _ := createTestGame(cfg)
+
+			g.grantPowerUp(0, "chaos")
+			g.grantPowerUp(0, "leech")
+			if msgs := drainChannel(send0); hasMessageType(msgs, "hand_overflow") {
+				t.Fatal("expected no overflow below the hand limit")
+			}
+			g.grantPowerUp(0, "oblivion")
+
+			p := g.Players[0]
+			if len(p.Hand) != len(tt.wantHand) {
+				t.Fatalf("expected hand %v, got %v", tt.wantHand, p.Hand)
+			}
+			for id, n := range tt.wantHand {
+				if p.Hand[id] != n {
+					t.Errorf("expected %d %s, got %d", n, id, p.Hand[id])
+				}
+			}
+			if handSize(p) > cfg.MaxHandSize {
+				t.Errorf("hand size %d exceeds limit %d", handSize(p), cfg.MaxHandSize)
+			}
+			if p.Score != tt.wantScore {
+				t.Errorf("expected score %d, got %d", tt.wantScore, p.Score)
+			}
+
+			var overflow map[string]any
+			for _, msg := range drainChannel(send0) {
+				var m map[string]any
+				json.Unmarshal(msg, &m)
+				if m["type"] == "hand_overflow" {
+					overflow = m
+				}
+			}
+			if overflow == nil {
+				t.Fatal("expected hand_overflow message")
+			}
+			if overflow["rule"] != tt.rule || overflow["powerUpId"] != "oblivion" || overflow["discardedPowerUpId"] != tt.wantDiscarded {
+				t.Errorf("unexpected hand_overflow %v", overflow)
+			}
+		})
+	}
+}
+
+func TestRemoveFromHand_KeepsCooldownCopies(t *testing.T) {
+	cfg := testConfig()
+	g, _, _, _ := createTestGame(cfg)
+	p := g.Players[0]
+	p.Hand["chaos"] = 1
+	p.HandOrder = []string{"chaos"}
+	p.HandCooldown = make(map[string]int)
+	g.grantPowerUp(0, "chaos")
+
+	removeFromHand(p, "chaos")
+	if p.Hand["chaos"] != 1 || p.HandCooldown["chaos"] != 1 {
+		t.Errorf("expected the cooldown copy to remain, got hand %d cooldown %d", p.Hand["chaos"], p.HandCooldown["chaos"])
+	}
+	removeFromHand(p, "chaos")
+	if _, ok := p.Hand["chaos"]; ok || p.HandCooldown["chaos"] != 0 || len(p.HandOrder) != 0 {
+		t.Errorf("expected empty hand, got %v cooldown %v order %v", p.Hand, p.HandCooldown, p.HandOrder)
+	}
+}
+
+func TestBuildStateForPlayer_UsableCountExcludesCooldown(t *testing.T) {
+	cfg := testConfig()
+	g, _, _, pups := createTestGame(cfg)
+	pups.Register("chaos", PowerUpDef{ID: "chaos", Name: "Chaos"})
+	p := g.Players[0]
+	p.Hand["chaos"] = 1
+	p.HandCooldown = make(map[string]int)
+	g.grantPowerUp(0, "chaos")
+
+	state := g.BuildStateForPlayer(0)
+	if len(state.Hand) != 1 || state.Hand[0].Count != 2 || state.Hand[0].UsableCount != 1 {
+		t.Errorf("expected 2 chaos with 1 usable, got %+v", state.Hand)
+	}
+}
@@ -0,0 +1,38 @@
+package game
+
+// HotseatView is added to game_state in pass-and-play games: both seats share the device, so the state
+// carries both players' hands and which seat holds the move.
+type HotseatView struct {
+	ActiveSeat int                `json:"activeSeat"`
+	Names      [2]string          `json:"names"`
+	Hands      [2][]PowerUpInHand `json:"hands"`
+}
+
+// hotseatSeat makes a pass-and-play action act for the seat on turn: both seats are played from one
+// connection, which cannot tell them apart.
+func (g *Game) hotseatSeat(action *Action) {
+	if g.Hotseat {
+		action.PlayerIdx = g.CurrentTurn
+	}
+}
+
+// hotseatView returns the HotseatView of the current state.
+func (g *Game) hotseatView() *HotseatView {
+	v := &HotseatView{ActiveSeat: g.CurrentTurn}
+	for i, p := range g.Players {
+		v.Names[i] = p.Name
+		v.Hands[i] = g.handView(p)
+	}
+	return v
+}
+
+// handleHotseatLeft ends a pass-and-play game whose device left (disconnect or leave_game). No seat is
+// left to notify or to wait for, so the game ends at once without a winner.
+func (g *Game) handleHotseatLeft() {
+	for _, p := range g.Players {
+		p.Send = nil
+	}
+	g.cancelTurnTimer()
+	g.Finished = true
+	g.reportGameEnd(-1, "abandoned", func(_, _, _, _ *int) {})
+}
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHotseat_ActionsFollowTurnAndStateIsSentOnce(t *testing.T) {
+	send := make(chan []byte, 100)
+	g, err := NewGame("hotseat-1", testConfig(), NewPlayer("Alice", send), NewPlayer("Bob", send), newMockPowerUpProvider())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Hotseat = true
+	g.CurrentTurn = 1
+	go g.Run()
+	time.Sleep(50 * time.Millisecond)
+
+	msgs := drainChannel(send)
+	if len(msgs) != 1 {
+		t.Fatalf("expected a single game_state on the shared connection, got %d messages", len(msgs))
+	}
+	var state GameStateMsg
+	json.Unmarshal(msgs[0], &state)
+	if state.Hotseat == nil || state.Hotseat.ActiveSeat != 1 || state.Hotseat.Names != [2]string{"Alice", "Bob"} {
+		t.Fatalf("expected hotseat view with Bob on the move, got %+v", state.Hotseat)
+	}
+	if !state.YourTurn || state.You.Name != "Bob" {
+		t.Errorf("expected the state as Bob sees it, got you=%q yourTurn=%v", state.You.Name, state.YourTurn)
+	}
+
+	// The connection always sends seat 0; the flip counts for the seat on turn.
+	idx, _ := findNonPair(g.Board)
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx}
+	time.Sleep(50 * time.Millisecond)
+	if g.Board.Cards[idx].State != Revealed {
+		t.Errorf("expected the flip to count for seat 1, card is %v", g.Board.Cards[idx].State)
+	}
+
+	g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 0}
+	<-g.Done
+	if !g.Finished || g.Players[0].Send != nil || g.Players[1].Send != nil {
+		t.Error("expected leaving to end the game and release both seats")
+	}
+}
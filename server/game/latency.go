@@ -0,0 +1,51 @@
+package game
+
+import "time"
+
+// TurnLatency summarizes how quickly the server handled the active player's actions in one turn. It is
+// reported with each turn's telemetry to study whether lag correlates with losses and to tune
+// latency-dependent timings.
+type TurnLatency struct {
+	// Actions is the number of flip and power-up actions of the player processed in the turn.
+	Actions int
+	// AvgProcessingMS and MaxProcessingMS measure the delay from the server receiving an action
+	// (Action.ReceivedAt) to the game loop processing it.
+	AvgProcessingMS int
+	MaxProcessingMS int
+	// RTTMS is the player's last measured round-trip time when the turn ended (0 when unknown, e.g. the AI).
+	RTTMS int
+}
+
+// turnLatency accumulates processing delays of the current turn's actions.
+type turnLatency struct {
+	actions int
+	total   time.Duration
+	max     time.Duration
+}
+
+// noteActionLatency records the processing delay of a flip or power-up action of the player on turn.
+// Actions without a receive time (e.g. built in tests) are ignored.
+func (g *Game) noteActionLatency(action Action) {
+	if action.ReceivedAt.IsZero() || action.PlayerIdx != g.CurrentTurn {
+		return
+	}
+	d := time.Since(action.ReceivedAt)
+	g.turnLatency.actions++
+	g.turnLatency.total += d
+	g.turnLatency.max = max(g.turnLatency.max, d)
+}
+
+// takeTurnLatency returns the latency summary of the turn that just ended for playerIdx and starts a new one.
+func (g *Game) takeTurnLatency(playerIdx int) TurnLatency {
+	t := g.turnLatency
+	g.turnLatency = turnLatency{}
+	out := TurnLatency{
+		Actions:         t.actions,
+		MaxProcessingMS: int(t.max.Milliseconds()),
+		RTTMS:           int(g.SeatRTT(playerIdx).Milliseconds()),
+	}
+	if t.actions > 0 {
+		out.AvgProcessingMS = int((t.total / time.Duration(t.actions)).Milliseconds())
+	}
+	return out
+}
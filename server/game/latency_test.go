@@ -0,0 +1,36 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTurnLatency_SummarizesActionsOfTurn(t *testing.T) {
+	cfg := testConfig()
+	g, _, _, _ := createTestGame(cfg)
+	cur := g.CurrentTurn
+	now := time.Now()
+
+	g.noteActionLatency(Action{Type: ActionFlipCard, PlayerIdx: cur, ReceivedAt: now.Add(-20 * time.Millisecond)})
+	g.noteActionLatency(Action{Type: ActionFlipCard, PlayerIdx: cur, ReceivedAt: now.Add(-60 * time.Millisecond)})
+	g.noteActionLatency(Action{Type: ActionFlipCard, PlayerIdx: 1 - cur, ReceivedAt: now.Add(-time.Second)})
+	g.noteActionLatency(Action{Type: ActionFlipCard, PlayerIdx: cur})
+	g.ReportRTT(cur, 80*time.Millisecond)
+
+	lat := g.takeTurnLatency(cur)
+	if lat.Actions != 2 {
+		t.Errorf("expected 2 actions (off-turn and untimed ones ignored), got %d", lat.Actions)
+	}
+	if lat.MaxProcessingMS < 60 || lat.MaxProcessingMS >= 1000 {
+		t.Errorf("expected max processing about 60ms, got %d", lat.MaxProcessingMS)
+	}
+	if lat.AvgProcessingMS < 40 || lat.AvgProcessingMS > lat.MaxProcessingMS {
+		t.Errorf("expected average processing about 40ms, got %d", lat.AvgProcessingMS)
+	}
+	if lat.RTTMS != 80 {
+		t.Errorf("expected RTT 80ms, got %d", lat.RTTMS)
+	}
+	if next := g.takeTurnLatency(cur); next.Actions != 0 || next.MaxProcessingMS != 0 {
+		t.Errorf("expected a fresh summary for the next turn, got %+v", next)
+	}
+}
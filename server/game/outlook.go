@@ -0,0 +1,183 @@
+package game
+
+// PointsPerMatch is the score for matching a pair.
+const PointsPerMatch = 1
+
+// Blood Pact payoffs (see handleFlipCard and the turn-end paths).
+const (
+	BloodPactMatchesNeeded = 3
+	BloodPactBonus         = 5
+	BloodPactPenalty       = 3
+)
+
+// ScoreOutlook describes what is still in play from one player's point of view. It is filled either
+// from the exact game state (Game.Outlook) or from a game_state view (the AI), in which case unknown
+// holdings should be over-estimated so MaxGain stays an upper bound.
+type ScoreOutlook struct {
+	// RemainingPairs is the number of pairs not yet matched or removed.
+	RemainingPairs int
+	// BoardArcana are power-up IDs of arcana pairs still on the board (either player may collect them).
+	BoardArcana []string
+	// OwnArcana and OpponentArcana are copies per power-up ID in each hand (including those on cooldown).
+	OwnArcana      map[string]int
+	OpponentArcana map[string]int
+	// OwnPactMatches is the number of matches already counted toward an active Blood Pact (-1 = no active pact).
+	OwnPactMatches int
+	// OwnLeechActive is true when the player's Leech is active this turn.
+	OwnLeechActive bool
+	// OpponentPactActive is true when the opponent has an active Blood Pact that can still break.
+	OpponentPactActive bool
+	// OpponentScore caps how much the opponent can lose (scores never go below 0).
+	OpponentScore int
+}
+
+// MaxGain returns an upper bound on how far the player can still move the score gap in their favor:
+// points from every remaining pair, Blood Pact bonuses they could complete, and points the opponent
+// could still lose (Leech drains, broken pacts). bounded is false when a Necromancy is still in play,
+// since it returns collected pairs to the board and no finite bound holds.
+func (o ScoreOutlook) MaxGain() (gain int, bounded bool) {
+	if o.RemainingPairs <= 0 {
+		return 0, true
+	}
+	if o.OwnArcana["necromancy"] > 0 || o.OpponentArcana["necromancy"] > 0 {
+		return 0, false
+	}
+	ownLeech, ownPacts, oppPacts := o.OwnArcana["leech"], o.OwnArcana["blood_pact"], o.OpponentArcana["blood_pact"]
+	for _, id := range o.BoardArcana {
+		switch id {
+		case "necromancy":
+			return 0, false
+		case "leech":
+			ownLeech++
+		case "blood_pact":
+			ownPacts++
+			oppPacts++
+		}
+	}
+
+	gain = o.RemainingPairs * PointsPerMatch
+
+	// Blood Pact bonuses: finish the active pact first, then fresh pacts while enough pairs remain.
+	pairsLeft := o.RemainingPairs
+	if o.OwnPactMatches >= 0 {
+		need := BloodPactMatchesNeeded - o.OwnPactMatches
+		if need <= pairsLeft {
+			gain += BloodPactBonus
+			pairsLeft -= need
+		}
+	}
+	gain += BloodPactBonus * min(ownPacts, pairsLeft/BloodPactMatchesNeeded)
+
+	// Opponent losses: Leech drains one point per match; each pact the opponent holds can break once.
+	loss := 0
+	if o.OwnLeechActive || ownLeech > 0 {
+		loss += o.RemainingPairs * PointsPerMatch
+	}
+	if o.OpponentPactActive {
+		oppPacts++
+	}
+	loss += BloodPactPenalty * oppPacts
+	gain += min(loss, o.OpponentScore)
+	return gain, true
+}
+
+// Outlook returns the exact ScoreOutlook for playerIdx from the current board and both players' state.
+func (g *Game) Outlook(playerIdx int) ScoreOutlook {
+	return g.outlook(playerIdx, false)
+}
+
+// PublicOutlook returns the ScoreOutlook for playerIdx using only what both players can see: every
+// collected arcana is assumed to be still in both hands, so it never reveals a hand and MaxGain stays
+// an upper bound.
+func (g *Game) PublicOutlook(playerIdx int) ScoreOutlook {
+	return g.outlook(playerIdx, true)
+}
+
+func (g *Game) outlook(playerIdx int, public bool) ScoreOutlook {
+	p, opp := g.Players[playerIdx], g.Players[g.opponentOf(playerIdx)]
+	o := ScoreOutlook{
+		RemainingPairs:     remainingPairs(g.Board),
+		OwnPactMatches:     -1,
+		OwnLeechActive:     p.LeechActive,
+		OpponentPactActive: opp.BloodPactActive,
+		OpponentScore:      opp.Score,
+	}
+	if p.BloodPactActive {
+		o.OwnPactMatches = p.BloodPactMatchesCount
+	}
+	onBoard := make(map[int]bool)
+	for _, c := range g.Board.Cards {
+		if c.State != Matched && c.State != Removed {
+			onBoard[c.PairID] = true
+		}
+	}
+	collected := make(map[string]int)
+	for pairID, id := range g.PairIDToPowerUp {
+		if onBoard[pairID] {
+			o.BoardArcana = append(o.BoardArcana, id)
+		} else {
+			collected[id]++
+		}
+	}
+	if public {
+		o.OwnArcana, o.OpponentArcana = collected, collected
+	} else {
+		o.OwnArcana, o.OpponentArcana = heldArcana(p), heldArcana(opp)
+	}
+	return o
+}
+
+// canWin reports whether playerIdx can still finish strictly ahead of every other seat, judged from
+// public information.
+func (g *Game) canWin(playerIdx int) bool {
+	deficit := g.Players[g.opponentOf(playerIdx)].Score - g.Players[playerIdx].Score
+	gain, bounded := g.PublicOutlook(playerIdx).MaxGain()
+	return !bounded || deficit < gain
+}
+
+// remainingPairs counts pairs that are neither matched nor removed.
+func remainingPairs(board *Board) int {
+	n := 0
+	for _, c := range board.Cards {
+		if c.State != Matched && c.State != Removed {
+			n++
+		}
+	}
+	return n / 2
+}
+
+// heldArcana returns the player's hand, including copies still on cooldown (they become usable later).
+func heldArcana(p *Player) map[string]int {
+	out := make(map[string]int, len(p.Hand))
+	for id, n := range p.Hand {
+		if n > 0 {
+			out[id] = n
+		}
+	}
+	return out
+}
+
+// endIfInsurmountable ends the game when EndOnInsurmountableLead is enabled and the leader's margin
+// exceeds everything each trailing player can still gain. Returns true when the game ended.
+func (g *Game) endIfInsurmountable() bool {
+	if !g.Config.EndOnInsurmountableLead || g.Finished {
+		return false
+	}
+	leader := g.leader()
+	if leader < 0 {
+		return false
+	}
+	for seat, p := range g.Players {
+		if seat == leader || g.HasLeft(seat) {
+			continue
+		}
+		lead := g.Players[leader].Score - p.Score
+		if gain, bounded := g.Outlook(seat).MaxGain(); !bounded || lead <= gain {
+			return false
+		}
+	}
+	g.cancelTurnTimer()
+	g.sendGameOver(leader, "insurmountable_lead")
+	g.Finished = true
+	return true
+}
@@ -0,0 +1,203 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestScoreOutlook_MaxGain(t *testing.T) {
+	tests := []struct {
+		name        string
+		outlook     ScoreOutlook
+		wantGain    int
+		wantBounded bool
+	}{
+		{name: "no pairs left", outlook: ScoreOutlook{OwnPactMatches: -1}, wantGain: 0, wantBounded: true},
+		{name: "pairs only", outlook: ScoreOutlook{RemainingPairs: 4, OwnPactMatches: -1, OpponentScore: 10}, wantGain: 4, wantBounded: true},
+		{name: "leech in hand drains opponent", outlook: ScoreOutlook{RemainingPairs: 4, OwnArcana: map[string]int{"leech": 1}, OwnPactMatches: -1, OpponentScore: 10}, wantGain: 8, wantBounded: true},
+		{name: "leech drain capped by opponent score", outlook: ScoreOutlook{RemainingPairs: 4, OwnLeechActive: true, OwnPactMatches: -1, OpponentScore: 1}, wantGain: 5, wantBounded: true},
+		{name: "blood pact needs three pairs", outlook: ScoreOutlook{RemainingPairs: 2, OwnArcana: map[string]int{"blood_pact": 1}, OwnPactMatches: -1}, wantGain: 2, wantBounded: true},
+		{name: "blood pact completes", outlook: ScoreOutlook{RemainingPairs: 3, OwnArcana: map[string]int{"blood_pact": 1}, OwnPactMatches: -1}, wantGain: 3 + BloodPactBonus, wantBounded: true},
+		{name: "active pact finishes with fewer pairs", outlook: ScoreOutlook{RemainingPairs: 1, OwnPactMatches: 2}, wantGain: 1 + BloodPactBonus, wantBounded: true},
+		{name: "opponent pact can break", outlook: ScoreOutlook{RemainingPairs: 2, OpponentArcana: map[string]int{"blood_pact": 1}, OwnPactMatches: -1, OpponentScore: 10}, wantGain: 2 + BloodPactPenalty, wantBounded: true},
+		{name: "board pact counts for both sides", outlook: ScoreOutlook{RemainingPairs: 3, BoardArcana: []string{"blood_pact"}, OwnPactMatches: -1, OpponentScore: 10}, wantGain: 3 + BloodPactBonus + BloodPactPenalty, wantBounded: true},
+		{name: "necromancy in a hand", outlook: ScoreOutlook{RemainingPairs: 3, OpponentArcana: map[string]int{"necromancy": 1}, OwnPactMatches: -1}, wantBounded: false},
+		{name: "necromancy on the board", outlook: ScoreOutlook{RemainingPairs: 3, BoardArcana: []string{"necromancy"}, OwnPactMatches: -1}, wantBounded: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gain, bounded := tt.outlook.MaxGain()
+			if bounded != tt.wantBounded {
+				t.Fatalf("bounded = %v, want %v", bounded, tt.wantBounded)
+			}
+			if bounded && gain != tt.wantGain {
+				t.Errorf("gain = %d, want %d", gain, tt.wantGain)
+			}
+		})
+	}
+}
+
+func TestGameOutlook_FromBoardAndHands(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	g.PairIDToPowerUp = map[int]string{0: "leech", 1: "chaos"}
+	// Match pair 0 (the leech pair) and give it to player 0.
+	for i := range g.Board.Cards {
+		if g.Board.Cards[i].PairID == 0 {
+			g.Board.Cards[i].State = Matched
+		}
+	}
+	g.Players[0].Hand["leech"] = 1
+	g.Players[1].Score = 3
+
+	o := g.Outlook(0)
+	if o.RemainingPairs != 7 {
+		t.Errorf("expected 7 remaining pairs on a 4x4 board with one matched, got %d", o.RemainingPairs)
+	}
+	if len(o.BoardArcana) != 1 || o.BoardArcana[0] != "chaos" {
+		t.Errorf("expected only chaos on the board, got %v", o.BoardArcana)
+	}
+	if o.OwnArcana["leech"] != 1 || o.OpponentScore != 3 {
+		t.Errorf("expected own leech and opponent score 3, got %v / %d", o.OwnArcana, o.OpponentScore)
+	}
+	if gain, _ := o.MaxGain(); gain != 7+3 {
+		t.Errorf("expected gain 10 (7 pairs + leech drain capped at 3), got %d", gain)
+	}
+}
+
+func TestResign(t *testing.T) {
+	g, send0, send1, _ := createTestGame(testConfig())
+	var gotReason string
+	var gotWinner int
+	g.OnGameEnd = func(_, _, _, _, _ string, _, _ int, winnerIdx int, endReason string, done func(_, _, _, _ *int)) {
+		gotReason, gotWinner = endReason, winnerIdx
+		done(nil, nil, nil, nil)
+	}
+	g.Players[1].Score = 5
+	go g.Run()
+	time.Sleep(50 * time.Millisecond)
+	drainChannel(send0)
+	drainChannel(send1)
+
+	g.Actions <- Action{Type: ActionResign, PlayerIdx: 1}
+	select {
+	case <-g.Done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the game to end after a resign")
+	}
+	if gotReason != "resigned" || gotWinner != 0 {
+		t.Errorf("expected OnGameEnd resigned with winner 0, got %q winner %d", gotReason, gotWinner)
+	}
+	for i, ch := range []chan []byte{send0, send1} {
+		var msg map[string]any
+		msgs := drainChannel(ch)
+		if len(msgs) == 0 {
+			t.Fatalf("player %d: expected game_over", i)
+		}
+		json.Unmarshal(msgs[len(msgs)-1], &msg)
+		want := "win"
+		if i == 1 {
+			want = "lose"
+		}
+		if msg["type"] != "game_over" || msg["result"] != want || msg["endReason"] != "resigned" {
+			t.Errorf("player %d: expected game_over %s resigned, got %v", i, want, msg)
+		}
+	}
+}
+
+// setupLeadGame matches all but three pairs in favor of player 0 and clears the arcana on the board,
+// so one more match by player 0 leaves a lead the trailer cannot close.
+func setupLeadGame(endOnLead bool) (*Game, chan []byte, chan []byte) {
+	cfg := testConfig()
+	cfg.EndOnInsurmountableLead = endOnLead
+	g, send0, send1, _ := createTestGame(cfg)
+	g.PairIDToPowerUp = map[int]string{}
+	g.CurrentTurn = 0
+	for i := range g.Board.Cards {
+		if g.Board.Cards[i].PairID < 5 {
+			g.Board.Cards[i].State = Matched
+		}
+	}
+	g.Players[0].Score = 5
+	return g, send0, send1
+}
+
+func TestInsurmountableLead_EndsGame(t *testing.T) {
+	g, send0, send1 := setupLeadGame(true)
+	var gotReason string
+	g.OnGameEnd = func(_, _, _, _, _ string, _, _ int, _ int, endReason string, done func(_, _, _, _ *int)) {
+		gotReason = endReason
+		done(nil, nil, nil, nil)
+	}
+	go g.Run()
+	time.Sleep(50 * time.Millisecond)
+	drainChannel(send0)
+	drainChannel(send1)
+
+	idx1, idx2 := findPair(g.Board)
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx1}
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx2}
+	select {
+	case <-g.Done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the game to end once the lead (6) exceeds the 2 remaining pairs")
+	}
+	if gotReason != "insurmountable_lead" {
+		t.Errorf("expected end reason insurmountable_lead, got %q", gotReason)
+	}
+	msgs := drainChannel(send1)
+	var msg map[string]any
+	json.Unmarshal(msgs[len(msgs)-1], &msg)
+	if msg["type"] != "game_over" || msg["result"] != "lose" || msg["endReason"] != "insurmountable_lead" {
+		t.Errorf("expected game_over lose insurmountable_lead for the trailer, got %v", msg)
+	}
+}
+
+func TestInsurmountableLead_DisabledKeepsPlaying(t *testing.T) {
+	g, _, _ := setupLeadGame(false)
+	go g.Run()
+	defer func() { g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 1} }()
+	time.Sleep(50 * time.Millisecond)
+
+	idx1, idx2 := findPair(g.Board)
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx1}
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx2}
+	time.Sleep(50 * time.Millisecond)
+	if g.Finished {
+		t.Error("expected the game to continue when end_on_insurmountable_lead is off")
+	}
+}
+
+func TestInsurmountableLead_CloseGameContinues(t *testing.T) {
+	g, _, _ := setupLeadGame(true)
+	g.Players[1].Score = 4
+	go g.Run()
+	defer func() { g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 1} }()
+	time.Sleep(50 * time.Millisecond)
+
+	idx1, idx2 := findPair(g.Board)
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx1}
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, Index: idx2}
+	time.Sleep(50 * time.Millisecond)
+	if g.Finished {
+		t.Error("expected the game to continue while the trailer can still draw")
+	}
+}
+
+func TestBuildState_ScoreProjection(t *testing.T) {
+	g, _, _ := setupLeadGame(false)
+
+	state := g.BuildStateForPlayer(1)
+	if state.MaxRemainingPoints != 3 {
+		t.Errorf("expected maxRemainingPoints 3, got %d", state.MaxRemainingPoints)
+	}
+	if state.You.CanWin || !state.Opponent.CanWin {
+		t.Errorf("expected only the leader to be able to win, got you=%v opponent=%v", state.You.CanWin, state.Opponent.CanWin)
+	}
+
+	// A collected Leech may sit in either hand: the trailer is not written off, and no hand is revealed.
+	g.PairIDToPowerUp = map[int]string{0: "leech"}
+	if !g.BuildStateForPlayer(1).You.CanWin || !g.BuildStateForPlayer(0).Opponent.CanWin {
+		t.Error("expected a collected Leech to keep the trailer's comeback possible from both views")
+	}
+}
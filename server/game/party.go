@@ -0,0 +1,265 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"memory-game-server/config"
+)
+
+// MaxSeats is the most players a game can seat: a party game has 3 or 4, every other game 2.
+const MaxSeats = 4
+
+// PartyPlayer is one seat of a party game as shown to every player.
+type PartyPlayer struct {
+	Seat  int    `json:"seat"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	// Left is set once the seat's player resigned or left; the seat keeps its score but no longer plays.
+	Left bool `json:"left,omitempty"`
+}
+
+// PartyView is added to game_state in party games: every seat in seat order, the viewer's seat and the
+// seat on turn. You and Opponent still carry the viewer and the best-scoring rival (see opponentOf).
+type PartyView struct {
+	Seat        int           `json:"seat"`
+	CurrentTurn int           `json:"currentTurn"`
+	Players     []PartyPlayer `json:"players"`
+}
+
+// PlayerLeftMsg tells the other seats of a party game that a player resigned ("resigned") or left
+// ("left", "disconnected"); the game goes on without them.
+type PlayerLeftMsg struct {
+	Type   string `json:"type"` // "player_left"
+	Seat   int    `json:"seat"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// NewPartyGame creates a free-for-all game between 2 to MaxSeats players, who take turns in seat order
+// from a random first seat. Returns an error for a bad player count or when cfg's board size fails
+// config.ValidateBoard.
+func NewPartyGame(id string, cfg *config.Config, players []*Player, pups PowerUpProvider) (*Game, error) {
+	if len(players) < 2 || len(players) > MaxSeats {
+		return nil, fmt.Errorf("a party game seats 2 to %d players, got %d", MaxSeats, len(players))
+	}
+	return newSeededGame(id, cfg, players, pups, rand.Int63(), nil)
+}
+
+// Party reports whether the game seats more than two players.
+func (g *Game) Party() bool {
+	return len(g.Players) > 2
+}
+
+// HasLeft reports whether the seat's player resigned or left a party game that goes on without them.
+// Safe to call from any goroutine.
+func (g *Game) HasLeft(seat int) bool {
+	return seat >= 0 && seat < len(g.Players) && g.seatLeft[seat].Load()
+}
+
+// nextSeat returns the seat that plays after seat: the next one in seat order that has not left. In a
+// 1v1 that is always the other seat.
+func (g *Game) nextSeat(seat int) int {
+	n := len(g.Players)
+	for i := 1; i <= n; i++ {
+		if s := (seat + i) % n; !g.seatLeft[s].Load() {
+			return s
+		}
+	}
+	return seat
+}
+
+// opponentOf returns the seat playerIdx is measured against: the other seat in a 1v1; in a party game
+// the best-scoring seat still in play, ties going to the first to play after playerIdx. Leech drains
+// this seat, and the Opponent of game_state and game_over shows it.
+func (g *Game) opponentOf(playerIdx int) int {
+	n := len(g.Players)
+	best := -1
+	for i := 1; i < n; i++ {
+		s := (playerIdx + i) % n
+		if g.seatLeft[s].Load() {
+			continue
+		}
+		if best < 0 || g.Players[s].Score > g.Players[best].Score {
+			best = s
+		}
+	}
+	if best < 0 {
+		return (playerIdx + 1) % n
+	}
+	return best
+}
+
+// leader returns the seat with the highest score among those still in play, or -1 when the top is tied.
+func (g *Game) leader() int {
+	best, tied := -1, false
+	for s, p := range g.Players {
+		if g.seatLeft[s].Load() {
+			continue
+		}
+		switch {
+		case best < 0 || p.Score > g.Players[best].Score:
+			best, tied = s, false
+		case p.Score == g.Players[best].Score:
+			tied = true
+		}
+	}
+	if tied {
+		return -1
+	}
+	return best
+}
+
+// seatsInPlay counts the seats that have not left.
+func (g *Game) seatsInPlay() int {
+	n := 0
+	for s := range g.Players {
+		if !g.seatLeft[s].Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// resultFor returns the game_over result of seat: "win", "lose" or "draw". In a party game without a
+// single winner only the seats sharing the top score draw; the rest, and seats that left, lose.
+func (g *Game) resultFor(seat, winnerIdx int) string {
+	switch {
+	case winnerIdx == seat:
+		return "win"
+	case winnerIdx >= 0:
+		return "lose"
+	case g.Party() && (g.seatLeft[seat].Load() || g.Players[seat].Score < g.topScore()):
+		return "lose"
+	}
+	return "draw"
+}
+
+// topScore returns the highest score among the seats still in play.
+func (g *Game) topScore() int {
+	top := 0
+	for s, p := range g.Players {
+		if !g.seatLeft[s].Load() && p.Score > top {
+			top = p.Score
+		}
+	}
+	return top
+}
+
+// noteTurnStartScores remembers every seat's score at the start of a turn (see TurnStartScores).
+func (g *Game) noteTurnStartScores() {
+	for i, p := range g.Players {
+		g.TurnStartScores[i] = p.Score
+	}
+}
+
+// sendToOthers delivers data to every seat but seat.
+func (g *Game) sendToOthers(seat int, data []byte) {
+	for i := range g.Players {
+		if i != seat {
+			g.sendToSeat(i, data)
+		}
+	}
+}
+
+// partyPlayers returns every seat of the game, in seat order.
+func (g *Game) partyPlayers() []PartyPlayer {
+	out := make([]PartyPlayer, len(g.Players))
+	for i, p := range g.Players {
+		out[i] = PartyPlayer{Seat: i, Name: p.Name, Score: p.Score, Left: g.seatLeft[i].Load()}
+	}
+	return out
+}
+
+// partyView returns the PartyView of the current state for seat.
+func (g *Game) partyView(seat int) *PartyView {
+	return &PartyView{Seat: seat, CurrentTurn: g.CurrentTurn, Players: g.partyPlayers()}
+}
+
+// standings returns the seats ordered by final score, highest first; equal scores keep seat order.
+func (g *Game) standings() []PartyPlayer {
+	out := g.partyPlayers()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+// leaveSeat takes a party seat out of the game when its player resigns or leaves: the player is told
+// they lost (on resign), the seat keeps its score but no longer takes turns, and the other seats get
+// player_left. Party games have no reconnection window. When the seat was on move the turn passes; when
+// a single seat is left, it wins.
+func (g *Game) leaveSeat(seat int, reason string) {
+	if seat < 0 || seat >= len(g.Players) || g.seatLeft[seat].Load() {
+		return
+	}
+	player := g.Players[seat]
+	g.seatLeft[seat].Store(true)
+	if reason == "resigned" {
+		data, _ := json.Marshal(map[string]any{
+			"type":      "game_over",
+			"result":    "lose",
+			"endReason": reason,
+			"you":       map[string]any{"name": player.Name, "score": player.Score},
+			"standings": g.standings(),
+		})
+		g.sendToSeat(seat, data)
+	}
+	player.Send = nil
+	data, _ := json.Marshal(PlayerLeftMsg{Type: "player_left", Seat: seat, Name: player.Name, Reason: reason})
+	g.sendToOthers(seat, data)
+
+	if g.seatsInPlay() == 1 {
+		g.cancelTurnTimer()
+		g.sendGameOver(g.nextSeat(seat), reason)
+		g.Finished = true
+		return
+	}
+	switch {
+	case g.TurnPhase == Draft:
+		if seat == g.CurrentTurn {
+			g.CurrentTurn = g.nextSeat(seat)
+		}
+		if g.draftComplete() {
+			g.finishDraft()
+			return
+		}
+	case seat == g.CurrentTurn:
+		g.passTurnFromLeftSeat()
+	}
+	g.broadcastState()
+	g.endIfInsurmountable()
+}
+
+// passTurnFromLeftSeat ends the turn of the seat on move, which just left: its face-up cards go down and
+// its turn effects end, as on a turn timeout, and the next seat in play gets the move.
+func (g *Game) passTurnFromLeftSeat() {
+	for _, idx := range g.FlippedIndices {
+		g.Board.Cards[idx].State = Hidden
+	}
+	g.FlippedIndices = g.FlippedIndices[:0]
+	for _, p := range g.Players {
+		p.HighlightIndices = nil
+	}
+	player := g.Players[g.CurrentTurn]
+	player.LeechActive = false
+	player.ThirdEyeActive = false
+	player.BloodPactActive = false
+	player.BloodPactMatchesCount = 0
+	g.missStreak = 0
+	g.recordTurn(TurnEndLeft)
+	g.Round++
+	g.CurrentTurn = g.nextSeat(g.CurrentTurn)
+	g.TurnPhase = FirstFlip
+	g.noteTurnStartScores()
+	g.clearHandCooldownForPlayer(g.CurrentTurn)
+	g.cancelTurnTimer()
+	g.startTurnTimer()
+}
+
+// highlightForAll shows the same highlighted cards to every seat (Unveiling, Elementals).
+func (g *Game) highlightForAll(indices []int) {
+	for _, p := range g.Players {
+		p.HighlightIndices = indices
+	}
+}
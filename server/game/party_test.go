@@ -0,0 +1,178 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// createPartyTestGame creates a party game of n players (Alice, Bob, Carol, Dave) with seat 0 on move.
+// It returns the game and the seats' send channels.
+func createPartyTestGame(n int) (*Game, []chan []byte) {
+	names := []string{"Alice", "Bob", "Carol", "Dave"}
+	sends := make([]chan []byte, n)
+	players := make([]*Player, n)
+	for i := range n {
+		sends[i] = make(chan []byte, 100)
+		players[i] = NewPlayer(names[i], sends[i])
+	}
+	g, err := NewPartyGame("party-1", testConfig(), players, newMockPowerUpProvider())
+	if err != nil {
+		panic(err)
+	}
+	g.CurrentTurn = 0
+	return g, sends
+}
+
+// missTurn makes the seat on move flip a non-matching pair and resolves the mismatch.
+func missTurn(g *Game) {
+	seat := g.CurrentTurn
+	a, b := findNonPair(g.Board)
+	g.handleFlipCard(seat, a)
+	g.handleFlipCard(seat, b)
+	g.handleResolveMismatch(seat)
+}
+
+func TestNewPartyGame_PlayerCount(t *testing.T) {
+	for _, n := range []int{1, MaxSeats + 1} {
+		players := make([]*Player, n)
+		for i := range players {
+			players[i] = NewPlayer("P", nil)
+		}
+		if _, err := NewPartyGame("p", testConfig(), players, newMockPowerUpProvider()); err == nil {
+			t.Errorf("expected an error for %d players", n)
+		}
+	}
+	g, _ := createPartyTestGame(3)
+	if !g.Party() {
+		t.Error("expected a 3-player game to be a party game")
+	}
+	if two, _, _, _ := createTestGame(testConfig()); two.Party() {
+		t.Error("expected a 1v1 game not to be a party game")
+	}
+}
+
+func TestParty_TurnRotation(t *testing.T) {
+	g, _ := createPartyTestGame(4)
+	for _, want := range []int{1, 2, 3, 0} {
+		missTurn(g)
+		if g.CurrentTurn != want {
+			t.Fatalf("expected seat %d on move, got %d", want, g.CurrentTurn)
+		}
+	}
+}
+
+func TestParty_LeechDrainsBestScoringRival(t *testing.T) {
+	g, _ := createPartyTestGame(3)
+	g.Players[1].Score = 2
+	g.Players[2].Score = 5
+	g.Players[0].LeechActive = true
+
+	a, b := findPair(g.Board)
+	g.handleFlipCard(0, a)
+	g.handleFlipCard(0, b)
+	if g.Players[2].Score != 5-PointsPerMatch || g.Players[1].Score != 2 {
+		t.Errorf("expected Leech to drain the leader Carol only, got Bob %d, Carol %d", g.Players[1].Score, g.Players[2].Score)
+	}
+}
+
+func TestParty_StateShowsEverySeat(t *testing.T) {
+	g, _ := createPartyTestGame(3)
+	g.Players[1].Score = 3
+	g.Players[2].Score = 7
+
+	state := g.BuildStateForPlayer(1)
+	if state.Party == nil || state.Party.Seat != 1 || len(state.Party.Players) != 3 {
+		t.Fatalf("expected a party view of 3 seats for seat 1, got %+v", state.Party)
+	}
+	if state.Opponent.Name != "Carol" {
+		t.Errorf("expected the best-scoring rival as opponent, got %q", state.Opponent.Name)
+	}
+	if two, _, _, _ := createTestGame(testConfig()); two.BuildStateForPlayer(0).Party != nil {
+		t.Error("expected no party view in a 1v1 game")
+	}
+}
+
+func TestParty_GameOverStandings(t *testing.T) {
+	g, sends := createPartyTestGame(3)
+	g.Players[0].Score = 4
+	g.Players[1].Score = 9
+	g.Players[2].Score = 6
+	g.broadcastGameOver()
+
+	want := []string{"lose", "win", "lose"}
+	for seat, ch := range sends {
+		var over struct {
+			Type      string        `json:"type"`
+			Result    string        `json:"result"`
+			Standings []PartyPlayer `json:"standings"`
+		}
+		for _, data := range drainChannel(ch) {
+			json.Unmarshal(data, &over)
+		}
+		if over.Type != "game_over" || over.Result != want[seat] {
+			t.Errorf("seat %d: expected game_over %q, got %q %q", seat, want[seat], over.Type, over.Result)
+		}
+		if len(over.Standings) != 3 || over.Standings[0].Name != "Bob" || over.Standings[2].Name != "Alice" {
+			t.Errorf("seat %d: expected standings Bob, Carol, Alice, got %+v", seat, over.Standings)
+		}
+	}
+}
+
+func TestParty_TiedTopDrawsOthersLose(t *testing.T) {
+	g, _ := createPartyTestGame(3)
+	g.Players[0].Score = 6
+	g.Players[1].Score = 6
+	g.Players[2].Score = 2
+	if g.leader() != -1 {
+		t.Fatalf("expected no single leader, got seat %d", g.leader())
+	}
+	for seat, want := range []string{"draw", "draw", "lose"} {
+		if got := g.resultFor(seat, -1); got != want {
+			t.Errorf("seat %d: expected %q, got %q", seat, want, got)
+		}
+	}
+}
+
+func TestParty_LeavingSeatIsSkipped(t *testing.T) {
+	g, sends := createPartyTestGame(3)
+	g.leaveSeat(0, "left")
+	g.handleChat(Action{Type: ActionChat, PlayerIdx: 1, Text: "bye"})
+
+	if g.Finished {
+		t.Fatal("expected the game to go on with two players left")
+	}
+	if !g.HasLeft(0) || g.CurrentTurn != 1 {
+		t.Fatalf("expected seat 0 out and seat 1 on move, got left=%v turn=%d", g.HasLeft(0), g.CurrentTurn)
+	}
+	if !hasMessageType(drainChannel(sends[2]), "player_left") {
+		t.Error("expected player_left for the remaining seats")
+	}
+	if hasMessageType(drainChannel(sends[0]), "chat") {
+		t.Error("expected no messages for the seat that left")
+	}
+	missTurn(g)
+	missTurn(g)
+	if g.CurrentTurn != 1 {
+		t.Errorf("expected the turn to skip seat 0, got seat %d", g.CurrentTurn)
+	}
+}
+
+func TestParty_LastSeatStandingWins(t *testing.T) {
+	g, sends := createPartyTestGame(3)
+	var winner int
+	g.OnGameEnd = func(_, _, _, _, _ string, _, _ int, winnerIdx int, _ string, done func(_, _, _, _ *int)) {
+		winner = winnerIdx
+		done(nil, nil, nil, nil)
+	}
+	g.handleResign(0)
+	if g.Finished {
+		t.Fatal("expected the game to go on after the first resign")
+	}
+	if !hasMessageType(drainChannel(sends[0]), "game_over") {
+		t.Error("expected game_over for the player who resigned")
+	}
+	g.leaveSeat(2, "left")
+	if !g.Finished || winner != 1 {
+		t.Errorf("expected seat 1 to win once alone, got finished=%v winner=%d", g.Finished, winner)
+	}
+}
@@ -0,0 +1,48 @@
+package game
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sort"
+)
+
+// awardMatchArcana grants the arcana tied to a matched pair, or, for a normal pair, a pity arcana once the
+// player has matched Config.ArcanaPityMatches normal pairs in a row without obtaining one. The pity copy is
+// drawn at random from this match's arcana pool and goes through the usual hand rules.
+// The opponent is told about arcana pairs (the pair is face up anyway) but not about pity copies.
+func (g *Game) awardMatchArcana(playerIdx, pairID int) {
+	player := g.Players[playerIdx]
+	if powerUpID, ok := g.PairIDToPowerUp[pairID]; ok {
+		player.MatchesSinceArcana = 0
+		g.grantPowerUp(playerIdx, powerUpID)
+		data, _ := json.Marshal(map[string]any{"type": "opponent_gained_arcana", "pairId": pairID, "powerUpId": powerUpID})
+		g.sendToOthers(playerIdx, data)
+		return
+	}
+	if g.Config.ArcanaPityMatches <= 0 || player.MatchesSinceArcana < g.Config.ArcanaPityMatches {
+		player.MatchesSinceArcana++
+		return
+	}
+	pool := g.arcanaPool()
+	if len(pool) == 0 {
+		return
+	}
+	powerUpID := pool[rand.Intn(len(pool))]
+	player.MatchesSinceArcana = 0
+	if g.TelemetrySink != nil {
+		g.TelemetrySink.RecordPityGrant(g.ID, g.Round, playerIdx, powerUpID, player.Score, g.Players[g.opponentOf(playerIdx)].Score)
+	}
+	data, _ := json.Marshal(map[string]string{"type": "arcana_pity", "powerUpId": powerUpID})
+	g.sendToSeat(playerIdx, data)
+	g.grantPowerUp(playerIdx, powerUpID)
+}
+
+// arcanaPool returns the power-up IDs in play this match, sorted so the draw does not depend on map order.
+func (g *Game) arcanaPool() []string {
+	pool := make([]string, 0, len(g.PairIDToPowerUp))
+	for _, id := range g.PairIDToPowerUp {
+		pool = append(pool, id)
+	}
+	sort.Strings(pool)
+	return pool
+}
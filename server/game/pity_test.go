@@ -0,0 +1,91 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type pityRecorder struct {
+	grants []string
+}
+
+func (r *pityRecorder) RecordTurn(string, int, int, int, int, int, int, int, TurnLatency) {}
+func (r *pityRecorder) RecordArcanaUse(string, int, int, string, int, int, int, int)      {}
+func (r *pityRecorder) RecordHandOverflow(string, int, int, string, string, string)       {}
+func (r *pityRecorder) RecordPityGrant(_ string, _, _ int, powerUpID string, _, _ int) {
+	r.grants = append(r.grants, powerUpID)
+}
+func (r *pityRecorder) RecordDraftPick(string, int, string, []string, bool) {}
+
+func TestAwardMatchArcana_PityAfterNormalPairs(t *testing.T) {
+	cfg := testConfig()
+	cfg.ArcanaPityMatches = 2
+	g, send0, _, _ := createTestGame(cfg)
+	g.PairIDToPowerUp = map[int]string{0: "chaos"}
+	sink := &pityRecorder{}
+	g.TelemetrySink = sink
+	p := g.Players[0]
+
+	g.awardMatchArcana(0, 3)
+	g.awardMatchArcana(0, 4)
+	if handSize(p) != 0 {
+		t.Fatalf("expected no arcana before the pity threshold, got %v", p.Hand)
+	}
+	g.awardMatchArcana(0, 5)
+	if p.Hand["chaos"] != 1 || p.MatchesSinceArcana != 0 {
+		t.Fatalf("expected a pity chaos and a reset counter, got %v and %d", p.Hand, p.MatchesSinceArcana)
+	}
+	if len(sink.grants) != 1 || !hasMessageType(drainChannel(send0), "arcana_pity") {
+		t.Errorf("expected one recorded pity grant and an arcana_pity message, got %v", sink.grants)
+	}
+
+	// Obtaining an arcana normally restarts the count.
+	g.awardMatchArcana(0, 3)
+	g.awardMatchArcana(0, 0)
+	g.awardMatchArcana(0, 4)
+	g.awardMatchArcana(0, 5)
+	if len(sink.grants) != 1 || p.Hand["chaos"] != 2 {
+		t.Errorf("expected the arcana pair to reset the pity timer, got grants %v hand %v", sink.grants, p.Hand)
+	}
+}
+
+func TestAwardMatchArcana_PityDisabled(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	g.PairIDToPowerUp = map[int]string{0: "chaos"}
+	for range 10 {
+		g.awardMatchArcana(0, 3)
+	}
+	if handSize(g.Players[0]) != 0 {
+		t.Errorf("expected no pity grants when disabled, got %v", g.Players[0].Hand)
+	}
+}
+
+func TestAwardMatchArcana_NotifiesOpponentOfArcanaPairsOnly(t *testing.T) {
+	cfg := testConfig()
+	cfg.ArcanaPityMatches = 1
+	g, send0, send1, _ := createTestGame(cfg)
+	g.PairIDToPowerUp = map[int]string{2: "chaos"}
+
+	g.awardMatchArcana(0, 2)
+	var gained map[string]any
+	for _, msg := range drainChannel(send1) {
+		var m map[string]any
+		json.Unmarshal(msg, &m)
+		if m["type"] == "opponent_gained_arcana" {
+			gained = m
+		}
+	}
+	if gained == nil || gained["pairId"] != float64(2) || gained["powerUpId"] != "chaos" {
+		t.Fatalf("expected opponent_gained_arcana for pair 2, got %v", gained)
+	}
+	if hasMessageType(drainChannel(send0), "opponent_gained_arcana") {
+		t.Error("expected no opponent_gained_arcana for the player who matched")
+	}
+
+	// Pity copies stay hidden from the opponent.
+	g.awardMatchArcana(0, 5)
+	g.awardMatchArcana(0, 6)
+	if hasMessageType(drainChannel(send1), "opponent_gained_arcana") {
+		t.Error("expected no opponent_gained_arcana for a pity grant")
+	}
+}
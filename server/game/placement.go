@@ -0,0 +1,116 @@
+package game
+
+import "memory-game-server/config"
+
+// placementAttempts is how many random greedy passes placeArcana makes before settling for the best one.
+const placementAttempts = 50
+
+// placeArcana moves the arcana cards of a freshly shuffled board so they follow the placement rules.
+// Positions are picked greedily in an order drawn from shuffle (retried up to placementAttempts times),
+// so a seeded shuffle reproduces the layout. When no pass fits every card, the cards left over take
+// free positions regardless of the rules (best effort).
+// Normal cards keep their shuffled order in the remaining positions. Indices are not reassigned.
+func placeArcana(cards []Card, rows, cols, arcanaPairs int, rules config.ArcanaPlacementConfig, shuffle func(n int, swap func(i, j int))) {
+	if !rules.NoAdjacent && !rules.SpreadQuadrants {
+		return
+	}
+	var arcana, normal []Card
+	for _, c := range cards {
+		if c.PairID < arcanaPairs {
+			arcana = append(arcana, c)
+		} else {
+			normal = append(normal, c)
+		}
+	}
+	if len(arcana) == 0 {
+		return
+	}
+
+	var taken []bool
+	var chosen, order []int
+	for range placementAttempts {
+		t, c, o := pickArcanaPositions(len(arcana), rows, cols, rules, shuffle)
+		if taken == nil || len(c) > len(chosen) {
+			taken, chosen, order = t, c, o
+		}
+		if len(chosen) == len(arcana) {
+			break
+		}
+	}
+	for _, pos := range order {
+		if len(chosen) == len(arcana) {
+			break
+		}
+		if !taken[pos] {
+			taken[pos] = true
+			chosen = append(chosen, pos)
+		}
+	}
+
+	for i, pos := range chosen {
+		cards[pos] = arcana[i]
+	}
+	n := 0
+	for pos := range cards {
+		if !taken[pos] {
+			cards[pos] = normal[n]
+			n++
+		}
+	}
+}
+
+// pickArcanaPositions goes through the board positions in a shuffled order and picks each one that
+// keeps the rules, up to n. It returns the picked positions (also marked in taken) and the order.
+func pickArcanaPositions(n, rows, cols int, rules config.ArcanaPlacementConfig, shuffle func(n int, swap func(i, j int))) (taken []bool, chosen, order []int) {
+	order = make([]int, rows*cols)
+	for i := range order {
+		order[i] = i
+	}
+	shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	quadrantCap := (n + 3) / 4
+	var perQuadrant [4]int
+	taken = make([]bool, len(order))
+	chosen = make([]int, 0, n)
+	for _, pos := range order {
+		if len(chosen) == n {
+			break
+		}
+		if rules.NoAdjacent && hasTakenNeighbor(taken, rows, cols, pos) {
+			continue
+		}
+		q := boardQuadrant(rows, cols, pos)
+		if rules.SpreadQuadrants && perQuadrant[q] >= quadrantCap {
+			continue
+		}
+		taken[pos] = true
+		perQuadrant[q]++
+		chosen = append(chosen, pos)
+	}
+	return taken, chosen, order
+}
+
+// hasTakenNeighbor reports whether a card orthogonally adjacent to pos is taken.
+func hasTakenNeighbor(taken []bool, rows, cols, pos int) bool {
+	r, c := pos/cols, pos%cols
+	return (r > 0 && taken[pos-cols]) ||
+		(r < rows-1 && taken[pos+cols]) ||
+		(c > 0 && taken[pos-1]) ||
+		(c < cols-1 && taken[pos+1])
+}
+
+// boardQuadrant returns the quadrant (0 top-left, 1 top-right, 2 bottom-left, 3 bottom-right) of pos.
+// With an odd row or column count the middle line belongs to the bottom or right half.
+func boardQuadrant(rows, cols, pos int) int {
+	r, c := pos/cols, pos%cols
+	q := 0
+	if r >= rows/2 {
+		q += 2
+	}
+	if c >= cols/2 {
+		q++
+	}
+	return q
+}
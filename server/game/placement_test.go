@@ -0,0 +1,127 @@
+package game
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"memory-game-server/config"
+)
+
+const placementTrials = 4000
+
+// arcanaStats counts, over many 6x6 boards dealt with rules, how often each cell holds an arcana card,
+// and how many boards had adjacent arcana or more than 3 arcana cards in one quadrant.
+func arcanaStats(t *testing.T, rules config.ArcanaPlacementConfig) (cellFreq []float64, adjacentBoards, crowdedBoards int) {
+	t.Helper()
+	rows, cols := 6, 6
+	counts := make([]int, rows*cols)
+	for range placementTrials {
+		b := NewPlacedBoard(rows, cols, ArcanaPairsPerMatch, rules)
+		checkBoardIntegrity(t, b)
+		taken := make([]bool, len(b.Cards))
+		var perQuadrant [4]int
+		adjacent := false
+		for _, c := range b.Cards {
+			if c.PairID < ArcanaPairsPerMatch {
+				taken[c.Index] = true
+				perQuadrant[boardQuadrant(rows, cols, c.Index)]++
+				counts[c.Index]++
+			}
+		}
+		for pos, ok := range taken {
+			if ok && hasTakenNeighbor(taken, rows, cols, pos) {
+				adjacent = true
+			}
+		}
+		if adjacent {
+			adjacentBoards++
+		}
+		for _, n := range perQuadrant {
+			if n > 3 {
+				crowdedBoards++
+				break
+			}
+		}
+	}
+	cellFreq = make([]float64, len(counts))
+	for i, n := range counts {
+		cellFreq[i] = float64(n) / placementTrials
+	}
+	return cellFreq, adjacentBoards, crowdedBoards
+}
+
+// checkBoardIntegrity fails when placement broke the board: every pair must still have two cards,
+// indices must match positions and normal cards must keep their element.
+func checkBoardIntegrity(t *testing.T, b *Board) {
+	t.Helper()
+	pairs := make(map[int]int)
+	for i, c := range b.Cards {
+		if c.Index != i {
+			t.Fatalf("card at %d has index %d", i, c.Index)
+		}
+		if c.Element != ElementForNormalPair(c.PairID, b.ArcanaPairs) {
+			t.Fatalf("card at %d (pair %d) has element %q", i, c.PairID, c.Element)
+		}
+		pairs[c.PairID]++
+	}
+	for id, n := range pairs {
+		if n != 2 {
+			t.Fatalf("pair %d has %d cards", id, n)
+		}
+	}
+}
+
+func TestNewPlacedBoard_WithoutRulesDealsDegenerateBoards(t *testing.T) {
+	_, adjacent, crowded := arcanaStats(t, config.ArcanaPlacementConfig{})
+	// 12 arcana cards on 36 cells: adjacent arcana are almost certain and a crowded quadrant is common,
+	// which is what the rules exist to prevent.
+	if adjacent < placementTrials*9/10 {
+		t.Errorf("expected most unconstrained boards to have adjacent arcana, got %d/%d", adjacent, placementTrials)
+	}
+	if crowded < placementTrials/4 {
+		t.Errorf("expected many unconstrained boards to crowd a quadrant, got %d/%d", crowded, placementTrials)
+	}
+}
+
+func TestNewPlacedBoard_NoAdjacent(t *testing.T) {
+	freq, adjacent, _ := arcanaStats(t, config.ArcanaPlacementConfig{NoAdjacent: true})
+	if adjacent != 0 {
+		t.Errorf("expected no board with adjacent arcana, got %d/%d", adjacent, placementTrials)
+	}
+	// Every cell must stay reachable: the rule spreads arcana, it must not pin them to a fixed pattern.
+	for i, f := range freq {
+		if f < 0.1 || f > 0.7 {
+			t.Errorf("cell %d holds an arcana in %.2f of boards, want between 0.1 and 0.7", i, f)
+		}
+	}
+}
+
+func TestNewPlacedBoard_SpreadQuadrants(t *testing.T) {
+	freq, _, crowded := arcanaStats(t, config.ArcanaPlacementConfig{SpreadQuadrants: true})
+	if crowded != 0 {
+		t.Errorf("expected no board with a crowded quadrant, got %d/%d", crowded, placementTrials)
+	}
+	// 3 arcana cards in each 9-cell quadrant: every cell should hold one in about a third of the boards.
+	for i, f := range freq {
+		if f < 0.28 || f > 0.39 {
+			t.Errorf("cell %d holds an arcana in %.2f of boards, want about 0.33", i, f)
+		}
+	}
+}
+
+func TestNewPlacedBoard_BothRules(t *testing.T) {
+	_, adjacent, crowded := arcanaStats(t, config.ArcanaPlacementConfig{NoAdjacent: true, SpreadQuadrants: true})
+	if adjacent != 0 || crowded != 0 {
+		t.Errorf("expected every board to follow both rules, got %d adjacent and %d crowded of %d", adjacent, crowded, placementTrials)
+	}
+}
+
+func TestNewBoard_PlacementIsReproducibleFromSeed(t *testing.T) {
+	rules := config.ArcanaPlacementConfig{NoAdjacent: true, SpreadQuadrants: true}
+	a := newBoard(6, 6, ArcanaPairsPerMatch, rules, rand.New(rand.NewSource(7)).Shuffle)
+	b := newBoard(6, 6, ArcanaPairsPerMatch, rules, rand.New(rand.NewSource(7)).Shuffle)
+	if !reflect.DeepEqual(a.Cards, b.Cards) {
+		t.Error("expected the same seed to place arcana the same way")
+	}
+}
@@ -4,7 +4,7 @@ package game
 type Player struct {
 	Name  string
 	Score int
-	Send  chan []byte // reference to the client's send channel
+	Send  chan []byte `json:"-"` // reference to the client's send channel; not part of a GameSnapshot
 
 	// Hand is the player's power-up hand: powerUpId -> count. Use is free; cards are gained by matching pairs.
 	Hand map[string]int
@@ -12,12 +12,21 @@ type Player struct {
 	// HandCooldown is the number of copies per powerUpId that were earned this turn and cannot be used until the player's next turn.
 	HandCooldown map[string]int
 
+	// HandOrder lists the held copies by power-up ID, oldest first (for the discard_oldest overflow rule).
+	HandOrder []string
+
+	// MatchesSinceArcana counts normal pairs matched since the player last obtained an arcana (pity timer).
+	MatchesSinceArcana int
+
 	// HighlightIndices are card indices to highlight (Unveiling: never-revealed hidden; Elementals: tiles of chosen element). Cleared when turn ends or Chaos is used.
 	HighlightIndices []int
 
 	// LeechActive is true after the player uses Leech; points from matching this turn are subtracted from the opponent. Cleared when turn ends.
 	LeechActive bool
 
+	// ThirdEyeActive is true after the player uses Third Eye; each attempt this turn may flip a third card. Cleared when turn ends.
+	ThirdEyeActive bool
+
 	// BloodPactActive is true after the player uses Blood Pact; they must match 3 pairs in a row for +5, or lose 3 on first mismatch.
 	BloodPactActive bool
 	// BloodPactMatchesCount is the number of consecutive matches since activating Blood Pact.
@@ -0,0 +1,34 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+
+	"memory-game-server/config"
+)
+
+// PollMsg asks a player a one-tap question of an active experiment after game_over. The answer comes back
+// as poll_answer and is stored with the match.
+type PollMsg struct {
+	Type     string   `json:"type"`
+	PollID   string   `json:"pollId"`
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+}
+
+// sendPolls sends every active experiment poll to the human seats. Bots are not asked.
+func (g *Game) sendPolls() {
+	polls := g.Config.ActivePolls()
+	if len(polls) == 0 {
+		return
+	}
+	for seat := range g.Players {
+		if strings.HasPrefix(g.PlayerUserIDs[seat], config.AIUserIDPrefix) {
+			continue
+		}
+		for _, p := range polls {
+			data, _ := json.Marshal(PollMsg{Type: "poll", PollID: p.ID, Question: p.Question, Options: p.PollOptions()})
+			g.sendToSeat(seat, data)
+		}
+	}
+}
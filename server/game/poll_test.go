@@ -0,0 +1,37 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"memory-game-server/config"
+)
+
+func TestGameOver_SendsActivePollsToHumans(t *testing.T) {
+	cfg := testConfig()
+	cfg.Experiments = []config.ExperimentConfig{
+		{ID: "fun", Active: true, Question: "Was this match fun?"},
+		{ID: "fair", Active: true, Question: "Did the arcana feel fair?", Options: []string{"fair", "unfair"}},
+		{ID: "old", Question: "Retired question"},
+	}
+	g, send0, send1, _ := createTestGame(cfg)
+	g.PlayerUserIDs = [MaxSeats]string{"user-a", config.AIUserIDPrefix + "Thalia"}
+	g.sendGameOver(0, "completed")
+
+	var polls []PollMsg
+	for _, data := range drainChannel(send0) {
+		var p PollMsg
+		if json.Unmarshal(data, &p) == nil && p.Type == "poll" {
+			polls = append(polls, p)
+		}
+	}
+	if len(polls) != 2 || polls[0].PollID != "fun" || polls[1].PollID != "fair" {
+		t.Fatalf("expected the two active polls in order, got %+v", polls)
+	}
+	if len(polls[0].Options) != 2 || polls[1].Options[1] != "unfair" {
+		t.Errorf("expected default and configured options, got %v and %v", polls[0].Options, polls[1].Options)
+	}
+	if hasMessageType(drainChannel(send1), "poll") {
+		t.Error("expected the bot not to be polled")
+	}
+}
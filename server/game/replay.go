@@ -0,0 +1,105 @@
+package game
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Replay event types.
+const (
+	ReplayStart    = "start"    // game set up: first layout, arcana pairs and the seat that opens
+	ReplayFlip     = "flip"     // Seat flipped Index
+	ReplayMatch    = "match"    // Seat's flipped cards matched
+	ReplayMismatch = "mismatch" // Seat's mismatched cards went face down again
+	ReplayTimeout  = "timeout"  // Seat ran out of time; its flipped cards went face down
+	ReplayArcana   = "arcana"   // Seat used PowerUpID on Index (-1 when untargeted)
+	ReplayPass     = "pass"     // Seat passed the turn with Silence
+	ReplayHide     = "hide"     // cards revealed by Clairvoyance went face down again
+	ReplayEnd      = "end"      // game over: Seat won (-1 for a draw), for Reason
+	ReplayTurn     = "turn"     // Seat's turn ended; Turn summarizes it
+)
+
+// ReplaySink records every state transition of a match so it can be played back move by move. Optional;
+// may be nil. Called from the game loop, so it must not block.
+type ReplaySink interface {
+	RecordReplayEvent(matchID string, ev ReplayEvent)
+}
+
+// ReplayEvent is one state transition of a match, with the state right after it: whose turn it is, the
+// scores and every card's state. Layout is set on the first event and whenever card positions changed
+// since the previous one (Chaos), so a player needs no game logic to draw the board at any event.
+type ReplayEvent struct {
+	Seq         int    `json:"seq"`
+	Type        string `json:"type"`
+	ElapsedMS   int64  `json:"elapsedMs"` // since the first event
+	Round       int    `json:"round"`
+	Seat        int    `json:"seat"`  // seat that acted; -1 for none
+	Index       int    `json:"index"` // card flipped or targeted; -1 for none
+	PowerUpID   string `json:"powerUpId,omitempty"`
+	Reason      string `json:"reason,omitempty"` // end reason, on end
+	CurrentTurn int    `json:"currentTurn"`
+	Scores      []int  `json:"scores"`
+	// Cards has one letter per card, by index: h hidden, r revealed, m matched, x removed.
+	Cards  string `json:"cards"`
+	Layout []int  `json:"layout,omitempty"` // pairId of each card, by index
+	// Arcana maps the pairIds of arcana cards to their power-up ID; on the start event only.
+	Arcana map[int]string `json:"arcana,omitempty"`
+	// Turn is the summary of the turn that ended; on turn events only.
+	Turn *TurnSummary `json:"turn,omitempty"`
+}
+
+// recordReplay reports a state transition to ReplaySink, taking the state from the game as it is now.
+// Flips, matches and arcana uses also go to the turn's summary.
+func (g *Game) recordReplay(typ string, seat, index int, powerUpID, reason string) {
+	g.noteTurnMove(typ, index, powerUpID)
+	g.recordReplayEvent(ReplayEvent{Type: typ, Seat: seat, Index: index, PowerUpID: powerUpID, Reason: reason})
+}
+
+// recordReplayEvent completes ev (type, seat, index and the like already set) with the sequence number
+// and the game state as it is now, and reports it to ReplaySink.
+func (g *Game) recordReplayEvent(ev ReplayEvent) {
+	if g.ReplaySink == nil {
+		return
+	}
+	ev.Seq = g.replaySeq
+	ev.Round = g.Round
+	ev.CurrentTurn = g.CurrentTurn
+	ev.Scores = make([]int, len(g.Players))
+	for i, p := range g.Players {
+		ev.Scores[i] = p.Score
+	}
+	var cards strings.Builder
+	layout := make([]int, len(g.Board.Cards))
+	for i, c := range g.Board.Cards {
+		cards.WriteByte(replayCardState(c.State))
+		layout[i] = c.PairID
+	}
+	ev.Cards = cards.String()
+	if g.replaySeq == 0 {
+		g.replayStart = time.Now()
+		ev.Arcana = maps.Clone(g.PairIDToPowerUp)
+	}
+	ev.ElapsedMS = time.Since(g.replayStart).Milliseconds()
+	if !slices.Equal(layout, g.replayLayout) {
+		ev.Layout = layout
+		g.replayLayout = layout
+	}
+	g.replaySeq++
+	g.ReplaySink.RecordReplayEvent(g.ID, ev)
+}
+
+// replayCardState returns the letter of a card state in ReplayEvent.Cards.
+func replayCardState(s CardState) byte {
+	switch s {
+	case Revealed:
+		return 'r'
+	case Matched:
+		return 'm'
+	case Removed:
+		return 'x'
+	default:
+		return 'h'
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// replayRecorder keeps the RecordReplayEvent calls.
+type replayRecorder struct {
+	events []ReplayEvent
+}
+
+func (r *replayRecorder) RecordReplayEvent(_ string, ev ReplayEvent) {
+	r.events = append(r.events, ev)
+}
+
+func (r *replayRecorder) types() []string {
+	out := make([]string, len(r.events))
+	for i, ev := range r.events {
+		out[i] = ev.Type
+	}
+	return out
+}
+
+func TestReplay_RecordsMovesInOrder(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	sink := &replayRecorder{}
+	g.ReplaySink = sink
+	g.CurrentTurn = 0
+	g.recordReplay(ReplayStart, -1, -1, "", "")
+
+	a, b := findPair(g.Board)
+	g.handleFlipCard(0, a)
+	g.handleFlipCard(0, b)
+	c, d := findNonPair(g.Board)
+	g.handleFlipCard(0, c)
+	g.handleFlipCard(0, d)
+	g.handleResolveMismatch(0)
+	g.handleResign(1)
+
+	want := []string{ReplayStart, ReplayFlip, ReplayFlip, ReplayMatch, ReplayFlip, ReplayFlip, ReplayTurn, ReplayMismatch, ReplayEnd}
+	if got := sink.types(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected events %v, got %v", want, got)
+	}
+	for i, ev := range sink.events {
+		if ev.Seq != i {
+			t.Errorf("event %d: expected seq %d, got %d", i, i, ev.Seq)
+		}
+	}
+	if start := sink.events[0]; len(start.Layout) != len(g.Board.Cards) || start.Arcana == nil {
+		t.Errorf("expected the start event to carry the layout and arcana, got %+v", start)
+	}
+	if flip := sink.events[1]; flip.Index != a || flip.Cards[a] != 'r' || flip.Layout != nil {
+		t.Errorf("expected a flip of card %d, revealed and without layout, got %+v", a, flip)
+	}
+	if match := sink.events[3]; match.Cards[a] != 'm' || match.Cards[b] != 'm' || match.Scores[0] != PointsPerMatch {
+		t.Errorf("expected both cards matched and the point scored, got %+v", match)
+	}
+	if turn := sink.events[6].Turn; turn == nil || turn.Seat != 0 || len(turn.Flips) != 4 || turn.Matches != 1 || turn.Ended != TurnEndMismatch {
+		t.Errorf("expected a summary of seat 0's turn ending in a mismatch, got %+v", turn)
+	}
+	if miss := sink.events[7]; miss.Cards[c] != 'h' || miss.CurrentTurn != 1 {
+		t.Errorf("expected the cards face down and seat 1 on move, got %+v", miss)
+	}
+	if end := sink.events[8]; end.Seat != 0 || end.Reason != "resigned" {
+		t.Errorf("expected seat 0 to win by resign, got %+v", end)
+	}
+}
+
+func TestReplay_LayoutAfterShuffle(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	sink := &replayRecorder{}
+	g.ReplaySink = sink
+	g.recordReplay(ReplayStart, -1, -1, "", "")
+
+	// Swap two cards of different pairs, as Chaos would.
+	a, b := findNonPair(g.Board)
+	cards := g.Board.Cards
+	cards[a].PairID, cards[b].PairID = cards[b].PairID, cards[a].PairID
+	g.recordReplay(ReplayArcana, 0, -1, "chaos", "")
+	if sink.events[1].Layout == nil {
+		t.Fatal("expected the layout after a shuffle")
+	}
+	g.recordReplay(ReplayHide, -1, -1, "", "")
+	if sink.events[2].Layout != nil {
+		t.Error("expected no layout when cards did not move")
+	}
+}
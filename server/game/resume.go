@@ -0,0 +1,148 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"memory-game-server/config"
+	"memory-game-server/modes"
+)
+
+// GameSnapshotVersion is bumped whenever GameSnapshot changes meaning; older snapshots are not resumed.
+const GameSnapshotVersion = 1
+
+// GameSnapshot is the state a game needs to resume in a new process after a crash or restart: the board,
+// both players (scores, hands, effects of the turn in progress), whose turn it is and the round. It is
+// only taken between moves (see checkpoint), so there is never a flip, reveal or timer to restore, and
+// only of 1v1 games: party games are not checkpointed.
+type GameSnapshot struct {
+	Version         int            `json:"version"`
+	ID              string         `json:"id"`
+	Seed            int64          `json:"seed"`
+	RematchOf       string         `json:"rematch_of,omitempty"`
+	Casual          bool           `json:"casual"` // Mode is casual; read back from checkpoints taken before Mode
+	Mode            string         `json:"mode,omitempty"`
+	Assist          [2]bool        `json:"assist"`
+	PlayerUserIDs   [2]string      `json:"player_user_ids"`
+	RejoinTokens    [2]string      `json:"rejoin_tokens"`
+	Board           *Board         `json:"board"`
+	PairIDToPowerUp map[int]string `json:"pair_id_to_power_up"`
+	Players         [2]*Player     `json:"players"`
+	CurrentTurn     int            `json:"current_turn"`
+	Round           int            `json:"round"`
+	MissStreak      int            `json:"miss_streak"`
+	KnownIndices    []int          `json:"known_indices"`
+}
+
+// Snapshot returns the game's resumable state. Must be called from the game loop.
+func (g *Game) Snapshot() GameSnapshot {
+	known := make([]int, 0, len(g.KnownIndices))
+	for idx := range g.KnownIndices {
+		known = append(known, idx)
+	}
+	sort.Ints(known)
+	return GameSnapshot{
+		Version:         GameSnapshotVersion,
+		ID:              g.ID,
+		Seed:            g.Seed,
+		RematchOf:       g.RematchOf,
+		Casual:          g.Mode == modes.Casual,
+		Mode:            g.Mode,
+		Assist:          [2]bool(g.Assist[:2]),
+		PlayerUserIDs:   [2]string(g.PlayerUserIDs[:2]),
+		RejoinTokens:    [2]string(g.RejoinTokens[:2]),
+		Board:           g.Board,
+		PairIDToPowerUp: g.PairIDToPowerUp,
+		Players:         [2]*Player(g.Players[:2]),
+		CurrentTurn:     g.CurrentTurn,
+		Round:           g.Round,
+		MissStreak:      g.missStreak,
+		KnownIndices:    known,
+	}
+}
+
+// RestoreGame rebuilds a game from a JSON GameSnapshot, with cfg's rules (board size from the snapshot)
+// and no connections: the caller attaches the seats' Send channels and OnGameEnd before Run.
+func RestoreGame(data []byte, cfg *config.Config, pups PowerUpProvider) (*Game, error) {
+	var s GameSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Version != GameSnapshotVersion {
+		return nil, fmt.Errorf("snapshot version %d, want %d", s.Version, GameSnapshotVersion)
+	}
+	if s.Board == nil || len(s.Board.Cards) != s.Board.Rows*s.Board.Cols || s.Players[0] == nil || s.Players[1] == nil {
+		return nil, errors.New("incomplete snapshot")
+	}
+	if s.Mode == "" && s.Casual {
+		s.Mode = modes.Casual // checkpointed before games recorded their mode
+	}
+	gameCfg := *cfg
+	gameCfg.BoardRows, gameCfg.BoardCols = s.Board.Rows, s.Board.Cols
+	known := make(map[int]struct{}, len(s.KnownIndices))
+	for _, idx := range s.KnownIndices {
+		known[idx] = struct{}{}
+	}
+	for _, p := range s.Players {
+		if p.Hand == nil {
+			p.Hand = make(map[string]int)
+		}
+		if p.HandCooldown == nil {
+			p.HandCooldown = make(map[string]int)
+		}
+	}
+	g := &Game{
+		ID:                    s.ID,
+		Seed:                  s.Seed,
+		RematchOf:             s.RematchOf,
+		Mode:                  s.Mode,
+		Board:                 s.Board,
+		PairIDToPowerUp:       s.PairIDToPowerUp,
+		Players:               s.Players[:],
+		CurrentTurn:           s.CurrentTurn,
+		Round:                 s.Round,
+		missStreak:            s.MissStreak,
+		KnownIndices:          known,
+		TurnPhase:             FirstFlip,
+		FlippedIndices:        make([]int, 0, 2),
+		Config:                &gameCfg,
+		PowerUps:              pups,
+		DisconnectedPlayerIdx: -1,
+		assistHintRound:       -1,
+		Actions:               make(chan Action, 16),
+		Done:                  make(chan struct{}),
+	}
+	copy(g.Assist[:], s.Assist[:])
+	copy(g.PlayerUserIDs[:], s.PlayerUserIDs[:])
+	copy(g.RejoinTokens[:], s.RejoinTokens[:])
+	if g.PairIDToPowerUp == nil {
+		g.PairIDToPowerUp = make(map[int]string)
+	}
+	g.seenByAll(known)
+	return g, nil
+}
+
+// checkpoint hands the game's snapshot to OnCheckpoint when the game is between moves (no card face up,
+// whether awaiting its pair, a mismatch reveal or Clairvoyance) and the shared state changed since the
+// last checkpoint.
+func (g *Game) checkpoint() {
+	if g.OnCheckpoint == nil || g.Finished || g.TurnPhase != FirstFlip || len(g.FlippedIndices) > 0 {
+		return
+	}
+	if slices.ContainsFunc(g.Board.Cards, func(c Card) bool { return c.State == Revealed }) {
+		return
+	}
+	sum := g.StateChecksum()
+	if sum == g.checkpointSum {
+		return
+	}
+	data, err := json.Marshal(g.Snapshot())
+	if err != nil {
+		return
+	}
+	g.checkpointSum = sum
+	g.OnCheckpoint(data)
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSnapshot_RestoreKeepsState(t *testing.T) {
+	g, _, _, pups := createTestGame(testConfig())
+	g.PlayerUserIDs = [MaxSeats]string{"u-alice", "u-bob"}
+	g.RejoinTokens = [MaxSeats]string{"tok-0", "tok-1"}
+	g.Board.Cards[0].State = Matched
+	g.Board.Cards[1].State = Matched
+	g.Players[0].Score = 1
+	g.Players[1].Hand["chaos"] = 1
+	g.CurrentTurn, g.Round = 1, 3
+	g.KnownIndices[4] = struct{}{}
+
+	data, err := json.Marshal(g.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := RestoreGame(data, testConfig(), pups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.StateChecksum() != g.StateChecksum() {
+		t.Error("expected the restored game to have the same state checksum")
+	}
+	if r.ID != g.ID || r.PlayerUserIDs != g.PlayerUserIDs || r.RejoinTokens != g.RejoinTokens {
+		t.Errorf("expected identity and rejoin tokens kept, got %q %v %v", r.ID, r.PlayerUserIDs, r.RejoinTokens)
+	}
+	if r.Players[0].Name != "Alice" || r.Players[1].Hand["chaos"] != 1 || r.Players[0].Send != nil {
+		t.Errorf("expected players restored without connections, got %+v %+v", r.Players[0], r.Players[1])
+	}
+	if _, ok := r.KnownIndices[4]; !ok || r.DisconnectedPlayerIdx != -1 || r.TurnPhase != FirstFlip {
+		t.Error("expected known cards kept and the game restored between moves")
+	}
+}
+
+func TestSnapshot_RejectsOtherVersion(t *testing.T) {
+	if _, err := RestoreGame([]byte(`{"version":99}`), testConfig(), newMockPowerUpProvider()); err == nil {
+		t.Error("expected a snapshot of another version to be rejected")
+	}
+}
+
+func TestCheckpoint_OnlyBetweenMovesWhenStateChanged(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	var got int
+	g.OnCheckpoint = func([]byte) { got++ }
+
+	g.checkpoint()
+	g.checkpoint()
+	if got != 1 {
+		t.Fatalf("expected one checkpoint for an unchanged state, got %d", got)
+	}
+	g.Board.Cards[0].State = Revealed
+	g.FlippedIndices = append(g.FlippedIndices, 0)
+	g.TurnPhase = SecondFlip
+	g.checkpoint()
+	if got != 1 {
+		t.Error("expected no checkpoint with a card face up")
+	}
+	g.Board.Cards[0].State = Matched
+	g.FlippedIndices = g.FlippedIndices[:0]
+	g.TurnPhase = FirstFlip
+	g.checkpoint()
+	if got != 2 {
+		t.Errorf("expected a checkpoint once the move is over, got %d", got)
+	}
+}
@@ -0,0 +1,47 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// handleSeatRestarted resends the seat's view after its AI was restarted, so the new AI can act even when
+// the old one died mid-turn. The reply carries every tile still in play that has been face up at some
+// point (index -> pairID), so the new AI does not start from a blank memory.
+func (g *Game) handleSeatRestarted(seat int, reply chan<- map[int]int) {
+	if seat < 0 || seat >= len(g.Players) {
+		return
+	}
+	known := make(map[int]int, len(g.KnownIndices))
+	for idx := range g.KnownIndices {
+		if idx < 0 || idx >= len(g.Board.Cards) {
+			continue
+		}
+		if c := g.Board.Cards[idx]; c.State != Matched && c.State != Removed {
+			known[idx] = c.PairID
+		}
+	}
+	if reply != nil {
+		select {
+		case reply <- known:
+		default:
+		}
+	}
+	data, err := json.Marshal(g.BuildStateForPlayer(seat))
+	if err != nil {
+		slog.Error("marshaling game state", "tag", "game", "err", err)
+		return
+	}
+	g.sendToSeat(seat, data)
+}
+
+// handleAIFailed ends the game when the AI of seat could not be kept running. The opponent is reported
+// as the winner with end reason "ai_failure"; such games are not rated.
+func (g *Game) handleAIFailed(seat int) {
+	if seat < 0 || seat >= len(g.Players) {
+		return
+	}
+	g.cancelTurnTimer()
+	g.sendGameOver(1-seat, "ai_failure")
+	g.Finished = true
+}
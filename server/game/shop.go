@@ -0,0 +1,40 @@
+package game
+
+import "encoding/json"
+
+// buildShop lists the power-ups the player can buy with score points (those with a price), with
+// whether they can afford each one now.
+func (g *Game) buildShop(playerIdx int) []PowerUpView {
+	var shop []PowerUpView
+	score := g.Players[playerIdx].Score
+	for _, def := range g.PowerUps.AllPowerUps() {
+		if def.Cost <= 0 {
+			continue
+		}
+		shop = append(shop, PowerUpView{
+			ID:          def.ID,
+			Name:        def.Name,
+			Description: def.Description,
+			Cost:        def.Cost,
+			CanAfford:   score >= def.Cost,
+		})
+	}
+	return shop
+}
+
+// sendPeekResult shows one hidden card to the seat that used Peek. The card stays hidden on the board,
+// so the opponent learns nothing beyond the announcement that Peek was used.
+func (g *Game) sendPeekResult(playerIdx, index int) {
+	card := g.Board.Cards[index]
+	msg := map[string]any{
+		"type":       "peek_result",
+		"index":      index,
+		"pairId":     card.PairID,
+		"durationMs": g.RevealDurationMS(),
+	}
+	if card.Element != "" {
+		msg["element"] = card.Element
+	}
+	data, _ := json.Marshal(msg)
+	g.sendToSeat(playerIdx, data)
+}
@@ -0,0 +1,61 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// EndReasonServerShutdown ends a game still running at the server shutdown deadline, as an unrated draw.
+const EndReasonServerShutdown = "server_shutdown"
+
+// ServerShutdownMsg warns a player that the server is restarting. A game in progress ends as a draw at
+// the deadline unless it finishes first; no new game can be started until the server is back.
+type ServerShutdownMsg struct {
+	Type           string `json:"type"` // "server_shutdown"
+	DeadlineUnixMs int64  `json:"deadlineUnixMs"`
+}
+
+// NewServerShutdownMsg returns the server_shutdown message for the given deadline.
+func NewServerShutdownMsg(deadline time.Time) ServerShutdownMsg {
+	return ServerShutdownMsg{Type: "server_shutdown", DeadlineUnixMs: deadline.UnixMilli()}
+}
+
+// handleServerShutdown warns every seat and arms the deadline. A second notice is ignored: the first
+// deadline stands.
+func (g *Game) handleServerShutdown(deadline time.Time) {
+	if !g.shutdownAt.IsZero() {
+		return
+	}
+	g.shutdownAt = deadline
+	data, err := json.Marshal(NewServerShutdownMsg(deadline))
+	if err != nil {
+		slog.Error("marshaling server_shutdown", "tag", "game", "err", err)
+		return
+	}
+	for i := range g.Players {
+		g.sendToSeat(i, data)
+	}
+	go func() {
+		select {
+		case <-time.After(time.Until(deadline)):
+			select {
+			case g.Actions <- Action{Type: ActionShutdownDeadline}:
+			case <-g.Done:
+			}
+		case <-g.Done:
+		}
+	}()
+}
+
+// handleShutdownDeadline ends the game as a draw: nobody lost it, so it is recorded but not rated.
+func (g *Game) handleShutdownDeadline() {
+	scores := make([]int, len(g.Players))
+	for i, p := range g.Players {
+		scores[i] = p.Score
+	}
+	slog.Info("game ended by server shutdown", "tag", "game", "game_id", g.ID, "round", g.Round, "scores", scores)
+	g.cancelTurnTimer()
+	g.sendGameOver(-1, EndReasonServerShutdown)
+	g.Finished = true
+}
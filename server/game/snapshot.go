@@ -0,0 +1,87 @@
+package game
+
+import (
+	"sort"
+
+	"memory-game-server/modes"
+)
+
+// ConfigSnapshotVersion is bumped whenever the meaning of a ConfigSnapshot field changes, so old rows
+// can still be told apart.
+const ConfigSnapshotVersion = 1
+
+// ConfigSnapshot is the effective rules a match was played with. It is stored with the match's game
+// history so telemetry can be segmented by rules even after the live config changes.
+type ConfigSnapshot struct {
+	Version   int `json:"version"`
+	BoardRows int `json:"board_rows"`
+	BoardCols int `json:"board_cols"`
+	// BoardSeed derived the opening layout and first turn; with ArcanaPool it replays the same board.
+	BoardSeed int64 `json:"board_seed"`
+	// TurnLimitSec is 0 when turns are not timed.
+	TurnLimitSec int `json:"turn_limit_sec"`
+	// Scoring is the scoring mode; every matched pair is worth PointsPerMatch.
+	Scoring        string `json:"scoring"`
+	PointsPerMatch int    `json:"points_per_match"`
+	// ArcanaPool lists the power-up IDs dealt as arcana pairs on this board, sorted.
+	ArcanaPool []string `json:"arcana_pool"`
+	// RevealDurationMS is the mismatch reveal used in this match (after latency adaptation).
+	RevealDurationMS int `json:"reveal_duration_ms"`
+	// ShopPrices maps each power-up for sale to its price in score points.
+	ShopPrices map[string]int `json:"shop_prices,omitempty"`
+
+	// Rules variants and feature flags.
+	EndOnInsurmountableLead bool   `json:"end_on_insurmountable_lead"`
+	MismatchRetries         int    `json:"mismatch_retries"`
+	MaxHandSize             int    `json:"max_hand_size"`
+	HandOverflowRule        string `json:"hand_overflow_rule,omitempty"`
+	ArcanaPityMatches       int    `json:"arcana_pity_matches"`
+	StartingDraft           bool   `json:"starting_draft"`
+	Assisted                bool   `json:"assisted"`
+	Raid                    bool   `json:"raid"`
+	Casual                  bool   `json:"casual"` // Mode is casual; kept for queries written before Mode
+	// Mode is the game mode ID (see package modes); empty for games from before modes were recorded.
+	Mode string `json:"mode,omitempty"`
+}
+
+// ConfigSnapshot returns the effective rules of this match.
+func (g *Game) ConfigSnapshot() ConfigSnapshot {
+	s := ConfigSnapshot{
+		Version:                 ConfigSnapshotVersion,
+		BoardRows:               g.Board.Rows,
+		BoardCols:               g.Board.Cols,
+		BoardSeed:               g.Seed,
+		TurnLimitSec:            max(g.Config.TurnLimitSec, 0),
+		Scoring:                 "fixed",
+		PointsPerMatch:          PointsPerMatch,
+		ArcanaPool:              make([]string, 0, len(g.PairIDToPowerUp)),
+		RevealDurationMS:        g.RevealDurationMS(),
+		EndOnInsurmountableLead: g.Config.EndOnInsurmountableLead,
+		MismatchRetries:         g.Config.MismatchRetries,
+		MaxHandSize:             g.Config.MaxHandSize,
+		ArcanaPityMatches:       g.Config.ArcanaPityMatches,
+		StartingDraft:           g.Draft,
+		Assisted:                g.Assist[0] || g.Assist[1],
+		Raid:                    g.Teams[0] != nil || g.Teams[1] != nil,
+		Casual:                  g.Mode == modes.Casual,
+		Mode:                    g.Mode,
+	}
+	for _, id := range g.PairIDToPowerUp {
+		s.ArcanaPool = append(s.ArcanaPool, id)
+	}
+	sort.Strings(s.ArcanaPool)
+	if s.MaxHandSize > 0 {
+		s.HandOverflowRule = g.handOverflowRule()
+	}
+	if g.PowerUps != nil {
+		for _, def := range g.PowerUps.AllPowerUps() {
+			if def.Cost > 0 {
+				if s.ShopPrices == nil {
+					s.ShopPrices = make(map[string]int)
+				}
+				s.ShopPrices[def.ID] = def.Cost
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigSnapshot(t *testing.T) {
+	cfg := testConfig()
+	cfg.TurnLimitSec = 45
+	cfg.MismatchRetries = 1
+	cfg.MaxHandSize = 3
+	g, _, _, pups := createTestGame(cfg)
+	pups.Register("peek", PowerUpDef{ID: "peek", Cost: 2})
+	pups.Register("chaos", PowerUpDef{ID: "chaos"})
+	g.PairIDToPowerUp = map[int]string{4: "leech", 1: "chaos"}
+
+	s := g.ConfigSnapshot()
+	if s.Version != ConfigSnapshotVersion || s.BoardRows != 4 || s.BoardCols != 4 || s.TurnLimitSec != 45 {
+		t.Errorf("unexpected board or turn settings: %+v", s)
+	}
+	if s.Scoring != "fixed" || s.PointsPerMatch != PointsPerMatch {
+		t.Errorf("unexpected scoring %q/%d", s.Scoring, s.PointsPerMatch)
+	}
+	if !reflect.DeepEqual(s.ArcanaPool, []string{"chaos", "leech"}) {
+		t.Errorf("expected sorted arcana pool [chaos leech], got %v", s.ArcanaPool)
+	}
+	if !reflect.DeepEqual(s.ShopPrices, map[string]int{"peek": 2}) {
+		t.Errorf("expected only priced power-ups in the shop, got %v", s.ShopPrices)
+	}
+	if s.MismatchRetries != 1 || s.MaxHandSize != 3 || s.HandOverflowRule != HandOverflowDiscardOldest || s.Raid || s.Assisted {
+		t.Errorf("unexpected rules variants: %+v", s)
+	}
+}
+
+func TestNewSeededGame_ReplaysOpening(t *testing.T) {
+	cfg := testConfig()
+	pool := []string{"leech", "chaos"}
+	a, err := NewSeededGame("a", cfg, NewPlayer("Alice", nil), NewPlayer("Bob", nil), newMockPowerUpProvider(), 42, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewSeededGame("b", cfg, NewPlayer("Alice", nil), NewPlayer("Bob", nil), newMockPowerUpProvider(), 42, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(a.Board.Cards, b.Board.Cards) || a.CurrentTurn != b.CurrentTurn {
+		t.Error("expected the same seed to deal the same board and first turn")
+	}
+	if !reflect.DeepEqual(a.PairIDToPowerUp, map[int]string{0: "chaos", 1: "leech"}) || !reflect.DeepEqual(a.PairIDToPowerUp, b.PairIDToPowerUp) {
+		t.Errorf("expected the pool assigned in ID order, got %v and %v", a.PairIDToPowerUp, b.PairIDToPowerUp)
+	}
+	if s := a.ConfigSnapshot(); s.BoardSeed != 42 {
+		t.Errorf("expected board_seed 42 in the snapshot, got %d", s.BoardSeed)
+	}
+}
@@ -13,9 +13,11 @@ type CardView struct {
 type PlayerView struct {
 	Name  string `json:"name"`
 	Score int    `json:"score"`
+	// CanWin is false once the player cannot finish ahead even taking every remaining point (public information only).
+	CanWin bool `json:"canWin"`
 }
 
-// PowerUpView is the client-facing representation of an available power-up (legacy; hand used instead).
+// PowerUpView is the client-facing representation of a power-up for sale (GameStateMsg.Shop).
 type PowerUpView struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
@@ -60,8 +62,33 @@ type GameStateMsg struct {
 	ClairvoyanceRevealedIndices []int `json:"clairvoyanceRevealedIndices,omitempty"`
 	// ClairvoyanceRevealEndsAtUnixMs is when the reveal ends (Unix ms). AI waits until then before flipping one of the 9.
 	ClairvoyanceRevealEndsAtUnixMs int64 `json:"clairvoyanceRevealEndsAtUnixMs,omitempty"`
+	// ClairvoyanceRevealDurationMS is the full length of the current Clairvoyance reveal (set while one is active).
+	ClairvoyanceRevealDurationMS int `json:"clairvoyanceRevealDurationMs,omitempty"`
+	// RevealDurationMS is how long a mismatched pair stays face up in this match (the resolve phase).
+	RevealDurationMS int `json:"revealDurationMs"`
 	// Round is the number of completed turns (incremented when a turn ends). Used by AI for recency-based forget.
 	Round int `json:"round,omitempty"`
+	// MaxRemainingPoints is the score still on the board: remaining pairs times the points per match.
+	MaxRemainingPoints int `json:"maxRemainingPoints"`
+	// MaxHandSize caps the arcana copies a player may hold (omitted when unlimited); HandOverflowRule is
+	// what happens to a pair matched with a full hand.
+	MaxHandSize      int    `json:"maxHandSize,omitempty"`
+	HandOverflowRule string `json:"handOverflowRule,omitempty"`
+	// MismatchRetries is the rules variant (extra tries after a mismatch before the turn passes; omitted when
+	// classic). RetriesLeft is how many the player on the move still has this turn.
+	MismatchRetries int `json:"mismatchRetries,omitempty"`
+	RetriesLeft     int `json:"retriesLeft,omitempty"`
+	// Shop lists power-ups the viewer can buy with score points when they hold no usable copy (omitted when none has a price).
+	Shop []PowerUpView `json:"shop,omitempty"`
+	// Team is the viewer's team roster in co-op raids; ActiveMember holds the move on the team's turn.
+	Team *TeamView `json:"team,omitempty"`
+	// Hotseat carries both seats' hands in pass-and-play games (see Game.Hotseat).
+	Hotseat *HotseatView `json:"hotseat,omitempty"`
+	// Party lists every seat of a party game (see Game.Party); You and Opponent show the viewer and the best-scoring rival.
+	Party *PartyView `json:"party,omitempty"`
+	// StateChecksum hashes the shared state (card states, scores, turn); clients echo it with their next
+	// flip or power-up so the server can detect a desynced view (see Game.StateChecksum).
+	StateChecksum string `json:"stateChecksum"`
 }
 
 // BuildCardViews constructs the client-facing card list. Server is source of truth: we send
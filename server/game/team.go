@@ -0,0 +1,133 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+
+	"memory-game-server/wsutil"
+)
+
+// TeamMember is one human sharing a team seat in a co-op raid.
+type TeamMember struct {
+	Name   string
+	UserID string
+	Send   chan []byte // cleared when the member disconnects or leaves
+}
+
+// Team is a group of humans sharing one seat (co-op raid). Members take the seat's turns in rotation;
+// score, hand and effects live on the seat's Player, so scoring is cooperative.
+type Team struct {
+	Members []*TeamMember
+	// Active is the index of the member who holds the move while it is the team's turn.
+	Active int
+}
+
+// TeamView is the client-facing roster of the viewer's team.
+type TeamView struct {
+	Members      []string `json:"members"`
+	ActiveMember int      `json:"activeMember"`
+}
+
+// NewTeam creates a team; the first member moves first.
+func NewTeam(members ...*TeamMember) *Team {
+	return &Team{Members: members}
+}
+
+// Names returns the members' names joined for display as the seat's name (e.g. "Alice & Bob").
+func (t *Team) Names() string {
+	names := make([]string, len(t.Members))
+	for i, m := range t.Members {
+		names[i] = m.Name
+	}
+	return strings.Join(names, " & ")
+}
+
+// connected returns how many members still have a connection.
+func (t *Team) connected() int {
+	n := 0
+	for _, m := range t.Members {
+		if m.Send != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// advance passes the move to the next connected member. No-op when nobody is connected.
+func (t *Team) advance() {
+	for range t.Members {
+		t.Active = (t.Active + 1) % len(t.Members)
+		if t.Members[t.Active].Send != nil {
+			return
+		}
+	}
+}
+
+func (t *Team) view() *TeamView {
+	names := make([]string, len(t.Members))
+	for i, m := range t.Members {
+		names[i] = m.Name
+	}
+	return &TeamView{Members: names, ActiveMember: t.Active}
+}
+
+// sendToSeat delivers data to whoever occupies the seat: the player's connection, or every connected
+// member when the seat is held by a team.
+func (g *Game) sendToSeat(seat int, data []byte) {
+	if g.Hotseat && seat != g.CurrentTurn {
+		return // one shared connection: only the copy for the seat on turn goes out
+	}
+	if t := g.Teams[seat]; t != nil {
+		for _, m := range t.Members {
+			if m.Send != nil {
+				wsutil.SafeSend(m.Send, data)
+			}
+		}
+		return
+	}
+	if p := g.Players[seat]; p != nil && p.Send != nil {
+		wsutil.SafeSend(p.Send, data)
+	}
+}
+
+// rotateTeam hands the seat's move to its next member; called when the turn passes to that seat.
+func (g *Game) rotateTeam(seat int) {
+	if t := g.Teams[seat]; t != nil {
+		t.advance()
+	}
+}
+
+// memberMayAct reports whether the action may proceed. For a team seat on its turn, only the active
+// member may flip or use arcana; anyone else gets an error. Always true for single-player seats.
+func (g *Game) memberMayAct(action Action) bool {
+	if action.PlayerIdx < 0 || action.PlayerIdx >= len(g.Players) {
+		return true
+	}
+	t := g.Teams[action.PlayerIdx]
+	if t == nil || action.PlayerIdx != g.CurrentTurn || action.MemberIdx == t.Active {
+		return true
+	}
+	g.sendErrorToSender(action, "It is your teammate's move.")
+	return false
+}
+
+// dropTeamMember removes a member's connection from a team seat. Raids have no rejoin: the member is out
+// for the rest of the match. Returns false when no member is left, in which case the seat forfeits.
+func (g *Game) dropTeamMember(seat, memberIdx int) bool {
+	t := g.Teams[seat]
+	if memberIdx < 0 || memberIdx >= len(t.Members) {
+		return t.connected() > 0
+	}
+	m := t.Members[memberIdx]
+	m.Send = nil
+	if t.connected() == 0 {
+		return false
+	}
+	if t.Active == memberIdx {
+		t.advance()
+	}
+	data, _ := json.Marshal(map[string]string{"type": "teammate_left", "name": m.Name})
+	g.sendToSeat(seat, data)
+	g.broadcastState()
+	return true
+}
@@ -0,0 +1,156 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// createTeamTestGame creates a game where seat 0 is a two-member team (co-op raid) and seat 1 a single player.
+// The team moves first. Returns the game and the send channels of member 0, member 1 and seat 1.
+func createTeamTestGame() (*Game, chan []byte, chan []byte, chan []byte) {
+	m0 := make(chan []byte, 100)
+	m1 := make(chan []byte, 100)
+	send1 := make(chan []byte, 100)
+	team := NewTeam(&TeamMember{Name: "Alice", Send: m0}, &TeamMember{Name: "Carol", Send: m1})
+	p0 := NewPlayer(team.Names(), nil)
+	p1 := NewPlayer("Bob", send1)
+	g, err := NewGame("raid-1", testConfig(), p0, p1, newMockPowerUpProvider())
+	if err != nil {
+		panic(err)
+	}
+	g.Teams[0] = team
+	g.CurrentTurn = 0
+	return g, m0, m1, send1
+}
+
+func hasMessageType(msgs [][]byte, msgType string) bool {
+	for _, msg := range msgs {
+		var m map[string]any
+		json.Unmarshal(msg, &m)
+		if m["type"] == msgType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTeam_NamesAndStateView(t *testing.T) {
+	g, m0, m1, _ := createTeamTestGame()
+	if got := g.Players[0].Name; got != "Alice & Carol" {
+		t.Errorf("expected team name %q, got %q", "Alice & Carol", got)
+	}
+	go g.Run()
+	defer func() { g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 1} }()
+	time.Sleep(50 * time.Millisecond)
+
+	// Both members receive the team seat's state, including the roster.
+	for i, ch := range []chan []byte{m0, m1} {
+		msgs := drainChannel(ch)
+		if len(msgs) == 0 {
+			t.Fatalf("member %d: expected game_state", i)
+		}
+		var state GameStateMsg
+		json.Unmarshal(msgs[0], &state)
+		if state.Team == nil || len(state.Team.Members) != 2 || state.Team.ActiveMember != 0 {
+			t.Errorf("member %d: expected team view with 2 members and active 0, got %+v", i, state.Team)
+		}
+	}
+	if g.BuildStateForPlayer(1).Team != nil {
+		t.Error("expected no team view for the single-player seat")
+	}
+}
+
+func TestTeam_OnlyActiveMemberMayFlip(t *testing.T) {
+	g, m0, m1, _ := createTeamTestGame()
+	go g.Run()
+	defer func() { g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 1} }()
+	time.Sleep(50 * time.Millisecond)
+	drainChannel(m0)
+	drainChannel(m1)
+
+	idx, _ := findNonPair(g.Board)
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, MemberIdx: 1, Index: idx}
+	time.Sleep(50 * time.Millisecond)
+
+	if g.Board.Cards[idx].State != Hidden {
+		t.Fatalf("expected card to stay hidden when the inactive member flips, got %v", g.Board.Cards[idx].State)
+	}
+	if !hasMessageType(drainChannel(m1), "error") {
+		t.Error("expected error for the member who is not on the move")
+	}
+	if len(drainChannel(m0)) != 0 {
+		t.Error("expected no message for the active member")
+	}
+
+	g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: 0, MemberIdx: 0, Index: idx}
+	time.Sleep(50 * time.Millisecond)
+	if g.Board.Cards[idx].State != Revealed {
+		t.Errorf("expected active member's flip to reveal the card, got %v", g.Board.Cards[idx].State)
+	}
+}
+
+func TestTeam_RotatesWhenTurnReturns(t *testing.T) {
+	g, _, _, _ := createTeamTestGame()
+	go g.Run()
+	defer func() { g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 1} }()
+	time.Sleep(50 * time.Millisecond)
+
+	mismatch := func(seat, member int) {
+		idx1, idx2 := findNonPair(g.Board)
+		g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: seat, MemberIdx: member, Index: idx1}
+		g.Actions <- Action{Type: ActionFlipCard, PlayerIdx: seat, MemberIdx: member, Index: idx2}
+		time.Sleep(time.Duration(g.Config.RevealDurationMS+100) * time.Millisecond)
+	}
+
+	mismatch(0, 0)
+	if g.CurrentTurn != 1 {
+		t.Fatalf("expected turn to pass to seat 1, got %d", g.CurrentTurn)
+	}
+	if g.Teams[0].Active != 0 {
+		t.Errorf("expected active member to stay 0 during the opponent's turn, got %d", g.Teams[0].Active)
+	}
+
+	mismatch(1, 0)
+	if g.CurrentTurn != 0 {
+		t.Fatalf("expected turn to return to the team, got %d", g.CurrentTurn)
+	}
+	if g.Teams[0].Active != 1 {
+		t.Errorf("expected member 1 to hold the move, got %d", g.Teams[0].Active)
+	}
+}
+
+func TestTeam_MemberLeavesThenTeamForfeits(t *testing.T) {
+	g, m0, m1, send1 := createTeamTestGame()
+	go g.Run()
+	time.Sleep(50 * time.Millisecond)
+	drainChannel(m0)
+	drainChannel(m1)
+	drainChannel(send1)
+
+	g.Actions <- Action{Type: ActionPlayerDisconnected, PlayerIdx: 0, MemberIdx: 0}
+	time.Sleep(50 * time.Millisecond)
+
+	if g.Finished {
+		t.Fatal("expected the raid to continue while a member is still connected")
+	}
+	if g.DisconnectedPlayerIdx != -1 {
+		t.Errorf("expected no reconnection pause for a team member, got %d", g.DisconnectedPlayerIdx)
+	}
+	if g.Teams[0].Active != 1 {
+		t.Errorf("expected the move to pass to the remaining member, got %d", g.Teams[0].Active)
+	}
+	if !hasMessageType(drainChannel(m1), "teammate_left") {
+		t.Error("expected teammate_left for the remaining member")
+	}
+
+	g.Actions <- Action{Type: ActionDisconnect, PlayerIdx: 0, MemberIdx: 1}
+	select {
+	case <-g.Done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the game to end when the last member leaves")
+	}
+	if !hasMessageType(drainChannel(send1), "opponent_disconnected") {
+		t.Error("expected opponent_disconnected for seat 1")
+	}
+}
@@ -0,0 +1,89 @@
+package game
+
+import "time"
+
+// FastFlips counts a seat's flips rejected in a match for arriving less than MinFlipSpacingMS after the
+// previous flip of the turn. Human hands rarely flip a second card that quickly; a script does.
+type FastFlips struct {
+	Count int
+	// MinGapMS is the shortest gap seen between a rejected flip and the flip before it.
+	MinGapMS int
+}
+
+// defaultClairvoyanceRevealMS applies when the Clairvoyance reveal duration is not configured.
+const defaultClairvoyanceRevealMS = 1000
+
+// ReportRTT records the latest (smoothed) round-trip time measured for the seat's connection. Safe to call
+// from any goroutine; the game reads it when scheduling a mismatch reveal.
+func (g *Game) ReportRTT(seat int, rtt time.Duration) {
+	if seat < 0 || seat >= len(g.Players) {
+		return
+	}
+	g.seatRTTMS[seat].Store(rtt.Milliseconds())
+}
+
+// SeatRTT returns the latest round-trip time reported for the seat (0 when unknown, e.g. the AI).
+func (g *Game) SeatRTT(seat int) time.Duration {
+	if seat < 0 || seat >= len(g.Players) {
+		return 0
+	}
+	return time.Duration(g.seatRTTMS[seat].Load()) * time.Millisecond
+}
+
+// RevealDurationMS is how long a mismatched pair stays face up in this match before it is hidden again.
+// With RevealDurationMaxMS set, the worst round-trip time of the seats is added to RevealDurationMS
+// and the result is clamped to [RevealDurationMinMS, RevealDurationMaxMS], so a high-latency player
+// still sees the pair for about as long as everyone else. Clients get the value in match_found and
+// game_state instead of hard-coding their own animation timing.
+func (g *Game) RevealDurationMS() int {
+	base := g.Config.RevealDurationMS
+	if g.Config.RevealDurationMaxMS <= 0 {
+		return base
+	}
+	var worst int
+	for seat := range g.Players {
+		worst = max(worst, int(g.seatRTTMS[seat].Load()))
+	}
+	return min(max(base+worst, g.Config.RevealDurationMinMS), g.Config.RevealDurationMaxMS)
+}
+
+// clairvoyanceRevealMS is how long Clairvoyance keeps its cards face up.
+func (g *Game) clairvoyanceRevealMS() int {
+	if ms := g.Config.PowerUps.Clairvoyance.RevealDurationMS; ms > 0 {
+		return ms
+	}
+	return defaultClairvoyanceRevealMS
+}
+
+// FastFlips returns the flips of seat rejected so far for coming too fast (see FastFlips). Call from the
+// game goroutine, e.g. in OnGameEnd.
+func (g *Game) FastFlips(seat int) FastFlips {
+	if seat < 0 || seat >= len(g.Players) {
+		return FastFlips{}
+	}
+	return g.fastFlips[seat]
+}
+
+// flipTooSoon rejects a second or third flip received less than MinFlipSpacingMS after the turn's previous
+// flip, telling the player and counting it for the seat. Flips without a receive time are not checked.
+func (g *Game) flipTooSoon(action Action) bool {
+	spacing := time.Duration(g.Config.MinFlipSpacingMS) * time.Millisecond
+	if spacing <= 0 || action.ReceivedAt.IsZero() || g.lastFlipAt.IsZero() || action.PlayerIdx != g.CurrentTurn {
+		return false
+	}
+	if g.TurnPhase != SecondFlip && g.TurnPhase != ThirdFlip {
+		return false
+	}
+	gap := action.ReceivedAt.Sub(g.lastFlipAt)
+	if gap >= spacing {
+		return false
+	}
+	ff := &g.fastFlips[action.PlayerIdx]
+	gapMS := int(max(gap, 0).Milliseconds())
+	if ff.Count == 0 || gapMS < ff.MinGapMS {
+		ff.MinGapMS = gapMS
+	}
+	ff.Count++
+	g.sendError(action.PlayerIdx, "Too fast, flip again.")
+	return true
+}
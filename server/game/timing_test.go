@@ -0,0 +1,43 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlipTooSoon_RejectsAndCountsFastSecondFlip(t *testing.T) {
+	cfg := testConfig()
+	cfg.MinFlipSpacingMS = 50
+	g, _, _, _ := createTestGame(cfg)
+	g.CurrentTurn = 0
+	a, b := findNonPair(g.Board)
+	g.handleFlipCard(0, a)
+	first := time.Now()
+	g.lastFlipAt = first
+
+	if !g.flipTooSoon(Action{Type: ActionFlipCard, PlayerIdx: 0, Index: b, ReceivedAt: first.Add(10 * time.Millisecond)}) {
+		t.Fatal("expected a flip 10ms after the first to be rejected")
+	}
+	if g.flipTooSoon(Action{Type: ActionFlipCard, PlayerIdx: 0, Index: b, ReceivedAt: first.Add(80 * time.Millisecond)}) {
+		t.Error("expected a flip 80ms after the first to be accepted")
+	}
+	if ff := g.FastFlips(0); ff.Count != 1 || ff.MinGapMS != 10 {
+		t.Errorf("expected one fast flip with a 10ms gap, got %+v", ff)
+	}
+}
+
+func TestFlipTooSoon_FirstFlipIsNotChecked(t *testing.T) {
+	cfg := testConfig()
+	cfg.MinFlipSpacingMS = 50
+	g, _, _, _ := createTestGame(cfg)
+	g.CurrentTurn = 0
+	g.lastFlipAt = time.Now()
+
+	// The previous turn's last flip says nothing about the first flip of this one.
+	if g.flipTooSoon(Action{Type: ActionFlipCard, PlayerIdx: 0, Index: 0, ReceivedAt: g.lastFlipAt}) {
+		t.Error("expected the first flip of a turn to be accepted")
+	}
+	if ff := g.FastFlips(0); ff.Count != 0 {
+		t.Errorf("expected no fast flip, got %+v", ff)
+	}
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// How a turn ended, in TurnSummary.Ended.
+const (
+	TurnEndMismatch = "mismatch"  // a mismatch with no retries left
+	TurnEndTimeout  = "timeout"   // the turn timer ran out
+	TurnEndPass     = "pass"      // passed with Silence
+	TurnEndLeft     = "left"      // the seat left a party game on its turn
+	TurnEndGameOver = "game_over" // the game ended during the turn
+)
+
+// TurnSummary is a compact account of one turn: who played, the cards flipped, the pairs matched, the
+// points gained and the arcana used. It drives move logs and replays without a full game_state.
+type TurnSummary struct {
+	Round  int    `json:"round"`
+	Seat   int    `json:"seat"`
+	Player string `json:"player"`
+	// Flips are the indices of the cards flipped, in order; Matches how many pairs they matched.
+	Flips   []int `json:"flips,omitempty"`
+	Matches int   `json:"matches"`
+	// Points is the seat's score change over the turn (negative after a penalty); Scores every seat's
+	// score after it.
+	Points int   `json:"points"`
+	Scores []int `json:"scores"`
+	// Arcana are the power-up IDs used, in order.
+	Arcana []string `json:"arcana,omitempty"`
+	Ended  string   `json:"ended"` // one of the TurnEnd* values
+}
+
+// TurnSummaryMsg is sent to every seat when a turn ends, before the game_state of the next one.
+type TurnSummaryMsg struct {
+	Type string `json:"type"` // "turn_summary"
+	TurnSummary
+}
+
+// noteTurnMove adds a replay transition of the turn in progress to its summary: a flip, a match or an
+// arcana use. Other transitions are not part of the summary.
+func (g *Game) noteTurnMove(typ string, index int, powerUpID string) {
+	switch typ {
+	case ReplayFlip:
+		g.turnFlips = append(g.turnFlips, index)
+	case ReplayMatch:
+		g.turnMatches++
+	case ReplayArcana:
+		g.turnArcana = append(g.turnArcana, powerUpID)
+	}
+}
+
+// recordTurn reports the turn that just ended, which ended as ended (a TurnEnd* value). TelemetrySink
+// gets the scores after it, how each changed since it started (a streak of matches counts as one turn),
+// the pairs matched and its action latency; ReplaySink and every seat get its TurnSummary. Called on every turn
+// transition, before Round and CurrentTurn advance, and by recordFinalTurn when the game ends mid-turn.
+func (g *Game) recordTurn(ended string) {
+	g.turnMoves = 0
+	summary := g.takeTurnSummary(ended)
+	g.recordReplayEvent(ReplayEvent{Type: ReplayTurn, Seat: summary.Seat, Index: -1, Turn: &summary})
+	g.broadcastTurnSummary(summary)
+	if g.TelemetrySink == nil {
+		return
+	}
+	pidx := g.CurrentTurn
+	scoreAfter := g.Players[pidx].Score
+	opp := g.opponentOf(pidx)
+	oppScoreAfter := g.Players[opp].Score
+	deltaPlayer := scoreAfter - g.TurnStartScores[pidx]
+	deltaOpponent := oppScoreAfter - g.TurnStartScores[opp]
+	g.TelemetrySink.RecordTurn(g.ID, g.Round, pidx, scoreAfter, oppScoreAfter, deltaPlayer, deltaOpponent, summary.Matches, g.takeTurnLatency(pidx))
+}
+
+// takeTurnSummary builds the summary of the turn of the seat on move and starts an empty log for the next.
+func (g *Game) takeTurnSummary(ended string) TurnSummary {
+	seat := g.CurrentTurn
+	s := TurnSummary{
+		Round:   g.Round,
+		Seat:    seat,
+		Player:  g.Players[seat].Name,
+		Flips:   g.turnFlips,
+		Matches: g.turnMatches,
+		Points:  g.Players[seat].Score - g.TurnStartScores[seat],
+		Scores:  make([]int, len(g.Players)),
+		Arcana:  g.turnArcana,
+		Ended:   ended,
+	}
+	for i, p := range g.Players {
+		s.Scores[i] = p.Score
+	}
+	g.turnFlips, g.turnMatches, g.turnArcana = nil, 0, nil
+	return s
+}
+
+// broadcastTurnSummary sends the turn_summary of a turn that ended to every seat.
+func (g *Game) broadcastTurnSummary(s TurnSummary) {
+	data, err := json.Marshal(TurnSummaryMsg{Type: "turn_summary", TurnSummary: s})
+	if err != nil {
+		slog.Error("marshaling turn summary", "tag", "game", "err", err)
+		return
+	}
+	for i := range g.Players {
+		g.sendToSeat(i, data)
+	}
+}
+
+// recordFinalTurn records the turn in progress when the game ends: the match that completes the board, a
+// lead becoming insurmountable, a resign or a disconnect. A turn that had not started yet (no move, no
+// score change) is not a turn played and is skipped.
+func (g *Game) recordFinalTurn() {
+	if g.turnMoves > 0 {
+		g.recordTurn(TurnEndGameOver)
+		return
+	}
+	for i, p := range g.Players {
+		if p.Score != g.TurnStartScores[i] {
+			g.recordTurn(TurnEndGameOver)
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type turnRecord struct {
+	round, playerIdx, deltaPlayer, deltaOpponent, pairsMatched int
+}
+
+// turnRecorder keeps the RecordTurn calls.
+type turnRecorder struct {
+	turns []turnRecord
+}
+
+func (r *turnRecorder) RecordTurn(_ string, round, playerIdx int, _, _ int, deltaPlayer, deltaOpponent, pairsMatched int, _ TurnLatency) {
+	r.turns = append(r.turns, turnRecord{round, playerIdx, deltaPlayer, deltaOpponent, pairsMatched})
+}
+func (r *turnRecorder) RecordArcanaUse(string, int, int, string, int, int, int, int) {}
+func (r *turnRecorder) RecordHandOverflow(string, int, int, string, string, string)  {}
+func (r *turnRecorder) RecordPityGrant(string, int, int, string, int, int)           {}
+func (r *turnRecorder) RecordDraftPick(string, int, string, []string, bool)          {}
+
+func TestRecordTurn_StreakEndingTheGameIsOneTurn(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	sink := &turnRecorder{}
+	g.TelemetrySink = sink
+	g.CurrentTurn = 0
+	g.TurnStartScores = [MaxSeats]int{}
+
+	for !g.Finished {
+		a, b := findPair(g.Board)
+		if a < 0 {
+			t.Fatal("board has no hidden pair left but the game is not over")
+		}
+		g.handleFlipCard(0, a)
+		g.handleFlipCard(0, b)
+	}
+	if len(sink.turns) != 1 {
+		t.Fatalf("expected the winning streak to be recorded as one turn, got %+v", sink.turns)
+	}
+	if got := sink.turns[0]; got.playerIdx != 0 || got.deltaPlayer != g.Players[0].Score || got.deltaOpponent != 0 {
+		t.Errorf("expected seat 0 gaining %d points, got %+v", g.Players[0].Score, got)
+	}
+	if got, pairs := sink.turns[0].pairsMatched, len(g.Board.Cards)/2; got != pairs {
+		t.Errorf("expected the turn to match all %d pairs, got %d", pairs, got)
+	}
+}
+
+func TestRecordTurn_GameEndingAtTurnStartAddsNoTurn(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	sink := &turnRecorder{}
+	g.TelemetrySink = sink
+	g.CurrentTurn = 0
+
+	a, b := findNonPair(g.Board)
+	g.handleFlipCard(0, a)
+	g.handleFlipCard(0, b)
+	g.handleResolveMismatch(0)
+	if len(sink.turns) != 1 || sink.turns[0].playerIdx != 0 {
+		t.Fatalf("expected the missed turn to be recorded, got %+v", sink.turns)
+	}
+
+	// Seat 1 resigns before moving: its turn never started.
+	g.handleResign(1)
+	if len(sink.turns) != 1 {
+		t.Fatalf("expected no record for the unplayed turn, got %+v", sink.turns)
+	}
+}
+
+// turnSummaries returns the turn_summary messages among msgs.
+func turnSummaries(t *testing.T, msgs [][]byte) []TurnSummaryMsg {
+	t.Helper()
+	var out []TurnSummaryMsg
+	for _, data := range msgs {
+		var msg TurnSummaryMsg
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("bad message %s: %v", data, err)
+		}
+		if msg.Type == "turn_summary" {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+func TestRecordTurn_SendsTurnSummaryToEverySeat(t *testing.T) {
+	g, send0, send1, _ := createTestGame(testConfig())
+	g.CurrentTurn = 0
+
+	a, b := findPair(g.Board)
+	g.handleFlipCard(0, a)
+	g.handleFlipCard(0, b)
+	c, d := findNonPair(g.Board)
+	g.handleFlipCard(0, c)
+	g.handleFlipCard(0, d)
+	if got := turnSummaries(t, drainChannel(send0)); len(got) != 0 {
+		t.Fatalf("expected no summary before the turn ends, got %+v", got)
+	}
+	g.handleResolveMismatch(0)
+
+	for seat, ch := range []chan []byte{send0, send1} {
+		got := turnSummaries(t, drainChannel(ch))
+		if len(got) != 1 {
+			t.Fatalf("seat %d: expected one turn_summary, got %+v", seat, got)
+		}
+		s := got[0]
+		if s.Seat != 0 || s.Player != "Alice" || len(s.Flips) != 4 || s.Flips[0] != a || s.Flips[3] != d {
+			t.Errorf("seat %d: expected Alice's four flips in order, got %+v", seat, s)
+		}
+		if s.Matches != 1 || s.Points != PointsPerMatch || s.Scores[0] != PointsPerMatch || s.Ended != TurnEndMismatch || len(s.Arcana) != 0 {
+			t.Errorf("seat %d: expected one match worth %d points, ending in a mismatch, got %+v", seat, PointsPerMatch, s)
+		}
+	}
+
+	// The next turn starts with an empty log.
+	e, f := findNonPair(g.Board)
+	g.handleFlipCard(1, e)
+	g.handleFlipCard(1, f)
+	g.handleResolveMismatch(1)
+	if got := turnSummaries(t, drainChannel(send1)); len(got) != 1 || got[0].Seat != 1 || len(got[0].Flips) != 2 || got[0].Matches != 0 {
+		t.Errorf("expected Bob's turn alone in the next summary, got %+v", got)
+	}
+}
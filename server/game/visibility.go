@@ -0,0 +1,111 @@
+package game
+
+// BlindPlay sums a seat's blind guesses in a match: flips onto a card the seat had never been shown while
+// the partner of a face-up card was also unseen, so only luck (or hidden knowledge) could find the pair.
+// Each guess has a chance k/U of hitting, where U is the number of hidden cards the seat had not seen and
+// k how many of them complete a face-up card. Hits far above ExpectedHits over many guesses point to a
+// client that sees the board.
+type BlindPlay struct {
+	Guesses      int
+	Hits         int
+	ExpectedHits float64
+	// HitVariance is the sum of p(1-p) over the guesses, for a z-score of Hits against ExpectedHits.
+	HitVariance float64
+}
+
+// BlindPlay returns the blind guesses of seat so far (see BlindPlay). Call from the game goroutine, e.g.
+// in OnGameEnd.
+func (g *Game) BlindPlay(seat int) BlindPlay {
+	if seat < 0 || seat >= len(g.Players) {
+		return BlindPlay{}
+	}
+	return g.blindPlay[seat]
+}
+
+// markSeen records that every seat was shown the face of card idx (a flip or a Clairvoyance reveal).
+func (g *Game) markSeen(idx int) {
+	for seat := range g.Players {
+		g.markSeenBy(seat, idx)
+	}
+}
+
+// markSeenBy records that seat was shown the face of card idx (e.g. Peek, for the buyer only).
+func (g *Game) markSeenBy(seat, idx int) {
+	if g.seenBy[seat] == nil {
+		g.seenBy[seat] = make(map[int]struct{})
+	}
+	g.seenBy[seat][idx] = struct{}{}
+}
+
+// seenByAll marks every index in known as seen by every seat: a restored game only has the shared
+// KnownIndices, and players remember what they saw before the restart.
+func (g *Game) seenByAll(known map[int]struct{}) {
+	for idx := range known {
+		g.markSeen(idx)
+	}
+}
+
+// forgetMoved drops what every seat saw of the cards whose pair changed since before (the pairIds by
+// index, taken before an arcana took effect): after Chaos or Necromancy an old sighting is no knowledge.
+func (g *Game) forgetMoved(before []int) {
+	for idx, c := range g.Board.Cards {
+		if idx < len(before) && c.PairID != before[idx] {
+			for seat := range g.Players {
+				delete(g.seenBy[seat], idx)
+			}
+		}
+	}
+}
+
+// noteBlindGuess counts the flip of cardIndex by seat as a blind guess when the card was unseen and some
+// face-up card's partner is among the seat's unseen hidden cards. Called before the card is flipped.
+// Turns with highlighted cards (Elementals, Unveiling) are skipped, since the highlight narrows the guess.
+func (g *Game) noteBlindGuess(seat, cardIndex int) {
+	if len(g.FlippedIndices) == 0 || len(g.Players[seat].HighlightIndices) > 0 {
+		return
+	}
+	if _, seen := g.seenBy[seat][cardIndex]; seen {
+		return
+	}
+	completes := func(idx int) bool {
+		for _, fi := range g.FlippedIndices {
+			if fi != idx && g.Board.Cards[fi].PairID == g.Board.Cards[idx].PairID {
+				return true
+			}
+		}
+		return false
+	}
+	unseen, partners := 0, 0
+	for idx, c := range g.Board.Cards {
+		if c.State != Hidden {
+			continue
+		}
+		if _, seen := g.seenBy[seat][idx]; seen {
+			continue
+		}
+		unseen++
+		if completes(idx) {
+			partners++
+		}
+	}
+	if partners == 0 || unseen == 0 {
+		return
+	}
+	p := float64(partners) / float64(unseen)
+	bp := &g.blindPlay[seat]
+	bp.Guesses++
+	bp.ExpectedHits += p
+	bp.HitVariance += p * (1 - p)
+	if completes(cardIndex) {
+		bp.Hits++
+	}
+}
+
+// pairIDs returns the pairId of every card, by index.
+func (g *Game) pairIDs() []int {
+	out := make([]int, len(g.Board.Cards))
+	for i, c := range g.Board.Cards {
+		out[i] = c.PairID
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// partnerOf returns the other card of idx's pair.
+func partnerOf(board *Board, idx int) int {
+	for i, c := range board.Cards {
+		if i != idx && c.PairID == board.Cards[idx].PairID {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestBlindPlay_UnseenPairIsALuckyGuess(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	g.CurrentTurn = 0
+	a, b := findPair(g.Board)
+	g.handleFlipCard(0, a)
+	g.handleFlipCard(0, b)
+
+	bp := g.BlindPlay(0)
+	want := 1 / float64(len(g.Board.Cards)-1)
+	if bp.Guesses != 1 || bp.Hits != 1 || math.Abs(bp.ExpectedHits-want) > 1e-9 {
+		t.Errorf("expected one lucky guess with chance %.4f, got %+v", want, bp)
+	}
+}
+
+func TestBlindPlay_SeenPartnerIsNoGuess(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	g.CurrentTurn = 0
+	x, y := findNonPair(g.Board)
+	g.handleFlipCard(0, x)
+	g.handleFlipCard(0, y)
+	g.handleResolveMismatch(0)
+
+	// Seat 1 saw x flipped: opening with its partner and then x is memory, not luck.
+	g.handleFlipCard(1, partnerOf(g.Board, x))
+	g.handleFlipCard(1, x)
+	if bp := g.BlindPlay(1); bp.Guesses != 0 || bp.Hits != 0 {
+		t.Errorf("expected no blind guess for a pair already seen, got %+v", bp)
+	}
+}
+
+func TestBlindPlay_MovedCardsAreForgotten(t *testing.T) {
+	g, _, _, _ := createTestGame(testConfig())
+	x, y := findNonPair(g.Board)
+	g.markSeen(x)
+	g.markSeenBy(1, y)
+
+	before := g.pairIDs()
+	g.Board.Cards[x].PairID, g.Board.Cards[y].PairID = g.Board.Cards[y].PairID, g.Board.Cards[x].PairID
+	g.forgetMoved(before)
+	for seat := range g.Players {
+		if _, seen := g.seenBy[seat][x]; seen {
+			t.Errorf("seat %d: expected card %d forgotten after it moved", seat, x)
+		}
+	}
+	if _, seen := g.seenBy[1][y]; seen {
+		t.Errorf("expected the peeked card %d forgotten after it moved", y)
+	}
+}
@@ -33,6 +33,11 @@ func setupTestServerWithConfig(t *testing.T, cfg *config.Config) (*httptest.Serv
 	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		hub.ServeWS(w, r)
 	})
+	bridge := ws.NewPollBridge(hub, time.Minute)
+	mux.HandleFunc("POST /api/kiosk/sessions", bridge.CreateSession)
+	mux.HandleFunc("POST /api/kiosk/sessions/{id}/join", bridge.Join)
+	mux.HandleFunc("POST /api/kiosk/sessions/{id}/flip", bridge.Flip)
+	mux.HandleFunc("GET /api/kiosk/sessions/{id}/events", bridge.Events)
 
 	server := httptest.NewServer(mux)
 	cleanup := func() {
@@ -422,3 +427,89 @@ func TestIntegration_SinglePlayerVsAI(t *testing.T) {
 		state = nextMsg
 	}
 }
+
+// kioskPost sends a JSON body to a kiosk bridge endpoint and returns the decoded response (if any).
+func kioskPost(t *testing.T, url string, body any) map[string]any {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := http.Post(url, "application/json", strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		t.Fatalf("POST %s: status %d", url, resp.StatusCode)
+	}
+	var out map[string]any
+	json.NewDecoder(resp.Body).Decode(&out)
+	return out
+}
+
+// kioskSession polls a kiosk bridge session, keeping events that were fetched but not consumed yet.
+type kioskSession struct {
+	url     string
+	pending []map[string]any
+}
+
+// pollUntil returns the next event of msgType, long-polling as needed; earlier events are skipped.
+func (k *kioskSession) pollUntil(t *testing.T, msgType string) map[string]any {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		for len(k.pending) > 0 {
+			ev := k.pending[0]
+			k.pending = k.pending[1:]
+			if ev["type"] == msgType {
+				return ev
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s event within 3s", msgType)
+		}
+		resp, err := http.Get(k.url + "/events?wait=1")
+		if err != nil {
+			t.Fatalf("poll: %v", err)
+		}
+		var body struct {
+			Events []map[string]any `json:"events"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		k.pending = append(k.pending, body.Events...)
+	}
+}
+
+func TestIntegration_KioskBridgeVsWebSocket(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	created := kioskPost(t, server.URL+"/api/kiosk/sessions", nil)
+	kiosk := &kioskSession{url: server.URL + "/api/kiosk/sessions/" + created["session_id"].(string)}
+	kioskPost(t, kiosk.url+"/join", map[string]string{"name": "Kiosk"})
+	kiosk.pollUntil(t, "waiting_for_match")
+
+	conn := connectWS(t, server)
+	defer conn.Close()
+	sendMsg(t, conn, map[string]string{"type": "set_name", "name": "Bob"})
+	readMsg(t, conn) // waiting_for_match
+	readMsg(t, conn) // match_found
+	readMsg(t, conn) // game_state
+
+	mf := kiosk.pollUntil(t, "match_found")
+	if mf["opponentName"] != "Bob" {
+		t.Errorf("expected kiosk opponent Bob, got %v", mf["opponentName"])
+	}
+	gs := kiosk.pollUntil(t, "game_state")
+
+	// Whoever moves first flips card 0; the kiosk sees the reveal either way.
+	if gs["yourTurn"].(bool) {
+		kioskPost(t, kiosk.url+"/flip", map[string]int{"index": 0})
+	} else {
+		sendMsg(t, conn, map[string]any{"type": "flip_card", "index": 0})
+	}
+	gs = kiosk.pollUntil(t, "game_state")
+	card := gs["cards"].([]any)[0].(map[string]any)
+	if card["state"] != "revealed" {
+		t.Errorf("expected card 0 revealed after the flip, got %v", card["state"])
+	}
+}
@@ -11,12 +11,15 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"memory-game-server/ai"
 	"memory-game-server/api"
+	"memory-game-server/balance"
 	"memory-game-server/config"
 	"memory-game-server/loghandler"
 	"memory-game-server/matchmaking"
 	"memory-game-server/powerup"
 	"memory-game-server/storage"
+	"memory-game-server/webclient"
 	"memory-game-server/ws"
 )
 
@@ -30,7 +33,16 @@ func main() {
 		}
 	}
 
-	cfg := config.Load()
+	// Subcommands (e.g. "config validate") run instead of the server.
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("cannot start", "tag", "server", "err", err)
+		os.Exit(1)
+	}
 	// Apply configured log level and set error_source=backend for all default logs.
 	baseLogger := slog.New(loghandler.NewCompactHandler(os.Stderr, cfg.SlogLevel()))
 	slog.SetDefault(baseLogger.With("error_source", "backend"))
@@ -58,20 +70,48 @@ func main() {
 		slog.Error("Failed to connect to database", "tag", "server", "err", err)
 		os.Exit(1)
 	}
-	if historyStore != nil {
-		defer historyStore.Close()
-	}
 
 	// Context for graceful shutdown: cancel signals hub and matchmaker to stop.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Balance alerts on per-card telemetry; the baseline is the window before this start (the previous config).
+	if historyStore != nil {
+		go balance.NewAnalyzer(historyStore, cfg.BalanceAlerts, time.Now()).Run(ctx)
+	}
+
+	// Leaderboard names follow Neon Auth renames: periodically, and for each user as they sign in.
+	if historyStore != nil {
+		go historyStore.RunDisplayNameSync(ctx, time.Duration(cfg.DisplayNameSyncSec)*time.Second)
+	}
+
+	// Rating seasons: the default realm and every configured realm reset on the same schedule.
+	if historyStore != nil {
+		seasonRealms := []string{""}
+		for name := range cfg.Realms {
+			seasonRealms = append(seasonRealms, name)
+		}
+		go historyStore.RunSeasons(ctx, seasonRealms, time.Duration(cfg.Seasons.LengthDays)*24*time.Hour, cfg.Seasons.CarryOverPct)
+	}
+	syncDisplayName := func(realm, userID, name string) {
+		updateCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := historyStore.UpdateDisplayName(updateCtx, realm, userID, name); err != nil {
+			slog.Error("UpdateDisplayName", "tag", "storage", "user_id", userID, "err", err)
+		}
+	}
+
+	// Every bot of every realm computes its moves on one bounded pool.
+	ai.SetWorkers(cfg.AIWorkers)
+
 	// Set up matchmaker
 	mm := matchmaking.NewMatchmaker(cfg, registry, historyStore)
-	go mm.Run(ctx)
 
 	// Set up WebSocket hub
 	hub := ws.NewHub(cfg, mm)
+	hub.OnAuthenticated = syncDisplayName
+	mm.Online = hub.Connections // adaptive AI fallback follows the players online
+	go mm.Run(ctx)
 	go hub.Run(ctx)
 
 	// HTTP handler for WebSocket upgrades
@@ -79,13 +119,99 @@ func main() {
 		hub.ServeWS(w, r)
 	})
 
+	// Realms: each configured community gets its own matchmaker (queues, active games) and hub,
+	// served under /realms/{realm}/.
+	statsSources := []api.StatsSource{{Hub: hub, Matchmaker: mm}}
+	matchmakers := []*matchmaking.Matchmaker{mm}
+	realmHubs := make(map[string]*ws.Hub)
+	for name := range cfg.Realms {
+		realmCfg, _ := cfg.ForRealm(name)
+		realmMM := matchmaking.NewRealmMatchmaker(name, realmCfg, registry, historyStore)
+		matchmakers = append(matchmakers, realmMM)
+		realmHub := ws.NewHub(realmCfg, realmMM)
+		realmHub.Realm = name
+		realmHub.OnAuthenticated = syncDisplayName
+		realmMM.Online = realmHub.Connections
+		go realmMM.Run(ctx)
+		go realmHub.Run(ctx)
+		realmHubs[name] = realmHub
+		statsSources = append(statsSources, api.StatsSource{Hub: realmHub, Matchmaker: realmMM})
+		slog.Info("Realm enabled", "tag", "server", "realm", name, "board_rows", realmCfg.BoardRows, "board_cols", realmCfg.BoardCols)
+	}
+	http.HandleFunc("/realms/{realm}/ws", func(w http.ResponseWriter, r *http.Request) {
+		realmHub, ok := realmHubs[r.PathValue("realm")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		realmHub.ServeWS(w, r)
+	})
+
+	// HTTP long-polling bridge for clients without WebSockets (kiosks); default realm only.
+	bridge := ws.NewPollBridge(hub, time.Duration(cfg.PollIdleTimeoutSec)*time.Second)
+	go bridge.Run(ctx)
+	http.HandleFunc("POST /api/kiosk/sessions", bridge.CreateSession)
+	http.HandleFunc("DELETE /api/kiosk/sessions/{id}", bridge.CloseSession)
+	http.HandleFunc("POST /api/kiosk/sessions/{id}/join", bridge.Join)
+	http.HandleFunc("POST /api/kiosk/sessions/{id}/flip", bridge.Flip)
+	http.HandleFunc("POST /api/kiosk/sessions/{id}/messages", bridge.Message)
+	http.HandleFunc("GET /api/kiosk/sessions/{id}/events", bridge.Events)
+
+	// Admin announcements reach every hub: the default one and each realm's.
+	announceHubs := []*ws.Hub{hub}
+	for _, realmHub := range realmHubs {
+		announceHubs = append(announceHubs, realmHub)
+	}
+
 	// REST API handlers
 	apiHandler := api.NewHandler(cfg, historyStore, frontendErrorLogger)
+	apiHandler.Announcer = ws.NewAnnouncer(announceHubs...)
+	apiHandler.StatsSources = statsSources
+	apiHandler.Hubs = announceHubs
 	http.HandleFunc("/api/history", apiHandler.History)
 	http.HandleFunc("/api/leaderboard", apiHandler.Leaderboard)
+	http.HandleFunc("/api/stats", apiHandler.Stats)
+	http.HandleFunc("/api/stats/me", apiHandler.PlayerStats)
+	http.HandleFunc("/realms/{realm}/api/history", apiHandler.History)
+	http.HandleFunc("/realms/{realm}/api/leaderboard", apiHandler.Leaderboard)
+	http.HandleFunc("/api/seasons", apiHandler.Seasons)
+	http.HandleFunc("/api/modes", apiHandler.Modes)
+	http.HandleFunc("/realms/{realm}/api/seasons", apiHandler.Seasons)
 	http.HandleFunc("/api/telemetry/metrics", apiHandler.TelemetryMetrics)
+	http.HandleFunc("/api/admin/telemetry", apiHandler.TelemetryMetrics) // alias under the admin prefix
+	http.HandleFunc("/api/telemetry/combos", apiHandler.TelemetryCombos)
+	http.HandleFunc("/realms/{realm}/api/telemetry/metrics", apiHandler.TelemetryMetrics)
+	http.HandleFunc("/realms/{realm}/api/admin/telemetry", apiHandler.TelemetryMetrics)
+	http.HandleFunc("/realms/{realm}/api/telemetry/combos", apiHandler.TelemetryCombos)
+	http.HandleFunc("/api/admin/integrity", apiHandler.IntegrityReport)
+	http.HandleFunc("/api/admin/persistence", apiHandler.PersistStats)
+	http.HandleFunc("/api/admin/ai", apiHandler.AIPoolStats)
+	http.HandleFunc("/api/admin/announcements", apiHandler.Announcements)
+	http.HandleFunc("/api/admin/announcements/{id}/cancel", apiHandler.CancelAnnouncement)
+	http.HandleFunc("/api/admin/display-names/sync", apiHandler.SyncDisplayNames)
+	http.HandleFunc("/api/admin/users/{id}/disconnect", apiHandler.DisconnectUser)
+	http.HandleFunc("/api/me/arcana-stats", apiHandler.ArcanaStats)
+	http.HandleFunc("/realms/{realm}/api/me/arcana-stats", apiHandler.ArcanaStats)
+	http.HandleFunc("/api/me/settings", apiHandler.Settings)
+	http.HandleFunc("/api/friends", apiHandler.Friends)
+	http.HandleFunc("/api/friends/request", apiHandler.RequestFriend)
+	http.HandleFunc("/api/friends/accept", apiHandler.AcceptFriend)
+	http.HandleFunc("/api/history/{id}/summary", apiHandler.MatchSummary)
+	http.HandleFunc("/realms/{realm}/api/history/{id}/summary", apiHandler.MatchSummary)
+	http.HandleFunc("/api/replay/{id}", apiHandler.MatchReplay)
 	http.HandleFunc("/api/log/frontend-error", apiHandler.FrontendError)
 
+	// Embedded web client (optional): "/" catches whatever the routes above do not, with SPA fallback.
+	if cfg.ServeWebClient {
+		clientFS, ok := webclient.Embedded()
+		if !ok {
+			slog.Error("SERVE_WEB_CLIENT is set but the binary has no client build; copy client/dist to server/webclient/dist and rebuild", "tag", "server")
+			os.Exit(1)
+		}
+		http.Handle("/", webclient.Handler(clientFS))
+		slog.Info("Serving embedded web client", "tag", "server", "path", "/")
+	}
+
 	addr := fmt.Sprintf(":%d", cfg.WSPort)
 	srv := &http.Server{Addr: addr}
 	go func() {
@@ -96,16 +222,26 @@ func main() {
 		}
 	}()
 
-	// Graceful shutdown on SIGINT/SIGTERM
+	// Graceful shutdown on SIGINT/SIGTERM: no new matches, games in progress get ShutdownGraceSec to finish
+	// (then end as draws) and are recorded before the hubs, HTTP server and database pool close.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	slog.Info("Shutting down server...", "tag", "server")
-	cancel() // stop hub and matchmaker (no new connections or matches)
+	slog.Info("Shutting down server...", "tag", "server", "grace_sec", cfg.ShutdownGraceSec)
+	drainMatchmakers(matchmakers, time.Duration(cfg.ShutdownGraceSec)*time.Second)
+	// Tell connected clients the server is restarting, so they reconnect instead of reporting an error.
+	for _, h := range announceHubs {
+		h.DisconnectAll(ws.CloseServiceRestart, "server restarting")
+	}
+	cancel() // stop hubs and matchmakers
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		slog.Error("Server shutdown", "tag", "server", "err", err)
 	}
+	if historyStore != nil {
+		historyStore.Close()
+		slog.Info("Database pool closed", "tag", "server")
+	}
 	slog.Info("Server stopped", "tag", "server")
 }
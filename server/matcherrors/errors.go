@@ -10,4 +10,23 @@ var (
 	ErrInvalidToken    = errors.New("invalid rejoin token")
 	ErrNotDisconnected = errors.New("this player is not disconnected")
 	ErrNoActiveGame    = errors.New("no active game for this user")
+	// ErrGameInterrupted means the rejoin credentials are valid but the match was lost with a server
+	// restart and cannot be restored (no usable snapshot, or the server is shutting down).
+	ErrGameInterrupted = errors.New("game was interrupted by a server restart")
+	// ErrNotAParticipant means a rematch was requested for a game the user did not play.
+	ErrNotAParticipant = errors.New("user did not play this game")
+	// ErrNoBoardSeed means the game was recorded without its board seed and cannot be replayed.
+	ErrNoBoardSeed = errors.New("game has no board seed")
+	// ErrInvalidBoard means the game's board size no longer passes the board constraints.
+	ErrInvalidBoard = errors.New("board size is not valid")
+	// ErrOpponentUnavailable means the AI profile of the original game is no longer configured.
+	ErrOpponentUnavailable = errors.New("opponent is no longer available")
+	// ErrUnknownChallenge means a challenge answer names no open challenge for the user.
+	ErrUnknownChallenge = errors.New("challenge not found")
+	// ErrOpponentBusy means the challenged or challenging player is already in a game.
+	ErrOpponentBusy = errors.New("opponent is in a game")
+	// ErrUnknownPoll means a poll answer names no active experiment poll.
+	ErrUnknownPoll = errors.New("poll is not active")
+	// ErrInvalidPollAnswer means a poll answer is not one of the poll's options.
+	ErrInvalidPollAnswer = errors.New("answer is not a poll option")
 )
@@ -0,0 +1,60 @@
+package matchmaking
+
+import (
+	"encoding/json"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/ws"
+	"memory-game-server/wsutil"
+)
+
+// aiPairTimeout chooses how long e waits for a human before a game vs the AI, counted from when it joined
+// the queue. With adaptation on (AIPairTimeoutMaxSec), the wait follows the load (adaptiveAIWait) and is
+// sent to the client with queue_status: the players online come from Online, the players queued are the
+// other entries of modes with an AI fallback (ranked and casual).
+func (m *Matchmaker) aiPairTimeout(e *queueEntry) time.Duration {
+	if m.config.AIPairTimeoutMaxSec <= 0 {
+		return adaptiveAIWait(m.config, -1, 0)
+	}
+	online, others := 0, -1
+	if m.Online != nil {
+		online = m.Online()
+		others = max(online-1, 0)
+	}
+	m.waitMu.Lock()
+	queued := 0
+	for _, o := range m.entries {
+		if o != e && o.mode.AIFallback && (o.state == entryQueued || o.state == entryPendingPair) {
+			queued++
+		}
+	}
+	client := e.client
+	m.waitMu.Unlock()
+
+	wait := adaptiveAIWait(m.config, others, queued)
+	data, _ := json.Marshal(ws.QueueStatusMsg{Type: "queue_status", AIWaitMs: wait.Milliseconds(), PlayersOnline: online, PlayersQueued: queued})
+	wsutil.SafeSend(client.Send, data)
+	return wait
+}
+
+// adaptiveAIWait returns the wait before a game vs the AI when others other players are online (-1 when
+// unknown) and queued of them wait in the queue. With AIPairTimeoutMaxSec set, a busy queue stretches
+// AIPairTimeoutSec toward the max (a human is likely to turn up) and a quiet server shrinks it toward
+// AIPairTimeoutMinSec (nobody is coming); each bound is reached at AIPairBusyPlayers players.
+func adaptiveAIWait(cfg *config.Config, others, queued int) time.Duration {
+	base := time.Duration(max(cfg.AIPairTimeoutSec, 0)) * time.Second
+	if cfg.AIPairTimeoutMaxSec <= 0 {
+		return base
+	}
+	busy := max(cfg.AIPairBusyPlayers, 1)
+	lo := min(time.Duration(max(cfg.AIPairTimeoutMinSec, 0))*time.Second, base)
+	hi := max(time.Duration(cfg.AIPairTimeoutMaxSec)*time.Second, base)
+	switch {
+	case queued > 0:
+		return base + (hi-base)*time.Duration(min(queued, busy))/time.Duration(busy)
+	case others >= 0:
+		return lo + (base-lo)*time.Duration(min(others, busy))/time.Duration(busy)
+	}
+	return base
+}
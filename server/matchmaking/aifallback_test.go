@@ -0,0 +1,54 @@
+package matchmaking
+
+import (
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+func TestAdaptiveAIWait(t *testing.T) {
+	cfg := &config.Config{AIPairTimeoutSec: 20, AIPairTimeoutMinSec: 4, AIPairTimeoutMaxSec: 60, AIPairBusyPlayers: 4}
+	tests := []struct {
+		name           string
+		others, queued int
+		want           time.Duration
+	}{
+		{"alone online", 0, 0, 4 * time.Second},
+		{"few online", 2, 0, 12 * time.Second},
+		{"many online, empty queue", 10, 0, 20 * time.Second},
+		{"online unknown", -1, 0, 20 * time.Second},
+		{"one queued", 5, 1, 30 * time.Second},
+		{"busy queue", 50, 9, 60 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := adaptiveAIWait(cfg, tt.others, tt.queued); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	cfg.AIPairTimeoutMaxSec = 0
+	if got := adaptiveAIWait(cfg, 0, 9); got != 20*time.Second {
+		t.Errorf("expected AIPairTimeoutSec without adaptation, got %v", got)
+	}
+}
+
+func TestAIPairTimeout_SentInQueueStatus(t *testing.T) {
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, RevealDurationMS: 100, MaxNameLength: 24,
+		AIPairTimeoutSec: 60, AIPairTimeoutMinSec: 10, AIPairTimeoutMaxSec: 120, AIPairBusyPlayers: 10}
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	mm.Online = func() int { return 1 }
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	mm.Enqueue(alice)
+	e := mm.entries[queueKey(alice)]
+
+	if got := mm.aiPairTimeout(e); got != 10*time.Second {
+		t.Fatalf("expected the minimum wait for the only player online, got %v", got)
+	}
+	st := nextOfType(t, alice.Send, "queue_status")
+	if st["aiWaitMs"] != float64(10000) || st["playersOnline"] != float64(1) || st["playersQueued"] != float64(0) {
+		t.Errorf("expected the chosen wait and load in queue_status, got %v", st)
+	}
+}
@@ -0,0 +1,28 @@
+package matchmaking
+
+import (
+	"memory-game-server/config"
+	"memory-game-server/ws"
+)
+
+// boardSize returns the board size c queues for: the side of the n x n board it requested, or 0 for the
+// server's default board, which also covers a request for the default size or for a size not offered.
+// Entries only pair with entries of the same size.
+func (m *Matchmaker) boardSize(c *ws.Client) int {
+	n := c.BoardSize
+	if !m.config.OffersBoardSize(n) || (n == m.config.BoardRows && n == m.config.BoardCols) {
+		return 0
+	}
+	return n
+}
+
+// boardConfig returns the config for games on an n x n board: m.config for 0, otherwise a copy with the
+// board size replaced.
+func (m *Matchmaker) boardConfig(n int) *config.Config {
+	if n <= 0 {
+		return m.config
+	}
+	cfg := *m.config
+	cfg.BoardRows, cfg.BoardCols = n, n
+	return &cfg
+}
@@ -0,0 +1,81 @@
+package matchmaking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+func TestMatchmakerPairsSameBoardSize(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:        2,
+		BoardCols:        2,
+		BoardSizes:       []int{4, 6},
+		RevealDurationMS: 100,
+		MaxNameLength:    24,
+		AIPairTimeoutSec: 60,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice", BoardSize: 4}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob", BoardSize: 6}
+	carol := &ws.Client{Send: make(chan []byte, 100), Name: "Carol"}
+	dave := &ws.Client{Send: make(chan []byte, 100), Name: "Dave", BoardSize: 4}
+
+	mm.Enqueue(alice)
+	mm.Enqueue(bob)
+	mm.Enqueue(carol)
+	time.Sleep(100 * time.Millisecond)
+	noGame(t, alice)
+	noGame(t, bob)
+	noGame(t, carol)
+	mm.Enqueue(dave)
+	g := awaitGame(t, alice, time.Second)
+	if g == nil || g != awaitGame(t, dave, time.Second) {
+		t.Fatal("expected Alice and Dave to be paired on the 4x4 board")
+	}
+	if b := g.Board; b.Rows != 4 || b.Cols != 4 {
+		t.Errorf("expected a 4x4 board, got %dx%d", b.Rows, b.Cols)
+	}
+	time.Sleep(100 * time.Millisecond)
+	noGame(t, bob)
+	noGame(t, carol)
+}
+
+func TestMatchmakerAIFallbackHonorsBoardSize(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:        2,
+		BoardCols:        2,
+		BoardSizes:       []int{4},
+		RevealDurationMS: 100,
+		MaxNameLength:    24,
+		AIPairTimeoutSec: 0,
+		AIProfiles:       []config.AIParams{{Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 50}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice", BoardSize: 4}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob", BoardSize: 8} // not offered: default board
+	mm.Enqueue(alice)
+	mm.Enqueue(bob)
+	aliceGame, bobGame := awaitGame(t, alice, time.Second), awaitGame(t, bob, time.Second)
+	if aliceGame == nil || bobGame == nil || aliceGame == bobGame {
+		t.Fatal("expected both players to get a game vs the AI")
+	}
+	if b := aliceGame.Board; b.Rows != 4 || b.Cols != 4 {
+		t.Errorf("expected a 4x4 board vs the AI, got %dx%d", b.Rows, b.Cols)
+	}
+	if b := bobGame.Board; b.Rows != 2 || b.Cols != 2 {
+		t.Errorf("expected the default board for a size not offered, got %dx%d", b.Rows, b.Cols)
+	}
+}
@@ -0,0 +1,45 @@
+package matchmaking
+
+import (
+	"math/rand"
+	"strings"
+
+	"memory-game-server/config"
+)
+
+// botNameSuffix marks a bot whose every identity collides with a human name in the match.
+const botNameSuffix = " (bot)"
+
+// pickAIIdentity returns the name and avatar the bot plays under this match, at random from the profile's
+// identity pool (the profile name when the pool is empty). Identities named like one of the humans
+// (case-insensitive) are skipped so nobody mistakes the bot for a player; when all of them collide, the
+// pick gets botNameSuffix.
+func pickAIIdentity(profile *config.AIParams, humans ...string) config.AIIdentity {
+	pool := profile.Identities
+	if len(pool) == 0 {
+		pool = []config.AIIdentity{{Name: profile.Name}}
+	}
+	taken := func(name string) bool {
+		for _, h := range humans {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+				return true
+			}
+		}
+		return false
+	}
+	var free []config.AIIdentity
+	for _, id := range pool {
+		if id.Name != "" && !taken(id.Name) {
+			free = append(free, id)
+		}
+	}
+	if len(free) > 0 {
+		return free[rand.Intn(len(free))]
+	}
+	id := pool[rand.Intn(len(pool))]
+	if id.Name == "" {
+		id.Name = profile.Name
+	}
+	id.Name += botNameSuffix
+	return id
+}
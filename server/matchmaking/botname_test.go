@@ -0,0 +1,28 @@
+package matchmaking
+
+import (
+	"testing"
+
+	"memory-game-server/config"
+)
+
+func TestPickAIIdentity_AvoidsHumanNames(t *testing.T) {
+	profile := &config.AIParams{Name: "Thalia", Identities: []config.AIIdentity{{Name: "Thalia", Avatar: "mask"}, {Name: "Clio", Avatar: "scroll"}}}
+	for range 20 {
+		if got := pickAIIdentity(profile, "thalia"); got.Name != "Clio" || got.Avatar != "scroll" {
+			t.Fatalf("expected the non-colliding identity Clio, got %+v", got)
+		}
+	}
+
+	got := pickAIIdentity(profile, "Thalia", "CLIO")
+	if got.Name != "Thalia"+botNameSuffix && got.Name != "Clio"+botNameSuffix {
+		t.Errorf("expected a suffixed bot name when every identity collides, got %q", got.Name)
+	}
+
+	if got := pickAIIdentity(&config.AIParams{Name: "Calliope"}, "Alice"); got.Name != "Calliope" {
+		t.Errorf("expected the profile name without an identity pool, got %q", got.Name)
+	}
+	if got := pickAIIdentity(&config.AIParams{Name: "Calliope"}, " calliope "); got.Name != "Calliope"+botNameSuffix {
+		t.Errorf("expected the profile name suffixed on collision, got %q", got.Name)
+	}
+}
@@ -0,0 +1,156 @@
+package matchmaking
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"memory-game-server/matcherrors"
+	"memory-game-server/modes"
+	"memory-game-server/ws"
+	"memory-game-server/wsutil"
+)
+
+// challengeWaitTimeout is how long a direct challenge waits for the invited user to answer.
+const challengeWaitTimeout = time.Minute
+
+// directChallenge is a player who invited a specific online user (challenge_user) and waits for an answer.
+type directChallenge struct {
+	id       string
+	from     *ws.Client
+	toUserID string
+	toName   string
+	to       []*ws.Client // the invited user's connections when the invite was sent
+	timer    *time.Timer
+}
+
+// Challenge invites the user behind targets (every connection of one user, as found by the hub) to a game
+// with c. The invite waits up to challengeWaitTimeout for AnswerChallenge; challenge_sent confirms it to c.
+// A new challenge from c replaces its previous one, and c leaves the queue (see LeaveQueue). Returns
+// ErrOpponentBusy when the invited user is in a game.
+func (m *Matchmaker) Challenge(c *ws.Client, targets []*ws.Client) error {
+	if m.refuseWhileDraining(c) || len(targets) == 0 {
+		return nil
+	}
+	toUserID := targets[0].UserID
+	if m.inGame(toUserID) {
+		return matcherrors.ErrOpponentBusy
+	}
+	m.LeaveQueue(c)
+
+	ch := &directChallenge{id: uuid.New().String(), from: c, toUserID: toUserID, toName: targets[0].Name, to: targets}
+	m.challengeMu.Lock()
+	ch.timer = time.AfterFunc(challengeWaitTimeout, func() { m.expireChallenge(ch) })
+	m.challenges[ch.id] = ch
+	m.challengeMu.Unlock()
+
+	slog.Info("direct challenge", "tag", "matchmaking", "challenge_id", ch.id, "user_id", c.UserID, "opponent_user_id", toUserID)
+	expires := challengeWaitTimeout.Milliseconds()
+	invite, _ := json.Marshal(ws.ChallengeReceivedMsg{Type: "challenge_received", ChallengeID: ch.id, FromName: c.Name, FromUserID: c.UserID, BoardSize: m.boardSize(c), ExpiresInMS: expires})
+	for _, t := range targets {
+		wsutil.SafeSend(t.Send, invite)
+	}
+	data, _ := json.Marshal(ws.ChallengeSentMsg{Type: "challenge_sent", ChallengeID: ch.id, OpponentName: ch.toName, ExpiresInMS: expires})
+	wsutil.SafeSend(c.Send, data)
+	return nil
+}
+
+// AnswerChallenge answers challenge id for c, which must belong to the invited user. Accepting starts an
+// unrated game right away with the challenger in seat 0, on the board size they asked for; declining tells
+// the challenger. Both players leave the queue. Returns ErrUnknownChallenge when the challenge is not open
+// for c, and ErrOpponentBusy when the challenger has started another game meanwhile (any game, party and
+// raid included).
+func (m *Matchmaker) AnswerChallenge(c *ws.Client, id string, accept bool) error {
+	m.challengeMu.Lock()
+	ch, ok := m.challenges[id]
+	if !ok || c.UserID == "" || c.UserID != ch.toUserID {
+		m.challengeMu.Unlock()
+		return matcherrors.ErrUnknownChallenge
+	}
+	delete(m.challenges, id)
+	m.challengeMu.Unlock()
+	ch.timer.Stop()
+
+	if !accept {
+		slog.Info("direct challenge declined", "tag", "matchmaking", "challenge_id", id, "user_id", c.UserID)
+		m.closeChallenge(ch, ws.ChallengeDeclined)
+		return nil
+	}
+	if playing(ch.from) || m.inGame(ch.from.UserID) || m.inGame(c.UserID) {
+		m.closeChallenge(ch, ws.ChallengeUnavailable)
+		return matcherrors.ErrOpponentBusy
+	}
+	m.LeaveQueue(ch.from)
+	m.LeaveQueue(c)
+	// The invite also went to the user's other connections; they can drop it.
+	data, _ := json.Marshal(ws.ChallengeClosedMsg{Type: "challenge_closed", ChallengeID: id, Reason: ws.ChallengeAnswered})
+	for _, t := range ch.to {
+		if t != c {
+			wsutil.SafeSend(t.Send, data)
+		}
+	}
+	slog.Info("direct challenge accepted", "tag", "matchmaking", "challenge_id", id, "user_id", c.UserID)
+	m.createGameFrom(ch.from, c, nil, modes.Get(modes.Casual))
+	return nil
+}
+
+// cancelChallenge withdraws c's direct challenge, if any, and tells both sides.
+func (m *Matchmaker) cancelChallenge(c *ws.Client) {
+	m.challengeMu.Lock()
+	var found *directChallenge
+	for id, ch := range m.challenges {
+		if ch.from == c {
+			found = ch
+			delete(m.challenges, id)
+			break
+		}
+	}
+	m.challengeMu.Unlock()
+	if found == nil {
+		return
+	}
+	found.timer.Stop()
+	slog.Info("direct challenge cancelled", "tag", "matchmaking", "challenge_id", found.id, "user_id", c.UserID)
+	m.closeChallenge(found, ws.ChallengeCancelled)
+}
+
+// expireChallenge drops ch when nobody answered it in time and tells both sides.
+func (m *Matchmaker) expireChallenge(ch *directChallenge) {
+	m.challengeMu.Lock()
+	if m.challenges[ch.id] != ch {
+		m.challengeMu.Unlock()
+		return
+	}
+	delete(m.challenges, ch.id)
+	m.challengeMu.Unlock()
+	slog.Info("direct challenge expired", "tag", "matchmaking", "challenge_id", ch.id, "user_id", ch.from.UserID)
+	m.closeChallenge(ch, ws.ChallengeExpired)
+}
+
+// closeChallenge sends challenge_closed with reason to the challenger and every invited connection.
+func (m *Matchmaker) closeChallenge(ch *directChallenge, reason string) {
+	data, _ := json.Marshal(ws.ChallengeClosedMsg{Type: "challenge_closed", ChallengeID: ch.id, Reason: reason})
+	wsutil.SafeSend(ch.from.Send, data)
+	for _, t := range ch.to {
+		wsutil.SafeSend(t.Send, data)
+	}
+}
+
+// playing reports whether c's connection is in a game that has not finished. Unlike inGame, it covers
+// party and raid games, which are not indexed by user.
+func playing(c *ws.Client) bool {
+	g := c.Game
+	return g != nil && !g.Finished
+}
+
+// inGame reports whether userID has a game in progress on this matchmaker.
+func (m *Matchmaker) inGame(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.userIDToGame[userID]
+	return ok
+}
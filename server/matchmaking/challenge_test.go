@@ -0,0 +1,167 @@
+package matchmaking
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/matcherrors"
+	"memory-game-server/modes"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+// nextOfType returns the first message of type typ on ch, skipping others; fails when there is none queued.
+func nextOfType(t *testing.T, ch chan []byte, typ string) map[string]any {
+	t.Helper()
+	for {
+		select {
+		case data := <-ch:
+			var msg map[string]any
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg["type"] == typ {
+				return msg
+			}
+		default:
+			t.Fatalf("expected a %s message", typ)
+			return nil
+		}
+	}
+}
+
+func challengeConfig() *config.Config {
+	return &config.Config{BoardRows: 2, BoardCols: 2, RevealDurationMS: 100, MaxNameLength: 24}
+}
+
+func TestChallenge_AcceptStartsCasualGame(t *testing.T) {
+	mm := NewMatchmaker(challengeConfig(), powerup.NewBuiltinRegistry(nil, 1), nil)
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	bob := &ws.Client{Send: make(chan []byte, 20), Name: "Bob", UserID: "u-bob"}
+	bobTab := &ws.Client{Send: make(chan []byte, 20), Name: "Bob", UserID: "u-bob"}
+
+	if err := mm.Challenge(alice, []*ws.Client{bob, bobTab}); err != nil {
+		t.Fatal(err)
+	}
+	sent := nextOfType(t, alice.Send, "challenge_sent")
+	invite := nextOfType(t, bob.Send, "challenge_received")
+	nextOfType(t, bobTab.Send, "challenge_received")
+	id, _ := invite["challengeId"].(string)
+	if sent["challengeId"] != id || sent["opponentName"] != "Bob" || invite["fromName"] != "Alice" {
+		t.Fatalf("expected matching challenge messages, got %v and %v", sent, invite)
+	}
+	if st := mm.Status(bob); len(st.Challenges) != 1 || !st.Challenges[0].Incoming {
+		t.Errorf("expected an incoming challenge in bob's status, got %+v", st.Challenges)
+	}
+
+	if err := mm.AnswerChallenge(alice, id, true); !errors.Is(err, matcherrors.ErrUnknownChallenge) {
+		t.Errorf("expected the challenger not to answer their own challenge, got %v", err)
+	}
+	if err := mm.AnswerChallenge(bob, id, true); err != nil {
+		t.Fatal(err)
+	}
+	nextOfType(t, alice.Send, "match_found")
+	nextOfType(t, bob.Send, "match_found")
+	if closed := nextOfType(t, bobTab.Send, "challenge_closed"); closed["reason"] != ws.ChallengeAnswered {
+		t.Errorf("expected bob's other tab to drop the invite, got %v", closed)
+	}
+	if alice.Game == nil || alice.Game != bob.Game || alice.Game.Mode != modes.Casual || alice.PlayerID != 0 {
+		t.Fatalf("expected alice in seat 0 of a casual game with bob, got %+v", alice.Game)
+	}
+
+	// Bob is in a game now, so a new challenge is refused.
+	carol := &ws.Client{Send: make(chan []byte, 20), Name: "Carol", UserID: "u-carol"}
+	if err := mm.Challenge(carol, []*ws.Client{bob}); !errors.Is(err, matcherrors.ErrOpponentBusy) {
+		t.Errorf("expected a busy opponent, got %v", err)
+	}
+	if err := mm.AnswerChallenge(bob, id, true); !errors.Is(err, matcherrors.ErrUnknownChallenge) {
+		t.Errorf("expected an answered challenge to be closed, got %v", err)
+	}
+}
+
+func TestChallenge_DeclineAndCancel(t *testing.T) {
+	mm := NewMatchmaker(challengeConfig(), powerup.NewBuiltinRegistry(nil, 1), nil)
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	bob := &ws.Client{Send: make(chan []byte, 20), Name: "Bob", UserID: "u-bob"}
+
+	mm.Challenge(alice, []*ws.Client{bob})
+	id, _ := nextOfType(t, bob.Send, "challenge_received")["challengeId"].(string)
+	if err := mm.AnswerChallenge(bob, id, false); err != nil {
+		t.Fatal(err)
+	}
+	if closed := nextOfType(t, alice.Send, "challenge_closed"); closed["reason"] != ws.ChallengeDeclined {
+		t.Errorf("expected the challenge declined, got %v", closed)
+	}
+
+	mm.Challenge(alice, []*ws.Client{bob})
+	nextOfType(t, bob.Send, "challenge_received")
+	mm.LeaveQueue(alice)
+	if closed := nextOfType(t, bob.Send, "challenge_closed"); closed["reason"] != ws.ChallengeCancelled {
+		t.Errorf("expected the challenge withdrawn, got %v", closed)
+	}
+	if st := mm.Status(bob); len(st.Challenges) != 0 {
+		t.Errorf("expected no challenge left, got %+v", st.Challenges)
+	}
+}
+
+func TestChallenge_ChallengerCannotBeBookedTwice(t *testing.T) {
+	mm := NewMatchmaker(challengeConfig(), powerup.NewBuiltinRegistry(nil, 1), nil)
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	aliceTab := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	bob := &ws.Client{Send: make(chan []byte, 20), Name: "Bob", UserID: "u-bob"}
+
+	// Queueing from the challenging connection withdraws the challenge.
+	mm.Challenge(alice, []*ws.Client{bob})
+	nextOfType(t, bob.Send, "challenge_received")
+	mm.Enqueue(alice)
+	if closed := nextOfType(t, bob.Send, "challenge_closed"); closed["reason"] != ws.ChallengeCancelled {
+		t.Errorf("expected the challenge withdrawn when the challenger queued, got %v", closed)
+	}
+
+	// Leaving the queue withdraws the challenge and still removes the entry (queued from another tab).
+	mm.Challenge(alice, []*ws.Client{bob})
+	nextOfType(t, bob.Send, "challenge_received")
+	mm.Enqueue(aliceTab)
+	mm.LeaveQueue(alice)
+	if closed := nextOfType(t, bob.Send, "challenge_closed"); closed["reason"] != ws.ChallengeCancelled {
+		t.Errorf("expected the challenge withdrawn, got %v", closed)
+	}
+	if len(mm.entries) != 0 {
+		t.Errorf("expected alice out of the queue, got %d entries", len(mm.entries))
+	}
+
+	// Accepting takes the challenger out of the queue too.
+	mm.Challenge(alice, []*ws.Client{bob})
+	id, _ := nextOfType(t, bob.Send, "challenge_received")["challengeId"].(string)
+	mm.Enqueue(aliceTab)
+	if err := mm.AnswerChallenge(bob, id, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(mm.entries) != 0 || alice.Game == nil {
+		t.Errorf("expected the game to start with alice out of the queue, got %d entries", len(mm.entries))
+	}
+}
+
+func TestChallenge_ChallengerInPartyIsBusy(t *testing.T) {
+	mm := NewMatchmaker(challengeConfig(), powerup.NewBuiltinRegistry(nil, 1), nil)
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	bob := &ws.Client{Send: make(chan []byte, 20), Name: "Bob", UserID: "u-bob"}
+
+	mm.Challenge(alice, []*ws.Client{bob})
+	id, _ := nextOfType(t, bob.Send, "challenge_received")["challengeId"].(string)
+	// Party games are not indexed by user, only by connection.
+	party := &game.Game{ID: "party"}
+	alice.Game = party
+	if err := mm.AnswerChallenge(bob, id, true); !errors.Is(err, matcherrors.ErrOpponentBusy) {
+		t.Fatalf("expected a busy challenger, got %v", err)
+	}
+	if alice.Game != party || bob.Game != nil {
+		t.Error("expected no game to be created")
+	}
+	if closed := nextOfType(t, alice.Send, "challenge_closed"); closed["reason"] != ws.ChallengeUnavailable {
+		t.Errorf("expected the challenge closed as unavailable, got %v", closed)
+	}
+}
@@ -0,0 +1,40 @@
+package matchmaking
+
+import (
+	"context"
+
+	"memory-game-server/game"
+	"memory-game-server/storage"
+)
+
+// isRatedEnd reports whether a game that ended with endReason updates ratings (e.g. ai_failure does not).
+func isRatedEnd(endReason string) bool {
+	switch endReason {
+	case "completed", "opponent_disconnected", "resigned", "insurmountable_lead":
+		return true
+	}
+	return false
+}
+
+// startingElo reads both seats' ratings when the match is created, so game_over can preview the rating
+// change without waiting for the update. Unrated players (and failed reads) count as storage.InitialElo.
+func (m *Matchmaker) startingElo(g *game.Game) [2]int {
+	elo := [2]int{storage.InitialElo, storage.InitialElo}
+	if m.historyStore == nil {
+		return elo
+	}
+	for i, uid := range g.PlayerUserIDs {
+		if e, err := m.historyStore.GetLeaderboardEntryByUserID(context.Background(), m.realm, uid); err == nil && e != nil {
+			elo[i] = e.Elo
+		}
+	}
+	return elo
+}
+
+// eloPreview returns each seat's rating before and after the result, computed from the ratings at match
+// start. The persisted values, sent later in rating_update, can differ when a rating moved meanwhile
+// (e.g. a bot profile finishing another match).
+func eloPreview(start [2]int, winnerIdx int) (elo0Before, elo0After, elo1Before, elo1After *int) {
+	r0, r1 := storage.PreviewEloUpdates(start[0], start[1], winnerIdx)
+	return &start[0], &r0, &start[1], &r1
+}
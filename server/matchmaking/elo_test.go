@@ -0,0 +1,27 @@
+package matchmaking
+
+import "testing"
+
+func TestEloPreview(t *testing.T) {
+	e0Before, e0After, e1Before, e1After := eloPreview([2]int{1200, 1000}, 1)
+	if *e0Before != 1200 || *e1Before != 1000 {
+		t.Fatalf("expected starting ratings 1200/1000, got %d/%d", *e0Before, *e1Before)
+	}
+	if *e0After >= 1200 || *e1After <= 1000 {
+		t.Errorf("upset should move ratings toward the winner, got %d/%d", *e0After, *e1After)
+	}
+	if gain, loss := *e1After-*e1Before, *e0Before-*e0After; gain != loss {
+		t.Errorf("expected a zero-sum update, got +%d/-%d", gain, loss)
+	}
+}
+
+func TestIsRatedEnd(t *testing.T) {
+	for _, reason := range []string{"completed", "opponent_disconnected", "resigned", "insurmountable_lead"} {
+		if !isRatedEnd(reason) {
+			t.Errorf("expected %q to be rated", reason)
+		}
+	}
+	if isRatedEnd("ai_failure") {
+		t.Error("expected ai_failure to be unrated")
+	}
+}
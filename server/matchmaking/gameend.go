@@ -0,0 +1,145 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/modes"
+	"memory-game-server/storage"
+	"memory-game-server/wsutil"
+)
+
+// wireGameEnd sets g.OnGameEnd by the game's mode (g.Mode must be set): games of recorded modes are rated
+// and written to history (recordGameEnd) when the matchmaker has a store; the others only log their
+// result. regions and bot are as for recordGameEnd. Returns true when the game is recorded, and so should
+// be checkpointed for resume.
+func (m *Matchmaker) wireGameEnd(g *game.Game, regions [2]string, bot *config.AIParams) bool {
+	if m.historyStore != nil && modes.Get(g.Mode).Recorded {
+		m.recordGameEnd(g, regions, bot)
+		return true
+	}
+	g.OnGameEnd = func(matchID, p0UID, p1UID, p0Name, p1Name string, p0Score, p1Score int, winnerIdx int, endReason string, done func(elo0Before, elo0After, elo1Before, elo1After *int)) {
+		winner := "draw"
+		if winnerIdx >= 0 && winnerIdx < len(g.Players) {
+			winner = g.Players[winnerIdx].Name // party games have more than two seats
+		}
+		slog.Info("Match ended", "tag", "matchmaking", "match_id", matchID, "end_reason", endReason, "winner", winner)
+		done(nil, nil, nil, nil)
+	}
+	return false
+}
+
+// recordGameEnd sets g.OnGameEnd to rate and record a finished 1v1 game: ratings (when rated), then
+// game history, telemetry, replay events, score series, arcana and latency. regions are the seats' region
+// hints. bot is the AI profile in seat 1, or nil when both seats are human. PlayerUserIDs must already be set, since
+// the match-start ratings for the game_over preview are read here.
+func (m *Matchmaker) recordGameEnd(g *game.Game, regions [2]string, bot *config.AIParams) {
+	store, realm := m.historyStore, m.realm
+	startElo := m.startingElo(g)
+	g.TelemetrySink = m.queuedSink
+	g.ReplaySink = m.queuedSink
+	g.OnGameEnd = func(matchID, p0UID, p1UID, p0Name, p1Name string, p0Score, p1Score int, winnerIdx int, endReason string, done func(elo0Before, elo0After, elo1Before, elo1After *int)) {
+		logMatchEnd(matchID, p0Name, p1Name, endReason, winnerIdx)
+		// Games of unrated modes (casual), assisted matches (server hints) and rematches (a board seen
+		// before) are recorded but never rated.
+		assisted := g.Assist[0] || g.Assist[1]
+		rated := modes.Get(g.Mode).Rated() && !assisted && g.RematchOf == "" && isRatedEnd(endReason)
+		// Send game_over immediately so the client can show the result without waiting for DB/telemetry.
+		// Rated games carry a preview from the ratings at match start; rating_update confirms it.
+		if rated {
+			done(eloPreview(startElo, winnerIdx))
+		} else {
+			done(nil, nil, nil, nil)
+		}
+		// The bot is rated under its profile name, not the identity it played under.
+		ratedName1, adaptive := p1Name, false
+		if bot != nil {
+			ratedName1, adaptive = bot.Name, bot.Adaptive
+		}
+		blindPlay := [2]game.BlindPlay{g.BlindPlay(0), g.BlindPlay(1)}
+		fastFlips := [2]game.FastFlips{g.FastFlips(0), g.FastFlips(1)}
+		m.persistInFlight.Add(1)
+		go func() {
+			defer m.persistInFlight.Add(-1)
+			var e0Before, e0After, e1Before, e1After *int
+			if rated {
+				_ = m.persist.do(matchID, persistUpdateRatings, func(ctx context.Context) error {
+					eb0, ea0, eb1, ea1, err := store.UpdateRatingsAfterGame(ctx, realm, matchID, p0UID, p1UID, p0Name, ratedName1, winnerIdx)
+					if err == nil {
+						e0Before, e0After = &eb0, &ea0
+						e1Before, e1After = &eb1, &ea1
+					}
+					return err
+				}, "winner_index", winnerIdx)
+			}
+			// Send rating to the human seats as soon as we have it; persistence below is independent.
+			for i := range 2 {
+				var before, after *int
+				if i == 0 {
+					before, after = e0Before, e0After
+				} else {
+					before, after = e1Before, e1After
+				}
+				if bot != nil && i == 1 {
+					continue
+				}
+				if before != nil && after != nil && g.Players[i] != nil && g.Players[i].Send != nil {
+					payload := map[string]any{
+						"type":           "rating_update",
+						"you_elo_before": *before,
+						"you_elo_after":  *after,
+					}
+					data, _ := json.Marshal(payload)
+					wsutil.SafeSend(g.Players[i].Send, data)
+				}
+			}
+			// Persist game history and telemetry after having responded with rating.
+			snapshot := configSnapshot(g)
+			err := m.persist.do(matchID, persistGameResult, func(ctx context.Context) error {
+				return store.InsertGameResult(ctx, realm, storage.GameResult{
+					MatchID: matchID, Player0UserID: p0UID, Player1UserID: p1UID, Player0Name: p0Name, Player1Name: p1Name,
+					Player0Score: p0Score, Player1Score: p1Score, WinnerIndex: winnerIdx, EndReason: endReason,
+					Elo0Before: e0Before, Elo0After: e0After, Elo1Before: e1Before, Elo1After: e1After,
+					Assisted: assisted, Adaptive: adaptive, MismatchRetries: g.Config.MismatchRetries, ConfigSnapshot: snapshot,
+				})
+			}, "player0_user_id", p0UID, "player1_user_id", p1UID, "scores", []int{p0Score, p1Score}, "winner_index", winnerIdx, "end_reason", endReason)
+			if err != nil {
+				// Telemetry, arcana and latency rows reference game_history; without it they cannot be written.
+				m.queuedSink.DiscardMatch(matchID)
+				return
+			}
+			m.queuedSink.FlushMatch(matchID)
+			var powerUpIDs []string
+			for i := range 6 {
+				if id, ok := g.PairIDToPowerUp[i]; ok {
+					powerUpIDs = append(powerUpIDs, id)
+				}
+			}
+			_ = m.persist.do(matchID, persistMatchArcana, func(ctx context.Context) error {
+				return store.InsertMatchArcana(ctx, matchID, powerUpIDs)
+			})
+			_ = m.persist.do(matchID, persistMatchLatency, func(ctx context.Context) error {
+				return store.InsertMatchLatency(ctx, matchID, regions[0], regions[1], int(g.SeatRTT(0).Milliseconds()), int(g.SeatRTT(1).Milliseconds()))
+			})
+			for seat, bp := range blindPlay {
+				if bp.Guesses == 0 {
+					continue
+				}
+				_ = m.persist.do(matchID, persistBlindPlay, func(ctx context.Context) error {
+					return store.InsertBlindPlay(ctx, matchID, seat, bp.Guesses, bp.Hits, bp.ExpectedHits, bp.HitVariance)
+				}, "seat", seat)
+			}
+			for seat, ff := range fastFlips {
+				if ff.Count == 0 {
+					continue
+				}
+				_ = m.persist.do(matchID, persistFastFlips, func(ctx context.Context) error {
+					return store.InsertSuspiciousActivity(ctx, matchID, seat, storage.SuspiciousFastFlip, ff.Count, ff.MinGapMS)
+				}, "seat", seat, "fast_flips", ff.Count)
+			}
+		}()
+	}
+}
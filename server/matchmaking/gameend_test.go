@@ -0,0 +1,28 @@
+package matchmaking
+
+import (
+	"testing"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/modes"
+)
+
+func TestWireGameEnd_FollowsModeRecorded(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{}, nil, ratingStore{})
+	for _, m := range modes.All() {
+		g := &game.Game{ID: "g1", Mode: m.ID}
+		if got := mm.wireGameEnd(g, [2]string{}, nil); got != m.Recorded {
+			t.Errorf("mode %q: expected recorded %v, got %v", m.ID, m.Recorded, got)
+		}
+		if g.OnGameEnd == nil {
+			t.Errorf("mode %q: expected OnGameEnd to be set", m.ID)
+		}
+	}
+
+	// Without a store nothing is recorded.
+	mm = NewMatchmaker(&config.Config{}, nil, nil)
+	if mm.wireGameEnd(&game.Game{ID: "g2", Mode: modes.Ranked}, [2]string{}, nil) {
+		t.Error("expected no recording without a history store")
+	}
+}
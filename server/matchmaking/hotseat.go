@@ -0,0 +1,66 @@
+package matchmaking
+
+import (
+	"encoding/json"
+	"log/slog"
+
+	"memory-game-server/game"
+	"memory-game-server/modes"
+	"memory-game-server/ws"
+	"memory-game-server/wsutil"
+
+	"github.com/google/uuid"
+)
+
+// StartHotseat starts a pass-and-play game on client's connection: the client plays seat 0 under its own
+// name and seat 1 as client.SecondName, on the board size it requested. There is no queue and no opponent
+// connection. Like raids, hotseat games are unrated and not written to history (one account plays both
+// sides), and cannot be rejoined.
+func (m *Matchmaker) StartHotseat(client *ws.Client) {
+	if m.refuseWhileDraining(client) {
+		return
+	}
+	matchID := uuid.New().String()
+	p0 := game.NewPlayer(client.Name, client.Send)
+	p1 := game.NewPlayer(client.SecondName, client.Send)
+
+	g, err := game.NewGame(matchID, m.boardConfig(m.boardSize(client)), p0, p1, m.powerUps)
+	if err != nil {
+		m.gameNotCreated(matchID, err, client)
+		return
+	}
+	g.Hotseat = true
+	g.Mode = modes.Hotseat
+	g.PlayerUserIDs[0] = client.UserID
+	m.wireGameEnd(g, [2]string{}, nil)
+
+	m.mu.Lock()
+	m.activeGames[matchID] = g
+	m.gameIDToClients[matchID] = []*ws.Client{client}
+	m.mu.Unlock()
+
+	client.Game = g
+	client.PlayerID = 0
+
+	slog.Info("Match created (hotseat)", "tag", "matchmaking", "match_id", matchID, "player", p0.Name, "second", p1.Name)
+
+	msg := ws.MatchFoundMsg{
+		Type:             "match_found",
+		GameID:           matchID,
+		OpponentName:     p1.Name,
+		BoardRows:        g.Board.Rows,
+		BoardCols:        g.Board.Cols,
+		YourTurn:         true,
+		RevealDurationMS: g.RevealDurationMS(),
+		MismatchRetries:  g.Config.MismatchRetries,
+		Hotseat:          true,
+		Mode:             g.Mode,
+	}
+	data, _ := json.Marshal(msg)
+	wsutil.SafeSend(client.Send, data)
+
+	go func() {
+		g.Run()
+		m.removeGame(matchID)
+	}()
+}
@@ -8,14 +8,17 @@ import (
 	"errors"
 	"log/slog"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"memory-game-server/ai"
 	"memory-game-server/config"
 	"memory-game-server/game"
 	"memory-game-server/matcherrors"
+	"memory-game-server/modes"
 	"memory-game-server/storage"
 	"memory-game-server/ws"
 	"memory-game-server/wsutil"
@@ -23,155 +26,12 @@ import (
 	"github.com/google/uuid"
 )
 
-// turnEvent and arcanaEvent hold telemetry data for async flush.
-type turnEvent struct {
-	matchID            string
-	round              int
-	playerIdx          int
-	playerScoreAfter   int
-	opponentScoreAfter int
-	deltaPlayer        int
-	deltaOpponent      int
-}
-
-type arcanaEvent struct {
-	matchID             string
-	round               int
-	playerIdx           int
-	powerUpID           string
-	targetCardIndex     int
-	playerScoreBefore   int
-	opponentScoreBefore int
-	pairsMatchedBefore  int
-}
-
-// queuedTelemetrySink implements game.TelemetrySink by enqueueing events and
-// persisting them in a background goroutine (batch insert), so the game loop
-// does not block on I/O.
-type queuedTelemetrySink struct {
-	store        storage.HistoryStore
-	mu           sync.Mutex
-	turnEvents   []turnEvent
-	arcanaEvents []arcanaEvent
-}
-
-// newQueuedTelemetrySink returns a sink that queues turn and arcana_use events.
-// Events are persisted only when FlushMatch(matchID) is called (after InsertGameResult).
-func newQueuedTelemetrySink(store storage.HistoryStore) *queuedTelemetrySink {
-	return &queuedTelemetrySink{
-		store:        store,
-		turnEvents:   nil,
-		arcanaEvents: nil,
-	}
-}
-
-// RecordTurn enqueues a turn event; non-blocking.
-func (s *queuedTelemetrySink) RecordTurn(matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent int) {
-	s.mu.Lock()
-	s.turnEvents = append(s.turnEvents, turnEvent{
-		matchID:            matchID,
-		round:              round,
-		playerIdx:          playerIdx,
-		playerScoreAfter:   playerScoreAfter,
-		opponentScoreAfter: opponentScoreAfter,
-		deltaPlayer:        deltaPlayer,
-		deltaOpponent:      deltaOpponent,
-	})
-	s.mu.Unlock()
-}
-
-// RecordArcanaUse enqueues an arcana use event; non-blocking.
-func (s *queuedTelemetrySink) RecordArcanaUse(matchID string, round, playerIdx int, powerUpID string, targetCardIndex int, playerScoreBefore, opponentScoreBefore, pairsMatchedBefore int) {
-	s.mu.Lock()
-	s.arcanaEvents = append(s.arcanaEvents, arcanaEvent{
-		matchID:             matchID,
-		round:               round,
-		playerIdx:           playerIdx,
-		powerUpID:           powerUpID,
-		targetCardIndex:     targetCardIndex,
-		playerScoreBefore:   playerScoreBefore,
-		opponentScoreBefore: opponentScoreBefore,
-		pairsMatchedBefore:  pairsMatchedBefore,
-	})
-	s.mu.Unlock()
-}
-
-// FlushMatch persists queued turn and arcana_use events for the given match.
-// Must be called after the game_history row exists (e.g. after InsertGameResult in OnGameEnd),
-// since turn and arcana_use reference game_history(id).
-//
-// Arcana point_delta is computed as the score change from the moment the card was used until
-// the end of that turn (for balance telemetry: direct and indirect impact of the card in the turn).
-func (s *queuedTelemetrySink) FlushMatch(matchID string) {
-	if s == nil {
-		return
-	}
-	s.mu.Lock()
-	var turns []turnEvent
-	var arcanas []arcanaEvent
-	for _, e := range s.turnEvents {
-		if e.matchID == matchID {
-			turns = append(turns, e)
-		}
-	}
-	for _, e := range s.arcanaEvents {
-		if e.matchID == matchID {
-			arcanas = append(arcanas, e)
-		}
-	}
-	// Remove flushed events from queue
-	newTurns := s.turnEvents[:0]
-	for _, e := range s.turnEvents {
-		if e.matchID != matchID {
-			newTurns = append(newTurns, e)
-		}
-	}
-	newArcanas := s.arcanaEvents[:0]
-	for _, e := range s.arcanaEvents {
-		if e.matchID != matchID {
-			newArcanas = append(newArcanas, e)
-		}
-	}
-	s.turnEvents = newTurns
-	s.arcanaEvents = newArcanas
-	s.mu.Unlock()
-	ctx := context.Background()
-	for _, e := range turns {
-		_ = s.store.InsertTurn(ctx, e.matchID, e.round, e.playerIdx, e.playerScoreAfter, e.opponentScoreAfter, e.deltaPlayer, e.deltaOpponent)
-	}
-	// Build round -> end-of-turn scores for this match (from turn events we just flushed).
-	endScoreByRound := make(map[int]struct{ score0, score1 int })
-	for _, t := range turns {
-		if t.playerIdx == 0 {
-			endScoreByRound[t.round] = struct{ score0, score1 int }{t.playerScoreAfter, t.opponentScoreAfter}
-		} else {
-			endScoreByRound[t.round] = struct{ score0, score1 int }{t.opponentScoreAfter, t.playerScoreAfter}
-		}
-	}
-	for _, e := range arcanas {
-		deltaPlayer, deltaOpponent := 0, 0
-		if end, ok := endScoreByRound[e.round]; ok {
-			// Delta from card use until end of turn for the player who used the card and the opponent.
-			if e.playerIdx == 0 {
-				deltaPlayer = end.score0 - e.playerScoreBefore
-				deltaOpponent = end.score1 - e.opponentScoreBefore
-			} else {
-				deltaPlayer = end.score1 - e.playerScoreBefore
-				deltaOpponent = end.score0 - e.opponentScoreBefore
-			}
-		}
-		_ = s.store.InsertArcanaUse(ctx, e.matchID, e.round, e.playerIdx, e.powerUpID, e.targetCardIndex, e.playerScoreBefore, e.opponentScoreBefore, e.pairsMatchedBefore, deltaPlayer, deltaOpponent)
-	}
-}
-
 // Matchmaker manages the queue of players waiting for a match.
 type Matchmaker struct {
-	waiting         map[*ws.Client]chan struct{} // client -> cancel channel (closed when client leaves queue)
+	entries         map[string]*queueEntry // queueKey -> entry, for both queues; guarded by waitMu
 	waitMu          sync.Mutex
 	notify          chan struct{} // buffered; signaled when a client is enqueued
-	pendingClient   *ws.Client    // client currently waiting for pair (not in waiting map)
-	pendingCancel   chan struct{} // closed when pending client cancels
-	pendingMu       sync.Mutex
+	realm           string        // realm whose players this matchmaker pairs; "" is the default realm
 	config          *config.Config
 	powerUps        game.PowerUpProvider
 	historyStore    storage.HistoryStore
@@ -181,28 +41,54 @@ type Matchmaker struct {
 	gameIDToClients     map[string][]*ws.Client // gameID -> clients to clear Game ref when game is removed
 	gameIDToHumanReady  map[string]chan struct{} // gameID -> channel closed when human sends board_ready (AI games only)
 	mu                  sync.RWMutex
+	stats               matchStats
+	persist             persistPipeline
+
+	rematchMu      sync.Mutex
+	rematchWaiting map[string]*rematchChallenge // recorded match ID -> challenge waiting for the other player
+
+	challengeMu sync.Mutex
+	challenges  map[string]*directChallenge // challenge ID -> direct challenge waiting for an answer
+
+	// shutdownAt is the shutdown deadline in Unix ms once Shutdown was called (0 while serving).
+	shutdownAt atomic.Int64
+	// persistInFlight counts games whose end-of-game writes are still running (see Shutdown).
+	persistInFlight atomic.Int64
+
+	// Online returns how many players are connected to the realm (the hub's connections), for the adaptive
+	// AI fallback (see aiPairTimeout). Optional; set before Run.
+	Online func() int
 }
 
 // NewMatchmaker creates a new Matchmaker. historyStore may be nil to disable game history persistence.
 // When historyStore is set, a shared queued telemetry sink is used; turn/arcana_use are persisted only
-// when a game ends (FlushMatch after InsertGameResult), since those tables reference game_history(id).
+// when a game ends (FlushMatch after InsertGameResult, written by the sink's flusher), since those tables
+// reference game_history(id).
 func NewMatchmaker(cfg *config.Config, pups game.PowerUpProvider, historyStore storage.HistoryStore) *Matchmaker {
-	var queuedSink *queuedTelemetrySink
-	if historyStore != nil {
-		queuedSink = newQueuedTelemetrySink(historyStore)
-	}
-	return &Matchmaker{
-		waiting:         make(map[*ws.Client]chan struct{}),
+	return NewRealmMatchmaker("", cfg, pups, historyStore)
+}
+
+// NewRealmMatchmaker creates a Matchmaker for one realm. cfg should be the realm's configuration
+// (config.ForRealm); games are rated and recorded within the realm.
+func NewRealmMatchmaker(realm string, cfg *config.Config, pups game.PowerUpProvider, historyStore storage.HistoryStore) *Matchmaker {
+	m := &Matchmaker{
+		entries:         make(map[string]*queueEntry),
 		notify:          make(chan struct{}, 1),
+		realm:           realm,
 		config:          cfg,
 		powerUps:        pups,
 		historyStore:    historyStore,
-		queuedSink:      queuedSink,
 		activeGames:        make(map[string]*game.Game),
 		userIDToGame:       make(map[string]string),
 		gameIDToClients:    make(map[string][]*ws.Client),
 		gameIDToHumanReady: make(map[string]chan struct{}),
+		rematchWaiting:     make(map[string]*rematchChallenge),
+		challenges:         make(map[string]*directChallenge),
 	}
+	if historyStore != nil {
+		m.queuedSink = newQueuedTelemetrySink(historyStore, &m.persist, &m.persistInFlight, cfg.TelemetryQueueMax)
+	}
+	return m
 }
 
 func generateRejoinToken() (string, error) {
@@ -213,47 +99,6 @@ func generateRejoinToken() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-// Enqueue adds a client to the matchmaking queue.
-func (m *Matchmaker) Enqueue(c *ws.Client) {
-	m.waitMu.Lock()
-	defer m.waitMu.Unlock()
-	if _, ok := m.waiting[c]; ok {
-		return // already in queue
-	}
-	m.waiting[c] = make(chan struct{})
-	slog.Info("started for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
-	select {
-	case m.notify <- struct{}{}:
-	default:
-	}
-}
-
-// LeaveQueue removes a client from the matchmaking queue. Idempotent.
-// The client may still be in waiting, or already be the "pending" client (taken by Run() and waiting for a second player or timeout).
-func (m *Matchmaker) LeaveQueue(c *ws.Client) {
-	m.waitMu.Lock()
-	ch, ok := m.waiting[c]
-	if ok {
-		delete(m.waiting, c)
-		m.waitMu.Unlock()
-		close(ch)
-		slog.Info("cancelled for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
-		return
-	}
-	m.waitMu.Unlock()
-
-	m.pendingMu.Lock()
-	if m.pendingClient == c && m.pendingCancel != nil {
-		close(m.pendingCancel)
-		m.pendingClient = nil
-		m.pendingCancel = nil
-		m.pendingMu.Unlock()
-		slog.Info("cancelled for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
-		return
-	}
-	m.pendingMu.Unlock()
-}
-
 // SignalHumanReady is called when the human client sends board_ready (intro dismissed).
 // For AI games, it closes the humanReady channel so the AI can start playing.
 func (m *Matchmaker) SignalHumanReady(gameID string) {
@@ -268,14 +113,14 @@ func (m *Matchmaker) SignalHumanReady(gameID string) {
 	m.mu.Unlock()
 }
 
-// Run is the matchmaker's main loop. It waits for a first player, then either
-// a second player within AIPairTimeoutSec or starts a game vs the AI.
-// Should be run as a goroutine. When ctx is cancelled (e.g. on server shutdown), Run returns.
+// Run is the matchmaker's scheduler. Each player picked from the queue is paired right away with the best
+// waiting player, if any, and otherwise gets a worker (waitForPartner) that waits for a partner or starts
+// a game vs the AI after AIPairTimeoutSec (adapted to the load, see aiPairTimeout), so any number of players can wait at once without delaying
+// each other's pairing or AI fallback. Players with the same region hint are paired first; other players
+// become eligible once either side has waited RegionFallbackSec. Ranked players are only paired within a
+// rating window that widens as they wait (RatingWindow, RatingWindowWidenPerSec).
+// Should be run as a goroutine. When ctx is cancelled (e.g. on server shutdown), Run and its workers return.
 func (m *Matchmaker) Run(ctx context.Context) {
-	timeout := time.Duration(m.config.AIPairTimeoutSec) * time.Second
-	if timeout < 0 {
-		timeout = 0
-	}
 	for {
 		select {
 		case <-ctx.Done():
@@ -285,81 +130,96 @@ func (m *Matchmaker) Run(ctx context.Context) {
 		case <-m.notify:
 		}
 		m.waitMu.Lock()
-		if len(m.waiting) == 0 {
-			m.waitMu.Unlock()
-			continue
-		}
-		var client1 *ws.Client
-		var cancelCh1 chan struct{}
-		for c, ch := range m.waiting {
-			client1 = c
-			cancelCh1 = ch
-			delete(m.waiting, c)
-			break
-		}
-		// If a second client is already waiting, pair immediately
-		var client2 *ws.Client
-		for c := range m.waiting {
-			client2 = c
-			delete(m.waiting, c)
-			break
+		var picked []*queueEntry
+		for _, e := range m.entries {
+			if e.mode.AIFallback && e.state == entryQueued {
+				e.state = entryPendingPair
+				picked = append(picked, e)
+			}
 		}
 		m.waitMu.Unlock()
-
-		if client2 != nil {
-			m.createGame(client1, client2)
-			continue
+		// Longest-waiting first, so that players who arrived together pair in queue order.
+		sort.Slice(picked, func(i, j int) bool { return picked[i].queuedAt.Before(picked[j].queuedAt) })
+		for _, e := range picked {
+			if !m.pairPending(e) {
+				go m.waitForPartner(ctx, e)
+			}
 		}
+	}
+}
 
-		// Register as pending so LeaveQueue can cancel us
-		m.pendingMu.Lock()
-		m.pendingClient = client1
-		m.pendingCancel = cancelCh1
-		m.pendingMu.Unlock()
-
+// waitForPartner is the worker of one pending entry: it retries pairing when the region fallback delay
+// ends and as its rating window widens, and starts a game vs the AI once the entry has been queued for
+// the wait chosen by aiPairTimeout. A newcomer pairs with the entry itself (pairPending), so the worker
+// needs no wake-up for arrivals. Returns when the entry leaves the queue (matched or left) or ctx is cancelled.
+func (m *Matchmaker) waitForPartner(ctx context.Context, e *queueEntry) {
+	timeout := m.aiPairTimeout(e)
+	aiTimer := time.NewTimer(time.Until(e.queuedAt.Add(timeout)))
+	defer aiTimer.Stop()
+	fallback := m.regionFallback(e)
+	widen, stopWiden := m.ratingWiden(e)
+	defer stopWiden()
+	for {
 		select {
 		case <-ctx.Done():
-			m.pendingMu.Lock()
-			m.pendingClient = nil
-			m.pendingCancel = nil
-			m.pendingMu.Unlock()
-			m.abandonAIGames()
 			return
-		case <-m.notify:
-			m.pendingMu.Lock()
-			m.pendingClient = nil
-			m.pendingCancel = nil
-			m.pendingMu.Unlock()
+		case <-e.done:
+			return
+		case <-fallback:
+			fallback = nil
+			if m.pairPending(e) {
+				return
+			}
+		case <-widen:
+			if m.pairPending(e) {
+				return
+			}
+		case <-aiTimer.C:
 			m.waitMu.Lock()
-			client2 = nil
-			for c := range m.waiting {
-				client2 = c
-				delete(m.waiting, c)
-				break
+			if e.state != entryPendingPair {
+				m.waitMu.Unlock()
+				return
 			}
+			clients := m.claim(e)
 			m.waitMu.Unlock()
-			if client2 != nil {
-				m.createGame(client1, client2)
-			} else {
-				m.createGameVsAI(client1)
-			}
-		case <-time.After(timeout):
-			m.pendingMu.Lock()
-			m.pendingClient = nil
-			m.pendingCancel = nil
-			m.pendingMu.Unlock()
-			m.createGameVsAI(client1)
-		case <-cancelCh1:
-			// client1 left queue (LeaveQueue closed the channel and logged)
-			m.pendingMu.Lock()
-			m.pendingClient = nil
-			m.pendingCancel = nil
-			m.pendingMu.Unlock()
+			m.createGameVsAI(clients[0])
+			return
 		}
 	}
 }
 
+// pairPending pairs the pending entry with the best waiting entry, if any. Returns true when the entry
+// needs no more waiting: a game was created, or it already left the queue.
+func (m *Matchmaker) pairPending(e1 *queueEntry) bool {
+	m.waitMu.Lock()
+	if e1.state != entryPendingPair {
+		m.waitMu.Unlock()
+		return true
+	}
+	e2 := m.takePartner(e1)
+	if e2 == nil {
+		m.waitMu.Unlock()
+		return false
+	}
+	// The partner waited longer or as long: it keeps seat 0, as when the queue was served in order.
+	first, second := e2, e1
+	if e1.queuedAt.Before(e2.queuedAt) {
+		first, second = e1, e2
+	}
+	clients := m.claim(first, second)
+	m.waitMu.Unlock()
+	m.createGame(clients[0], clients[1])
+	return true
+}
+
 func (m *Matchmaker) createGame(client1, client2 *ws.Client) {
+	m.createGameFrom(client1, client2, nil, modes.Get(client1.QueueMode))
+}
+
+// createGameFrom starts a game of the given mode (ranked or casual) with client1 in seat 0 and client2 in
+// seat 1, on the board size they queued for (the same for both). src is set for a rematch from history:
+// the game replays src's board, in src's mode, and is recorded but not rated.
+func (m *Matchmaker) createGameFrom(client1, client2 *ws.Client, src *storage.RematchSource, mode modes.Mode) {
 	matchID := uuid.New().String()
 
 	t0, _ := generateRejoinToken()
@@ -368,57 +228,22 @@ func (m *Matchmaker) createGame(client1, client2 *ws.Client) {
 	p0 := game.NewPlayer(client1.Name, client1.Send)
 	p1 := game.NewPlayer(client2.Name, client2.Send)
 
-	g := game.NewGame(matchID, m.config, p0, p1, m.powerUps)
+	g, err := m.newGame(matchID, p0, p1, m.boardSize(client1), src)
+	if err != nil {
+		m.gameNotCreated(matchID, err, client1, client2)
+		return
+	}
 	g.RejoinTokens[0] = t0
 	g.RejoinTokens[1] = t1
 	g.PlayerUserIDs[0] = client1.UserID
 	g.PlayerUserIDs[1] = client2.UserID
-	if m.historyStore != nil {
-		store := m.historyStore
-		g.TelemetrySink = m.queuedSink
-		g.OnGameEnd = func(matchID, p0UID, p1UID, p0Name, p1Name string, p0Score, p1Score int, winnerIdx int, endReason string, done func(elo0Before, elo0After, elo1Before, elo1After *int)) {
-			logMatchEnd(matchID, p0Name, p1Name, endReason, winnerIdx)
-			// Send game_over immediately so the client can show the result without waiting for DB/telemetry.
-			done(nil, nil, nil, nil)
-			go func() {
-				var e0Before, e0After, e1Before, e1After *int
-				if endReason == "completed" || endReason == "opponent_disconnected" {
-					eb0, ea0, eb1, ea1, err := store.UpdateRatingsAfterGame(context.Background(), p0UID, p1UID, p0Name, p1Name, winnerIdx)
-					if err == nil {
-						e0Before, e0After = &eb0, &ea0
-						e1Before, e1After = &eb1, &ea1
-					}
-				}
-				// Send rating to clients as soon as we have it; persistence below is independent.
-				for i := range 2 {
-					var before, after *int
-					if i == 0 {
-						before, after = e0Before, e0After
-					} else {
-						before, after = e1Before, e1After
-					}
-					if before != nil && after != nil && g.Players[i] != nil && g.Players[i].Send != nil {
-					payload := map[string]any{
-						"type":           "rating_update",
-						"you_elo_before": *before,
-						"you_elo_after":  *after,
-					}
-						data, _ := json.Marshal(payload)
-						wsutil.SafeSend(g.Players[i].Send, data)
-					}
-				}
-				// Persist game history and telemetry after having responded with rating.
-				_ = store.InsertGameResult(context.Background(), matchID, p0UID, p1UID, p0Name, p1Name, p0Score, p1Score, winnerIdx, endReason, e0Before, e0After, e1Before, e1After)
-				m.queuedSink.FlushMatch(matchID)
-				var powerUpIDs []string
-				for i := range 6 {
-					if id, ok := g.PairIDToPowerUp[i]; ok {
-						powerUpIDs = append(powerUpIDs, id)
-					}
-				}
-				_ = store.InsertMatchArcana(context.Background(), matchID, powerUpIDs)
-			}()
-		}
+	g.Mode = mode.ID
+	g.Draft = m.config.StartingDraftSec > 0
+	g.Assist[0], g.Assist[1] = client1.Assist, client2.Assist
+	g.ReportRTT(0, client1.RTT())
+	g.ReportRTT(1, client2.RTT())
+	if m.wireGameEnd(g, [2]string{client1.Region, client2.Region}, nil) {
+		m.checkpointGame(g)
 	}
 
 	m.mu.Lock()
@@ -433,76 +258,58 @@ func (m *Matchmaker) createGame(client1, client2 *ws.Client) {
 	client2.Game = g
 	client2.PlayerID = 1
 
-	slog.Info("Match created", "tag", "matchmaking", "match_id", matchID, "player1", client1.Name, "player2", client2.Name)
+	slog.Info("Match created", "tag", "matchmaking", "match_id", matchID, "player1", client1.Name, "player2", client2.Name, "rematch_of", rematchOf(src))
 
-	m.sendMatchFound(client1, client2.Name, g, 0)
-	m.sendMatchFound(client2, client1.Name, g, 1)
+	m.sendMatchFound(client1, client2.Name, "", g, 0)
+	m.sendMatchFound(client2, client1.Name, "", g, 1)
 
 	go func() {
+		m.saveRejoinTokens(g, 0, 1)
 		g.Run()
 		m.removeGame(matchID)
 	}()
 }
 
 func (m *Matchmaker) createGameVsAI(client1 *ws.Client) {
+	profiles := m.config.AIProfiles
+	if len(profiles) == 0 {
+		profiles = config.Defaults().AIProfiles
+	}
+	m.createGameVsAIFrom(client1, &profiles[rand.Intn(len(profiles))], nil)
+}
+
+// createGameVsAIFrom starts a game with client1 in seat 0 against the AI profile in seat 1, on the board
+// size client1 queued for, in client1's queue mode. src is set for a rematch from history, as in createGameFrom.
+func (m *Matchmaker) createGameVsAIFrom(client1 *ws.Client, profile *config.AIParams, src *storage.RematchSource) {
 	matchID := uuid.New().String()
 
 	t0, _ := generateRejoinToken()
 	t1, _ := generateRejoinToken()
 
-	profiles := m.config.AIProfiles
-	if len(profiles) == 0 {
-		profiles = config.Defaults().AIProfiles
-	}
-	profile := &profiles[rand.Intn(len(profiles))]
+	identity := pickAIIdentity(profile, client1.Name)
 
 	aiSend := make(chan []byte, 256)
 	p0 := game.NewPlayer(client1.Name, client1.Send)
-	p1 := game.NewPlayer(profile.Name, aiSend)
+	p1 := game.NewPlayer(identity.Name, aiSend)
 
-	g := game.NewGame(matchID, m.config, p0, p1, m.powerUps)
+	g, err := m.newGame(matchID, p0, p1, m.boardSize(client1), src)
+	if err != nil {
+		m.gameNotCreated(matchID, err, client1)
+		return
+	}
 	g.RejoinTokens[0] = t0
 	g.RejoinTokens[1] = t1
 	g.PlayerUserIDs[0] = client1.UserID
-	g.PlayerUserIDs[1] = "ai:" + profile.Name // fixed ID per bot for ELO and leaderboard
-	if m.historyStore != nil {
-		store := m.historyStore
-		g.TelemetrySink = m.queuedSink
-		g.OnGameEnd = func(matchID, p0UID, p1UID, p0Name, p1Name string, p0Score, p1Score int, winnerIdx int, endReason string, done func(elo0Before, elo0After, elo1Before, elo1After *int)) {
-			logMatchEnd(matchID, p0Name, p1Name, endReason, winnerIdx)
-			// Send game_over immediately so the client can show the result without waiting for DB/telemetry.
-			done(nil, nil, nil, nil)
-			go func() {
-				var e0Before, e0After, e1Before, e1After *int
-				if endReason == "completed" || endReason == "opponent_disconnected" {
-					eb0, ea0, eb1, ea1, err := store.UpdateRatingsAfterGame(context.Background(), p0UID, p1UID, p0Name, p1Name, winnerIdx)
-					if err == nil {
-						e0Before, e0After = &eb0, &ea0
-						e1Before, e1After = &eb1, &ea1
-					}
-				}
-				// Send rating to client as soon as we have it; persistence below is independent.
-				if e0Before != nil && e0After != nil && g.Players[0] != nil && g.Players[0].Send != nil {
-					payload := map[string]any{
-						"type":           "rating_update",
-						"you_elo_before": *e0Before,
-						"you_elo_after":  *e0After,
-					}
-					data, _ := json.Marshal(payload)
-					wsutil.SafeSend(g.Players[0].Send, data)
-				}
-				// Persist game history and telemetry after having responded with rating.
-				_ = store.InsertGameResult(context.Background(), matchID, p0UID, p1UID, p0Name, p1Name, p0Score, p1Score, winnerIdx, endReason, e0Before, e0After, e1Before, e1After)
-				m.queuedSink.FlushMatch(matchID)
-				var powerUpIDs []string
-				for i := range 6 {
-					if id, ok := g.PairIDToPowerUp[i]; ok {
-						powerUpIDs = append(powerUpIDs, id)
-					}
-				}
-				_ = store.InsertMatchArcana(context.Background(), matchID, powerUpIDs)
-			}()
-		}
+	g.PlayerUserIDs[1] = profile.UserID() // fixed ID per bot for ELO and leaderboard
+	g.Mode = modes.Get(client1.QueueMode).ID
+	if src != nil {
+		g.Mode = modes.Get(src.Mode).ID
+	}
+	g.Draft = m.config.StartingDraftSec > 0
+	g.Assist[0] = client1.Assist
+	g.ReportRTT(0, client1.RTT())
+	if m.wireGameEnd(g, [2]string{client1.Region, ""}, profile) {
+		m.checkpointGame(g)
 	}
 
 	humanReady := make(chan struct{})
@@ -517,41 +324,153 @@ func (m *Matchmaker) createGameVsAI(client1 *ws.Client) {
 	client1.Game = g
 	client1.PlayerID = 0
 
-	slog.Info("Match created (AI)", "tag", "matchmaking", "match_id", matchID, "player", client1.Name, "ai", profile.Name)
+	slog.Info("Match created (AI)", "tag", "matchmaking", "match_id", matchID, "player", client1.Name, "ai", profile.Name, "ai_name", identity.Name, "rematch_of", rematchOf(src))
 
-	m.sendMatchFound(client1, profile.Name, g, 0)
+	m.sendMatchFound(client1, identity.Name, identity.Avatar, g, 0)
 
 	go func() {
+		m.saveRejoinTokens(g, 0)
 		g.Run()
 		m.removeGame(matchID)
 	}()
-	go ai.Run(aiSend, g, 1, profile, humanReady)
+	go superviseAI(g, 1, profile.Name, func(known map[int]int) {
+		ai.RunWithKnowledge(aiSend, g, 1, profile, humanReady, known)
+	}, nil)
 }
 
-func (m *Matchmaker) sendMatchFound(client *ws.Client, opponentName string, g *game.Game, playerIdx int) {
+// createRaid starts a co-op raid: both clients share seat 0 as a team and take its turns in rotation,
+// against the configured raid AI on the raid board. The AI starts out knowing Raid.PeekTiles tiles.
+// Raids are unrated and not persisted to game history; members who disconnect cannot rejoin.
+func (m *Matchmaker) createRaid(client1, client2 *ws.Client) {
+	matchID := uuid.New().String()
+	raidCfg := m.config.Raid
+	profile := m.raidProfile()
+	identity := pickAIIdentity(profile, client1.Name, client2.Name)
+
+	team := game.NewTeam(
+		&game.TeamMember{Name: client1.Name, UserID: client1.UserID, Send: client1.Send},
+		&game.TeamMember{Name: client2.Name, UserID: client2.UserID, Send: client2.Send},
+	)
+	aiSend := make(chan []byte, 256)
+	p0 := game.NewPlayer(team.Names(), nil)
+	p1 := game.NewPlayer(identity.Name, aiSend)
+
+	g, err := game.NewGame(matchID, m.config, p0, p1, m.powerUps)
+	if err == nil {
+		err = config.ValidateBoard(raidCfg.BoardRows, raidCfg.BoardCols, m.config.MinPairsPerElement)
+	}
+	if err != nil {
+		m.gameNotCreated(matchID, err, client1, client2)
+		return
+	}
+	g.Board = game.NewPlacedBoard(raidCfg.BoardRows, raidCfg.BoardCols, game.ArcanaPairsPerMatch, raidCfg.ArcanaPlacement)
+	g.Teams[0] = team
+	g.Mode = modes.Raid
+	g.PlayerUserIDs[1] = profile.UserID()
+	m.wireGameEnd(g, [2]string{}, profile)
+	known := peekTiles(g.Board, raidCfg.PeekTiles)
+
+	humanReady := make(chan struct{})
+
+	m.mu.Lock()
+	m.activeGames[matchID] = g
+	m.gameIDToClients[matchID] = []*ws.Client{client1, client2}
+	m.gameIDToHumanReady[matchID] = humanReady
+	m.mu.Unlock()
+
+	for i, cl := range []*ws.Client{client1, client2} {
+		cl.Game = g
+		cl.PlayerID = 0
+		cl.TeamMember = i
+	}
+
+	slog.Info("Match created (raid)", "tag", "matchmaking", "match_id", matchID, "team", p0.Name, "ai", profile.Name)
+
+	for i, cl := range []*ws.Client{client1, client2} {
+		msg := ws.MatchFoundMsg{
+			Type:             "match_found",
+			GameID:           matchID,
+			OpponentName:     identity.Name,
+			OpponentAvatar:   identity.Avatar,
+			OpponentUserID:   g.PlayerUserIDs[1],
+			BoardRows:        g.Board.Rows,
+			BoardCols:        g.Board.Cols,
+			YourTurn:         g.CurrentTurn == 0 && i == team.Active,
+			Raid:             &ws.RaidInfo{Members: []string{client1.Name, client2.Name}, YourMemberIdx: i},
+			RevealDurationMS: g.RevealDurationMS(),
+			MismatchRetries:  g.Config.MismatchRetries,
+			Mode:             g.Mode,
+		}
+		data, _ := json.Marshal(msg)
+		wsutil.SafeSend(cl.Send, data)
+	}
+
+	go func() {
+		g.Run()
+		m.removeGame(matchID)
+	}()
+	go superviseAI(g, 1, profile.Name, func(known map[int]int) {
+		ai.RunWithKnowledge(aiSend, g, 1, profile, humanReady, known)
+	}, known)
+}
+
+// raidProfile returns the AI profile named by Raid.AIProfile, or the first configured profile. A profile
+// renamed by a realm skin is still found by its original name, kept as its ID.
+func (m *Matchmaker) raidProfile() *config.AIParams {
+	profiles := m.config.AIProfiles
+	if len(profiles) == 0 {
+		profiles = config.Defaults().AIProfiles
+	}
+	for i := range profiles {
+		if strings.EqualFold(profiles[i].Name, m.config.Raid.AIProfile) || strings.EqualFold(profiles[i].ID, m.config.Raid.AIProfile) {
+			return &profiles[i]
+		}
+	}
+	return &profiles[0]
+}
+
+// peekTiles picks n random tiles of a fresh board and returns index -> pairID (the raid AI's head start).
+func peekTiles(board *game.Board, n int) map[int]int {
+	known := make(map[int]int, n)
+	for _, idx := range rand.Perm(len(board.Cards)) {
+		if len(known) >= n {
+			break
+		}
+		known[idx] = board.Cards[idx].PairID
+	}
+	return known
+}
+
+func (m *Matchmaker) sendMatchFound(client *ws.Client, opponentName, opponentAvatar string, g *game.Game, playerIdx int) {
 	yourTurn := playerIdx == g.CurrentTurn
 	token := ""
 	if playerIdx >= 0 && playerIdx <= 1 {
 		token = g.RejoinTokens[playerIdx]
 	}
 	msg := ws.MatchFoundMsg{
-		Type:           "match_found",
-		GameID:         g.ID,
-		RejoinToken:    token,
-		OpponentName:   opponentName,
-		OpponentUserID: g.PlayerUserIDs[1-playerIdx],
-		BoardRows:      m.config.BoardRows,
-		BoardCols:      m.config.BoardCols,
-		YourTurn:       yourTurn,
+		Type:             "match_found",
+		GameID:           g.ID,
+		RejoinToken:      token,
+		OpponentName:     opponentName,
+		OpponentAvatar:   opponentAvatar,
+		OpponentUserID:   g.PlayerUserIDs[1-playerIdx],
+		BoardRows:        g.Board.Rows,
+		BoardCols:        g.Board.Cols,
+		YourTurn:         yourTurn,
+		RevealDurationMS: g.RevealDurationMS(),
+		MismatchRetries:  g.Config.MismatchRetries,
+		RematchOf:        g.RematchOf,
+		Casual:           g.Mode == modes.Casual,
+		Mode:             g.Mode,
 	}
 	if m.historyStore != nil {
 		ctx := context.Background()
-		if e, err := m.historyStore.GetLeaderboardEntryByUserID(ctx, client.UserID); err == nil && e != nil {
+		if e, err := m.historyStore.GetLeaderboardEntryByUserID(ctx, m.realm, client.UserID); err == nil && e != nil {
 			msg.YourElo = &e.Elo
 		}
 		opponentUID := g.PlayerUserIDs[1-playerIdx]
 		if opponentUID != "" {
-			if e, err := m.historyStore.GetLeaderboardEntryByUserID(ctx, opponentUID); err == nil && e != nil {
+			if e, err := m.historyStore.GetLeaderboardEntryByUserID(ctx, m.realm, opponentUID); err == nil && e != nil {
 				msg.OpponentElo = &e.Elo
 			}
 		}
@@ -560,6 +479,16 @@ func (m *Matchmaker) sendMatchFound(client *ws.Client, opponentName string, g *g
 	wsutil.SafeSend(client.Send, data)
 }
 
+// configSnapshot returns the game's effective rules as JSON for game_history.config_snapshot.
+func configSnapshot(g *game.Game) []byte {
+	data, err := json.Marshal(g.ConfigSnapshot())
+	if err != nil {
+		slog.Warn("could not encode config snapshot", "tag", "matchmaking", "match_id", g.ID, "err", err)
+		return nil
+	}
+	return data
+}
+
 // logMatchEnd logs match end with match_id, end_reason and winner (or "draw").
 func logMatchEnd(matchID, p0Name, p1Name, endReason string, winnerIdx int) {
 	winner := "draw"
@@ -572,6 +501,16 @@ func logMatchEnd(matchID, p0Name, p1Name, endReason string, winnerIdx int) {
 	slog.Info("Match ended", "tag", "matchmaking", "match_id", matchID, "end_reason", endReason, "winner", winner)
 }
 
+// gameNotCreated reports a game that could not be set up (its board failed validation) to the clients
+// that were matched for it. They are out of the queue and can queue again.
+func (m *Matchmaker) gameNotCreated(matchID string, err error, clients ...*ws.Client) {
+	slog.Error("could not create game", "tag", "matchmaking", "match_id", matchID, "err", err)
+	data, _ := json.Marshal(map[string]string{"type": "error", "message": "Could not start the game."})
+	for _, c := range clients {
+		wsutil.SafeSend(c.Send, data)
+	}
+}
+
 func (m *Matchmaker) removeGame(gameID string) {
 	m.mu.Lock()
 	g := m.activeGames[gameID]
@@ -580,18 +519,33 @@ func (m *Matchmaker) removeGame(gameID string) {
 	delete(m.gameIDToClients, gameID)
 	delete(m.gameIDToHumanReady, gameID)
 	if g != nil {
-		for i := range 2 {
-			if g.PlayerUserIDs[i] != "" {
-				delete(m.userIDToGame, g.PlayerUserIDs[i])
+		// Only the players still indexed to this game: party games are not indexed at all, and a player may
+		// have moved on to another game (e.g. left a party for a 1v1) that keeps its rejoin by user.
+		for _, uid := range g.PlayerUserIDs {
+			if uid != "" && m.userIDToGame[uid] == gameID {
+				delete(m.userIDToGame, uid)
 			}
 		}
 	}
 	m.mu.Unlock()
-	// Clear client Game refs so they can Find game again (e.g. after opponent_disconnected)
+	if g != nil && g.Finished {
+		m.stats.recordGame(time.Now())
+	}
+	if m.historyStore != nil {
+		if err := m.historyStore.DeleteRejoinTokens(context.Background(), gameID); err != nil {
+			slog.Warn("could not delete rejoin tokens", "tag", "matchmaking", "match_id", gameID, "error", err)
+		}
+		if err := m.historyStore.DeleteActiveGame(context.Background(), gameID); err != nil {
+			slog.Warn("could not delete game snapshot", "tag", "matchmaking", "match_id", gameID, "error", err)
+		}
+	}
+	// Clear client Game refs so they can Find game again (e.g. after opponent_disconnected). A client that
+	// already moved on to another game (play_again before this teardown) keeps its new reference.
 	for _, cl := range clients {
-		if cl != nil {
+		if cl != nil && (cl.Game == nil || cl.Game.ID == gameID) {
 			cl.Game = nil
 			cl.PlayerID = 0
+			cl.TeamMember = 0
 		}
 	}
 }
@@ -617,6 +571,21 @@ func (m *Matchmaker) abandonAIGames() {
 	}
 }
 
+// saveRejoinTokens persists the rejoin tokens of the given human seats, so rejoin attempts can still be
+// recognised after a restart. Runs before the game loop starts; a failure only costs that recognition.
+func (m *Matchmaker) saveRejoinTokens(g *game.Game, seats ...int) {
+	if m.historyStore == nil {
+		return
+	}
+	tokens := make([]storage.RejoinToken, 0, len(seats))
+	for _, seat := range seats {
+		tokens = append(tokens, storage.RejoinToken{MatchID: g.ID, Seat: seat, UserID: g.PlayerUserIDs[seat], Token: g.RejoinTokens[seat]})
+	}
+	if err := m.historyStore.SaveRejoinTokens(context.Background(), tokens); err != nil {
+		slog.Warn("could not persist rejoin tokens", "tag", "matchmaking", "match_id", g.ID, "error", err)
+	}
+}
+
 // Rejoin looks up a game by ID and rejoin token, and returns the game and player index if the token
 // matches the disconnected player. Caller must then attach the client and send ActionRejoinCompleted.
 func (m *Matchmaker) Rejoin(gameID, rejoinToken, name string) (*game.Game, int, error) {
@@ -624,7 +593,17 @@ func (m *Matchmaker) Rejoin(gameID, rejoinToken, name string) (*game.Game, int,
 	g, ok := m.activeGames[gameID]
 	m.mu.RUnlock()
 	if !ok || g == nil {
-		return nil, -1, matcherrors.ErrGameNotFound
+		if m.historyStore == nil {
+			return nil, -1, matcherrors.ErrGameNotFound
+		}
+		t, err := m.historyStore.FindRejoinToken(context.Background(), gameID, rejoinToken)
+		if err != nil || t == nil {
+			return nil, -1, matcherrors.ErrGameNotFound
+		}
+		// The game was interrupted by a restart: resume it from its last snapshot.
+		if g, err = m.resumeGame(t); err != nil {
+			return nil, -1, err
+		}
 	}
 	if g.Finished {
 		return nil, -1, matcherrors.ErrGameFinished
@@ -654,12 +633,24 @@ func (m *Matchmaker) RejoinByUser(userID string) (*game.Game, int, string, error
 	m.mu.RLock()
 	gameID, ok := m.userIDToGame[userID]
 	m.mu.RUnlock()
+	var g *game.Game
 	if !ok || gameID == "" {
-		return nil, -1, "", matcherrors.ErrNoActiveGame
+		if m.historyStore == nil {
+			return nil, -1, "", matcherrors.ErrNoActiveGame
+		}
+		t, err := m.historyStore.FindRejoinTokenByUser(context.Background(), userID)
+		if err != nil || t == nil {
+			return nil, -1, "", matcherrors.ErrNoActiveGame
+		}
+		// The game was interrupted by a restart: resume it from its last snapshot.
+		if g, err = m.resumeGame(t); err != nil {
+			return nil, -1, "", err
+		}
+	} else {
+		m.mu.RLock()
+		g = m.activeGames[gameID]
+		m.mu.RUnlock()
 	}
-	m.mu.RLock()
-	g := m.activeGames[gameID]
-	m.mu.RUnlock()
 	if g == nil {
 		return nil, -1, "", matcherrors.ErrGameNotFound
 	}
@@ -8,23 +8,10 @@ import (
 
 	"memory-game-server/config"
 	"memory-game-server/game"
+	"memory-game-server/powerup"
 	"memory-game-server/ws"
 )
 
-type mockPowerUpProvider struct{}
-
-func (m *mockPowerUpProvider) GetPowerUp(id string) (game.PowerUpDef, bool) {
-	return game.PowerUpDef{}, false
-}
-
-func (m *mockPowerUpProvider) AllPowerUps() []game.PowerUpDef {
-	return nil
-}
-
-func (m *mockPowerUpProvider) PickArcanaForMatch(n int) []game.PowerUpDef {
-	return nil
-}
-
 // mockClient creates a client-like struct for testing.
 // Since Client depends on websocket.Conn and Hub, we need to work around this.
 // We'll test the matchmaker's Enqueue behavior and pairing.
@@ -41,7 +28,7 @@ func TestMatchmakerPairsPlayers(t *testing.T) {
 		AIProfiles:         []config.AIParams{{Name: "Mnemosyne", DelayMinMS: 100, DelayMaxMS: 500, UseBestMoveChance: 85, ArcanaRandomness: 0}},
 	}
 
-	pups := &mockPowerUpProvider{}
+	pups := powerup.NewBuiltinRegistry(nil, 1)
 	mm := NewMatchmaker(cfg, pups, nil)
 	go mm.Run(context.Background())
 
@@ -117,7 +104,7 @@ func TestMatchmakerPairsWithAIAfterTimeout(t *testing.T) {
 		AIProfiles:         []config.AIParams{{Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 50, UseBestMoveChance: 85, ArcanaRandomness: 0}},
 	}
 
-	pups := &mockPowerUpProvider{}
+	pups := powerup.NewBuiltinRegistry(nil, 1)
 	mm := NewMatchmaker(cfg, pups, nil)
 	go mm.Run(context.Background())
 
@@ -162,7 +149,7 @@ func TestMatchmakerLeaveQueue(t *testing.T) {
 		AIProfiles:         []config.AIParams{{Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 50, UseBestMoveChance: 85, ArcanaRandomness: 0}},
 	}
 
-	pups := &mockPowerUpProvider{}
+	pups := powerup.NewBuiltinRegistry(nil, 1)
 	mm := NewMatchmaker(cfg, pups, nil)
 	go mm.Run(context.Background())
 
@@ -192,3 +179,113 @@ func TestMatchmakerLeaveQueue(t *testing.T) {
 		// expected: no match
 	}
 }
+
+func TestMatchmakerRaidTeamsTwoClients(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:        2,
+		BoardCols:        2,
+		RevealDurationMS: 100,
+		MaxNameLength:    24,
+		AIPairTimeoutSec: 60,
+		AIProfiles:       []config.AIParams{{Name: "Calliope", DelayMinMS: 10, DelayMaxMS: 50}, {Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 50}},
+		Raid:             config.RaidConfig{BoardRows: 4, BoardCols: 4, AIProfile: "Mnemosyne", PeekTiles: 2},
+	}
+
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+
+	send1 := make(chan []byte, 100)
+	send2 := make(chan []byte, 100)
+	c1 := &ws.Client{Send: send1, Name: "Alice"}
+	c2 := &ws.Client{Send: send2, Name: "Carol"}
+
+	mm.EnqueueRaid(c1)
+	if c1.Game != nil {
+		t.Fatal("a raid should not start with a single client")
+	}
+	mm.EnqueueRaid(c2)
+
+	if c1.Game == nil || c1.Game != c2.Game {
+		t.Fatal("both clients should share the raid game")
+	}
+	g := c1.Game
+	if c1.PlayerID != 0 || c2.PlayerID != 0 || c1.TeamMember != 0 || c2.TeamMember != 1 {
+		t.Errorf("expected both on seat 0 as members 0 and 1, got seat %d/%d member %d/%d", c1.PlayerID, c2.PlayerID, c1.TeamMember, c2.TeamMember)
+	}
+	if g.Teams[0] == nil || len(g.Teams[0].Members) != 2 {
+		t.Fatal("expected seat 0 to be a two-member team")
+	}
+	if len(g.Board.Cards) != 16 {
+		t.Errorf("expected the raid board (16 cards), got %d", len(g.Board.Cards))
+	}
+	if g.PlayerUserIDs[1] != "ai:Mnemosyne" {
+		t.Errorf("expected the configured raid AI, got %q", g.PlayerUserIDs[1])
+	}
+
+	for i, ch := range []chan []byte{send1, send2} {
+		var mf ws.MatchFoundMsg
+		json.Unmarshal(<-ch, &mf)
+		if mf.Type != "match_found" || mf.Raid == nil || mf.Raid.YourMemberIdx != i || len(mf.Raid.Members) != 2 {
+			t.Errorf("member %d: expected raid match_found, got %+v", i, mf)
+		}
+		if mf.BoardRows != 4 || mf.BoardCols != 4 {
+			t.Errorf("member %d: expected raid board 4x4, got %dx%d", i, mf.BoardRows, mf.BoardCols)
+		}
+	}
+
+	g.Actions <- game.Action{Type: game.ActionDisconnect, PlayerIdx: 1}
+}
+
+func TestMatchmakerLeaveRaidQueue(t *testing.T) {
+	cfg := &config.Config{MaxNameLength: 24, Raid: config.RaidConfig{BoardRows: 4, BoardCols: 4}}
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+
+	c1 := &ws.Client{Send: make(chan []byte, 10), Name: "Alice"}
+	c2 := &ws.Client{Send: make(chan []byte, 10), Name: "Carol"}
+
+	mm.EnqueueRaid(c1)
+	mm.LeaveQueue(c1)
+	mm.EnqueueRaid(c2)
+
+	if c1.Game != nil || c2.Game != nil {
+		t.Error("no raid should start after the first client left the queue")
+	}
+}
+
+// awaitGame waits up to within for c's match_found and returns c.Game. Receiving the message orders the
+// read after the matchmaker assigned the game, so tests do not race it by reading c.Game directly.
+func awaitGame(t *testing.T, c *ws.Client, within time.Duration) *game.Game {
+	t.Helper()
+	deadline := time.After(within)
+	for {
+		select {
+		case data := <-c.Send:
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(data, &msg) == nil && msg.Type == "match_found" {
+				return c.Game
+			}
+		case <-deadline:
+			t.Fatalf("expected %s to get a game", c.Name)
+			return nil
+		}
+	}
+}
+
+// noGame fails when c has been sent a match_found, without reading c.Game.
+func noGame(t *testing.T, c *ws.Client) {
+	t.Helper()
+	for {
+		select {
+		case data := <-c.Send:
+			var msg struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(data, &msg) == nil && msg.Type == "match_found" {
+				t.Fatalf("expected %s to keep waiting, got a game", c.Name)
+			}
+		default:
+			return
+		}
+	}
+}
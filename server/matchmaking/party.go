@@ -0,0 +1,116 @@
+package matchmaking
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"memory-game-server/game"
+	"memory-game-server/modes"
+	"memory-game-server/ws"
+	"memory-game-server/wsutil"
+
+	"github.com/google/uuid"
+)
+
+// EnqueueParty adds a client to the party queue for its PartySize. As soon as that many clients wait for
+// the same party size and board size, the longest-waiting of them start a party game. Like raids, there is
+// no timeout or AI fallback: a party needs humans in every seat.
+func (m *Matchmaker) EnqueueParty(c *ws.Client) {
+	if !m.enqueue(c, false) {
+		return
+	}
+	m.waitMu.Lock()
+	entry, ok := m.entries[queueKey(c)]
+	if !ok || entry.party == 0 {
+		m.waitMu.Unlock()
+		return
+	}
+	var waiting []*queueEntry
+	for _, e := range m.entries {
+		if e.party == entry.party && e.board == entry.board && e.state == entryQueued {
+			waiting = append(waiting, e)
+		}
+	}
+	if len(waiting) < entry.party {
+		m.waitMu.Unlock()
+		return
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].queuedAt.Before(waiting[j].queuedAt) })
+	clients := m.claim(waiting[:entry.party]...)
+	m.waitMu.Unlock()
+	m.createParty(clients)
+}
+
+// createParty starts a party game with the clients seated in queue order; the first turn goes to a random
+// seat. Party games are unrated and not written to history, and cannot be rejoined: a player who leaves or
+// disconnects is out, and the others play on until the board is cleared or one player is left.
+func (m *Matchmaker) createParty(clients []*ws.Client) {
+	matchID := uuid.New().String()
+	players := make([]*game.Player, len(clients))
+	names := make([]string, len(clients))
+	for i, cl := range clients {
+		players[i] = game.NewPlayer(cl.Name, cl.Send)
+		names[i] = cl.Name
+	}
+	g, err := game.NewPartyGame(matchID, m.boardConfig(m.boardSize(clients[0])), players, m.powerUps)
+	if err != nil {
+		m.gameNotCreated(matchID, err, clients...)
+		return
+	}
+	for i, cl := range clients {
+		g.PlayerUserIDs[i] = cl.UserID
+		g.Assist[i] = cl.Assist
+		g.ReportRTT(i, cl.RTT())
+	}
+	g.Mode = modes.Party
+	g.Draft = m.config.StartingDraftSec > 0
+	m.wireGameEnd(g, [2]string{}, nil)
+
+	m.mu.Lock()
+	m.activeGames[matchID] = g
+	m.gameIDToClients[matchID] = clients
+	m.mu.Unlock()
+
+	for i, cl := range clients {
+		cl.Game = g
+		cl.PlayerID = i
+		cl.TeamMember = 0
+	}
+
+	slog.Info("Match created (party)", "tag", "matchmaking", "match_id", matchID, "players", strings.Join(names, ", "))
+
+	for i, cl := range clients {
+		msg := ws.MatchFoundMsg{
+			Type:             "match_found",
+			GameID:           matchID,
+			OpponentName:     otherNames(g, i),
+			BoardRows:        g.Board.Rows,
+			BoardCols:        g.Board.Cols,
+			YourTurn:         g.CurrentTurn == i,
+			RevealDurationMS: g.RevealDurationMS(),
+			MismatchRetries:  g.Config.MismatchRetries,
+			Party:            &ws.PartyInfo{Players: names, YourSeat: i},
+			Mode:             g.Mode,
+		}
+		data, _ := json.Marshal(msg)
+		wsutil.SafeSend(cl.Send, data)
+	}
+
+	go func() {
+		g.Run()
+		m.removeGame(matchID)
+	}()
+}
+
+// otherNames lists the names of every seat of g but seat, for a party game's opponentName.
+func otherNames(g *game.Game, seat int) string {
+	others := make([]string, 0, len(g.Players)-1)
+	for i, p := range g.Players {
+		if i != seat {
+			others = append(others, p.Name)
+		}
+	}
+	return strings.Join(others, ", ")
+}
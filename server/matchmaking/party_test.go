@@ -0,0 +1,68 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+func TestMatchmakerPartyGroupsSameSize(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:        4,
+		BoardCols:        4,
+		RevealDurationMS: 100,
+		MaxNameLength:    24,
+		AIPairTimeoutSec: 0,
+		AIProfiles:       []config.AIParams{{Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 50}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	party := func(name string, size int) *ws.Client {
+		return &ws.Client{Send: make(chan []byte, 100), Name: name, QueueMode: ws.QueueModeParty, PartySize: size}
+	}
+	alice, bob, carol := party("Alice", 3), party("Bob", 3), party("Carol", 3)
+	dave := party("Dave", 4)
+
+	mm.EnqueueParty(alice)
+	mm.EnqueueParty(dave)
+	mm.EnqueueParty(bob)
+	time.Sleep(100 * time.Millisecond)
+	if alice.Game != nil || bob.Game != nil || dave.Game != nil {
+		t.Fatal("expected party players to wait for a full party, without an AI fallback")
+	}
+	mm.EnqueueParty(carol)
+
+	g := alice.Game
+	if g == nil || bob.Game != g || carol.Game != g || len(g.Players) != 3 {
+		t.Fatal("expected Alice, Bob and Carol in one 3-player game")
+	}
+	if dave.Game != nil {
+		t.Error("expected Dave to keep waiting for a 4-player party")
+	}
+	for seat, c := range []*ws.Client{alice, bob, carol} {
+		if c.PlayerID != seat {
+			t.Errorf("expected %s in seat %d, got %d", c.Name, seat, c.PlayerID)
+		}
+		var mf ws.MatchFoundMsg
+		json.Unmarshal(<-c.Send, &mf)
+		if mf.Type != "match_found" || mf.Party == nil || mf.Party.YourSeat != seat || len(mf.Party.Players) != 3 {
+			t.Errorf("%s: expected party match_found, got %+v", c.Name, mf)
+		}
+	}
+	if st := mm.Status(dave); !st.InQueue || st.QueueMode != ws.QueueModeParty {
+		t.Errorf("expected Dave in the party queue, got %+v", st)
+	}
+
+	for seat := range 3 {
+		g.Actions <- game.Action{Type: game.ActionDisconnect, PlayerIdx: seat}
+	}
+}
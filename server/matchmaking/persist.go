@@ -0,0 +1,132 @@
+package matchmaking
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// End-of-game write steps, as reported by PersistStats.
+const (
+	persistUpdateRatings = "update_ratings"
+	persistGameResult    = "insert_game_result"
+	persistMatchArcana   = "insert_match_arcana"
+	persistMatchLatency  = "insert_match_latency"
+	persistBlindPlay     = "insert_blind_play"
+	persistFastFlips     = "insert_fast_flips"
+
+	// Queued telemetry, written by the flusher once the game_history row exists (see queuedTelemetrySink).
+	persistTurns         = "insert_turns"
+	persistScoreSeries   = "cache_score_series"
+	persistArcanaUses    = "insert_arcana_uses"
+	persistHandOverflows = "insert_hand_overflows"
+	persistPityGrants    = "insert_pity_grants"
+	persistDraftPicks    = "insert_draft_picks"
+	persistReplayEvents  = "insert_replay_events"
+)
+
+const (
+	persistAttempts = 3
+	persistTimeout  = 5 * time.Second
+)
+
+// persistBackoff is the pause before the first retry; it doubles on each further attempt.
+var persistBackoff = 200 * time.Millisecond
+
+// PersistStepStats counts one kind of end-of-game write since the server started.
+type PersistStepStats struct {
+	Step string
+	// Succeeded and Failed count writes that eventually succeeded and writes given up after every attempt.
+	Succeeded int64
+	Failed    int64
+	// Retries counts attempts beyond the first, whatever their outcome.
+	Retries int64
+	// AvgLatency and MaxLatency cover successful writes, retries included.
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+}
+
+type persistStep struct {
+	succeeded, failed, retries int64
+	latencyTotal, latencyMax   time.Duration
+}
+
+// persistPipeline runs the writes that follow a game (ratings, history, arcana, latency, score series,
+// queued telemetry) with retries, and counts their outcomes. Every write is idempotent per match or
+// all-or-nothing, so retrying one that failed is harmless.
+type persistPipeline struct {
+	mu    sync.Mutex
+	steps map[string]*persistStep
+}
+
+// do runs write for matchID up to persistAttempts times, each with its own timeout. When every attempt
+// fails the write is dead-lettered: logged at error level with the match, step and attrs, so it can be
+// replayed by hand. Returns the last error.
+func (p *persistPipeline) do(matchID, step string, write func(ctx context.Context) error, attrs ...any) error {
+	start := time.Now()
+	backoff := persistBackoff
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+		err = write(ctx)
+		cancel()
+		if err == nil || attempt == persistAttempts {
+			break
+		}
+		slog.Warn("end-of-game write failed, retrying", "tag", "persist", "match_id", matchID, "step", step, "attempt", attempt, "err", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	p.record(step, err == nil, attempt-1, time.Since(start))
+	if err != nil {
+		args := append([]any{"tag", "persist", "match_id", matchID, "step", step, "attempts", attempt, "err", err}, attrs...)
+		slog.Error("end-of-game write dead-lettered", args...)
+	}
+	return err
+}
+
+func (p *persistPipeline) record(step string, ok bool, retries int, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.steps == nil {
+		p.steps = make(map[string]*persistStep)
+	}
+	s := p.steps[step]
+	if s == nil {
+		s = &persistStep{}
+		p.steps[step] = s
+	}
+	s.retries += int64(retries)
+	if !ok {
+		s.failed++
+		return
+	}
+	s.succeeded++
+	s.latencyTotal += latency
+	s.latencyMax = max(s.latencyMax, latency)
+}
+
+// PersistStats returns the end-of-game write counters per step, sorted by step name.
+func (m *Matchmaker) PersistStats() []PersistStepStats {
+	m.persist.mu.Lock()
+	defer m.persist.mu.Unlock()
+	out := make([]PersistStepStats, 0, len(m.persist.steps))
+	for name, s := range m.persist.steps {
+		st := PersistStepStats{Step: name, Succeeded: s.succeeded, Failed: s.failed, Retries: s.retries, MaxLatency: s.latencyMax}
+		if s.succeeded > 0 {
+			st.AvgLatency = s.latencyTotal / time.Duration(s.succeeded)
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Step < out[j].Step })
+	return out
+}
+
+// TelemetryQueueStats returns the size and eviction counters of the queued telemetry (zero without a
+// history store).
+func (m *Matchmaker) TelemetryQueueStats() TelemetryQueueStats {
+	return m.queuedSink.Stats()
+}
@@ -0,0 +1,45 @@
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPersistPipelineRetriesAndDeadLetters(t *testing.T) {
+	old := persistBackoff
+	persistBackoff = time.Millisecond
+	defer func() { persistBackoff = old }()
+
+	mm := &Matchmaker{}
+	calls := 0
+	err := mm.persist.do("m1", persistGameResult, func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("expected success on the second attempt, got err %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = mm.persist.do("m2", persistGameResult, func(ctx context.Context) error {
+		calls++
+		return errors.New("database unavailable")
+	})
+	if err == nil || calls != persistAttempts {
+		t.Fatalf("expected failure after %d attempts, got err %v after %d calls", persistAttempts, err, calls)
+	}
+
+	stats := mm.PersistStats()
+	if len(stats) != 1 || stats[0].Step != persistGameResult {
+		t.Fatalf("expected one insert_game_result entry, got %+v", stats)
+	}
+	st := stats[0]
+	if st.Succeeded != 1 || st.Failed != 1 || st.Retries != int64(1+persistAttempts-1) {
+		t.Errorf("unexpected counters %+v", st)
+	}
+}
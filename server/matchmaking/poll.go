@@ -0,0 +1,31 @@
+package matchmaking
+
+import (
+	"context"
+	"slices"
+
+	"memory-game-server/matcherrors"
+	"memory-game-server/ws"
+)
+
+// AnswerPoll stores the client's answer to an experiment poll asked after their last game. The poll must
+// still be active and the answer one of its options. Without a history store the answer is dropped.
+func (m *Matchmaker) AnswerPoll(c *ws.Client, pollID, answer string) error {
+	g := c.Game
+	if g == nil || !g.Finished {
+		return matcherrors.ErrGameNotFound
+	}
+	poll, ok := m.config.ActivePoll(pollID)
+	if !ok {
+		return matcherrors.ErrUnknownPoll
+	}
+	if !slices.Contains(poll.PollOptions(), answer) {
+		return matcherrors.ErrInvalidPollAnswer
+	}
+	if m.historyStore == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+	defer cancel()
+	return m.historyStore.InsertPollResponse(ctx, g.ID, pollID, c.PlayerID, c.TeamMember, c.UserID, answer)
+}
@@ -0,0 +1,44 @@
+package matchmaking
+
+import (
+	"errors"
+	"testing"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/matcherrors"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+func TestAnswerPollValidatesPollAndAnswer(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:   2,
+		BoardCols:   2,
+		Experiments: []config.ExperimentConfig{{ID: "fun", Active: true, Question: "Was this match fun?"}},
+	}
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	c := &ws.Client{Send: make(chan []byte, 10), Name: "Alice"}
+
+	if err := mm.AnswerPoll(c, "fun", "yes"); !errors.Is(err, matcherrors.ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound without a finished game, got %v", err)
+	}
+	g, err := game.NewGame("poll-1", cfg, game.NewPlayer("Alice", nil), game.NewPlayer("Bob", nil), powerup.NewBuiltinRegistry(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Game = g
+	if err := mm.AnswerPoll(c, "fun", "yes"); !errors.Is(err, matcherrors.ErrGameNotFound) {
+		t.Errorf("expected ErrGameNotFound while the game is running, got %v", err)
+	}
+	g.Finished = true
+	if err := mm.AnswerPoll(c, "other", "yes"); !errors.Is(err, matcherrors.ErrUnknownPoll) {
+		t.Errorf("expected ErrUnknownPoll, got %v", err)
+	}
+	if err := mm.AnswerPoll(c, "fun", "maybe"); !errors.Is(err, matcherrors.ErrInvalidPollAnswer) {
+		t.Errorf("expected ErrInvalidPollAnswer, got %v", err)
+	}
+	if err := mm.AnswerPoll(c, "fun", "no"); err != nil {
+		t.Errorf("expected a valid answer to be accepted, got %v", err)
+	}
+}
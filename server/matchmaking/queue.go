@@ -0,0 +1,189 @@
+package matchmaking
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"memory-game-server/modes"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+// queueState is where a queue entry stands. Entries move forward only, always under waitMu:
+// queued -> pendingPair -> matched, or to left when the player leaves before being matched. Any number
+// of entries may be pending at once, each with its own worker (see Run).
+type queueState int
+
+const (
+	entryQueued      queueState = iota // waiting in the queue
+	entryPendingPair                   // picked by Run: its worker waits for a partner or the AI timeout
+	entryMatched                       // taken for a game; no longer in the queue
+	entryLeft                          // removed by LeaveQueue (or replaced by another queue) before a match
+)
+
+// queueEntry is one player's place in a queue. Entries are keyed by user (queueKey): a second connection
+// of the same user takes the place over instead of queueing twice, keeping the original wait.
+type queueEntry struct {
+	key      string
+	client   *ws.Client // connection the game goes to; the user's latest connection to enqueue
+	mode     modes.Mode // pairs only with entries of the same mode.Queue
+	board    int        // requested n x n board (0 = default board); pairs only with entries of the same size
+	party    int        // party queue: players per game (0 = not a party entry); see EnqueueParty
+	elo      int        // player's rating when queued (rated modes); see ratingsClose
+	state    queueState
+	queuedAt time.Time
+	done     chan struct{} // closed when the entry leaves the queue (matched or left); stops its worker
+}
+
+// queueKey identifies the player behind a connection: the user ID, or the connection itself for
+// clients without one (local dev, tests).
+func queueKey(c *ws.Client) string {
+	if c.UserID != "" {
+		return "user:" + c.UserID
+	}
+	return fmt.Sprintf("conn:%p", c)
+}
+
+// Enqueue adds a client to the ranked or casual matchmaking queue (by its QueueMode). Idempotent: a player
+// already in that queue keeps their place (taken over by this connection). Ignored while the client is in
+// a game that has not finished.
+func (m *Matchmaker) Enqueue(c *ws.Client) {
+	m.enqueue(c, false)
+}
+
+// EnqueueRaid adds a client to the co-op raid queue. As soon as two clients are waiting they are
+// teamed up against the raid AI; there is no timeout or AI fallback since a raid needs two humans.
+func (m *Matchmaker) EnqueueRaid(c *ws.Client) {
+	if !m.enqueue(c, true) {
+		return
+	}
+	m.waitMu.Lock()
+	var first, second *queueEntry
+	for _, e := range m.entries {
+		if e.mode.Queue != modes.Raid || e.state != entryQueued {
+			continue
+		}
+		switch {
+		case first == nil || e.queuedAt.Before(first.queuedAt):
+			first, second = e, first
+		case second == nil || e.queuedAt.Before(second.queuedAt):
+			second = e
+		}
+	}
+	if second == nil {
+		m.waitMu.Unlock()
+		return
+	}
+	clients := m.claim(first, second)
+	m.waitMu.Unlock()
+	m.createRaid(clients[0], clients[1])
+}
+
+// enqueue creates the client's entry in the queue of its mode: the raid queue when raid is set, else
+// the one of its QueueMode (ranked, casual or party), and withdraws the client's rematch or direct
+// challenge. Returns true when a new entry was created; false when the player was already in that queue,
+// the client is still in a game or the server is shutting down.
+func (m *Matchmaker) enqueue(c *ws.Client, raid bool) bool {
+	if m.refuseWhileDraining(c) {
+		return false
+	}
+	if g := c.Game; g != nil && !g.Finished {
+		slog.Warn("enqueue ignored, client is in a game", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID, "game_id", g.ID)
+		return false
+	}
+	// A queued player may not also wait on a challenge: either could start a game.
+	m.cancelRematch(c)
+	m.cancelChallenge(c)
+	key := queueKey(c)
+	mode := modes.Get(c.QueueMode)
+	if raid {
+		mode = modes.Get(modes.Raid)
+	}
+	party := 0
+	if mode.ID == modes.Party {
+		party = c.PartySize
+		if party == 0 {
+			party = mode.DefaultPlayers
+		}
+	}
+	board := 0
+	if mode.BoardChoice {
+		board = m.boardSize(c)
+	}
+	elo := storage.InitialElo
+	if mode.Rated() {
+		elo = m.queueRating(c)
+	}
+	m.waitMu.Lock()
+	if e, ok := m.entries[key]; ok {
+		if e.mode.ID == mode.ID && e.board == board && e.party == party {
+			e.client = c
+			m.waitMu.Unlock()
+			return false
+		}
+		m.removeEntry(e) // switching queues
+	}
+	m.entries[key] = &queueEntry{key: key, client: c, mode: mode, board: board, party: party, elo: elo, queuedAt: time.Now(), done: make(chan struct{})}
+	m.waitMu.Unlock()
+	if raid {
+		slog.Info("started raid queue for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
+		return true
+	}
+	if party > 0 {
+		slog.Info("started party queue for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID, "party_size", party, "board", board)
+		return true
+	}
+	slog.Info("started for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID, "casual", mode.ID == modes.Casual, "board", board, "elo", elo)
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// LeaveQueue removes the client's player from the matchmaking or raid queue, and withdraws its rematch
+// and direct challenge. Idempotent; a player already matched is not affected (the game has started).
+func (m *Matchmaker) LeaveQueue(c *ws.Client) {
+	m.cancelRematch(c)
+	m.cancelChallenge(c)
+	m.waitMu.Lock()
+	e, ok := m.entries[queueKey(c)]
+	if !ok {
+		m.waitMu.Unlock()
+		return
+	}
+	m.removeEntry(e)
+	m.waitMu.Unlock()
+	if e.mode.ID == modes.Raid {
+		slog.Info("cancelled raid queue for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
+		return
+	}
+	if e.party > 0 {
+		slog.Info("cancelled party queue for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
+		return
+	}
+	slog.Info("cancelled for player", "tag", "matchmaking", "name", c.Name, "user_id", c.UserID)
+}
+
+// removeEntry takes e out of the queue without a match. Caller holds waitMu.
+func (m *Matchmaker) removeEntry(e *queueEntry) {
+	delete(m.entries, e.key)
+	e.state = entryLeft
+	close(e.done)
+}
+
+// claim marks the entries matched, takes them out of the queue and records their queue wait. Returns
+// their clients in order. Caller holds waitMu and checked that the entries are still queued or pending.
+func (m *Matchmaker) claim(entries ...*queueEntry) []*ws.Client {
+	now := time.Now()
+	clients := make([]*ws.Client, len(entries))
+	for i, e := range entries {
+		delete(m.entries, e.key)
+		e.state = entryMatched
+		close(e.done)
+		m.stats.recordWait(now.Sub(e.queuedAt))
+		clients[i] = e.client
+	}
+	return clients
+}
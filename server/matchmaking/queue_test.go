@@ -0,0 +1,231 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/modes"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+func TestQueue_EnqueueIsIdempotentPerUser(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{MaxNameLength: 24}, powerup.NewBuiltinRegistry(nil, 1), nil)
+	tab1 := &ws.Client{Send: make(chan []byte, 10), Name: "Alice", UserID: "u-alice"}
+	tab2 := &ws.Client{Send: make(chan []byte, 10), Name: "Alice", UserID: "u-alice"}
+
+	mm.Enqueue(tab1)
+	mm.Enqueue(tab1)
+	mm.Enqueue(tab2)
+	if len(mm.entries) != 1 {
+		t.Fatalf("expected one entry for the user, got %d", len(mm.entries))
+	}
+	if e := mm.entries[queueKey(tab1)]; e.client != tab2 {
+		t.Error("expected the latest connection to take the entry over")
+	}
+
+	// Switching to the raid queue replaces the regular entry.
+	mm.EnqueueRaid(tab2)
+	if e := mm.entries[queueKey(tab2)]; len(mm.entries) != 1 || e.mode.ID != modes.Raid {
+		t.Fatalf("expected a single raid entry, got %+v", mm.entries)
+	}
+	mm.LeaveQueue(tab1)
+	if len(mm.entries) != 0 {
+		t.Errorf("expected the user out of the queue, got %d entries", len(mm.entries))
+	}
+}
+
+func TestQueue_EnqueueIgnoredDuringGame(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{MaxNameLength: 24}, powerup.NewBuiltinRegistry(nil, 1), nil)
+	c := &ws.Client{Send: make(chan []byte, 10), Name: "Alice", Game: &game.Game{ID: "g1"}}
+	mm.Enqueue(c)
+	if len(mm.entries) != 0 {
+		t.Error("expected no queue entry while the client is in an unfinished game")
+	}
+	c.Game.Finished = true
+	mm.Enqueue(c)
+	if len(mm.entries) != 1 {
+		t.Error("expected a queue entry once the game finished")
+	}
+}
+
+func TestQueue_LeaveQueueDoesNotUndoAMatch(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{MaxNameLength: 24}, powerup.NewBuiltinRegistry(nil, 1), nil)
+	c := &ws.Client{Send: make(chan []byte, 10), Name: "Alice"}
+	mm.Enqueue(c)
+	e := mm.entries[queueKey(c)]
+	mm.waitMu.Lock()
+	mm.claim(e)
+	mm.waitMu.Unlock()
+	mm.LeaveQueue(c)
+	if e.state != entryMatched {
+		t.Errorf("expected the entry to stay matched, got state %d", e.state)
+	}
+}
+
+func TestRemoveGame_KeepsNewerGameReference(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{MaxNameLength: 24}, powerup.NewBuiltinRegistry(nil, 1), nil)
+	c := &ws.Client{Send: make(chan []byte, 10), Name: "Alice"}
+	mm.gameIDToClients["old"] = []*ws.Client{c}
+	c.Game = &game.Game{ID: "new"}
+	c.PlayerID = 1
+
+	mm.removeGame("old")
+	if c.Game == nil || c.Game.ID != "new" || c.PlayerID != 1 {
+		t.Error("expected the late teardown of the old game to keep the new game reference")
+	}
+}
+
+func TestRemoveGame_KeepsNewerGameRejoin(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{MaxNameLength: 24}, powerup.NewBuiltinRegistry(nil, 1), nil)
+	party := &game.Game{ID: "party"}
+	party.PlayerUserIDs[0], party.PlayerUserIDs[1], party.PlayerUserIDs[2] = "u-alice", "u-bob", "u-carol"
+	mm.activeGames["party"] = party
+	mm.activeGames["duel"] = &game.Game{ID: "duel"}
+	mm.userIDToGame["u-alice"] = "duel" // alice left the party for a 1v1
+
+	mm.removeGame("party")
+	if mm.userIDToGame["u-alice"] != "duel" {
+		t.Error("expected the end of the party to keep alice's rejoin to the 1v1")
+	}
+}
+
+// TestQueue_ConcurrentEnqueueAndLeave races Enqueue, play-again style re-enqueues and LeaveQueue against
+// Run. Every player must end up either out of the queue or in exactly one game. Run with -race.
+func TestQueue_ConcurrentEnqueueAndLeave(t *testing.T) {
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, RevealDurationMS: 100, MaxNameLength: 24, AIPairTimeoutSec: 60}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	const players = 40
+	clients := make([]*ws.Client, players)
+	for i := range clients {
+		clients[i] = &ws.Client{Send: make(chan []byte, 100), Name: fmt.Sprintf("P%d", i), UserID: fmt.Sprintf("u%d", i)}
+	}
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mm.Enqueue(c)
+			mm.Enqueue(c)
+			if i%2 == 0 {
+				mm.LeaveQueue(c)
+			}
+		}()
+	}
+	wg.Wait()
+	time.Sleep(100 * time.Millisecond)
+
+	// Whoever is still queued leaves too, which stops their workers.
+	for _, c := range clients {
+		mm.LeaveQueue(c)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	mm.waitMu.Lock()
+	left := len(mm.entries)
+	mm.waitMu.Unlock()
+	if left != 0 {
+		t.Errorf("expected an empty queue, got %d entries", left)
+	}
+	seen := make(map[*ws.Client]string)
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	for gameID, cls := range mm.gameIDToClients {
+		if len(cls) != 2 || cls[0] == cls[1] {
+			t.Errorf("game %s has players %v", gameID, cls)
+		}
+		for _, c := range cls {
+			if other, ok := seen[c]; ok {
+				t.Errorf("%s is in games %s and %s", c.Name, other, gameID)
+			}
+			seen[c] = gameID
+		}
+	}
+}
+
+// TestQueue_PendingPlayersFallBackToAIIndependently checks that players who cannot be paired (different
+// regions) wait side by side: each gets an AI game after its own timeout, not one after the other.
+func TestQueue_PendingPlayersFallBackToAIIndependently(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:         2,
+		BoardCols:         2,
+		RevealDurationMS:  100,
+		MaxNameLength:     24,
+		AIPairTimeoutSec:  1,
+		RegionFallbackSec: 60,
+		AIProfiles:        []config.AIParams{{Name: "Mnemosyne", DelayMinMS: 10, DelayMaxMS: 50}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice", Region: "eu-west"}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob", Region: "us-east"}
+	mm.Enqueue(alice)
+	mm.Enqueue(bob)
+
+	deadline := time.After(1500 * time.Millisecond)
+	for _, c := range []*ws.Client{alice, bob} {
+		select {
+		case msg := <-c.Send:
+			var mf ws.MatchFoundMsg
+			if err := json.Unmarshal(msg, &mf); err != nil || mf.Type != "match_found" || mf.OpponentName != "Mnemosyne" {
+				t.Errorf("expected an AI match_found for %s, got %s", c.Name, msg)
+			}
+		case <-deadline:
+			t.Fatalf("expected %s to get an AI game after one timeout", c.Name)
+		}
+	}
+}
+
+func TestQueue_CasualAndRankedPairSeparately(t *testing.T) {
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, RevealDurationMS: 100, MaxNameLength: 24, AIPairTimeoutSec: 60}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice", QueueMode: ws.QueueModeCasual}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob"}
+	carol := &ws.Client{Send: make(chan []byte, 100), Name: "Carol", QueueMode: ws.QueueModeCasual}
+	dave := &ws.Client{Send: make(chan []byte, 100), Name: "Dave"}
+
+	mm.Enqueue(alice)
+	mm.Enqueue(bob)
+	time.Sleep(100 * time.Millisecond)
+	noGame(t, alice)
+	noGame(t, bob)
+	if st := mm.Status(alice); st.QueueMode != ws.QueueModeCasual {
+		t.Errorf("expected the casual queue in status, got %q", st.QueueMode)
+	}
+	mm.Enqueue(carol)
+	mm.Enqueue(dave)
+	if g := awaitGame(t, alice, time.Second); g == nil || g != awaitGame(t, carol, time.Second) || g.Mode != modes.Casual {
+		t.Fatal("expected Alice and Carol in a casual game")
+	}
+	if g := awaitGame(t, bob, time.Second); g == nil || g != awaitGame(t, dave, time.Second) || g.Mode != modes.Ranked {
+		t.Fatal("expected Bob and Dave in a ranked game")
+	}
+}
+
+func TestQueue_SwitchingToCasualReplacesRankedEntry(t *testing.T) {
+	mm := NewMatchmaker(&config.Config{MaxNameLength: 24}, powerup.NewBuiltinRegistry(nil, 1), nil)
+	c := &ws.Client{Send: make(chan []byte, 10), Name: "Alice", UserID: "u-alice"}
+	mm.Enqueue(c)
+	c.QueueMode = ws.QueueModeCasual
+	mm.Enqueue(c)
+	if e := mm.entries[queueKey(c)]; len(mm.entries) != 1 || e.mode.ID != modes.Casual {
+		t.Fatalf("expected a single casual entry, got %+v", mm.entries)
+	}
+}
@@ -0,0 +1,65 @@
+package matchmaking
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+const (
+	// ratingLookupTimeout bounds the rating query made when a player enters the ranked queue.
+	ratingLookupTimeout = 2 * time.Second
+	// ratingWidenInterval is how often a waiting ranked player retries pairing as their window grows.
+	ratingWidenInterval = time.Second
+)
+
+// queueRating returns the player's ELO in the matchmaker's realm, used to pair ranked players of similar
+// strength. Guests, new players and lookup failures get storage.InitialElo, the rating they would start at.
+func (m *Matchmaker) queueRating(c *ws.Client) int {
+	if m.historyStore == nil || c.UserID == "" {
+		return storage.InitialElo
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ratingLookupTimeout)
+	defer cancel()
+	entry, err := m.historyStore.GetLeaderboardEntryByUserID(ctx, m.realm, c.UserID)
+	if err != nil {
+		slog.Warn("rating lookup failed, queueing at the initial rating", "tag", "matchmaking", "user_id", c.UserID, "err", err)
+		return storage.InitialElo
+	}
+	if entry == nil {
+		return storage.InitialElo
+	}
+	return entry.Elo
+}
+
+// ratingWindow returns the ELO gap e accepts after the time it has waited.
+func (m *Matchmaker) ratingWindow(e *queueEntry) int {
+	waited := time.Since(e.queuedAt).Seconds()
+	return m.config.RatingWindow + int(waited*float64(m.config.RatingWindowWidenPerSec))
+}
+
+// ratingsClose reports whether e1 and e2 may be paired on rating: always in unrated modes (casual) or with
+// RatingWindow 0, otherwise once either entry's window covers their gap.
+func (m *Matchmaker) ratingsClose(e1, e2 *queueEntry) bool {
+	if m.config.RatingWindow <= 0 || !e1.mode.Rated() {
+		return true
+	}
+	gap := e1.elo - e2.elo
+	if gap < 0 {
+		gap = -gap
+	}
+	return gap <= max(m.ratingWindow(e1), m.ratingWindow(e2))
+}
+
+// ratingWiden returns a ticker channel on which e retries pairing as its window grows, or nil when rating
+// does not restrict e's pairing (a nil channel never fires in select). stop releases the ticker.
+func (m *Matchmaker) ratingWiden(e *queueEntry) (tick <-chan time.Time, stop func()) {
+	if m.config.RatingWindow <= 0 || m.config.RatingWindowWidenPerSec <= 0 || !e.mode.Rated() {
+		return nil, func() {}
+	}
+	t := time.NewTicker(ratingWidenInterval)
+	return t.C, t.Stop
+}
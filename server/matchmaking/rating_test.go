@@ -0,0 +1,95 @@
+package matchmaking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/modes"
+	"memory-game-server/powerup"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+// ratingStore serves fixed ratings; the games it is used for never end, so no other method is called
+// but SaveRejoinTokens and SaveActiveGame.
+type ratingStore struct {
+	storage.HistoryStore
+	elo map[string]int
+}
+
+func (s ratingStore) GetLeaderboardEntryByUserID(_ context.Context, _, userID string) (*storage.LeaderboardEntry, error) {
+	elo, ok := s.elo[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &storage.LeaderboardEntry{UserID: userID, Elo: elo}, nil
+}
+
+func (s ratingStore) SaveRejoinTokens(context.Context, []storage.RejoinToken) error { return nil }
+
+func (s ratingStore) SaveActiveGame(context.Context, string, string, []byte) error { return nil }
+
+func TestMatchmakerPairsWithinRatingWindow(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:               2,
+		BoardCols:               2,
+		RevealDurationMS:        100,
+		MaxNameLength:           24,
+		AIPairTimeoutSec:        60,
+		RatingWindow:            100,
+		RatingWindowWidenPerSec: 100,
+	}
+	store := ratingStore{elo: map[string]int{"alice": 1600, "carol": 1550, "dave": 1250}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), store)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice", UserID: "alice"}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob", UserID: "bob"} // new player: 1000
+	carol := &ws.Client{Send: make(chan []byte, 100), Name: "Carol", UserID: "carol"}
+
+	mm.Enqueue(alice)
+	time.Sleep(20 * time.Millisecond)
+	mm.Enqueue(bob)
+	time.Sleep(100 * time.Millisecond)
+	noGame(t, alice)
+	noGame(t, bob)
+	mm.Enqueue(carol)
+	if g := awaitGame(t, alice, time.Second); g == nil || g != awaitGame(t, carol, time.Second) {
+		t.Fatal("expected Alice to be paired with Carol, 50 points apart")
+	}
+	noGame(t, bob)
+
+	// Dave is 250 points above Bob: they are paired once Bob's window has widened past the gap.
+	dave := &ws.Client{Send: make(chan []byte, 100), Name: "Dave", UserID: "dave"}
+	mm.Enqueue(dave)
+	time.Sleep(100 * time.Millisecond)
+	noGame(t, bob)
+	if g := awaitGame(t, bob, 3*time.Second); g == nil || g != awaitGame(t, dave, time.Second) {
+		t.Fatal("expected Bob and Dave to be paired once the rating window widened")
+	}
+}
+
+func TestRatingsCloseIgnoresCasualAndDisabledWindow(t *testing.T) {
+	now := time.Now()
+	ranked, casual := modes.Get(modes.Ranked), modes.Get(modes.Casual)
+	strong := &queueEntry{mode: ranked, elo: 1800, queuedAt: now}
+	weak := &queueEntry{mode: ranked, elo: 1000, queuedAt: now}
+
+	mm := &Matchmaker{config: &config.Config{RatingWindow: 200, RatingWindowWidenPerSec: 25}}
+	if mm.ratingsClose(strong, weak) {
+		t.Error("expected an 800-point gap to be outside a fresh 200 window")
+	}
+	strong.mode, weak.mode = casual, casual
+	if !mm.ratingsClose(strong, weak) {
+		t.Error("expected casual entries to pair regardless of rating")
+	}
+	strong.mode, weak.mode = ranked, ranked
+	mm.config.RatingWindow = 0
+	if !mm.ratingsClose(strong, weak) {
+		t.Error("expected RatingWindow 0 to pair regardless of rating")
+	}
+}
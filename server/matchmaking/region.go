@@ -0,0 +1,69 @@
+package matchmaking
+
+import (
+	"time"
+
+	"memory-game-server/ws"
+)
+
+// Pairing preference of a waiting client for the pending one (lower is better).
+const (
+	regionSame    = iota // same region hint
+	regionUnknown        // either client sent no hint
+	regionCross          // different regions: only after the region fallback delay
+)
+
+func regionRank(a, b *ws.Client) int {
+	switch {
+	case a.Region == "" || b.Region == "":
+		return regionUnknown
+	case a.Region == b.Region:
+		return regionSame
+	}
+	return regionCross
+}
+
+// takePartner returns the best opponent for e1 among the other pending entries: same region first, then
+// clients without a region hint, longest-waiting first within each. Only entries for the same board size
+// are considered. Clients from another region are only
+// taken once either side has waited RegionFallbackSec, and ranked clients only within the rating window
+// (ratingsClose). Returns nil when nobody suitable is waiting.
+// Caller holds waitMu and claims the result.
+func (m *Matchmaker) takePartner(e1 *queueEntry) *queueEntry {
+	crossOK := m.regionFallbackDelay(e1) <= 0
+	var best *queueEntry
+	bestRank := regionCross + 1
+	for _, e := range m.entries {
+		if e == e1 || e.mode.Queue != e1.mode.Queue || e.board != e1.board || e.state != entryPendingPair || !m.ratingsClose(e1, e) {
+			continue
+		}
+		rank := regionRank(e1.client, e.client)
+		if rank == regionCross && !crossOK && m.regionFallbackDelay(e) > 0 {
+			continue
+		}
+		if rank < bestRank || (rank == bestRank && e.queuedAt.Before(best.queuedAt)) {
+			best, bestRank = e, rank
+		}
+	}
+	return best
+}
+
+// regionFallbackDelay returns how much longer e1 must wait before cross-region pairing is allowed
+// (<= 0 when it already is).
+func (m *Matchmaker) regionFallbackDelay(e1 *queueEntry) time.Duration {
+	delay := time.Duration(m.config.RegionFallbackSec) * time.Second
+	if delay <= 0 {
+		return 0
+	}
+	return delay - time.Since(e1.queuedAt)
+}
+
+// regionFallback returns a channel that fires when e1 may be paired across regions, or nil when it
+// already may (a nil channel never fires in select).
+func (m *Matchmaker) regionFallback(e1 *queueEntry) <-chan time.Time {
+	remaining := m.regionFallbackDelay(e1)
+	if remaining <= 0 {
+		return nil
+	}
+	return time.After(remaining)
+}
@@ -0,0 +1,50 @@
+package matchmaking
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+func TestMatchmakerPrefersSameRegion(t *testing.T) {
+	cfg := &config.Config{
+		BoardRows:         2,
+		BoardCols:         2,
+		RevealDurationMS:  100,
+		MaxNameLength:     24,
+		AIPairTimeoutSec:  60,
+		RegionFallbackSec: 1,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice", Region: "eu-west"}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob", Region: "us-east"}
+	carol := &ws.Client{Send: make(chan []byte, 100), Name: "Carol", Region: "eu-west"}
+
+	mm.Enqueue(alice)
+	time.Sleep(20 * time.Millisecond)
+	mm.Enqueue(bob)
+	time.Sleep(100 * time.Millisecond)
+	noGame(t, alice)
+	noGame(t, bob)
+	mm.Enqueue(carol)
+	if g := awaitGame(t, alice, time.Second); g == nil || g != awaitGame(t, carol, time.Second) {
+		t.Fatal("expected Alice to be paired with Carol from the same region")
+	}
+	noGame(t, bob)
+
+	// Bob falls back to a cross-region opponent once he has waited RegionFallbackSec.
+	dave := &ws.Client{Send: make(chan []byte, 100), Name: "Dave", Region: "eu-west"}
+	mm.Enqueue(dave)
+	within := time.Duration(cfg.RegionFallbackSec)*time.Second + time.Second
+	if g := awaitGame(t, bob, within); g == nil || g != awaitGame(t, dave, time.Second) {
+		t.Fatal("expected Bob and Dave to be paired across regions after the fallback delay")
+	}
+}
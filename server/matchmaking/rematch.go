@@ -0,0 +1,166 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/matcherrors"
+	"memory-game-server/modes"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+	"memory-game-server/wsutil"
+)
+
+// rematchWaitTimeout is how long a rematch challenge against a human waits for them to accept.
+const rematchWaitTimeout = 2 * time.Minute
+
+// rematchChallenge is a player waiting for the other human of a recorded game to ask for the same rematch.
+type rematchChallenge struct {
+	client *ws.Client
+	seat   int // the challenger's seat in the recorded game, kept in the rematch
+	src    *storage.RematchSource
+	timer  *time.Timer
+}
+
+// Rematch plays the recorded game matchID again on the same board (seed and arcana) with the same seats.
+// Against an AI the game starts at once with the same profile. Against a human the client waits up to
+// rematchWaitTimeout until the other player asks for the same rematch; waiting_for_rematch confirms it.
+// Like Challenge, asking leaves the queue and withdraws c's other challenges (see LeaveQueue). Rematches
+// are recorded in history but never rated, since a player may remember the board.
+func (m *Matchmaker) Rematch(c *ws.Client, matchID string) error {
+	if m.refuseWhileDraining(c) {
+		return nil
+	}
+	if m.historyStore == nil {
+		return matcherrors.ErrGameNotFound
+	}
+	src, err := m.historyStore.GetRematchSource(context.Background(), matchID)
+	if err != nil {
+		return err
+	}
+	if src == nil || src.Realm != m.realm {
+		return matcherrors.ErrGameNotFound
+	}
+	seat := -1
+	for i, uid := range src.PlayerUserIDs {
+		if c.UserID != "" && uid == c.UserID {
+			seat = i
+		}
+	}
+	if seat < 0 {
+		return matcherrors.ErrNotAParticipant
+	}
+	if !src.HasSeed {
+		return matcherrors.ErrNoBoardSeed
+	}
+	if src.BoardRows > 0 && src.BoardCols > 0 {
+		if err := config.ValidateBoard(src.BoardRows, src.BoardCols, m.config.MinPairsPerElement); err != nil {
+			return fmt.Errorf("%w: %v", matcherrors.ErrInvalidBoard, err)
+		}
+	}
+
+	m.LeaveQueue(c)
+
+	opponentUID := src.PlayerUserIDs[1-seat]
+	if strings.HasPrefix(opponentUID, "ai:") {
+		profile := m.config.AIProfileByUserID(opponentUID)
+		if profile == nil {
+			return matcherrors.ErrOpponentUnavailable
+		}
+		// The AI always plays seat 1; replaying the seed keeps the board and who opens.
+		m.createGameVsAIFrom(c, profile, src)
+		return nil
+	}
+
+	m.rematchMu.Lock()
+	if ch, ok := m.rematchWaiting[matchID]; ok && ch.client.UserID == opponentUID {
+		delete(m.rematchWaiting, matchID)
+		m.rematchMu.Unlock()
+		ch.timer.Stop()
+		m.LeaveQueue(ch.client) // the challenger may have queued from another connection meanwhile
+		if seat == 0 {
+			m.createGameFrom(c, ch.client, src, modes.Get(src.Mode))
+		} else {
+			m.createGameFrom(ch.client, c, src, modes.Get(src.Mode))
+		}
+		return nil
+	}
+	if ch, ok := m.rematchWaiting[matchID]; ok {
+		ch.timer.Stop() // the same player asking again (e.g. from another connection) replaces the challenge
+	}
+	ch := &rematchChallenge{client: c, seat: seat, src: src}
+	ch.timer = time.AfterFunc(rematchWaitTimeout, func() { m.expireRematch(matchID, ch) })
+	m.rematchWaiting[matchID] = ch
+	m.rematchMu.Unlock()
+
+	slog.Info("rematch challenge", "tag", "matchmaking", "match_id", matchID, "name", c.Name, "user_id", c.UserID)
+	data, _ := json.Marshal(ws.WaitingForRematchMsg{Type: "waiting_for_rematch", MatchID: matchID, OpponentName: src.PlayerNames[1-seat]})
+	wsutil.SafeSend(c.Send, data)
+	return nil
+}
+
+// expireRematch drops ch when nobody accepted it in time and tells the challenger.
+func (m *Matchmaker) expireRematch(matchID string, ch *rematchChallenge) {
+	m.rematchMu.Lock()
+	if m.rematchWaiting[matchID] != ch {
+		m.rematchMu.Unlock()
+		return
+	}
+	delete(m.rematchWaiting, matchID)
+	m.rematchMu.Unlock()
+	slog.Info("rematch challenge expired", "tag", "matchmaking", "match_id", matchID, "user_id", ch.client.UserID)
+	data, _ := json.Marshal(ws.RematchExpiredMsg{Type: "rematch_expired", MatchID: matchID})
+	wsutil.SafeSend(ch.client.Send, data)
+}
+
+// cancelRematch withdraws c's rematch challenge, if any.
+func (m *Matchmaker) cancelRematch(c *ws.Client) {
+	m.rematchMu.Lock()
+	defer m.rematchMu.Unlock()
+	for matchID, ch := range m.rematchWaiting {
+		if ch.client == c {
+			ch.timer.Stop()
+			delete(m.rematchWaiting, matchID)
+			slog.Info("rematch challenge cancelled", "tag", "matchmaking", "match_id", matchID, "user_id", c.UserID)
+			return
+		}
+	}
+}
+
+// newGame creates the game for matchID on an n x n board for board > 0 (see boardSize). For a rematch
+// (src set) it replays src's board instead: same size, seed and arcana (those still registered). Fails when the board does not pass config.ValidateBoard.
+func (m *Matchmaker) newGame(matchID string, p0, p1 *game.Player, board int, src *storage.RematchSource) (*game.Game, error) {
+	if src == nil {
+		return game.NewGame(matchID, m.boardConfig(board), p0, p1, m.powerUps)
+	}
+	cfg := *m.config
+	if src.BoardRows > 0 && src.BoardCols > 0 {
+		cfg.BoardRows, cfg.BoardCols = src.BoardRows, src.BoardCols
+	}
+	var pool []string
+	for _, id := range src.ArcanaPool {
+		if _, ok := m.powerUps.GetPowerUp(id); ok {
+			pool = append(pool, id)
+		}
+	}
+	g, err := game.NewSeededGame(matchID, &cfg, p0, p1, m.powerUps, src.BoardSeed, pool)
+	if err != nil {
+		return nil, err
+	}
+	g.RematchOf = src.MatchID
+	return g, nil
+}
+
+// rematchOf returns the recorded match a rematch replays, for logs ("" for regular games).
+func rematchOf(src *storage.RematchSource) string {
+	if src == nil {
+		return ""
+	}
+	return src.MatchID
+}
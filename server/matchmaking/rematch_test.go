@@ -0,0 +1,74 @@
+package matchmaking
+
+import (
+	"context"
+	"testing"
+
+	"memory-game-server/config"
+	"memory-game-server/modes"
+	"memory-game-server/powerup"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+// rematchStore serves two recorded matches: m1 between u-alice and u-bob, and a casual game of u-alice
+// against the first default bot. Players have no rating yet.
+type rematchStore struct {
+	ratingStore
+}
+
+func (rematchStore) GetRematchSource(_ context.Context, matchID string) (*storage.RematchSource, error) {
+	switch matchID {
+	case "m1":
+		return &storage.RematchSource{MatchID: "m1", PlayerUserIDs: [2]string{"u-alice", "u-bob"}, PlayerNames: [2]string{"Alice", "Bob"}, HasSeed: true}, nil
+	case "casual-vs-ai":
+		bot := config.Defaults().AIProfiles[0]
+		return &storage.RematchSource{MatchID: matchID, PlayerUserIDs: [2]string{"u-alice", bot.UserID()}, PlayerNames: [2]string{"Alice", bot.Name}, HasSeed: true, Mode: modes.Casual}, nil
+	}
+	return nil, nil
+}
+
+func TestRematch_LeavesQueueAndWithdrawsChallenge(t *testing.T) {
+	mm := NewMatchmaker(challengeConfig(), powerup.NewBuiltinRegistry(nil, 1), rematchStore{})
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	aliceTab := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	carol := &ws.Client{Send: make(chan []byte, 20), Name: "Carol", UserID: "u-carol"}
+
+	mm.Challenge(alice, []*ws.Client{carol})
+	nextOfType(t, carol.Send, "challenge_received")
+	mm.Enqueue(aliceTab)
+	if err := mm.Rematch(alice, "m1"); err != nil {
+		t.Fatal(err)
+	}
+	nextOfType(t, alice.Send, "waiting_for_rematch")
+	if closed := nextOfType(t, carol.Send, "challenge_closed"); closed["reason"] != ws.ChallengeCancelled {
+		t.Errorf("expected the direct challenge withdrawn, got %v", closed)
+	}
+	if len(mm.entries) != 0 {
+		t.Errorf("expected alice out of the queue, got %d entries", len(mm.entries))
+	}
+
+	// Queueing withdraws the rematch in turn.
+	mm.Enqueue(alice)
+	if len(mm.entries) != 1 || len(mm.rematchWaiting) != 0 {
+		t.Errorf("expected alice queued with no rematch pending, got %d entries and %d rematches", len(mm.entries), len(mm.rematchWaiting))
+	}
+}
+
+func TestRematch_KeepsSourceMode(t *testing.T) {
+	mm := NewMatchmaker(challengeConfig(), powerup.NewBuiltinRegistry(nil, 1), rematchStore{})
+	alice := &ws.Client{Send: make(chan []byte, 20), Name: "Alice", UserID: "u-alice"}
+	if err := mm.Rematch(alice, "casual-vs-ai"); err != nil {
+		t.Fatal(err)
+	}
+	g := alice.Game
+	if g == nil || g.RematchOf != "casual-vs-ai" {
+		t.Fatalf("expected the rematch to start, got %+v", g)
+	}
+	if g.Mode != modes.Casual || g.ConfigSnapshot().Mode != modes.Casual {
+		t.Errorf("expected the rematch of a casual game to be casual, got %q", g.Mode)
+	}
+	if found := nextOfType(t, alice.Send, "match_found"); found["mode"] != modes.Casual || found["casual"] != true {
+		t.Errorf("expected match_found to carry the casual mode, got %v", found)
+	}
+}
@@ -0,0 +1,111 @@
+package matchmaking
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"memory-game-server/ai"
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/matcherrors"
+	"memory-game-server/storage"
+)
+
+// checkpointGame saves g's snapshots to active_games while it runs, so a rejoin after a crash or restart
+// can resume it (see resumeGame). Snapshots are written one at a time on a goroutine of their own; one
+// superseded before its turn is skipped, so the game loop never waits on the database. removeGame
+// deletes the row once the game is over.
+func (m *Matchmaker) checkpointGame(g *game.Game) {
+	store, realm := m.historyStore, m.realm
+	latest := make(chan []byte, 1)
+	g.OnCheckpoint = func(snapshot []byte) {
+		select {
+		case <-latest:
+		default:
+		}
+		latest <- snapshot
+	}
+	go func() {
+		for {
+			select {
+			case snapshot := <-latest:
+				m.persistInFlight.Add(1)
+				ctx, cancel := context.WithTimeout(context.Background(), persistTimeout)
+				if err := store.SaveActiveGame(ctx, realm, g.ID, snapshot); err != nil {
+					slog.Warn("could not save game snapshot", "tag", "matchmaking", "match_id", g.ID, "err", err)
+				}
+				cancel()
+				m.persistInFlight.Add(-1)
+			case <-g.Done:
+				return
+			}
+		}
+	}()
+}
+
+// resumeGame restores the match of a persisted rejoin token from its last snapshot, after the process
+// that ran it stopped. The game restarts at the snapshot (the start of the move in progress then) with
+// t's seat about to rejoin: the caller attaches it with ActionRejoinCompleted, which opens the usual
+// reconnection window for a human opponent who is not back yet. The AI of a bot match starts over
+// without memory. Returns the game already running when the other seat resumed it first, and
+// ErrGameInterrupted when the match cannot be resumed.
+func (m *Matchmaker) resumeGame(t *storage.RejoinToken) (*game.Game, error) {
+	if t.SnapshotRef == "" || m.shutdownAt.Load() != 0 {
+		return nil, matcherrors.ErrGameInterrupted
+	}
+	data, err := m.historyStore.LoadActiveGame(context.Background(), m.realm, t.SnapshotRef)
+	if err != nil || data == nil {
+		if err != nil {
+			slog.Warn("could not load game snapshot", "tag", "matchmaking", "match_id", t.MatchID, "err", err)
+		}
+		return nil, matcherrors.ErrGameInterrupted
+	}
+	g, err := game.RestoreGame(data, m.config, m.powerUps)
+	if err != nil {
+		slog.Warn("could not restore game snapshot", "tag", "matchmaking", "match_id", t.MatchID, "err", err)
+		return nil, matcherrors.ErrGameInterrupted
+	}
+	var profile *config.AIParams
+	var aiSend chan []byte
+	if strings.HasPrefix(g.PlayerUserIDs[1], config.AIUserIDPrefix) {
+		if profile = m.config.AIProfileByUserID(g.PlayerUserIDs[1]); profile == nil {
+			slog.Warn("cannot resume game: its AI profile is gone", "tag", "matchmaking", "match_id", t.MatchID, "ai", g.PlayerUserIDs[1])
+			return nil, matcherrors.ErrGameInterrupted
+		}
+		aiSend = make(chan []byte, 256)
+		g.Players[1].Send = aiSend
+	}
+	g.DisconnectedPlayerIdx = t.Seat
+	m.wireGameEnd(g, [2]string{}, profile)
+
+	m.mu.Lock()
+	if running, ok := m.activeGames[g.ID]; ok {
+		m.mu.Unlock()
+		return running, nil
+	}
+	m.activeGames[g.ID] = g
+	for i := range 2 {
+		if i == 1 && profile != nil {
+			break
+		}
+		m.userIDToGame[g.PlayerUserIDs[i]] = g.ID
+	}
+	m.mu.Unlock()
+
+	slog.Info("Match resumed from snapshot", "tag", "matchmaking", "match_id", g.ID, "round", g.Round,
+		"scores", []int{g.Players[0].Score, g.Players[1].Score}, "seat", t.Seat)
+	m.checkpointGame(g)
+	go func() {
+		g.Run()
+		m.removeGame(g.ID)
+	}()
+	if profile != nil {
+		ready := make(chan struct{})
+		close(ready)
+		go superviseAI(g, 1, profile.Name, func(known map[int]int) {
+			ai.RunWithKnowledge(aiSend, g, 1, profile, ready, known)
+		}, nil)
+	}
+	return g, nil
+}
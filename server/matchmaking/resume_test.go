@@ -0,0 +1,97 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/matcherrors"
+	"memory-game-server/powerup"
+	"memory-game-server/storage"
+)
+
+// snapshotStore serves the rejoin tokens and the saved snapshot of one match interrupted by a restart.
+type snapshotStore struct {
+	storage.HistoryStore
+	tokens   []storage.RejoinToken
+	snapshot []byte
+}
+
+func (s *snapshotStore) FindRejoinTokenByUser(_ context.Context, userID string) (*storage.RejoinToken, error) {
+	for i := range s.tokens {
+		if s.tokens[i].UserID == userID {
+			return &s.tokens[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *snapshotStore) LoadActiveGame(_ context.Context, _, matchID string) ([]byte, error) {
+	if len(s.tokens) == 0 || s.tokens[0].SnapshotRef != matchID {
+		return nil, nil
+	}
+	return s.snapshot, nil
+}
+
+func (s *snapshotStore) GetLeaderboardEntryByUserID(context.Context, string, string) (*storage.LeaderboardEntry, error) {
+	return nil, nil
+}
+
+func (s *snapshotStore) SaveActiveGame(context.Context, string, string, []byte) error { return nil }
+
+func TestRejoinByUserResumesInterruptedGame(t *testing.T) {
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, RevealDurationMS: 100, MaxNameLength: 24}
+	registry := powerup.NewBuiltinRegistry(nil, 1)
+	before, err := game.NewGame("match-1", cfg, game.NewPlayer("Alice", nil), game.NewPlayer("Bob", nil), registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before.PlayerUserIDs = [game.MaxSeats]string{"u-alice", "u-bob"}
+	before.RejoinTokens = [game.MaxSeats]string{"tok-alice", "tok-bob"}
+	before.Players[1].Score = 1
+	before.CurrentTurn = 1
+	snapshot, _ := json.Marshal(before.Snapshot())
+	store := &snapshotStore{snapshot: snapshot, tokens: []storage.RejoinToken{
+		{MatchID: "match-1", Seat: 0, UserID: "u-alice", Token: "tok-alice", SnapshotRef: "match-1"},
+		{MatchID: "match-1", Seat: 1, UserID: "u-bob", Token: "tok-bob", SnapshotRef: "match-1"},
+	}}
+	mm := NewMatchmaker(cfg, registry, store)
+
+	g, seat, token, err := mm.RejoinByUser("u-alice")
+	if err != nil {
+		t.Fatalf("expected the interrupted game to resume, got %v", err)
+	}
+	if g.ID != "match-1" || seat != 0 || token != "tok-alice" || g.Players[1].Score != 1 || g.CurrentTurn != 1 {
+		t.Fatalf("expected Alice back in seat 0 of the saved game, got %q seat %d, token %q", g.ID, seat, token)
+	}
+	send := make(chan []byte, 100)
+	g.Actions <- game.Action{Type: game.ActionRejoinCompleted, PlayerIdx: 0, NewSend: send}
+
+	// Bob is not back yet: he gets the usual reconnection window and can then rejoin the same game. Alice is
+	// told once the game loop has opened it; waiting for that orders the lookup after the loop's writes.
+	deadline := time.After(time.Second)
+	for reconnecting := false; !reconnecting; {
+		select {
+		case data := <-send:
+			var msg struct {
+				Type string `json:"type"`
+			}
+			reconnecting = json.Unmarshal(data, &msg) == nil && msg.Type == "opponent_reconnecting"
+		case <-deadline:
+			t.Fatal("expected Alice to be told that Bob may reconnect")
+		}
+	}
+	again, seat, _, err := mm.RejoinByUser("u-bob")
+	if err != nil || again != g || seat != 1 {
+		t.Fatalf("expected Bob to rejoin the resumed game in seat 1, got %v", err)
+	}
+
+	store.snapshot = nil
+	if _, _, _, err := NewMatchmaker(cfg, registry, store).RejoinByUser("u-alice"); !errors.Is(err, matcherrors.ErrGameInterrupted) {
+		t.Errorf("expected a game without a snapshot to be reported interrupted, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"memory-game-server/game"
+	"memory-game-server/ws"
+	"memory-game-server/wsutil"
+)
+
+// drainPollInterval is how often Shutdown checks whether the games and their writes are done.
+const drainPollInterval = 100 * time.Millisecond
+
+// Shutdown drains the matchmaker before the server stops. It empties the queues and pending rematch
+// challenges, refuses new games from then on, and warns every game in progress with server_shutdown: a
+// game still running at deadline ends as an unrated draw (game.EndReasonServerShutdown) and is recorded
+// like any other. Returns once every game is over and its end-of-game writes are done, or with an error
+// when ctx ends first. Call it before cancelling Run's context.
+func (m *Matchmaker) Shutdown(ctx context.Context, deadline time.Time) error {
+	m.shutdownAt.CompareAndSwap(0, deadline.UnixMilli())
+	deadline = time.UnixMilli(m.shutdownAt.Load())
+	notice, err := json.Marshal(game.NewServerShutdownMsg(deadline))
+	if err != nil {
+		return err
+	}
+
+	var waiting []*ws.Client
+	m.waitMu.Lock()
+	for _, e := range m.entries {
+		m.removeEntry(e)
+		waiting = append(waiting, e.client)
+	}
+	m.waitMu.Unlock()
+	m.rematchMu.Lock()
+	for matchID, ch := range m.rematchWaiting {
+		ch.timer.Stop()
+		delete(m.rematchWaiting, matchID)
+		waiting = append(waiting, ch.client)
+	}
+	m.rematchMu.Unlock()
+	for _, c := range waiting {
+		wsutil.SafeSend(c.Send, notice)
+	}
+
+	warned := make(map[string]bool)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		// Games are listed again on every pass: a pairing under way when the queues were emptied may
+		// still add one.
+		m.mu.RLock()
+		var games []*game.Game
+		for id, g := range m.activeGames {
+			if !warned[id] {
+				warned[id] = true
+				games = append(games, g)
+			}
+		}
+		remaining := len(m.activeGames)
+		m.mu.RUnlock()
+		for _, g := range games {
+			select {
+			case g.Actions <- game.Action{Type: game.ActionServerShutdown, Deadline: deadline}:
+			case <-g.Done:
+			case <-ctx.Done():
+			}
+		}
+		if len(games) > 0 {
+			slog.Info("warned games of shutdown", "tag", "matchmaking", "realm", m.realm, "games", len(games), "queued", len(waiting), "deadline", deadline)
+			waiting = nil
+		}
+		writes := m.persistInFlight.Load()
+		if remaining == 0 && writes == 0 {
+			slog.Info("drained", "tag", "matchmaking", "realm", m.realm)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d games still running, %d end-of-game writes in flight: %w", remaining, writes, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// refuseWhileDraining tells c that no game can start because the server is shutting down, and returns
+// true; false while the matchmaker is serving.
+func (m *Matchmaker) refuseWhileDraining(c *ws.Client) bool {
+	at := m.shutdownAt.Load()
+	if at == 0 {
+		return false
+	}
+	data, _ := json.Marshal(game.NewServerShutdownMsg(time.UnixMilli(at)))
+	wsutil.SafeSend(c.Send, data)
+	return true
+}
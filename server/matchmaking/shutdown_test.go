@@ -0,0 +1,79 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/powerup"
+	"memory-game-server/ws"
+)
+
+// messageTypes returns the types of the messages waiting on ch.
+func messageTypes(ch chan []byte) map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage)
+	for {
+		select {
+		case data := <-ch:
+			var env struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(data, &env) == nil {
+				out[env.Type] = data
+			}
+		default:
+			return out
+		}
+	}
+}
+
+func TestShutdown_EndsRunningGamesAsDrawsAndRefusesNewOnes(t *testing.T) {
+	cfg := &config.Config{BoardRows: 2, BoardCols: 2, RevealDurationMS: 100, MaxNameLength: 24, AIPairTimeoutSec: 60}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+	go mm.Run(ctx)
+
+	alice := &ws.Client{Send: make(chan []byte, 100), Name: "Alice"}
+	bob := &ws.Client{Send: make(chan []byte, 100), Name: "Bob"}
+	carol := &ws.Client{Send: make(chan []byte, 100), Name: "Carol"}
+	mm.Enqueue(alice)
+	mm.Enqueue(bob)
+	if g := awaitGame(t, alice, time.Second); g == nil || g != awaitGame(t, bob, time.Second) {
+		t.Fatal("expected Alice and Bob to be paired")
+	}
+	mm.Enqueue(carol)
+	time.Sleep(50 * time.Millisecond)
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer drainCancel()
+	if err := mm.Shutdown(drainCtx, time.Now().Add(200*time.Millisecond)); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	for _, c := range []*ws.Client{alice, bob} {
+		msgs := messageTypes(c.Send)
+		if _, ok := msgs["server_shutdown"]; !ok {
+			t.Errorf("expected %s to be warned of the shutdown", c.Name)
+		}
+		var over struct {
+			Result    string `json:"result"`
+			EndReason string `json:"endReason"`
+		}
+		if err := json.Unmarshal(msgs["game_over"], &over); err != nil || over.Result != "draw" || over.EndReason != game.EndReasonServerShutdown {
+			t.Errorf("expected %s's game to end as a shutdown draw, got %s", c.Name, msgs["game_over"])
+		}
+	}
+	if _, ok := messageTypes(carol.Send)["server_shutdown"]; !ok || len(mm.entries) != 0 {
+		t.Error("expected Carol to be taken out of the queue and told why")
+	}
+
+	dave := &ws.Client{Send: make(chan []byte, 10), Name: "Dave"}
+	mm.Enqueue(dave)
+	if _, ok := messageTypes(dave.Send)["server_shutdown"]; !ok || len(mm.entries) != 0 {
+		t.Error("expected enqueueing to be refused during shutdown")
+	}
+}
@@ -0,0 +1,79 @@
+package matchmaking
+
+import (
+	"sync"
+	"time"
+)
+
+// queueWaitSamples is how many recent pairings the average queue wait covers.
+const queueWaitSamples = 100
+
+// Stats is a snapshot of one matchmaker's activity, for the public stats endpoint.
+type Stats struct {
+	GamesInProgress int
+	// GamesToday counts games that finished since midnight UTC.
+	GamesToday int
+	// AvgQueueWait is the mean time from joining the queue to being paired over the last
+	// QueueWaitSamples pairings (0 when there are none yet).
+	AvgQueueWait     time.Duration
+	QueueWaitSamples int
+}
+
+// matchStats keeps the counters behind Stats. Safe for concurrent use.
+type matchStats struct {
+	mu         sync.Mutex
+	day        string // UTC date gamesToday counts for
+	gamesToday int
+	waits      []time.Duration // latest queue waits, up to queueWaitSamples (ring buffer)
+	next       int
+}
+
+// rollDay resets the daily counter when the UTC date changed. Caller holds mu.
+func (s *matchStats) rollDay(now time.Time) {
+	if day := now.UTC().Format(time.DateOnly); day != s.day {
+		s.day = day
+		s.gamesToday = 0
+	}
+}
+
+func (s *matchStats) recordGame(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollDay(now)
+	s.gamesToday++
+}
+
+func (s *matchStats) recordWait(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waits) < queueWaitSamples {
+		s.waits = append(s.waits, d)
+		return
+	}
+	s.waits[s.next] = d
+	s.next = (s.next + 1) % queueWaitSamples
+}
+
+func (s *matchStats) snapshot(now time.Time) Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollDay(now)
+	st := Stats{GamesToday: s.gamesToday, QueueWaitSamples: len(s.waits)}
+	if len(s.waits) > 0 {
+		var total time.Duration
+		for _, d := range s.waits {
+			total += d
+		}
+		st.AvgQueueWait = total / time.Duration(len(s.waits))
+	}
+	return st
+}
+
+// Stats returns the matchmaker's current activity.
+func (m *Matchmaker) Stats() Stats {
+	st := m.stats.snapshot(time.Now())
+	m.mu.RLock()
+	st.GamesInProgress = len(m.activeGames)
+	m.mu.RUnlock()
+	return st
+}
@@ -0,0 +1,35 @@
+package matchmaking
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchStats_DailyGamesAndQueueWait(t *testing.T) {
+	var s matchStats
+	day1 := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	s.recordGame(day1)
+	s.recordGame(day1)
+	if got := s.snapshot(day1).GamesToday; got != 2 {
+		t.Errorf("expected 2 games today, got %d", got)
+	}
+	if got := s.snapshot(day1.Add(2 * time.Hour)).GamesToday; got != 0 {
+		t.Errorf("expected the count to reset after midnight UTC, got %d", got)
+	}
+
+	if st := s.snapshot(day1); st.AvgQueueWait != 0 || st.QueueWaitSamples != 0 {
+		t.Errorf("expected no wait samples yet, got %+v", st)
+	}
+	for range queueWaitSamples {
+		s.recordWait(time.Second)
+	}
+	s.recordWait(time.Second + time.Duration(queueWaitSamples)*time.Second)
+	st := s.snapshot(day1)
+	if st.QueueWaitSamples != queueWaitSamples {
+		t.Errorf("expected %d samples, got %d", queueWaitSamples, st.QueueWaitSamples)
+	}
+	// The oldest 1s sample was replaced by one 100s longer: the mean moves up by exactly 1s.
+	if st.AvgQueueWait != 2*time.Second {
+		t.Errorf("expected average wait 2s, got %v", st.AvgQueueWait)
+	}
+}
@@ -0,0 +1,112 @@
+package matchmaking
+
+import (
+	"context"
+	"sort"
+
+	"memory-game-server/modes"
+	"memory-game-server/ws"
+)
+
+// Status gathers what the client's user has pending on this matchmaker: a queue entry, a game in
+// progress (and whether it can be rejoined), rematch and direct challenges sent or received, and the
+// chat phrases when chat is restricted. Entries of other connections of the same user count too, so a
+// reconnecting client sees what it left behind.
+func (m *Matchmaker) Status(c *ws.Client) ws.StatusMsg {
+	st := ws.StatusMsg{Type: "status", RematchChallenges: []ws.RematchChallengeStatus{}, Challenges: []ws.ChallengeStatus{}}
+	same := func(o *ws.Client) bool {
+		return o == c || (c.UserID != "" && o.UserID == c.UserID)
+	}
+
+	m.waitMu.Lock()
+	if e, ok := m.entries[queueKey(c)]; ok {
+		st.InQueue = true
+		if e.mode.ID != modes.Ranked {
+			st.QueueMode = e.mode.ID
+		}
+	}
+	m.waitMu.Unlock()
+
+	st.ActiveGame = m.activeGameStatus(c)
+	if chat := m.config.Chat; chat.Restricted() && !chat.Disabled {
+		st.ChatPhrases = chat.Phrases
+	}
+
+	m.rematchMu.Lock()
+	for matchID, ch := range m.rematchWaiting {
+		opponentSeat := 1 - ch.seat
+		switch {
+		case same(ch.client):
+			st.RematchChallenges = append(st.RematchChallenges, ws.RematchChallengeStatus{
+				MatchID: matchID, OpponentName: ch.src.PlayerNames[opponentSeat],
+			})
+		case c.UserID != "" && ch.src.PlayerUserIDs[opponentSeat] == c.UserID:
+			st.RematchChallenges = append(st.RematchChallenges, ws.RematchChallengeStatus{
+				MatchID: matchID, OpponentName: ch.src.PlayerNames[ch.seat], Incoming: true,
+			})
+		}
+	}
+	m.rematchMu.Unlock()
+	sort.Slice(st.RematchChallenges, func(i, j int) bool { return st.RematchChallenges[i].MatchID < st.RematchChallenges[j].MatchID })
+
+	m.challengeMu.Lock()
+	for id, ch := range m.challenges {
+		switch {
+		case same(ch.from):
+			st.Challenges = append(st.Challenges, ws.ChallengeStatus{ChallengeID: id, OpponentName: ch.toName})
+		case c.UserID != "" && ch.toUserID == c.UserID:
+			st.Challenges = append(st.Challenges, ws.ChallengeStatus{ChallengeID: id, OpponentName: ch.from.Name, Incoming: true})
+		}
+	}
+	m.challengeMu.Unlock()
+	sort.Slice(st.Challenges, func(i, j int) bool { return st.Challenges[i].ChallengeID < st.Challenges[j].ChallengeID })
+	return st
+}
+
+// activeGameStatus returns the game the client plays or its user left, or nil when there is none.
+// A game lost with a server restart is reported as rejoinable when it has a snapshot to resume from
+// (see resumeGame), otherwise as interrupted.
+func (m *Matchmaker) activeGameStatus(c *ws.Client) *ws.ActiveGameStatus {
+	gameID := ""
+	if g := c.Game; g != nil && !g.Finished {
+		gameID = g.ID
+	}
+	m.mu.RLock()
+	if gameID == "" && c.UserID != "" {
+		gameID = m.userIDToGame[c.UserID]
+	}
+	g := m.activeGames[gameID]
+	m.mu.RUnlock()
+
+	if g == nil || g.Finished {
+		if c.UserID != "" && m.historyStore != nil {
+			if t, err := m.historyStore.FindRejoinTokenByUser(context.Background(), c.UserID); err == nil && t != nil {
+				if t.SnapshotRef != "" {
+					return &ws.ActiveGameStatus{GameID: t.MatchID, Rejoinable: true}
+				}
+				return &ws.ActiveGameStatus{Interrupted: true}
+			}
+		}
+		return nil
+	}
+	seat := -1
+	if c.Game == g {
+		seat = c.PlayerID
+	} else {
+		for i, uid := range g.PlayerUserIDs {
+			if uid == c.UserID {
+				seat = i
+			}
+		}
+	}
+	if seat < 0 {
+		return nil
+	}
+	st := &ws.ActiveGameStatus{GameID: g.ID, Rejoinable: c.Game != g && g.DisconnectedPlayerIdx == seat}
+	if g.Party() {
+		st.OpponentName = otherNames(g, seat)
+	} else if p := g.Players[1-seat]; p != nil {
+		st.OpponentName = p.Name
+	}
+	return st
+}
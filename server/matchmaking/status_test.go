@@ -0,0 +1,43 @@
+package matchmaking
+
+import (
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/powerup"
+	"memory-game-server/storage"
+	"memory-game-server/ws"
+)
+
+func TestMatchmakerStatus(t *testing.T) {
+	cfg := &config.Config{MaxNameLength: 24}
+	mm := NewMatchmaker(cfg, powerup.NewBuiltinRegistry(nil, 1), nil)
+
+	alice := &ws.Client{Send: make(chan []byte, 10), Name: "Alice", UserID: "u-alice"}
+	if st := mm.Status(alice); st.InQueue || st.ActiveGame != nil || len(st.RematchChallenges) != 0 {
+		t.Fatalf("expected nothing pending, got %+v", st)
+	}
+
+	mm.Enqueue(alice)
+	// A second connection of the same user sees the queue entry.
+	if st := mm.Status(&ws.Client{UserID: "u-alice"}); !st.InQueue {
+		t.Error("expected the user to be in the queue")
+	}
+	mm.LeaveQueue(alice)
+
+	src := &storage.RematchSource{MatchID: "m1", PlayerUserIDs: [2]string{"u-alice", "u-bob"}, PlayerNames: [2]string{"Alice", "Bob"}}
+	mm.rematchWaiting["m1"] = &rematchChallenge{client: alice, seat: 0, src: src, timer: time.NewTimer(time.Hour)}
+	st := mm.Status(alice)
+	if st.InQueue || len(st.RematchChallenges) != 1 || st.RematchChallenges[0].Incoming || st.RematchChallenges[0].OpponentName != "Bob" {
+		t.Errorf("expected an outgoing challenge to Bob, got %+v", st)
+	}
+	st = mm.Status(&ws.Client{UserID: "u-bob"})
+	if len(st.RematchChallenges) != 1 || !st.RematchChallenges[0].Incoming || st.RematchChallenges[0].OpponentName != "Alice" {
+		t.Errorf("expected an incoming challenge from Alice, got %+v", st)
+	}
+	mm.LeaveQueue(alice)
+	if st := mm.Status(alice); len(st.RematchChallenges) != 0 {
+		t.Errorf("expected the challenge withdrawn, got %+v", st.RematchChallenges)
+	}
+}
@@ -0,0 +1,75 @@
+package matchmaking
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"memory-game-server/game"
+)
+
+// maxAIRestarts is how many times an AI seat that stopped mid-game is restarted before the game is called off.
+const maxAIRestarts = 2
+
+// aiExitGrace is how long to wait for the game loop to finish after the AI returns. The AI returns as soon
+// as it reads game_over, slightly before the loop closes Done, so a normal finish must not count as a failure.
+var aiExitGrace = time.Second
+
+// superviseAI runs the AI of seat and keeps the seat alive: when run panics or returns while the game is
+// still going, it is restarted with the memory rebuilt by the game (ActionSeatRestarted), up to
+// maxAIRestarts times. After that the game ends with "ai_failure", so the human is not left waiting and
+// the match is not rated. run receives the tiles the AI should start out knowing (nil on the first run).
+func superviseAI(g *game.Game, seat int, name string, run func(known map[int]int), known map[int]int) {
+	for restarts := 0; ; restarts++ {
+		err := runRecovered(func() { run(known) })
+		if gameEnded(g) {
+			return
+		}
+		slog.Warn("AI stopped mid-game", "tag", "matchmaking", "match_id", g.ID, "ai", name, "error", err, "restarts", restarts)
+		if restarts >= maxAIRestarts {
+			sendAction(g, game.Action{Type: game.ActionAIFailed, PlayerIdx: seat})
+			return
+		}
+		reply := make(chan map[int]int, 1)
+		if !sendAction(g, game.Action{Type: game.ActionSeatRestarted, PlayerIdx: seat, KnownReply: reply}) {
+			return
+		}
+		select {
+		case known = <-reply:
+		case <-g.Done:
+			return
+		}
+	}
+}
+
+// runRecovered calls run and turns a panic into an error; a normal return yields an error as well, since
+// the AI is only expected to return once the game is over.
+func runRecovered(run func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	run()
+	return fmt.Errorf("returned before the game ended")
+}
+
+// gameEnded reports whether the game loop finishes within aiExitGrace.
+func gameEnded(g *game.Game) bool {
+	select {
+	case <-g.Done:
+		return true
+	case <-time.After(aiExitGrace):
+		return false
+	}
+}
+
+// sendAction delivers an internal action to the game loop; false if the game ended first.
+func sendAction(g *game.Game, action game.Action) bool {
+	select {
+	case g.Actions <- action:
+		return true
+	case <-g.Done:
+		return false
+	}
+}
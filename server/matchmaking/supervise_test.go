@@ -0,0 +1,115 @@
+package matchmaking
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/powerup"
+)
+
+func newSupervisedGame(t *testing.T) (*game.Game, chan []byte, chan []byte) {
+	t.Helper()
+	aiExitGrace = 50 * time.Millisecond
+	t.Cleanup(func() { aiExitGrace = time.Second })
+	humanSend := make(chan []byte, 64)
+	aiSend := make(chan []byte, 64)
+	cfg := &config.Config{BoardRows: 4, BoardCols: 4, RevealDurationMS: 100, MaxNameLength: 24}
+	g, err := game.NewGame("supervised", cfg, game.NewPlayer("Alice", humanSend), game.NewPlayer("Bot", aiSend), powerup.NewBuiltinRegistry(nil, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go g.Run()
+	return g, humanSend, aiSend
+}
+
+// supervise runs superviseAI on seat 1 of g and, before the test's cleanup restores aiExitGrace, waits for
+// it to return, so the supervisor never reads the grace while the cleanup writes it.
+func supervise(t *testing.T, g *game.Game, run func(known map[int]int)) {
+	t.Helper()
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		superviseAI(g, 1, "Bot", run, nil)
+	}()
+	t.Cleanup(func() {
+		select {
+		case <-returned:
+		case <-time.After(2 * time.Second):
+			t.Error("expected the supervisor to return once the game ended")
+		}
+	})
+}
+
+func waitForGameOver(t *testing.T, ch chan []byte) map[string]any {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case data := <-ch:
+			var msg map[string]any
+			json.Unmarshal(data, &msg)
+			if msg["type"] == "game_over" {
+				return msg
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for game_over")
+		}
+	}
+}
+
+func TestSuperviseAI_EndsGameWhenAIKeepsFailing(t *testing.T) {
+	g, humanSend, _ := newSupervisedGame(t)
+	var runs atomic.Int32
+	supervise(t, g, func(map[int]int) {
+		runs.Add(1)
+		panic("boom")
+	})
+
+	msg := waitForGameOver(t, humanSend)
+	if msg["endReason"] != "ai_failure" || msg["result"] != "win" {
+		t.Errorf("expected the human to win with endReason ai_failure, got %v", msg)
+	}
+	if n := runs.Load(); n != maxAIRestarts+1 {
+		t.Errorf("expected %d runs, got %d", maxAIRestarts+1, n)
+	}
+}
+
+func TestSuperviseAI_RestartsWithRebuiltMemory(t *testing.T) {
+	g, _, aiSend := newSupervisedGame(t)
+	restarted := make(chan map[int]int, 1)
+	var runs atomic.Int32
+	supervise(t, g, func(known map[int]int) {
+		if runs.Add(1) == 1 {
+			panic("boom")
+		}
+		restarted <- known
+		<-g.Done
+	})
+
+	select {
+	case known := <-restarted:
+		if known == nil {
+			t.Error("expected rebuilt memory on restart")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the AI to be restarted")
+	}
+	// The restarted AI is sent a fresh view so it can act even if the old one died mid-turn.
+	time.Sleep(50 * time.Millisecond)
+	states := 0
+	for len(aiSend) > 0 {
+		var msg map[string]any
+		json.Unmarshal(<-aiSend, &msg)
+		if msg["type"] == "game_state" {
+			states++
+		}
+	}
+	if states < 2 {
+		t.Errorf("expected the initial and a resent game_state, got %d", states)
+	}
+	g.Actions <- game.Action{Type: game.ActionDisconnect, PlayerIdx: 0}
+}
@@ -0,0 +1,447 @@
+package matchmaking
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"memory-game-server/game"
+	"memory-game-server/storage"
+)
+
+// turnEvent, arcanaEvent, handOverflowEvent, pityEvent and draftEvent hold telemetry data for async flush.
+type turnEvent struct {
+	matchID            string
+	round              int
+	playerIdx          int
+	playerScoreAfter   int
+	opponentScoreAfter int
+	deltaPlayer        int
+	deltaOpponent      int
+	pairsMatched       int
+	latency            game.TurnLatency
+}
+
+type arcanaEvent struct {
+	matchID             string
+	round               int
+	playerIdx           int
+	powerUpID           string
+	targetCardIndex     int
+	playerScoreBefore   int
+	opponentScoreBefore int
+	pairsMatchedBefore  int
+}
+
+type handOverflowEvent struct {
+	matchID            string
+	round              int
+	playerIdx          int
+	powerUpID          string
+	rule               string
+	discardedPowerUpID string
+}
+
+type pityEvent struct {
+	matchID       string
+	round         int
+	playerIdx     int
+	powerUpID     string
+	playerScore   int
+	opponentScore int
+}
+
+type draftEvent struct {
+	matchID    string
+	playerIdx  int
+	powerUpID  string
+	offered    []string
+	autoPicked bool
+}
+
+type replayEvent struct {
+	matchID string
+	event   game.ReplayEvent
+}
+
+// matchTelemetry is the queued telemetry of one match.
+type matchTelemetry struct {
+	seq       uint64 // order of the match's first event; the lowest is evicted first
+	turns     []turnEvent
+	arcanas   []arcanaEvent
+	overflows []handOverflowEvent
+	pities    []pityEvent
+	drafts    []draftEvent
+	replays   []replayEvent
+}
+
+func (t *matchTelemetry) events() int {
+	return len(t.turns) + len(t.arcanas) + len(t.overflows) + len(t.pities) + len(t.drafts) + len(t.replays)
+}
+
+// telemetryFlushBacklog is how many ended matches may wait for the flusher; FlushMatch drops the events
+// of any match beyond that.
+const telemetryFlushBacklog = 256
+
+// telemetryEvictedMarks caps the evicted marks of matches that have not ended (see evictLocked).
+const telemetryEvictedMarks = 1024
+
+type telemetryFlush struct {
+	matchID string
+	t       *matchTelemetry
+}
+
+// queuedTelemetrySink implements game.TelemetrySink and game.ReplaySink by queueing events in memory
+// per match, so the game loop does not block on I/O. When a match ends, FlushMatch hands its events to
+// a background flusher that writes them with the end-of-game retries (persistPipeline).
+type queuedTelemetrySink struct {
+	store    storage.HistoryStore
+	persist  *persistPipeline
+	inFlight *atomic.Int64 // the matchmaker's persistInFlight: counts flushes not written yet (see Shutdown)
+	// maxEvents caps the events queued for matches still running; 0 = no cap (see evictLocked).
+	maxEvents int
+	flushes   chan telemetryFlush
+
+	mu      sync.Mutex
+	matches map[string]*matchTelemetry
+	// evicted holds the matches dropped by evictLocked that have not ended yet, with the seq of their
+	// first event: their later events are dropped too, until FlushMatch or DiscardMatch.
+	evicted        map[string]uint64
+	nextSeq        uint64
+	queued         int // events in matches
+	evictedMatches int64
+	evictedEvents  int64
+	droppedFlushes int64
+}
+
+// TelemetryQueueStats is a snapshot of a matchmaker's queued telemetry.
+type TelemetryQueueStats struct {
+	// QueuedEvents and QueuedMatches cover matches still running (their events are written when they end).
+	QueuedEvents  int
+	QueuedMatches int
+	// EvictedMatches and EvictedEvents count what was dropped since the server started to stay within
+	// TelemetryQueueMax, including the events evicted matches recorded afterwards.
+	EvictedMatches int64
+	EvictedEvents  int64
+	// DroppedFlushes counts the ended matches whose events were dropped because telemetryFlushBacklog
+	// matches already waited for the flusher.
+	DroppedFlushes int64
+}
+
+// newQueuedTelemetrySink returns a sink that queues telemetry and replay events, and starts its flusher.
+// Events are persisted only when FlushMatch(matchID) is called (after InsertGameResult).
+func newQueuedTelemetrySink(store storage.HistoryStore, persist *persistPipeline, inFlight *atomic.Int64, maxEvents int) *queuedTelemetrySink {
+	s := &queuedTelemetrySink{
+		store:     store,
+		persist:   persist,
+		inFlight:  inFlight,
+		maxEvents: maxEvents,
+		flushes:   make(chan telemetryFlush, telemetryFlushBacklog),
+		matches:   make(map[string]*matchTelemetry),
+		evicted:   make(map[string]uint64),
+	}
+	go s.run()
+	return s
+}
+
+// add queues one event of matchID (appended by fn), then evicts if the queue is over its cap. The event
+// is dropped when the match was already evicted.
+func (s *queuedTelemetrySink) add(matchID string, fn func(t *matchTelemetry)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.evicted[matchID]; ok {
+		s.evictedEvents++
+		return
+	}
+	t := s.matches[matchID]
+	if t == nil {
+		s.nextSeq++
+		t = &matchTelemetry{seq: s.nextSeq}
+		s.matches[matchID] = t
+	}
+	fn(t)
+	s.queued++
+	s.evictLocked()
+}
+
+// evictLocked drops whole matches, oldest first event first, until the queue is within maxEvents. The
+// oldest match is the likeliest to never end (e.g. a game leaked by a bug), and a match with part of its
+// events would be misleading: the match stays marked as evicted, so the events it records afterwards are
+// dropped as well. Past telemetryEvictedMarks marks the oldest is forgotten: a match evicted that long ago
+// has most likely leaked, and if it does end, only its events since then are written. Caller holds mu.
+func (s *queuedTelemetrySink) evictLocked() {
+	for s.maxEvents > 0 && s.queued > s.maxEvents {
+		var oldestID string
+		var oldest *matchTelemetry
+		for id, t := range s.matches {
+			if oldest == nil || t.seq < oldest.seq {
+				oldestID, oldest = id, t
+			}
+		}
+		n := oldest.events()
+		delete(s.matches, oldestID)
+		s.evicted[oldestID] = oldest.seq
+		s.queued -= n
+		s.evictedMatches++
+		s.evictedEvents += int64(n)
+		slog.Warn("telemetry queue full, dropped the events of a match", "tag", "persist", "match_id", oldestID, "events", n, "max_events", s.maxEvents)
+	}
+	for len(s.evicted) > telemetryEvictedMarks {
+		var oldestID string
+		var oldestSeq uint64
+		for id, seq := range s.evicted {
+			if oldestID == "" || seq < oldestSeq {
+				oldestID, oldestSeq = id, seq
+			}
+		}
+		delete(s.evicted, oldestID)
+	}
+}
+
+// take removes matchID's events from the queue, along with its evicted mark: it is only called once the
+// match has ended. Returns nil when it has none.
+func (s *queuedTelemetrySink) take(matchID string) *matchTelemetry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.evicted, matchID)
+	t := s.matches[matchID]
+	if t != nil {
+		delete(s.matches, matchID)
+		s.queued -= t.events()
+	}
+	return t
+}
+
+// RecordTurn enqueues a turn event; non-blocking.
+func (s *queuedTelemetrySink) RecordTurn(matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent, pairsMatched int, latency game.TurnLatency) {
+	s.add(matchID, func(t *matchTelemetry) {
+		t.turns = append(t.turns, turnEvent{
+			matchID:            matchID,
+			round:              round,
+			playerIdx:          playerIdx,
+			playerScoreAfter:   playerScoreAfter,
+			opponentScoreAfter: opponentScoreAfter,
+			deltaPlayer:        deltaPlayer,
+			deltaOpponent:      deltaOpponent,
+			pairsMatched:       pairsMatched,
+			latency:            latency,
+		})
+	})
+}
+
+// RecordArcanaUse enqueues an arcana use event; non-blocking.
+func (s *queuedTelemetrySink) RecordArcanaUse(matchID string, round, playerIdx int, powerUpID string, targetCardIndex int, playerScoreBefore, opponentScoreBefore, pairsMatchedBefore int) {
+	s.add(matchID, func(t *matchTelemetry) {
+		t.arcanas = append(t.arcanas, arcanaEvent{
+			matchID:             matchID,
+			round:               round,
+			playerIdx:           playerIdx,
+			powerUpID:           powerUpID,
+			targetCardIndex:     targetCardIndex,
+			playerScoreBefore:   playerScoreBefore,
+			opponentScoreBefore: opponentScoreBefore,
+			pairsMatchedBefore:  pairsMatchedBefore,
+		})
+	})
+}
+
+// RecordHandOverflow enqueues a hand overflow event; non-blocking.
+func (s *queuedTelemetrySink) RecordHandOverflow(matchID string, round, playerIdx int, powerUpID, rule, discardedPowerUpID string) {
+	s.add(matchID, func(t *matchTelemetry) {
+		t.overflows = append(t.overflows, handOverflowEvent{
+			matchID:            matchID,
+			round:              round,
+			playerIdx:          playerIdx,
+			powerUpID:          powerUpID,
+			rule:               rule,
+			discardedPowerUpID: discardedPowerUpID,
+		})
+	})
+}
+
+// RecordPityGrant enqueues a pity timer grant; non-blocking.
+func (s *queuedTelemetrySink) RecordPityGrant(matchID string, round, playerIdx int, powerUpID string, playerScore, opponentScore int) {
+	s.add(matchID, func(t *matchTelemetry) {
+		t.pities = append(t.pities, pityEvent{
+			matchID:       matchID,
+			round:         round,
+			playerIdx:     playerIdx,
+			powerUpID:     powerUpID,
+			playerScore:   playerScore,
+			opponentScore: opponentScore,
+		})
+	})
+}
+
+// RecordDraftPick enqueues a starting draft pick; non-blocking.
+func (s *queuedTelemetrySink) RecordDraftPick(matchID string, playerIdx int, powerUpID string, offered []string, autoPicked bool) {
+	s.add(matchID, func(t *matchTelemetry) {
+		t.drafts = append(t.drafts, draftEvent{
+			matchID:    matchID,
+			playerIdx:  playerIdx,
+			powerUpID:  powerUpID,
+			offered:    offered,
+			autoPicked: autoPicked,
+		})
+	})
+}
+
+// RecordReplayEvent enqueues a replay event; non-blocking.
+func (s *queuedTelemetrySink) RecordReplayEvent(matchID string, ev game.ReplayEvent) {
+	s.add(matchID, func(t *matchTelemetry) {
+		t.replays = append(t.replays, replayEvent{matchID: matchID, event: ev})
+	})
+}
+
+// FlushMatch hands the queued turn, arcana_use, hand_overflow, arcana_pity, draft_pick and replay events
+// of the given match to the flusher, which writes them in the background. Must be called after the
+// game_history row exists (e.g. after InsertGameResult in OnGameEnd), since those tables reference
+// game_history(id). Never blocks: when telemetryFlushBacklog matches already wait for the flusher, the
+// match's events are dropped and counted in DroppedFlushes.
+func (s *queuedTelemetrySink) FlushMatch(matchID string) {
+	if s == nil {
+		return
+	}
+	t := s.take(matchID)
+	if t == nil {
+		return
+	}
+	s.inFlight.Add(1)
+	select {
+	case s.flushes <- telemetryFlush{matchID: matchID, t: t}:
+	default:
+		s.inFlight.Add(-1)
+		s.mu.Lock()
+		s.droppedFlushes++
+		s.mu.Unlock()
+		slog.Warn("telemetry flusher backlogged, dropped the events of a match", "tag", "persist", "match_id", matchID, "events", t.events(), "backlog", telemetryFlushBacklog)
+	}
+}
+
+// DiscardMatch drops the queued events of a match whose game_history row could not be written, so they
+// do not pile up in the queue (they could not be inserted without it).
+func (s *queuedTelemetrySink) DiscardMatch(matchID string) {
+	if s == nil {
+		return
+	}
+	s.take(matchID)
+}
+
+// Stats returns the queue's size and eviction counters.
+func (s *queuedTelemetrySink) Stats() TelemetryQueueStats {
+	if s == nil {
+		return TelemetryQueueStats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return TelemetryQueueStats{QueuedEvents: s.queued, QueuedMatches: len(s.matches), EvictedMatches: s.evictedMatches, EvictedEvents: s.evictedEvents, DroppedFlushes: s.droppedFlushes}
+}
+
+// run is the flusher: it writes the flushed matches one at a time, for as long as the process runs.
+func (s *queuedTelemetrySink) run() {
+	for f := range s.flushes {
+		s.write(f.matchID, f.t)
+		s.inFlight.Add(-1)
+	}
+}
+
+// write persists one match's events, each table as one end-of-game write step with retries and backoff;
+// a step that fails every attempt is dead-lettered (logged) and the next one still runs. Turns and
+// arcana uses go in one batch each, which writes all of its rows or none. The other tables are written
+// row by row, and a retry resumes from the row that failed. The score series is cached from the turn
+// rows once they are written; when they are not, it is left unset and the match summary rebuilds it.
+//
+// Arcana point_delta is computed as the score change from the moment the card was used until
+// the end of that turn (for balance telemetry: direct and indirect impact of the card in the turn).
+func (s *queuedTelemetrySink) write(matchID string, t *matchTelemetry) {
+	turnRecords := make([]storage.TurnRecord, 0, len(t.turns))
+	for _, e := range t.turns {
+		turnRecords = append(turnRecords, storage.TurnRecord{
+			Round: e.round, PlayerIdx: e.playerIdx, PlayerScoreAfter: e.playerScoreAfter, OpponentScoreAfter: e.opponentScoreAfter,
+			DeltaPlayer: e.deltaPlayer, DeltaOpponent: e.deltaOpponent, PairsMatched: e.pairsMatched,
+			Actions: e.latency.Actions, AvgProcessingMS: e.latency.AvgProcessingMS, MaxProcessingMS: e.latency.MaxProcessingMS, RTTMS: e.latency.RTTMS,
+		})
+	}
+	var turnsErr error
+	if len(turnRecords) > 0 {
+		turnsErr = s.persist.do(matchID, persistTurns, func(ctx context.Context) error {
+			return s.store.InsertTurnsBatch(ctx, matchID, turnRecords)
+		}, "turns", len(turnRecords))
+	}
+	if turnsErr == nil {
+		_ = s.persist.do(matchID, persistScoreSeries, func(ctx context.Context) error {
+			return s.store.CacheScoreSeries(ctx, matchID)
+		})
+	}
+	// Build round -> end-of-turn scores for this match (from turn events we just flushed).
+	endScoreByRound := make(map[int]struct{ score0, score1 int })
+	for _, e := range t.turns {
+		if e.playerIdx == 0 {
+			endScoreByRound[e.round] = struct{ score0, score1 int }{e.playerScoreAfter, e.opponentScoreAfter}
+		} else {
+			endScoreByRound[e.round] = struct{ score0, score1 int }{e.opponentScoreAfter, e.playerScoreAfter}
+		}
+	}
+	arcanaRecords := make([]storage.ArcanaUseRecord, 0, len(t.arcanas))
+	for _, e := range t.arcanas {
+		deltaPlayer, deltaOpponent := 0, 0
+		if end, ok := endScoreByRound[e.round]; ok {
+			// Delta from card use until end of turn for the player who used the card and the opponent.
+			if e.playerIdx == 0 {
+				deltaPlayer = end.score0 - e.playerScoreBefore
+				deltaOpponent = end.score1 - e.opponentScoreBefore
+			} else {
+				deltaPlayer = end.score1 - e.playerScoreBefore
+				deltaOpponent = end.score0 - e.opponentScoreBefore
+			}
+		}
+		arcanaRecords = append(arcanaRecords, storage.ArcanaUseRecord{
+			Round: e.round, PlayerIdx: e.playerIdx, PowerUpID: e.powerUpID, TargetCardIndex: e.targetCardIndex,
+			PlayerScoreBefore: e.playerScoreBefore, OpponentScoreBefore: e.opponentScoreBefore, PairsMatchedBefore: e.pairsMatchedBefore,
+			PointDeltaPlayer: deltaPlayer, PointDeltaOpponent: deltaOpponent,
+		})
+	}
+	if len(arcanaRecords) > 0 {
+		_ = s.persist.do(matchID, persistArcanaUses, func(ctx context.Context) error {
+			return s.store.InsertArcanaUsesBatch(ctx, matchID, arcanaRecords)
+		}, "arcana_uses", len(arcanaRecords))
+	}
+	writeRows(s.persist, matchID, persistHandOverflows, t.overflows, func(ctx context.Context, e handOverflowEvent) error {
+		return s.store.InsertHandOverflow(ctx, e.matchID, e.round, e.playerIdx, e.powerUpID, e.rule, e.discardedPowerUpID)
+	})
+	writeRows(s.persist, matchID, persistPityGrants, t.pities, func(ctx context.Context, e pityEvent) error {
+		return s.store.InsertPityGrant(ctx, e.matchID, e.round, e.playerIdx, e.powerUpID, e.playerScore, e.opponentScore)
+	})
+	writeRows(s.persist, matchID, persistDraftPicks, t.drafts, func(ctx context.Context, e draftEvent) error {
+		return s.store.InsertDraftPick(ctx, e.matchID, e.playerIdx, e.powerUpID, e.offered, e.autoPicked)
+	})
+	if len(t.replays) > 0 {
+		replays := make([]storage.ReplayEvent, 0, len(t.replays))
+		for _, e := range t.replays {
+			data, _ := json.Marshal(e.event)
+			replays = append(replays, storage.ReplayEvent{Seq: e.event.Seq, Type: e.event.Type, Data: data})
+		}
+		_ = s.persist.do(matchID, persistReplayEvents, func(ctx context.Context) error {
+			return s.store.InsertReplayEvents(ctx, matchID, replays)
+		}, "events", len(replays))
+	}
+}
+
+// writeRows inserts rows one by one as a single write step; a retry resumes from the row that failed,
+// so the rows already written are not duplicated.
+func writeRows[E any](p *persistPipeline, matchID, step string, rows []E, insert func(ctx context.Context, e E) error) {
+	if len(rows) == 0 {
+		return
+	}
+	next := 0
+	_ = p.do(matchID, step, func(ctx context.Context) error {
+		for ; next < len(rows); next++ {
+			if err := insert(ctx, rows[next]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, "rows", len(rows))
+}
@@ -0,0 +1,228 @@
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memory-game-server/config"
+	"memory-game-server/game"
+	"memory-game-server/storage"
+)
+
+// flakyTelemetryStore fails the first turn batch and the second pity grant once, as a database hiccup.
+// With turnsDown set, every turn batch fails.
+type flakyTelemetryStore struct {
+	storage.HistoryStore
+	mu         sync.Mutex
+	turnsDown  bool
+	turnCalls  int
+	turns      int
+	pityCalls  int
+	pityRounds []int
+	// series holds, for each CacheScoreSeries call, how many turns were written when it ran.
+	series []int
+}
+
+func (s *flakyTelemetryStore) InsertTurnsBatch(_ context.Context, _ string, turns []storage.TurnRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turnCalls++
+	if s.turnCalls == 1 || s.turnsDown {
+		return errors.New("connection reset")
+	}
+	s.turns += len(turns)
+	return nil
+}
+
+func (s *flakyTelemetryStore) CacheScoreSeries(context.Context, string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series = append(s.series, s.turns)
+	return nil
+}
+
+// waitFlushed waits for mm's end-of-game writes to finish.
+func waitFlushed(t *testing.T, mm *Matchmaker) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for mm.persistInFlight.Load() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := mm.persistInFlight.Load(); n != 0 {
+		t.Fatalf("expected the flush to finish, %d still in flight", n)
+	}
+}
+
+func (s *flakyTelemetryStore) InsertPityGrant(_ context.Context, _ string, round, _ int, _ string, _, _ int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pityCalls++
+	if s.pityCalls == 2 {
+		return errors.New("connection reset")
+	}
+	s.pityRounds = append(s.pityRounds, round)
+	return nil
+}
+
+func TestQueuedTelemetrySink_FlushRetriesInBackground(t *testing.T) {
+	old := persistBackoff
+	persistBackoff = time.Millisecond
+	defer func() { persistBackoff = old }()
+
+	store := &flakyTelemetryStore{}
+	mm := NewMatchmaker(&config.Config{}, nil, store)
+	sink := mm.queuedSink
+	sink.RecordTurn("m1", 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	sink.RecordTurn("m1", 2, 1, 0, 1, 0, 0, 0, game.TurnLatency{})
+	for round := 1; round <= 3; round++ {
+		sink.RecordPityGrant("m1", round, 0, "chaos", 0, 0)
+	}
+	sink.FlushMatch("m1")
+
+	waitFlushed(t, mm)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.turnCalls != 2 || store.turns != 2 {
+		t.Errorf("expected the turn batch to be retried once, got %d calls writing %d turns", store.turnCalls, store.turns)
+	}
+	if len(store.pityRounds) != 3 || store.pityRounds[0] != 1 || store.pityRounds[2] != 3 {
+		t.Errorf("expected each pity grant written once, in order, got rounds %v", store.pityRounds)
+	}
+	for _, st := range mm.PersistStats() {
+		if (st.Step == persistTurns || st.Step == persistPityGrants) && (st.Succeeded != 1 || st.Retries != 1) {
+			t.Errorf("expected %s to succeed after one retry, got %+v", st.Step, st)
+		}
+	}
+	if q := mm.TelemetryQueueStats(); q.QueuedEvents != 0 || q.QueuedMatches != 0 {
+		t.Errorf("expected an empty queue after the flush, got %+v", q)
+	}
+}
+
+func TestQueuedTelemetrySink_EvictsOldestMatchOverCap(t *testing.T) {
+	var inFlight atomic.Int64
+	sink := newQueuedTelemetrySink(&flakyTelemetryStore{}, &persistPipeline{}, &inFlight, 3)
+	sink.RecordTurn("stale", 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	sink.RecordTurn("stale", 2, 1, 0, 1, 0, 0, 0, game.TurnLatency{})
+	sink.RecordTurn("live", 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	sink.RecordReplayEvent("live", game.ReplayEvent{Seq: 1})
+
+	st := sink.Stats()
+	if st.QueuedEvents != 2 || st.QueuedMatches != 1 || st.EvictedMatches != 1 || st.EvictedEvents != 2 {
+		t.Errorf("expected the older match to be evicted whole, got %+v", st)
+	}
+
+	// The evicted match is still running: its later events are dropped rather than queued as a partial match.
+	sink.RecordReplayEvent("stale", game.ReplayEvent{Seq: 3})
+	if st := sink.Stats(); st.QueuedMatches != 1 || st.EvictedEvents != 3 {
+		t.Errorf("expected the evicted match's later event to be dropped, got %+v", st)
+	}
+	if sink.take("stale") != nil || sink.take("live") == nil {
+		t.Error("expected only the newer match to be left")
+	}
+	// Ending the match clears the mark.
+	sink.RecordTurn("stale", 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	if sink.take("stale") == nil {
+		t.Error("expected the evicted mark to be cleared when the match ended")
+	}
+}
+
+func TestQueuedTelemetrySink_ForgetsOldestEvictedMarks(t *testing.T) {
+	var inFlight atomic.Int64
+	sink := newQueuedTelemetrySink(&flakyTelemetryStore{}, &persistPipeline{}, &inFlight, 1)
+	// With room for one event, each new match evicts the one before it.
+	for i := range telemetryEvictedMarks + 2 {
+		sink.RecordTurn(fmt.Sprintf("m%d", i), 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	}
+	sink.mu.Lock()
+	marks := len(sink.evicted)
+	_, first := sink.evicted["m0"]
+	sink.mu.Unlock()
+	if marks != telemetryEvictedMarks || first {
+		t.Errorf("expected %d marks without the oldest, got %d (m0 marked: %v)", telemetryEvictedMarks, marks, first)
+	}
+}
+
+// stalledTelemetryStore blocks every turn batch until release is closed.
+type stalledTelemetryStore struct {
+	storage.HistoryStore
+	release chan struct{}
+}
+
+func (s *stalledTelemetryStore) InsertTurnsBatch(context.Context, string, []storage.TurnRecord) error {
+	<-s.release
+	return nil
+}
+
+func (s *stalledTelemetryStore) CacheScoreSeries(context.Context, string) error { return nil }
+
+func TestQueuedTelemetrySink_DropsFlushesWhenBacklogged(t *testing.T) {
+	var inFlight atomic.Int64
+	store := &stalledTelemetryStore{release: make(chan struct{})}
+	sink := newQueuedTelemetrySink(store, &persistPipeline{}, &inFlight, 0)
+	const matches = telemetryFlushBacklog + 2
+
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		for i := range matches {
+			id := fmt.Sprintf("m%d", i)
+			sink.RecordTurn(id, 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+			sink.FlushMatch(id)
+		}
+	}()
+	select {
+	case <-flushed:
+	case <-time.After(2 * time.Second):
+		close(store.release)
+		t.Fatal("expected FlushMatch not to block on a stalled flusher")
+	}
+
+	// The flusher holds one match at most; the backlog holds the next ones and the rest are dropped.
+	dropped := sink.Stats().DroppedFlushes
+	if dropped < 1 || dropped > 2 || inFlight.Load() != matches-dropped {
+		t.Errorf("expected 1 or 2 dropped flushes and the rest in flight, got %d dropped, %d in flight", dropped, inFlight.Load())
+	}
+	close(store.release)
+	deadline := time.Now().Add(2 * time.Second)
+	for inFlight.Load() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := inFlight.Load(); n != 0 {
+		t.Errorf("expected the backlog to drain, %d still in flight", n)
+	}
+}
+
+func TestQueuedTelemetrySink_CachesScoreSeriesAfterTurns(t *testing.T) {
+	old := persistBackoff
+	persistBackoff = time.Millisecond
+	defer func() { persistBackoff = old }()
+
+	store := &flakyTelemetryStore{}
+	mm := NewMatchmaker(&config.Config{}, nil, store)
+	mm.queuedSink.RecordTurn("m1", 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	mm.queuedSink.RecordTurn("m1", 2, 1, 0, 1, 0, 0, 0, game.TurnLatency{})
+	mm.queuedSink.FlushMatch("m1")
+	waitFlushed(t, mm)
+	store.mu.Lock()
+	if len(store.series) != 1 || store.series[0] != 2 {
+		t.Errorf("expected the series cached once, from both turns, got %v", store.series)
+	}
+	store.mu.Unlock()
+
+	// Without its turn rows the series is left unset, so the match summary rebuilds it later.
+	down := &flakyTelemetryStore{turnsDown: true}
+	mm = NewMatchmaker(&config.Config{}, nil, down)
+	mm.queuedSink.RecordTurn("m2", 1, 0, 1, 0, 1, 0, 0, game.TurnLatency{})
+	mm.queuedSink.FlushMatch("m2")
+	waitFlushed(t, mm)
+	down.mu.Lock()
+	defer down.mu.Unlock()
+	if len(down.series) != 0 {
+		t.Errorf("expected no series cached when the turns were not written, got %v", down.series)
+	}
+}
@@ -0,0 +1,83 @@
+// Package modes is the table of game modes a player can pick with set_name: how each one queues, whether
+// it is rated and recorded, and how many players it seats. Matchmaking, game creation, match history and
+// GET /api/modes all read it instead of keeping their own per-mode flags.
+package modes
+
+// Mode IDs, as sent in set_name's mode (and returned in status' queueMode and match_found's mode).
+const (
+	Ranked  = "ranked"
+	Casual  = "casual"
+	Raid    = "raid"
+	Party   = "party"
+	Hotseat = "hotseat"
+)
+
+// RatingBucketElo is the realm leaderboard rating. It is the only rating bucket: rated modes all share it.
+const RatingBucketElo = "elo"
+
+// Mode describes one game mode.
+type Mode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Queue is the matchmaking queue the mode waits in; entries only pair within the same queue (and
+	// board size). Empty for modes that start at once without an opponent to wait for.
+	Queue string `json:"queue,omitempty"`
+	// RatingBucket is the rating a finished game updates; empty for unrated modes.
+	RatingBucket string `json:"rating_bucket,omitempty"`
+	// Recorded games are written to match history (and count in stats), with a resume checkpoint while
+	// they run; the others only log their result.
+	Recorded bool `json:"recorded"`
+	// MinPlayers to MaxPlayers humans play one game, DefaultPlayers when set_name does not say.
+	MinPlayers     int `json:"min_players"`
+	MaxPlayers     int `json:"max_players"`
+	DefaultPlayers int `json:"default_players"`
+	// AIFallback is set when the AI steps in after nobody was found within the AI pair timeout.
+	AIFallback bool `json:"ai_fallback,omitempty"`
+	// BoardChoice is set when players may ask for one of the configured board sizes.
+	BoardChoice bool `json:"board_choice"`
+}
+
+// Rated reports whether finished games of the mode update a rating.
+func (m Mode) Rated() bool {
+	return m.RatingBucket != ""
+}
+
+// registry lists every mode, in the order menus show them.
+var registry = []Mode{
+	{ID: Ranked, Name: "Ranked", Queue: Ranked, RatingBucket: RatingBucketElo, Recorded: true,
+		MinPlayers: 1, MaxPlayers: 2, DefaultPlayers: 2, AIFallback: true, BoardChoice: true},
+	{ID: Casual, Name: "Casual", Queue: Casual, Recorded: true,
+		MinPlayers: 1, MaxPlayers: 2, DefaultPlayers: 2, AIFallback: true, BoardChoice: true},
+	{ID: Raid, Name: "Co-op raid", Queue: Raid,
+		MinPlayers: 2, MaxPlayers: 2, DefaultPlayers: 2},
+	{ID: Party, Name: "Party", Queue: Party,
+		MinPlayers: 3, MaxPlayers: 4, DefaultPlayers: 3, BoardChoice: true},
+	{ID: Hotseat, Name: "Pass and play",
+		MinPlayers: 2, MaxPlayers: 2, DefaultPlayers: 2, BoardChoice: true},
+}
+
+// All returns every mode, in menu order.
+func All() []Mode {
+	return append([]Mode(nil), registry...)
+}
+
+// Lookup returns the mode with the given ID; an empty ID is Ranked, the default queue.
+func Lookup(id string) (Mode, bool) {
+	if id == "" {
+		id = Ranked
+	}
+	for _, m := range registry {
+		if m.ID == id {
+			return m, true
+		}
+	}
+	return Mode{}, false
+}
+
+// Get is Lookup for IDs already validated (set_name checked them); an unknown ID yields Ranked.
+func Get(id string) Mode {
+	if m, ok := Lookup(id); ok {
+		return m
+	}
+	return registry[0]
+}
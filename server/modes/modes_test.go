@@ -0,0 +1,38 @@
+package modes
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	if m, ok := Lookup(""); !ok || m.ID != Ranked || !m.Rated() {
+		t.Errorf("expected an empty mode to be ranked, got %+v", m)
+	}
+	if m, ok := Lookup(Casual); !ok || m.Rated() || !m.Recorded || m.Queue == Get(Ranked).Queue {
+		t.Errorf("expected casual to be recorded, unrated and queued apart from ranked, got %+v", m)
+	}
+	if _, ok := Lookup("blitz"); ok {
+		t.Error("expected an unknown mode not to be found")
+	}
+	if m := Get("blitz"); m.ID != Ranked {
+		t.Errorf("expected Get to fall back to ranked, got %q", m.ID)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	seen := map[string]bool{}
+	for _, m := range All() {
+		if seen[m.ID] {
+			t.Errorf("mode %q registered twice", m.ID)
+		}
+		seen[m.ID] = true
+		if m.MinPlayers < 1 || m.MinPlayers > m.DefaultPlayers || m.DefaultPlayers > m.MaxPlayers {
+			t.Errorf("mode %q: expected min <= default <= max players, got %d/%d/%d", m.ID, m.MinPlayers, m.DefaultPlayers, m.MaxPlayers)
+		}
+		if m.Rated() && !m.Recorded {
+			t.Errorf("mode %q: a rated mode must be recorded", m.ID)
+		}
+	}
+	All()[0].ID = "changed"
+	if Get(Ranked).ID != Ranked {
+		t.Error("expected All to return a copy")
+	}
+}
@@ -0,0 +1,25 @@
+package powerup
+
+import (
+	"memory-game-server/game"
+)
+
+// PeekPowerUp shows the buyer one hidden tile of their choice; the tile stays hidden on the board.
+// It is never dealt as an arcana pair: players buy it with score points (CostValue; 0 = not for sale).
+// The reveal is sent in the game layer (handleUsePowerUp, sendPeekResult).
+type PeekPowerUp struct {
+	CostValue int
+}
+
+func (p *PeekPowerUp) ID() string   { return "peek" }
+func (p *PeekPowerUp) Name() string { return "Peek" }
+func (p *PeekPowerUp) Description() string {
+	return "Buy a quick look at one hidden tile. Only you see it."
+}
+func (p *PeekPowerUp) Cost() int   { return p.CostValue }
+func (p *PeekPowerUp) Rarity() int { return RarityShopOnly }
+
+func (p *PeekPowerUp) Apply(board *game.Board, active *game.Player, opponent *game.Player, ctx *game.PowerUpContext) error {
+	// Effect is applied in game.handleUsePowerUp (private reveal).
+	return nil
+}
@@ -2,6 +2,7 @@ package powerup
 
 import (
 	"math/rand"
+	"sync"
 
 	"memory-game-server/config"
 	"memory-game-server/game"
@@ -14,6 +15,8 @@ const (
 	RarityCommon   = 1
 	RarityUncommon = 2
 	RarityRare     = 3
+	// RarityShopOnly marks basic power-ups that are only bought with score points, never dealt as arcana pairs.
+	RarityShopOnly = -1
 )
 
 // PowerUp defines the interface that all power-ups must implement.
@@ -30,6 +33,9 @@ type PowerUp interface {
 type Registry struct {
 	powerUps map[string]PowerUp
 	order    []string // registration order for deterministic AllPowerUps()
+
+	rngMu sync.Mutex
+	rng   *rand.Rand // seeded source for PickArcanaForMatch; nil uses the global source
 }
 
 // NewRegistry creates a new empty power-up registry.
@@ -40,6 +46,16 @@ func NewRegistry() *Registry {
 	}
 }
 
+// NewBuiltinRegistry returns a registry holding every built-in power-up (see RegisterAll) whose
+// PickArcanaForMatch draws from a source seeded with seed, so the same seed deals the same arcana. It is
+// the production set for tests and simulations; safe for concurrent use once created.
+func NewBuiltinRegistry(cfg *config.PowerUpsConfig, seed int64) *Registry {
+	r := NewRegistry()
+	RegisterAll(r, cfg)
+	r.rng = rand.New(rand.NewSource(seed))
+	return r
+}
+
 // Register adds a power-up to the registry.
 func (r *Registry) Register(p PowerUp) {
 	id := p.ID()
@@ -85,10 +101,16 @@ func (r *Registry) AllPowerUps() []game.PowerUpDef {
 }
 
 // PickArcanaForMatch selects n distinct power-ups with probability inverse to Rarity (common = more likely, rare = less likely).
+// Shop-only power-ups are never picked.
 // Cards with RarityMust are always included (for debug/testing); the rest of the slots are filled by weighted selection.
 // It satisfies the game.PowerUpProvider interface.
 func (r *Registry) PickArcanaForMatch(n int) []game.PowerUpDef {
-	all := r.AllPowerUps()
+	var all []game.PowerUpDef
+	for _, p := range r.AllPowerUps() {
+		if p.Rarity != RarityShopOnly {
+			all = append(all, p)
+		}
+	}
 	if n <= 0 || len(all) == 0 {
 		return nil
 	}
@@ -121,20 +143,11 @@ func (r *Registry) PickArcanaForMatch(n int) []game.PowerUpDef {
 		return picked
 	}
 
-	// Weight = 2^(RarityRare-Rarity) so Common=4, Uncommon=2, Rare=1.
 	indices := make([]int, len(pool))
 	weights := make([]int, len(pool))
 	for i := range pool {
 		indices[i] = i
-		shift := RarityRare - pool[i].Rarity
-		if shift < 0 {
-			shift = 0
-		}
-		w := 1 << shift
-		if w < 1 {
-			w = 1
-		}
-		weights[i] = w
+		weights[i] = RarityWeight(pool[i].Rarity)
 	}
 	for len(picked) < n && len(indices) > 0 {
 		var total int
@@ -144,7 +157,7 @@ func (r *Registry) PickArcanaForMatch(n int) []game.PowerUpDef {
 		if total <= 0 {
 			break
 		}
-		roll := rand.Intn(total)
+		roll := r.intn(total)
 		var idx int
 		for i, w := range weights {
 			roll -= w
@@ -160,6 +173,26 @@ func (r *Registry) PickArcanaForMatch(n int) []game.PowerUpDef {
 	return picked
 }
 
+// RarityWeight is the weight PickArcanaForMatch gives a card of the given rarity when filling a slot:
+// 2^(RarityRare-rarity), so Common=4, Uncommon=2, Rare=1.
+func RarityWeight(rarity int) int {
+	shift := RarityRare - rarity
+	if shift < 0 {
+		shift = 0
+	}
+	return 1 << shift
+}
+
+// intn returns a random int in [0, n) from the registry's seeded source, or the global one.
+func (r *Registry) intn(n int) int {
+	if r.rng == nil {
+		return rand.Intn(n)
+	}
+	r.rngMu.Lock()
+	defer r.rngMu.Unlock()
+	return r.rng.Intn(n)
+}
+
 // RegisterAll registers all built-in power-ups on the registry using the given power-up config.
 // Call this from main (or server setup) so adding a new power-up only requires registering it here.
 func RegisterAll(r *Registry, cfg *config.PowerUpsConfig) {
@@ -182,4 +215,6 @@ func RegisterAll(r *Registry, cfg *config.PowerUpsConfig) {
 	r.Register(&FireElementalPowerUp{CostValue: 0})
 	r.Register(&WaterElementalPowerUp{CostValue: 0})
 	r.Register(&AirElementalPowerUp{CostValue: 0})
+	r.Register(&ThirdEyePowerUp{CostValue: 0})
+	r.Register(&PeekPowerUp{CostValue: cfg.Peek.Cost})
 }
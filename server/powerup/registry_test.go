@@ -1,6 +1,7 @@
 package powerup
 
 import (
+	"sync"
 	"testing"
 
 	"memory-game-server/game"
@@ -107,3 +108,55 @@ func TestChaosPowerUpMetadata(t *testing.T) {
 		t.Errorf("expected Cost=5, got %d", s.Cost())
 	}
 }
+
+func TestPickArcanaForMatch_SkipsShopOnly(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&ChaosPowerUp{})
+	r.Register(&SilencePowerUp{})
+	r.Register(&PeekPowerUp{CostValue: 2})
+
+	for range 20 {
+		for _, def := range r.PickArcanaForMatch(3) {
+			if def.ID == "peek" {
+				t.Fatal("expected shop-only peek never to be dealt as an arcana pair")
+			}
+		}
+	}
+	if _, ok := r.GetPowerUp("peek"); !ok {
+		t.Error("expected peek to stay available for purchase")
+	}
+}
+
+func TestNewBuiltinRegistry_SeededPicks(t *testing.T) {
+	a := NewBuiltinRegistry(nil, 42)
+	b := NewBuiltinRegistry(nil, 42)
+	if len(a.AllPowerUps()) != 14 {
+		t.Fatalf("expected every built-in power-up, got %d", len(a.AllPowerUps()))
+	}
+	for range 10 {
+		pa, pb := a.PickArcanaForMatch(game.ArcanaPairsPerMatch), b.PickArcanaForMatch(game.ArcanaPairsPerMatch)
+		if len(pa) != game.ArcanaPairsPerMatch || len(pa) != len(pb) {
+			t.Fatalf("expected %d arcana, got %d and %d", game.ArcanaPairsPerMatch, len(pa), len(pb))
+		}
+		for i := range pa {
+			if pa[i].ID != pb[i].ID {
+				t.Fatalf("expected the same seed to pick the same arcana, got %s and %s", pa[i].ID, pb[i].ID)
+			}
+		}
+	}
+}
+
+func TestNewBuiltinRegistry_ConcurrentPicks(t *testing.T) {
+	r := NewBuiltinRegistry(nil, 1)
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				r.PickArcanaForMatch(game.ArcanaPairsPerMatch)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,25 @@
+package powerup
+
+import (
+	"memory-game-server/game"
+)
+
+// ThirdEyePowerUp lets the player flip a third card this turn whenever the first two do not match;
+// any two of the three that match score. Activation and flip logic are applied in the game layer
+// (handleUsePowerUp, handleFlipCard).
+type ThirdEyePowerUp struct {
+	CostValue int
+}
+
+func (t *ThirdEyePowerUp) ID() string   { return "third_eye" }
+func (t *ThirdEyePowerUp) Name() string { return "Third Eye" }
+func (t *ThirdEyePowerUp) Description() string {
+	return "This turn, when your two tiles do not match, flip a third: any two of the three that match score."
+}
+func (t *ThirdEyePowerUp) Cost() int   { return t.CostValue }
+func (t *ThirdEyePowerUp) Rarity() int { return RarityUncommon }
+
+func (t *ThirdEyePowerUp) Apply(board *game.Board, active *game.Player, opponent *game.Player, ctx *game.PowerUpContext) error {
+	// Effect is applied in game.handleUsePowerUp (ThirdEyeActive) and handleFlipCard (third flip).
+	return nil
+}
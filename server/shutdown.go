@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"memory-game-server/matchmaking"
+)
+
+// shutdownWriteGrace is how long the end-of-game writes of games ended at the shutdown deadline may take.
+const shutdownWriteGrace = 10 * time.Second
+
+// drainMatchmakers stops new matches on every matchmaker (default realm and realms alike) and waits until
+// their games are over and recorded: finished normally within grace, or ended as draws at its end.
+// Gives up shutdownWriteGrace after the deadline.
+func drainMatchmakers(matchmakers []*matchmaking.Matchmaker, grace time.Duration) {
+	deadline := time.Now().Add(grace)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline.Add(shutdownWriteGrace))
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, mm := range matchmakers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := mm.Shutdown(ctx, deadline); err != nil {
+				slog.Error("matchmaker did not drain", "tag", "server", "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveActiveGame stores the latest snapshot of a match in progress (game.GameSnapshot as JSON), replacing
+// the previous one, and points the match's rejoin tokens at it so a rejoin after a restart can resume it.
+func (s *Store) SaveActiveGame(ctx context.Context, realm, matchID string, state []byte) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO active_games (match_id, realm, state, updated_at) VALUES ($1, $2, $3, now())
+		ON CONFLICT (match_id) DO UPDATE SET state = EXCLUDED.state, updated_at = now()`,
+		matchID, realm, state)
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, `UPDATE rejoin_tokens SET snapshot_ref = $1 WHERE match_id::text = $1 AND snapshot_ref IS NULL`, matchID)
+	return err
+}
+
+// LoadActiveGame returns the saved snapshot of a match in the realm, or (nil, nil) if there is none.
+func (s *Store) LoadActiveGame(ctx context.Context, realm, matchID string) ([]byte, error) {
+	if s == nil || s.pool == nil || matchID == "" {
+		return nil, nil
+	}
+	var state []byte
+	err := s.pool.QueryRow(ctx, `SELECT state FROM active_games WHERE match_id::text = $1 AND realm = $2`, matchID, realm).Scan(&state)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return state, err
+}
+
+// DeleteActiveGame removes a match's snapshot once the match is over.
+func (s *Store) DeleteActiveGame(ctx context.Context, matchID string) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `DELETE FROM active_games WHERE match_id::text = $1`, matchID)
+	return err
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// CardBalance is the balance picture of one arcana over a period, from the point of view of the players
+// who used it.
+type CardBalance struct {
+	PowerUpID string `json:"power_up_id"`
+	// Users is the number of (match, player) pairs in which the card was used at least once.
+	Users int `json:"users"`
+	// Wins is how many of those players won the match.
+	Wins       int     `json:"wins"`
+	WinRatePct float64 `json:"win_rate_pct"`
+	// Uses is the number of times the card was used; UseSharePct is its share of every arcana use in the period.
+	Uses        int     `json:"uses"`
+	UseSharePct float64 `json:"use_share_pct"`
+}
+
+// GetCardBalance returns per-arcana win rate (of the players who used it) and use share for games
+// played in [since, until), ordered by power-up ID.
+func (s *Store) GetCardBalance(ctx context.Context, since, until time.Time) ([]CardBalance, error) {
+	if s == nil || s.pool == nil {
+		return []CardBalance{}, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+		WITH users AS (
+			SELECT au.power_up_id, au.match_id, au.player_idx, COUNT(*) AS uses,
+				BOOL_OR(gh.winner_index = au.player_idx) AS won
+			FROM arcana_use au
+			JOIN game_history gh ON gh.id = au.match_id
+			WHERE gh.played_at >= $1 AND gh.played_at < $2
+			GROUP BY au.power_up_id, au.match_id, au.player_idx
+		)
+		SELECT power_up_id, COUNT(*) AS users, COUNT(*) FILTER (WHERE won) AS wins, SUM(uses)::int AS uses
+		FROM users
+		GROUP BY power_up_id
+		ORDER BY power_up_id
+	`, since, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []CardBalance{}
+	totalUses := 0
+	for rows.Next() {
+		var c CardBalance
+		if err := rows.Scan(&c.PowerUpID, &c.Users, &c.Wins, &c.Uses); err != nil {
+			return nil, err
+		}
+		if c.Users > 0 {
+			c.WinRatePct = 100.0 * float64(c.Wins) / float64(c.Users)
+		}
+		totalUses += c.Uses
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range out {
+		if totalUses > 0 {
+			out[i].UseSharePct = 100.0 * float64(out[i].Uses) / float64(totalUses)
+		}
+	}
+	return out, nil
+}
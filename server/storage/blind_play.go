@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// createMatchBlindPlaySQL stores each seat's blind guesses per match (see game.BlindPlay): flips onto cards
+// the player had never been shown that could complete a face-up card, with the hits expected by chance.
+const createMatchBlindPlaySQL = `
+CREATE TABLE IF NOT EXISTS match_blind_play (
+	match_id      UUID NOT NULL REFERENCES game_history(id),
+	player_idx    SMALLINT NOT NULL,
+	guesses       INT NOT NULL,
+	hits          INT NOT NULL,
+	expected_hits DOUBLE PRECISION NOT NULL,
+	hit_variance  DOUBLE PRECISION NOT NULL,
+	PRIMARY KEY (match_id, player_idx)
+);
+`
+
+// BlindPlayFlag is one account whose blind guesses hit far more often than chance over the period.
+type BlindPlayFlag struct {
+	UserID       string  `json:"user_id"`
+	Name         string  `json:"name"`
+	Matches      int     `json:"matches"`
+	Guesses      int     `json:"guesses"`
+	Hits         int     `json:"hits"`
+	ExpectedHits float64 `json:"expected_hits"`
+	HitRatePct   float64 `json:"hit_rate_pct"`
+	// ZScore is how many standard deviations Hits is above ExpectedHits.
+	ZScore       float64 `json:"z_score"`
+	LastPlayedAt string  `json:"last_played_at"` // ISO8601
+}
+
+// blindPlayTotals is one account's blind guesses summed over the period, as read for the report.
+type blindPlayTotals struct {
+	UserID, Name  string
+	Matches       int
+	Guesses, Hits int
+	Expected, Var float64
+	LastPlayedAt  time.Time
+}
+
+// InsertBlindPlay records a seat's blind guesses for a finished match. Seats without a blind guess are
+// not stored. A repeated call for the same seat is ignored.
+func (s *Store) InsertBlindPlay(ctx context.Context, matchID string, playerIdx, guesses, hits int, expectedHits, hitVariance float64) error {
+	if s == nil || s.pool == nil || guesses == 0 {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO match_blind_play (match_id, player_idx, guesses, hits, expected_hits, hit_variance)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (match_id, player_idx) DO NOTHING`,
+		matchID, playerIdx, guesses, hits, expectedHits, hitVariance)
+	return err
+}
+
+// GetBlindPlayReport sums the blind guesses of each human account over cfg.TimeRange and returns the
+// accounts that hit unseen pairs far more often than chance (see detectBlindPlay), by z-score, highest first.
+func (s *Store) GetBlindPlayReport(ctx context.Context, cfg IntegrityReportConfig) ([]BlindPlayFlag, error) {
+	if s == nil || s.pool == nil {
+		return []BlindPlayFlag{}, nil
+	}
+	interval := telemetryTimeIntervalSQL(cfg.TimeRange)
+	if cfg.TimeRange == "" {
+		interval = "30 days"
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT user_id, (array_agg(name ORDER BY played_at DESC))[1], COUNT(*), SUM(guesses), SUM(hits),
+			SUM(expected_hits), SUM(hit_variance), MAX(played_at)
+		FROM (
+			SELECT CASE WHEN bp.player_idx = 0 THEN gh.player0_user_id ELSE gh.player1_user_id END AS user_id,
+				CASE WHEN bp.player_idx = 0 THEN gh.player0_name ELSE gh.player1_name END AS name,
+				gh.played_at, bp.guesses, bp.hits, bp.expected_hits, bp.hit_variance
+			FROM match_blind_play bp
+			JOIN game_history gh ON gh.id = bp.match_id
+			WHERE gh.played_at >= now() - interval '`+interval+`'
+		) t
+		WHERE user_id NOT LIKE 'ai:%'
+		GROUP BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var totals []blindPlayTotals
+	for rows.Next() {
+		var t blindPlayTotals
+		if err := rows.Scan(&t.UserID, &t.Name, &t.Matches, &t.Guesses, &t.Hits, &t.Expected, &t.Var, &t.LastPlayedAt); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return detectBlindPlay(totals, cfg), nil
+}
+
+// detectBlindPlay returns the accounts with at least cfg.MinBlindGuesses blind guesses whose hits are at
+// least cfg.BlindPlayZ standard deviations above the hits expected by chance.
+func detectBlindPlay(totals []blindPlayTotals, cfg IntegrityReportConfig) []BlindPlayFlag {
+	if cfg.MinBlindGuesses <= 0 {
+		cfg.MinBlindGuesses = 30
+	}
+	if cfg.BlindPlayZ <= 0 {
+		cfg.BlindPlayZ = 4
+	}
+	out := []BlindPlayFlag{}
+	for _, t := range totals {
+		if t.Guesses < cfg.MinBlindGuesses || t.Var <= 0 {
+			continue
+		}
+		z := (float64(t.Hits) - t.Expected) / math.Sqrt(t.Var)
+		if z < cfg.BlindPlayZ {
+			continue
+		}
+		out = append(out, BlindPlayFlag{
+			UserID:       t.UserID,
+			Name:         t.Name,
+			Matches:      t.Matches,
+			Guesses:      t.Guesses,
+			Hits:         t.Hits,
+			ExpectedHits: math.Round(t.Expected*10) / 10,
+			HitRatePct:   pct(t.Hits, t.Guesses),
+			ZScore:       math.Round(z*10) / 10,
+			LastPlayedAt: t.LastPlayedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ZScore != out[j].ZScore {
+			return out[i].ZScore > out[j].ZScore
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
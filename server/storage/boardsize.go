@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TelemetryGroupByBoardSize is the TelemetryBinConfig.GroupBy value that adds one segment per board size.
+const TelemetryGroupByBoardSize = "board_size"
+
+// TelemetrySegment is the metrics of the games played on one board size.
+type TelemetrySegment struct {
+	BoardSize string            `json:"board_size"` // "<rows>x<cols>"
+	Metrics   *TelemetryMetrics `json:"metrics"`
+}
+
+// ParseBoardSize parses "<rows>x<cols>" (e.g. "4x4"). ok is false unless both are positive integers.
+func ParseBoardSize(s string) (rows, cols int, ok bool) {
+	r, c, found := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	if !found {
+		return 0, 0, false
+	}
+	rows, err1 := strconv.Atoi(r)
+	cols, err2 := strconv.Atoi(c)
+	if err1 != nil || err2 != nil || rows <= 0 || cols <= 0 {
+		return 0, 0, false
+	}
+	return rows, cols, true
+}
+
+// boardSizeCondition returns the SQL condition (table alias "gh") keeping games played on boardSize, read
+// from the match's config snapshot; "true" when boardSize is empty or invalid. Games recorded before
+// snapshots existed have no board size and never match a filter.
+func boardSizeCondition(boardSize string) string {
+	rows, cols, ok := ParseBoardSize(boardSize)
+	if !ok {
+		return "true"
+	}
+	return fmt.Sprintf("(gh.config_snapshot->>'board_rows')::int = %d AND (gh.config_snapshot->>'board_cols')::int = %d", rows, cols)
+}
+
+// telemetryBoardSegments returns the metrics of each board size played in the realm in the period selected
+// by cfg (board size filter and grouping ignored), smallest board first.
+func (s *Store) telemetryBoardSegments(ctx context.Context, realm string, cfg TelemetryBinConfig) ([]TelemetrySegment, error) {
+	cfg.BoardSize, cfg.GroupBy = "", ""
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+		SELECT DISTINCT (gh.config_snapshot->>'board_rows')::int AS board_rows, (gh.config_snapshot->>'board_cols')::int AS board_cols
+		FROM game_history gh
+		WHERE %s AND gh.config_snapshot IS NOT NULL
+		ORDER BY 1, 2
+	`, getGameHistoryCondForDirectQuery(cfg.MatchType, cfg.TimeRange, "")), realm)
+	if err != nil {
+		return nil, err
+	}
+	var sizes []string
+	for rows.Next() {
+		var r, c int
+		if err := rows.Scan(&r, &c); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sizes = append(sizes, fmt.Sprintf("%dx%d", r, c))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	segments := make([]TelemetrySegment, 0, len(sizes))
+	for _, size := range sizes {
+		sub := cfg
+		sub.BoardSize = size
+		m, err := s.GetTelemetryMetrics(ctx, realm, &sub)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, TelemetrySegment{BoardSize: size, Metrics: m})
+	}
+	return segments, nil
+}
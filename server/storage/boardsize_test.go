@@ -0,0 +1,34 @@
+package storage
+
+import "testing"
+
+func TestParseBoardSize(t *testing.T) {
+	tests := []struct {
+		in         string
+		rows, cols int
+		ok         bool
+	}{
+		{"4x4", 4, 4, true},
+		{" 6X8 ", 6, 8, true},
+		{"6", 0, 0, false},
+		{"0x4", 0, 0, false},
+		{"4x-2", 0, 0, false},
+		{"4x4; DROP TABLE game_history", 0, 0, false},
+	}
+	for _, tt := range tests {
+		rows, cols, ok := ParseBoardSize(tt.in)
+		if rows != tt.rows || cols != tt.cols || ok != tt.ok {
+			t.Errorf("ParseBoardSize(%q) = %d, %d, %v; want %d, %d, %v", tt.in, rows, cols, ok, tt.rows, tt.cols, tt.ok)
+		}
+	}
+}
+
+func TestBoardSizeCondition(t *testing.T) {
+	if got := boardSizeCondition(""); got != "true" {
+		t.Errorf("expected no filter for an empty size, got %q", got)
+	}
+	want := "(gh.config_snapshot->>'board_rows')::int = 4 AND (gh.config_snapshot->>'board_cols')::int = 6"
+	if got := boardSizeCondition("4x6"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
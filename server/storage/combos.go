@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Combo sort orders (TelemetryComboQuery.SortBy). Every order is descending, ties broken by use count
+// and then combo key so pages stay stable.
+const (
+	ComboSortUses    = "uses"
+	ComboSortWinRate = "win_rate"
+	ComboSortSwing   = "swing" // net point swing: player gain minus opponent gain
+)
+
+const maxComboLimit = 200
+
+// TelemetryComboQuery selects a page of combos for GetTopCombos. Bins carries the histogram bins and the
+// match type and time range filters, as in GetTelemetryMetrics.
+type TelemetryComboQuery struct {
+	Bins TelemetryBinConfig
+	// MinUses drops combos used fewer times than this (default 1), so small samples do not top the win-rate sort.
+	MinUses int
+	// SortBy is one of the ComboSort* orders (default uses).
+	SortBy string
+	// Limit is the page size (default 50, at most 200); Offset skips that many combos.
+	Limit  int
+	Offset int
+}
+
+// comboOrderSQL returns the ORDER BY clause for sortBy; unknown values sort by use count.
+func comboOrderSQL(sortBy string) string {
+	switch sortBy {
+	case ComboSortWinRate:
+		return "COUNT(*) FILTER (WHERE gh.winner_index = ts.player_idx)::float / COUNT(*) DESC, COUNT(*) DESC, ts.combo_key"
+	case ComboSortSwing:
+		return "AVG((t.player_score_after_turn - ts.first_player_before) - (t.opponent_score_after_turn - ts.first_opponent_before)) DESC, COUNT(*) DESC, ts.combo_key"
+	default:
+		return "COUNT(*) DESC, ts.combo_key"
+	}
+}
+
+// GetTopCombos returns one page of the realm's combos (cards used together in one turn) that were used at
+// least q.MinUses times, in q.SortBy order. hasMore is true when further combos follow the page.
+func (s *Store) GetTopCombos(ctx context.Context, realm string, q TelemetryComboQuery) (combos []TelemetryByCombo, hasMore bool, err error) {
+	if s == nil || s.pool == nil {
+		return []TelemetryByCombo{}, false, nil
+	}
+	if q.MinUses < 1 {
+		q.MinUses = 1
+	}
+	if q.Limit <= 0 {
+		q.Limit = 50
+	}
+	if q.Limit > maxComboLimit {
+		q.Limit = maxComboLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	cfg := normalizeTelemetryBinConfig(&q.Bins)
+	return s.queryCombos(ctx, realm, cfg, q.MinUses, q.SortBy, q.Limit, q.Offset)
+}
+
+// queryCombos aggregates combos with at least minUses uses in the realm's matches selected by cfg and returns
+// limit of them from offset, in sortBy order, with their game-stage histograms. hasMore is true when
+// further combos follow.
+func (s *Store) queryCombos(ctx context.Context, realm string, cfg TelemetryBinConfig, minUses int, sortBy string, limit, offset int) ([]TelemetryByCombo, bool, error) {
+	ghCond := getGameHistoryCondForDirectQuery(cfg.MatchType, cfg.TimeRange, cfg.BoardSize)
+	matchIDsSubq := filteredMatchIDsSubquery(cfg.MatchType, cfg.TimeRange, cfg.BoardSize)
+
+	// Combo = sorted set of power_up_ids used in one turn (arcana_use grouped by match_id, round,
+	// player_idx); only combos with 2+ cards (synergy).
+	// Wins = number of times that combo was used and the player who used it won the match.
+	// Point swing = from first card of combo until end of turn (consistent with individual card metric).
+	comboRows, err := s.pool.Query(ctx, fmt.Sprintf(`
+		WITH turn_cards AS (
+			SELECT match_id, round, player_idx, array_agg(DISTINCT power_up_id ORDER BY power_up_id) AS arr
+			FROM arcana_use
+			WHERE match_id IN (%s)
+			GROUP BY match_id, round, player_idx
+		),
+		turn_combos AS (
+			SELECT match_id, round, player_idx,
+				array_to_string(arr, ',') AS combo_key,
+				array_length(arr, 1) AS card_count
+			FROM turn_cards
+			WHERE array_length(arr, 1) >= 2
+		),
+		turn_swing AS (
+			SELECT tc.combo_key, tc.card_count, tc.match_id, tc.round, tc.player_idx,
+				MIN(au.player_score_before) AS first_player_before,
+				MIN(au.opponent_score_before) AS first_opponent_before
+			FROM turn_combos tc
+			JOIN arcana_use au ON au.match_id = tc.match_id AND au.round = tc.round AND au.player_idx = tc.player_idx
+			GROUP BY tc.combo_key, tc.card_count, tc.match_id, tc.round, tc.player_idx
+		)
+		SELECT ts.combo_key, ts.card_count,
+			COUNT(*) AS total_uses,
+			COUNT(*) FILTER (WHERE gh.winner_index = ts.player_idx) AS wins,
+			AVG(t.player_score_after_turn - ts.first_player_before)::float AS avg_point_swing_player,
+			AVG(t.opponent_score_after_turn - ts.first_opponent_before)::float AS avg_point_swing_opponent
+		FROM turn_swing ts
+		JOIN turn t ON t.match_id = ts.match_id AND t.round = ts.round AND t.player_idx = ts.player_idx
+		JOIN game_history gh ON gh.id = ts.match_id AND %s
+		GROUP BY ts.combo_key, ts.card_count
+		HAVING COUNT(*) >= $2
+		ORDER BY %s
+		LIMIT $3 OFFSET $4
+	`, matchIDsSubq, ghCond, comboOrderSQL(sortBy)), realm, minUses, limit+1, offset)
+	if err != nil {
+		return nil, false, err
+	}
+	combos := []TelemetryByCombo{}
+	for comboRows.Next() {
+		var c TelemetryByCombo
+		if err := comboRows.Scan(&c.ComboKey, &c.CardCount, &c.TotalMatches, &c.Wins, &c.AvgPointSwingPlayer, &c.AvgPointSwingOpponent); err != nil {
+			comboRows.Close()
+			return nil, false, err
+		}
+		if c.TotalMatches > 0 {
+			c.WinRatePct = 100.0 * float64(c.Wins) / float64(c.TotalMatches)
+		}
+		combos = append(combos, c)
+	}
+	comboRows.Close()
+	if err := comboRows.Err(); err != nil {
+		return nil, false, err
+	}
+	hasMore := len(combos) > limit
+	if hasMore {
+		combos = combos[:limit]
+	}
+	if len(combos) == 0 {
+		return combos, hasMore, nil
+	}
+	keys := make([]string, len(combos))
+	for i, c := range combos {
+		keys[i] = c.ComboKey
+	}
+
+	// Combo "game stage at use": (combo_key, round, pairs_matched_before) per use of the page's combos.
+	useRows, err := s.pool.Query(ctx, fmt.Sprintf(`
+		WITH turn_cards AS (
+			SELECT match_id, round, player_idx, array_agg(DISTINCT power_up_id ORDER BY power_up_id) AS arr
+			FROM arcana_use
+			WHERE match_id IN (%s)
+			GROUP BY match_id, round, player_idx
+		),
+		turn_combos AS (
+			SELECT match_id, round, player_idx,
+				array_to_string(arr, ',') AS combo_key
+			FROM turn_cards
+			WHERE array_length(arr, 1) >= 2
+		)
+		SELECT tc.combo_key, tc.round, MIN(au.pairs_matched_before) AS pairs_matched_before
+		FROM turn_combos tc
+		JOIN arcana_use au ON au.match_id = tc.match_id AND au.round = tc.round AND au.player_idx = tc.player_idx
+		WHERE tc.combo_key = ANY($2)
+		GROUP BY tc.combo_key, tc.match_id, tc.round, tc.player_idx
+	`, matchIDsSubq), realm, keys)
+	if err != nil {
+		return nil, false, err
+	}
+	usesByKey := make(map[string][]comboUse)
+	for useRows.Next() {
+		var key string
+		var u comboUse
+		if err := useRows.Scan(&key, &u.round, &u.pairs); err != nil {
+			useRows.Close()
+			return nil, false, err
+		}
+		usesByKey[key] = append(usesByKey[key], u)
+	}
+	useRows.Close()
+	if err := useRows.Err(); err != nil {
+		return nil, false, err
+	}
+	for i := range combos {
+		fillComboStage(&combos[i], usesByKey[combos[i].ComboKey], cfg)
+	}
+	return combos, hasMore, nil
+}
+
+// comboUse is the game stage of one combo use: the round and the pairs matched before its first card.
+type comboUse struct {
+	round, pairs int
+}
+
+// fillComboStage sets the combo's average turn and pairs at use and its turn and pairs histograms.
+func fillComboStage(c *TelemetryByCombo, uses []comboUse, cfg TelemetryBinConfig) {
+	turnStep := cfg.TurnMax / (cfg.TurnNumBins - 1)
+	if turnStep < 1 {
+		turnStep = 1
+	}
+	pairsStep := cfg.PairsMax / cfg.PairsNumBins
+	if pairsStep < 1 {
+		pairsStep = 1
+	}
+	turnBins := make([]int, cfg.TurnNumBins)
+	pairsBins := make([]int, cfg.PairsNumBins)
+	var avgTurn, avgPairs float64
+	for _, u := range uses {
+		avgTurn += float64(u.round)
+		avgPairs += float64(u.pairs)
+		binIdx := cfg.TurnNumBins - 1
+		if u.round < cfg.TurnMax {
+			binIdx = u.round / turnStep
+			if binIdx >= cfg.TurnNumBins-1 {
+				binIdx = cfg.TurnNumBins - 2
+			}
+		}
+		turnBins[binIdx]++
+		pairsBinIdx := u.pairs / pairsStep
+		if pairsBinIdx >= cfg.PairsNumBins {
+			pairsBinIdx = cfg.PairsNumBins - 1
+		}
+		pairsBins[pairsBinIdx]++
+	}
+	if n := len(uses); n > 0 {
+		avgTurn /= float64(n)
+		avgPairs /= float64(n)
+	}
+	c.AvgTurnAtUse, c.AvgPairsMatchedBefore = avgTurn, avgPairs
+	turnLabels, pairsLabels := buildTurnHistogramLabels(cfg), buildPairsHistogramLabels(cfg)
+	c.TurnHistogram = make([]TelemetryHistogramBucket, len(turnLabels))
+	for i, label := range turnLabels {
+		c.TurnHistogram[i] = TelemetryHistogramBucket{Label: label, Count: turnBins[i]}
+	}
+	c.PairsHistogram = make([]TelemetryHistogramBucket, len(pairsLabels))
+	for i, label := range pairsLabels {
+		c.PairsHistogram[i] = TelemetryHistogramBucket{Label: label, Count: pairsBins[i]}
+	}
+}
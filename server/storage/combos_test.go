@@ -0,0 +1,34 @@
+package storage
+
+import "testing"
+
+func TestFillComboStage(t *testing.T) {
+	cfg := TelemetryBinConfig{TurnMax: 10, TurnNumBins: 3, PairsMax: 6, PairsNumBins: 2}
+	var c TelemetryByCombo
+	fillComboStage(&c, []comboUse{{round: 2, pairs: 0}, {round: 7, pairs: 4}, {round: 12, pairs: 9}}, cfg)
+
+	if c.AvgTurnAtUse != 7 || c.AvgPairsMatchedBefore != float64(13)/3 {
+		t.Errorf("unexpected averages: turn %v pairs %v", c.AvgTurnAtUse, c.AvgPairsMatchedBefore)
+	}
+	wantTurn := []TelemetryHistogramBucket{{"0-5", 1}, {"5-10", 1}, {"10+", 1}}
+	wantPairs := []TelemetryHistogramBucket{{"0-3", 1}, {"3-6", 2}}
+	for i, b := range wantTurn {
+		if i >= len(c.TurnHistogram) || c.TurnHistogram[i] != b {
+			t.Fatalf("expected turn histogram %v, got %v", wantTurn, c.TurnHistogram)
+		}
+	}
+	for i, b := range wantPairs {
+		if i >= len(c.PairsHistogram) || c.PairsHistogram[i] != b {
+			t.Fatalf("expected pairs histogram %v, got %v", wantPairs, c.PairsHistogram)
+		}
+	}
+}
+
+func TestFillComboStage_NoUses(t *testing.T) {
+	cfg := TelemetryBinConfig{TurnMax: 10, TurnNumBins: 3, PairsMax: 6, PairsNumBins: 2}
+	var c TelemetryByCombo
+	fillComboStage(&c, nil, cfg)
+	if c.AvgTurnAtUse != 0 || len(c.TurnHistogram) != 3 || len(c.PairsHistogram) != 2 {
+		t.Errorf("expected zero averages and empty bins, got %+v", c)
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Friendship statuses, as returned by RequestFriend and ListFriends.
+const (
+	FriendAccepted = "accepted"
+	// FriendPending is a request the other user has not accepted yet (RequestFriend).
+	FriendPending = "pending"
+	// FriendIncoming and FriendOutgoing tell a pending request apart in ListFriends: sent to the user, or by them.
+	FriendIncoming = "incoming"
+	FriendOutgoing = "outgoing"
+)
+
+// Leaderboard scopes for ListLeaderboard.
+const (
+	LeaderboardScopeGlobal = ""
+	// LeaderboardScopeFriends lists only the viewer and their accepted friends.
+	LeaderboardScopeFriends = "friends"
+)
+
+// createFriendsSQL stores friend requests, one row per pair: user_id asked friend_id. The row is
+// 'pending' until friend_id accepts it.
+const createFriendsSQL = `
+CREATE TABLE IF NOT EXISTS friends (
+	user_id     TEXT NOT NULL,
+	friend_id   TEXT NOT NULL,
+	status      TEXT NOT NULL DEFAULT 'pending',
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+	accepted_at TIMESTAMPTZ,
+	PRIMARY KEY (user_id, friend_id)
+);
+CREATE INDEX IF NOT EXISTS friends_friend_id ON friends (friend_id);
+`
+
+// friendsSQL is a SQL condition true when the users in the two expressions are accepted friends, in
+// either direction.
+func friendsSQL(userExprA, userExprB string) string {
+	return `EXISTS (SELECT 1 FROM friends f WHERE f.status = 'accepted' AND ((f.user_id = ` + userExprA + ` AND f.friend_id = ` + userExprB + `) OR (f.user_id = ` + userExprB + ` AND f.friend_id = ` + userExprA + `)))`
+}
+
+// Friend is one entry of a user's friend list. DisplayName and Elo come from the realm's leaderboard and
+// are empty until the friend has played a rated game there.
+type Friend struct {
+	UserID      string `json:"user_id"`
+	DisplayName string `json:"display_name"`
+	// Status is FriendAccepted, FriendIncoming or FriendOutgoing.
+	Status string `json:"status"`
+	Elo    *int   `json:"elo,omitempty"`
+}
+
+// RequestFriend asks friendID to be userID's friend and returns the resulting status. A request while
+// friendID's own request to userID is pending accepts it (FriendAccepted); a repeated request returns the
+// status of the existing one.
+func (s *Store) RequestFriend(ctx context.Context, userID, friendID string) (string, error) {
+	if s == nil || s.pool == nil || userID == "" || friendID == "" {
+		return "", nil
+	}
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE friends SET status = 'accepted', accepted_at = now()
+		WHERE user_id = $2 AND friend_id = $1 AND status = 'pending'`,
+		userID, friendID)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() > 0 {
+		return FriendAccepted, nil
+	}
+	var status string
+	err = s.pool.QueryRow(ctx, `
+		SELECT status FROM friends
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)`,
+		userID, friendID).Scan(&status)
+	if err == nil {
+		return status, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	_, err = s.pool.Exec(ctx, `
+		INSERT INTO friends (user_id, friend_id) VALUES ($1, $2)
+		ON CONFLICT (user_id, friend_id) DO NOTHING`,
+		userID, friendID)
+	if err != nil {
+		return "", err
+	}
+	return FriendPending, nil
+}
+
+// AcceptFriend accepts the pending request requesterID sent to userID. It returns false when there is no
+// such request.
+func (s *Store) AcceptFriend(ctx context.Context, userID, requesterID string) (bool, error) {
+	if s == nil || s.pool == nil || userID == "" || requesterID == "" {
+		return false, nil
+	}
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE friends SET status = 'accepted', accepted_at = now()
+		WHERE user_id = $2 AND friend_id = $1 AND status = 'pending'`,
+		userID, requesterID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ListFriends returns userID's friends and pending requests with their name and rating in the realm:
+// accepted friends first, by rating, then requests by age. A private user with a request still pending is
+// shown as AnonymousDisplayName.
+func (s *Store) ListFriends(ctx context.Context, realm, userID string) ([]Friend, error) {
+	if s == nil || s.pool == nil || userID == "" {
+		return []Friend{}, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT f.other,
+			CASE WHEN f.status <> 'accepted' AND `+privateUserSQL("f.other")+` THEN $3 ELSE COALESCE(pr.display_name, '') END,
+			CASE WHEN f.status = 'accepted' THEN 'accepted' WHEN f.incoming THEN 'incoming' ELSE 'outgoing' END,
+			pr.elo
+		FROM (
+			SELECT friend_id AS other, status, false AS incoming, created_at FROM friends WHERE user_id = $1
+			UNION ALL
+			SELECT user_id, status, true, created_at FROM friends WHERE friend_id = $1
+		) f
+		LEFT JOIN player_ratings pr ON pr.realm = $2 AND pr.user_id = f.other
+		ORDER BY f.status = 'accepted' DESC, CASE WHEN f.status = 'accepted' THEN pr.elo END DESC NULLS LAST, f.created_at`,
+		userID, realm, AnonymousDisplayName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Friend{}
+	for rows.Next() {
+		var f Friend
+		if err := rows.Scan(&f.UserID, &f.DisplayName, &f.Status, &f.Elo); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Integrity flag reasons.
+const (
+	// IntegrityRepeatPairing: the pair's games are a large share of one player's PvP games (queue sniping).
+	IntegrityRepeatPairing = "repeat_pairing"
+	// IntegrityAlternatingWins: the winner flips from one game to the next far more often than chance.
+	IntegrityAlternatingWins = "alternating_wins"
+	// IntegrityForfeitLosses: many of the pair's games were decided by a resign or a disconnect.
+	IntegrityForfeitLosses = "forfeit_losses"
+)
+
+// IntegrityReportConfig holds the thresholds for win-trading detection. Zero values use the defaults.
+type IntegrityReportConfig struct {
+	// TimeRange is "24h", "7d" or "30d" (default "30d"); only games in the period are analysed.
+	TimeRange string
+	// MinMatches is the number of games a pair must have played against each other to be considered (default 5).
+	MinMatches int
+	// ConcentrationPct is the share of a player's PvP games against the same opponent that counts as repeat pairing (default 50).
+	ConcentrationPct int
+	// AlternationPct is the share of consecutive decided games where the winner changed that counts as trading (default 80).
+	AlternationPct int
+	// ForfeitPct is the share of the pair's games ended by resign or disconnect that counts as thrown games (default 50).
+	ForfeitPct int
+	// MinBlindGuesses is the number of blind guesses an account needs in the period to be judged (default 30).
+	MinBlindGuesses int
+	// BlindPlayZ is how many standard deviations above chance an account's blind hits must be to be flagged (default 4).
+	BlindPlayZ float64
+	// MinFastFlips is the number of fast flips an account needs in the period to be flagged for timing (default 5).
+	MinFastFlips int
+}
+
+// IntegrityFlag is one pair of accounts whose games against each other look like win trading.
+type IntegrityFlag struct {
+	PlayerA        string   `json:"player_a"`
+	PlayerB        string   `json:"player_b"`
+	NameA          string   `json:"name_a"`
+	NameB          string   `json:"name_b"`
+	Matches        int      `json:"matches"`
+	WinsA          int      `json:"wins_a"`
+	WinsB          int      `json:"wins_b"`
+	ShareOfAPct    float64  `json:"share_of_a_pct"`  // pair's games as a share of A's PvP games
+	ShareOfBPct    float64  `json:"share_of_b_pct"`  // pair's games as a share of B's PvP games
+	AlternationPct float64  `json:"alternation_pct"` // winner changes over consecutive decided games
+	ForfeitPct     float64  `json:"forfeit_pct"`     // games ended by resign or disconnect
+	LastPlayedAt   string   `json:"last_played_at"`  // ISO8601
+	Reasons        []string `json:"reasons"`
+}
+
+// integrityGame is one rated human-vs-human game as read for the integrity report.
+type integrityGame struct {
+	PlayedAt     time.Time
+	P0, P1       string
+	Name0, Name1 string
+	WinnerIdx    *int
+	EndReason    string
+}
+
+// GetIntegrityReport analyses rated human-vs-human games for win trading: the same two accounts meeting
+// again and again (repeat pairing) while trading wins or throwing games. A pair is flagged when it shows
+// repeat pairing plus at least one outcome pattern. Flags are ordered by number of games, highest first.
+func (s *Store) GetIntegrityReport(ctx context.Context, cfg IntegrityReportConfig) ([]IntegrityFlag, error) {
+	if s == nil || s.pool == nil {
+		return []IntegrityFlag{}, nil
+	}
+	interval := telemetryTimeIntervalSQL(cfg.TimeRange)
+	if cfg.TimeRange == "" {
+		interval = "30 days"
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT played_at, player0_user_id, player1_user_id, player0_name, player1_name, winner_index, COALESCE(end_reason,'')
+		FROM game_history
+		WHERE player0_user_id NOT LIKE 'ai:%' AND player1_user_id NOT LIKE 'ai:%'
+			AND player0_elo_after IS NOT NULL
+			AND played_at >= now() - interval '`+interval+`'
+		ORDER BY played_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var games []integrityGame
+	for rows.Next() {
+		var g integrityGame
+		if err := rows.Scan(&g.PlayedAt, &g.P0, &g.P1, &g.Name0, &g.Name1, &g.WinnerIdx, &g.EndReason); err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return detectWinTrading(games, cfg), nil
+}
+
+// detectWinTrading groups games (ordered by played_at) by pair of accounts and returns the pairs that
+// match the thresholds in cfg.
+func detectWinTrading(games []integrityGame, cfg IntegrityReportConfig) []IntegrityFlag {
+	if cfg.MinMatches <= 0 {
+		cfg.MinMatches = 5
+	}
+	if cfg.ConcentrationPct <= 0 {
+		cfg.ConcentrationPct = 50
+	}
+	if cfg.AlternationPct <= 0 {
+		cfg.AlternationPct = 80
+	}
+	if cfg.ForfeitPct <= 0 {
+		cfg.ForfeitPct = 50
+	}
+
+	type pairKey struct{ a, b string }
+	totals := make(map[string]int)
+	pairs := make(map[pairKey][]integrityGame)
+	for _, g := range games {
+		totals[g.P0]++
+		totals[g.P1]++
+		k := pairKey{g.P0, g.P1}
+		if k.b < k.a {
+			k = pairKey{g.P1, g.P0}
+		}
+		pairs[k] = append(pairs[k], g)
+	}
+
+	out := []IntegrityFlag{}
+	for k, pg := range pairs {
+		if len(pg) < cfg.MinMatches {
+			continue
+		}
+		f := IntegrityFlag{PlayerA: k.a, PlayerB: k.b, Matches: len(pg)}
+		var lastWinner string
+		decided, changes, forfeits := 0, 0, 0
+		for _, g := range pg {
+			if g.P0 == k.a {
+				f.NameA, f.NameB = g.Name0, g.Name1
+			} else {
+				f.NameA, f.NameB = g.Name1, g.Name0
+			}
+			if g.EndReason == "resigned" || g.EndReason == "opponent_disconnected" {
+				forfeits++
+			}
+			if g.WinnerIdx == nil {
+				continue
+			}
+			winner := g.P0
+			if *g.WinnerIdx == 1 {
+				winner = g.P1
+			}
+			if winner == k.a {
+				f.WinsA++
+			} else {
+				f.WinsB++
+			}
+			if decided > 0 && winner != lastWinner {
+				changes++
+			}
+			lastWinner = winner
+			decided++
+		}
+		f.LastPlayedAt = pg[len(pg)-1].PlayedAt.UTC().Format(time.RFC3339)
+		f.ShareOfAPct = pct(len(pg), totals[k.a])
+		f.ShareOfBPct = pct(len(pg), totals[k.b])
+		if decided > 1 {
+			f.AlternationPct = pct(changes, decided-1)
+		}
+		f.ForfeitPct = pct(forfeits, len(pg))
+
+		if f.ShareOfAPct < float64(cfg.ConcentrationPct) && f.ShareOfBPct < float64(cfg.ConcentrationPct) {
+			continue
+		}
+		f.Reasons = []string{IntegrityRepeatPairing}
+		if f.WinsA > 0 && f.WinsB > 0 && f.AlternationPct >= float64(cfg.AlternationPct) {
+			f.Reasons = append(f.Reasons, IntegrityAlternatingWins)
+		}
+		if f.ForfeitPct >= float64(cfg.ForfeitPct) {
+			f.Reasons = append(f.Reasons, IntegrityForfeitLosses)
+		}
+		if len(f.Reasons) > 1 {
+			out = append(out, f)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Matches != out[j].Matches {
+			return out[i].Matches > out[j].Matches
+		}
+		return out[i].PlayerA < out[j].PlayerA
+	})
+	return out
+}
+
+// pct returns n as a percentage of total, rounded to one decimal (0 when total is 0).
+func pct(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(total)) / 10
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// integrityGames builds games between p0 and p1 from a winner pattern ('0' or '1' is the seat that won,
+// 'd' a draw), one minute apart.
+func integrityGames(p0, p1, pattern, endReason string, start time.Time) []integrityGame {
+	games := make([]integrityGame, 0, len(pattern))
+	for i, c := range pattern {
+		g := integrityGame{PlayedAt: start.Add(time.Duration(i) * time.Minute), P0: p0, P1: p1, Name0: p0, Name1: p1, EndReason: endReason}
+		if c != 'd' {
+			w := int(c - '0')
+			g.WinnerIdx = &w
+		}
+		games = append(games, g)
+	}
+	return games
+}
+
+func TestDetectWinTrading(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var games []integrityGame
+	// Traders: always meet each other and alternate wins.
+	games = append(games, integrityGames("u:trader1", "u:trader2", "010101", "completed", start)...)
+	// Rivals: meet often but with an ordinary spread of results.
+	games = append(games, integrityGames("u:rival1", "u:rival2", "001100", "completed", start)...)
+	// Forfeiters: one keeps resigning to the other.
+	games = append(games, integrityGames("u:thrower", "u:booster", "111111", "resigned", start)...)
+	// Alternating results, but both players mostly play other people.
+	games = append(games, integrityGames("u:busy1", "u:busy2", "01010", "completed", start)...)
+	for i := range 6 {
+		games = append(games, integrityGames("u:busy1", "u:other"+string(rune('a'+i)), "00", "completed", start)...)
+		games = append(games, integrityGames("u:busy2", "u:other"+string(rune('a'+i)), "11", "completed", start)...)
+	}
+
+	flags := detectWinTrading(games, IntegrityReportConfig{})
+	if len(flags) != 2 {
+		t.Fatalf("expected 2 flagged pairs, got %+v", flags)
+	}
+	byPair := map[string]IntegrityFlag{}
+	for _, f := range flags {
+		byPair[f.PlayerA+"|"+f.PlayerB] = f
+	}
+
+	traders, ok := byPair["u:trader1|u:trader2"]
+	if !ok {
+		t.Fatal("expected the alternating pair to be flagged")
+	}
+	if !slices.Equal(traders.Reasons, []string{IntegrityRepeatPairing, IntegrityAlternatingWins}) {
+		t.Errorf("unexpected trader reasons %v", traders.Reasons)
+	}
+	if traders.WinsA != 3 || traders.WinsB != 3 || traders.AlternationPct != 100 || traders.ShareOfAPct != 100 {
+		t.Errorf("unexpected trader stats %+v", traders)
+	}
+
+	boost, ok := byPair["u:booster|u:thrower"]
+	if !ok {
+		t.Fatal("expected the forfeiting pair to be flagged")
+	}
+	if !slices.Equal(boost.Reasons, []string{IntegrityRepeatPairing, IntegrityForfeitLosses}) || boost.WinsA != 6 {
+		t.Errorf("unexpected booster flag %+v", boost)
+	}
+}
+
+func TestDetectWinTrading_MinMatches(t *testing.T) {
+	games := integrityGames("u:a", "u:b", "0101", "completed", time.Now())
+	if flags := detectWinTrading(games, IntegrityReportConfig{}); len(flags) != 0 {
+		t.Errorf("expected no flag below the default minimum, got %+v", flags)
+	}
+	if flags := detectWinTrading(games, IntegrityReportConfig{MinMatches: 4}); len(flags) != 1 {
+		t.Errorf("expected a flag with min_matches 4, got %+v", flags)
+	}
+}
+
+func TestDetectBlindPlay(t *testing.T) {
+	last := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	totals := []blindPlayTotals{
+		// Sees the board: 40 blind guesses at roughly 1 in 20, all hits.
+		{UserID: "u:cheat", Name: "Cheat", Matches: 8, Guesses: 40, Hits: 40, Expected: 2, Var: 1.9, LastPlayedAt: last},
+		// Lucky, within chance.
+		{UserID: "u:lucky", Name: "Lucky", Matches: 8, Guesses: 40, Hits: 4, Expected: 2, Var: 1.9, LastPlayedAt: last},
+		// Suspicious rate but too few guesses to judge.
+		{UserID: "u:new", Name: "New", Matches: 1, Guesses: 5, Hits: 5, Expected: 0.3, Var: 0.28, LastPlayedAt: last},
+	}
+	flags := detectBlindPlay(totals, IntegrityReportConfig{})
+	if len(flags) != 1 || flags[0].UserID != "u:cheat" {
+		t.Fatalf("expected only u:cheat flagged, got %+v", flags)
+	}
+	if f := flags[0]; f.HitRatePct != 100 || f.ZScore < 20 {
+		t.Errorf("expected a 100%% hit rate far above chance, got %+v", f)
+	}
+	if flags := detectBlindPlay(totals, IntegrityReportConfig{MinBlindGuesses: 5}); len(flags) != 2 {
+		t.Errorf("expected u:new flagged with a lower guess minimum, got %+v", flags)
+	}
+}
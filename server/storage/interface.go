@@ -6,19 +6,56 @@ import "context"
 // Implementations can be swapped for testing (mocks) or different backends (e.g. read replicas).
 type HistoryStore interface {
 	// Read
-	ListByUserID(ctx context.Context, userID string) ([]GameRecord, error)
-	ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int) ([]GameRecord, bool, error)
-	ListLeaderboard(ctx context.Context, limit, offset int) ([]LeaderboardEntry, error)
-	GetLeaderboardEntryByUserID(ctx context.Context, userID string) (*LeaderboardEntry, error)
+	ListByUserID(ctx context.Context, realm, userID string) ([]GameRecord, error)
+	ListByUserIDPaginated(ctx context.Context, realm, userID string, q HistoryQuery) ([]GameRecord, bool, error)
+	ListLeaderboard(ctx context.Context, realm string, q LeaderboardQuery) ([]LeaderboardEntry, error)
+	GetLeaderboardEntryByUserID(ctx context.Context, realm, userID string) (*LeaderboardEntry, error)
+	GetSeasonEntryByUserID(ctx context.Context, realm, userID string, season int) (*LeaderboardEntry, error)
+	CurrentSeason(ctx context.Context, realm string) (Season, error)
+	ListSeasons(ctx context.Context, realm string) ([]Season, error)
 	GetUserRole(ctx context.Context, userID string) (string, error)
-	GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBinConfig) (*TelemetryMetrics, error)
+	GetTelemetryMetrics(ctx context.Context, realm string, binConfig *TelemetryBinConfig) (*TelemetryMetrics, error)
+	GetTopCombos(ctx context.Context, realm string, q TelemetryComboQuery) ([]TelemetryByCombo, bool, error)
+	GetUserArcanaStats(ctx context.Context, realm, userID string) ([]UserArcanaStats, error)
+	GetPlayerStats(ctx context.Context, realm, userID string) (*PlayerStats, error)
+	GetMatchSummary(ctx context.Context, realm, matchID string) (*MatchSummary, error)
+	GetMatchReplay(ctx context.Context, matchID string) (*MatchReplay, error)
+	GetUserSettings(ctx context.Context, userID string) (UserSettings, error)
+	ListFriends(ctx context.Context, realm, userID string) ([]Friend, error)
+	GetRematchSource(ctx context.Context, matchID string) (*RematchSource, error)
+	GetIntegrityReport(ctx context.Context, cfg IntegrityReportConfig) ([]IntegrityFlag, error)
+	GetBlindPlayReport(ctx context.Context, cfg IntegrityReportConfig) ([]BlindPlayFlag, error)
+	GetSuspiciousTimingReport(ctx context.Context, cfg IntegrityReportConfig) ([]SuspiciousTimingFlag, error)
+	FindRejoinToken(ctx context.Context, matchID, token string) (*RejoinToken, error)
+	FindRejoinTokenByUser(ctx context.Context, userID string) (*RejoinToken, error)
+	LoadActiveGame(ctx context.Context, realm, matchID string) ([]byte, error)
 
 	// Write
-	InsertGameResult(ctx context.Context, matchID, player0UserID, player1UserID, player0Name, player1Name string, player0Score, player1Score int, winnerIndex int, endReason string, elo0Before, elo0After, elo1Before, elo1After *int) error
-	UpdateRatingsAfterGame(ctx context.Context, p0UserID, p1UserID, p0Name, p1Name string, winnerIdx int) (elo0Before, elo0After, elo1Before, elo1After int, err error)
+	InsertGameResult(ctx context.Context, realm string, r GameResult) error
+	UpdateRatingsAfterGame(ctx context.Context, realm, matchID, p0UserID, p1UserID, p0Name, p1Name string, winnerIdx int) (elo0Before, elo0After, elo1Before, elo1After int, err error)
 	InsertMatchArcana(ctx context.Context, matchID string, powerUpIDs []string) error
-	InsertTurn(ctx context.Context, matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent int) error
+	InsertTurn(ctx context.Context, matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent, pairsMatched int, actions, avgProcessingMS, maxProcessingMS, rttMS int) error
 	InsertArcanaUse(ctx context.Context, matchID string, round, playerIdx int, powerUpID string, targetCardIndex int, playerScoreBefore, opponentScoreBefore, pairsMatchedBefore int, pointDeltaPlayer, pointDeltaOpponent int) error
+	InsertTurnsBatch(ctx context.Context, matchID string, turns []TurnRecord) error
+	InsertArcanaUsesBatch(ctx context.Context, matchID string, uses []ArcanaUseRecord) error
+	InsertHandOverflow(ctx context.Context, matchID string, round, playerIdx int, powerUpID, rule, discardedPowerUpID string) error
+	InsertPityGrant(ctx context.Context, matchID string, round, playerIdx int, powerUpID string, playerScore, opponentScore int) error
+	InsertDraftPick(ctx context.Context, matchID string, playerIdx int, powerUpID string, offered []string, autoPicked bool) error
+	InsertMatchLatency(ctx context.Context, matchID, player0Region, player1Region string, player0RTTMS, player1RTTMS int) error
+	InsertBlindPlay(ctx context.Context, matchID string, playerIdx, guesses, hits int, expectedHits, hitVariance float64) error
+	InsertSuspiciousActivity(ctx context.Context, matchID string, playerIdx int, kind string, count, minGapMS int) error
+	InsertReplayEvents(ctx context.Context, matchID string, events []ReplayEvent) error
+	CacheScoreSeries(ctx context.Context, matchID string) error
+	SaveRejoinTokens(ctx context.Context, tokens []RejoinToken) error
+	DeleteRejoinTokens(ctx context.Context, matchID string) error
+	SaveActiveGame(ctx context.Context, realm, matchID string, state []byte) error
+	DeleteActiveGame(ctx context.Context, matchID string) error
+	UpdateDisplayName(ctx context.Context, realm, userID, name string) error
+	SyncDisplayNamesFromAuth(ctx context.Context) (int64, error)
+	SaveUserSettings(ctx context.Context, userID string, settings UserSettings) error
+	RequestFriend(ctx context.Context, userID, friendID string) (string, error)
+	AcceptFriend(ctx context.Context, userID, requesterID string) (bool, error)
+	InsertPollResponse(ctx context.Context, matchID, pollID string, seat, member int, userID, answer string) error
 
 	// Lifecycle
 	Close()
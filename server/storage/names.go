@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// authFirstNameSQL is the display name derived from neon_auth."user".name: its first word, as
+// auth.FirstNameFromClaims does for a connection.
+const authFirstNameSQL = `split_part(regexp_replace(btrim(u.name), '\s+', ' ', 'g'), ' ', 1)`
+
+// UpdateDisplayName sets the user's display name on their rating rows in the realm (for example when they
+// authenticate with a changed name). Users without a rating yet are left alone; their row gets the name
+// when their first game ends.
+func (s *Store) UpdateDisplayName(ctx context.Context, realm, userID, name string) error {
+	if s == nil || s.pool == nil || userID == "" {
+		return nil
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		UPDATE player_ratings SET display_name = $3
+		WHERE realm = $1 AND user_id = $2 AND display_name <> $3`,
+		realm, userID, name)
+	return err
+}
+
+// SyncDisplayNamesFromAuth copies the current name of every Neon Auth user (first word, as at
+// authentication) to their rating rows in every realm, so renamed users do not stay stale on the
+// leaderboard until their next game. Bots and users with an empty name are skipped. Returns the number
+// of rows changed.
+func (s *Store) SyncDisplayNamesFromAuth(ctx context.Context) (int64, error) {
+	if s == nil || s.pool == nil {
+		return 0, nil
+	}
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE player_ratings pr SET display_name = `+authFirstNameSQL+`
+		FROM neon_auth."user" u
+		WHERE pr.user_id = u.id::text
+			AND btrim(COALESCE(u.name, '')) <> ''
+			AND pr.display_name <> `+authFirstNameSQL)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// RunDisplayNameSync calls SyncDisplayNamesFromAuth every interval until ctx is cancelled. Should be run as
+// a goroutine; returns right away when interval is not positive.
+func (s *Store) RunDisplayNameSync(ctx context.Context, interval time.Duration) {
+	if s == nil || s.pool == nil || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.SyncDisplayNamesFromAuth(ctx)
+			if err != nil {
+				slog.Error("display name sync failed", "tag", "storage", "err", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("display names synced", "tag", "storage", "updated", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// alterTurnAddPairsMatched records how many pairs each turn matched. The turn's point delta is no
+// measure of it: Blood Pact, arcana costs and hand overflow also move the score. Turns recorded before
+// the column existed have NULL and are left out of the combo streaks.
+const alterTurnAddPairsMatched = `
+ALTER TABLE turn ADD COLUMN IF NOT EXISTS pairs_matched INT;
+`
+
+// eloTrendGames is how many of the user's latest rated games PlayerStats.EloTrend covers.
+const eloTrendGames = 30
+
+// PlayerStats aggregates one user's games in a realm for GET /api/stats/me.
+type PlayerStats struct {
+	Games    int         `json:"games"`
+	VsHumans RecordSplit `json:"vs_humans"`
+	VsBots   RecordSplit `json:"vs_bots"`
+	AvgScore float64     `json:"avg_score"`
+	// AvgComboStreak is the average number of pairs the user matched in a row, over their turns that
+	// matched at least one (a match keeps the turn), from turn.pairs_matched. BestComboStreak is the longest.
+	AvgComboStreak  float64 `json:"avg_combo_streak"`
+	BestComboStreak int     `json:"best_combo_streak"`
+	// AvgGameTurns is the average number of turns (both players') of the user's games with recorded turns.
+	AvgGameTurns   float64         `json:"avg_game_turns"`
+	FavoriteArcana *FavoriteArcana `json:"favorite_arcana"` // nil when the user never used an arcana
+	// EloTrend is the user's rating after each of their latest rated games (up to 30), oldest first.
+	EloTrend []EloPoint `json:"elo_trend"`
+}
+
+// RecordSplit is a win/loss/draw record against one kind of opponent.
+type RecordSplit struct {
+	Games      int     `json:"games"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	Draws      int     `json:"draws"`
+	WinRatePct float64 `json:"win_rate_pct"`
+}
+
+// FavoriteArcana is the arcana a user used most.
+type FavoriteArcana struct {
+	PowerUpID string `json:"power_up_id"`
+	Uses      int    `json:"uses"`
+}
+
+// EloPoint is a user's rating after one rated game.
+type EloPoint struct {
+	MatchID  string `json:"match_id"`
+	PlayedAt string `json:"played_at"` // ISO8601
+	Elo      int    `json:"elo"`
+}
+
+// userSeatSQL is a SQL condition true when the row aliased alias (with a player_idx column) belongs to the
+// seat userExpr played in the game_history row aliased gh.
+func userSeatSQL(alias, userExpr string) string {
+	return `((` + alias + `.player_idx = 0 AND gh.player0_user_id = ` + userExpr + `) OR (` + alias + `.player_idx = 1 AND gh.player1_user_id = ` + userExpr + `))`
+}
+
+// GetPlayerStats aggregates userID's games in the realm from game_history, turn and arcana_use: win rate
+// against humans and bots, average score, combo streaks, favorite arcana, game length and rating trend.
+func (s *Store) GetPlayerStats(ctx context.Context, realm, userID string) (*PlayerStats, error) {
+	out := &PlayerStats{EloTrend: []EloPoint{}}
+	if s == nil || s.pool == nil || userID == "" {
+		return out, nil
+	}
+
+	var humanWins, humanDraws, botWins, botDraws int
+	err := s.pool.QueryRow(ctx, `
+		WITH g AS (
+			SELECT CASE WHEN player0_user_id = $1 THEN 0 ELSE 1 END AS seat,
+				CASE WHEN player0_user_id = $1 THEN player1_user_id ELSE player0_user_id END AS opponent,
+				CASE WHEN player0_user_id = $1 THEN player0_score ELSE player1_score END AS score,
+				winner_index
+			FROM game_history
+			WHERE (player0_user_id = $1 OR player1_user_id = $1) AND realm = $2
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE opponent NOT LIKE 'ai:%'),
+			COUNT(*) FILTER (WHERE opponent NOT LIKE 'ai:%' AND winner_index = seat),
+			COUNT(*) FILTER (WHERE opponent NOT LIKE 'ai:%' AND winner_index IS NULL),
+			COUNT(*) FILTER (WHERE opponent LIKE 'ai:%'),
+			COUNT(*) FILTER (WHERE opponent LIKE 'ai:%' AND winner_index = seat),
+			COUNT(*) FILTER (WHERE opponent LIKE 'ai:%' AND winner_index IS NULL),
+			COALESCE(AVG(score), 0)::float
+		FROM g`,
+		userID, realm).Scan(&out.VsHumans.Games, &humanWins, &humanDraws, &out.VsBots.Games, &botWins, &botDraws, &out.AvgScore)
+	if err != nil {
+		return nil, err
+	}
+	out.VsHumans = newRecordSplit(out.VsHumans.Games, humanWins, humanDraws)
+	out.VsBots = newRecordSplit(out.VsBots.Games, botWins, botDraws)
+	out.Games = out.VsHumans.Games + out.VsBots.Games
+	if out.Games == 0 {
+		return out, nil
+	}
+
+	err = s.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COALESCE(AVG(t.pairs_matched), 0)::float
+			FROM turn t JOIN game_history gh ON gh.id = t.match_id
+			WHERE gh.realm = $2 AND t.pairs_matched > 0 AND `+userSeatSQL("t", "$1")+`),
+			(SELECT COALESCE(MAX(t.pairs_matched), 0)
+			FROM turn t JOIN game_history gh ON gh.id = t.match_id
+			WHERE gh.realm = $2 AND `+userSeatSQL("t", "$1")+`),
+			(SELECT COALESCE(AVG(n), 0)::float FROM (
+				SELECT COUNT(*) AS n
+				FROM turn t JOIN game_history gh ON gh.id = t.match_id
+				WHERE gh.realm = $2 AND (gh.player0_user_id = $1 OR gh.player1_user_id = $1)
+				GROUP BY t.match_id
+			) per_game)`,
+		userID, realm).Scan(&out.AvgComboStreak, &out.BestComboStreak, &out.AvgGameTurns)
+	if err != nil {
+		return nil, err
+	}
+
+	var fav FavoriteArcana
+	err = s.pool.QueryRow(ctx, `
+		SELECT au.power_up_id, COUNT(*) AS uses
+		FROM arcana_use au JOIN game_history gh ON gh.id = au.match_id
+		WHERE gh.realm = $2 AND `+userSeatSQL("au", "$1")+`
+		GROUP BY au.power_up_id
+		ORDER BY uses DESC, au.power_up_id
+		LIMIT 1`,
+		userID, realm).Scan(&fav.PowerUpID, &fav.Uses)
+	switch {
+	case err == nil:
+		out.FavoriteArcana = &fav
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, played_at, CASE WHEN player0_user_id = $1 THEN player0_elo_after ELSE player1_elo_after END AS elo
+		FROM game_history
+		WHERE (player0_user_id = $1 OR player1_user_id = $1) AND realm = $2
+			AND (CASE WHEN player0_user_id = $1 THEN player0_elo_after ELSE player1_elo_after END) IS NOT NULL
+		ORDER BY played_at DESC
+		LIMIT $3`,
+		userID, realm, eloTrendGames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p EloPoint
+		var playedAt time.Time
+		if err := rows.Scan(&p.MatchID, &playedAt, &p.Elo); err != nil {
+			return nil, err
+		}
+		p.PlayedAt = playedAt.UTC().Format(time.RFC3339)
+		out.EloTrend = append(out.EloTrend, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Newest first from the query; the trend reads oldest first.
+	slices.Reverse(out.EloTrend)
+	return out, nil
+}
+
+// newRecordSplit builds a record from its game, win and draw counts.
+func newRecordSplit(games, wins, draws int) RecordSplit {
+	r := RecordSplit{Games: games, Wins: wins, Losses: games - wins - draws, Draws: draws}
+	if games > 0 {
+		r.WinRatePct = 100.0 * float64(wins) / float64(games)
+	}
+	return r
+}
@@ -0,0 +1,17 @@
+package storage
+
+import "context"
+
+// InsertPollResponse stores a player's answer to an experiment poll asked after the match. The first answer
+// of a seat member to a poll is kept; later ones are ignored.
+func (s *Store) InsertPollResponse(ctx context.Context, matchID, pollID string, seat, member int, userID, answer string) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO poll_response (match_id, poll_id, seat, member, user_id, answer)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (match_id, poll_id, seat, member) DO NOTHING`,
+		matchID, pollID, seat, member, userID, answer)
+	return err
+}
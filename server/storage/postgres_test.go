@@ -0,0 +1,977 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// These tests run against a real Postgres: the TEST_DATABASE_URL database when set, otherwise a postgres:16
+// container started on first use and removed when the package's tests are done (needs Docker). Without
+// either they are skipped, except in CI (CI set), where a missing database fails them.
+//
+// Each test gets its own schema, dropped at the end, so tests can run in parallel and leave nothing behind.
+
+var (
+	testDBOnce      sync.Once
+	testDBURL       string
+	testDBErr       error
+	testDBContainer *postgres.PostgresContainer
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testDBContainer != nil {
+		testcontainers.TerminateContainer(testDBContainer)
+	}
+	os.Exit(code)
+}
+
+// testDatabaseURL returns TEST_DATABASE_URL, or the URL of a throwaway container started on the first call.
+func testDatabaseURL() (string, error) {
+	if dbURL := os.Getenv("TEST_DATABASE_URL"); dbURL != "" {
+		return dbURL, nil
+	}
+	testDBOnce.Do(func() {
+		ctx := context.Background()
+		// testcontainers panics instead of failing when it finds no Docker at all.
+		defer func() {
+			if r := recover(); r != nil {
+				testDBContainer, testDBErr = nil, fmt.Errorf("start postgres container: %v", r)
+			}
+		}()
+		testDBContainer, testDBErr = postgres.Run(ctx, "postgres:16", postgres.WithPassword("test"), postgres.BasicWaitStrategies())
+		if testDBErr != nil {
+			testDBContainer = nil
+			return
+		}
+		testDBURL, testDBErr = testDBContainer.ConnectionString(ctx, "sslmode=disable")
+	})
+	return testDBURL, testDBErr
+}
+
+// newTestStore returns a Store on a fresh schema of the test database (see testDatabaseURL), or skips the
+// test when there is none outside CI.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	dbURL, err := testDatabaseURL()
+	if err != nil {
+		if os.Getenv("CI") != "" {
+			t.Fatalf("no test database: %v", err)
+		}
+		t.Skipf("no test database (set TEST_DATABASE_URL or start Docker): %v", err)
+	}
+	ctx := context.Background()
+	admin, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "storage_test_" + hex.EncodeToString(suffix)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	sep := "?"
+	if strings.Contains(dbURL, "?") {
+		sep = "&"
+	}
+	store, err := NewStore(ctx, dbURL+sep+"search_path="+schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+// insertTestGame records a finished game between p0 and p1 with no ratings.
+func insertTestGame(t *testing.T, s *Store, matchID, p0, p1 string, score0, score1, winner int) {
+	t.Helper()
+	insertRealmGame(t, s, "", matchID, p0, p1, score0, score1, winner)
+}
+
+// insertRealmGame is insertTestGame in the given realm.
+func insertRealmGame(t *testing.T, s *Store, realm, matchID, p0, p1 string, score0, score1, winner int) {
+	t.Helper()
+	if err := s.InsertGameResult(context.Background(), realm, GameResult{MatchID: matchID, Player0UserID: p0, Player1UserID: p1, Player0Name: p0, Player1Name: p1,
+		Player0Score: score0, Player1Score: score1, WinnerIndex: winner, EndReason: "completed"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostgres_UpdateRatingsAfterGameConcurrentSameMatch(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	matchID := uuid.New().String()
+
+	// The end of a match can be reported more than once at the same time; only one call may count.
+	const calls = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _, _, err := s.UpdateRatingsAfterGame(ctx, "", matchID, "user-a", "user-b", "A", "B", 0)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"user-a", "user-b"} {
+		e, err := s.GetLeaderboardEntryByUserID(ctx, "", id)
+		if err != nil || e == nil {
+			t.Fatalf("expected a rating for %s, got %v, %v", id, e, err)
+		}
+		if n := e.Wins + e.Losses + e.Draws; n != 1 {
+			t.Errorf("expected %s to have 1 game recorded, got %d", id, n)
+		}
+	}
+}
+
+func TestPostgres_UpdateRatingsAfterGameManyGamesAtOnce(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// user-a finishes many games at the same moment, against two opponents and from either seat, so the
+	// same rows are requested in both orders.
+	const games = 40
+	var wg sync.WaitGroup
+	errs := make(chan error, games)
+	for i := range games {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p0, p1 := "user-a", "user-b"
+			if i%4 >= 2 {
+				p1 = "user-c"
+			}
+			if i%2 == 1 {
+				p0, p1 = p1, p0
+			}
+			_, _, _, _, err := s.UpdateRatingsAfterGame(ctx, "", uuid.New().String(), p0, p1, p0, p1, i%3-1)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]int{"user-a": games, "user-b": games / 2, "user-c": games / 2}
+	for id, n := range want {
+		e, err := s.GetLeaderboardEntryByUserID(ctx, "", id)
+		if err != nil || e == nil {
+			t.Fatalf("expected a rating for %s, got %v, %v", id, e, err)
+		}
+		if got := e.Wins + e.Losses + e.Draws; got != n {
+			t.Errorf("expected %s to have %d games recorded, got %d (an update was lost)", id, n, got)
+		}
+	}
+}
+
+func TestPostgres_UpdateRatingsAfterGameIdempotent(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	matchID := uuid.New().String()
+
+	b0, a0, b1, a1, err := s.UpdateRatingsAfterGame(ctx, "", matchID, "user-a", "user-b", "A", "B", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb0, ra0, rb1, ra1, err := s.UpdateRatingsAfterGame(ctx, "", matchID, "user-a", "user-b", "A", "B", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rb0 != b0 || ra0 != a0 || rb1 != b1 || ra1 != a1 {
+		t.Errorf("expected the repeated call to return %d/%d/%d/%d, got %d/%d/%d/%d", b0, a0, b1, a1, rb0, ra0, rb1, ra1)
+	}
+	e, _ := s.GetLeaderboardEntryByUserID(ctx, "", "user-a")
+	if e == nil || e.Wins != 1 || e.Losses != 0 || e.Elo != a0 {
+		t.Errorf("expected one win at %d for user-a, got %+v", a0, e)
+	}
+}
+
+func TestPostgres_InsertGameResultIdempotent(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	matchID := uuid.New().String()
+
+	insertTestGame(t, s, matchID, "user-a", "user-b", 10, 4, 0)
+	insertTestGame(t, s, matchID, "user-a", "user-b", 2, 12, 1)
+	arcana := []string{"chaos", "leech"}
+	for range 2 {
+		if err := s.InsertMatchArcana(ctx, matchID, arcana); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count, score0, winner int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*), MAX(player0_score), MAX(winner_index) FROM game_history WHERE id = $1`, matchID).Scan(&count, &score0, &winner); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || score0 != 10 || winner != 0 {
+		t.Errorf("expected the first result only, got %d rows, score %d, winner %d", count, score0, winner)
+	}
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM match_arcana WHERE match_id = $1`, matchID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != len(arcana) {
+		t.Errorf("expected %d arcana rows, got %d", len(arcana), count)
+	}
+}
+
+func TestPostgres_LeaderboardBrackets(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for _, r := range []struct {
+		userID string
+		elo    int
+	}{{"ai:Mnemosyne", 1500}, {"human-a", 1300}, {"ai:Thalia", 1200}, {"human-b", 1100}} {
+		if _, err := s.pool.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name, elo) VALUES ('', $1, $1, $2)`, r.userID, r.elo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func(bracket string) []string {
+		entries, err := s.ListLeaderboard(ctx, "", LeaderboardQuery{Scope: LeaderboardScopeGlobal, Bracket: bracket, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.UserID)
+		}
+		return out
+	}
+	if got := ids(LeaderboardBracketAll); !slices.Equal(got, []string{"ai:Mnemosyne", "human-a", "ai:Thalia", "human-b"}) {
+		t.Errorf("expected everyone ranked together, got %v", got)
+	}
+	if got := ids(LeaderboardBracketHumans); !slices.Equal(got, []string{"human-a", "human-b"}) {
+		t.Errorf("expected humans only, got %v", got)
+	}
+	if got := ids(LeaderboardBracketBots); !slices.Equal(got, []string{"ai:Mnemosyne", "ai:Thalia"}) {
+		t.Errorf("expected bots only, got %v", got)
+	}
+}
+
+func TestPostgres_ListLeaderboardPagination(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	const players = 7
+	for i := range players {
+		if _, err := s.pool.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name, elo) VALUES ('', $1, $1, $2)`,
+			fmt.Sprintf("user-%d", i), 1000+10*i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.pool.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name, elo) VALUES ('school', 'other', 'Other', 2000)`); err != nil {
+		t.Fatal(err)
+	}
+
+	var all []LeaderboardEntry
+	for offset := 0; ; offset += 3 {
+		page, err := s.ListLeaderboard(ctx, "", LeaderboardQuery{Scope: LeaderboardScopeGlobal, Bracket: LeaderboardBracketAll, Limit: 3, Offset: offset})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		if len(page) > 3 {
+			t.Fatalf("expected at most 3 entries per page, got %d", len(page))
+		}
+		all = append(all, page...)
+	}
+	if len(all) != players {
+		t.Fatalf("expected %d entries across pages, got %d", players, len(all))
+	}
+	seen := make(map[string]bool)
+	for i, e := range all {
+		if seen[e.UserID] || e.UserID == "other" {
+			t.Errorf("unexpected entry %s at %d", e.UserID, i)
+		}
+		seen[e.UserID] = true
+		if i > 0 && e.Elo > all[i-1].Elo {
+			t.Errorf("expected elo descending, got %d after %d", e.Elo, all[i-1].Elo)
+		}
+	}
+	if all[0].UserID != "user-6" {
+		t.Errorf("expected user-6 first, got %s", all[0].UserID)
+	}
+}
+
+func TestPostgres_GetTelemetryMetrics(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// Two matches: a pvp win with chaos and leech dealt, and a draw against a bot with chaos only.
+	win, draw := uuid.New().String(), uuid.New().String()
+	insertTestGame(t, s, win, "user-a", "user-b", 8, 4, 0)
+	insertTestGame(t, s, draw, "user-a", "ai:thalia", 6, 6, -1)
+	if err := s.InsertMatchArcana(ctx, win, []string{"chaos", "leech"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertMatchArcana(ctx, draw, []string{"chaos"}); err != nil {
+		t.Fatal(err)
+	}
+	for round, m := range []string{win, win, win, draw} {
+		if err := s.InsertTurn(ctx, m, round, round%2, 2, 0, 2, 0, 1, 2, 0, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Round 0 of the win: chaos and leech together (a combo); round 2: chaos alone.
+	uses := []struct {
+		round   int
+		id      string
+		delta   int
+		matchID string
+	}{{0, "chaos", 2, win}, {0, "leech", 0, win}, {2, "chaos", 4, win}}
+	for _, u := range uses {
+		if err := s.InsertArcanaUse(ctx, u.matchID, u.round, 0, u.id, -1, 0, 0, u.round, u.delta, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := s.GetTelemetryMetrics(ctx, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Global.TotalMatches != 2 || m.Global.TotalTurns != 4 || m.Global.AvgTurnsPerMatch != 2 {
+		t.Errorf("unexpected global totals %+v", m.Global)
+	}
+	if m.Global.CardsPerTurnMax != 2 || m.Global.CardsPerTurnAvg != 1.5 {
+		t.Errorf("expected 1.5 cards per turn on average and 2 at most, got %+v", m.Global)
+	}
+	if m.Players.ActiveInPeriod != 3 {
+		t.Errorf("expected 3 active players, got %d", m.Players.ActiveInPeriod)
+	}
+	byCard := make(map[string]TelemetryByCard)
+	for _, c := range m.ByCard {
+		byCard[c.PowerUpID] = c
+	}
+	chaos, leech := byCard["chaos"], byCard["leech"]
+	if chaos.TotalMatches != 2 || chaos.WinsWithCard != 1 || chaos.WinRatePct != 50 || chaos.UseCount != 2 || chaos.AvgPointSwingPlayer != 3 {
+		t.Errorf("unexpected chaos metrics %+v", chaos)
+	}
+	if leech.TotalMatches != 1 || leech.WinsWithCard != 1 || leech.UseCount != 1 {
+		t.Errorf("unexpected leech metrics %+v", leech)
+	}
+	if len(m.ByCombo) != 1 || m.ByCombo[0].ComboKey != "chaos,leech" || m.ByCombo[0].TotalMatches != 1 {
+		t.Errorf("expected the chaos+leech combo once, got %+v", m.ByCombo)
+	}
+
+	pvp, err := s.GetTelemetryMetrics(ctx, "", &TelemetryBinConfig{MatchType: "pvp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pvp.Global.TotalMatches != 1 || pvp.Global.TotalTurns != 3 {
+		t.Errorf("expected the pvp filter to keep only the win, got %+v", pvp.Global)
+	}
+}
+
+func TestPostgres_TelemetryBatches(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	matchID := uuid.New().String()
+	insertTestGame(t, s, matchID, "user-a", "user-b", 3, 1, 0)
+	turns := []TurnRecord{
+		{Round: 1, PlayerIdx: 0, PlayerScoreAfter: 2, DeltaPlayer: 2, Actions: 4, RTTMS: 40},
+		{Round: 2, PlayerIdx: 1, PlayerScoreAfter: 1, OpponentScoreAfter: 2, DeltaPlayer: 1, Actions: 2},
+		{Round: 3, PlayerIdx: 0, PlayerScoreAfter: 3, OpponentScoreAfter: 1, DeltaPlayer: 1, Actions: 2},
+	}
+	uses := []ArcanaUseRecord{
+		{Round: 1, PlayerIdx: 0, PowerUpID: "chaos", TargetCardIndex: -1, PointDeltaPlayer: 2},
+		{Round: 2, PlayerIdx: 1, PowerUpID: "leech", TargetCardIndex: 5, PlayerScoreBefore: 0, OpponentScoreBefore: 2, PairsMatchedBefore: 2},
+	}
+	if err := s.InsertTurnsBatch(ctx, matchID, turns); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertArcanaUsesBatch(ctx, matchID, uses); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertTurnsBatch(ctx, matchID, nil); err != nil {
+		t.Errorf("expected an empty batch to be a no-op, got %v", err)
+	}
+
+	var turnCount, deltaSum, rtt int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*), SUM(point_delta_player), MAX(rtt_ms) FROM turn WHERE match_id = $1`, matchID).Scan(&turnCount, &deltaSum, &rtt); err != nil {
+		t.Fatal(err)
+	}
+	if turnCount != 3 || deltaSum != 4 || rtt != 40 {
+		t.Errorf("expected 3 turns worth 4 points with a 40ms RTT, got %d turns, %d points, %dms", turnCount, deltaSum, rtt)
+	}
+	var target int
+	if err := s.pool.QueryRow(ctx, `SELECT target_card_index FROM arcana_use WHERE match_id = $1 AND power_up_id = 'leech'`, matchID).Scan(&target); err != nil {
+		t.Fatal(err)
+	}
+	if target != 5 {
+		t.Errorf("expected the leech use stored with its target, got %d", target)
+	}
+}
+
+func TestPostgres_DisplayNameSync(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// neon_auth is shared by every test schema; the test only touches its own user.
+	userID := uuid.New().String()
+	if _, err := s.pool.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS neon_auth`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS neon_auth."user" (id UUID PRIMARY KEY, name TEXT, role TEXT)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.pool.Exec(ctx, `INSERT INTO neon_auth."user" (id, name) VALUES ($1, '  Maria   Silva ')`, userID); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.pool.Exec(context.Background(), `DELETE FROM neon_auth."user" WHERE id = $1`, userID) })
+
+	for _, realm := range []string{"", "school"} {
+		if _, err := s.pool.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name) VALUES ($1, $2, 'Old')`, realm, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := s.SyncDisplayNamesFromAuth(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected both realms' rows to be renamed, got %d", n)
+	}
+	if n, _ := s.SyncDisplayNamesFromAuth(ctx); n != 0 {
+		t.Errorf("expected nothing left to sync, got %d", n)
+	}
+	e, _ := s.GetLeaderboardEntryByUserID(ctx, "school", userID)
+	if e == nil || e.DisplayName != "Maria" {
+		t.Errorf("expected the first name from auth, got %+v", e)
+	}
+
+	if err := s.UpdateDisplayName(ctx, "", userID, "Mia"); err != nil {
+		t.Fatal(err)
+	}
+	e, _ = s.GetLeaderboardEntryByUserID(ctx, "", userID)
+	other, _ := s.GetLeaderboardEntryByUserID(ctx, "school", userID)
+	if e == nil || e.DisplayName != "Mia" || other == nil || other.DisplayName != "Maria" {
+		t.Errorf("expected only the realm's row to change, got %+v and %+v", e, other)
+	}
+	if err := s.UpdateDisplayName(ctx, "", "unknown-user", "Nobody"); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := s.GetLeaderboardEntryByUserID(ctx, "", "unknown-user"); e != nil {
+		t.Errorf("expected no row for a user without games, got %+v", e)
+	}
+}
+
+func TestPostgres_PrivateProfile(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if st, err := s.GetUserSettings(ctx, "user-a"); err != nil || st.ProfilePrivate {
+		t.Fatalf("expected public defaults, got %+v (%v)", st, err)
+	}
+	if err := s.SaveUserSettings(ctx, "user-a", UserSettings{ProfilePrivate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if st, _ := s.GetUserSettings(ctx, "user-a"); !st.ProfilePrivate {
+		t.Fatal("expected the saved setting to be returned")
+	}
+
+	for _, id := range []string{"user-a", "user-b"} {
+		if _, err := s.pool.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name) VALUES ('', $1, $1)`, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(viewer string) map[string]string {
+		entries, err := s.ListLeaderboard(ctx, "", LeaderboardQuery{ViewerUserID: viewer, Scope: LeaderboardScopeGlobal, Bracket: LeaderboardBracketAll, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]string)
+		for _, e := range entries {
+			out[e.DisplayName] = e.UserID
+		}
+		return out
+	}
+	if got := names(""); got[AnonymousDisplayName] != "" || got["user-b"] != "user-b" || len(got) != 2 {
+		t.Errorf("expected user-a to be anonymous to others, got %v", got)
+	}
+	if got := names("user-a"); got["user-a"] != "user-a" {
+		t.Errorf("expected user-a to see their own entry, got %v", got)
+	}
+
+	matchID := uuid.New().String()
+	insertTestGame(t, s, matchID, "user-a", "user-b", 5, 3, 0)
+	sum, err := s.GetMatchSummary(ctx, "", matchID)
+	if err != nil || sum == nil {
+		t.Fatalf("expected a summary, got %v (%v)", sum, err)
+	}
+	if sum.Players[0].Name != AnonymousDisplayName || sum.Players[1].Name != "user-b" {
+		t.Errorf("expected only the private player to be anonymous, got %+v", sum.Players)
+	}
+
+	if err := s.SaveUserSettings(ctx, "user-a", UserSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(""); got["user-a"] != "user-a" {
+		t.Errorf("expected user-a to be listed again after going public, got %v", got)
+	}
+}
+
+func TestPostgres_InsertPollResponseKeepsFirstAnswer(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	matchID := uuid.New().String()
+	for _, answer := range []string{"yes", "no"} {
+		if err := s.InsertPollResponse(ctx, matchID, "fun", 0, 0, "user-a", answer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.InsertPollResponse(ctx, matchID, "fun", 1, 0, "user-b", "no"); err != nil {
+		t.Fatal(err)
+	}
+	var answer string
+	if err := s.pool.QueryRow(ctx, `SELECT answer FROM poll_response WHERE match_id = $1 AND seat = 0`, matchID).Scan(&answer); err != nil {
+		t.Fatal(err)
+	}
+	if answer != "yes" {
+		t.Errorf("expected the first answer to be kept, got %q", answer)
+	}
+	var count int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM poll_response WHERE match_id = $1`, matchID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected one answer per seat, got %d", count)
+	}
+}
+
+func TestPostgres_MatchSummaryScoreSeries(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	matchID := uuid.New().String()
+	insertTestGame(t, s, matchID, "user-a", "user-b", 2, 3, 1)
+	for _, turn := range []struct{ round, seat, own, other int }{{0, 0, 2, 0}, {1, 1, 3, 2}} {
+		if err := s.InsertTurn(ctx, matchID, turn.round, turn.seat, turn.own, turn.other, 0, 0, 0, 0, 0, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []ScorePoint{{Round: 0, Scores: [2]int{2, 0}}, {Round: 1, Scores: [2]int{2, 3}}}
+	check := func(when string) {
+		t.Helper()
+		sum, err := s.GetMatchSummary(ctx, "", matchID)
+		if err != nil || sum == nil {
+			t.Fatalf("%s: expected a summary, got %v (%v)", when, sum, err)
+		}
+		if len(sum.ScoreSeries) != len(want) || sum.ScoreSeries[0] != want[0] || sum.ScoreSeries[1] != want[1] {
+			t.Errorf("%s: expected series %+v, got %+v", when, want, sum.ScoreSeries)
+		}
+	}
+	check("before caching")
+
+	if err := s.CacheScoreSeries(ctx, matchID); err != nil {
+		t.Fatal(err)
+	}
+	var cached []byte
+	if err := s.pool.QueryRow(ctx, `SELECT score_series FROM game_history WHERE id = $1`, matchID).Scan(&cached); err != nil || cached == nil {
+		t.Fatalf("expected the series to be stored, got %s (%v)", cached, err)
+	}
+	check("after caching")
+}
+
+func TestPostgres_MatchReplay(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if replay, err := s.GetMatchReplay(ctx, uuid.New().String()); err != nil || replay != nil {
+		t.Fatalf("expected no replay for an unknown match, got %v (%v)", replay, err)
+	}
+	matchID := uuid.New().String()
+	insertTestGame(t, s, matchID, "user-a", "ai:Mnemosyne", 2, 0, 0)
+	events := []ReplayEvent{
+		{Seq: 1, Type: "flip", Data: []byte(`{"seq":1,"type":"flip"}`)},
+		{Seq: 0, Type: "start", Data: []byte(`{"seq":0,"type":"start"}`)},
+	}
+	// A retried flush stores each event once.
+	for range 2 {
+		if err := s.InsertReplayEvents(ctx, matchID, events); err != nil {
+			t.Fatal(err)
+		}
+	}
+	replay, err := s.GetMatchReplay(ctx, matchID)
+	if err != nil || replay == nil {
+		t.Fatalf("expected a replay, got %v (%v)", replay, err)
+	}
+	if len(replay.Events) != 2 || !strings.Contains(string(replay.Events[0]), `"start"`) {
+		t.Errorf("expected start then flip, got %s", replay.Events)
+	}
+	if replay.Players[0].Name != "user-a" || !replay.Players[1].IsBot {
+		t.Errorf("expected user-a against a bot, got %+v", replay.Players)
+	}
+}
+
+func TestPostgres_Friends(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	for i, id := range []string{"user-a", "user-b", "user-c"} {
+		if _, err := s.pool.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name, elo) VALUES ('', $1, $1, $2)`, id, 1000+100*i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.SaveUserSettings(ctx, "user-b", UserSettings{ProfilePrivate: true}); err != nil {
+		t.Fatal(err)
+	}
+	if st, err := s.RequestFriend(ctx, "user-a", "user-b"); err != nil || st != FriendPending {
+		t.Fatalf("expected a pending request, got %q (%v)", st, err)
+	}
+	if st, _ := s.RequestFriend(ctx, "user-a", "user-b"); st != FriendPending {
+		t.Errorf("expected a repeated request to stay pending, got %q", st)
+	}
+	friends, err := s.ListFriends(ctx, "", "user-a")
+	if err != nil || len(friends) != 1 || friends[0].Status != FriendOutgoing || friends[0].DisplayName != AnonymousDisplayName {
+		t.Fatalf("expected one outgoing request to an anonymous user, got %+v (%v)", friends, err)
+	}
+	if ok, _ := s.AcceptFriend(ctx, "user-a", "user-b"); ok {
+		t.Error("expected the requester not to accept their own request")
+	}
+	if ok, err := s.AcceptFriend(ctx, "user-b", "user-a"); err != nil || !ok {
+		t.Fatalf("expected user-b to accept, got %v (%v)", ok, err)
+	}
+	// Asking back a user who already asked accepts their request.
+	s.RequestFriend(ctx, "user-c", "user-a")
+	if st, _ := s.RequestFriend(ctx, "user-a", "user-c"); st != FriendAccepted {
+		t.Errorf("expected the crossed requests to be accepted, got %q", st)
+	}
+
+	friends, _ = s.ListFriends(ctx, "", "user-a")
+	if len(friends) != 2 || friends[0].UserID != "user-c" || friends[1].DisplayName != "user-b" || friends[1].Status != FriendAccepted {
+		t.Errorf("expected user-c then user-b, both accepted and named, got %+v", friends)
+	}
+	entries, err := s.ListLeaderboard(ctx, "", LeaderboardQuery{ViewerUserID: "user-b", Scope: LeaderboardScopeFriends, Bracket: LeaderboardBracketAll, Limit: 10})
+	if err != nil || len(entries) != 2 || entries[0].UserID != "user-b" || entries[1].UserID != "user-a" {
+		t.Errorf("expected only user-b and their friend user-a, got %+v (%v)", entries, err)
+	}
+	entries, _ = s.ListLeaderboard(ctx, "", LeaderboardQuery{ViewerUserID: "user-a", Scope: LeaderboardScopeGlobal, Bracket: LeaderboardBracketAll, Limit: 10})
+	for _, e := range entries {
+		if e.DisplayName == AnonymousDisplayName {
+			t.Errorf("expected the private friend user-b to be named for user-a, got %+v", entries)
+		}
+	}
+}
+
+func TestPostgres_SuspiciousTiming(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	user := "fast-" + uuid.New().String()
+	for i, count := range []int{2, 4} {
+		matchID := uuid.New().String()
+		insertTestGame(t, s, matchID, user, "ai:Mnemosyne", 2, 0, 0)
+		// A retried write stores the seat once.
+		for range 2 {
+			if err := s.InsertSuspiciousActivity(ctx, matchID, 0, SuspiciousFastFlip, count, 20-i*5); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	flags, err := s.GetSuspiciousTimingReport(ctx, IntegrityReportConfig{TimeRange: "24h", MinFastFlips: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found *SuspiciousTimingFlag
+	for i := range flags {
+		if flags[i].UserID == user {
+			found = &flags[i]
+		}
+	}
+	if found == nil || found.Matches != 2 || found.FastFlips != 6 || found.MinGapMS != 15 {
+		t.Fatalf("expected %s flagged with 6 fast flips over 2 matches, min gap 15ms, got %+v", user, found)
+	}
+	for _, f := range flags {
+		if strings.HasPrefix(f.UserID, "ai:") {
+			t.Errorf("expected no bot in the report, got %+v", f)
+		}
+	}
+}
+
+func TestPostgres_ActiveGameSnapshot(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	matchID := uuid.New().String()
+	if err := s.SaveRejoinTokens(ctx, []RejoinToken{{MatchID: matchID, Seat: 0, UserID: "user-a", Token: "tok-a"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, state := range []string{`{"round":1}`, `{"round":2}`} {
+		if err := s.SaveActiveGame(ctx, "", matchID, []byte(state)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tok, err := s.FindRejoinTokenByUser(ctx, "user-a")
+	if err != nil || tok == nil || tok.SnapshotRef != matchID {
+		t.Fatalf("expected the token to point at the snapshot, got %+v (%v)", tok, err)
+	}
+	state, err := s.LoadActiveGame(ctx, "", matchID)
+	if err != nil || !strings.Contains(string(state), `"round": 2`) {
+		t.Fatalf("expected the latest snapshot, got %s (%v)", state, err)
+	}
+	if state, _ := s.LoadActiveGame(ctx, "other-realm", matchID); state != nil {
+		t.Error("expected no snapshot for another realm")
+	}
+	if err := s.DeleteActiveGame(ctx, matchID); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := s.LoadActiveGame(ctx, "", matchID); state != nil {
+		t.Error("expected the snapshot to be gone once deleted")
+	}
+}
+
+func TestPostgres_Seasons(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	if cur, err := s.CurrentSeason(ctx, ""); err != nil || cur.Number != 0 {
+		t.Fatalf("expected no season before they start, got %+v (%v)", cur, err)
+	}
+	for range 2 {
+		if cur, err := s.StartSeasons(ctx, ""); err != nil || cur.Number != 1 {
+			t.Fatalf("expected season 1, got %+v (%v)", cur, err)
+		}
+	}
+	matchID := uuid.New().String()
+	insertTestGame(t, s, matchID, "user-a", "user-b", 3, 1, 0)
+	if _, _, _, _, err := s.UpdateRatingsAfterGame(ctx, "", matchID, "user-a", "user-b", "A", "B", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if ended, err := s.EndSeason(ctx, "", 1, 50); err != nil || !ended {
+		t.Fatalf("expected season 1 to end, got %v (%v)", ended, err)
+	}
+	if ended, _ := s.EndSeason(ctx, "", 1, 50); ended {
+		t.Error("expected an ended season not to end twice")
+	}
+	seasons, err := s.ListSeasons(ctx, "")
+	if err != nil || len(seasons) != 2 || seasons[0].Number != 2 || seasons[0].EndedAt != "" || seasons[1].EndedAt == "" {
+		t.Fatalf("expected season 2 open after the ended season 1, got %+v (%v)", seasons, err)
+	}
+
+	// 1016 and 984 after the game, squashed halfway back to 1000.
+	current, _ := s.ListLeaderboard(ctx, "", LeaderboardQuery{Scope: LeaderboardScopeGlobal, Bracket: LeaderboardBracketAll, Limit: 10})
+	if len(current) != 2 || current[0].Elo != 1008 || current[0].Wins != 0 || current[1].Elo != 992 {
+		t.Errorf("expected squashed ratings with a fresh record, got %+v", current)
+	}
+	archived, _ := s.ListLeaderboard(ctx, "", LeaderboardQuery{Scope: LeaderboardScopeGlobal, Bracket: LeaderboardBracketAll, Season: 1, Limit: 10})
+	if len(archived) != 2 || archived[0].UserID != "user-a" || archived[0].Elo != 1016 || archived[0].Wins != 1 {
+		t.Errorf("expected season 1's final ratings, got %+v", archived)
+	}
+	if e, _ := s.GetSeasonEntryByUserID(ctx, "", "user-b", 1); e == nil || e.Elo != 984 || e.Losses != 1 {
+		t.Errorf("expected user-b's season 1 entry, got %+v", e)
+	}
+
+	if games, _, _ := s.ListByUserIDPaginated(ctx, "", "user-a", HistoryQuery{Season: 1, Limit: 10}); len(games) != 1 {
+		t.Errorf("expected the game in season 1, got %d", len(games))
+	}
+	if games, _, _ := s.ListByUserIDPaginated(ctx, "", "user-a", HistoryQuery{Season: 2, Limit: 10}); len(games) != 0 {
+		t.Errorf("expected no game in season 2, got %d", len(games))
+	}
+}
+
+func TestPostgres_PlayerStats(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// A rated win against a human, a draw against a bot and a loss in the other seat.
+	games := []struct {
+		p0, p1         string
+		score0, score1 int
+		winner         int
+	}{
+		{"user-a", "user-b", 5, 3, 0},
+		{"user-a", "ai:Mnemosyne", 4, 4, -1},
+		{"user-b", "user-a", 6, 2, 0},
+	}
+	for i, g := range games {
+		matchID := uuid.New().String()
+		elo0, elo1 := 1000+i, 1000-i
+		if err := s.InsertGameResult(ctx, "", GameResult{MatchID: matchID, Player0UserID: g.p0, Player1UserID: g.p1, Player0Name: g.p0, Player1Name: g.p1,
+			Player0Score: g.score0, Player1Score: g.score1, WinnerIndex: g.winner, EndReason: "completed",
+			Elo0Before: &elo0, Elo0After: &elo0, Elo1Before: &elo1, Elo1After: &elo1}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			// Three pairs and a Blood Pact bonus (8 points), then one pair paid for with an arcana (0 points):
+			// the streaks count pairs, not points.
+			s.InsertTurn(ctx, matchID, 1, 0, 8, 0, 8, 0, 3, 0, 0, 0, 0)
+			s.InsertTurn(ctx, matchID, 2, 1, 3, 8, 3, 0, 3, 0, 0, 0, 0)
+			s.InsertTurn(ctx, matchID, 3, 0, 8, 3, 0, 0, 1, 0, 0, 0, 0)
+			s.InsertArcanaUse(ctx, matchID, 1, 0, "chaos", 4, 0, 0, 0, 0, 0)
+			s.InsertArcanaUse(ctx, matchID, 3, 0, "chaos", 4, 3, 3, 3, 0, 0)
+			s.InsertArcanaUse(ctx, matchID, 2, 1, "leech", 2, 0, 3, 2, 0, 0)
+		}
+	}
+
+	st, err := s.GetPlayerStats(ctx, "", "user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Games != 3 || st.VsHumans.Games != 2 || st.VsHumans.Wins != 1 || st.VsHumans.Losses != 1 || st.VsHumans.WinRatePct != 50 {
+		t.Errorf("expected 1-1 against humans in 3 games, got %+v", st)
+	}
+	if st.VsBots.Games != 1 || st.VsBots.Draws != 1 || st.VsBots.Losses != 0 {
+		t.Errorf("expected a draw against the bot, got %+v", st.VsBots)
+	}
+	if math.Abs(st.AvgScore-11.0/3) > 1e-9 || st.AvgComboStreak != 2 || st.BestComboStreak != 3 || st.AvgGameTurns != 3 {
+		t.Errorf("expected avg score 3.67, streaks 2 and 3 over a 3-turn game, got %+v", st)
+	}
+	if st.FavoriteArcana == nil || st.FavoriteArcana.PowerUpID != "chaos" || st.FavoriteArcana.Uses != 2 {
+		t.Errorf("expected chaos used twice, got %+v", st.FavoriteArcana)
+	}
+	if len(st.EloTrend) != 3 || st.EloTrend[0].Elo != 1000 || st.EloTrend[2].Elo != 998 {
+		t.Errorf("expected the rating after each game, oldest first, got %+v", st.EloTrend)
+	}
+
+	if st, _ := s.GetPlayerStats(ctx, "", "nobody"); st.Games != 0 || st.FavoriteArcana != nil || len(st.EloTrend) != 0 {
+		t.Errorf("expected empty stats for a user without games, got %+v", st)
+	}
+}
+
+func TestPostgres_ListByUserIDRealm(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	home, east := uuid.New().String(), uuid.New().String()
+	insertRealmGame(t, s, "", home, "user-a", "user-b", 5, 3, 0)
+	insertRealmGame(t, s, "east", east, "user-a", "user-c", 2, 6, 1)
+
+	games, err := s.ListByUserID(ctx, "east", "user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || games[0].ID != east {
+		t.Errorf("expected only the east game, got %+v", games)
+	}
+	if games, _ := s.ListByUserID(ctx, "", "user-a"); len(games) != 1 || games[0].ID != home {
+		t.Errorf("expected only the default realm's game, got %+v", games)
+	}
+}
+
+func TestPostgres_UserArcanaStatsRealm(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	home, east := uuid.New().String(), uuid.New().String()
+	insertRealmGame(t, s, "", home, "user-a", "user-b", 5, 3, 0)
+	insertRealmGame(t, s, "east", east, "user-a", "user-c", 2, 6, 1)
+	if err := s.InsertArcanaUse(ctx, home, 1, 0, "chaos", -1, 0, 0, 0, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.InsertArcanaUse(ctx, east, 1, 0, "leech", -1, 0, 0, 0, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	cards, err := s.GetUserArcanaStats(ctx, "east", "user-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].PowerUpID != "leech" || cards[0].WinsWhenUsed != 0 {
+		t.Errorf("expected only the lost east game's leech, got %+v", cards)
+	}
+	if cards, _ := s.GetUserArcanaStats(ctx, "", "user-a"); len(cards) != 1 || cards[0].PowerUpID != "chaos" || cards[0].WinsWhenUsed != 1 {
+		t.Errorf("expected only the won default realm game's chaos, got %+v", cards)
+	}
+}
+
+func TestPostgres_MatchSummaryRealm(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	matchID := uuid.New().String()
+	insertRealmGame(t, s, "east", matchID, "user-a", "user-b", 5, 3, 0)
+
+	if sum, err := s.GetMatchSummary(ctx, "east", matchID); err != nil || sum == nil || sum.Players[0].Score != 5 {
+		t.Fatalf("expected the east match's summary, got %+v (%v)", sum, err)
+	}
+	if sum, err := s.GetMatchSummary(ctx, "", matchID); err != nil || sum != nil {
+		t.Errorf("expected no summary from another realm, got %+v (%v)", sum, err)
+	}
+}
+
+func TestPostgres_TelemetryMetricsRealm(t *testing.T) {
+	t.Parallel()
+	s := newTestStore(t)
+	ctx := context.Background()
+	home, east := uuid.New().String(), uuid.New().String()
+	insertRealmGame(t, s, "", home, "user-a", "user-b", 5, 3, 0)
+	insertRealmGame(t, s, "east", east, "user-c", "user-d", 2, 6, 1)
+	if _, _, _, _, err := s.UpdateRatingsAfterGame(ctx, "east", east, "user-c", "user-d", "C", "D", 1); err != nil {
+		t.Fatal(err)
+	}
+	for round, m := range []string{home, home, east} {
+		if err := s.InsertTurn(ctx, m, round, round%2, 2, 0, 2, 0, 1, 2, 0, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.InsertArcanaUse(ctx, home, 0, 0, "chaos", -1, 0, 0, 0, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := s.GetTelemetryMetrics(ctx, "east", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Global.TotalMatches != 1 || m.Global.TotalTurns != 1 || len(m.ByCard) != 0 {
+		t.Errorf("expected only the east game and its turn, got %+v and cards %+v", m.Global, m.ByCard)
+	}
+	if m.Players.ActiveInPeriod != 2 || m.Players.RegisteredCount != 2 {
+		t.Errorf("expected the two east players, got %+v", m.Players)
+	}
+	if m, _ := s.GetTelemetryMetrics(ctx, "", nil); m.Global.TotalMatches != 1 || m.Global.TotalTurns != 2 || m.Players.RegisteredCount != 0 {
+		t.Errorf("expected only the default realm's game, got %+v and %+v", m.Global, m.Players)
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"memory-game-server/modes"
+)
+
+// RematchSource is what a past game needs to be played again: its realm, both seats and the board it
+// was dealt (from the config snapshot).
+type RematchSource struct {
+	MatchID       string
+	Realm         string
+	PlayerUserIDs [2]string
+	PlayerNames   [2]string
+	BoardRows     int
+	BoardCols     int
+	// BoardSeed and ArcanaPool replay the opening; HasSeed is false for games recorded before seeds were stored.
+	BoardSeed  int64
+	ArcanaPool []string
+	HasSeed    bool
+	// Mode is the game mode the match was played in (see package modes); empty for ranked games recorded
+	// before modes were, and Casual for the casual ones.
+	Mode string
+}
+
+// GetRematchSource returns the rematch source of a recorded game, or nil when the match is unknown.
+func (s *Store) GetRematchSource(ctx context.Context, matchID string) (*RematchSource, error) {
+	if s == nil || s.pool == nil || matchID == "" {
+		return nil, nil
+	}
+	src := &RematchSource{MatchID: matchID}
+	var snapshot []byte
+	err := s.pool.QueryRow(ctx, `
+		SELECT realm, player0_user_id, player1_user_id, player0_name, player1_name, config_snapshot
+		FROM game_history
+		WHERE id = $1`,
+		matchID).Scan(&src.Realm, &src.PlayerUserIDs[0], &src.PlayerUserIDs[1], &src.PlayerNames[0], &src.PlayerNames[1], &snapshot)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(snapshot) == 0 {
+		return src, nil
+	}
+	var board struct {
+		BoardRows  int      `json:"board_rows"`
+		BoardCols  int      `json:"board_cols"`
+		BoardSeed  *int64   `json:"board_seed"`
+		ArcanaPool []string `json:"arcana_pool"`
+		Mode       string   `json:"mode"`
+		Casual     bool     `json:"casual"`
+	}
+	if err := json.Unmarshal(snapshot, &board); err != nil {
+		return nil, err
+	}
+	src.BoardRows, src.BoardCols, src.ArcanaPool, src.Mode = board.BoardRows, board.BoardCols, board.ArcanaPool, board.Mode
+	if src.Mode == "" && board.Casual {
+		src.Mode = modes.Casual
+	}
+	if board.BoardSeed != nil {
+		src.BoardSeed, src.HasSeed = *board.BoardSeed, true
+	}
+	return src, nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// createMatchEventsSQL stores each recorded match's replay events (see game.ReplayEvent) in order, one
+// row per state transition, with the event as JSON.
+const createMatchEventsSQL = `
+CREATE TABLE IF NOT EXISTS match_events (
+	match_id UUID NOT NULL REFERENCES game_history(id),
+	seq      INT NOT NULL,
+	type     TEXT NOT NULL,
+	data     JSONB NOT NULL,
+	PRIMARY KEY (match_id, seq)
+);
+`
+
+// ReplayEvent is one stored replay event: its position in the match, its type and the event as JSON.
+type ReplayEvent struct {
+	Seq  int
+	Type string
+	Data []byte
+}
+
+// MatchReplay is the ordered event stream of a recorded match, for playing it back move by move (no user
+// IDs). Events is empty for matches recorded before replays were.
+type MatchReplay struct {
+	MatchID string            `json:"match_id"`
+	Players [2]SummaryPlayer  `json:"players"`
+	Events  []json.RawMessage `json:"events"`
+}
+
+// InsertReplayEvents stores the replay events of a match in one batch. Must be called after the
+// game_history row exists; events already stored for the match (same seq) are kept.
+func (s *Store) InsertReplayEvents(ctx context.Context, matchID string, events []ReplayEvent) error {
+	if s == nil || s.pool == nil || len(events) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		batch.Queue(`
+			INSERT INTO match_events (match_id, seq, type, data)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (match_id, seq) DO NOTHING`,
+			matchID, e.Seq, e.Type, e.Data)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// GetMatchReplay returns the replay of a match, or (nil, nil) if the match is not in game_history.
+// Private players are shown as AnonymousDisplayName, as in the match summary.
+func (s *Store) GetMatchReplay(ctx context.Context, matchID string) (*MatchReplay, error) {
+	if s == nil || s.pool == nil || matchID == "" {
+		return nil, nil
+	}
+	replay := &MatchReplay{MatchID: matchID, Events: []json.RawMessage{}}
+	err := s.pool.QueryRow(ctx, `
+		SELECT
+			CASE WHEN `+privateUserSQL("player0_user_id")+` THEN $2 ELSE player0_name END,
+			CASE WHEN `+privateUserSQL("player1_user_id")+` THEN $2 ELSE player1_name END,
+			player0_score, player1_score,
+			player0_user_id LIKE 'ai:%', player1_user_id LIKE 'ai:%'
+		FROM game_history
+		WHERE id = $1`,
+		matchID, AnonymousDisplayName).Scan(&replay.Players[0].Name, &replay.Players[1].Name, &replay.Players[0].Score, &replay.Players[1].Score, &replay.Players[0].IsBot, &replay.Players[1].IsBot)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx, `SELECT data FROM match_events WHERE match_id = $1 ORDER BY seq`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		replay.Events = append(replay.Events, data)
+	}
+	return replay, rows.Err()
+}
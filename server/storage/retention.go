@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultChurnDays is TelemetryBinConfig.ChurnDays when unset.
+const defaultChurnDays = 14
+
+// fillRetention sets the engagement fields of players for human players (AI seats excluded) in games of
+// cfg.MatchType on cfg.BoardSize: retention of the players whose first game falls in cfg.TimeRange,
+// median games per player active in the period, and churn over all time.
+func (s *Store) fillRetention(ctx context.Context, realm string, cfg TelemetryBinConfig, players *TelemetryPlayers) error {
+	typeCond := telemetryRealmCondition + " AND " + gameHistoryMatchTypeCondition(cfg.MatchType) + " AND " + boardSizeCondition(cfg.BoardSize)
+	interval := telemetryTimeIntervalSQL(cfg.TimeRange)
+	players.ChurnDays = cfg.ChurnDays
+
+	// Retention is rolling: a player counts as retained on day N when their last game is at least N days
+	// after their first. Only players whose first game is at least N days old are eligible.
+	var d1Eligible, d1Retained, d7Eligible, d7Retained int
+	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`
+		WITH games AS (
+			SELECT player0_user_id AS user_id, played_at FROM game_history gh WHERE %s
+			UNION ALL
+			SELECT player1_user_id, played_at FROM game_history gh WHERE %s
+		),
+		span AS (
+			SELECT user_id, MIN(played_at) AS first_at, MAX(played_at) AS last_at
+			FROM games
+			WHERE user_id NOT LIKE 'ai:%%'
+			GROUP BY user_id
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE first_at >= now() - interval '%s'),
+			COUNT(*) FILTER (WHERE first_at >= now() - interval '%s' AND first_at <= now() - interval '1 day'),
+			COUNT(*) FILTER (WHERE first_at >= now() - interval '%s' AND first_at <= now() - interval '1 day' AND last_at >= first_at + interval '1 day'),
+			COUNT(*) FILTER (WHERE first_at >= now() - interval '%s' AND first_at <= now() - interval '7 days'),
+			COUNT(*) FILTER (WHERE first_at >= now() - interval '%s' AND first_at <= now() - interval '7 days' AND last_at >= first_at + interval '7 days'),
+			COUNT(*) FILTER (WHERE last_at < now() - make_interval(days => $2))
+		FROM span
+	`, typeCond, typeCond, interval, interval, interval, interval, interval), realm, cfg.ChurnDays).Scan(
+		&players.NewPlayers, &d1Eligible, &d1Retained, &d7Eligible, &d7Retained, &players.ChurnedPlayers); err != nil {
+		return err
+	}
+	players.Day1RetentionPct = retentionPct(d1Eligible, d1Retained)
+	players.Day7RetentionPct = retentionPct(d7Eligible, d7Retained)
+
+	ghCond := getGameHistoryCondForDirectQuery(cfg.MatchType, cfg.TimeRange, cfg.BoardSize)
+	return s.pool.QueryRow(ctx, fmt.Sprintf(`
+		SELECT COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY games), 0)::float
+		FROM (
+			SELECT user_id, COUNT(*) AS games
+			FROM (
+				SELECT player0_user_id AS user_id FROM game_history gh WHERE %s
+				UNION ALL
+				SELECT player1_user_id FROM game_history gh WHERE %s
+			) t
+			WHERE user_id NOT LIKE 'ai:%%'
+			GROUP BY user_id
+		) per_player
+	`, ghCond, ghCond), realm).Scan(&players.MedianGamesPerPlayer)
+}
+
+// retentionPct returns retained as a percentage of eligible, or nil when no player is eligible yet.
+func retentionPct(eligible, retained int) *float64 {
+	if eligible == 0 {
+		return nil
+	}
+	pct := 100.0 * float64(retained) / float64(eligible)
+	return &pct
+}
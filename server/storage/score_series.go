@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// alterGameHistoryAddScoreSeries caches each match's score-by-round series (see CacheScoreSeries), so
+// the match summary does not rebuild it from the turn rows on every request.
+const alterGameHistoryAddScoreSeries = `
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS score_series JSONB;
+`
+
+// ScorePoint is both players' cumulative score at the end of one turn, for a momentum graph.
+type ScorePoint struct {
+	Round  int    `json:"round"`
+	Scores [2]int `json:"scores"` // indexed by player (seat), like MatchSummary.Players
+}
+
+// scoreSeries turns the match's turns (ordered by round) into seat-indexed cumulative scores.
+func scoreSeries(turns []summaryTurn) []ScorePoint {
+	out := make([]ScorePoint, 0, len(turns))
+	for _, t := range turns {
+		p := ScorePoint{Round: t.Round}
+		p.Scores[t.PlayerIdx] = t.PlayerScoreAfter
+		p.Scores[1-t.PlayerIdx] = t.OpponentScoreAfter
+		out = append(out, p)
+	}
+	return out
+}
+
+// summaryTurns loads the turn columns used by the match summary, ordered by round.
+func (s *Store) summaryTurns(ctx context.Context, matchID string) ([]summaryTurn, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT round, player_idx, player_score_after_turn, opponent_score_after_turn, point_delta_player
+		FROM turn
+		WHERE match_id = $1
+		ORDER BY round`,
+		matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var turns []summaryTurn
+	for rows.Next() {
+		var t summaryTurn
+		if err := rows.Scan(&t.Round, &t.PlayerIdx, &t.PlayerScoreAfter, &t.OpponentScoreAfter, &t.DeltaPlayer); err != nil {
+			return nil, err
+		}
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
+
+// CacheScoreSeries builds the match's score-by-round series from its turn rows and stores it in
+// game_history. Call at match end, after the turns are flushed; calling it again rewrites the same series.
+func (s *Store) CacheScoreSeries(ctx context.Context, matchID string) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	turns, err := s.summaryTurns(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	series, err := json.Marshal(scoreSeries(turns))
+	if err != nil {
+		return err
+	}
+	_, err = s.pool.Exec(ctx, `UPDATE game_history SET score_series = $2 WHERE id = $1`, matchID, series)
+	return err
+}
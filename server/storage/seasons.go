@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// seasonCheckInterval is how often RunSeasons checks whether a realm's season is over.
+const seasonCheckInterval = 10 * time.Minute
+
+// createSeasonsSQL stores rating seasons per realm (the open one has no ended_at) and each player's final
+// rating of every ended season.
+const createSeasonsSQL = `
+CREATE TABLE IF NOT EXISTS seasons (
+	realm      TEXT NOT NULL,
+	number     INT  NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	ended_at   TIMESTAMPTZ,
+	PRIMARY KEY (realm, number)
+);
+CREATE TABLE IF NOT EXISTS player_ratings_history (
+	realm        TEXT NOT NULL,
+	season       INT  NOT NULL,
+	user_id      TEXT NOT NULL,
+	display_name TEXT NOT NULL DEFAULT '',
+	elo          INT  NOT NULL,
+	wins         INT  NOT NULL DEFAULT 0,
+	losses       INT  NOT NULL DEFAULT 0,
+	draws        INT  NOT NULL DEFAULT 0,
+	PRIMARY KEY (realm, season, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_player_ratings_history_elo ON player_ratings_history(realm, season, elo DESC);
+`
+
+// seasonSQL is a SQL condition true when the game_history row aliased gh was played in season number
+// seasonExpr of its realm.
+func seasonSQL(seasonExpr string) string {
+	return `EXISTS (SELECT 1 FROM seasons se WHERE se.realm = gh.realm AND se.number = ` + seasonExpr + ` AND gh.played_at >= se.started_at AND (se.ended_at IS NULL OR gh.played_at < se.ended_at))`
+}
+
+// Season is one rating season of a realm.
+type Season struct {
+	Number    int    `json:"number"`
+	StartedAt string `json:"started_at"`         // ISO8601
+	EndedAt   string `json:"ended_at,omitempty"` // ISO8601; empty for the current season
+}
+
+// CurrentSeason returns the realm's open season, or a zero Season (Number 0) when seasons have never
+// started there.
+func (s *Store) CurrentSeason(ctx context.Context, realm string) (Season, error) {
+	if s == nil || s.pool == nil {
+		return Season{}, nil
+	}
+	var season Season
+	var startedAt time.Time
+	err := s.pool.QueryRow(ctx, `
+		SELECT number, started_at FROM seasons
+		WHERE realm = $1 AND ended_at IS NULL
+		ORDER BY number DESC LIMIT 1`,
+		realm).Scan(&season.Number, &startedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Season{}, nil
+		}
+		return Season{}, err
+	}
+	season.StartedAt = startedAt.UTC().Format(time.RFC3339)
+	return season, nil
+}
+
+// ListSeasons returns the realm's seasons, newest first.
+func (s *Store) ListSeasons(ctx context.Context, realm string) ([]Season, error) {
+	if s == nil || s.pool == nil {
+		return []Season{}, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT number, started_at, ended_at FROM seasons
+		WHERE realm = $1
+		ORDER BY number DESC`,
+		realm)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Season{}
+	for rows.Next() {
+		var season Season
+		var startedAt time.Time
+		var endedAt *time.Time
+		if err := rows.Scan(&season.Number, &startedAt, &endedAt); err != nil {
+			return nil, err
+		}
+		season.StartedAt = startedAt.UTC().Format(time.RFC3339)
+		if endedAt != nil {
+			season.EndedAt = endedAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, season)
+	}
+	return out, rows.Err()
+}
+
+// StartSeasons opens season 1 in the realm when it has no season yet. Returns the realm's open season.
+func (s *Store) StartSeasons(ctx context.Context, realm string) (Season, error) {
+	if s == nil || s.pool == nil {
+		return Season{}, nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO seasons (realm, number)
+		SELECT $1::text, 1 WHERE NOT EXISTS (SELECT 1 FROM seasons WHERE realm = $1)
+		ON CONFLICT (realm, number) DO NOTHING`,
+		realm)
+	if err != nil {
+		return Season{}, err
+	}
+	return s.CurrentSeason(ctx, realm)
+}
+
+// EndSeason closes season number in the realm and opens the next one. Every rating of the realm is copied
+// to player_ratings_history, then squashed toward InitialElo, keeping carryOverPct percent of its distance
+// from it, and its wins, losses and draws are cleared. Returns false when the season was not the open one
+// (e.g. another server instance ended it first).
+func (s *Store) EndSeason(ctx context.Context, realm string, number, carryOverPct int) (bool, error) {
+	if s == nil || s.pool == nil {
+		return false, nil
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE seasons SET ended_at = now() WHERE realm = $1 AND number = $2 AND ended_at IS NULL`, realm, number)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO player_ratings_history (realm, season, user_id, display_name, elo, wins, losses, draws)
+		SELECT realm, $2, user_id, display_name, elo, wins, losses, draws FROM player_ratings WHERE realm = $1
+		ON CONFLICT (realm, season, user_id) DO NOTHING`,
+		realm, number)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE player_ratings
+		SET elo = $2 + round((elo - $2) * $3 / 100.0)::int, wins = 0, losses = 0, draws = 0, updated_at = now()
+		WHERE realm = $1`,
+		realm, InitialElo, carryOverPct)
+	if err != nil {
+		return false, err
+	}
+	if _, err = tx.Exec(ctx, `INSERT INTO seasons (realm, number) VALUES ($1, $2)`, realm, number+1); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// GetSeasonEntryByUserID returns one player's final entry of an ended season in the realm, or (nil, nil)
+// if they did not play in it.
+func (s *Store) GetSeasonEntryByUserID(ctx context.Context, realm, userID string, season int) (*LeaderboardEntry, error) {
+	if s == nil || s.pool == nil || userID == "" {
+		return nil, nil
+	}
+	var e LeaderboardEntry
+	err := s.pool.QueryRow(ctx, `
+		SELECT user_id, display_name, elo, wins, losses, draws
+		FROM player_ratings_history
+		WHERE realm = $1 AND season = $2 AND user_id = $3`,
+		realm, season, userID).Scan(&e.UserID, &e.DisplayName, &e.Elo, &e.Wins, &e.Losses, &e.Draws)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	e.IsBot = strings.HasPrefix(e.UserID, aiUserIDPrefix)
+	return &e, nil
+}
+
+// RunSeasons opens season 1 in each realm, then ends a realm's season once it has lasted length (see
+// EndSeason), checking every seasonCheckInterval until ctx is cancelled. Should be run as a goroutine;
+// returns right away when length is not positive.
+func (s *Store) RunSeasons(ctx context.Context, realms []string, length time.Duration, carryOverPct int) {
+	if s == nil || s.pool == nil || length <= 0 {
+		return
+	}
+	ticker := time.NewTicker(seasonCheckInterval)
+	defer ticker.Stop()
+	for {
+		for _, realm := range realms {
+			s.rollSeason(ctx, realm, length, carryOverPct)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollSeason ends the realm's open season when it has lasted length.
+func (s *Store) rollSeason(ctx context.Context, realm string, length time.Duration, carryOverPct int) {
+	cur, err := s.StartSeasons(ctx, realm)
+	if err != nil {
+		slog.Error("season check failed", "tag", "storage", "realm", realm, "err", err)
+		return
+	}
+	startedAt, err := time.Parse(time.RFC3339, cur.StartedAt)
+	if err != nil || time.Since(startedAt) < length {
+		return
+	}
+	ended, err := s.EndSeason(ctx, realm, cur.Number, carryOverPct)
+	if err != nil {
+		slog.Error("season reset failed", "tag", "storage", "realm", realm, "season", cur.Number, "err", err)
+		return
+	}
+	if ended {
+		slog.Info("season ended", "tag", "storage", "realm", realm, "season", cur.Number, "carry_over_pct", carryOverPct)
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AnonymousDisplayName replaces the name of a private user wherever someone else sees them.
+const AnonymousDisplayName = "Anonymous"
+
+// UserSettings are a user's account preferences (user_settings row; defaults when there is none).
+type UserSettings struct {
+	// ProfilePrivate hides the user's name and ID from other players on the leaderboard and in match summaries.
+	ProfilePrivate bool `json:"profile_private"`
+}
+
+// privateUserSQL is a condition that is true when the user ID expression belongs to a private profile.
+func privateUserSQL(userIDExpr string) string {
+	return `EXISTS (SELECT 1 FROM user_settings us WHERE us.user_id = ` + userIDExpr + ` AND us.profile_private)`
+}
+
+// GetUserSettings returns the user's settings, or the defaults if they never saved any.
+func (s *Store) GetUserSettings(ctx context.Context, userID string) (UserSettings, error) {
+	var out UserSettings
+	if s == nil || s.pool == nil || userID == "" {
+		return out, nil
+	}
+	err := s.pool.QueryRow(ctx, `SELECT profile_private FROM user_settings WHERE user_id = $1`, userID).Scan(&out.ProfilePrivate)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return UserSettings{}, err
+	}
+	return out, nil
+}
+
+// SaveUserSettings stores the user's settings, replacing any previous ones.
+func (s *Store) SaveUserSettings(ctx context.Context, userID string, settings UserSettings) error {
+	if s == nil || s.pool == nil || userID == "" {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO user_settings (user_id, profile_private, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (user_id) DO UPDATE SET profile_private = EXCLUDED.profile_private, updated_at = now()`,
+		userID, settings.ProfilePrivate)
+	return err
+}
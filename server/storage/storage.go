@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -84,6 +85,82 @@ CREATE TABLE IF NOT EXISTS arcana_use (
 CREATE INDEX IF NOT EXISTS idx_arcana_use_match_id ON arcana_use(match_id);
 CREATE INDEX IF NOT EXISTS idx_arcana_use_power_up_id ON arcana_use(power_up_id);
 CREATE INDEX IF NOT EXISTS idx_arcana_use_match_round ON arcana_use(match_id, round);
+CREATE TABLE IF NOT EXISTS hand_overflow (
+	id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	match_id              UUID NOT NULL REFERENCES game_history(id),
+	round                 INT NOT NULL,
+	player_idx            SMALLINT NOT NULL,
+	power_up_id           TEXT NOT NULL,
+	rule                  TEXT NOT NULL,
+	discarded_power_up_id TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_hand_overflow_match_id ON hand_overflow(match_id);
+CREATE TABLE IF NOT EXISTS arcana_pity (
+	id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	match_id       UUID NOT NULL REFERENCES game_history(id),
+	round          INT NOT NULL,
+	player_idx     SMALLINT NOT NULL,
+	power_up_id    TEXT NOT NULL,
+	player_score   INT NOT NULL,
+	opponent_score INT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_arcana_pity_match_id ON arcana_pity(match_id);
+CREATE TABLE IF NOT EXISTS draft_pick (
+	id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	match_id    UUID NOT NULL REFERENCES game_history(id),
+	player_idx  SMALLINT NOT NULL,
+	power_up_id TEXT NOT NULL,
+	offered     TEXT[] NOT NULL,
+	auto_picked BOOLEAN NOT NULL DEFAULT false
+);
+CREATE INDEX IF NOT EXISTS idx_draft_pick_match_id ON draft_pick(match_id);
+CREATE TABLE IF NOT EXISTS match_latency (
+	match_id        UUID PRIMARY KEY REFERENCES game_history(id),
+	player0_region  TEXT NOT NULL DEFAULT '',
+	player1_region  TEXT NOT NULL DEFAULT '',
+	player0_rtt_ms  INT NOT NULL DEFAULT 0,
+	player1_rtt_ms  INT NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS rejoin_tokens (
+	match_id     UUID NOT NULL,
+	seat         SMALLINT NOT NULL,
+	user_id      TEXT NOT NULL DEFAULT '',
+	token        TEXT NOT NULL,
+	snapshot_ref TEXT,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (match_id, seat)
+);
+CREATE INDEX IF NOT EXISTS idx_rejoin_tokens_user_id ON rejoin_tokens(user_id);
+CREATE TABLE IF NOT EXISTS active_games (
+	match_id   UUID PRIMARY KEY,
+	realm      TEXT NOT NULL DEFAULT '',
+	state      JSONB NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS rating_updates (
+	match_id    UUID PRIMARY KEY,
+	elo0_before INT NOT NULL DEFAULT 0,
+	elo0_after  INT NOT NULL DEFAULT 0,
+	elo1_before INT NOT NULL DEFAULT 0,
+	elo1_after  INT NOT NULL DEFAULT 0,
+	created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS poll_response (
+	match_id    UUID NOT NULL,
+	poll_id     TEXT NOT NULL,
+	seat        SMALLINT NOT NULL,
+	member      SMALLINT NOT NULL DEFAULT 0,
+	user_id     TEXT NOT NULL DEFAULT '',
+	answer      TEXT NOT NULL,
+	answered_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (match_id, poll_id, seat, member)
+);
+CREATE INDEX IF NOT EXISTS idx_poll_response_poll_id ON poll_response(poll_id);
+CREATE TABLE IF NOT EXISTS user_settings (
+	user_id         TEXT PRIMARY KEY,
+	profile_private BOOLEAN NOT NULL DEFAULT false,
+	updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
 `
 
 // alterGameHistoryAddEloColumns adds elo columns to game_history for existing DBs (no-op if already present).
@@ -99,6 +176,68 @@ const alterGameHistoryDropGameID = `
 ALTER TABLE game_history DROP COLUMN IF EXISTS game_id;
 `
 
+// alterAddRealmColumns scopes game_history and player_ratings to a realm (isolated community) for existing DBs.
+// The default realm is the empty string, so existing rows keep their meaning.
+const alterAddRealmColumns = `
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS realm TEXT NOT NULL DEFAULT '';
+ALTER TABLE player_ratings ADD COLUMN IF NOT EXISTS realm TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_game_history_realm ON game_history(realm);
+CREATE INDEX IF NOT EXISTS idx_player_ratings_realm_elo ON player_ratings(realm, elo DESC);
+`
+
+// migrateRatingsRealmKey makes (realm, user_id) the primary key of player_ratings, so a user has one rating
+// per realm. Runs as a single statement; no-op once the key includes realm.
+const migrateRatingsRealmKey = `
+DO $$
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1 FROM information_schema.key_column_usage
+		WHERE table_schema = current_schema() AND table_name = 'player_ratings' AND constraint_name = 'player_ratings_pkey' AND column_name = 'realm'
+	) THEN
+		ALTER TABLE player_ratings DROP CONSTRAINT IF EXISTS player_ratings_pkey;
+		ALTER TABLE player_ratings ADD PRIMARY KEY (realm, user_id);
+	END IF;
+END $$;
+`
+
+// purgeStaleRejoinTokens drops rejoin tokens and game snapshots left behind by matches that no process
+// can resume anymore.
+const purgeStaleRejoinTokens = `
+DELETE FROM rejoin_tokens WHERE created_at < now() - interval '1 day';
+DELETE FROM active_games WHERE updated_at < now() - interval '1 day';
+`
+
+// alterGameHistoryAddAssisted flags games played with assisted accessibility mode (server hints); those are unrated.
+const alterGameHistoryAddAssisted = `
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS assisted BOOLEAN NOT NULL DEFAULT false;
+`
+
+// alterGameHistoryAddIsAdaptive flags games against a bot whose play adapted to the score gap, disclosed
+// in history for fairness.
+const alterGameHistoryAddIsAdaptive = `
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS is_adaptive BOOLEAN NOT NULL DEFAULT false;
+`
+
+// alterGameHistoryAddConfigSnapshot stores the effective rules of each match (board size, turn limit,
+// scoring, arcana pool, rules variants) as JSON, so telemetry can be segmented by rules version.
+const alterGameHistoryAddConfigSnapshot = `
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS config_snapshot JSONB;
+`
+
+// alterTurnAddLatency adds per-turn action latency: how many actions the player sent, the delay from
+// receiving each to processing it (average and max), and the player's round-trip time at turn end.
+const alterTurnAddLatency = `
+ALTER TABLE turn ADD COLUMN IF NOT EXISTS actions INT NOT NULL DEFAULT 0;
+ALTER TABLE turn ADD COLUMN IF NOT EXISTS avg_processing_ms INT NOT NULL DEFAULT 0;
+ALTER TABLE turn ADD COLUMN IF NOT EXISTS max_processing_ms INT NOT NULL DEFAULT 0;
+ALTER TABLE turn ADD COLUMN IF NOT EXISTS rtt_ms INT NOT NULL DEFAULT 0;
+`
+
+// alterGameHistoryAddMismatchRetries records the mismatch retries rules variant a game was played with (0 = classic).
+const alterGameHistoryAddMismatchRetries = `
+ALTER TABLE game_history ADD COLUMN IF NOT EXISTS mismatch_retries SMALLINT NOT NULL DEFAULT 0;
+`
+
 // Store persists and retrieves game history.
 type Store struct {
 	pool *pgxpool.Pool
@@ -142,6 +281,72 @@ func NewStore(ctx context.Context, databaseURL string) (*Store, error) {
 			return nil, err
 		}
 	}
+	for _, q := range strings.Split(strings.TrimSpace(alterAddRealmColumns), "\n") {
+		q = strings.TrimSpace(q)
+		if q == "" {
+			continue
+		}
+		if _, err := pool.Exec(ctx, q); err != nil {
+			pool.Close()
+			return nil, err
+		}
+	}
+	if _, err := pool.Exec(ctx, migrateRatingsRealmKey); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterGameHistoryAddAssisted); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterGameHistoryAddMismatchRetries); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterTurnAddLatency); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterGameHistoryAddConfigSnapshot); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterGameHistoryAddIsAdaptive); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterGameHistoryAddScoreSeries); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, alterTurnAddPairsMatched); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, createMatchEventsSQL); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, createMatchBlindPlaySQL); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, createSuspiciousActivitySQL); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, createFriendsSQL); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, createSeasonsSQL); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	if _, err := pool.Exec(ctx, purgeStaleRejoinTokens); err != nil {
+		pool.Close()
+		return nil, err
+	}
 	slog.Info("connected to Postgres", "tag", "storage")
 	return &Store{pool: pool}, nil
 }
@@ -179,9 +384,16 @@ func computeEloUpdates(r0, r1 int, winnerIdx int) (newR0, newR1 int) {
 	return newR0, newR1
 }
 
-// UpdateRatingsAfterGame updates ELO and W/L/D for both players after a completed game.
+// PreviewEloUpdates returns the ratings a result would produce without touching storage, so the
+// outcome can be shown before UpdateRatingsAfterGame has run.
+func PreviewEloUpdates(r0, r1 int, winnerIdx int) (newR0, newR1 int) {
+	return computeEloUpdates(r0, r1, winnerIdx)
+}
+
+// UpdateRatingsAfterGame updates ELO and W/L/D for both players in the realm after a completed game.
 // Returns each player's elo before and after the game so the caller can store them in game_history.
-func (s *Store) UpdateRatingsAfterGame(ctx context.Context, p0UserID, p1UserID, p0Name, p1Name string, winnerIdx int) (elo0Before, elo0After, elo1Before, elo1After int, err error) {
+// Idempotent per matchID: a repeated call changes nothing and returns the values of the first one.
+func (s *Store) UpdateRatingsAfterGame(ctx context.Context, realm, matchID, p0UserID, p1UserID, p0Name, p1Name string, winnerIdx int) (elo0Before, elo0After, elo1Before, elo1After int, err error) {
 	if s == nil || s.pool == nil {
 		return 0, 0, 0, 0, nil
 	}
@@ -191,16 +403,36 @@ func (s *Store) UpdateRatingsAfterGame(ctx context.Context, p0UserID, p1UserID,
 	}
 	defer tx.Rollback(ctx)
 
-	// Ensure both players have a row (default 1000 elo, 0 W/L/D)
-	_, _ = tx.Exec(ctx, `INSERT INTO player_ratings (user_id, display_name, elo, wins, losses, draws) VALUES ($1, '', 1000, 0, 0, 0) ON CONFLICT (user_id) DO NOTHING`, p0UserID)
-	_, _ = tx.Exec(ctx, `INSERT INTO player_ratings (user_id, display_name, elo, wins, losses, draws) VALUES ($1, '', 1000, 0, 0, 0) ON CONFLICT (user_id) DO NOTHING`, p1UserID)
+	// Claim the match first: a concurrent call for the same match blocks here until this one commits.
+	tag, err := tx.Exec(ctx, `INSERT INTO rating_updates (match_id) VALUES ($1) ON CONFLICT (match_id) DO NOTHING`, matchID)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		err = tx.QueryRow(ctx, `SELECT elo0_before, elo0_after, elo1_before, elo1_after FROM rating_updates WHERE match_id = $1`, matchID).
+			Scan(&elo0Before, &elo0After, &elo1Before, &elo1After)
+		return elo0Before, elo0After, elo1Before, elo1After, err
+	}
+
+	// Ensure both players have a row (default 1000 elo, 0 W/L/D), then lock both rows. Rows are
+	// created and locked in user_id order so concurrent games of the same players neither deadlock
+	// nor overwrite each other's rating.
+	first, second := p0UserID, p1UserID
+	if second < first {
+		first, second = second, first
+	}
+	_, _ = tx.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name, elo, wins, losses, draws) VALUES ($1, $2, '', 1000, 0, 0, 0) ON CONFLICT (realm, user_id) DO NOTHING`, realm, first)
+	_, _ = tx.Exec(ctx, `INSERT INTO player_ratings (realm, user_id, display_name, elo, wins, losses, draws) VALUES ($1, $2, '', 1000, 0, 0, 0) ON CONFLICT (realm, user_id) DO NOTHING`, realm, second)
+	if _, err = tx.Exec(ctx, `SELECT 1 FROM player_ratings WHERE realm = $1 AND user_id IN ($2, $3) ORDER BY user_id FOR UPDATE`, realm, first, second); err != nil {
+		return 0, 0, 0, 0, err
+	}
 
 	var r0, w0, l0, d0, r1, w1, l1, d1 int
-	err = tx.QueryRow(ctx, `SELECT elo, wins, losses, draws FROM player_ratings WHERE user_id = $1`, p0UserID).Scan(&r0, &w0, &l0, &d0)
+	err = tx.QueryRow(ctx, `SELECT elo, wins, losses, draws FROM player_ratings WHERE realm = $1 AND user_id = $2`, realm, p0UserID).Scan(&r0, &w0, &l0, &d0)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
-	err = tx.QueryRow(ctx, `SELECT elo, wins, losses, draws FROM player_ratings WHERE user_id = $1`, p1UserID).Scan(&r1, &w1, &l1, &d1)
+	err = tx.QueryRow(ctx, `SELECT elo, wins, losses, draws FROM player_ratings WHERE realm = $1 AND user_id = $2`, realm, p1UserID).Scan(&r1, &w1, &l1, &d1)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
@@ -220,13 +452,18 @@ func (s *Store) UpdateRatingsAfterGame(ctx context.Context, p0UserID, p1UserID,
 		d1++
 	}
 
-	_, err = tx.Exec(ctx, `UPDATE player_ratings SET display_name = $1, elo = $2, wins = $3, losses = $4, draws = $5, updated_at = now() WHERE user_id = $6`,
-		p0Name, newR0, w0, l0, d0, p0UserID)
+	_, err = tx.Exec(ctx, `UPDATE player_ratings SET display_name = $1, elo = $2, wins = $3, losses = $4, draws = $5, updated_at = now() WHERE realm = $6 AND user_id = $7`,
+		p0Name, newR0, w0, l0, d0, realm, p0UserID)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE player_ratings SET display_name = $1, elo = $2, wins = $3, losses = $4, draws = $5, updated_at = now() WHERE realm = $6 AND user_id = $7`,
+		p1Name, newR1, w1, l1, d1, realm, p1UserID)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
-	_, err = tx.Exec(ctx, `UPDATE player_ratings SET display_name = $1, elo = $2, wins = $3, losses = $4, draws = $5, updated_at = now() WHERE user_id = $6`,
-		p1Name, newR1, w1, l1, d1, p1UserID)
+	_, err = tx.Exec(ctx, `UPDATE rating_updates SET elo0_before = $1, elo0_after = $2, elo1_before = $3, elo1_after = $4 WHERE match_id = $5`,
+		elo0Before, elo0After, elo1Before, elo1After, matchID)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
@@ -236,32 +473,60 @@ func (s *Store) UpdateRatingsAfterGame(ctx context.Context, p0UserID, p1UserID,
 	return elo0Before, elo0After, elo1Before, elo1After, nil
 }
 
-// InsertGameResult records a finished game. matchID is the UUID of the match (used as game_history.id).
-// winnerIndex is 0 or 1 (winner), or -1 for draw (stored as NULL).
-// For end_reason "opponent_disconnected", winnerIndex is the player who stayed (winner); the abandoner is 1 - winnerIndex.
-// Pass elo before/after for both "completed" and "opponent_disconnected"; pass nil only when ratings are not updated.
-func (s *Store) InsertGameResult(ctx context.Context, matchID, player0UserID, player1UserID, player0Name, player1Name string, player0Score, player1Score int, winnerIndex int, endReason string, elo0Before, elo0After, elo1Before, elo1After *int) error {
+// GameResult is a finished game for InsertGameResult.
+type GameResult struct {
+	// MatchID is the UUID of the match (used as game_history.id).
+	MatchID                      string
+	Player0UserID, Player1UserID string
+	Player0Name, Player1Name     string
+	Player0Score, Player1Score   int
+	// WinnerIndex is 0 or 1 (winner), or -1 for draw (stored as NULL). For end_reason "opponent_disconnected",
+	// it is the player who stayed; the abandoner is 1 - WinnerIndex.
+	WinnerIndex int
+	EndReason   string
+	// Elo before/after is set for both "completed" and "opponent_disconnected"; nil only when ratings are not updated.
+	Elo0Before, Elo0After, Elo1Before, Elo1After *int
+	// Assisted marks a game where either seat played in assisted accessibility mode; Adaptive a game against
+	// a bot in adaptive difficulty mode.
+	Assisted, Adaptive bool
+	// MismatchRetries is the rules variant in effect (0 = a mismatch passes the turn).
+	MismatchRetries int
+	// ConfigSnapshot is the match's effective rules as JSON (stored as config_snapshot; nil stores NULL).
+	ConfigSnapshot []byte
+}
+
+// InsertGameResult records a finished game in the realm. A second insert for the same MatchID is ignored.
+func (s *Store) InsertGameResult(ctx context.Context, realm string, r GameResult) error {
 	if s == nil || s.pool == nil {
 		return nil
 	}
 	var winner *int
-	if winnerIndex >= 0 && winnerIndex <= 1 {
-		winner = &winnerIndex
+	if r.WinnerIndex >= 0 && r.WinnerIndex <= 1 {
+		winner = &r.WinnerIndex
+	}
+	var snapshot *string
+	if len(r.ConfigSnapshot) > 0 {
+		v := string(r.ConfigSnapshot)
+		snapshot = &v
 	}
 	_, err := s.pool.Exec(ctx, `
-		INSERT INTO game_history (id, player0_user_id, player1_user_id, player0_name, player1_name, player0_score, player1_score, winner_index, end_reason, player0_elo_before, player0_elo_after, player1_elo_before, player1_elo_after)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
-		matchID, player0UserID, player1UserID, player0Name, player1Name, player0Score, player1Score, winner, endReason, elo0Before, elo0After, elo1Before, elo1After)
+		INSERT INTO game_history (id, player0_user_id, player1_user_id, player0_name, player1_name, player0_score, player1_score, winner_index, end_reason, player0_elo_before, player0_elo_after, player1_elo_before, player1_elo_after, realm, assisted, mismatch_retries, config_snapshot, is_adaptive)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17::jsonb, $18)
+		ON CONFLICT (id) DO NOTHING`,
+		r.MatchID, r.Player0UserID, r.Player1UserID, r.Player0Name, r.Player1Name, r.Player0Score, r.Player1Score, winner, r.EndReason, r.Elo0Before, r.Elo0After, r.Elo1Before, r.Elo1After, realm, r.Assisted, r.MismatchRetries, snapshot, r.Adaptive)
 	return err
 }
 
 // InsertMatchArcana inserts one row per arcana in the match (typically 6). Call after InsertGameResult for the same matchID.
+// Arcana already recorded for the match are skipped, so a repeated call adds nothing.
 func (s *Store) InsertMatchArcana(ctx context.Context, matchID string, powerUpIDs []string) error {
 	if s == nil || s.pool == nil {
 		return nil
 	}
 	for _, pid := range powerUpIDs {
-		_, err := s.pool.Exec(ctx, `INSERT INTO match_arcana (match_id, power_up_id) VALUES ($1, $2)`, matchID, pid)
+		_, err := s.pool.Exec(ctx, `
+			INSERT INTO match_arcana (match_id, power_up_id)
+			SELECT $1::uuid, $2::text WHERE NOT EXISTS (SELECT 1 FROM match_arcana WHERE match_id = $1::uuid AND power_up_id = $2::text)`, matchID, pid)
 		if err != nil {
 			return err
 		}
@@ -269,15 +534,16 @@ func (s *Store) InsertMatchArcana(ctx context.Context, matchID string, powerUpID
 	return nil
 }
 
-// InsertTurn inserts a turn record for telemetry. Deltas are the score change for the player who had the turn and the opponent.
-func (s *Store) InsertTurn(ctx context.Context, matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent int) error {
+// InsertTurn inserts a turn record for telemetry. Deltas are the score change for the player who had the turn and the opponent;
+// pairsMatched is how many pairs the player matched in the turn.
+func (s *Store) InsertTurn(ctx context.Context, matchID string, round, playerIdx int, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent, pairsMatched int, actions, avgProcessingMS, maxProcessingMS, rttMS int) error {
 	if s == nil || s.pool == nil {
 		return nil
 	}
 	_, err := s.pool.Exec(ctx, `
-		INSERT INTO turn (match_id, round, player_idx, player_score_after_turn, opponent_score_after_turn, point_delta_player, point_delta_opponent)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
-		matchID, round, playerIdx, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent)
+		INSERT INTO turn (match_id, round, player_idx, player_score_after_turn, opponent_score_after_turn, point_delta_player, point_delta_opponent, pairs_matched, actions, avg_processing_ms, max_processing_ms, rtt_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		matchID, round, playerIdx, playerScoreAfter, opponentScoreAfter, deltaPlayer, deltaOpponent, pairsMatched, actions, avgProcessingMS, maxProcessingMS, rttMS)
 	return err
 }
 
@@ -294,6 +560,188 @@ func (s *Store) InsertArcanaUse(ctx context.Context, matchID string, round, play
 	return err
 }
 
+// TurnRecord is one turn for InsertTurnsBatch; the fields are those of InsertTurn.
+type TurnRecord struct {
+	Round, PlayerIdx                                 int
+	PlayerScoreAfter, OpponentScoreAfter             int
+	DeltaPlayer, DeltaOpponent                       int
+	PairsMatched                                     int
+	Actions, AvgProcessingMS, MaxProcessingMS, RTTMS int
+}
+
+// InsertTurnsBatch inserts every turn of a match in one round trip. Must be called after the
+// game_history row exists.
+func (s *Store) InsertTurnsBatch(ctx context.Context, matchID string, turns []TurnRecord) error {
+	if s == nil || s.pool == nil || len(turns) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, t := range turns {
+		batch.Queue(`
+			INSERT INTO turn (match_id, round, player_idx, player_score_after_turn, opponent_score_after_turn, point_delta_player, point_delta_opponent, pairs_matched, actions, avg_processing_ms, max_processing_ms, rtt_ms)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			matchID, t.Round, t.PlayerIdx, t.PlayerScoreAfter, t.OpponentScoreAfter, t.DeltaPlayer, t.DeltaOpponent, t.PairsMatched, t.Actions, t.AvgProcessingMS, t.MaxProcessingMS, t.RTTMS)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// ArcanaUseRecord is one power-up use for InsertArcanaUsesBatch; the fields are those of InsertArcanaUse.
+type ArcanaUseRecord struct {
+	Round, PlayerIdx                                           int
+	PowerUpID                                                  string
+	TargetCardIndex                                            int
+	PlayerScoreBefore, OpponentScoreBefore, PairsMatchedBefore int
+	PointDeltaPlayer, PointDeltaOpponent                       int
+}
+
+// InsertArcanaUsesBatch records every power-up use of a match in one round trip. Must be called after
+// the game_history row exists.
+func (s *Store) InsertArcanaUsesBatch(ctx context.Context, matchID string, uses []ArcanaUseRecord) error {
+	if s == nil || s.pool == nil || len(uses) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, u := range uses {
+		batch.Queue(`
+			INSERT INTO arcana_use (match_id, round, player_idx, power_up_id, target_card_index, player_score_before, opponent_score_before, pairs_matched_before, point_delta_player, point_delta_opponent)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			matchID, u.Round, u.PlayerIdx, u.PowerUpID, u.TargetCardIndex, u.PlayerScoreBefore, u.OpponentScoreBefore, u.PairsMatchedBefore, u.PointDeltaPlayer, u.PointDeltaOpponent)
+	}
+	return s.pool.SendBatch(ctx, batch).Close()
+}
+
+// InsertHandOverflow records an arcana grant that met a full hand and the overflow rule applied.
+// discardedPowerUpID is empty unless the rule discarded an older copy.
+func (s *Store) InsertHandOverflow(ctx context.Context, matchID string, round, playerIdx int, powerUpID, rule, discardedPowerUpID string) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	var discarded *string
+	if discardedPowerUpID != "" {
+		discarded = &discardedPowerUpID
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO hand_overflow (match_id, round, player_idx, power_up_id, rule, discarded_power_up_id)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		matchID, round, playerIdx, powerUpID, rule, discarded)
+	return err
+}
+
+// InsertPityGrant records an arcana granted by the pity timer with the scores at that moment, so its
+// effect on comebacks can be compared with the match outcome in game_history.
+func (s *Store) InsertPityGrant(ctx context.Context, matchID string, round, playerIdx int, powerUpID string, playerScore, opponentScore int) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO arcana_pity (match_id, round, player_idx, power_up_id, player_score, opponent_score)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		matchID, round, playerIdx, powerUpID, playerScore, opponentScore)
+	return err
+}
+
+// InsertDraftPick records the starting arcana a player picked in the starting-hand draft, with the options
+// offered and whether it was picked for them at the deadline, so win rates can be compared by pick.
+func (s *Store) InsertDraftPick(ctx context.Context, matchID string, playerIdx int, powerUpID string, offered []string, autoPicked bool) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO draft_pick (match_id, player_idx, power_up_id, offered, auto_picked)
+		VALUES ($1, $2, $3, $4, $5)`,
+		matchID, playerIdx, powerUpID, offered, autoPicked)
+	return err
+}
+
+// InsertMatchLatency records each seat's region hint and last measured round-trip time for a finished
+// match, for latency analytics (e.g. same-region vs cross-region pairings). Regions are empty when the
+// client sent none; the AI seat has no region and an RTT of 0. A repeated call for the match is ignored.
+func (s *Store) InsertMatchLatency(ctx context.Context, matchID, player0Region, player1Region string, player0RTTMS, player1RTTMS int) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO match_latency (match_id, player0_region, player1_region, player0_rtt_ms, player1_rtt_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (match_id) DO NOTHING`,
+		matchID, player0Region, player1Region, player0RTTMS, player1RTTMS)
+	return err
+}
+
+// RejoinToken is the persisted rejoin credential of one seat in an active match, so a rejoin attempt
+// can be recognised after the process that held the match has restarted.
+type RejoinToken struct {
+	MatchID string
+	Seat    int
+	UserID  string
+	Token   string
+	// SnapshotRef is the active_games match ID of the saved game snapshot to resume the match from (see
+	// SaveActiveGame); empty while none is saved.
+	SnapshotRef string
+}
+
+// SaveRejoinTokens stores the rejoin tokens of a new match (one per human seat).
+func (s *Store) SaveRejoinTokens(ctx context.Context, tokens []RejoinToken) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	for _, t := range tokens {
+		var snapshotRef *string
+		if t.SnapshotRef != "" {
+			snapshotRef = &t.SnapshotRef
+		}
+		_, err := s.pool.Exec(ctx, `
+			INSERT INTO rejoin_tokens (match_id, seat, user_id, token, snapshot_ref) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (match_id, seat) DO UPDATE SET user_id = EXCLUDED.user_id, token = EXCLUDED.token, snapshot_ref = EXCLUDED.snapshot_ref`,
+			t.MatchID, t.Seat, t.UserID, t.Token, snapshotRef)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindRejoinToken returns the persisted token for matchID that equals token, or (nil, nil) if none matches.
+func (s *Store) FindRejoinToken(ctx context.Context, matchID, token string) (*RejoinToken, error) {
+	if s == nil || s.pool == nil {
+		return nil, nil
+	}
+	return s.scanRejoinToken(ctx, `
+		SELECT match_id::text, seat, user_id, token, COALESCE(snapshot_ref,'')
+		FROM rejoin_tokens WHERE match_id::text = $1 AND token = $2`, matchID, token)
+}
+
+// FindRejoinTokenByUser returns the user's most recent persisted token, or (nil, nil) if there is none.
+func (s *Store) FindRejoinTokenByUser(ctx context.Context, userID string) (*RejoinToken, error) {
+	if s == nil || s.pool == nil || userID == "" {
+		return nil, nil
+	}
+	return s.scanRejoinToken(ctx, `
+		SELECT match_id::text, seat, user_id, token, COALESCE(snapshot_ref,'')
+		FROM rejoin_tokens WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`, userID)
+}
+
+func (s *Store) scanRejoinToken(ctx context.Context, query string, args ...any) (*RejoinToken, error) {
+	var t RejoinToken
+	err := s.pool.QueryRow(ctx, query, args...).Scan(&t.MatchID, &t.Seat, &t.UserID, &t.Token, &t.SnapshotRef)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteRejoinTokens removes the tokens of a match once it can no longer be rejoined.
+func (s *Store) DeleteRejoinTokens(ctx context.Context, matchID string) error {
+	if s == nil || s.pool == nil {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `DELETE FROM rejoin_tokens WHERE match_id::text = $1`, matchID)
+	return err
+}
+
 // GameRecord is a single row returned for the history API.
 // GameID is set to ID (match UUID) for client compatibility.
 type GameRecord struct {
@@ -313,21 +761,25 @@ type GameRecord struct {
 	Player0EloAfter  *int    `json:"player0_elo_after,omitempty"`
 	Player1EloBefore *int    `json:"player1_elo_before,omitempty"`
 	Player1EloAfter  *int    `json:"player1_elo_after,omitempty"`
+	Assisted         bool    `json:"assisted"` // played in assisted accessibility mode; unrated
+	MismatchRetries  int     `json:"mismatch_retries"` // rules variant: extra tries after a mismatch before the turn passes
+
+	IsAdaptive bool `json:"is_adaptive"` // against a bot that eased up or tightened with the score gap
 }
 
-// ListByUserID returns all games where the user participated, ordered by played_at DESC.
+// ListByUserID returns all of the realm's games where the user participated, ordered by played_at DESC.
 // Each record has your_index set to 0 or 1 so the client can show "You" vs opponent.
-func (s *Store) ListByUserID(ctx context.Context, userID string) ([]GameRecord, error) {
+func (s *Store) ListByUserID(ctx context.Context, realm, userID string) ([]GameRecord, error) {
 	if s == nil || s.pool == nil {
 		return []GameRecord{}, nil
 	}
 	rows, err := s.pool.Query(ctx, `
 		SELECT id, played_at, player0_user_id, player1_user_id, player0_name, player1_name, player0_score, player1_score, winner_index, COALESCE(end_reason,''),
-			player0_elo_before, player0_elo_after, player1_elo_before, player1_elo_after
+			player0_elo_before, player0_elo_after, player1_elo_before, player1_elo_after, assisted, mismatch_retries, is_adaptive
 		FROM game_history
-		WHERE player0_user_id = $1 OR player1_user_id = $1
+		WHERE (player0_user_id = $1 OR player1_user_id = $1) AND realm = $2
 		ORDER BY played_at DESC`,
-		userID)
+		userID, realm)
 	if err != nil {
 		return nil, err
 	}
@@ -338,7 +790,7 @@ func (s *Store) ListByUserID(ctx context.Context, userID string) ([]GameRecord,
 		var winnerIndex *int
 		var playedAt time.Time
 		var elo0Before, elo0After, elo1Before, elo1After *int
-		if err := rows.Scan(&r.ID, &playedAt, &r.Player0UserID, &r.Player1UserID, &r.Player0Name, &r.Player1Name, &r.Player0Score, &r.Player1Score, &winnerIndex, &r.EndReason, &elo0Before, &elo0After, &elo1Before, &elo1After); err != nil {
+		if err := rows.Scan(&r.ID, &playedAt, &r.Player0UserID, &r.Player1UserID, &r.Player0Name, &r.Player1Name, &r.Player0Score, &r.Player1Score, &winnerIndex, &r.EndReason, &elo0Before, &elo0After, &elo1Before, &elo1After, &r.Assisted, &r.MismatchRetries, &r.IsAdaptive); err != nil {
 			return nil, err
 		}
 		r.GameID = r.ID // backward compatibility for clients expecting game_id
@@ -360,12 +812,21 @@ func (s *Store) ListByUserID(ctx context.Context, userID string) ([]GameRecord,
 
 const maxHistoryLimit = 100
 
-// ListByUserIDPaginated returns a page of games where the user participated, ordered by played_at DESC.
+// HistoryQuery selects a page of a player's games for ListByUserIDPaginated.
+type HistoryQuery struct {
+	// Season 0 includes every season; another number only the games played during that season of the realm.
+	Season int
+	Limit  int
+	Offset int
+}
+
+// ListByUserIDPaginated returns a page of the realm's games where the user participated, ordered by played_at DESC.
 // hasMore is true if there are more results after this page.
-func (s *Store) ListByUserIDPaginated(ctx context.Context, userID string, limit, offset int) ([]GameRecord, bool, error) {
+func (s *Store) ListByUserIDPaginated(ctx context.Context, realm, userID string, q HistoryQuery) ([]GameRecord, bool, error) {
 	if s == nil || s.pool == nil {
 		return []GameRecord{}, false, nil
 	}
+	limit, offset := q.Limit, q.Offset
 	if limit <= 0 {
 		limit = 10
 	}
@@ -377,12 +838,12 @@ func (s *Store) ListByUserIDPaginated(ctx context.Context, userID string, limit,
 	}
 	rows, err := s.pool.Query(ctx, `
 		SELECT id, played_at, player0_user_id, player1_user_id, player0_name, player1_name, player0_score, player1_score, winner_index, COALESCE(end_reason,''),
-			player0_elo_before, player0_elo_after, player1_elo_before, player1_elo_after
-		FROM game_history
-		WHERE player0_user_id = $1 OR player1_user_id = $1
+			player0_elo_before, player0_elo_after, player1_elo_before, player1_elo_after, assisted, mismatch_retries, is_adaptive
+		FROM game_history gh
+		WHERE (player0_user_id = $1 OR player1_user_id = $1) AND realm = $4 AND ($5 = 0 OR `+seasonSQL("$5")+`)
 		ORDER BY played_at DESC
 		LIMIT $2 OFFSET $3`,
-		userID, limit+1, offset)
+		userID, limit+1, offset, realm, q.Season)
 	if err != nil {
 		return nil, false, err
 	}
@@ -393,7 +854,7 @@ func (s *Store) ListByUserIDPaginated(ctx context.Context, userID string, limit,
 		var winnerIndex *int
 		var playedAt time.Time
 		var elo0Before, elo0After, elo1Before, elo1After *int
-		if err := rows.Scan(&r.ID, &playedAt, &r.Player0UserID, &r.Player1UserID, &r.Player0Name, &r.Player1Name, &r.Player0Score, &r.Player1Score, &winnerIndex, &r.EndReason, &elo0Before, &elo0After, &elo1Before, &elo1After); err != nil {
+		if err := rows.Scan(&r.ID, &playedAt, &r.Player0UserID, &r.Player1UserID, &r.Player0Name, &r.Player1Name, &r.Player0Score, &r.Player1Score, &winnerIndex, &r.EndReason, &elo0Before, &elo0After, &elo1Before, &elo1After, &r.Assisted, &r.MismatchRetries, &r.IsAdaptive); err != nil {
 			return nil, false, err
 		}
 		r.GameID = r.ID
@@ -420,6 +881,170 @@ func (s *Store) ListByUserIDPaginated(ctx context.Context, userID string, limit,
 	return out, hasMore, nil
 }
 
+// MatchSummary is a compact, shareable recap of one match (no user IDs), built from game_history,
+// turn and arcana_use.
+type MatchSummary struct {
+	MatchID     string           `json:"match_id"`
+	PlayedAt    string           `json:"played_at"` // ISO8601
+	Players     [2]SummaryPlayer `json:"players"`
+	WinnerIndex *int             `json:"winner_index"` // 0 or 1, or null for draw
+	EndReason   string           `json:"end_reason"`
+	Turns       int              `json:"turns"`
+	KeyMoments  []KeyMoment      `json:"key_moments"`
+
+	// ScoreSeries is both players' score after each turn, for a momentum graph.
+	ScoreSeries []ScorePoint `json:"score_series"`
+}
+
+// SummaryPlayer is one side of a MatchSummary.
+type SummaryPlayer struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	IsBot bool   `json:"is_bot"`
+}
+
+// Key moment kinds.
+const (
+	KeyMomentBiggestCombo   = "biggest_combo"   // turn with the most points scored
+	KeyMomentDecisiveArcana = "decisive_arcana" // winner's arcana use with the largest net swing
+	KeyMomentComeback       = "comeback"        // largest deficit the winner recovered from
+)
+
+// KeyMoment is a highlight extracted from the match's turn and arcana_use rows.
+type KeyMoment struct {
+	Kind       string `json:"kind"`
+	Round      int    `json:"round"`
+	PlayerIdx  int    `json:"player_idx"`
+	PowerUpID  string `json:"power_up_id,omitempty"`
+	PointSwing int    `json:"point_swing"` // points scored (combo), net swing (arcana) or deficit recovered (comeback)
+}
+
+// summaryTurn and summaryArcanaUse are the turn / arcana_use columns needed for key moments.
+type summaryTurn struct {
+	Round, PlayerIdx, PlayerScoreAfter, OpponentScoreAfter, DeltaPlayer int
+}
+
+type summaryArcanaUse struct {
+	Round, PlayerIdx           int
+	PowerUpID                  string
+	DeltaPlayer, DeltaOpponent int
+}
+
+// GetMatchSummary returns the summary of a match in the realm, or (nil, nil) if the match is not in the
+// realm's game_history.
+func (s *Store) GetMatchSummary(ctx context.Context, realm, matchID string) (*MatchSummary, error) {
+	if s == nil || s.pool == nil || matchID == "" {
+		return nil, nil
+	}
+	sum := &MatchSummary{MatchID: matchID}
+	var playedAt time.Time
+	var p0UserID, p1UserID string
+	var cachedSeries []byte
+	// Private players are shown as AnonymousDisplayName: the summary is public.
+	err := s.pool.QueryRow(ctx, `
+		SELECT played_at, player0_user_id, player1_user_id,
+			CASE WHEN `+privateUserSQL("player0_user_id")+` THEN $2 ELSE player0_name END,
+			CASE WHEN `+privateUserSQL("player1_user_id")+` THEN $2 ELSE player1_name END,
+			player0_score, player1_score, winner_index, COALESCE(end_reason,''), score_series
+		FROM game_history
+		WHERE id = $1 AND realm = $3`,
+		matchID, AnonymousDisplayName, realm).Scan(&playedAt, &p0UserID, &p1UserID, &sum.Players[0].Name, &sum.Players[1].Name, &sum.Players[0].Score, &sum.Players[1].Score, &sum.WinnerIndex, &sum.EndReason, &cachedSeries)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sum.PlayedAt = playedAt.UTC().Format(time.RFC3339)
+	sum.Players[0].IsBot = strings.HasPrefix(p0UserID, aiUserIDPrefix)
+	sum.Players[1].IsBot = strings.HasPrefix(p1UserID, aiUserIDPrefix)
+
+	turns, err := s.summaryTurns(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	useRows, err := s.pool.Query(ctx, `
+		SELECT round, player_idx, power_up_id, COALESCE(point_delta_player, 0), COALESCE(point_delta_opponent, 0)
+		FROM arcana_use
+		WHERE match_id = $1
+		ORDER BY played_at`,
+		matchID)
+	if err != nil {
+		return nil, err
+	}
+	var uses []summaryArcanaUse
+	for useRows.Next() {
+		var u summaryArcanaUse
+		if err := useRows.Scan(&u.Round, &u.PlayerIdx, &u.PowerUpID, &u.DeltaPlayer, &u.DeltaOpponent); err != nil {
+			useRows.Close()
+			return nil, err
+		}
+		uses = append(uses, u)
+	}
+	useRows.Close()
+	if err := useRows.Err(); err != nil {
+		return nil, err
+	}
+
+	sum.Turns = len(turns)
+	sum.KeyMoments = extractKeyMoments(turns, uses, sum.WinnerIndex)
+	// Matches played before the series was cached at match end have none stored: build it from the turns.
+	if cachedSeries == nil || json.Unmarshal(cachedSeries, &sum.ScoreSeries) != nil {
+		sum.ScoreSeries = scoreSeries(turns)
+	}
+	return sum, nil
+}
+
+// extractKeyMoments picks the match highlights: the turn that scored the most (2+ points), the winner's
+// arcana use with the largest positive net swing, and the largest deficit the winner came back from.
+// Ties go to the earliest moment. Draws have no decisive arcana or comeback.
+func extractKeyMoments(turns []summaryTurn, uses []summaryArcanaUse, winnerIdx *int) []KeyMoment {
+	out := []KeyMoment{}
+	var combo *summaryTurn
+	for i := range turns {
+		if turns[i].DeltaPlayer >= 2 && (combo == nil || turns[i].DeltaPlayer > combo.DeltaPlayer) {
+			combo = &turns[i]
+		}
+	}
+	if combo != nil {
+		out = append(out, KeyMoment{Kind: KeyMomentBiggestCombo, Round: combo.Round, PlayerIdx: combo.PlayerIdx, PointSwing: combo.DeltaPlayer})
+	}
+	if winnerIdx == nil {
+		return out
+	}
+	winner := *winnerIdx
+
+	var decisive *summaryArcanaUse
+	for i := range uses {
+		u := &uses[i]
+		if u.PlayerIdx != winner || u.DeltaPlayer-u.DeltaOpponent <= 0 {
+			continue
+		}
+		if decisive == nil || u.DeltaPlayer-u.DeltaOpponent > decisive.DeltaPlayer-decisive.DeltaOpponent {
+			decisive = u
+		}
+	}
+	if decisive != nil {
+		out = append(out, KeyMoment{Kind: KeyMomentDecisiveArcana, Round: decisive.Round, PlayerIdx: winner, PowerUpID: decisive.PowerUpID, PointSwing: decisive.DeltaPlayer - decisive.DeltaOpponent})
+	}
+
+	deficit, deficitRound := 0, 0
+	for _, t := range turns {
+		winnerScore, loserScore := t.PlayerScoreAfter, t.OpponentScoreAfter
+		if t.PlayerIdx != winner {
+			winnerScore, loserScore = loserScore, winnerScore
+		}
+		if d := loserScore - winnerScore; d > deficit {
+			deficit, deficitRound = d, t.Round
+		}
+	}
+	if deficit > 0 {
+		out = append(out, KeyMoment{Kind: KeyMomentComeback, Round: deficitRound, PlayerIdx: winner, PointSwing: deficit})
+	}
+	return out
+}
+
 // LeaderboardEntry is a single row for the leaderboard API.
 type LeaderboardEntry struct {
 	UserID        string `json:"user_id"`
@@ -430,13 +1055,47 @@ type LeaderboardEntry struct {
 	Draws         int    `json:"draws"`
 	IsBot         bool   `json:"is_bot"`
 	IsCurrentUser bool   `json:"is_current_user,omitempty"`
+	// BotDifficulty is the bot's profile difficulty (filled by the API from config; empty for humans).
+	BotDifficulty string `json:"bot_difficulty,omitempty"`
+}
+
+// Leaderboard brackets for ListLeaderboard: who is ranked.
+const (
+	LeaderboardBracketAll = ""
+	// LeaderboardBracketHumans leaves bots (user IDs with the ai: prefix) out.
+	LeaderboardBracketHumans = "humans"
+	// LeaderboardBracketBots ranks only bots.
+	LeaderboardBracketBots = "bots"
+)
+
+// LeaderboardQuery selects a page of ListLeaderboard.
+type LeaderboardQuery struct {
+	// ViewerUserID is who is asking (empty for anonymous requests).
+	ViewerUserID string
+	// Scope is one of the LeaderboardScope* values and Bracket one of the LeaderboardBracket* values.
+	Scope   string
+	Bracket string
+	// Season 0 selects the current ratings; another number the final ratings of that ended season.
+	Season int
+	Limit  int
+	Offset int
 }
 
-// ListLeaderboard returns entries ordered by elo DESC, with optional limit and offset.
-func (s *Store) ListLeaderboard(ctx context.Context, limit, offset int) ([]LeaderboardEntry, error) {
+// ListLeaderboard returns the realm's entries ordered by elo DESC, with optional limit and offset.
+// Private users are listed as AnonymousDisplayName with no user_id, except to themselves (q.ViewerUserID)
+// and to their accepted friends. Scope LeaderboardScopeFriends keeps only the viewer and their accepted
+// friends (nothing for an anonymous viewer). Season 0 lists the current ratings; another number lists the
+// final ratings of that ended season (see EndSeason). Bracket LeaderboardBracketHumans or
+// LeaderboardBracketBots ranks humans or bots only.
+func (s *Store) ListLeaderboard(ctx context.Context, realm string, q LeaderboardQuery) ([]LeaderboardEntry, error) {
 	if s == nil || s.pool == nil {
 		return []LeaderboardEntry{}, nil
 	}
+	viewerUserID, bracket, season, limit, offset := q.ViewerUserID, q.Bracket, q.Season, q.Limit, q.Offset
+	friendsOnly := q.Scope == LeaderboardScopeFriends
+	if friendsOnly && viewerUserID == "" {
+		return []LeaderboardEntry{}, nil
+	}
 	if limit <= 0 {
 		limit = 50
 	}
@@ -446,12 +1105,29 @@ func (s *Store) ListLeaderboard(ctx context.Context, limit, offset int) ([]Leade
 	if offset < 0 {
 		offset = 0
 	}
+	source := `player_ratings`
+	if bracket != LeaderboardBracketHumans && bracket != LeaderboardBracketBots {
+		bracket = LeaderboardBracketAll
+	}
+	args := []any{limit, offset, realm, viewerUserID, AnonymousDisplayName, friendsOnly, bracket}
+	if season > 0 {
+		source = `(SELECT realm, user_id, display_name, elo, wins, losses, draws FROM player_ratings_history WHERE season = $8)`
+		args = append(args, season)
+	}
 	rows, err := s.pool.Query(ctx, `
-		SELECT user_id, display_name, elo, wins, losses, draws
-		FROM player_ratings
+		SELECT
+			CASE WHEN hidden THEN '' ELSE user_id END,
+			CASE WHEN hidden THEN $5 ELSE display_name END,
+			elo, wins, losses, draws
+		FROM (
+			SELECT pr.*, (pr.user_id <> $4 AND `+privateUserSQL("pr.user_id")+` AND NOT `+friendsSQL("pr.user_id", "$4")+`) AS hidden
+			FROM `+source+` pr
+			WHERE pr.realm = $3 AND (NOT $6 OR pr.user_id = $4 OR `+friendsSQL("pr.user_id", "$4")+`)
+				AND ($7 = '' OR (pr.user_id LIKE '`+aiUserIDPrefix+`%') = ($7 = '`+LeaderboardBracketBots+`'))
+		) lb
 		ORDER BY elo DESC
 		LIMIT $1 OFFSET $2`,
-		limit, offset)
+		args...)
 	if err != nil {
 		return nil, err
 	}
@@ -468,8 +1144,8 @@ func (s *Store) ListLeaderboard(ctx context.Context, limit, offset int) ([]Leade
 	return out, rows.Err()
 }
 
-// GetLeaderboardEntryByUserID returns one player's leaderboard entry by user_id, or (nil, nil) if not found.
-func (s *Store) GetLeaderboardEntryByUserID(ctx context.Context, userID string) (*LeaderboardEntry, error) {
+// GetLeaderboardEntryByUserID returns one player's leaderboard entry in the realm by user_id, or (nil, nil) if not found.
+func (s *Store) GetLeaderboardEntryByUserID(ctx context.Context, realm, userID string) (*LeaderboardEntry, error) {
 	if s == nil || s.pool == nil || userID == "" {
 		return nil, nil
 	}
@@ -477,8 +1153,8 @@ func (s *Store) GetLeaderboardEntryByUserID(ctx context.Context, userID string)
 	err := s.pool.QueryRow(ctx, `
 		SELECT user_id, display_name, elo, wins, losses, draws
 		FROM player_ratings
-		WHERE user_id = $1`,
-		userID).Scan(&e.UserID, &e.DisplayName, &e.Elo, &e.Wins, &e.Losses, &e.Draws)
+		WHERE realm = $1 AND user_id = $2`,
+		realm, userID).Scan(&e.UserID, &e.DisplayName, &e.Elo, &e.Wins, &e.Losses, &e.Draws)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -507,12 +1183,64 @@ func (s *Store) GetUserRole(ctx context.Context, userID string) (string, error)
 	return role, nil
 }
 
+// UserArcanaStats is one arcana's usage summary for a single user (the per-card telemetry view scoped to them).
+type UserArcanaStats struct {
+	PowerUpID             string  `json:"power_up_id"`
+	UseCount              int     `json:"use_count"`
+	MatchesUsed           int     `json:"matches_used"`
+	WinsWhenUsed          int     `json:"wins_when_used"`
+	WinRatePct            float64 `json:"win_rate_pct"`
+	AvgPointSwingPlayer   float64 `json:"avg_point_swing_player"`
+	AvgPointSwingOpponent float64 `json:"avg_point_swing_opponent"`
+}
+
+// GetUserArcanaStats aggregates the user's arcana_use rows in the realm's games per power_up_id: how often
+// they used each card, the share of matches in which they used it that they went on to win, and the average
+// point swing per use.
+func (s *Store) GetUserArcanaStats(ctx context.Context, realm, userID string) ([]UserArcanaStats, error) {
+	if s == nil || s.pool == nil || userID == "" {
+		return []UserArcanaStats{}, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT au.power_up_id,
+			COUNT(*) AS use_count,
+			COUNT(DISTINCT au.match_id) AS matches_used,
+			COUNT(DISTINCT au.match_id) FILTER (WHERE gh.winner_index = au.player_idx) AS wins_when_used,
+			AVG(COALESCE(au.point_delta_player, 0))::float AS avg_delta_player,
+			AVG(COALESCE(au.point_delta_opponent, 0))::float AS avg_delta_opponent
+		FROM arcana_use au
+		JOIN game_history gh ON gh.id = au.match_id
+		WHERE ((au.player_idx = 0 AND gh.player0_user_id = $1) OR (au.player_idx = 1 AND gh.player1_user_id = $1)) AND gh.realm = $2
+		GROUP BY au.power_up_id
+		ORDER BY use_count DESC, au.power_up_id`,
+		userID, realm)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []UserArcanaStats{}
+	for rows.Next() {
+		var e UserArcanaStats
+		if err := rows.Scan(&e.PowerUpID, &e.UseCount, &e.MatchesUsed, &e.WinsWhenUsed, &e.AvgPointSwingPlayer, &e.AvgPointSwingOpponent); err != nil {
+			return nil, err
+		}
+		if e.MatchesUsed > 0 {
+			e.WinRatePct = 100.0 * float64(e.WinsWhenUsed) / float64(e.MatchesUsed)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
 // TelemetryMetrics holds aggregated metrics for the admin telemetry dashboard.
 type TelemetryMetrics struct {
 	Players TelemetryPlayers  `json:"players"`
 	Global  TelemetryGlobal   `json:"global"`
 	ByCard  []TelemetryByCard `json:"by_card"`
 	ByCombo []TelemetryByCombo `json:"by_combo"`
+
+	// Segments holds the same metrics per board size when grouped by board size (TelemetryBinConfig.GroupBy).
+	Segments []TelemetrySegment `json:"segments,omitempty"`
 }
 
 // TelemetryPlayers holds player-count and activity metrics.
@@ -520,11 +1248,23 @@ type TelemetryPlayers struct {
 	RegisteredCount int `json:"registered_count"`
 	ActiveInPeriod  int `json:"active_in_period"`
 	TotalMatches    int `json:"total_matches"`
+
+	// Engagement of human players. NewPlayers played their first game in the period; Day1RetentionPct and
+	// Day7RetentionPct are the shares of them who played again at least 1 or 7 days after it, among those
+	// whose first game is old enough (nil when none is). ChurnedPlayers have not played for ChurnDays.
+	NewPlayers           int      `json:"new_players"`
+	Day1RetentionPct     *float64 `json:"day1_retention_pct,omitempty"`
+	Day7RetentionPct     *float64 `json:"day7_retention_pct,omitempty"`
+	MedianGamesPerPlayer float64  `json:"median_games_per_player"`
+	ChurnDays            int      `json:"churn_days"`
+	ChurnedPlayers       int      `json:"churned_players"`
 }
 
 type TelemetryGlobal struct {
 	TotalMatches            int      `json:"total_matches"`
 	TotalTurns              int      `json:"total_turns"`
+	// AvgTurnsPerMatch is TotalTurns / TotalMatches. A turn runs until it passes or the game ends, so a
+	// streak of matches is one turn; matches recorded before the final turn was counted have one turn fewer.
 	AvgTurnsPerMatch        float64  `json:"avg_turns_per_match"`
 	AvgNetPointSwingPerTurn float64  `json:"avg_net_point_swing_per_turn"`
 	AvgNetPointSwingPerCard *float64 `json:"avg_net_point_swing_per_card,omitempty"`
@@ -542,6 +1282,9 @@ type TelemetryBinConfig struct {
 	PairsNumBins int
 	MatchType    string // "all", "pvp", "vs_ai"
 	TimeRange    string // "24h", "7d", "30d"
+	ChurnDays    int    // players without a game for this many days count as churned (default 14)
+	BoardSize    string // "<rows>x<cols>" keeps only games on that board; empty = every size
+	GroupBy      string // TelemetryGroupByBoardSize adds TelemetryMetrics.Segments; empty = no grouping
 }
 
 // TelemetryHistogramBucket is one bin in a histogram (label + count).
@@ -644,28 +1387,30 @@ func gameHistoryMatchTypeCondition(matchType string) string {
 	}
 }
 
-// filteredMatchIDsSubquery returns a subquery "SELECT id FROM game_history WHERE <match_type and time range>"
+// telemetryRealmCondition keeps the games of one realm (table alias "gh"). The telemetry queries bind the
+// realm as their first argument.
+const telemetryRealmCondition = "gh.realm = $1"
+
+// filteredMatchIDsSubquery returns a subquery "SELECT id FROM game_history WHERE <realm, match_type, board size and time range>"
 // for use in WHERE match_id IN (...). timeRange is applied via played_at >= now() - interval.
-func filteredMatchIDsSubquery(matchType, timeRange string) string {
-	cond := gameHistoryMatchTypeCondition(matchType)
+func filteredMatchIDsSubquery(matchType, timeRange, boardSize string) string {
+	cond := telemetryRealmCondition + " AND " + gameHistoryMatchTypeCondition(matchType) + " AND " + boardSizeCondition(boardSize)
 	condNoAlias := strings.ReplaceAll(cond, "gh.", "")
 	interval := telemetryTimeIntervalSQL(timeRange)
 	return "SELECT id FROM game_history WHERE " + condNoAlias + " AND played_at >= now() - interval '" + interval + "'"
 }
 
 // getGameHistoryCondForDirectQuery returns the condition for a query that already has game_history (aliased as gh).
-// Includes match type and time range (played_at >= now() - interval).
-func getGameHistoryCondForDirectQuery(matchType, timeRange string) string {
-	cond := gameHistoryMatchTypeCondition(matchType)
+// Includes realm, match type, board size and time range (played_at >= now() - interval).
+func getGameHistoryCondForDirectQuery(matchType, timeRange, boardSize string) string {
+	cond := telemetryRealmCondition + " AND " + gameHistoryMatchTypeCondition(matchType) + " AND " + boardSizeCondition(boardSize)
 	interval := telemetryTimeIntervalSQL(timeRange)
 	return cond + " AND gh.played_at >= now() - interval '" + interval + "'"
 }
 
-// GetTelemetryMetrics returns aggregated metrics from game_history, match_arcana, turn, arcana_use.
-func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBinConfig) (*TelemetryMetrics, error) {
-	if s == nil || s.pool == nil {
-		return &TelemetryMetrics{}, nil
-	}
+// normalizeTelemetryBinConfig returns binConfig (or the defaults when nil) with unset bins and an unknown
+// time range replaced by the defaults.
+func normalizeTelemetryBinConfig(binConfig *TelemetryBinConfig) TelemetryBinConfig {
 	cfg := defaultTelemetryBinConfig
 	if binConfig != nil {
 		cfg = *binConfig
@@ -682,18 +1427,29 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 	if cfg.PairsMax <= 0 {
 		cfg.PairsMax = 36
 	}
-	matchType := cfg.MatchType
-	timeRange := cfg.TimeRange
-	if timeRange != "24h" && timeRange != "7d" && timeRange != "30d" {
-		timeRange = "7d"
+	if cfg.TimeRange != "24h" && cfg.TimeRange != "7d" && cfg.TimeRange != "30d" {
+		cfg.TimeRange = "7d"
+	}
+	if cfg.ChurnDays <= 0 {
+		cfg.ChurnDays = defaultChurnDays
+	}
+	return cfg
+}
+
+// GetTelemetryMetrics returns aggregated metrics of the realm's games from game_history, match_arcana, turn, arcana_use.
+func (s *Store) GetTelemetryMetrics(ctx context.Context, realm string, binConfig *TelemetryBinConfig) (*TelemetryMetrics, error) {
+	if s == nil || s.pool == nil {
+		return &TelemetryMetrics{}, nil
 	}
-	ghCond := getGameHistoryCondForDirectQuery(matchType, timeRange)
-	matchIDsSubq := filteredMatchIDsSubquery(matchType, timeRange)
+	cfg := normalizeTelemetryBinConfig(binConfig)
+	matchType, timeRange := cfg.MatchType, cfg.TimeRange
+	ghCond := getGameHistoryCondForDirectQuery(matchType, timeRange, cfg.BoardSize)
+	matchIDsSubq := filteredMatchIDsSubquery(matchType, timeRange, cfg.BoardSize)
 
 	out := &TelemetryMetrics{}
 
-	// Players: registered count (players with a rating row)
-	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM player_ratings`).Scan(&out.Players.RegisteredCount); err != nil {
+	// Players: registered count (players with a rating row in the realm)
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(DISTINCT user_id) FROM player_ratings WHERE realm = $1`, realm).Scan(&out.Players.RegisteredCount); err != nil {
 		return nil, err
 	}
 	// Players: active in selected period (distinct users who played a match of the selected type)
@@ -703,17 +1459,21 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 			UNION
 			SELECT player1_user_id FROM game_history gh WHERE %s
 		) t
-	`, ghCond, ghCond)).Scan(&out.Players.ActiveInPeriod); err != nil {
+	`, ghCond, ghCond), realm).Scan(&out.Players.ActiveInPeriod); err != nil {
+		return nil, err
+	}
+	// Players: retention, median games and churn
+	if err := s.fillRetention(ctx, realm, cfg, &out.Players); err != nil {
 		return nil, err
 	}
 	// Global: total matches (also used for Players.TotalMatches)
-	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM game_history gh WHERE %s`, ghCond)).Scan(&out.Global.TotalMatches); err != nil {
+	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM game_history gh WHERE %s`, ghCond), realm).Scan(&out.Global.TotalMatches); err != nil {
 		return nil, err
 	}
 	out.Players.TotalMatches = out.Global.TotalMatches
 
 	// Global: total turns (only in filtered matches)
-	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM turn WHERE match_id IN (%s)`, matchIDsSubq)).Scan(&out.Global.TotalTurns); err != nil {
+	if err := s.pool.QueryRow(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM turn WHERE match_id IN (%s)`, matchIDsSubq), realm).Scan(&out.Global.TotalTurns); err != nil {
 		return nil, err
 	}
 	// Global: avg turns per match
@@ -724,7 +1484,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 	if out.Global.TotalTurns > 0 {
 		if err := s.pool.QueryRow(ctx, fmt.Sprintf(`
 			SELECT AVG((point_delta_player - point_delta_opponent))::float FROM turn WHERE match_id IN (%s)
-		`, matchIDsSubq)).Scan(&out.Global.AvgNetPointSwingPerTurn); err != nil {
+		`, matchIDsSubq), realm).Scan(&out.Global.AvgNetPointSwingPerTurn); err != nil {
 			return nil, err
 		}
 	}
@@ -734,7 +1494,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 		SELECT AVG((COALESCE(point_delta_player, 0) - COALESCE(point_delta_opponent, 0)))::float
 		FROM arcana_use
 		WHERE match_id IN (%s) AND point_delta_player IS NOT NULL AND point_delta_opponent IS NOT NULL
-	`, matchIDsSubq)).Scan(&avgNetPerCard); err != nil {
+	`, matchIDsSubq), realm).Scan(&avgNetPerCard); err != nil {
 		return nil, err
 	}
 	out.Global.AvgNetPointSwingPerCard = avgNetPerCard
@@ -745,7 +1505,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 		SELECT AVG(cnt)::float, MAX(cnt)::int FROM (
 			SELECT match_id, round, COUNT(*) AS cnt FROM arcana_use WHERE match_id IN (%s) GROUP BY match_id, round
 		) t
-	`, matchIDsSubq)).Scan(&cardsPerTurnAvg, &cardsPerTurnMax); err != nil {
+	`, matchIDsSubq), realm).Scan(&cardsPerTurnAvg, &cardsPerTurnMax); err != nil {
 		return nil, err
 	}
 	if cardsPerTurnAvg != nil {
@@ -765,7 +1525,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 		FROM match_arcana ma
 		JOIN game_history gh ON gh.id = ma.match_id AND %s
 		GROUP BY ma.power_up_id
-	`, ghCond))
+	`, ghCond), realm)
 	if err != nil {
 		return nil, err
 	}
@@ -794,7 +1554,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 		FROM arcana_use
 		WHERE match_id IN (%s)
 		GROUP BY power_up_id
-	`, matchIDsSubq))
+	`, matchIDsSubq), realm)
 	if err != nil {
 		return nil, err
 	}
@@ -838,7 +1598,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 		WHERE match_id IN (%s)
 		GROUP BY power_up_id, round
 		ORDER BY power_up_id, round
-	`, matchIDsSubq))
+	`, matchIDsSubq), realm)
 	if err != nil {
 		return nil, err
 	}
@@ -873,7 +1633,7 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 	}
 	pairsLabels := buildPairsHistogramLabels(cfg)
 	pairsBinsByCard := make(map[string][]int)
-	pairsRows, err := s.pool.Query(ctx, fmt.Sprintf(`SELECT power_up_id, pairs_matched_before FROM arcana_use WHERE match_id IN (%s)`, matchIDsSubq))
+	pairsRows, err := s.pool.Query(ctx, fmt.Sprintf(`SELECT power_up_id, pairs_matched_before FROM arcana_use WHERE match_id IN (%s)`, matchIDsSubq), realm)
 	if err != nil {
 		return nil, err
 	}
@@ -955,148 +1715,16 @@ func (s *Store) GetTelemetryMetrics(ctx context.Context, binConfig *TelemetryBin
 		})
 	}
 
-	// Combo "game stage at use": (combo_key, round, pairs_matched_before) per combo use for histograms.
-	comboUseRows, err := s.pool.Query(ctx, fmt.Sprintf(`
-		WITH turn_cards AS (
-			SELECT match_id, round, player_idx, array_agg(DISTINCT power_up_id ORDER BY power_up_id) AS arr
-			FROM arcana_use
-			WHERE match_id IN (%s)
-			GROUP BY match_id, round, player_idx
-		),
-		turn_combos AS (
-			SELECT match_id, round, player_idx,
-				array_to_string(arr, ',') AS combo_key
-			FROM turn_cards
-			WHERE array_length(arr, 1) >= 2
-		)
-		SELECT tc.combo_key, tc.round, MIN(au.pairs_matched_before) AS pairs_matched_before
-		FROM turn_combos tc
-		JOIN arcana_use au ON au.match_id = tc.match_id AND au.round = tc.round AND au.player_idx = tc.player_idx
-		GROUP BY tc.combo_key, tc.match_id, tc.round, tc.player_idx
-	`, matchIDsSubq))
+	// By combo: the 50 most used combos (no minimum sample).
+	out.ByCombo, _, err = s.queryCombos(ctx, realm, cfg, 1, ComboSortUses, 50, 0)
 	if err != nil {
 		return nil, err
 	}
-	comboUseByKey := make(map[string][]struct{ round, pairs int })
-	for comboUseRows.Next() {
-		var comboKey string
-		var round, pairs int
-		if err := comboUseRows.Scan(&comboKey, &round, &pairs); err != nil {
-			comboUseRows.Close()
-			return nil, err
-		}
-		comboUseByKey[comboKey] = append(comboUseByKey[comboKey], struct{ round, pairs int }{round, pairs})
-	}
-	comboUseRows.Close()
-	if err := comboUseRows.Err(); err != nil {
-		return nil, err
-	}
 
-	// By combo: cards used together in the same turn (arcana_use grouped by match_id, round, player_idx).
-	// Combo = sorted set of power_up_ids used in one turn; only combos with 2+ cards (synergy).
-	// Wins = number of times that combo was used and the player who used it won the match.
-	// Point swing = from first card of combo until end of turn (consistent with individual card metric).
-	comboRows, err := s.pool.Query(ctx, fmt.Sprintf(`
-		WITH turn_cards AS (
-			SELECT match_id, round, player_idx, array_agg(DISTINCT power_up_id ORDER BY power_up_id) AS arr
-			FROM arcana_use
-			WHERE match_id IN (%s)
-			GROUP BY match_id, round, player_idx
-		),
-		turn_combos AS (
-			SELECT match_id, round, player_idx,
-				array_to_string(arr, ',') AS combo_key,
-				array_length(arr, 1) AS card_count
-			FROM turn_cards
-			WHERE array_length(arr, 1) >= 2
-		),
-		turn_swing AS (
-			SELECT tc.combo_key, tc.card_count, tc.match_id, tc.round, tc.player_idx,
-				MIN(au.player_score_before) AS first_player_before,
-				MIN(au.opponent_score_before) AS first_opponent_before
-			FROM turn_combos tc
-			JOIN arcana_use au ON au.match_id = tc.match_id AND au.round = tc.round AND au.player_idx = tc.player_idx
-			GROUP BY tc.combo_key, tc.card_count, tc.match_id, tc.round, tc.player_idx
-		)
-		SELECT ts.combo_key, ts.card_count,
-			COUNT(*) AS total_uses,
-			COUNT(*) FILTER (WHERE gh.winner_index = ts.player_idx) AS wins,
-			AVG(t.player_score_after_turn - ts.first_player_before)::float AS avg_point_swing_player,
-			AVG(t.opponent_score_after_turn - ts.first_opponent_before)::float AS avg_point_swing_opponent
-		FROM turn_swing ts
-		JOIN turn t ON t.match_id = ts.match_id AND t.round = ts.round AND t.player_idx = ts.player_idx
-		JOIN game_history gh ON gh.id = ts.match_id AND %s
-		GROUP BY ts.combo_key, ts.card_count
-		ORDER BY COUNT(*) DESC
-		LIMIT 50
-	`, matchIDsSubq, ghCond))
-	if err != nil {
-		return nil, err
-	}
-	defer comboRows.Close()
-	for comboRows.Next() {
-		var comboKey string
-		var cardCount, total, wins int
-		var avgSwingPlayer, avgSwingOpponent float64
-		if err := comboRows.Scan(&comboKey, &cardCount, &total, &wins, &avgSwingPlayer, &avgSwingOpponent); err != nil {
+	if cfg.GroupBy == TelemetryGroupByBoardSize {
+		if out.Segments, err = s.telemetryBoardSegments(ctx, realm, cfg); err != nil {
 			return nil, err
 		}
-		winRatePct := 0.0
-		if total > 0 {
-			winRatePct = 100.0 * float64(wins) / float64(total)
-		}
-		uses := comboUseByKey[comboKey]
-		var avgTurn, avgPairs float64
-		comboTurnBins := make([]int, len(turnLabels))
-		comboPairsBins := make([]int, len(pairsLabels))
-		for _, u := range uses {
-			avgTurn += float64(u.round)
-			avgPairs += float64(u.pairs)
-			binIdx := cfg.TurnNumBins - 1
-			if u.round < cfg.TurnMax {
-				binIdx = u.round / turnStep
-				if binIdx >= cfg.TurnNumBins-1 {
-					binIdx = cfg.TurnNumBins - 2
-				}
-			}
-			comboTurnBins[binIdx]++
-			pairsBinIdx := u.pairs / pairsStep
-			if pairsBinIdx >= cfg.PairsNumBins {
-				pairsBinIdx = cfg.PairsNumBins - 1
-			}
-			comboPairsBins[pairsBinIdx]++
-		}
-		if n := len(uses); n > 0 {
-			avgTurn /= float64(n)
-			avgPairs /= float64(n)
-		}
-		turnHist := make([]TelemetryHistogramBucket, len(turnLabels))
-		for i, label := range turnLabels {
-			turnHist[i] = TelemetryHistogramBucket{Label: label, Count: comboTurnBins[i]}
-		}
-		pairsHist := make([]TelemetryHistogramBucket, len(pairsLabels))
-		for i, label := range pairsLabels {
-			pairsHist[i] = TelemetryHistogramBucket{Label: label, Count: comboPairsBins[i]}
-		}
-		out.ByCombo = append(out.ByCombo, TelemetryByCombo{
-			ComboKey:              comboKey,
-			CardCount:             cardCount,
-			TotalMatches:          total,
-			Wins:                  wins,
-			WinRatePct:            winRatePct,
-			AvgPointSwingPlayer:   avgSwingPlayer,
-			AvgPointSwingOpponent: avgSwingOpponent,
-			AvgTurnAtUse:          avgTurn,
-			AvgPairsMatchedBefore: avgPairs,
-			TurnHistogram:         turnHist,
-			PairsHistogram:        pairsHist,
-		})
-	}
-	if err := comboRows.Err(); err != nil {
-		return nil, err
-	}
-	if out.ByCombo == nil {
-		out.ByCombo = []TelemetryByCombo{}
 	}
 	return out, nil
 }
@@ -0,0 +1,66 @@
+package storage
+
+import "testing"
+
+func TestExtractKeyMoments(t *testing.T) {
+	// Player 1 falls behind 0-3, then wins 5-3 after a 4-point turn opened by a Leech.
+	turns := []summaryTurn{
+		{Round: 0, PlayerIdx: 0, PlayerScoreAfter: 3, OpponentScoreAfter: 0, DeltaPlayer: 3},
+		{Round: 1, PlayerIdx: 1, PlayerScoreAfter: 1, OpponentScoreAfter: 3, DeltaPlayer: 1},
+		{Round: 2, PlayerIdx: 0, PlayerScoreAfter: 3, OpponentScoreAfter: 1, DeltaPlayer: 0},
+		{Round: 3, PlayerIdx: 1, PlayerScoreAfter: 5, OpponentScoreAfter: 3, DeltaPlayer: 4},
+	}
+	uses := []summaryArcanaUse{
+		{Round: 0, PlayerIdx: 0, PowerUpID: "chaos", DeltaPlayer: 3},
+		{Round: 1, PlayerIdx: 1, PowerUpID: "unveiling", DeltaPlayer: 1},
+		{Round: 3, PlayerIdx: 1, PowerUpID: "leech", DeltaPlayer: 4, DeltaOpponent: -2},
+	}
+	winner := 1
+	got := extractKeyMoments(turns, uses, &winner)
+	want := []KeyMoment{
+		{Kind: KeyMomentBiggestCombo, Round: 3, PlayerIdx: 1, PointSwing: 4},
+		{Kind: KeyMomentDecisiveArcana, Round: 3, PlayerIdx: 1, PowerUpID: "leech", PointSwing: 6},
+		{Kind: KeyMomentComeback, Round: 0, PlayerIdx: 1, PointSwing: 3},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d key moments, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("moment %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestExtractKeyMoments_DrawAndQuietMatch(t *testing.T) {
+	turns := []summaryTurn{
+		{Round: 0, PlayerIdx: 0, PlayerScoreAfter: 1, OpponentScoreAfter: 0, DeltaPlayer: 1},
+		{Round: 1, PlayerIdx: 1, PlayerScoreAfter: 1, OpponentScoreAfter: 1, DeltaPlayer: 1},
+	}
+	uses := []summaryArcanaUse{{Round: 1, PlayerIdx: 1, PowerUpID: "leech", DeltaPlayer: 1, DeltaOpponent: -1}}
+	if got := extractKeyMoments(turns, uses, nil); len(got) != 0 {
+		t.Errorf("expected no key moments for a one-point-per-turn draw, got %+v", got)
+	}
+}
+
+func TestScoreSeries(t *testing.T) {
+	turns := []summaryTurn{
+		{Round: 0, PlayerIdx: 0, PlayerScoreAfter: 2, OpponentScoreAfter: 0},
+		{Round: 1, PlayerIdx: 1, PlayerScoreAfter: 1, OpponentScoreAfter: 2},
+		{Round: 2, PlayerIdx: 0, PlayerScoreAfter: 2, OpponentScoreAfter: 4},
+	}
+	got := scoreSeries(turns)
+	want := []ScorePoint{
+		{Round: 0, Scores: [2]int{2, 0}},
+		{Round: 1, Scores: [2]int{2, 1}},
+		{Round: 2, Scores: [2]int{2, 4}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d points, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// Suspicious activity kinds.
+const (
+	// SuspiciousFastFlip: flips rejected for arriving less than MIN_FLIP_SPACING_MS after the turn's
+	// previous flip (see game.FastFlips).
+	SuspiciousFastFlip = "fast_flip"
+)
+
+// createSuspiciousActivitySQL stores, per match and seat, how often each kind of suspicious timing was
+// seen and the shortest gap measured.
+const createSuspiciousActivitySQL = `
+CREATE TABLE IF NOT EXISTS suspicious_activity (
+	match_id   UUID NOT NULL REFERENCES game_history(id),
+	player_idx SMALLINT NOT NULL,
+	kind       TEXT NOT NULL,
+	count      INT NOT NULL,
+	min_gap_ms INT NOT NULL,
+	PRIMARY KEY (match_id, player_idx, kind)
+);
+`
+
+// SuspiciousTimingFlag is one account with many flips rejected for inhuman timing over the period.
+type SuspiciousTimingFlag struct {
+	UserID       string `json:"user_id"`
+	Name         string `json:"name"`
+	Matches      int    `json:"matches"`
+	FastFlips    int    `json:"fast_flips"`
+	MinGapMS     int    `json:"min_gap_ms"`
+	LastPlayedAt string `json:"last_played_at"` // ISO8601
+}
+
+// InsertSuspiciousActivity records count occurrences of kind for a seat in a finished match. A count of 0
+// is not stored; a repeated call for the same seat and kind is ignored.
+func (s *Store) InsertSuspiciousActivity(ctx context.Context, matchID string, playerIdx int, kind string, count, minGapMS int) error {
+	if s == nil || s.pool == nil || count == 0 {
+		return nil
+	}
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO suspicious_activity (match_id, player_idx, kind, count, min_gap_ms)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (match_id, player_idx, kind) DO NOTHING`,
+		matchID, playerIdx, kind, count, minGapMS)
+	return err
+}
+
+// GetSuspiciousTimingReport returns the human accounts with at least cfg.MinFastFlips fast flips over
+// cfg.TimeRange, most fast flips first.
+func (s *Store) GetSuspiciousTimingReport(ctx context.Context, cfg IntegrityReportConfig) ([]SuspiciousTimingFlag, error) {
+	if s == nil || s.pool == nil {
+		return []SuspiciousTimingFlag{}, nil
+	}
+	if cfg.MinFastFlips <= 0 {
+		cfg.MinFastFlips = 5
+	}
+	interval := telemetryTimeIntervalSQL(cfg.TimeRange)
+	if cfg.TimeRange == "" {
+		interval = "30 days"
+	}
+	rows, err := s.pool.Query(ctx, `
+		SELECT user_id, (array_agg(name ORDER BY played_at DESC))[1], COUNT(*), SUM(count), MIN(min_gap_ms), MAX(played_at)
+		FROM (
+			SELECT CASE WHEN sa.player_idx = 0 THEN gh.player0_user_id ELSE gh.player1_user_id END AS user_id,
+				CASE WHEN sa.player_idx = 0 THEN gh.player0_name ELSE gh.player1_name END AS name,
+				gh.played_at, sa.count, sa.min_gap_ms
+			FROM suspicious_activity sa
+			JOIN game_history gh ON gh.id = sa.match_id
+			WHERE sa.kind = $1 AND gh.played_at >= now() - interval '`+interval+`'
+		) t
+		WHERE user_id NOT LIKE 'ai:%'
+		GROUP BY user_id
+		HAVING SUM(count) >= $2
+		ORDER BY SUM(count) DESC, user_id`,
+		SuspiciousFastFlip, cfg.MinFastFlips)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []SuspiciousTimingFlag{}
+	for rows.Next() {
+		var f SuspiciousTimingFlag
+		var last time.Time
+		if err := rows.Scan(&f.UserID, &f.Name, &f.Matches, &f.FastFlips, &f.MinGapMS, &last); err != nil {
+			return nil, err
+		}
+		f.LastPlayedAt = last.UTC().Format(time.RFC3339)
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
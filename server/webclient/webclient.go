@@ -0,0 +1,96 @@
+// Package webclient serves the built web client from the server binary, so a small self-hosted deployment
+// needs a single process. The client build (client/dist) is copied into webclient/dist before go build;
+// without it the embedded tree only holds a placeholder and Embedded reports false.
+package webclient
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Cache-Control values: Vite puts content-hashed bundles under /assets/, which never change under the same
+// name; index.html must be revalidated so a deploy reaches browsers right away; other static files (cards,
+// sounds, avatars) keep their names across builds and are cached for a short while.
+const (
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+	cacheShort      = "public, max-age=3600"
+)
+
+// reservedRoots are server routes the client must never shadow: an unknown path at or below them is a 404,
+// not the client's index.html.
+var reservedRoots = []string{"/api", "/ws", "/realms"}
+
+// Embedded returns the client build embedded in the binary, and whether it holds an index.html.
+func Embedded() (fs.FS, bool) {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(sub, "index.html"); err != nil {
+		return nil, false
+	}
+	return sub, true
+}
+
+// Handler serves the client build in fsys at /. Existing files are served as is; any other path without a
+// file extension gets index.html, so client-side routes (e.g. /leaderboard) load the app on refresh.
+// Only GET and HEAD are answered.
+func Handler(fsys fs.FS) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		urlPath := path.Clean("/" + r.URL.Path)
+		for _, root := range reservedRoots {
+			if urlPath == root || strings.HasPrefix(urlPath, root+"/") {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		name := strings.TrimPrefix(urlPath, "/")
+		if name == "" || name == "index.html" {
+			serveIndex(w, r, fsys)
+			return
+		}
+		info, err := fs.Stat(fsys, name)
+		switch {
+		case err == nil && !info.IsDir():
+			if strings.HasPrefix(name, "assets/") {
+				w.Header().Set("Cache-Control", cacheImmutable)
+			} else {
+				w.Header().Set("Cache-Control", cacheShort)
+			}
+			http.ServeFileFS(w, r, fsys, name)
+		case (err == nil || errors.Is(err, fs.ErrNotExist)) && path.Ext(name) == "":
+			serveIndex(w, r, fsys)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// serveIndex writes the client's index.html, revalidated on every load.
+func serveIndex(w http.ResponseWriter, r *http.Request, fsys fs.FS) {
+	data, err := fs.ReadFile(fsys, "index.html")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", cacheRevalidate)
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,75 @@
+package webclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testBuild() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":           {Data: []byte("<!doctype html><title>Memory Game</title>")},
+		"assets/index-4f2a.js": {Data: []byte("console.log(1)")},
+		"cards/back.png":       {Data: []byte("png")},
+		"sounds/match/one.mp3": {Data: []byte("mp3")},
+		"avatars/.placeholder": {Data: []byte{}},
+		"media-license.txt":    {Data: []byte("license")},
+	}
+}
+
+func get(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestHandler_ServesFilesWithCacheHeaders(t *testing.T) {
+	h := Handler(testBuild())
+	cases := []struct {
+		target, cache string
+	}{
+		{"/", cacheRevalidate},
+		{"/index.html", cacheRevalidate},
+		{"/assets/index-4f2a.js", cacheImmutable},
+		{"/cards/back.png", cacheShort},
+	}
+	for _, c := range cases {
+		rec := get(h, http.MethodGet, c.target)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", c.target, rec.Code)
+		}
+		if got := rec.Header().Get("Cache-Control"); got != c.cache {
+			t.Errorf("%s: expected Cache-Control %q, got %q", c.target, c.cache, got)
+		}
+	}
+}
+
+func TestHandler_SPAFallback(t *testing.T) {
+	h := Handler(testBuild())
+	for _, target := range []string{"/leaderboard", "/history/abc-123", "/sounds"} {
+		rec := get(h, http.MethodGet, target)
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<title>Memory Game</title>") {
+			t.Errorf("%s: expected index.html, got %d %q", target, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := get(h, http.MethodGet, "/assets/missing-9b1c.js"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing asset, got %d", rec.Code)
+	}
+}
+
+func TestHandler_NeverShadowsServerRoutes(t *testing.T) {
+	h := Handler(testBuild())
+	for _, target := range []string{"/api/unknown", "/api", "/ws", "/ws/extra", "/realms/eu/leaderboard"} {
+		if rec := get(h, http.MethodGet, target); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", target, rec.Code)
+		}
+	}
+	if rec := get(h, http.MethodGet, "/wsx"); rec.Code != http.StatusOK {
+		t.Errorf("expected /wsx to fall back to the client, got %d", rec.Code)
+	}
+	if rec := get(h, http.MethodPost, "/"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for POST, got %d", rec.Code)
+	}
+}
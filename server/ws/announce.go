@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Announcement levels. Clients show info as a passing notice and warning (e.g. upcoming maintenance)
+// more prominently; neither interrupts a game.
+const (
+	AnnouncementInfo    = "info"
+	AnnouncementWarning = "warning"
+)
+
+const (
+	maxAnnouncementLen     = 500
+	maxAnnouncementAdvance = 7 * 24 * time.Hour
+	announceSendTimeout    = time.Second
+)
+
+// Announcement is an admin message delivered to every connected client of every hub.
+type Announcement struct {
+	ID      string    `json:"id"`
+	Message string    `json:"message"`
+	Level   string    `json:"level"`
+	SendAt  time.Time `json:"send_at"`
+}
+
+// Announcer delivers announcements to a set of hubs, now or at a scheduled time. Scheduled
+// announcements live in memory only: a restart drops the ones that have not been sent yet.
+type Announcer struct {
+	hubs []*Hub
+
+	mu      sync.Mutex
+	pending map[string]*scheduledAnnouncement
+}
+
+type scheduledAnnouncement struct {
+	Announcement
+	timer *time.Timer
+}
+
+// NewAnnouncer creates an announcer that broadcasts through hubs (typically the default hub and every realm hub).
+func NewAnnouncer(hubs ...*Hub) *Announcer {
+	return &Announcer{
+		hubs:    hubs,
+		pending: make(map[string]*scheduledAnnouncement),
+	}
+}
+
+// Schedule validates the announcement and sends it at sendAt, or right away when sendAt is zero or in
+// the past. Returns the stored announcement with its ID and effective send time.
+func (a *Announcer) Schedule(message, level string, sendAt time.Time) (Announcement, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return Announcement{}, errors.New("message required")
+	}
+	if len(message) > maxAnnouncementLen {
+		return Announcement{}, errors.New("message too long")
+	}
+	if level == "" {
+		level = AnnouncementInfo
+	}
+	if level != AnnouncementInfo && level != AnnouncementWarning {
+		return Announcement{}, errors.New("level must be info or warning")
+	}
+	now := time.Now()
+	if sendAt.IsZero() || sendAt.Before(now) {
+		sendAt = now
+	}
+	if sendAt.Sub(now) > maxAnnouncementAdvance {
+		return Announcement{}, errors.New("send_at is too far in the future")
+	}
+
+	ann := Announcement{ID: uuid.New().String(), Message: message, Level: level, SendAt: sendAt}
+	if !sendAt.After(now) {
+		a.send(ann)
+		return ann, nil
+	}
+	a.mu.Lock()
+	a.pending[ann.ID] = &scheduledAnnouncement{
+		Announcement: ann,
+		timer:        time.AfterFunc(sendAt.Sub(now), func() { a.fire(ann.ID) }),
+	}
+	a.mu.Unlock()
+	slog.Info("announcement scheduled", "tag", "announce", "id", ann.ID, "send_at", sendAt)
+	return ann, nil
+}
+
+// Pending returns the announcements that have not been sent yet, soonest first.
+func (a *Announcer) Pending() []Announcement {
+	a.mu.Lock()
+	out := make([]Announcement, 0, len(a.pending))
+	for _, s := range a.pending {
+		out = append(out, s.Announcement)
+	}
+	a.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].SendAt.Before(out[j].SendAt) })
+	return out
+}
+
+// Cancel drops a scheduled announcement. Returns false when it is unknown or already sent.
+func (a *Announcer) Cancel(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.pending[id]
+	if !ok {
+		return false
+	}
+	s.timer.Stop()
+	delete(a.pending, id)
+	return true
+}
+
+func (a *Announcer) fire(id string) {
+	a.mu.Lock()
+	s, ok := a.pending[id]
+	delete(a.pending, id)
+	a.mu.Unlock()
+	if ok {
+		a.send(s.Announcement)
+	}
+}
+
+// send broadcasts the announcement to every hub. A hub that has stopped (server shutdown) is skipped
+// after announceSendTimeout instead of blocking the caller.
+func (a *Announcer) send(ann Announcement) {
+	data, _ := json.Marshal(map[string]string{
+		"type":    "announcement",
+		"id":      ann.ID,
+		"message": ann.Message,
+		"level":   ann.Level,
+	})
+	for _, h := range a.hubs {
+		select {
+		case h.Broadcast <- data:
+		case <-time.After(announceSendTimeout):
+			slog.Warn("announcement not delivered, hub not running", "tag", "announce", "id", ann.ID, "realm", h.Realm)
+		}
+	}
+	slog.Info("announcement sent", "tag", "announce", "id", ann.ID, "level", ann.Level)
+}
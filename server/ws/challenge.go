@@ -0,0 +1,112 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"memory-game-server/matcherrors"
+)
+
+// hubFind asks the hub for the authenticated connections of userID; they are sent on done.
+type hubFind struct {
+	userID string
+	done   chan []*Client
+}
+
+// ClientsOf returns the authenticated connections of userID on this hub (none when the hub is not running).
+func (h *Hub) ClientsOf(userID string) []*Client {
+	if userID == "" {
+		return nil
+	}
+	req := hubFind{userID: userID, done: make(chan []*Client, 1)}
+	select {
+	case h.findRequests <- req:
+	case <-time.After(hubCloseTimeout):
+		return nil
+	}
+	return <-req.done
+}
+
+// findClients handles a hubFind in Run.
+func (h *Hub) findClients(req hubFind) {
+	var out []*Client
+	for client := range h.Clients {
+		if client.Authenticated && client.UserID == req.userID {
+			out = append(out, client)
+		}
+	}
+	req.done <- out
+}
+
+func (c *Client) handleChallengeUser(raw json.RawMessage) {
+	if c.UserID == "" {
+		c.sendError("Sign in to challenge a player.")
+		return
+	}
+	if c.Game != nil && !c.Game.Finished {
+		c.sendError("Cannot challenge a player while in an active game.")
+		return
+	}
+	var msg ChallengeUserMsg
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.UserID == "" {
+		c.sendError("Invalid challenge_user message.")
+		return
+	}
+	if msg.UserID == c.UserID {
+		c.sendError("You cannot challenge yourself.")
+		return
+	}
+	if msg.BoardSize != 0 && !c.Hub.Config.OffersBoardSize(msg.BoardSize) {
+		size := strconv.Itoa(msg.BoardSize)
+		c.sendError("Board size " + size + "x" + size + " is not available.")
+		return
+	}
+	targets := c.Hub.ClientsOf(msg.UserID)
+	if len(targets) == 0 {
+		c.sendError("That player is not online.")
+		return
+	}
+	c.BoardSize = msg.BoardSize
+	c.Game = nil
+	c.PlayerID = 0
+	c.TeamMember = 0
+	if err := c.Hub.Matchmaker.Challenge(c, targets); err != nil {
+		if errors.Is(err, matcherrors.ErrOpponentBusy) {
+			c.sendError("That player is in a game.")
+			return
+		}
+		slog.Error("challenge failed", "tag", "matchmaking", "user_id", c.UserID, "err", err)
+		c.sendError("Could not send the challenge.")
+	}
+}
+
+func (c *Client) handleChallengeAnswer(raw json.RawMessage) {
+	var msg ChallengeAnswerMsg
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.ChallengeID == "" {
+		c.sendError("Invalid challenge_answer message.")
+		return
+	}
+	if msg.Accept && c.Game != nil && !c.Game.Finished {
+		c.sendError("Cannot accept a challenge while in an active game.")
+		return
+	}
+	if msg.Accept {
+		c.Game = nil
+		c.PlayerID = 0
+		c.TeamMember = 0
+	}
+	if err := c.Hub.Matchmaker.AnswerChallenge(c, msg.ChallengeID, msg.Accept); err != nil {
+		switch {
+		case errors.Is(err, matcherrors.ErrUnknownChallenge):
+			c.sendError("This challenge is no longer open.")
+		case errors.Is(err, matcherrors.ErrOpponentBusy):
+			c.sendError("That player is no longer available.")
+		default:
+			slog.Error("challenge answer failed", "tag", "matchmaking", "challenge_id", msg.ChallengeID, "err", err)
+			c.sendError("Could not answer the challenge.")
+		}
+	}
+}
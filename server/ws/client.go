@@ -6,12 +6,15 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/websocket"
 	"memory-game-server/auth"
 	"memory-game-server/game"
 	"memory-game-server/matcherrors"
+	"memory-game-server/modes"
 	"memory-game-server/wsutil"
 )
 
@@ -27,6 +30,10 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 4096
+
+	// Ping period while connection quality is tracked (ConnectionQuality thresholds set), so missed pongs
+	// and rising RTT show up within seconds instead of once per pingPeriod.
+	probePeriod = 5 * time.Second
 )
 
 // Client is a middleman between the websocket connection and the hub.
@@ -36,23 +43,124 @@ type Client struct {
 	Send          chan []byte
 	Name          string
 	Game          *game.Game
-	PlayerID      int    // 0 or 1 within the game
+	PlayerID      int    // seat within the game: 0 or 1, up to 3 in a party game
+	TeamMember    int    // position in the team rotation when PlayerID is a team seat (co-op raid)
+	QueueMode     string // queue entered by set_name ("", QueueModeCasual, QueueModeRaid, QueueModeHotseat or QueueModeParty); reused by play_again
+	Assist        bool   // assisted accessibility mode requested in set_name; reused by play_again
 	UserID        string // from JWT sub claim
 	Authenticated bool
+	Region        string // region hint from auth or set_name (normalized; "" = unknown)
+	SecondName    string // second player of a hotseat game, from set_name; reused by play_again
+	BoardSize     int    // side of the n x n board requested in set_name (0 = default board); reused by play_again
+	PartySize     int    // players of the party game requested in set_name (party mode); reused by play_again
+
+	// rttMS is the smoothed round-trip time measured with ping/pong, in ms (0 = not measured yet).
+	rttMS atomic.Int64
+	// pingPending is set while a ping waits for its pong; missedPongs counts pings sent while the previous
+	// one was still unanswered, reset by the next pong.
+	pingPending atomic.Bool
+	missedPongs atomic.Int32
+
+	// chatSent holds when this connection's recent chat lines were accepted, for the chat rate limit.
+	// Only touched from ReadPump.
+	chatSent []time.Time
+	// msgWindowStart and msgCount count the messages read in the current second, for MaxMessagesPerSec.
+	// Only touched from ReadPump (for a poll session, under its handleMu).
+	msgWindowStart time.Time
+	msgCount       int
+	// actionTokens, actionRefill and actionsDropped are the token bucket for game actions (ActionsPerSec,
+	// ActionBurst) and the actions dropped since one last got through. Only touched from ReadPump (for a
+	// poll session, under its handleMu).
+	actionTokens   float64
+	actionRefill   time.Time
+	actionsDropped int
+	// rateLimited is set once the connection is being closed for flooding; later messages are dropped.
+	// Only touched from ReadPump (for a poll session, under its handleMu).
+	rateLimited bool
+	// closeReq hands a Disconnect to WritePump; nil for clients without a WebSocket connection.
+	closeReq chan closeFrame
+}
+
+// RTT returns the connection's smoothed round-trip time, or 0 before the first measurement.
+func (c *Client) RTT() time.Duration {
+	return time.Duration(c.rttMS.Load()) * time.Millisecond
+}
+
+// recordPong updates the RTT from a pong echoing a ping payload (the send time in Unix nanoseconds) and
+// reports it to the client's game. Pongs without a timestamp are ignored.
+func (c *Client) recordPong(appData string) {
+	sent, err := strconv.ParseInt(appData, 10, 64)
+	if err != nil {
+		return
+	}
+	sample := time.Since(time.Unix(0, sent)).Milliseconds()
+	if sample < 0 {
+		return
+	}
+	rtt := sample
+	if prev := c.rttMS.Load(); prev > 0 {
+		rtt = (3*prev + sample) / 4
+	}
+	c.rttMS.Store(rtt)
+	c.pingPending.Store(false)
+	c.missedPongs.Store(0)
+	if g := c.Game; g != nil {
+		g.ReportRTT(c.PlayerID, c.RTT())
+	}
+	c.reportQuality()
+}
+
+// writePing sends a ping carrying the current time, so the pong measures the round trip.
+func (c *Client) writePing() error {
+	if c.pingPending.Swap(true) {
+		c.missedPongs.Add(1)
+		c.reportQuality()
+	}
+	c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.Conn.WriteMessage(websocket.PingMessage, []byte(strconv.FormatInt(time.Now().UnixNano(), 10)))
+}
+
+// reportQuality tells the client's game whether the connection is unstable: too many missed pongs in a
+// row, or an RTT at or above the configured threshold.
+func (c *Client) reportQuality() {
+	g := c.Game
+	if g == nil {
+		return
+	}
+	cfg := c.Hub.Config.ConnectionQuality
+	unstable := (cfg.MissedPongs > 0 && int(c.missedPongs.Load()) >= cfg.MissedPongs) ||
+		(cfg.RTTThresholdMS > 0 && c.rttMS.Load() >= int64(cfg.RTTThresholdMS))
+	g.ReportConnectionQuality(c.PlayerID, unstable)
+}
+
+// pingInterval is probePeriod while connection quality is tracked, pingPeriod otherwise.
+func (c *Client) pingInterval() time.Duration {
+	if cfg := c.Hub.Config.ConnectionQuality; cfg.MissedPongs > 0 || cfg.RTTThresholdMS > 0 {
+		return probePeriod
+	}
+	return pingPeriod
+}
+
+// leave runs when the client's connection or poll session ends: it withdraws the client's queue entry and
+// challenges (LeaveQueue covers both) and unregisters it from the hub.
+func (c *Client) leave() {
+	c.Hub.Matchmaker.LeaveQueue(c)
+	c.Hub.Unregister <- c
 }
 
 // ReadPump pumps messages from the websocket connection to the hub.
 // It runs in its own goroutine per connection.
 func (c *Client) ReadPump() {
 	defer func() {
-		c.Hub.Unregister <- c
+		c.leave()
 		c.Conn.Close()
 	}()
 
 	c.Conn.SetReadLimit(maxMessageSize)
 	c.Conn.SetReadDeadline(time.Now().Add(pongWait))
-	c.Conn.SetPongHandler(func(string) error {
+	c.Conn.SetPongHandler(func(appData string) error {
 		c.Conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.recordPong(appData)
 		return nil
 	})
 
@@ -65,6 +173,17 @@ func (c *Client) ReadPump() {
 			}
 			break
 		}
+		// Past the message rate limit the connection is closed; whatever arrives until then is dropped.
+		if c.rateLimited {
+			continue
+		}
+		if !c.allowMessage(time.Now(), c.Hub.Config.MaxMessagesPerSec) {
+			c.rateLimited = true
+			slog.Warn("client over the message rate limit", "tag", "hub", "user_id", c.UserID)
+			c.sendError("Too many messages.")
+			c.Disconnect(CloseRateLimited, "too many messages")
+			continue
+		}
 
 		c.handleMessage(message)
 	}
@@ -73,12 +192,17 @@ func (c *Client) ReadPump() {
 // WritePump pumps messages from the send channel to the websocket connection.
 // It runs in its own goroutine per connection.
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := time.NewTicker(c.pingInterval())
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
 	}()
 
+	// Measure the round trip right away instead of waiting a full ping period.
+	if err := c.writePing(); err != nil {
+		return
+	}
+
 	for {
 		select {
 		case message, ok := <-c.Send:
@@ -100,10 +224,13 @@ func (c *Client) WritePump() {
 			}
 
 		case <-ticker.C:
-			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+			if err := c.writePing(); err != nil {
 				return
 			}
+
+		case frame := <-c.closeReq:
+			c.writeClose(frame)
+			return
 		}
 	}
 }
@@ -122,6 +249,10 @@ func (c *Client) handleMessage(data []byte) {
 		return
 	}
 
+	if (envelope.Type == "flip_card" || envelope.Type == "use_power_up") && !c.admitAction(time.Now()) {
+		return
+	}
+
 	switch envelope.Type {
 	case "auth":
 		c.handleAuth(envelope.Raw)
@@ -131,12 +262,28 @@ func (c *Client) handleMessage(data []byte) {
 		c.handleRejoin(envelope.Raw)
 	case "rejoin_my_game":
 		c.handleRejoinMyGame()
+	case "whoami_status":
+		c.handleStatus()
 	case "flip_card":
 		c.handleFlipCard(envelope.Raw)
 	case "use_power_up":
 		c.handleUsePowerUp(envelope.Raw)
 	case "play_again":
 		c.handlePlayAgain()
+	case "rematch":
+		c.handleRematch(envelope.Raw)
+	case "challenge_user":
+		c.handleChallengeUser(envelope.Raw)
+	case "challenge_answer":
+		c.handleChallengeAnswer(envelope.Raw)
+	case "poll_answer":
+		c.handlePollAnswer(envelope.Raw)
+	case "chat":
+		c.handleChat(envelope.Raw)
+	case "emote":
+		c.handleEmote(envelope.Raw)
+	case "draft_pick":
+		c.handleDraftPick(envelope.Raw)
 	case "leave_game":
 		c.handleLeaveGame()
 	case "leave_queue":
@@ -170,12 +317,23 @@ func (c *Client) handleAuth(raw json.RawMessage) {
 	if err != nil {
 		slog.Error("token validation failed", "tag", "auth", "err", err)
 		c.sendError("Invalid or expired token.")
+		c.Disconnect(CloseAuthFailed, "invalid or expired token")
+		return
+	}
+	if realm := auth.RealmFromClaims(claims); realm != c.Hub.Realm {
+		slog.Info("token realm does not match connection", "tag", "auth", "realm", realm, "hub_realm", c.Hub.Realm)
+		c.sendError("This account belongs to another realm.")
+		c.Disconnect(CloseAuthFailed, "account belongs to another realm")
 		return
 	}
 	c.UserID = auth.UserIDFromClaims(claims)
 	c.Name = auth.FirstNameFromClaims(claims)
+	c.Region = normalizeRegion(msg.Region)
 	c.Authenticated = true
 	slog.Info("authenticated user", "tag", "auth", "user_id", c.UserID, "name", c.Name, "total_users", c.Hub.uniqueAuthenticatedUsers())
+	if onAuth := c.Hub.OnAuthenticated; onAuth != nil {
+		go onAuth(c.Hub.Realm, c.UserID, c.Name)
+	}
 }
 
 func (c *Client) handleSetName(raw json.RawMessage) {
@@ -203,8 +361,84 @@ func (c *Client) handleSetName(raw json.RawMessage) {
 		return
 	}
 
+	mode, ok := modes.Lookup(msg.Mode)
+	if !ok {
+		c.sendError("Unknown queue mode: " + msg.Mode)
+		return
+	}
+	if mode.ID == QueueModeRanked {
+		msg.Mode = "" // the default queue
+	}
+	if msg.Mode == QueueModeHotseat {
+		second := strings.TrimSpace(msg.SecondName)
+		if second == "" {
+			second = defaultSecondName
+		}
+		if len(second) > c.Hub.Config.MaxNameLength {
+			c.sendError("Name must be between 1 and " + strconv.Itoa(c.Hub.Config.MaxNameLength) + " characters.")
+			return
+		}
+		c.SecondName = second
+	}
+	if msg.Mode == QueueModeParty {
+		if msg.PartySize == 0 {
+			msg.PartySize = mode.DefaultPlayers
+		}
+		if msg.PartySize < mode.MinPlayers || msg.PartySize > mode.MaxPlayers {
+			c.sendError("A party game seats " + strconv.Itoa(mode.MinPlayers) + " to " + strconv.Itoa(mode.MaxPlayers) + " players.")
+			return
+		}
+		c.PartySize = msg.PartySize
+	}
+	if !mode.BoardChoice {
+		msg.BoardSize = 0
+	}
+	if msg.BoardSize != 0 && !c.Hub.Config.OffersBoardSize(msg.BoardSize) {
+		size := strconv.Itoa(msg.BoardSize)
+		c.sendError("Board size " + size + "x" + size + " is not available.")
+		return
+	}
+	c.QueueMode = msg.Mode
+	c.Assist = msg.Assist
+	c.BoardSize = msg.BoardSize
+	if region := normalizeRegion(msg.Region); region != "" {
+		c.Region = region
+	}
+
 	// Enter matchmaking queue (c.Name already set from JWT)
-	c.Hub.Matchmaker.Enqueue(c)
+	c.enqueue()
+}
+
+// maxRegionLen caps region hints; they are free-form labels chosen by the client.
+const maxRegionLen = 32
+
+// normalizeRegion lowercases and trims a client region hint so equal regions compare equal.
+func normalizeRegion(region string) string {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if len(region) > maxRegionLen {
+		region = region[:maxRegionLen]
+	}
+	return region
+}
+
+// defaultSecondName is the second player's name in a hotseat game when set_name does not give one.
+const defaultSecondName = "Player 2"
+
+// enqueue puts the client in the queue for its QueueMode and confirms with waiting_for_match. A hotseat
+// game has no one to wait for and starts right away.
+func (c *Client) enqueue() {
+	if c.QueueMode == QueueModeHotseat {
+		c.Hub.Matchmaker.StartHotseat(c)
+		return
+	}
+	switch c.QueueMode {
+	case QueueModeRaid:
+		c.Hub.Matchmaker.EnqueueRaid(c)
+	case QueueModeParty:
+		c.Hub.Matchmaker.EnqueueParty(c)
+	default:
+		c.Hub.Matchmaker.Enqueue(c)
+	}
 
 	// Send WaitingForMatch
 	waitMsg := WaitingForMatchMsg{Type: "waiting_for_match"}
@@ -231,6 +465,8 @@ func (c *Client) handleRejoin(raw json.RawMessage) {
 		switch {
 		case errors.Is(err, matcherrors.ErrGameNotFound), errors.Is(err, matcherrors.ErrGameFinished):
 			c.sendError("Game not found or already ended.")
+		case errors.Is(err, matcherrors.ErrGameInterrupted):
+			c.sendError("This game was interrupted by a server restart and cannot be resumed.")
 		case errors.Is(err, matcherrors.ErrInvalidToken):
 			c.sendError("Invalid rejoin token.")
 		case errors.Is(err, matcherrors.ErrNotDisconnected):
@@ -270,8 +506,8 @@ func (c *Client) handleRejoin(raw json.RawMessage) {
 		RejoinToken:    msg.RejoinToken,
 		OpponentName:   opponentName,
 		OpponentUserID: g.PlayerUserIDs[opponentIdx],
-		BoardRows:      c.Hub.Config.BoardRows,
-		BoardCols:      c.Hub.Config.BoardCols,
+		BoardRows:      g.Board.Rows,
+		BoardCols:      g.Board.Cols,
 		YourTurn:       playerIdx == g.CurrentTurn,
 	}
 	matchData, _ := json.Marshal(matchMsg)
@@ -293,6 +529,8 @@ func (c *Client) handleRejoinMyGame() {
 		switch {
 		case errors.Is(err, matcherrors.ErrGameNotFound), errors.Is(err, matcherrors.ErrGameFinished):
 			c.sendError("Game not found or already ended.")
+		case errors.Is(err, matcherrors.ErrGameInterrupted):
+			c.sendError("This game was interrupted by a server restart and cannot be resumed.")
 		case errors.Is(err, matcherrors.ErrNoActiveGame):
 			c.sendError("No active game for this user.")
 		case errors.Is(err, matcherrors.ErrNotDisconnected):
@@ -330,8 +568,8 @@ func (c *Client) handleRejoinMyGame() {
 		RejoinToken:    rejoinToken,
 		OpponentName:   opponentName,
 		OpponentUserID: g.PlayerUserIDs[opponentIdx],
-		BoardRows:      c.Hub.Config.BoardRows,
-		BoardCols:      c.Hub.Config.BoardCols,
+		BoardRows:      g.Board.Rows,
+		BoardCols:      g.Board.Cols,
 		YourTurn:       playerIdx == g.CurrentTurn,
 	}
 	matchData, _ := json.Marshal(matchMsg)
@@ -343,6 +581,7 @@ func (c *Client) handleRejoinMyGame() {
 }
 
 func (c *Client) handleFlipCard(raw json.RawMessage) {
+	received := time.Now()
 	if c.Game == nil {
 		c.sendError("You are not in a game.")
 		return
@@ -355,13 +594,18 @@ func (c *Client) handleFlipCard(raw json.RawMessage) {
 	}
 
 	c.Game.Actions <- game.Action{
-		Type:      game.ActionFlipCard,
-		PlayerIdx: c.PlayerID,
-		Index:     msg.Index,
+		Type:       game.ActionFlipCard,
+		PlayerIdx:  c.PlayerID,
+		MemberIdx:  c.TeamMember,
+		Index:      msg.Index,
+		ReceivedAt: received,
+
+		StateChecksum: msg.StateChecksum,
 	}
 }
 
 func (c *Client) handleUsePowerUp(raw json.RawMessage) {
+	received := time.Now()
 	if c.Game == nil {
 		c.sendError("You are not in a game.")
 		return
@@ -378,10 +622,186 @@ func (c *Client) handleUsePowerUp(raw json.RawMessage) {
 		cardIndex = msg.CardIndex
 	}
 	c.Game.Actions <- game.Action{
-		Type:      game.ActionUsePowerUp,
+		Type:       game.ActionUsePowerUp,
+		PlayerIdx:  c.PlayerID,
+		MemberIdx:  c.TeamMember,
+		PowerUpID:  msg.PowerUpID,
+		CardIndex:  cardIndex,
+		ReceivedAt: received,
+
+		StateChecksum: msg.StateChecksum,
+	}
+}
+
+// handleChat relays a chat line to the client's match. Lines must be 1 to Chat.MaxLength characters once
+// trimmed, and each connection may send at most Chat.MaxMessages per Chat.WindowSec.
+func (c *Client) handleChat(raw json.RawMessage) {
+	received := time.Now()
+	cfg := c.Hub.Config.Chat
+	if cfg.Disabled {
+		c.sendError("Chat is disabled.")
+		return
+	}
+	if c.Game == nil || c.Game.Finished {
+		c.sendError("You are not in a game.")
+		return
+	}
+	if c.Game.Hotseat {
+		c.sendError("Chat is not available in hotseat games.")
+		return
+	}
+
+	var msg ChatMsg
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		c.sendError("Invalid chat message.")
+		return
+	}
+	text := strings.TrimSpace(msg.Text)
+	if n := utf8.RuneCountInString(text); n < 1 || n > cfg.MaxLength {
+		c.sendError("Chat messages must be between 1 and " + strconv.Itoa(cfg.MaxLength) + " characters.")
+		return
+	}
+	if !cfg.Allows(text) {
+		c.sendError("Only the preset phrases and emoji can be sent in this chat.")
+		return
+	}
+	if !c.allowChat(received, cfg.MaxMessages, time.Duration(cfg.WindowSec)*time.Second) {
+		c.sendError("You are sending messages too fast.")
+		return
+	}
+
+	select {
+	case c.Game.Actions <- game.Action{
+		Type:       game.ActionChat,
+		PlayerIdx:  c.PlayerID,
+		MemberIdx:  c.TeamMember,
+		Text:       text,
+		ReceivedAt: received,
+	}:
+	default:
+		c.sendError("Could not send the message. Try again.")
+	}
+}
+
+// allowMessage reports whether a message read at now fits in limit messages per second, and counts it.
+// A limit of 0 does not limit.
+func (c *Client) allowMessage(now time.Time, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	if now.Sub(c.msgWindowStart) >= time.Second {
+		c.msgWindowStart, c.msgCount = now, 0
+	}
+	c.msgCount++
+	return c.msgCount <= limit
+}
+
+// admitAction takes a token for a game action received at now. Without one the action is dropped: the
+// first drop of a run is answered with an error, and ActionBurst drops in a row close the connection.
+func (c *Client) admitAction(now time.Time) bool {
+	cfg := c.Hub.Config
+	if c.allowAction(now, cfg.ActionsPerSec, cfg.ActionBurst) {
+		c.actionsDropped = 0
+		return true
+	}
+	c.actionsDropped++
+	switch {
+	case c.actionsDropped == 1:
+		c.sendError("Too many actions, slow down.")
+	case c.actionsDropped >= max(cfg.ActionBurst, 1):
+		c.rateLimited = true
+		slog.Warn("client over the action rate limit", "tag", "hub", "user_id", c.UserID)
+		c.sendError("Too many actions.")
+		c.Disconnect(CloseRateLimited, "too many actions")
+	}
+	return false
+}
+
+// allowAction reports whether a game action at now fits in the token bucket of burst tokens refilled at
+// rate per second, and takes a token if so. A rate of 0 does not limit; a burst below 1 is one token.
+func (c *Client) allowAction(now time.Time, rate, burst int) bool {
+	if rate <= 0 {
+		return true
+	}
+	capacity := float64(max(burst, 1))
+	if c.actionRefill.IsZero() {
+		c.actionTokens = capacity
+	} else {
+		c.actionTokens = min(capacity, c.actionTokens+now.Sub(c.actionRefill).Seconds()*float64(rate))
+	}
+	c.actionRefill = now
+	if c.actionTokens < 1 {
+		return false
+	}
+	c.actionTokens--
+	return true
+}
+
+// allowChat reports whether a chat line sent at now fits in the rate limit, and counts it if so.
+// A limit or window of 0 does not limit.
+func (c *Client) allowChat(now time.Time, limit int, window time.Duration) bool {
+	if limit <= 0 || window <= 0 {
+		return true
+	}
+	recent := c.chatSent[:0]
+	for _, t := range c.chatSent {
+		if now.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	c.chatSent = recent
+	if len(c.chatSent) >= limit {
+		return false
+	}
+	c.chatSent = append(c.chatSent, now)
+	return true
+}
+
+// handleEmote relays a predefined emote to the opponent; the game enforces the per-turn limit.
+func (c *Client) handleEmote(raw json.RawMessage) {
+	if c.Game == nil || c.Game.Finished {
+		c.sendError("You are not in a game.")
+		return
+	}
+	var msg EmoteMsg
+	if err := json.Unmarshal(raw, &msg); err != nil || !game.IsEmote(msg.EmoteID) {
+		c.sendError("Invalid emote message.")
+		return
+	}
+
+	select {
+	case c.Game.Actions <- game.Action{
+		Type:      game.ActionEmote,
+		PlayerIdx: c.PlayerID,
+		MemberIdx: c.TeamMember,
+		EmoteID:   msg.EmoteID,
+	}:
+	default:
+		c.sendError("Could not send the emote. Try again.")
+	}
+}
+
+// handleDraftPick forwards the player's starting arcana pick; the game checks it against the offer.
+func (c *Client) handleDraftPick(raw json.RawMessage) {
+	if c.Game == nil || c.Game.Finished {
+		c.sendError("You are not in a game.")
+		return
+	}
+	var msg DraftPickMsg
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.PowerUpID == "" {
+		c.sendError("Invalid draft_pick message.")
+		return
+	}
+
+	select {
+	case c.Game.Actions <- game.Action{
+		Type:      game.ActionDraftPick,
 		PlayerIdx: c.PlayerID,
+		MemberIdx: c.TeamMember,
 		PowerUpID: msg.PowerUpID,
-		CardIndex: cardIndex,
+	}:
+	default:
+		c.sendError("Could not send the pick. Try again.")
 	}
 }
 
@@ -394,16 +814,78 @@ func (c *Client) handlePlayAgain() {
 	// Reset game reference
 	c.Game = nil
 	c.PlayerID = 0
+	c.TeamMember = 0
 
-	// Re-enter matchmaking queue
-	c.Hub.Matchmaker.Enqueue(c)
+	// Re-enter the same matchmaking queue
+	c.enqueue()
+}
 
-	// Send WaitingForMatch
-	waitMsg := WaitingForMatchMsg{Type: "waiting_for_match"}
-	data, _ := json.Marshal(waitMsg)
+// handleStatus answers whoami_status with the user's queue entry, game in progress and rematch challenges.
+func (c *Client) handleStatus() {
+	data, _ := json.Marshal(c.Hub.Matchmaker.Status(c))
 	wsutil.SafeSend(c.Send, data)
 }
 
+// handleRematch asks to replay a recorded game (same opponent and board). Requires a signed-in user,
+// since participation is checked against the recorded user IDs.
+func (c *Client) handleRematch(raw json.RawMessage) {
+	if c.Game != nil && !c.Game.Finished {
+		c.sendError("Cannot start a rematch while in an active game.")
+		return
+	}
+	var msg RematchMsg
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.MatchID == "" {
+		c.sendError("Invalid rematch message.")
+		return
+	}
+	if c.UserID == "" {
+		c.sendError("Sign in to play a rematch.")
+		return
+	}
+	c.Game = nil
+	c.PlayerID = 0
+	c.TeamMember = 0
+	if err := c.Hub.Matchmaker.Rematch(c, msg.MatchID); err != nil {
+		switch {
+		case errors.Is(err, matcherrors.ErrGameNotFound):
+			c.sendError("Game not found.")
+		case errors.Is(err, matcherrors.ErrNotAParticipant):
+			c.sendError("You can only rematch games you played.")
+		case errors.Is(err, matcherrors.ErrNoBoardSeed):
+			c.sendError("This game cannot be replayed.")
+		case errors.Is(err, matcherrors.ErrInvalidBoard):
+			c.sendError("This game's board is no longer allowed.")
+		case errors.Is(err, matcherrors.ErrOpponentUnavailable):
+			c.sendError("This opponent is no longer available.")
+		default:
+			slog.Error("rematch failed", "tag", "matchmaking", "match_id", msg.MatchID, "err", err)
+			c.sendError("Could not start the rematch.")
+		}
+	}
+}
+
+// handlePollAnswer records the answer to a post-game poll of the client's last game.
+func (c *Client) handlePollAnswer(raw json.RawMessage) {
+	var msg PollAnswerMsg
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.PollID == "" {
+		c.sendError("Invalid poll_answer message.")
+		return
+	}
+	if err := c.Hub.Matchmaker.AnswerPoll(c, msg.PollID, msg.Answer); err != nil {
+		switch {
+		case errors.Is(err, matcherrors.ErrGameNotFound):
+			c.sendError("Polls can only be answered after a game.")
+		case errors.Is(err, matcherrors.ErrUnknownPoll):
+			c.sendError("This poll is closed.")
+		case errors.Is(err, matcherrors.ErrInvalidPollAnswer):
+			c.sendError("Invalid poll answer.")
+		default:
+			slog.Error("poll answer failed", "tag", "matchmaking", "poll_id", msg.PollID, "err", err)
+			c.sendError("Could not save the poll answer.")
+		}
+	}
+}
+
 func (c *Client) handleLeaveQueue() {
 	if c.Game != nil {
 		c.sendError("Cannot leave queue while in a game.")
@@ -431,18 +913,22 @@ func (c *Client) handleLeaveGame() {
 
 	g := c.Game
 	playerIdx := c.PlayerID
+	memberIdx := c.TeamMember
 	c.Game = nil
 	c.PlayerID = 0
+	c.TeamMember = 0
 
 	select {
 	case g.Actions <- game.Action{
 		Type:      game.ActionDisconnect,
 		PlayerIdx: playerIdx,
+		MemberIdx: memberIdx,
 	}:
 	default:
 		c.sendError("Could not leave game. Try again.")
 		c.Game = g
 		c.PlayerID = playerIdx
+		c.TeamMember = memberIdx
 	}
 }
 
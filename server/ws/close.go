@@ -0,0 +1,106 @@
+package ws
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Close codes of the WebSocket close frame sent when the server ends a connection, so the client can
+// tell the player why and decide whether to reconnect. 1012 is the standard "service restart"; the 4xxx
+// codes are this application's.
+const (
+	// CloseServiceRestart: the server is restarting for maintenance; reconnect after a short delay.
+	CloseServiceRestart = websocket.CloseServiceRestart
+	// CloseAuthFailed: the token was invalid, expired or for another realm; sign in again before reconnecting.
+	CloseAuthFailed = 4001
+	// CloseKicked: an admin disconnected the user; do not reconnect automatically.
+	CloseKicked = 4003
+	// CloseRateLimited: the client sent too many messages; reconnect after backing off.
+	CloseRateLimited = 4029
+)
+
+// hubCloseTimeout bounds how long a disconnect request waits for a hub that may have stopped.
+const hubCloseTimeout = time.Second
+
+// closeFrame is the code and reason a connection is closed with.
+type closeFrame struct {
+	code   int
+	reason string
+}
+
+// hubClose asks the hub to close the connections of userID (every connection when empty); the number
+// closed is sent on done.
+type hubClose struct {
+	userID string
+	frame  closeFrame
+	done   chan int
+}
+
+// Disconnect closes the connection with a close frame carrying code and a short reason, after the
+// messages already queued on Send (e.g. the error that explains it). Safe from any goroutine; later
+// calls and clients without a WebSocket connection (bots, kiosk sessions) are ignored.
+func (c *Client) Disconnect(code int, reason string) {
+	if c.closeReq == nil {
+		return
+	}
+	select {
+	case c.closeReq <- closeFrame{code: code, reason: reason}:
+	default:
+	}
+}
+
+// writeClose writes what is left on Send, then the close frame. Called by WritePump, which then closes
+// the connection; ReadPump ends on the closed connection and unregisters the client.
+func (c *Client) writeClose(frame closeFrame) {
+	for range len(c.Send) {
+		message, ok := <-c.Send
+		if !ok {
+			break
+		}
+		c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			return
+		}
+	}
+	c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+	c.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(frame.code, frame.reason))
+	slog.Info("Client disconnected by server", "tag", "hub", "user_id", c.UserID, "code", frame.code, "reason", frame.reason)
+}
+
+// DisconnectUser closes every connection of userID on this hub with code and reason. Returns how many
+// were closed; 0 when the hub is not running.
+func (h *Hub) DisconnectUser(userID string, code int, reason string) int {
+	if userID == "" {
+		return 0
+	}
+	return h.requestClose(hubClose{userID: userID, frame: closeFrame{code: code, reason: reason}})
+}
+
+// DisconnectAll closes every connection on this hub with code and reason (e.g. before a restart).
+func (h *Hub) DisconnectAll(code int, reason string) int {
+	return h.requestClose(hubClose{frame: closeFrame{code: code, reason: reason}})
+}
+
+func (h *Hub) requestClose(req hubClose) int {
+	req.done = make(chan int, 1)
+	select {
+	case h.closeRequests <- req:
+	case <-time.After(hubCloseTimeout):
+		return 0
+	}
+	return <-req.done
+}
+
+// closeClients handles a hubClose in Run.
+func (h *Hub) closeClients(req hubClose) {
+	n := 0
+	for client := range h.Clients {
+		if req.userID == "" || client.UserID == req.userID {
+			client.Disconnect(req.frame.code, req.frame.reason)
+			n++
+		}
+	}
+	req.done <- n
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"memory-game-server/config"
 	"memory-game-server/game"
+	"memory-game-server/wsutil"
 )
 
 var upgrader = websocket.Upgrader{
@@ -21,6 +23,14 @@ var upgrader = websocket.Upgrader{
 // MatchmakerInterface defines what the Hub needs from the Matchmaker.
 type MatchmakerInterface interface {
 	Enqueue(c *Client)
+	EnqueueRaid(c *Client)
+	EnqueueParty(c *Client)
+	StartHotseat(c *Client)
+	Rematch(c *Client, matchID string) error
+	Challenge(c *Client, targets []*Client) error
+	AnswerChallenge(c *Client, challengeID string, accept bool) error
+	AnswerPoll(c *Client, pollID, answer string) error
+	Status(c *Client) StatusMsg
 	LeaveQueue(c *Client)
 	Rejoin(gameID, rejoinToken, name string) (*game.Game, int, error)
 	RejoinByUser(userID string) (*game.Game, int, string, error)
@@ -32,18 +42,34 @@ type Hub struct {
 	Clients    map[*Client]bool
 	Register   chan *Client
 	Unregister chan *Client
+	// Broadcast delivers a message to every connected client, in or out of a game.
+	Broadcast  chan []byte
 	Matchmaker MatchmakerInterface
 	Config     *config.Config
+	// Realm is the community this hub serves ("" = default). Authenticated users must carry the same realm claim.
+	Realm string
+	// OnAuthenticated is called (in its own goroutine) after a client authenticates, with the name from
+	// its token; used to keep the leaderboard name in sync. Optional.
+	OnAuthenticated func(realm, userID, name string)
+
+	connections atomic.Int64 // len(Clients), readable outside Run
+	// closeRequests carries DisconnectUser and DisconnectAll to Run, which owns Clients.
+	closeRequests chan hubClose
+	// findRequests carries ClientsOf to Run.
+	findRequests chan hubFind
 }
 
 // NewHub creates a new Hub.
 func NewHub(cfg *config.Config, mm MatchmakerInterface) *Hub {
 	return &Hub{
-		Clients:    make(map[*Client]bool),
-		Register:   make(chan *Client),
-		Unregister: make(chan *Client),
-		Matchmaker: mm,
-		Config:     cfg,
+		Clients:       make(map[*Client]bool),
+		Register:      make(chan *Client),
+		Unregister:    make(chan *Client),
+		Broadcast:     make(chan []byte),
+		Matchmaker:    mm,
+		closeRequests: make(chan hubClose),
+		findRequests:  make(chan hubFind),
+		Config:        cfg,
 	}
 }
 
@@ -59,6 +85,11 @@ func (h *Hub) uniqueAuthenticatedUsers() int {
 	return len(seen)
 }
 
+// Connections returns the number of open client connections. Safe to call from any goroutine.
+func (h *Hub) Connections() int {
+	return int(h.connections.Load())
+}
+
 // Run starts the hub's main loop. Should be run as a goroutine.
 // When ctx is cancelled (e.g. on server shutdown), Run returns and no longer accepts new registrations.
 func (h *Hub) Run(ctx context.Context) {
@@ -69,11 +100,13 @@ func (h *Hub) Run(ctx context.Context) {
 			return
 		case client := <-h.Register:
 			h.Clients[client] = true
+			h.connections.Store(int64(len(h.Clients)))
 			slog.Info("Client connected", "tag", "hub", "total_connections", len(h.Clients), "total_users", h.uniqueAuthenticatedUsers())
 
 		case client := <-h.Unregister:
 			if _, ok := h.Clients[client]; ok {
 				delete(h.Clients, client)
+				h.connections.Store(int64(len(h.Clients)))
 				slog.Info("Client disconnected", "tag", "hub", "total_connections", len(h.Clients), "total_users", h.uniqueAuthenticatedUsers())
 
 				// Notify game so it can clear player.Send before we close the channel.
@@ -83,6 +116,7 @@ func (h *Hub) Run(ctx context.Context) {
 					act := game.Action{
 						Type:      game.ActionPlayerDisconnected,
 						PlayerIdx: client.PlayerID,
+						MemberIdx: client.TeamMember,
 					}
 					go func() {
 						client.Game.Actions <- act
@@ -95,6 +129,17 @@ func (h *Hub) Run(ctx context.Context) {
 					close(c.Send)
 				}(client)
 			}
+
+		case data := <-h.Broadcast:
+			for client := range h.Clients {
+				wsutil.SafeSend(client.Send, data)
+			}
+
+		case req := <-h.closeRequests:
+			h.closeClients(req)
+
+		case req := <-h.findRequests:
+			h.findClients(req)
 		}
 	}
 }
@@ -108,9 +153,10 @@ func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := &Client{
-		Hub:  h,
-		Conn: conn,
-		Send: make(chan []byte, 256),
+		Hub:      h,
+		Conn:     conn,
+		Send:     make(chan []byte, 256),
+		closeReq: make(chan closeFrame, 1),
 	}
 
 	h.Register <- client
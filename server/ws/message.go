@@ -1,6 +1,10 @@
 package ws
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"memory-game-server/modes"
+)
 
 // InboundEnvelope is the generic envelope for all client-to-server messages.
 // The Type field is used for routing; Raw holds the full JSON payload.
@@ -30,18 +34,57 @@ func (e *InboundEnvelope) UnmarshalJSON(data []byte) error {
 type AuthMsg struct {
 	Type  string `json:"type"`
 	Token string `json:"token"`
+	// Region is an optional hint of where the client plays from (e.g. "eu-west"); matchmaking prefers
+	// opponents with the same hint. set_name may override it.
+	Region string `json:"region,omitempty"`
 }
 
-// SetNameMsg is sent by the client to declare a display name.
+// QueueModeRaid is the set_name mode for the co-op raid queue (two humans vs one AI).
+const QueueModeRaid = modes.Raid
+
+// QueueModeHotseat is the set_name mode for pass-and-play: two players share this connection and device.
+const QueueModeHotseat = modes.Hotseat
+
+// QueueModeParty is the set_name mode for party games: 3 or 4 humans in one free-for-all match (see
+// SetNameMsg.PartySize). Party games are unrated.
+const QueueModeParty = modes.Party
+
+// QueueModeRanked and QueueModeCasual are the set_name modes for regular matches. Ranked (same as an empty
+// mode) updates ratings; casual games are recorded but never rated, and pair only with other casual players.
+const (
+	QueueModeRanked = modes.Ranked
+	QueueModeCasual = modes.Casual
+)
+
+// SetNameMsg is sent by the client to declare a display name and enter matchmaking.
+// Mode selects the queue: empty or QueueModeRanked for rated matches, QueueModeCasual for unrated ones,
+// QueueModeRaid for co-op raids, QueueModeHotseat for pass-and-play (starts at once, no queue),
+// QueueModeParty for 3-4 player games.
 type SetNameMsg struct {
 	Type string `json:"type"`
 	Name string `json:"name"`
+	Mode string `json:"mode,omitempty"`
+	// Assist opts into assisted accessibility mode: after a period of inactivity on their turn the server
+	// highlights a card (assist_hint). Assisted matches are unrated.
+	Assist bool `json:"assist,omitempty"`
+	// Region is an optional region hint (see AuthMsg.Region); empty keeps the one sent at auth.
+	Region string `json:"region,omitempty"`
+	// SecondName names the second player in hotseat mode (default "Player 2").
+	SecondName string `json:"secondName,omitempty"`
+	// BoardSize requests an n x n board (4, 6 or 8, among the server's BOARD_SIZES); 0 keeps the default
+	// board. Players are only paired with others requesting the same size. Ignored for raids.
+	BoardSize int `json:"boardSize,omitempty"`
+	// PartySize is how many players a party game should seat (the party mode's MinPlayers to MaxPlayers;
+	// 0 = its DefaultPlayers). Players only start a party with others asking for the same size. Party mode only.
+	PartySize int `json:"partySize,omitempty"`
 }
 
 // FlipCardMsg is sent by the client to flip a card.
 type FlipCardMsg struct {
 	Type  string `json:"type"`
 	Index int    `json:"index"`
+	// StateChecksum echoes the stateChecksum of the last game_state the client applied (optional).
+	StateChecksum string `json:"stateChecksum,omitempty"`
 }
 
 // UsePowerUpMsg is sent by the client to activate a power-up.
@@ -50,6 +93,8 @@ type UsePowerUpMsg struct {
 	Type      string `json:"type"`
 	PowerUpID string `json:"powerUpId"`
 	CardIndex int    `json:"cardIndex,omitempty"` // -1 when not used
+	// StateChecksum echoes the stateChecksum of the last game_state the client applied (optional).
+	StateChecksum string `json:"stateChecksum,omitempty"`
 }
 
 // PlayAgainMsg is sent by the client to re-enter matchmaking.
@@ -65,6 +110,52 @@ type RejoinMsg struct {
 	Name        string `json:"name"`
 }
 
+// ChallengeUserMsg invites a specific online user to a game, on the requested board size (0 = default).
+type ChallengeUserMsg struct {
+	Type      string `json:"type"`
+	UserID    string `json:"userId"`
+	BoardSize int    `json:"boardSize,omitempty"`
+}
+
+// ChallengeAnswerMsg accepts or declines a challenge_received.
+type ChallengeAnswerMsg struct {
+	Type        string `json:"type"`
+	ChallengeID string `json:"challengeId"`
+	Accept      bool   `json:"accept"`
+}
+
+// RematchMsg asks to play a recorded game again: against the same AI profile right away, or against the
+// same human once they send rematch for the same game too.
+type RematchMsg struct {
+	Type    string `json:"type"`
+	MatchID string `json:"matchId"`
+}
+
+// ChatMsg is sent by the client to say something to everyone in its match.
+type ChatMsg struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// EmoteMsg is sent by the client to react to its opponent with one of the predefined emotes.
+type EmoteMsg struct {
+	Type    string `json:"type"`
+	EmoteID string `json:"emoteId"`
+}
+
+// DraftPickMsg is sent by the client to pick its starting arcana from the draft_offer it received.
+type DraftPickMsg struct {
+	Type      string `json:"type"`
+	PowerUpID string `json:"powerUpId"`
+}
+
+// PollAnswerMsg is sent by the client to answer a poll received after game_over.
+type PollAnswerMsg struct {
+	Type   string `json:"type"`
+	PollID string `json:"pollId"`
+	Answer string `json:"answer"`
+}
+
 // --- Server-to-Client messages ---
 
 // ErrorMsg is sent when a client action is invalid.
@@ -78,6 +169,105 @@ type WaitingForMatchMsg struct {
 	Type string `json:"type"`
 }
 
+// QueueStatusMsg tells a player in the ranked or casual queue how long they wait for a human before being
+// offered a bot (AIWaitMs, counted from joining the queue), and the load it was chosen from. Sent once the
+// server starts waiting for an opponent for them, when the wait adapts to the load (AIPairTimeoutMaxSec).
+type QueueStatusMsg struct {
+	Type          string `json:"type"`
+	AIWaitMs      int64  `json:"aiWaitMs"`
+	PlayersOnline int    `json:"playersOnline,omitempty"` // connected players; 0 when unknown
+	PlayersQueued int    `json:"playersQueued"`           // other players waiting in the queue
+}
+
+// StatusMsg answers whoami_status with everything the user has pending on the server, so a (re)connecting
+// client can restore its screen with one request.
+type StatusMsg struct {
+	Type      string `json:"type"`
+	InQueue   bool   `json:"inQueue"`
+	QueueMode string `json:"queueMode,omitempty"` // queue entered, when InQueue ("" = ranked matchmaking)
+	// ActiveGame is the game in progress for this user; nil when there is none.
+	ActiveGame        *ActiveGameStatus        `json:"activeGame,omitempty"`
+	RematchChallenges []RematchChallengeStatus `json:"rematchChallenges"`
+	// Challenges are direct challenges (challenge_user) sent by this user or waiting for their answer.
+	Challenges []ChallengeStatus `json:"challenges"`
+	// ChatPhrases is set when chat is restricted to preset phrases and emoji: the phrases to offer.
+	ChatPhrases []string `json:"chatPhrases,omitempty"`
+}
+
+// ActiveGameStatus describes the user's game in progress. Rejoinable is true when this user's seat is
+// disconnected and rejoin_my_game would restore it; Interrupted when the game was lost with a server
+// restart (no other field is set then).
+type ActiveGameStatus struct {
+	GameID       string `json:"gameId,omitempty"`
+	OpponentName string `json:"opponentName,omitempty"`
+	Rejoinable   bool   `json:"rejoinable"`
+	Interrupted  bool   `json:"interrupted,omitempty"`
+}
+
+// RematchChallengeStatus is a rematch challenge waiting for an answer: sent by this user, or received
+// (Incoming) from OpponentName, who waits for a rematch message with MatchID.
+type RematchChallengeStatus struct {
+	MatchID      string `json:"matchId"`
+	OpponentName string `json:"opponentName"`
+	Incoming     bool   `json:"incoming"`
+}
+
+// ChallengeStatus is a direct challenge waiting for an answer: sent by this user, or received by them.
+type ChallengeStatus struct {
+	ChallengeID  string `json:"challengeId"`
+	OpponentName string `json:"opponentName"`
+	Incoming     bool   `json:"incoming"`
+}
+
+// Reasons a direct challenge closes without a game, in ChallengeClosedMsg.
+const (
+	ChallengeDeclined  = "declined"
+	ChallengeExpired   = "expired"
+	ChallengeCancelled = "cancelled"
+	// ChallengeUnavailable: one of the players started another game before the challenge was accepted.
+	ChallengeUnavailable = "unavailable"
+	// ChallengeAnswered: accepted from another connection of the challenged user.
+	ChallengeAnswered = "answered"
+)
+
+// ChallengeSentMsg confirms a challenge_user: the invite was delivered to the user's connections.
+type ChallengeSentMsg struct {
+	Type         string `json:"type"` // "challenge_sent"
+	ChallengeID  string `json:"challengeId"`
+	OpponentName string `json:"opponentName"`
+	ExpiresInMS  int64  `json:"expiresInMs"`
+}
+
+// ChallengeReceivedMsg invites the user to a game; answer with challenge_answer.
+type ChallengeReceivedMsg struct {
+	Type        string `json:"type"` // "challenge_received"
+	ChallengeID string `json:"challengeId"`
+	FromName    string `json:"fromName"`
+	FromUserID  string `json:"fromUserId"`
+	BoardSize   int    `json:"boardSize,omitempty"`
+	ExpiresInMS int64  `json:"expiresInMs"`
+}
+
+// ChallengeClosedMsg tells both sides a challenge ended without a game (see the Challenge* reasons).
+type ChallengeClosedMsg struct {
+	Type        string `json:"type"` // "challenge_closed"
+	ChallengeID string `json:"challengeId"`
+	Reason      string `json:"reason"`
+}
+
+// WaitingForRematchMsg confirms a rematch challenge: the server waits for the other player of MatchID.
+type WaitingForRematchMsg struct {
+	Type         string `json:"type"`
+	MatchID      string `json:"matchId"`
+	OpponentName string `json:"opponentName"`
+}
+
+// RematchExpiredMsg is sent when the other player did not accept a rematch challenge in time.
+type RematchExpiredMsg struct {
+	Type    string `json:"type"`
+	MatchID string `json:"matchId"`
+}
+
 // MatchFoundMsg is sent when two players are paired.
 type MatchFoundMsg struct {
 	Type           string `json:"type"`
@@ -88,7 +278,37 @@ type MatchFoundMsg struct {
 	BoardRows      int    `json:"boardRows"`
 	BoardCols      int    `json:"boardCols"`
 	YourTurn       bool   `json:"yourTurn"`
+	// OpponentAvatar is set when the opponent is a bot playing under an identity with an avatar.
+	OpponentAvatar string `json:"opponentAvatar,omitempty"`
 	// YourElo and OpponentElo are current ratings when available (from leaderboard).
 	YourElo     *int `json:"your_elo,omitempty"`
 	OpponentElo *int `json:"opponent_elo,omitempty"`
+	// Raid is set for co-op raids: the team roster and the receiver's position in the move rotation.
+	Raid *RaidInfo `json:"raid,omitempty"`
+	// RevealDurationMS is how long a mismatched pair stays face up in this match (also in every game_state).
+	RevealDurationMS int `json:"revealDurationMs,omitempty"`
+	// MismatchRetries is the rules variant of this match: extra tries after a mismatch before the turn passes (0 = classic).
+	MismatchRetries int `json:"mismatchRetries,omitempty"`
+	// Hotseat is set for pass-and-play games: this connection plays both seats, and opponentName is the second player.
+	Hotseat bool `json:"hotseat,omitempty"`
+	// RematchOf is the recorded game this one replays (same opponent and board), for rematches from history.
+	RematchOf string `json:"rematchOf,omitempty"`
+	// Casual is set for games from the casual queue, which do not change ratings.
+	Casual bool `json:"casual,omitempty"`
+	// Party is set for party games: every seat's player, in seat (turn) order. OpponentName lists the others.
+	Party *PartyInfo `json:"party,omitempty"`
+	// Mode is the game mode ID (see GET /api/modes); empty for kiosk games.
+	Mode string `json:"mode,omitempty"`
+}
+
+// PartyInfo describes the seats of a party game.
+type PartyInfo struct {
+	Players  []string `json:"players"`
+	YourSeat int      `json:"yourSeat"`
+}
+
+// RaidInfo describes the receiver's team in a co-op raid.
+type RaidInfo struct {
+	Members       []string `json:"members"`
+	YourMemberIdx int      `json:"yourMemberIdx"`
 }
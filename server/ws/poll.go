@@ -0,0 +1,298 @@
+package ws
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	pollDefaultWait = 25 * time.Second
+	pollMaxWait     = 30 * time.Second
+)
+
+// PollBridge lets devices that cannot hold a WebSocket (kiosks, embedded clients) play over plain HTTP
+// long-polling. Each session is a Client without a connection: requests are fed through the same
+// handleMessage path as WebSocket frames, and outbound messages wait in the client's Send buffer until
+// the next poll. Sessions without a request for IdleTimeout are dropped like a closed connection. Open
+// sessions are capped in total and per remote address (PollMaxSessions, PollMaxSessionsPerAddr), and
+// each session is held to the same message and action rate limits as a WebSocket connection.
+type PollBridge struct {
+	Hub         *Hub
+	IdleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*pollSession
+}
+
+type pollSession struct {
+	client   *Client
+	addr     string     // remote host that created the session
+	handleMu sync.Mutex // serializes handleMessage, as ReadPump does for WebSocket clients
+	lastSeen time.Time  // guarded by PollBridge.mu
+}
+
+// NewPollBridge creates a bridge that registers its sessions with hub.
+func NewPollBridge(hub *Hub, idleTimeout time.Duration) *PollBridge {
+	return &PollBridge{
+		Hub:         hub,
+		IdleTimeout: idleTimeout,
+		sessions:    make(map[string]*pollSession),
+	}
+}
+
+// Run drops idle sessions until ctx is cancelled. Should be run as a goroutine.
+func (b *PollBridge) Run(ctx context.Context) {
+	interval := b.IdleTimeout / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.reapIdle()
+		}
+	}
+}
+
+func (b *PollBridge) reapIdle() {
+	b.mu.Lock()
+	var idle []*pollSession
+	for id, s := range b.sessions {
+		if time.Since(s.lastSeen) > b.IdleTimeout {
+			idle = append(idle, s)
+			delete(b.sessions, id)
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range idle {
+		slog.Info("poll session idle, dropping", "tag", "hub", "name", s.client.Name)
+		s.client.leave()
+	}
+}
+
+// CreateSession handles POST /api/kiosk/sessions: creates a guest session and returns its ID, or 429 when
+// the session caps are reached. The session follows the WebSocket rules: send auth first when the server
+// requires it.
+func (b *PollBridge) CreateSession(w http.ResponseWriter, r *http.Request) {
+	raw := make([]byte, 16)
+	if _, err := crand.Read(raw); err != nil {
+		http.Error(w, "failed to create session", http.StatusInternalServerError)
+		return
+	}
+	id := hex.EncodeToString(raw)
+	addr := remoteHost(r)
+	client := &Client{
+		Hub:  b.Hub,
+		Send: make(chan []byte, 256),
+	}
+
+	b.mu.Lock()
+	if refused := b.refuseLocked(addr); refused != "" {
+		b.mu.Unlock()
+		slog.Warn("poll session refused", "tag", "hub", "addr", addr, "reason", refused)
+		http.Error(w, refused, http.StatusTooManyRequests)
+		return
+	}
+	b.sessions[id] = &pollSession{client: client, addr: addr, lastSeen: time.Now()}
+	b.mu.Unlock()
+	b.Hub.Register <- client
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"session_id": id})
+}
+
+// refuseLocked returns why a new session from addr is refused, or "" when it fits under the caps. Caller
+// holds mu.
+func (b *PollBridge) refuseLocked(addr string) string {
+	cfg := b.Hub.Config
+	if cfg.PollMaxSessions > 0 && len(b.sessions) >= cfg.PollMaxSessions {
+		return "too many sessions"
+	}
+	if cfg.PollMaxSessionsPerAddr > 0 {
+		n := 0
+		for _, s := range b.sessions {
+			if s.addr == addr {
+				n++
+			}
+		}
+		if n >= cfg.PollMaxSessionsPerAddr {
+			return "too many sessions from this address"
+		}
+	}
+	return ""
+}
+
+// remoteHost returns the host part of the request's remote address.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// CloseSession handles DELETE /api/kiosk/sessions/{id}: leaves like a closed WebSocket.
+func (b *PollBridge) CloseSession(w http.ResponseWriter, r *http.Request) {
+	if !b.drop(r.PathValue("id")) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// drop removes the session and leaves like a closed connection. It reports whether there was one.
+func (b *PollBridge) drop(id string) bool {
+	b.mu.Lock()
+	s, ok := b.sessions[id]
+	delete(b.sessions, id)
+	b.mu.Unlock()
+	if ok {
+		s.client.leave()
+	}
+	return ok
+}
+
+// Join handles POST /api/kiosk/sessions/{id}/join with {"name", "mode", "assist"}: same as set_name.
+func (b *PollBridge) Join(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name   string `json:"name"`
+		Mode   string `json:"mode"`
+		Assist bool   `json:"assist"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	data, _ := json.Marshal(SetNameMsg{Type: "set_name", Name: body.Name, Mode: body.Mode, Assist: body.Assist})
+	b.deliver(w, r, data)
+}
+
+// Flip handles POST /api/kiosk/sessions/{id}/flip with {"index"}: same as flip_card.
+func (b *PollBridge) Flip(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Index *int `json:"index"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Index == nil {
+		http.Error(w, "index required", http.StatusBadRequest)
+		return
+	}
+	data, _ := json.Marshal(FlipCardMsg{Type: "flip_card", Index: *body.Index})
+	b.deliver(w, r, data)
+}
+
+// Message handles POST /api/kiosk/sessions/{id}/messages with any client-to-server WebSocket message
+// (auth, use_power_up, board_ready, play_again, ...).
+func (b *PollBridge) Message(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return
+	}
+	b.deliver(w, r, body)
+}
+
+// deliver hands data to the session's client. Replies (including errors) arrive as events. The request
+// counts against MaxMessagesPerSec and, for game actions, the action bucket (see admitAction). A session
+// over either limit is dropped, as the WebSocket would be closed, and the request gets 429.
+func (b *PollBridge) deliver(w http.ResponseWriter, r *http.Request, data []byte) {
+	id := r.PathValue("id")
+	s := b.session(id)
+	if s == nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	s.handleMu.Lock()
+	c := s.client
+	if !c.rateLimited && !c.allowMessage(time.Now(), b.Hub.Config.MaxMessagesPerSec) {
+		c.rateLimited = true
+		slog.Warn("poll session over the message rate limit", "tag", "hub", "user_id", c.UserID)
+	}
+	if !c.rateLimited {
+		c.handleMessage(data)
+	}
+	limited := c.rateLimited
+	s.handleMu.Unlock()
+	if limited {
+		b.drop(id)
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Events handles GET /api/kiosk/sessions/{id}/events?wait=<sec>: waits up to wait seconds (default 25,
+// max 30) for at least one server message, then returns everything buffered as {"events": [...]}.
+func (b *PollBridge) Events(w http.ResponseWriter, r *http.Request) {
+	s := b.session(r.PathValue("id"))
+	if s == nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	wait := pollDefaultWait
+	if v := r.URL.Query().Get("wait"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			wait = min(time.Duration(n)*time.Second, pollMaxWait)
+		}
+	}
+
+	events := []json.RawMessage{}
+	closed := false
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case msg, ok := <-s.client.Send:
+		if ok {
+			events = append(events, msg)
+		} else {
+			closed = true
+		}
+	case <-timer.C:
+	case <-r.Context().Done():
+		return
+	}
+drain:
+	for !closed {
+		select {
+		case msg, ok := <-s.client.Send:
+			if !ok {
+				closed = true
+				break drain
+			}
+			events = append(events, msg)
+		default:
+			break drain
+		}
+	}
+	b.session(r.PathValue("id")) // a long poll counts as activity when it returns, too
+	if closed && len(events) == 0 {
+		http.Error(w, "session closed", http.StatusGone)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"events": events})
+}
+
+// session returns the live session for id and marks it active, or nil.
+func (b *PollBridge) session(id string) *pollSession {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.sessions[id]
+	if !ok {
+		return nil
+	}
+	s.lastSeen = time.Now()
+	return s
+}